@@ -0,0 +1,925 @@
+package canvas
+
+import (
+	"fmt"
+	"strings"
+)
+
+// modulesToPath returns the path of the dark modules in the matrix, indexed by row and column with row zero at the top, where each module is a square of the given size. The modules are offset by the quiet zone in modules, so that the symbol including its quiet zone starts at the origin. Horizontally adjacent dark modules are merged into a single rectangle.
+func modulesToPath(modules [][]bool, size float64, quietZone int) *Path {
+	p := &Path{}
+	n := len(modules)
+	for r, row := range modules {
+		y := float64(quietZone+n-1-r) * size
+		for c := 0; c < len(row); {
+			if !row[c] {
+				c++
+				continue
+			}
+			c0 := c
+			for c < len(row) && row[c] {
+				c++
+			}
+			x0, x1 := float64(quietZone+c0)*size, float64(quietZone+c)*size
+			p.MoveTo(x0, y)
+			p.LineTo(x1, y)
+			p.LineTo(x1, y+size)
+			p.LineTo(x0, y+size)
+			p.Close()
+		}
+	}
+	return p
+}
+
+// barsToPath returns the path of a one-dimensional barcode given as the alternating widths of bars and spaces in modules, starting with a bar.
+func barsToPath(widths []int, size, height float64, quietZone int) *Path {
+	p := &Path{}
+	x := quietZone
+	for i, w := range widths {
+		if i%2 == 0 {
+			x0, x1 := float64(x)*size, float64(x+w)*size
+			p.MoveTo(x0, 0.0)
+			p.LineTo(x1, 0.0)
+			p.LineTo(x1, height)
+			p.LineTo(x0, height)
+			p.Close()
+		}
+		x += w
+	}
+	return p
+}
+
+// galoisField is a finite field GF(256) with the given primitive polynomial, used for Reed-Solomon error correction.
+type galoisField struct {
+	exp [512]byte
+	log [256]byte
+}
+
+func newGaloisField(poly int) *galoisField {
+	gf := &galoisField{}
+	x := 1
+	for i := 0; i < 255; i++ {
+		gf.exp[i] = byte(x)
+		gf.log[x] = byte(i)
+		x <<= 1
+		if 0x100 <= x {
+			x ^= poly
+		}
+	}
+	for i := 255; i < 512; i++ {
+		gf.exp[i] = gf.exp[i-255]
+	}
+	return gf
+}
+
+func (gf *galoisField) mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gf.exp[int(gf.log[a])+int(gf.log[b])]
+}
+
+// generator returns the coefficients of the Reed-Solomon generator polynomial of the given degree with roots α^start, ..., α^(start+degree-1), from highest to lowest degree excluding the leading one.
+func (gf *galoisField) generator(degree, start int) []byte {
+	g := make([]byte, degree+1)
+	g[0] = 1
+	for i := 0; i < degree; i++ {
+		root := gf.exp[start+i]
+		for j := i + 1; 0 < j; j-- {
+			g[j] = g[j-1] ^ gf.mul(g[j], root)
+		}
+		g[0] = gf.mul(g[0], root)
+	}
+	// reverse to highest degree first and drop the leading coefficient
+	rs := make([]byte, degree)
+	for i := 0; i < degree; i++ {
+		rs[i] = g[degree-1-i]
+	}
+	return rs
+}
+
+// remainder returns the Reed-Solomon error correction codewords of data for the generator polynomial.
+func (gf *galoisField) remainder(data, generator []byte) []byte {
+	rem := make([]byte, len(generator))
+	for _, b := range data {
+		factor := b ^ rem[0]
+		copy(rem, rem[1:])
+		rem[len(rem)-1] = 0
+		for i, g := range generator {
+			rem[i] ^= gf.mul(g, factor)
+		}
+	}
+	return rem
+}
+
+var (
+	qrField         = newGaloisField(0x11D)
+	dataMatrixField = newGaloisField(0x12D)
+)
+
+////////////////////////////////////////////////////////////////
+
+// QRErrorCorrection is the error correction level of a QR code, which is the fraction of the codewords that can be restored: about 7% for QRLow, 15% for QRMedium, 25% for QRQuartile, and 30% for QRHigh.
+type QRErrorCorrection int
+
+// see QRErrorCorrection
+const (
+	QRLow QRErrorCorrection = iota
+	QRMedium
+	QRQuartile
+	QRHigh
+)
+
+// qrECCPerBlock and qrBlocks are the number of error correction codewords per block and the number of blocks for each error correction level and version.
+var qrECCPerBlock = [4][41]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+var qrBlocks = [4][41]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// qrFormatLevel is the value of the error correction level in the format information.
+var qrFormatLevel = [4]int{1, 0, 3, 2}
+
+const qrAlphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// qrRawModules returns the number of modules available for data and error correction codewords, including remainder bits.
+func qrRawModules(version int) int {
+	n := (16*version+128)*version + 64
+	if 2 <= version {
+		align := version/7 + 2
+		n -= (25*align-10)*align - 55
+		if 7 <= version {
+			n -= 36
+		}
+	}
+	return n
+}
+
+// qrDataCodewords returns the number of data codewords for the version and error correction level.
+func qrDataCodewords(version int, level QRErrorCorrection) int {
+	return qrRawModules(version)/8 - qrECCPerBlock[level][version]*qrBlocks[level][version]
+}
+
+// qrAlignmentPositions returns the row and column positions of the alignment patterns.
+func qrAlignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	n := version/7 + 2
+	step := (version*4 + n*2 + 1) / (n*2 - 2) * 2
+	if version == 32 {
+		step = 26
+	}
+	positions := make([]int, n)
+	positions[0] = 6
+	for i, pos := n-1, version*4+10; 0 < i; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+type bitBuffer struct {
+	bits []bool
+}
+
+func (b *bitBuffer) append(value, n int) {
+	for i := n - 1; 0 <= i; i-- {
+		b.bits = append(b.bits, (value>>i)&1 == 1)
+	}
+}
+
+// qrEncode returns the data bits, and the mode indicator followed by the number of character count bits for versions 1–9, 10–26 and 27–40.
+func qrEncode(data string) (*bitBuffer, [4]int) {
+	numeric, alphanumeric := true, true
+	for i := 0; i < len(data); i++ {
+		if data[i] < '0' || '9' < data[i] {
+			numeric = false
+		}
+		if strings.IndexByte(qrAlphanumeric, data[i]) == -1 {
+			alphanumeric = false
+		}
+	}
+
+	b := &bitBuffer{}
+	if numeric {
+		for i := 0; i < len(data); i += 3 {
+			n := min(3, len(data)-i)
+			v := 0
+			for _, c := range data[i : i+n] {
+				v = v*10 + int(c-'0')
+			}
+			b.append(v, 3*n+1)
+		}
+		return b, [4]int{0x1, 10, 12, 14}
+	} else if alphanumeric {
+		for i := 0; i < len(data); i += 2 {
+			v := strings.IndexByte(qrAlphanumeric, data[i])
+			if i+1 < len(data) {
+				b.append(v*45+strings.IndexByte(qrAlphanumeric, data[i+1]), 11)
+			} else {
+				b.append(v, 6)
+			}
+		}
+		return b, [4]int{0x2, 9, 11, 13}
+	}
+	for i := 0; i < len(data); i++ {
+		b.append(int(data[i]), 8)
+	}
+	return b, [4]int{0x4, 8, 16, 16}
+}
+
+// QRCode returns a QR code (ISO/IEC 18004) of data as a path of square modules of the given size, placed so that the symbol including a quiet zone of the given number of modules (four by specification) starts at the origin. The data is encoded in numeric, alphanumeric or byte mode, whichever is the most compact for the whole string, in the smallest version that fits at the given error correction level.
+func QRCode(data string, level QRErrorCorrection, size float64, quietZone int) (*Path, error) {
+	modules, err := qrCodeModules(data, level)
+	if err != nil {
+		return nil, err
+	}
+	return modulesToPath(modules, size, quietZone), nil
+}
+
+func qrCodeModules(data string, level QRErrorCorrection) ([][]bool, error) {
+	if level < QRLow || QRHigh < level {
+		return nil, fmt.Errorf("invalid QR code error correction level")
+	}
+	segment, mode := qrEncode(data)
+	version, numChars := 1, len(data)
+	for ; ; version++ {
+		if 40 < version {
+			return nil, fmt.Errorf("data too long for QR code")
+		}
+		countBits := mode[1]
+		if 27 <= version {
+			countBits = mode[3]
+		} else if 10 <= version {
+			countBits = mode[2]
+		}
+		if numChars < 1<<countBits && 4+countBits+len(segment.bits) <= 8*qrDataCodewords(version, level) {
+			b := &bitBuffer{}
+			b.append(mode[0], 4)
+			b.append(numChars, countBits)
+			b.bits = append(b.bits, segment.bits...)
+			return qrSymbol(b, version, level), nil
+		}
+	}
+}
+
+// qrSymbol returns the modules of the QR code of version and error correction level containing the data bits.
+func qrSymbol(b *bitBuffer, version int, level QRErrorCorrection) [][]bool {
+	// terminator and padding
+	capacity := 8 * qrDataCodewords(version, level)
+	b.append(0, min(4, capacity-len(b.bits)))
+	b.append(0, (8-len(b.bits)%8)%8)
+	for pad := 0xEC; len(b.bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		b.append(pad, 8)
+	}
+	data := make([]byte, len(b.bits)/8)
+	for i, bit := range b.bits {
+		if bit {
+			data[i/8] |= 1 << (7 - i%8)
+		}
+	}
+
+	// split into blocks, add error correction codewords, and interleave
+	numBlocks, eccLen := qrBlocks[level][version], qrECCPerBlock[level][version]
+	rawCodewords := qrRawModules(version) / 8
+	numShort := numBlocks - rawCodewords%numBlocks
+	shortLen := rawCodewords / numBlocks
+	generator := qrField.generator(eccLen, 0)
+	blocks := make([][]byte, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		n := shortLen - eccLen
+		if numShort <= i {
+			n++
+		}
+		block := append([]byte{}, data[k:k+n]...)
+		k += n
+		ecc := qrField.remainder(block, generator)
+		if i < numShort {
+			block = append(block, 0) // dummy for interleaving
+		}
+		blocks[i] = append(block, ecc...)
+	}
+	codewords := make([]byte, 0, rawCodewords)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			if i != shortLen-eccLen || numShort <= j {
+				codewords = append(codewords, block[i])
+			}
+		}
+	}
+
+	// function patterns
+	n := 4*version + 17
+	modules := make([][]bool, n)
+	function := make([][]bool, n)
+	for i := range modules {
+		modules[i] = make([]bool, n)
+		function[i] = make([]bool, n)
+	}
+	set := func(x, y int, dark bool) {
+		modules[y][x] = dark
+		function[y][x] = true
+	}
+	for i := 0; i < n; i++ {
+		set(6, i, i%2 == 0)
+		set(i, 6, i%2 == 0)
+	}
+	for _, c := range [][2]int{{3, 3}, {n - 4, 3}, {3, n - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if 0 <= x && x < n && 0 <= y && y < n {
+					dist := max(abs(dx), abs(dy))
+					set(x, y, dist != 2 && dist != 4)
+				}
+			}
+		}
+	}
+	align := qrAlignmentPositions(version)
+	for i, ay := range align {
+		for j, ax := range align {
+			if i == 0 && j == 0 || i == 0 && j == len(align)-1 || i == len(align)-1 && j == 0 {
+				continue // finder patterns
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					set(ax+dx, ay+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+	qrFormat(set, n, level, 0) // reserve format information
+	if 7 <= version {
+		rem := version
+		for i := 0; i < 12; i++ {
+			rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+		}
+		bits := version<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := (bits>>i)&1 == 1
+			a, b := n-11+i%3, i/3
+			set(a, b, dark)
+			set(b, a, dark)
+		}
+	}
+
+	// codewords in a zigzag from the bottom-right
+	i := 0
+	for right := n - 1; 1 <= right; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < n; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = n - 1 - vert
+				}
+				if !function[y][x] && i < 8*len(codewords) {
+					modules[y][x] = (codewords[i/8]>>(7-i%8))&1 == 1
+					i++
+				}
+			}
+		}
+	}
+
+	// apply the mask with the lowest penalty
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		qrMask(modules, function, mask)
+		qrFormat(set, n, level, mask)
+		if penalty := qrPenalty(modules); bestPenalty == -1 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		qrMask(modules, function, mask) // undo
+	}
+	qrMask(modules, function, best)
+	qrFormat(set, n, level, best)
+	return modules
+}
+
+func abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// qrFormatBits returns the 15 format information bits for the error correction level and mask.
+func qrFormatBits(level QRErrorCorrection, mask int) int {
+	data := qrFormatLevel[level]<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+func qrFormat(set func(int, int, bool), n int, level QRErrorCorrection, mask int) {
+	bits := qrFormatBits(level, mask)
+	bit := func(i int) bool {
+		return (bits>>i)&1 == 1
+	}
+	for i := 0; i < 6; i++ {
+		set(8, i, bit(i))
+	}
+	set(8, 7, bit(6))
+	set(8, 8, bit(7))
+	set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		set(n-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		set(8, n-15+i, bit(i))
+	}
+	set(8, n-8, true) // dark module
+}
+
+func qrMask(modules, function [][]bool, mask int) {
+	for y, row := range modules {
+		for x := range row {
+			if function[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				row[x] = !row[x]
+			}
+		}
+	}
+}
+
+// qrPenalty returns the penalty score of the symbol, used to select the mask.
+func qrPenalty(modules [][]bool) int {
+	n := len(modules)
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			return modules[x][y]
+		}
+		return modules[y][x]
+	}
+
+	penalty, dark := 0, 0
+	for _, transpose := range []bool{false, true} {
+		for y := 0; y < n; y++ {
+			// runs of five or more modules of the same color
+			run := 1
+			for x := 1; x <= n; x++ {
+				if x < n && at(x, y, transpose) == at(x-1, y, transpose) {
+					run++
+					continue
+				}
+				if 5 <= run {
+					penalty += 3 + run - 5
+				}
+				run = 1
+			}
+
+			// finder-like patterns 1:1:3:1:1 with four light modules on either side
+			for x := -4; x < n; x++ {
+				match := func(pattern string) bool {
+					for k := 0; k < len(pattern); k++ {
+						xx := x + k
+						if (xx < 0 || n <= xx || !at(xx, y, transpose)) != (pattern[k] == '0') {
+							return false
+						}
+					}
+					return true
+				}
+				if match("10111010000") || match("00001011101") {
+					penalty += 40
+				}
+			}
+		}
+	}
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if modules[y][x] {
+				dark++
+			}
+			if x+1 < n && y+1 < n {
+				c := modules[y][x]
+				if modules[y][x+1] == c && modules[y+1][x] == c && modules[y+1][x+1] == c {
+					penalty += 3
+				}
+			}
+		}
+	}
+	total := n * n
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return penalty + 10*k
+}
+
+////////////////////////////////////////////////////////////////
+
+// dataMatrixSizes are the square ECC 200 symbol sizes with their data region size, number of data and error correction codewords, and number of interleaved blocks.
+var dataMatrixSizes = []struct {
+	size, region, data, ecc, blocks int
+}{
+	{10, 8, 3, 5, 1},
+	{12, 10, 5, 7, 1},
+	{14, 12, 8, 10, 1},
+	{16, 14, 12, 12, 1},
+	{18, 16, 18, 14, 1},
+	{20, 18, 22, 18, 1},
+	{22, 20, 30, 20, 1},
+	{24, 22, 36, 24, 1},
+	{26, 24, 44, 28, 1},
+	{32, 14, 62, 36, 1},
+	{36, 16, 86, 42, 1},
+	{40, 18, 114, 48, 1},
+	{44, 20, 144, 56, 1},
+	{48, 22, 174, 68, 1},
+	{52, 24, 204, 84, 2},
+	{64, 14, 280, 112, 2},
+	{72, 16, 368, 144, 4},
+	{80, 18, 456, 192, 4},
+	{88, 20, 576, 224, 4},
+	{96, 22, 696, 272, 4},
+	{104, 24, 816, 336, 6},
+	{120, 18, 1050, 408, 6},
+	{132, 20, 1304, 496, 8},
+	{144, 22, 1558, 620, 10},
+}
+
+// dataMatrixEncode returns the codewords of data in ASCII encodation, with digit pairs encoded in a single codeword.
+func dataMatrixEncode(data string) []byte {
+	codewords := []byte{}
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if '0' <= c && c <= '9' && i+1 < len(data) && '0' <= data[i+1] && data[i+1] <= '9' {
+			codewords = append(codewords, 130+(c-'0')*10+(data[i+1]-'0'))
+			i++
+		} else if c < 128 {
+			codewords = append(codewords, c+1)
+		} else {
+			codewords = append(codewords, 235, c-127) // upper shift
+		}
+	}
+	return codewords
+}
+
+// DataMatrix returns a square Data Matrix ECC 200 code (ISO/IEC 16022) of data as a path of square modules of the given size, placed so that the symbol including a quiet zone of the given number of modules (one by specification) starts at the origin. The data is encoded in ASCII encodation in the smallest symbol that fits.
+func DataMatrix(data string, size float64, quietZone int) (*Path, error) {
+	modules, err := dataMatrixModules(data)
+	if err != nil {
+		return nil, err
+	}
+	return modulesToPath(modules, size, quietZone), nil
+}
+
+func dataMatrixModules(data string) ([][]bool, error) {
+	codewords := dataMatrixEncode(data)
+	k := 0
+	for k < len(dataMatrixSizes) && dataMatrixSizes[k].data < len(codewords) {
+		k++
+	}
+	if k == len(dataMatrixSizes) {
+		return nil, fmt.Errorf("data too long for Data Matrix")
+	}
+	symbol := dataMatrixSizes[k]
+
+	// padding
+	for i := len(codewords); i < symbol.data; i++ {
+		if i == len(codewords) {
+			codewords = append(codewords, 129)
+		} else {
+			pad := 129 + (149*(i+1))%253 + 1
+			if 254 < pad {
+				pad -= 254
+			}
+			codewords = append(codewords, byte(pad))
+		}
+	}
+
+	// error correction per interleaved block
+	eccLen := symbol.ecc / symbol.blocks
+	generator := dataMatrixField.generator(eccLen, 1)
+	codewords = append(codewords, make([]byte, symbol.ecc)...)
+	for b := 0; b < symbol.blocks; b++ {
+		block := []byte{}
+		for i := b; i < symbol.data; i += symbol.blocks {
+			block = append(block, codewords[i])
+		}
+		for i, c := range dataMatrixField.remainder(block, generator) {
+			codewords[symbol.data+b+i*symbol.blocks] = c
+		}
+	}
+
+	// place codewords in the mapping matrix
+	regions := symbol.size / (symbol.region + 2)
+	nrow := regions * symbol.region
+	ncol := nrow
+	placement := make([]int, nrow*ncol) // 10*codeword+bit, or 1 for dark
+	module := func(row, col, chr, bit int) {
+		if row < 0 {
+			row += nrow
+			col += 4 - (nrow+4)%8
+		}
+		if col < 0 {
+			col += ncol
+			row += 4 - (ncol+4)%8
+		}
+		placement[row*ncol+col] = 10*chr + bit
+	}
+	utah := func(row, col, chr int) {
+		module(row-2, col-2, chr, 1)
+		module(row-2, col-1, chr, 2)
+		module(row-1, col-2, chr, 3)
+		module(row-1, col-1, chr, 4)
+		module(row-1, col, chr, 5)
+		module(row, col-2, chr, 6)
+		module(row, col-1, chr, 7)
+		module(row, col, chr, 8)
+	}
+	corner := func(chr int, positions [8][2]int) {
+		for i, pos := range positions {
+			module(pos[0], pos[1], chr, i+1)
+		}
+	}
+	chr, row, col := 1, 4, 0
+	for row < nrow || col < ncol {
+		if row == nrow && col == 0 {
+			corner(chr, [8][2]int{{nrow - 1, 0}, {nrow - 1, 1}, {nrow - 1, 2}, {0, ncol - 2}, {0, ncol - 1}, {1, ncol - 1}, {2, ncol - 1}, {3, ncol - 1}})
+			chr++
+		}
+		if row == nrow-2 && col == 0 && ncol%4 != 0 {
+			corner(chr, [8][2]int{{nrow - 3, 0}, {nrow - 2, 0}, {nrow - 1, 0}, {0, ncol - 4}, {0, ncol - 3}, {0, ncol - 2}, {0, ncol - 1}, {1, ncol - 1}})
+			chr++
+		}
+		if row == nrow-2 && col == 0 && ncol%8 == 4 {
+			corner(chr, [8][2]int{{nrow - 3, 0}, {nrow - 2, 0}, {nrow - 1, 0}, {0, ncol - 2}, {0, ncol - 1}, {1, ncol - 1}, {2, ncol - 1}, {3, ncol - 1}})
+			chr++
+		}
+		if row == nrow+4 && col == 2 && ncol%8 == 0 {
+			corner(chr, [8][2]int{{nrow - 1, 0}, {nrow - 1, ncol - 1}, {0, ncol - 3}, {0, ncol - 2}, {0, ncol - 1}, {1, ncol - 3}, {1, ncol - 2}, {1, ncol - 1}})
+			chr++
+		}
+		for { // sweep upwards
+			if row < nrow && 0 <= col && placement[row*ncol+col] == 0 {
+				utah(row, col, chr)
+				chr++
+			}
+			row -= 2
+			col += 2
+			if row < 0 || ncol <= col {
+				break
+			}
+		}
+		row++
+		col += 3
+		for { // sweep downwards
+			if 0 <= row && col < ncol && placement[row*ncol+col] == 0 {
+				utah(row, col, chr)
+				chr++
+			}
+			row += 2
+			col -= 2
+			if nrow <= row || col < 0 {
+				break
+			}
+		}
+		row += 3
+		col++
+	}
+	if placement[nrow*ncol-1] == 0 {
+		placement[nrow*ncol-1] = 1
+		placement[nrow*ncol-ncol-2] = 1
+	}
+
+	// symbol with finder and timing patterns around every data region
+	modules := make([][]bool, symbol.size)
+	for i := range modules {
+		modules[i] = make([]bool, symbol.size)
+	}
+	regionSize := symbol.region + 2
+	for r := 0; r < symbol.size; r++ {
+		for c := 0; c < symbol.size; c++ {
+			rr, cc := r%regionSize, c%regionSize
+			if cc == 0 || rr == regionSize-1 {
+				modules[r][c] = true // solid left and bottom edges
+			} else if rr == 0 {
+				modules[r][c] = cc%2 == 0 // alternating top edge
+			} else if cc == regionSize-1 {
+				modules[r][c] = rr%2 == 1 // alternating right edge
+			} else {
+				v := placement[(r/regionSize*symbol.region+rr-1)*ncol+c/regionSize*symbol.region+cc-1]
+				if v == 1 {
+					modules[r][c] = true
+				} else if 10 <= v {
+					modules[r][c] = (codewords[v/10-1]>>(8-v%10))&1 == 1
+				}
+			}
+		}
+	}
+	return modules, nil
+}
+
+////////////////////////////////////////////////////////////////
+
+// code128Patterns are the widths of the bars and spaces of the Code 128 symbols, starting with a bar. Symbols 103–105 are the start symbols for code sets A, B and C, and 106 is the stop symbol.
+var code128Patterns = [107]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+// code128Symbols returns the symbol values of data including the start symbol, switching between code sets A, B and C to minimize its length, but without the check symbol and stop symbol.
+func code128Symbols(data string) ([]int, error) {
+	const (
+		codeA, codeB, codeC = 101, 100, 99
+	)
+	digits := func(i int) int {
+		n := 0
+		for i+n < len(data) && '0' <= data[i+n] && data[i+n] <= '9' {
+			n++
+		}
+		return n
+	}
+	set := func(c byte) int {
+		if c < 32 {
+			return codeA
+		}
+		return codeB
+	}
+
+	symbols := []int{}
+	current := 0
+	for i := 0; i < len(data); {
+		c := data[i]
+		if 127 < c {
+			return nil, fmt.Errorf("invalid character for Code 128: %q", c)
+		}
+
+		n := digits(i)
+		if current == codeC {
+			if 2 <= n {
+				symbols = append(symbols, int(c-'0')*10+int(data[i+1]-'0'))
+				i += 2
+				continue
+			}
+			current = set(c)
+			symbols = append(symbols, current)
+		} else if 4 <= n && (n%2 == 0 || current != 0) || 2 <= n && i == 0 && n == len(data) {
+			// switch to code set C, encoding the first digit in the current code set for odd runs
+			if n%2 == 1 {
+				symbols = append(symbols, int(c)-32)
+				i++
+				c = data[i]
+			}
+			if current == 0 {
+				symbols = append(symbols, 105)
+			} else {
+				symbols = append(symbols, codeC)
+			}
+			current = codeC
+			continue
+		} else if current == 0 {
+			current = set(c)
+			symbols = append(symbols, 103+codeA-current)
+		} else if c < 32 && current == codeB || 96 <= c && current == codeA {
+			current = set(c)
+			symbols = append(symbols, current)
+		}
+
+		if current == codeA && c < 32 {
+			symbols = append(symbols, int(c)+64)
+		} else {
+			symbols = append(symbols, int(c)-32)
+		}
+		i++
+	}
+	if len(symbols) == 0 {
+		symbols = append(symbols, 104)
+	}
+	return symbols, nil
+}
+
+// Code128 returns a Code 128 barcode of data, which must consist of ASCII characters, as a path of bars of the given module width and height, placed so that the barcode including a quiet zone of the given number of modules (ten by specification) on either side starts at the origin.
+func Code128(data string, size, height float64, quietZone int) (*Path, error) {
+	symbols, err := code128Symbols(data)
+	if err != nil {
+		return nil, err
+	}
+	check := symbols[0]
+	for i, symbol := range symbols[1:] {
+		check += (i + 1) * symbol
+	}
+	symbols = append(symbols, check%103, 106)
+
+	widths := []int{}
+	for _, symbol := range symbols {
+		for _, w := range code128Patterns[symbol] {
+			widths = append(widths, int(w-'0'))
+		}
+	}
+	return barsToPath(widths, size, height, quietZone), nil
+}
+
+////////////////////////////////////////////////////////////////
+
+var (
+	ean13Codes = [3][10]string{
+		{"0001101", "0011001", "0010011", "0111101", "0100011", "0110001", "0101111", "0111011", "0110111", "0001011"}, // L
+		{"0100111", "0110011", "0011011", "0100001", "0011101", "0111001", "0000101", "0010001", "0001001", "0010111"}, // G
+		{"1110010", "1100110", "1101100", "1000010", "1011100", "1001110", "1010000", "1000100", "1001000", "1110100"}, // R
+	}
+	ean13Parity = [10]string{"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG", "LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL"}
+)
+
+// ean13CheckDigit returns the check digit of the first twelve digits of an EAN-13 code.
+func ean13CheckDigit(digits string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		d := int(digits[i] - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// EAN13 returns an EAN-13 barcode of twelve digits, to which the check digit is added, or of thirteen digits including the check digit, as a path of bars of the given module width and height. The barcode is placed so that the barcode including a quiet zone of the given number of modules on either side (eleven on the left and seven on the right by specification) starts at the origin. The human-readable digits are not included.
+func EAN13(digits string, size, height float64, quietZone int) (*Path, error) {
+	if len(digits) != 12 && len(digits) != 13 {
+		return nil, fmt.Errorf("EAN-13 code must have 12 or 13 digits")
+	}
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || '9' < digits[i] {
+			return nil, fmt.Errorf("EAN-13 code must consist of digits")
+		}
+	}
+	check := ean13CheckDigit(digits)
+	if len(digits) == 13 && digits[12] != check {
+		return nil, fmt.Errorf("invalid EAN-13 check digit")
+	}
+	digits = digits[:12] + string(check)
+
+	sb := strings.Builder{}
+	sb.WriteString("101")
+	parity := ean13Parity[digits[0]-'0']
+	for i := 1; i < 7; i++ {
+		code := 0
+		if parity[i-1] == 'G' {
+			code = 1
+		}
+		sb.WriteString(ean13Codes[code][digits[i]-'0'])
+	}
+	sb.WriteString("01010")
+	for i := 7; i < 13; i++ {
+		sb.WriteString(ean13Codes[2][digits[i]-'0'])
+	}
+	sb.WriteString("101")
+
+	// convert modules to the widths of bars and spaces
+	modules := sb.String()
+	widths := []int{}
+	for i := 0; i < len(modules); {
+		j := i
+		for j < len(modules) && modules[j] == modules[i] {
+			j++
+		}
+		widths = append(widths, j-i)
+		i = j
+	}
+	return barsToPath(widths, size, height, quietZone), nil
+}
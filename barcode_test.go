@@ -0,0 +1,153 @@
+package canvas
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestReedSolomon(t *testing.T) {
+	// QR code HELLO WORLD 1-M
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	test.T(t, qrField.remainder(data, qrField.generator(10, 0)), []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23})
+
+	// Data Matrix 123456
+	data = dataMatrixEncode("123456")
+	test.T(t, data, []byte{142, 164, 186})
+	test.T(t, dataMatrixField.remainder(data, dataMatrixField.generator(5, 1)), []byte{114, 25, 5, 88, 102})
+}
+
+func TestQRCode(t *testing.T) {
+	test.T(t, qrFormatBits(QRLow, 0), 0x77C4)      // 111011111000100
+	test.T(t, qrFormatBits(QRMedium, 0), 0x5412)   // 101010000010010
+	test.T(t, qrFormatBits(QRQuartile, 0), 0x355F) // 011010101011111
+	test.T(t, qrFormatBits(QRHigh, 0), 0x1689)     // 001011010001001
+	test.T(t, qrAlignmentPositions(7), []int{6, 22, 38})
+	test.T(t, qrAlignmentPositions(32), []int{6, 34, 60, 86, 112, 138})
+
+	var tts = []struct {
+		data  string
+		level QRErrorCorrection
+		size  int
+	}{
+		{"HELLO WORLD", QRMedium, 21},
+		{"HELLO WORLD", QRHigh, 25},
+		{"01234567890123456789012345678901234567", QRLow, 21},
+		{"https://github.com/tdewolff/canvas", QRQuartile, 33},
+		{string(make([]byte, 300)), QRMedium, 69},
+	}
+	for i, tt := range tts {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			modules, err := qrCodeModules(tt.data, tt.level)
+			test.Error(t, err)
+			test.T(t, len(modules), tt.size)
+
+			// finder patterns
+			for _, c := range [][2]int{{0, 0}, {tt.size - 7, 0}, {0, tt.size - 7}} {
+				for i := 0; i < 7; i++ {
+					test.That(t, modules[c[1]][c[0]+i] && modules[c[1]+6][c[0]+i], "finder pattern border")
+					test.That(t, modules[c[1]+i][c[0]] && modules[c[1]+i][c[0]+6], "finder pattern border")
+				}
+				test.That(t, modules[c[1]+3][c[0]+3], "finder pattern center")
+			}
+		})
+	}
+
+	_, err := qrCodeModules(string(make([]byte, 3000)), QRHigh)
+	test.That(t, err != nil, "data too long")
+
+	p, err := QRCode("HELLO WORLD", QRMedium, 0.5, 4)
+	test.Error(t, err)
+	test.T(t, p.FastBounds(), Rect{2.0, 2.0, 10.5, 10.5})
+}
+
+func TestDataMatrix(t *testing.T) {
+	var tts = []struct {
+		data string
+		size int
+	}{
+		{"123456", 10},
+		{"Hello World", 16},
+		{string(make([]byte, 100)), 40},
+		{string(make([]byte, 500)), 88},
+	}
+	for i, tt := range tts {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			modules, err := dataMatrixModules(tt.data)
+			test.Error(t, err)
+			test.T(t, len(modules), tt.size)
+
+			// finder and timing patterns
+			for i := 0; i < tt.size; i++ {
+				test.That(t, modules[i][0], "solid left edge")
+				test.That(t, modules[tt.size-1][i], "solid bottom edge")
+				test.T(t, modules[0][i], i%2 == 0, "alternating top edge")
+				test.T(t, modules[i][tt.size-1], i%2 == 1, "alternating right edge")
+			}
+		})
+	}
+
+	p, err := DataMatrix("123456", 1.0, 1)
+	test.Error(t, err)
+	test.T(t, p.FastBounds(), Rect{1.0, 1.0, 10.0, 10.0})
+}
+
+func TestCode128(t *testing.T) {
+	var tts = []struct {
+		data    string
+		symbols []int
+	}{
+		{"PJJ123C", []int{104, 48, 42, 42, 17, 18, 19, 35}},
+		{"123456", []int{105, 12, 34, 56}},
+		{"AB12345678", []int{104, 33, 34, 99, 12, 34, 56, 78}},
+		{"A1234567", []int{104, 33, 17, 99, 23, 45, 67}},
+		{"A\nb", []int{104, 33, 101, 74, 100, 66}},
+	}
+	for _, tt := range tts {
+		t.Run(tt.data, func(t *testing.T) {
+			symbols, err := code128Symbols(tt.data)
+			test.Error(t, err)
+			test.T(t, symbols, tt.symbols)
+		})
+	}
+
+	for i, pattern := range code128Patterns {
+		n := 0
+		for _, w := range pattern {
+			n += int(w - '0')
+		}
+		if i == 106 {
+			test.T(t, n, 13, "stop symbol width")
+		} else {
+			test.T(t, n, 11, fmt.Sprint("symbol ", i, " width"))
+		}
+	}
+
+	// start, 7 symbols, check, stop
+	p, err := Code128("PJJ123C", 0.5, 10.0, 10)
+	test.Error(t, err)
+	test.T(t, p.FastBounds(), Rect{5.0, 0.0, 0.5 * (9*11 + 13), 10.0})
+
+	_, err = Code128("é", 0.5, 10.0, 10)
+	test.That(t, err != nil, "invalid character")
+}
+
+func TestEAN13(t *testing.T) {
+	test.T(t, ean13CheckDigit("400638133393"), byte('1'))
+	test.T(t, ean13CheckDigit("590123412345"), byte('7'))
+
+	p, err := EAN13("400638133393", 0.33, 20.0, 11)
+	test.Error(t, err)
+	test.Float(t, p.FastBounds().X, 11*0.33)
+	test.Float(t, p.FastBounds().W, 95*0.33)
+
+	q, err := EAN13("4006381333931", 0.33, 20.0, 11)
+	test.Error(t, err)
+	test.T(t, q, p)
+
+	_, err = EAN13("4006381333932", 0.33, 20.0, 11)
+	test.That(t, err != nil, "invalid check digit")
+	_, err = EAN13("40063813339", 0.33, 20.0, 11)
+	test.That(t, err != nil, "invalid length")
+}
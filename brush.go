@@ -0,0 +1,124 @@
+package canvas
+
+import (
+	"image"
+	"math"
+	"math/rand"
+)
+
+// Brush stamps a shape or an image repeatedly along a path, such as for textured, painterly, or chalk-like strokes. Stamps are placed at regular spacing along each subpath and can be jittered in position, rotation, and scale. The shape or image is centered on the path.
+type Brush struct {
+	Shape      *Path       // shape that is filled with the fill paint at every stamp, centered at the origin
+	Image      image.Image // image that is drawn at every stamp, used when Shape is nil
+	Resolution Resolution  // resolution of the image
+
+	Spacing        float64 // distance in millimeters between the stamps
+	FollowTangent  bool    // rotate the stamps along the direction of the path
+	Jitter         float64 // maximum random offset in millimeters of the stamps perpendicular to the path
+	RotationJitter float64 // maximum random rotation in degrees of the stamps
+	ScaleJitter    float64 // in [0,1), the maximum random relative change in size of the stamps
+	Seed           int64   // seed of the random jitter, so that strokes are reproducible
+}
+
+// NewShapeBrush returns a new brush that stamps the given shape every spacing millimeters, rotated along the path.
+func NewShapeBrush(shape *Path, spacing float64) *Brush {
+	return &Brush{
+		Shape:         shape,
+		Spacing:       spacing,
+		FollowTangent: true,
+	}
+}
+
+// NewImageBrush returns a new brush that stamps the given image at the given resolution every spacing millimeters, rotated along the path.
+func NewImageBrush(img image.Image, resolution Resolution, spacing float64) *Brush {
+	return &Brush{
+		Image:         img,
+		Resolution:    resolution,
+		Spacing:       spacing,
+		FollowTangent: true,
+	}
+}
+
+// Stamps returns the transformations of the stamps along the path, each mapping the origin of the shape or the center of the image to its position on the path. Every subpath starts with a stamp at its start.
+func (b *Brush) Stamps(p *Path) []Matrix {
+	if b.Spacing <= 0.0 {
+		return nil
+	}
+
+	rng := rand.New(rand.NewSource(b.Seed))
+	jitter := func(amount float64) float64 {
+		return amount * (2.0*rng.Float64() - 1.0)
+	}
+
+	stamps := []Matrix{}
+	for _, ps := range p.Flatten(Tolerance).Split() {
+		next := 0.0 // distance along the segment of the next stamp
+		scanner := ps.Scanner()
+		for scanner.Scan() {
+			if scanner.Cmd() == MoveToCmd {
+				continue
+			}
+			start, end := scanner.Start(), scanner.End()
+			d := end.Sub(start)
+			length := d.Length()
+			for ; next <= length && !Equal(length, 0.0); next += b.Spacing {
+				pos := start.Interpolate(end, next/length)
+				if b.Jitter != 0.0 {
+					pos = pos.Add(d.Rot90CCW().Norm(jitter(b.Jitter)))
+				}
+				rot := jitter(b.RotationJitter)
+				if b.FollowTangent {
+					rot += d.Angle() * 180.0 / math.Pi
+				}
+				scale := 1.0 + jitter(b.ScaleJitter)
+				stamps = append(stamps, Identity.Translate(pos.X, pos.Y).Rotate(rot).Scale(scale, scale))
+			}
+			next -= length
+		}
+	}
+	return stamps
+}
+
+// DrawBrushStroke draws a brush stroke along the path at position (x,y) using the current draw state, filling shapes with the fill paint. The stamps are drawn as a group so that renderers supporting groups keep them together, which is an isolated group in vector formats and is composited as a whole in raster formats.
+func (c *Context) DrawBrushStroke(x, y float64, path *Path, brush *Brush) {
+	if brush.Shape == nil && (brush.Image == nil || brush.Image.Bounds().Empty()) {
+		return
+	} else if brush.Shape != nil && !c.Style.HasFill() {
+		return
+	} else if !path.finite() {
+		c.setErr(ErrInvalidPath)
+		return
+	}
+
+	coord := c.coordView.Dot(Point{x, y})
+	m := c.coordSystemView().Mul(c.unitView()).Translate(coord.X, coord.Y)
+
+	style := c.Style
+	style.Stroke = Paint{}
+	style.Dashes = nil
+
+	var img image.Image
+	var imgView Matrix
+	if brush.Shape == nil {
+		img = imageWithFilter(c.orientImage(brush.Image), c.imageFilter)
+		size := img.Bounds().Size()
+		dpu := brush.Resolution.DPMM() * float64(c.Unit())
+		imgView = Identity.Scale(1.0/dpu, 1.0/dpu).Translate(-float64(size.X)/2.0, -float64(size.Y)/2.0)
+		if c.coordSystem == CartesianIII || c.coordSystem == CartesianIV {
+			imgView = imgView.ReflectYAbout(float64(size.Y) / 2.0)
+		}
+		if c.coordSystem == CartesianII || c.coordSystem == CartesianIII {
+			imgView = imgView.ReflectXAbout(float64(size.X) / 2.0)
+		}
+	}
+
+	c.BeginGroup(1.0)
+	for _, stamp := range brush.Stamps(path) {
+		if brush.Shape != nil {
+			renderPath(c.Renderer, brush.Shape, style, m.Mul(stamp))
+		} else {
+			c.RenderImage(img, m.Mul(stamp).Mul(imgView))
+		}
+	}
+	c.EndGroup()
+}
@@ -0,0 +1,29 @@
+package canvas
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestBrushStamps(t *testing.T) {
+	brush := NewShapeBrush(Circle(0.5), 2.0)
+	stamps := brush.Stamps(MustParseSVGPath("M0 0L5 0L5 4"))
+	test.T(t, len(stamps), 5)
+	test.T(t, stamps[0].Dot(Point{}), Point{0.0, 0.0})
+	test.T(t, stamps[2].Dot(Point{}), Point{4.0, 0.0})
+	test.T(t, stamps[3].Dot(Point{}), Point{5.0, 1.0})
+	test.T(t, stamps[3].Dot(Point{1.0, 0.0}), Point{5.0, 2.0}) // rotated along the path
+
+	// every subpath starts with a stamp
+	stamps = brush.Stamps(MustParseSVGPath("M0 0L3 0M0 5L1 5"))
+	test.T(t, len(stamps), 3)
+	test.T(t, stamps[2].Dot(Point{}), Point{0.0, 5.0})
+
+	// jitter is reproducible
+	brush.Jitter = 0.5
+	brush.RotationJitter = 30.0
+	brush.ScaleJitter = 0.2
+	test.T(t, brush.Stamps(MustParseSVGPath("M0 0L10 0")), brush.Stamps(MustParseSVGPath("M0 0L10 0")))
+	test.T(t, len(NewShapeBrush(Circle(0.5), 0.0).Stamps(MustParseSVGPath("M0 0L10 0"))), 0)
+}
@@ -1,12 +1,18 @@
 package canvas
 
 import (
+	"encoding/xml"
+	"errors"
+	"fmt"
 	"image"
 	"image/color"
 	"io"
+	"math"
 	"os"
 	"reflect"
 	"sort"
+	"strings"
+	"time"
 )
 
 // const mmPerPx = 25.4 / 96.0
@@ -42,6 +48,32 @@ func (res Resolution) DPI() float64 {
 // DefaultResolution is the default resolution used for font PPEMs and is set to 96 DPI.
 const DefaultResolution = Resolution(96.0 * inchPerMm)
 
+// Unit is a unit of length expressed in millimeters, used to specify positions and sizes in other units than millimeters.
+type Unit float64
+
+// Predefined units of length.
+const (
+	Mm   Unit = 1.0
+	Cm   Unit = 10.0
+	Inch Unit = mmPerInch
+	Pt   Unit = mmPerPt
+)
+
+// Px returns the unit of length of a pixel at the given resolution, e.g. Px(DPI(96.0)) for CSS pixels.
+func Px(resolution Resolution) Unit {
+	return Unit(1.0 / resolution.DPMM())
+}
+
+// ToMM converts a length in the given unit to millimeters.
+func (unit Unit) ToMM(length float64) float64 {
+	return length * float64(unit)
+}
+
+// FromMM converts a length in millimeters to the given unit.
+func (unit Unit) FromMM(length float64) float64 {
+	return length / float64(unit)
+}
+
 // Size defines a size (width and height).
 type Size struct {
 	W, H float64
@@ -104,14 +136,15 @@ const (
 
 // Paint is the type of paint used to fill or stroke a path. It can be either a color or a pattern. Default is transparent (no paint).
 type Paint struct {
-	Color color.RGBA
+	Color  color.RGBA
+	Swatch *Swatch // named color of Color, if any
 	Gradient
 	Pattern
 }
 
 // Equal returns true if Paints are equal.
 func (paint Paint) Equal(other Paint) bool {
-	if paint.IsColor() && other.IsColor() && paint.Color == other.Color {
+	if paint.IsColor() && other.IsColor() && paint.Color == other.Color && paint.Swatch.Equal(other.Swatch) {
 		return true
 	} else if paint.IsGradient() && other.IsGradient() && reflect.DeepEqual(paint, other) {
 		return true
@@ -141,16 +174,20 @@ func (paint Paint) IsPattern() bool {
 	return paint.Pattern != nil
 }
 
-// Style is the path style that defines how to draw the path. When Fill is not set it will not fill the path. If StrokeColor is transparent or StrokeWidth is zero, it will not stroke the path. If Dashes is an empty array, it will not draw dashes but instead a solid stroke line. FillRule determines how to fill the path when paths overlap and have certain directions (clockwise, counter clockwise).
+// Style is the path style that defines how to draw the path. When Fill is not set it will not fill the path. If StrokeColor is transparent or StrokeWidth is zero, it will not stroke the path. If Dashes is an empty array, it will not draw dashes but instead a solid stroke line. FillRule determines how to fill the path when paths overlap and have certain directions (clockwise, counter clockwise). NonScalingStroke makes the stroke width and dashes be in output units (millimeters of the canvas) instead of being scaled by the view, similar to SVG's non-scaling-stroke vector effect. DashFit scales the dashes slightly for each subpath so that an integer number of periods fits, see Path.DashFit.
 type Style struct {
-	Fill         Paint
-	Stroke       Paint
-	StrokeWidth  float64
-	StrokeCapper Capper
-	StrokeJoiner Joiner
-	DashOffset   float64
-	Dashes       []float64
-	FillRule     // TODO: test for all renderers
+	Fill             Paint
+	Stroke           Paint
+	StrokeWidth      float64
+	StrokeCapper     Capper
+	StrokeJoiner     Joiner
+	DashOffset       float64
+	Dashes           []float64
+	FillRule         // TODO: test for all renderers
+	NonScalingStroke bool
+	DashFit          bool
+	CompositeMode    CompositeMode
+	Shadow           *Shadow
 }
 
 // HasFill returns true if the style has a fill
@@ -188,6 +225,132 @@ type Renderer interface {
 	RenderImage(img image.Image, m Matrix)
 }
 
+// ImageQuadRenderer is an interface that renderers may implement to map images onto arbitrary quadrilaterals using projective interpolation. The corners of the quadrilateral are in mm and correspond to the image's top-left, top-right, bottom-right, and bottom-left corners respectively. Renderers that do not implement it receive an approximating mesh of affinely transformed image slices instead.
+type ImageQuadRenderer interface {
+	RenderImageQuad(img image.Image, q [4]Point)
+}
+
+// MetadataRenderer is an interface that renderers may implement to embed document metadata. It is called by Canvas before rendering any drawing operations.
+type MetadataRenderer interface {
+	SetMetadata(metadata Metadata)
+}
+
+// PageBoxRenderer is an interface that renderers may implement to embed the trim and bleed boxes of the page for print production, see Canvas.ForPrint. It is called by Canvas before rendering any drawing operations.
+type PageBoxRenderer interface {
+	SetPageBoxes(trimBox, bleedBox Rect)
+}
+
+// ErrorRenderer is an interface that renderers may implement to report the first error that occurred while rendering, such as write or image encoding errors. It is used by Context.Err.
+type ErrorRenderer interface {
+	Err() error
+}
+
+// ErrorWriter is a writer that records the first write error, after which all writes are ignored. Renderers wrap their output writer to report write errors at the end through ErrorRenderer.
+type ErrorWriter struct {
+	io.Writer
+	Err error
+}
+
+func (w *ErrorWriter) Write(b []byte) (int, error) {
+	if w.Err != nil {
+		return 0, w.Err
+	}
+	n, err := w.Writer.Write(b)
+	w.Err = err
+	return n, err
+}
+
+// GroupRenderer is an interface that renderers may implement to composite a group of drawing operations as a whole (isolated) with a given opacity. Groups may be nested. Renderers that do not implement it receive each drawing operation with its alpha multiplied by the group's opacity instead, see Context.BeginGroup.
+type GroupRenderer interface {
+	BeginGroup(opacity float64)
+	EndGroup()
+}
+
+// FilterRenderer is an interface that renderers may implement to apply a filter to a group of drawing operations as a whole, see Filter. Filters may be nested. Renderers that do not implement it draw the operations without the filter, see Context.BeginFilter.
+type FilterRenderer interface {
+	BeginFilter(filter Filter)
+	EndFilter()
+}
+
+// opacityRenderer multiplies the alpha of all drawing operations by an opacity, which is used for group opacity by renderers that do not implement GroupRenderer. Overlapping drawing operations thus show through each other, unlike for isolated groups.
+type opacityRenderer struct {
+	Renderer
+	opacity float64
+}
+
+func (r opacityRenderer) RenderPath(path *Path, style Style, m Matrix) {
+	style.Fill = paintWithOpacity(style.Fill, r.opacity)
+	style.Stroke = paintWithOpacity(style.Stroke, r.opacity)
+	if style.Shadow != nil {
+		shadow := *style.Shadow
+		shadow.Color = colorWithOpacity(shadow.Color, r.opacity)
+		style.Shadow = &shadow
+	}
+	renderPath(r.Renderer, path, style, m)
+}
+
+func (r opacityRenderer) Capabilities() Capabilities {
+	return RendererCapabilities(r.Renderer)
+}
+
+func (r opacityRenderer) RenderText(text *Text, m Matrix) {
+	text.RenderAsPath(r, m, DefaultResolution)
+}
+
+func (r opacityRenderer) RenderImage(img image.Image, m Matrix) {
+	r.Renderer.RenderImage(imageWithOpacity(img, r.opacity), m)
+}
+
+func (r opacityRenderer) RenderImageQuad(img image.Image, q [4]Point) {
+	renderImageQuad(r.Renderer, imageWithOpacity(img, r.opacity), q)
+}
+
+// textStyleRenderer renders the paths of text with a composite mode and shadow.
+type textStyleRenderer struct {
+	Renderer
+	mode   CompositeMode
+	shadow *Shadow
+}
+
+func (r textStyleRenderer) RenderPath(path *Path, style Style, m Matrix) {
+	style.CompositeMode = r.mode
+	style.Shadow = r.shadow
+	renderPath(r.Renderer, path, style, m)
+}
+
+func (r textStyleRenderer) Capabilities() Capabilities {
+	return RendererCapabilities(r.Renderer)
+}
+
+func colorWithOpacity(col color.RGBA, opacity float64) color.RGBA {
+	return color.RGBA{
+		uint8(float64(col.R)*opacity + 0.5),
+		uint8(float64(col.G)*opacity + 0.5),
+		uint8(float64(col.B)*opacity + 0.5),
+		uint8(float64(col.A)*opacity + 0.5),
+	}
+}
+
+func paintWithOpacity(paint Paint, opacity float64) Paint {
+	paint.Color = colorWithOpacity(paint.Color, opacity)
+	if g, ok := paint.Gradient.(*LinearGradient); ok {
+		gradient := *g
+		gradient.Stops = append(Stops{}, g.Stops...)
+		for i := range gradient.Stops {
+			gradient.Stops[i].Color = colorWithOpacity(gradient.Stops[i].Color, opacity)
+		}
+		paint.Gradient = &gradient
+	} else if g, ok := paint.Gradient.(*RadialGradient); ok {
+		gradient := *g
+		gradient.Stops = append(Stops{}, g.Stops...)
+		for i := range gradient.Stops {
+			gradient.Stops[i].Color = colorWithOpacity(gradient.Stops[i].Color, opacity)
+		}
+		paint.Gradient = &gradient
+	}
+	return paint
+}
+
 ////////////////////////////////////////////////////////////////
 
 // CoordSystem is the coordinate system, which can be either of the four cartesian quadrants. Most useful are the I'th and IV'th quadrants. CartesianI is the default quadrant with the zero-point in the bottom-left (the default for mathematics). The CartesianII has its zero-point in the bottom-right, CartesianIII in the top-right, and CartesianIV in the top-left (often used as default for printing devices). See https://en.wikipedia.org/wiki/Cartesian_coordinate_system#Quadrants_and_octants for an explanation.
@@ -207,6 +370,10 @@ type ContextState struct {
 	view        Matrix
 	coordView   Matrix
 	coordSystem CoordSystem
+	imageFilter ImageFilter
+	imageExif   bool
+	unit        Unit
+	palette     *Palette
 }
 
 // Context maintains the state for the current path, path style, and view transformation matrix.
@@ -215,7 +382,17 @@ type Context struct {
 
 	path *Path
 	ContextState
-	stack []ContextState
+	stack   []ContextState
+	groups  []Renderer
+	filters []Renderer
+	pushed  []pushedGroup
+	err     error
+}
+
+// pushedGroup is the renderer before PushGroup and the canvas that records the group.
+type pushedGroup struct {
+	renderer Renderer
+	group    *Canvas
 }
 
 // NewContext returns a new context which is a wrapper around a renderer. Contexts maintain the state of the current path, path style, and view transformation matrix.
@@ -228,6 +405,7 @@ func NewContext(r Renderer) *Context {
 			view:        Identity,
 			coordView:   Identity,
 			coordSystem: CartesianI,
+			unit:        Mm,
 		},
 		stack: nil,
 	}
@@ -277,6 +455,27 @@ func (c *Context) coordSystemView() Matrix {
 	return Identity
 }
 
+// Unit returns the current unit of length.
+func (c *Context) Unit() Unit {
+	if c.unit == 0.0 {
+		return Mm
+	}
+	return c.unit
+}
+
+// SetUnit sets the unit of length for all subsequent positions and sizes, such as coordinates, paths, stroke widths, dashes, and view transformations, which otherwise are in millimeters. Font sizes are unaffected and image sizes are still given by their resolution. The unit is applied before the coordinate system, thus the canvas' width and height remain in millimeters.
+func (c *Context) SetUnit(unit Unit) {
+	c.unit = unit
+}
+
+// unitView returns the view with the unit of length applied.
+func (c *Context) unitView() Matrix {
+	if unit := float64(c.Unit()); unit != 1.0 {
+		return Identity.Scale(unit, unit).Mul(c.view)
+	}
+	return c.view
+}
+
 // SetCoordSystem sets the Cartesian coordinate system.
 func (c *Context) SetCoordSystem(coordSystem CoordSystem) {
 	c.coordSystem = coordSystem
@@ -372,6 +571,66 @@ func (c *Context) ShearAbout(sx, sy, x, y float64) {
 	c.view = c.view.Mul(Identity.ShearAbout(sx, sy, x, y))
 }
 
+// ErrInvalidPath is reported when drawing a path with coordinates that are NaN or infinite.
+var ErrInvalidPath = errors.New("path has non-finite coordinates")
+
+// ErrInvalidNineSlice is reported when drawing a nine-slice image with insets larger than the image.
+var ErrInvalidNineSlice = errors.New("nine-slice insets are larger than the image")
+
+// Err returns the first error that occurred while drawing, either by the context or as reported by the renderer (see ErrorRenderer). Drawing operations that fail are skipped, so that a long sequence of drawing operations can be checked once at the end.
+func (c *Context) Err() error {
+	if c.err != nil {
+		return c.err
+	}
+	r := c.Renderer
+	if 0 < len(c.groups) {
+		r = c.groups[0]
+	}
+	if er, ok := r.(ErrorRenderer); ok {
+		return er.Err()
+	}
+	return nil
+}
+
+func (c *Context) setErr(err error) {
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+// SetPalette sets the palette of named colors, which can be referenced by name in SetFill, SetStroke, SetFillSwatch, and SetStrokeSwatch.
+func (c *Context) SetPalette(palette *Palette) {
+	c.palette = palette
+}
+
+// Palette returns the palette of named colors.
+func (c *Context) Palette() *Palette {
+	return c.palette
+}
+
+func swatchPaint(swatch *Swatch) Paint {
+	return Paint{Color: swatch.Color, Swatch: swatch}
+}
+
+func (c *Context) paletteSwatchPaint(name string) Paint {
+	if c.palette != nil {
+		if swatch, ok := c.palette.Get(name); ok {
+			return swatchPaint(swatch)
+		}
+	}
+	return Paint{}
+}
+
+// SetFillSwatch sets the named color of the palette to be used for filling operations. Unknown names result in no fill.
+func (c *Context) SetFillSwatch(name string) {
+	c.Style.Fill = c.paletteSwatchPaint(name)
+}
+
+// SetStrokeSwatch sets the named color of the palette to be used for stroking operations. Unknown names result in no stroke.
+func (c *Context) SetStrokeSwatch(name string) {
+	c.Style.Stroke = c.paletteSwatchPaint(name)
+}
+
 // SetFill sets the color, gradient, or pattern to be used for filling operations.
 func (c *Context) SetFill(ifill interface{}) {
 	if paint, ok := ifill.(Paint); ok {
@@ -380,6 +639,10 @@ func (c *Context) SetFill(ifill interface{}) {
 		c.Style.Fill = Paint{Pattern: pattern}
 	} else if gradient, ok := ifill.(Gradient); ok {
 		c.Style.Fill = Paint{Gradient: gradient}
+	} else if swatch, ok := ifill.(*Swatch); ok {
+		c.Style.Fill = swatchPaint(swatch)
+	} else if name, ok := ifill.(string); ok {
+		c.Style.Fill = c.paletteSwatchPaint(name)
 	} else if col, ok := ifill.(color.Color); ok {
 		c.Style.Fill = Paint{Color: rgbaColor(col)}
 	} else {
@@ -390,6 +653,7 @@ func (c *Context) SetFill(ifill interface{}) {
 // SetFillColor sets the color to be used for filling operations.
 func (c *Context) SetFillColor(col color.Color) {
 	c.Style.Fill.Color = rgbaColor(col)
+	c.Style.Fill.Swatch, _ = col.(*Swatch)
 	c.Style.Fill.Gradient = nil
 	c.Style.Fill.Pattern = nil
 }
@@ -397,6 +661,7 @@ func (c *Context) SetFillColor(col color.Color) {
 // SetFillGradient sets the gradient to be used for filling operations.
 func (c *Context) SetFillGradient(gradient Gradient) {
 	c.Style.Fill.Color = Transparent
+	c.Style.Fill.Swatch = nil
 	c.Style.Fill.Gradient = gradient
 	c.Style.Fill.Pattern = nil
 }
@@ -404,6 +669,7 @@ func (c *Context) SetFillGradient(gradient Gradient) {
 // SetFillPattern sets the pattern to be used for filling operations.
 func (c *Context) SetFillPattern(pattern Pattern) {
 	c.Style.Fill.Color = Transparent
+	c.Style.Fill.Swatch = nil
 	c.Style.Fill.Gradient = nil
 	c.Style.Fill.Pattern = pattern
 }
@@ -416,6 +682,10 @@ func (c *Context) SetStroke(istroke interface{}) {
 		c.Style.Stroke = Paint{Pattern: pattern}
 	} else if gradient, ok := istroke.(Gradient); ok {
 		c.Style.Stroke = Paint{Gradient: gradient}
+	} else if swatch, ok := istroke.(*Swatch); ok {
+		c.Style.Stroke = swatchPaint(swatch)
+	} else if name, ok := istroke.(string); ok {
+		c.Style.Stroke = c.paletteSwatchPaint(name)
 	} else if col, ok := istroke.(color.Color); ok {
 		c.Style.Stroke = Paint{Color: rgbaColor(col)}
 	} else {
@@ -426,6 +696,7 @@ func (c *Context) SetStroke(istroke interface{}) {
 // SetStrokeColor sets the color to be used for stroking operations.
 func (c *Context) SetStrokeColor(col color.Color) {
 	c.Style.Stroke.Color = rgbaColor(col)
+	c.Style.Stroke.Swatch, _ = col.(*Swatch)
 	c.Style.Stroke.Gradient = nil
 	c.Style.Stroke.Pattern = nil
 }
@@ -433,6 +704,7 @@ func (c *Context) SetStrokeColor(col color.Color) {
 // SetStrokeGradient sets the gradients to be used for stroking operations.
 func (c *Context) SetStrokeGradient(gradient Gradient) {
 	c.Style.Stroke.Color = Transparent
+	c.Style.Stroke.Swatch = nil
 	c.Style.Stroke.Gradient = gradient
 	c.Style.Stroke.Pattern = nil
 }
@@ -440,6 +712,7 @@ func (c *Context) SetStrokeGradient(gradient Gradient) {
 // SetStrokePattern sets the pattern to be used for stroking operations.
 func (c *Context) SetStrokePattern(pattern Pattern) {
 	c.Style.Stroke.Color = Transparent
+	c.Style.Stroke.Swatch = nil
 	c.Style.Stroke.Gradient = nil
 	c.Style.Stroke.Pattern = pattern
 }
@@ -459,22 +732,136 @@ func (c *Context) SetStrokeJoiner(joiner Joiner) {
 	c.Style.StrokeJoiner = joiner
 }
 
+// SetStrokeMiterLimit sets the miter limit of the current line join function if it is a miter or arcs joiner. The limit is the ratio of the miter length to the stroke width, when exceeded the joiner falls back to its gap joiner (such as a bevel join) or clips the join at the limit.
+func (c *Context) SetStrokeMiterLimit(limit float64) {
+	c.Style.StrokeJoiner = joinerWithLimit(c.Style.StrokeJoiner, limit)
+}
+
 // SetDashes sets the dash pattern to be used for stroking operations. The dash offset denotes the offset into the dash array in millimeters from where to start. Negative values are allowed.
 func (c *Context) SetDashes(offset float64, dashes ...float64) {
 	c.Style.DashOffset = offset
 	c.Style.Dashes = dashes
 }
 
+// SetNonScalingStroke sets whether the stroke width and dashes are in output units and not affected by the view. This is useful for drawings that are rendered at different zoom levels but should keep the same line widths.
+func (c *Context) SetNonScalingStroke(nonScaling bool) {
+	c.Style.NonScalingStroke = nonScaling
+}
+
+// SetDashFit sets whether the dash pattern is scaled slightly for each subpath so that an integer number of periods fits its length, which avoids partial dashes at the ends of open paths and at the seam of closed paths.
+func (c *Context) SetDashFit(fit bool) {
+	c.Style.DashFit = fit
+}
+
 // SetFillRule sets the fill rule to be used for filling paths.
 func (c *Context) SetFillRule(rule FillRule) {
 	c.Style.FillRule = rule
 }
 
+// SetCompositeMode sets the way paths and text are composited with what has been drawn before, such as the Porter-Duff operators and blend modes like multiply and screen. Images are always drawn over. Renderers without support for composite modes draw over, and renderers may support only some of the modes, such as only the blend modes for PDF and SVG.
+func (c *Context) SetCompositeMode(mode CompositeMode) {
+	c.Style.CompositeMode = mode
+}
+
+// SetShadow sets the drop shadow of paths and text, which is offset by (dx,dy) and blurred with standard deviation blur, all in millimeters and unaffected by the view, see Shadow. A transparent color removes the shadow.
+func (c *Context) SetShadow(dx, dy, blur float64, col color.Color) {
+	rgba := rgbaColor(col)
+	if rgba.A == 0 {
+		c.Style.Shadow = nil
+		return
+	}
+	c.Style.Shadow = &Shadow{Point{dx, dy}, blur, rgba}
+}
+
 // ResetStyle resets the draw state to its default (colors, stroke widths, dashes, ...).
 func (c *Context) ResetStyle() {
 	c.Style = DefaultStyle
 }
 
+// SetImageFilter sets the resampling filter used for drawing images. The DefaultImageFilter keeps the filter of images of type Image.
+func (c *Context) SetImageFilter(filter ImageFilter) {
+	c.imageFilter = filter
+}
+
+// SetImageExifOrientation sets whether to honor the EXIF orientation of JPEG images (see NewJPEGImage) when drawing images, rotating and/or flipping them upright.
+func (c *Context) SetImageExifOrientation(exif bool) {
+	c.imageExif = exif
+}
+
+func (c *Context) orientImage(img image.Image) image.Image {
+	if c.imageExif {
+		if cimg, ok := img.(Image); ok {
+			return OrientImage(cimg, cimg.ExifOrientation())
+		}
+	}
+	return img
+}
+
+// BeginGroup starts a group of drawing operations that is composited as a whole with the given opacity ∈ [0,1] at EndGroup, which must be called for each BeginGroup. Unlike the alpha of fill and stroke colors, overlapping shapes within the group do not show through each other. Groups can be nested. Renderers that don't implement GroupRenderer approximate group opacity by multiplying the alpha of each drawing operation.
+func (c *Context) BeginGroup(opacity float64) {
+	c.groups = append(c.groups, c.Renderer)
+	if gr, ok := c.Renderer.(GroupRenderer); ok {
+		gr.BeginGroup(opacity)
+	} else {
+		c.Renderer = opacityRenderer{c.Renderer, opacity}
+	}
+}
+
+// EndGroup ends the last group started with BeginGroup and composites it.
+func (c *Context) EndGroup() {
+	if len(c.groups) == 0 {
+		return
+	}
+	c.Renderer = c.groups[len(c.groups)-1]
+	c.groups = c.groups[:len(c.groups)-1]
+	if gr, ok := c.Renderer.(GroupRenderer); ok {
+		gr.EndGroup()
+	}
+}
+
+// PushGroup starts a group of drawing operations like BeginGroup, but the opacity is given when the group ends at PopGroup, which must be called for each PushGroup. This is useful when the opacity depends on what is drawn. The drawing operations are recorded and passed to the renderer at PopGroup.
+func (c *Context) PushGroup() {
+	group := New(c.Renderer.Size())
+	c.pushed = append(c.pushed, pushedGroup{c.Renderer, group})
+	c.Renderer = group
+}
+
+// PopGroup ends the last group started with PushGroup and composites it with the given opacity ∈ [0,1], see BeginGroup.
+func (c *Context) PopGroup(opacity float64) {
+	if len(c.pushed) == 0 {
+		return
+	}
+	group := c.pushed[len(c.pushed)-1].group
+	c.Renderer = c.pushed[len(c.pushed)-1].renderer
+	c.pushed = c.pushed[:len(c.pushed)-1]
+	if group.Empty() {
+		return
+	}
+	c.BeginGroup(opacity)
+	group.RenderTo(c.Renderer)
+	c.EndGroup()
+}
+
+// BeginFilter starts a group of drawing operations to which the filter is applied as a whole at EndFilter, which must be called for each BeginFilter, such as a blur or color transformation. Filters can be nested, and are applied to the filtered result of the nested filters. Renderers that don't implement FilterRenderer draw the operations without the filter.
+func (c *Context) BeginFilter(filter Filter) {
+	c.filters = append(c.filters, c.Renderer)
+	if fr, ok := c.Renderer.(FilterRenderer); ok {
+		fr.BeginFilter(filter)
+	}
+}
+
+// EndFilter ends the last group started with BeginFilter and applies its filter.
+func (c *Context) EndFilter() {
+	if len(c.filters) == 0 {
+		return
+	}
+	c.Renderer = c.filters[len(c.filters)-1]
+	c.filters = c.filters[:len(c.filters)-1]
+	if fr, ok := c.Renderer.(FilterRenderer); ok {
+		fr.EndFilter()
+	}
+}
+
 // SetZIndex sets the z-index. This will call the renderer's `SetZIndex` function only if it exists (in this case only for `Canvas`).
 func (c *Context) SetZIndex(zindex int) {
 	if zindexer, ok := c.Renderer.(interface{ SetZIndex(int) }); ok {
@@ -482,6 +869,14 @@ func (c *Context) SetZIndex(zindex int) {
 	}
 }
 
+// ZIndex returns the current z-index. This will call the renderer's `ZIndex` function only if it exists (in this case only for `Canvas`), otherwise it returns zero.
+func (c *Context) ZIndex() int {
+	if zindexer, ok := c.Renderer.(interface{ ZIndex() int }); ok {
+		return zindexer.ZIndex()
+	}
+	return 0
+}
+
 // Pos returns the current position of the path, which is the end point of the last command.
 func (c *Context) Pos() (float64, float64) {
 	return c.path.Pos().X, c.path.Pos().Y
@@ -551,6 +946,7 @@ func (c *Context) FitImage(img image.Image, rect Rect, fit ImageFit) {
 	if img.Bounds().Size().Eq(image.Point{}) || rect.W == 0 || rect.H == 0 {
 		return
 	}
+	img = c.orientImage(img)
 
 	width := float64(img.Bounds().Max.X - img.Bounds().Min.X)
 	height := float64(img.Bounds().Max.Y - img.Bounds().Min.Y)
@@ -576,25 +972,19 @@ func (c *Context) FitImage(img image.Image, rect Rect, fit ImageFit) {
 			dx = int((width-rect.W*yres)/2.0 + 0.5)
 			xres = (width - float64(2*dx)) / rect.W
 		}
-		if subimg, ok := img.(interface {
-			SubImage(image.Rectangle) image.Image
-		}); ok {
-			imgRect := img.Bounds()
-			imgRect.Min.X += dx
-			imgRect.Min.Y += dy
-			imgRect.Max.X -= dx
-			imgRect.Max.Y -= dy
-			img = subimg.SubImage(imgRect)
-		} else {
-			panic("image doesn't have SubImage method")
-		}
+		imgRect := img.Bounds()
+		imgRect.Min.X += dx
+		imgRect.Min.Y += dy
+		imgRect.Max.X -= dx
+		imgRect.Max.Y -= dy
+		img = subImage(img, imgRect)
 	default:
 		// ImageFill
 	}
 
 	// get view
 	coord := c.coordView.Dot(Point{x, y})
-	m := c.coordSystemView().Mul(c.view).Translate(coord.X, coord.Y)
+	m := c.coordSystemView().Mul(c.unitView()).Translate(coord.X, coord.Y)
 
 	// set resolution
 	m = m.Scale(1.0/xres, 1.0/yres)
@@ -606,7 +996,7 @@ func (c *Context) FitImage(img image.Image, rect Rect, fit ImageFit) {
 	if c.coordSystem == CartesianII || c.coordSystem == CartesianIII {
 		m = m.ReflectXAbout(float64(img.Bounds().Size().X) / 2.0)
 	}
-	c.RenderImage(img, m)
+	c.RenderImage(imageWithFilter(img, c.imageFilter), m)
 }
 
 // DrawPath draws a path at position (x,y) using the current draw state.
@@ -615,6 +1005,13 @@ func (c *Context) DrawPath(x, y float64, paths ...*Path) {
 		return
 	}
 
+	for _, path := range paths {
+		if !path.finite() {
+			c.setErr(ErrInvalidPath)
+			return
+		}
+	}
+
 	// TODO: apply coordinate view to fill/stroke gradients/patterns
 	style := c.Style
 	m := c.coordSystemView()
@@ -631,28 +1028,63 @@ func (c *Context) DrawPath(x, y float64, paths ...*Path) {
 
 	// get view
 	coord := c.coordView.Dot(Point{x, y})
-	m = m.Mul(c.view).Translate(coord.X, coord.Y)
+	m = m.Mul(c.unitView()).Translate(coord.X, coord.Y)
 
-	dashes := style.Dashes
 	for _, path := range paths {
+		pathStyle, pathView := style, m
+		if style.NonScalingStroke && style.HasStroke() && !m.Equals(Identity) {
+			// transform the path beforehand so that the stroke and its dashes are not affected by the view
+			unit := c.Unit()
+			pathStyle.StrokeWidth = unit.ToMM(style.StrokeWidth)
+			pathStyle.DashOffset = unit.ToMM(style.DashOffset)
+			pathStyle.Dashes = make([]float64, len(style.Dashes))
+			for i, dash := range style.Dashes {
+				pathStyle.Dashes[i] = unit.ToMM(dash)
+			}
+			path, pathView = path.Transform(m), Identity
+		}
+
+		if style.DashFit && 0 < len(style.Dashes) && style.HasStroke() {
+			// dash beforehand since the dash pattern is different for each subpath
+			if style.HasFill() {
+				fillStyle := pathStyle
+				fillStyle.Stroke = Paint{}
+				fillStyle.Dashes = nil
+				renderPath(c.Renderer, path, fillStyle, pathView)
+			}
+			strokeStyle := pathStyle
+			strokeStyle.Fill = Paint{}
+			strokeStyle.Dashes = nil
+			renderPath(c.Renderer, path.DashFit(pathStyle.DashOffset, pathStyle.Dashes...), strokeStyle, pathView)
+			continue
+		}
+
 		var ok bool
-		style.Dashes, ok = path.checkDash(c.Style.DashOffset, dashes)
+		pathStyle.Dashes, ok = path.checkDash(pathStyle.DashOffset, pathStyle.Dashes)
 		if !ok {
-			style.Stroke = Paint{}
+			pathStyle.Stroke = Paint{}
 		}
-		c.RenderPath(path, style, m)
+		renderPath(c.Renderer, path, pathStyle, pathView)
 	}
 }
 
-// DrawText draws text at position (x,y) using the current draw state.
+// DrawText draws text at position (x,y) using the current draw state. Text of which the glyph outlines cannot be read, such as for malformed fonts, is not drawn and reported by Err.
 func (c *Context) DrawText(x, y float64, text *Text) {
 	if text.Empty() {
 		return
+	} else if err := text.outlineErr(); err != nil {
+		c.setErr(err)
+		return
 	}
 
 	// get view
 	coord := c.coordView.Dot(Point{x, y})
-	m := c.coordSystemView().Mul(c.view).Translate(coord.X, coord.Y)
+	m := c.coordSystemView().Mul(c.unitView()).Translate(coord.X, coord.Y)
+
+	// font sizes are unaffected by the unit of length
+	if unit := float64(c.Unit()); unit != 1.0 {
+		m = m.Scale(1.0/unit, 1.0/unit)
+	}
 
 	// keep textbox origin at the top-left
 	if c.coordSystem == CartesianIII || c.coordSystem == CartesianIV {
@@ -661,7 +1093,30 @@ func (c *Context) DrawText(x, y float64, text *Text) {
 	if c.coordSystem == CartesianII || c.coordSystem == CartesianIII {
 		m = m.ReflectX()
 	}
-	c.RenderText(text, m)
+	if c.Style.CompositeMode != CompositeSrcOver || c.Style.Shadow != nil {
+		// text objects have no style, composite their paths instead
+		text.RenderAsPath(textStyleRenderer{c.Renderer, c.Style.CompositeMode, c.Style.Shadow}, m, DefaultResolution)
+		return
+	}
+	renderText(c.Renderer, text, m)
+}
+
+// DrawTextBox lays out the rich text within the rectangle and draws it, handling text that doesn't fit according to the overflow mode. It returns the number of bytes of the rich text that fitted, which allows continuing the remaining text in another box (or page) using RichText.Slice.
+func (c *Context) DrawTextBox(rect Rect, rt *RichText, halign, valign TextAlign, overflow TextOverflow) int {
+	// font sizes are unaffected by the unit of length
+	unit := float64(c.Unit())
+	text, n := rt.ToTextBox(rect.W*unit, rect.H*unit, halign, valign, 0.0, 0.0, overflow)
+
+	// position the textbox origin at the top-left of the rectangle
+	x, y := rect.X, rect.Y+rect.H
+	if c.coordSystem == CartesianII || c.coordSystem == CartesianIII {
+		x = rect.X + rect.W
+	}
+	if c.coordSystem == CartesianIII || c.coordSystem == CartesianIV {
+		y = rect.Y
+	}
+	c.DrawText(x, y, text)
+	return n
 }
 
 // DrawImage draws an image at position (x,y) using the current draw state and the given resolution in pixels-per-millimeter. A higher resolution will draw a smaller image (ie. more image pixels per millimeter of document).
@@ -669,13 +1124,15 @@ func (c *Context) DrawImage(x, y float64, img image.Image, resolution Resolution
 	if img.Bounds().Size().Eq(image.Point{}) {
 		return
 	}
+	img = c.orientImage(img)
 
 	// get view
 	coord := c.coordView.Dot(Point{x, y})
-	m := c.coordSystemView().Mul(c.view).Translate(coord.X, coord.Y)
+	m := c.coordSystemView().Mul(c.unitView()).Translate(coord.X, coord.Y)
 
-	// set resolution
-	m = m.Scale(1.0/resolution.DPMM(), 1.0/resolution.DPMM())
+	// set resolution, which is unaffected by the unit of length
+	dpu := resolution.DPMM() * float64(c.Unit())
+	m = m.Scale(1.0/dpu, 1.0/dpu)
 
 	// set origin of image closest to the image's origin (ie. top-left for CartesianIV)
 	if c.coordSystem == CartesianIII || c.coordSystem == CartesianIV {
@@ -684,7 +1141,91 @@ func (c *Context) DrawImage(x, y float64, img image.Image, resolution Resolution
 	if c.coordSystem == CartesianII || c.coordSystem == CartesianIII {
 		m = m.ReflectXAbout(float64(img.Bounds().Size().X) / 2.0)
 	}
-	c.RenderImage(img, m)
+	c.RenderImage(imageWithFilter(img, c.imageFilter), m)
+}
+
+// DrawImageQuad draws an image mapped onto an arbitrary quadrilateral using a perspective (projective) transformation, which is useful for mockups and texture placement. The points of the quadrilateral correspond to the image's top-left, top-right, bottom-right, and bottom-left corners respectively, irrespective of the coordinate system. Renderers that implement ImageQuadRenderer use projective interpolation, others draw an approximating mesh. Nothing is drawn for degenerate quadrilaterals, see IsDegenerateQuad.
+func (c *Context) DrawImageQuad(img image.Image, q [4]Point) {
+	if img.Bounds().Size().Eq(image.Point{}) {
+		return
+	}
+	img = c.orientImage(img)
+
+	m := c.coordSystemView().Mul(c.unitView())
+	for i := range q {
+		q[i] = m.Dot(c.coordView.Dot(q[i]))
+	}
+	renderImageQuad(c.Renderer, imageWithFilter(img, c.imageFilter), q)
+}
+
+// DrawClippedImage draws an image at position (x,y) like DrawImage, but only the part that is inside the clipping path. The clipping path is relative to (x,y), ie. the image's origin, which allows placing images inside arbitrary shapes such as circles or rounded rectangles. Clipping is applied to the image pixels and is thus supported by all renderers.
+func (c *Context) DrawClippedImage(x, y float64, img image.Image, resolution Resolution, clip *Path) {
+	if img.Bounds().Size().Eq(image.Point{}) {
+		return
+	}
+	img = c.orientImage(img)
+
+	// express the clipping path in millimeters with the origin at the bottom-left of the image
+	if unit := float64(c.Unit()); unit != 1.0 {
+		clip = clip.Transform(Identity.Scale(unit, unit))
+	}
+	size := img.Bounds().Size()
+	w, h := float64(size.X)/resolution.DPMM(), float64(size.Y)/resolution.DPMM()
+	if c.coordSystem == CartesianIII || c.coordSystem == CartesianIV {
+		clip = clip.Transform(Identity.ReflectYAbout(h / 2.0))
+	}
+	if c.coordSystem == CartesianII || c.coordSystem == CartesianIII {
+		clip = clip.Transform(Identity.ReflectXAbout(w / 2.0))
+	}
+
+	imageExif := c.imageExif
+	c.imageExif = false
+	c.DrawImage(x, y, ClipImage(img, clip, resolution), resolution)
+	c.imageExif = imageExif
+}
+
+// DrawImageNineSlice draws an image stretched over a rectangle using nine-slice scaling. The image is divided into nine parts by the insets in pixels from the left, top, right, and bottom of the image. The four corners are drawn unscaled at the given resolution, the edges are stretched in one direction, and the center is stretched in both directions to fill the rectangle. This is useful for drawing scalable frames and UI elements.
+func (c *Context) DrawImageNineSlice(rect Rect, img image.Image, left, top, right, bottom int, resolution Resolution) {
+	bounds := img.Bounds()
+	if bounds.Dx() < left+right || bounds.Dy() < top+bottom {
+		c.setErr(ErrInvalidNineSlice)
+		return
+	}
+
+	// image columns and rows in pixels, and the destination columns and rows in the unit of length
+	xs := [4]int{bounds.Min.X, bounds.Min.X + left, bounds.Max.X - right, bounds.Max.X}
+	ys := [4]int{bounds.Min.Y, bounds.Min.Y + top, bounds.Max.Y - bottom, bounds.Max.Y}
+	dpu := resolution.DPMM() * float64(c.Unit())
+	l, r := float64(left)/dpu, float64(right)/dpu
+	t, b := float64(top)/dpu, float64(bottom)/dpu
+	if rect.W < l+r {
+		f := rect.W / (l + r)
+		l, r = l*f, r*f
+	}
+	if rect.H < t+b {
+		f := rect.H / (t + b)
+		t, b = t*f, b*f
+	}
+	dxs := [4]float64{rect.X, rect.X + l, rect.X + rect.W - r, rect.X + rect.W}
+	dys := [4]float64{rect.Y + rect.H, rect.Y + rect.H - t, rect.Y + b, rect.Y}
+	if c.coordSystem == CartesianIII || c.coordSystem == CartesianIV {
+		// the first image row is at the top of the rectangle, ie. at the lowest Y coordinate
+		dys = [4]float64{rect.Y, rect.Y + t, rect.Y + rect.H - b, rect.Y + rect.H}
+	}
+	if c.coordSystem == CartesianII || c.coordSystem == CartesianIII {
+		dxs = [4]float64{rect.X + rect.W, rect.X + rect.W - l, rect.X + r, rect.X}
+	}
+
+	for j := 0; j < 3; j++ {
+		for i := 0; i < 3; i++ {
+			src := image.Rect(xs[i], ys[j], xs[i+1], ys[j+1])
+			dst := Rect{math.Min(dxs[i], dxs[i+1]), math.Min(dys[j], dys[j+1]), math.Abs(dxs[i+1] - dxs[i]), math.Abs(dys[j+1] - dys[j])}
+			if src.Empty() || Equal(dst.W, 0.0) || Equal(dst.H, 0.0) {
+				continue
+			}
+			c.FitImage(subImage(img, src), dst, ImageFill)
+		}
+	}
 }
 
 ////////////////////////////////////////////////////////////////
@@ -696,16 +1237,113 @@ type layer struct {
 	path *Path
 	text *Text
 	img  image.Image
+	quad *[4]Point // only for img, maps the image onto a quadrilateral
+
+	// begins a group with opacity or filter, or ends a group
+	beginGroup, endGroup bool
+	opacity              float64
+	filter               Filter
 
 	m     Matrix
 	style Style // only for path
 }
 
+// bounds returns the bounds of the drawing operation, including the stroke.
+func (l layer) bounds() Rect {
+	bounds := Rect{}
+	if l.path != nil {
+		// transform the path instead of its bounds to keep the bounds tight for rotations
+		bounds = l.path.Transform(l.m).Bounds()
+		if l.style.HasStroke() {
+			dx := l.style.StrokeWidth * math.Hypot(l.m[0][0], l.m[0][1])
+			dy := l.style.StrokeWidth * math.Hypot(l.m[1][0], l.m[1][1])
+			bounds.X -= dx / 2.0
+			bounds.Y -= dy / 2.0
+			bounds.W += dx
+			bounds.H += dy
+		}
+		return bounds
+	} else if l.text != nil {
+		bounds = l.text.Bounds()
+	} else if l.img != nil && l.quad != nil {
+		bounds = Rect{l.quad[0].X, l.quad[0].Y, 0.0, 0.0}
+		for _, p := range l.quad[1:] {
+			bounds = bounds.AddPoint(p)
+		}
+	} else if l.img != nil {
+		size := l.img.Bounds().Size()
+		bounds = Rect{0.0, 0.0, float64(size.X), float64(size.Y)}
+	}
+	return bounds.Transform(l.m)
+}
+
+// equals returns true if both layers draw the same. Texts are compared by identity.
+func (l layer) equals(q layer) bool {
+	if l.m != q.m || l.beginGroup != q.beginGroup || l.endGroup != q.endGroup || l.opacity != q.opacity || l.text != q.text {
+		return false
+	} else if (l.path == nil) != (q.path == nil) || l.path != nil && !l.path.Equals(q.path) {
+		return false
+	} else if (l.quad == nil) != (q.quad == nil) || l.quad != nil && *l.quad != *q.quad {
+		return false
+	}
+	return reflect.DeepEqual(l.img, q.img) && reflect.DeepEqual(l.style, q.style) && reflect.DeepEqual(l.filter, q.filter)
+}
+
+// Metadata is document-level metadata, which renderers embed where the output format supports it.
+type Metadata struct {
+	Title        string
+	Description  string
+	Creator      string // person or organization that created the content, i.e. the author
+	CreationDate time.Time
+	Language     string // BCP 47 language tag, e.g. en-US
+}
+
+// Empty returns true if no metadata is set.
+func (metadata Metadata) Empty() bool {
+	return metadata.Title == "" && metadata.Description == "" && metadata.Creator == "" && metadata.CreationDate.IsZero() && metadata.Language == ""
+}
+
+// RDF returns the metadata as an RDF element using Dublin Core and XMP properties, which can be embedded in SVG metadata or in XMP packets.
+func (metadata Metadata) RDF() string {
+	escape := func(s string) string {
+		sb := strings.Builder{}
+		xml.EscapeText(&sb, []byte(s))
+		return sb.String()
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString(`<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">`)
+	sb.WriteString(`<rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:xmp="http://ns.adobe.com/xap/1.0/">`)
+	if metadata.Title != "" {
+		fmt.Fprintf(&sb, `<dc:title><rdf:Alt><rdf:li xml:lang="x-default">%s</rdf:li></rdf:Alt></dc:title>`, escape(metadata.Title))
+	}
+	if metadata.Description != "" {
+		fmt.Fprintf(&sb, `<dc:description><rdf:Alt><rdf:li xml:lang="x-default">%s</rdf:li></rdf:Alt></dc:description>`, escape(metadata.Description))
+	}
+	if metadata.Creator != "" {
+		fmt.Fprintf(&sb, `<dc:creator><rdf:Seq><rdf:li>%s</rdf:li></rdf:Seq></dc:creator>`, escape(metadata.Creator))
+	}
+	if metadata.Language != "" {
+		fmt.Fprintf(&sb, `<dc:language><rdf:Bag><rdf:li>%s</rdf:li></rdf:Bag></dc:language>`, escape(metadata.Language))
+	}
+	if !metadata.CreationDate.IsZero() {
+		fmt.Fprintf(&sb, `<xmp:CreateDate>%s</xmp:CreateDate>`, metadata.CreationDate.Format(time.RFC3339))
+	}
+	sb.WriteString(`</rdf:Description></rdf:RDF>`)
+	return sb.String()
+}
+
 // Canvas stores all drawing operations as layers that can be re-rendered to other renderers.
 type Canvas struct {
 	layers map[int][]layer
 	zindex int
 	W, H   float64
+
+	// Metadata is embedded by renderers that implement MetadataRenderer.
+	Metadata Metadata
+
+	// TrimBox and BleedBox are the page boxes for print production, which are embedded by renderers that implement PageBoxRenderer. They are unset (zero) by default.
+	TrimBox, BleedBox Rect
 }
 
 // New returns a new canvas with width and height in millimeters, that records all drawing operations into layers. The canvas can then be rendered to any other renderer.
@@ -743,6 +1381,50 @@ func (c *Canvas) RenderImage(img image.Image, m Matrix) {
 	c.layers[c.zindex] = append(c.layers[c.zindex], layer{img: img, m: m})
 }
 
+// BeginGroup starts a group of drawing operations that is composited with the given opacity.
+func (c *Canvas) BeginGroup(opacity float64) {
+	c.layers[c.zindex] = append(c.layers[c.zindex], layer{beginGroup: true, opacity: opacity})
+}
+
+// EndGroup ends the last group.
+func (c *Canvas) EndGroup() {
+	c.layers[c.zindex] = append(c.layers[c.zindex], layer{endGroup: true})
+}
+
+// BeginFilter starts a group of drawing operations to which the filter is applied.
+func (c *Canvas) BeginFilter(filter Filter) {
+	c.layers[c.zindex] = append(c.layers[c.zindex], layer{beginGroup: true, opacity: 1.0, filter: filter})
+}
+
+// EndFilter ends the last group started with BeginFilter.
+func (c *Canvas) EndFilter() {
+	c.layers[c.zindex] = append(c.layers[c.zindex], layer{endGroup: true})
+}
+
+// RenderImageQuad renders an image to the canvas mapped onto a quadrilateral.
+func (c *Canvas) RenderImageQuad(img image.Image, q [4]Point) {
+	c.layers[c.zindex] = append(c.layers[c.zindex], layer{img: img, quad: &q, m: Identity})
+}
+
+// Capabilities returns that all constructs are supported, since they are recorded as-is and converted when rendering to another renderer.
+func (c *Canvas) Capabilities() Capabilities {
+	return Capabilities{
+		Gradients:      true,
+		GouraudShading: true,
+		Patterns:       true,
+		BlendModes:     true,
+		Shadows:        true,
+		Clipping:       true,
+		NativeText:     true,
+		Transparency:   true,
+	}
+}
+
+// SetMetadata sets the document metadata.
+func (c *Canvas) SetMetadata(metadata Metadata) {
+	c.Metadata = metadata
+}
+
 // Empty return true if the canvas is empty.
 func (c *Canvas) Empty() bool {
 	return len(c.layers) == 0
@@ -753,11 +1435,51 @@ func (c *Canvas) Reset() {
 	c.layers = map[int][]layer{}
 }
 
-// SetZIndex sets the z-index.
+// SetZIndex sets the z-index. Subsequent drawing operations are recorded into the layer of the given z-index, layers are rendered in ascending z-index order.
 func (c *Canvas) SetZIndex(zindex int) {
 	c.zindex = zindex
 }
 
+// ZIndex returns the current z-index.
+func (c *Canvas) ZIndex() int {
+	return c.zindex
+}
+
+// ZIndices returns the z-indices of all layers in ascending order.
+func (c *Canvas) ZIndices() []int {
+	zindices := make([]int, 0, len(c.layers))
+	for zindex := range c.layers {
+		zindices = append(zindices, zindex)
+	}
+	sort.Ints(zindices)
+	return zindices
+}
+
+// MoveZIndex moves all drawing operations of the layer at z-index from to z-index to. If the destination layer is not empty, the moved drawing operations are drawn on top of the existing ones.
+func (c *Canvas) MoveZIndex(from, to int) {
+	if from == to {
+		return
+	} else if layers, ok := c.layers[from]; ok {
+		c.layers[to] = append(c.layers[to], layers...)
+		delete(c.layers, from)
+	}
+}
+
+// InsertZIndex inserts an empty layer at the given z-index by incrementing the z-index of that layer and all layers above it. The current z-index is shifted as well if it is affected.
+func (c *Canvas) InsertZIndex(zindex int) {
+	layers := make(map[int][]layer, len(c.layers))
+	for z, l := range c.layers {
+		if zindex <= z {
+			z++
+		}
+		layers[z] = l
+	}
+	c.layers = layers
+	if zindex <= c.zindex {
+		c.zindex++
+	}
+}
+
 // Transform transforms the canvas.
 func (c *Canvas) Transform(m Matrix) {
 	for _, layers := range c.layers {
@@ -780,23 +1502,7 @@ func (c *Canvas) Fit(margin float64) {
 	// TODO: slow when we have many paths (see Graph example)
 	for _, layers := range c.layers {
 		for _, l := range layers {
-			bounds := Rect{}
-			if l.path != nil {
-				bounds = l.path.Bounds()
-				if l.style.HasStroke() {
-					bounds.X -= l.style.StrokeWidth / 2.0
-					bounds.Y -= l.style.StrokeWidth / 2.0
-					bounds.W += l.style.StrokeWidth
-					bounds.H += l.style.StrokeWidth
-				}
-			} else if l.text != nil {
-				bounds = l.text.Bounds()
-			} else if l.img != nil {
-				size := l.img.Bounds().Size()
-				bounds = Rect{0.0, 0.0, float64(size.X), float64(size.Y)}
-			}
-			bounds = bounds.Transform(l.m)
-			rect = rect.Add(bounds)
+			rect = rect.Add(l.bounds())
 		}
 	}
 	rect.X -= margin
@@ -806,6 +1512,153 @@ func (c *Canvas) Fit(margin float64) {
 	c.Clip(rect)
 }
 
+// Crop returns a new canvas of the size of the rectangle with only the drawing operations that overlap the rectangle, where the bottom-left of the rectangle becomes the origin. This is useful to split large drawings into tiles or pages. Paths that cross the border of the rectangle are clipped using the path boolean operations, where their stroke is converted into a filled path. Text that crosses the border is converted to paths and clipped as well, while images are kept whole.
+func (c *Canvas) Crop(rect Rect) *Canvas {
+	crop := New(rect.W, rect.H)
+	crop.Metadata = c.Metadata
+	crop.zindex = c.zindex
+
+	view := Identity.Translate(-rect.X, -rect.Y)
+	for zindex, layers := range c.layers {
+		cropped := []layer{}
+		for _, l := range layers {
+			if l.beginGroup || l.endGroup {
+				cropped = append(cropped, l)
+				continue
+			}
+
+			bounds := l.bounds()
+			if !bounds.Overlaps(rect) {
+				continue
+			} else if l.img != nil || rect.X <= bounds.X && bounds.X+bounds.W <= rect.X+rect.W && rect.Y <= bounds.Y && bounds.Y+bounds.H <= rect.Y+rect.H {
+				l.m = view.Mul(l.m)
+				cropped = append(cropped, l)
+			} else if l.path != nil {
+				cropped = append(cropped, cropPath(l, rect, view)...)
+			} else if l.text != nil {
+				paths := New(c.W, c.H)
+				l.text.RenderAsPath(paths, l.m, 0.0)
+				paths = paths.Crop(rect)
+				for _, zindex := range paths.ZIndices() {
+					cropped = append(cropped, paths.layers[zindex]...)
+				}
+			}
+		}
+		if 0 < len(cropped) {
+			crop.layers[zindex] = cropped
+		}
+	}
+	return crop
+}
+
+// cropPath returns the layers that draw the fill and stroke of a path layer clipped by the rectangle, and transformed by the view.
+func cropPath(l layer, rect Rect, view Matrix) []layer {
+	return clipPath(l, rect.ToPath(), view)
+}
+
+// clipLayer returns the layers that draw a layer clipped by the clipping path, and transformed by the view, see CanvasPattern.Tile. The clipping path is in the coordinates of the layer's canvas.
+func clipLayer(l layer, clip *Path, view Matrix) []layer {
+	if l.beginGroup || l.endGroup {
+		return []layer{l}
+	} else if l.path != nil {
+		return clipPath(l, clip, view)
+	} else if l.text != nil {
+		paths := New(0.0, 0.0)
+		l.text.RenderAsPath(paths, l.m, 0.0)
+		layers := []layer{}
+		for _, zindex := range paths.ZIndices() {
+			for _, pl := range paths.layers[zindex] {
+				layers = append(layers, clipPath(pl, clip, view)...)
+			}
+		}
+		return layers
+	} else if l.img != nil && l.quad == nil && !Equal(l.m.Det(), 0.0) {
+		l.img = ClipImage(l.img, clip.Transform(l.m.Inv()), DPMM(1.0))
+	}
+	l.m = view.Mul(l.m)
+	return []layer{l}
+}
+
+// clipPath returns the layers that draw the fill and stroke of a path layer clipped by the clipping path, and transformed by the view. The clipping path is in the coordinates of the layer's canvas.
+func clipPath(l layer, clip *Path, view Matrix) []layer {
+	if Equal(l.m.Det(), 0.0) {
+		return nil
+	}
+	clip = clip.Transform(l.m.Inv())
+
+	layers := []layer{}
+	if l.style.HasFill() {
+		fill := l.path
+		if l.style.FillRule != NonZero {
+			fill = fill.Settle(l.style.FillRule)
+		}
+		if fill = fill.And(clip); !fill.Empty() {
+			style := l.style
+			style.Stroke = Paint{}
+			style.FillRule = NonZero
+			layers = append(layers, layer{path: fill, m: view.Mul(l.m), style: style})
+		}
+	}
+	if l.style.HasStroke() {
+		stroke := l.path
+		if l.style.IsDashed() {
+			stroke = stroke.Dash(l.style.DashOffset, l.style.Dashes...)
+		}
+		stroke = stroke.Stroke(l.style.StrokeWidth, l.style.StrokeCapper, l.style.StrokeJoiner, Tolerance)
+		if stroke = stroke.And(clip); !stroke.Empty() {
+			style := l.style
+			style.Fill = l.style.Stroke
+			style.Stroke = Paint{}
+			style.Dashes = nil
+			style.FillRule = NonZero
+			layers = append(layers, layer{path: stroke, m: view.Mul(l.m), style: style})
+		}
+	}
+	return layers
+}
+
+// Changes returns the regions of the canvas in millimeters that differ from the previous canvas, such as the previous frame of an animation or interactive drawing. Drawing operations are compared in order per z-index, and both the old and new bounds of changed drawing operations are returned. When the size of the canvas or a group has changed, or when prev is nil, the whole canvas is returned. See rasterizer.DrawChanges to redraw only the changed regions of an image.
+func (c *Canvas) Changes(prev *Canvas) []Rect {
+	all := []Rect{{0.0, 0.0, c.W, c.H}}
+	if prev == nil || c.W != prev.W || c.H != prev.H {
+		return all
+	}
+
+	zindices := c.ZIndices()
+	for _, zindex := range prev.ZIndices() {
+		if _, ok := c.layers[zindex]; !ok {
+			zindices = append(zindices, zindex)
+		}
+	}
+
+	rects := []Rect{}
+	for _, zindex := range zindices {
+		layers, prevLayers := c.layers[zindex], prev.layers[zindex]
+		for i := 0; i < len(layers) || i < len(prevLayers); i++ {
+			var l, prevL *layer
+			if i < len(layers) {
+				l = &layers[i]
+			}
+			if i < len(prevLayers) {
+				prevL = &prevLayers[i]
+			}
+			if l != nil && prevL != nil && l.equals(*prevL) {
+				continue
+			}
+			for _, l := range []*layer{l, prevL} {
+				if l == nil {
+					continue
+				} else if l.beginGroup || l.endGroup {
+					return all
+				} else if bounds := l.bounds(); bounds.W != 0.0 && bounds.H != 0.0 {
+					rects = append(rects, bounds)
+				}
+			}
+		}
+	}
+	return rects
+}
+
 // RenderTo renders the accumulated canvas drawing operations to another renderer.
 func (c *Canvas) RenderTo(r Renderer) {
 	c.RenderViewTo(r, Identity)
@@ -813,19 +1666,52 @@ func (c *Canvas) RenderTo(r Renderer) {
 
 // RenderViewTo transforms and renders the accumulated canvas drawing operations to another renderer.
 func (c *Canvas) RenderViewTo(r Renderer, view Matrix) {
-	zindices := []int{}
-	for zindex := range c.layers {
-		zindices = append(zindices, zindex)
+	if mr, ok := r.(MetadataRenderer); ok && !c.Metadata.Empty() {
+		mr.SetMetadata(c.Metadata)
+	}
+	if pr, ok := r.(PageBoxRenderer); ok && (c.TrimBox != Rect{} || c.BleedBox != Rect{}) {
+		pr.SetPageBoxes(c.TrimBox.Transform(view), c.BleedBox.Transform(view))
 	}
-	sort.Ints(zindices)
 
-	for _, zindex := range zindices {
+	groups := []Renderer{}
+	filters := []bool{} // whether each group is a filter
+	for _, zindex := range c.ZIndices() {
 		for _, l := range c.layers[zindex] {
 			m := view.Mul(l.m)
-			if l.path != nil {
-				r.RenderPath(l.path, l.style, m)
+			if l.beginGroup {
+				groups = append(groups, r)
+				filters = append(filters, l.filter != nil)
+				if l.filter != nil {
+					if fr, ok := r.(FilterRenderer); ok {
+						fr.BeginFilter(l.filter)
+					}
+				} else if gr, ok := r.(GroupRenderer); ok {
+					gr.BeginGroup(l.opacity)
+				} else {
+					r = opacityRenderer{r, l.opacity}
+				}
+			} else if l.endGroup && 0 < len(groups) {
+				r = groups[len(groups)-1]
+				groups = groups[:len(groups)-1]
+				filter := filters[len(filters)-1]
+				filters = filters[:len(filters)-1]
+				if filter {
+					if fr, ok := r.(FilterRenderer); ok {
+						fr.EndFilter()
+					}
+				} else if gr, ok := r.(GroupRenderer); ok {
+					gr.EndGroup()
+				}
+			} else if l.path != nil {
+				renderPath(r, l.path, l.style, m)
 			} else if l.text != nil {
-				r.RenderText(l.text, m)
+				renderText(r, l.text, m)
+			} else if l.img != nil && l.quad != nil {
+				q := *l.quad
+				for i := range q {
+					q[i] = m.Dot(q[i])
+				}
+				renderImageQuad(r, l.img, q)
 			} else if l.img != nil {
 				r.RenderImage(l.img, m)
 			}
@@ -1,8 +1,13 @@
 package canvas
 
 import (
+	"fmt"
 	"image"
+	"image/color"
+	"image/draw"
+	"math"
 	"testing"
+	"time"
 
 	"github.com/tdewolff/test"
 )
@@ -72,3 +77,718 @@ func TestCanvasFit(t *testing.T) {
 	test.Float(t, c.W, 20)
 	test.Float(t, c.H, 20)
 }
+
+func TestCanvasFitRotated(t *testing.T) {
+	// the bounds of a rotated circle equal the bounds of the circle
+	c := New(100, 100)
+	ctx := NewContext(c)
+	ctx.SetStrokeColor(Transparent)
+	ctx.ComposeView(Identity.Rotate(45.0).Scale(2.0, 2.0))
+	ctx.DrawPath(0.0, 0.0, Circle(5.0))
+	c.Fit(0.0)
+	test.Float(t, c.W, 20.0)
+	test.Float(t, c.H, 20.0)
+
+	c = New(100, 100)
+	ctx = NewContext(c)
+	ctx.ComposeView(Identity.Rotate(45.0).Scale(2.0, 2.0))
+	ctx.SetStrokeColor(Black)
+	ctx.SetStrokeWidth(1.0)
+	ctx.DrawPath(0.0, 0.0, Circle(5.0))
+	c.Fit(0.0)
+	test.Float(t, c.W, 22.0)
+	test.Float(t, c.H, 22.0)
+}
+
+func TestCanvasZIndex(t *testing.T) {
+	c := New(100, 100)
+	ctx := NewContext(c)
+	ctx.DrawPath(0.0, 0.0, Rectangle(1.0, 1.0))
+	ctx.SetZIndex(2)
+	ctx.DrawPath(0.0, 0.0, Rectangle(2.0, 2.0))
+	ctx.SetZIndex(-1)
+	ctx.DrawPath(0.0, 0.0, Rectangle(3.0, 3.0))
+	test.T(t, c.ZIndices(), []int{-1, 0, 2})
+	test.T(t, ctx.ZIndex(), -1)
+
+	c.InsertZIndex(0)
+	test.T(t, c.ZIndices(), []int{-1, 1, 3})
+	test.T(t, c.ZIndex(), -1)
+
+	c.MoveZIndex(-1, 3)
+	test.T(t, c.ZIndices(), []int{1, 3})
+	test.T(t, len(c.layers[3]), 2)
+	test.T(t, c.layers[3][1].path, Rectangle(3.0, 3.0))
+}
+
+func TestCanvasCrop(t *testing.T) {
+	c := New(100, 100)
+	ctx := NewContext(c)
+	ctx.DrawPath(10.0, 10.0, Rectangle(5.0, 5.0))
+	ctx.DrawPath(20.0, 20.0, Rectangle(10.0, 10.0))
+	ctx.DrawPath(50.0, 50.0, Rectangle(5.0, 5.0))
+
+	crop := c.Crop(Rect{8.0, 8.0, 20.0, 20.0})
+	test.Float(t, crop.W, 20.0)
+	test.Float(t, crop.H, 20.0)
+	test.T(t, len(crop.layers[0]), 2)
+	test.T(t, crop.layers[0][0].m, Identity.Translate(2.0, 2.0))
+	test.T(t, crop.layers[0][0].path, Rectangle(5.0, 5.0))
+
+	bounds := crop.layers[0][1].bounds()
+	test.Float(t, bounds.X, 12.0)
+	test.Float(t, bounds.Y, 12.0)
+	test.Float(t, bounds.W, 8.0)
+	test.Float(t, bounds.H, 8.0)
+}
+
+func TestCanvasChanges(t *testing.T) {
+	frame := func(x float64) *Canvas {
+		c := New(100, 100)
+		ctx := NewContext(c)
+		ctx.DrawPath(10.0, 10.0, Rectangle(5.0, 5.0))
+		ctx.DrawPath(x, 50.0, Rectangle(5.0, 5.0))
+		return c
+	}
+
+	prev := frame(20.0)
+	test.T(t, frame(20.0).Changes(prev), []Rect{})
+	test.T(t, frame(30.0).Changes(prev), []Rect{{30.0, 50.0, 5.0, 5.0}, {20.0, 50.0, 5.0, 5.0}})
+
+	c := frame(20.0)
+	NewContext(c).DrawPath(0.0, 0.0, Rectangle(1.0, 1.0))
+	test.T(t, c.Changes(prev), []Rect{{0.0, 0.0, 1.0, 1.0}})
+	test.T(t, New(50, 50).Changes(prev), []Rect{{0.0, 0.0, 50.0, 50.0}})
+	test.T(t, prev.Changes(nil), []Rect{{0.0, 0.0, 100.0, 100.0}})
+}
+
+func TestContextNonScalingStroke(t *testing.T) {
+	c := New(100, 100)
+	ctx := NewContext(c)
+	ctx.SetStrokeColor(Black)
+	ctx.SetStrokeWidth(1.0)
+	ctx.Scale(2.0, 2.0)
+	ctx.DrawPath(1.0, 0.0, Line(10.0, 0.0))
+	test.T(t, c.layers[0][0].m, Identity.Scale(2.0, 2.0).Translate(1.0, 0.0))
+
+	ctx.SetNonScalingStroke(true)
+	ctx.DrawPath(1.0, 0.0, Line(10.0, 0.0))
+	test.T(t, c.layers[0][1].m, Identity)
+	test.T(t, c.layers[0][1].path, MustParseSVGPath("M2 0L22 0"))
+	test.T(t, c.layers[0][1].style.StrokeWidth, 1.0)
+
+	// dashes are in output units and are checked against the transformed path
+	c = New(100, 100)
+	ctx = NewContext(c)
+	ctx.SetUnit(Cm)
+	ctx.SetFillColor(Transparent)
+	ctx.SetStrokeColor(Black)
+	ctx.SetStrokeWidth(0.1)
+	ctx.SetNonScalingStroke(true)
+	ctx.Scale(2.0, 2.0)
+	ctx.SetDashes(0.1, 0.2, 0.3)
+	ctx.DrawPath(1.0, 0.0, Line(1.0, 0.0))
+	test.T(t, c.layers[0][0].path, MustParseSVGPath("M20 0L40 0"))
+	test.T(t, c.layers[0][0].style.StrokeWidth, 1.0)
+	test.T(t, c.layers[0][0].style.DashOffset, 1.0)
+	test.T(t, c.layers[0][0].style.Dashes, []float64{2.0, 3.0})
+
+	// dashes longer than the transformed path draw a solid line
+	ctx.SetDashes(0.0, 5.0, 1.0)
+	ctx.DrawPath(1.0, 0.0, Line(1.0, 0.0))
+	test.T(t, len(c.layers[0][1].style.Dashes), 0)
+
+	ctx.SetDashes(0.0, 0.4, 0.6)
+	ctx.SetDashFit(true)
+	ctx.DrawPath(1.0, 0.0, Line(1.0, 0.0))
+	test.T(t, c.layers[0][2].m, Identity)
+	test.T(t, c.layers[0][2].path, MustParseSVGPath("M20 0L23.333333333333 0M28.333333333333 0L31.666666666667 0M36.666666666667 0L40 0"))
+}
+
+func TestContextPathGradient(t *testing.T) {
+	gradient := NewPathGradient()
+	gradient.Add(0.0, Red)
+	gradient.Add(0.5, Lime)
+	gradient.Add(1.0, Blue)
+
+	c := New(100, 100)
+	ctx := NewContext(c)
+	ctx.SetFillColor(Black)
+	ctx.SetStrokeGradient(gradient)
+	ctx.SetStrokeWidth(2.0)
+	ctx.DrawPath(0.0, 0.0, MustParseSVGPath("M0 0L32 0M0 10L32 10"))
+
+	layers := c.layers[0]
+	test.T(t, len(layers), 1+pathGradientPieces)
+	test.T(t, layers[0].style.Fill, Paint{Color: Black})
+	test.T(t, layers[0].style.Stroke, Paint{})
+	test.T(t, layers[1].path.Bounds(), Rect{0.0, -1.0, 1.0, 2.0})
+	test.T(t, layers[1].style.Fill.Gradient.(*LinearGradient).Stops, Stops{{0.0, Red}, {1.0, gradient.Stops.At(1.0 / 64.0)}})
+	test.T(t, layers[32].style.Fill.Gradient.(*LinearGradient).Stops[1].Color, Lime)
+	test.T(t, layers[33].path.Bounds(), Rect{0.0, 9.0, 1.0, 2.0})
+	test.T(t, layers[64].style.Fill.Gradient.(*LinearGradient).Stops[1].Color, Blue)
+
+	// fills use the start color
+	c = New(100, 100)
+	ctx = NewContext(c)
+	ctx.SetFillGradient(gradient)
+	ctx.DrawPath(0.0, 0.0, Rectangle(10.0, 10.0))
+	test.T(t, c.layers[0][0].style.Fill, Paint{Color: Red})
+}
+
+func TestGradientSpread(t *testing.T) {
+	gradient := NewLinearGradient(Point{0.0, 0.0}, Point{10.0, 0.0})
+	gradient.Add(0.0, Red)
+	gradient.Add(1.0, Blue)
+	test.T(t, gradient.At(-5.0, 0.0), Red)
+	test.T(t, gradient.At(15.0, 0.0), Blue)
+
+	gradient.Spread = RepeatSpread
+	test.T(t, gradient.At(12.5, 0.0), gradient.At(2.5, 0.0))
+	test.T(t, gradient.At(-7.5, 0.0), gradient.At(2.5, 0.0))
+
+	gradient.Spread = ReflectSpread
+	test.T(t, gradient.At(12.5, 0.0), gradient.At(7.5, 0.0))
+	test.T(t, gradient.At(-2.5, 0.0), gradient.At(2.5, 0.0))
+	test.T(t, gradient.At(22.5, 0.0), gradient.At(2.5, 0.0))
+
+	radial := NewRadialGradient(Point{0.0, 0.0}, 0.0, Point{0.0, 0.0}, 10.0)
+	radial.Add(0.0, Red)
+	radial.Add(1.0, Blue)
+	radial.Spread = RepeatSpread
+	test.T(t, radial.At(12.5, 0.0), radial.At(2.5, 0.0))
+	test.T(t, radial.SetView(Identity.Scale(2.0, 2.0)).At(5.0, 0.0), radial.At(2.5, 0.0))
+	test.T(t, ReflectSpread.String(), "Reflect")
+}
+
+func TestGouraudGradient(t *testing.T) {
+	vertices := []Point{{0.0, 0.0}, {10.0, 0.0}, {10.0, 10.0}, {0.0, 10.0}}
+	colors := []color.RGBA{Red, Lime, Blue, Black}
+	triangles := [][3]int{{0, 1, 2}, {0, 3, 2}}
+	gradient := NewGouraudGradient(vertices, colors, triangles)
+	test.T(t, gradient.Path(), MustParseSVGPath("M0 0L10 0L10 10zM0 0L10 10L0 10z"))
+	test.T(t, gradient.At(0.0, 0.0), Red)
+	test.T(t, gradient.At(10.0, 10.0), Blue)
+	test.T(t, gradient.At(5.0, 0.0), color.RGBA{128, 128, 0, 255})
+	test.T(t, gradient.At(0.0, 5.0), color.RGBA{128, 0, 0, 255})
+	test.T(t, gradient.At(20.0, 5.0), Transparent)
+	test.T(t, gradient.SetView(Identity.Translate(10.0, 0.0)).At(10.0, 0.0), Red)
+
+	gradient = &GouraudGradient{Vertices: vertices, Colors: colors, Triangles: triangles}
+	test.T(t, gradient.At(5.0, 0.0), color.RGBA{128, 128, 0, 255})
+}
+
+func TestCoonsGradient(t *testing.T) {
+	patch := NewCoonsPatch(Point{0.0, 0.0}, Point{10.0, 0.0}, Point{10.0, 10.0}, Point{0.0, 10.0}, Red, Lime, Blue, Black)
+	test.T(t, patch.Points[4], Point{10.0, 10.0 / 3.0})
+	test.T(t, patch.Pos(0.5, 0.5), Point{5.0, 5.0})
+	test.T(t, patch.Color(0.5, 0.0), color.RGBA{128, 128, 0, 255})
+
+	gradient := NewCoonsGradient([]CoonsPatch{patch})
+	test.T(t, gradient.Path().Bounds(), Rect{0.0, 0.0, 10.0, 10.0})
+	test.T(t, len(gradient.Mesh(2).Vertices), 9)
+	test.T(t, len(gradient.Mesh(2).Triangles), 8)
+	test.T(t, gradient.At(0.0, 0.0), Red)
+	test.T(t, gradient.At(10.0, 10.0), Blue)
+	test.T(t, gradient.At(5.0, 0.0), color.RGBA{128, 128, 0, 255})
+	test.T(t, gradient.At(5.0, -2.0), Transparent)
+	test.T(t, gradient.SetView(Identity.Translate(10.0, 0.0)).At(10.0, 0.0), Red)
+
+	// bulge the first side outwards
+	patch.Points[1].Y, patch.Points[2].Y = -3.0, -3.0
+	gradient = NewCoonsGradient([]CoonsPatch{patch})
+	test.That(t, gradient.At(5.0, -2.0) != Transparent)
+	test.T(t, gradient.At(5.0, -3.0), Transparent)
+}
+
+func TestCompositeMode(t *testing.T) {
+	test.String(t, CompositeColorDodge.String(), "ColorDodge")
+	test.String(t, CompositeMode(-1).String(), "Invalid(-1)")
+	test.That(t, CompositeMultiply.IsBlend())
+	test.That(t, !CompositeXor.IsBlend())
+
+	halfRed := color.RGBA{128, 0, 0, 128}
+	test.T(t, CompositeSrcOver.Composite(halfRed, Blue), color.RGBA{128, 0, 127, 255})
+	test.T(t, CompositeClear.Composite(Red, Blue), Transparent)
+	test.T(t, CompositeSrc.Composite(halfRed, Blue), halfRed)
+	test.T(t, CompositeDst.Composite(halfRed, Blue), Blue)
+	test.T(t, CompositeSrcIn.Composite(Red, Blue), Red)
+	test.T(t, CompositeSrcIn.Composite(Red, Transparent), Transparent)
+	test.T(t, CompositeDstOut.Composite(Red, Blue), Transparent)
+	test.T(t, CompositeXor.Composite(Red, Transparent), Red)
+	test.T(t, CompositeXor.Composite(Red, Blue), Transparent)
+	test.T(t, CompositeMultiply.Composite(Red, White), Red)
+	test.T(t, CompositeMultiply.Composite(Red, Blue), Black)
+	test.T(t, CompositeMultiply.Composite(Red, Transparent), Red)
+	test.T(t, CompositeScreen.Composite(Red, Blue), Magenta)
+	test.T(t, CompositeDifference.Composite(White, Red), Cyan)
+	test.T(t, CompositeDarken.Composite(Gray, White), Gray)
+
+	c := New(100.0, 100.0)
+	ctx := NewContext(c)
+	ctx.SetCompositeMode(CompositeMultiply)
+	ctx.DrawPath(0.0, 0.0, Rectangle(10.0, 10.0))
+	test.T(t, c.layers[0][0].style.CompositeMode, CompositeMultiply)
+}
+
+func TestShadow(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 5, 5))
+	img.SetRGBA(2, 2, White)
+	blurred := GaussianBlur(img, 1.0)
+	test.That(t, 0 < blurred.RGBAAt(2, 2).A && blurred.RGBAAt(2, 2).A < 255)
+	test.That(t, blurred.RGBAAt(1, 2).A < blurred.RGBAAt(2, 2).A)
+	test.T(t, blurred.RGBAAt(1, 2), blurred.RGBAAt(3, 2))
+	test.T(t, GaussianBlur(img, 0.0).RGBAAt(2, 2), White)
+
+	shadow := Shadow{Point{2.0, -2.0}, 0.0, Black}
+	shadowImg, m := shadow.Image(Rectangle(10.0, 10.0), DefaultStyle, Identity, 1.0)
+	test.T(t, shadowImg.Bounds(), image.Rect(0, 0, 10, 10))
+	test.T(t, m, Identity.Translate(2.0, -2.0))
+	test.T(t, shadowImg.At(5, 5), Black)
+
+	shadow.Blur = 1.0
+	shadowImg, m = shadow.Image(Rectangle(10.0, 10.0), DefaultStyle, Identity, 1.0)
+	test.T(t, shadowImg.Bounds(), image.Rect(0, 0, 16, 16))
+	test.T(t, m, Identity.Translate(-1.0, -5.0))
+
+	c := New(100.0, 100.0)
+	ctx := NewContext(c)
+	ctx.SetShadow(2.0, -2.0, 1.0, Black)
+	ctx.DrawPath(0.0, 0.0, Rectangle(10.0, 10.0))
+	ctx.SetShadow(0.0, 0.0, 0.0, Transparent)
+	ctx.DrawPath(0.0, 0.0, Rectangle(10.0, 10.0))
+	test.T(t, *c.layers[0][0].style.Shadow, shadow)
+	test.That(t, c.layers[0][1].style.Shadow == nil)
+
+	// renderers without shadow support receive a blurred image
+	c2 := New(100.0, 100.0)
+	c.RenderTo(struct{ Renderer }{c2})
+	test.T(t, len(c2.layers[0]), 3)
+	test.That(t, c2.layers[0][0].img != nil)
+	test.That(t, c2.layers[0][1].style.Shadow == nil)
+}
+
+func TestFilter(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 5, 5))
+	img.SetRGBA(2, 2, color.RGBA{255, 0, 0, 255})
+
+	blurred := NewBlurFilter(1.0).Apply(img, 1.0)
+	test.That(t, 0 < blurred.RGBAAt(1, 2).A && blurred.RGBAAt(2, 2).A < 255)
+
+	gray := NewSaturateFilter(0.0).Apply(img, 1.0).RGBAAt(2, 2)
+	test.T(t, gray, color.RGBA{54, 54, 54, 255})
+	test.T(t, NewSaturateFilter(1.0).Apply(img, 1.0).RGBAAt(2, 2), color.RGBA{255, 0, 0, 255})
+	test.T(t, NewColorMatrixFilter([4][5]float64{{0, 0, 0, 0, 0}, {0, 0, 0, 0, 0}, {0, 0, 0, 0, 0}, {0, 0, 0, 0.5, 0}}).Apply(img, 1.0).RGBAAt(2, 2), color.RGBA{0, 0, 0, 128})
+
+	// the kernel is rotated, so that a weight on the left moves the pixel to the left
+	shifted := NewConvolveFilter(3, []float64{0, 0, 0, 1, 0, 0, 0, 0, 0}).Apply(img, 1.0)
+	test.T(t, shifted.RGBAAt(1, 2), color.RGBA{255, 0, 0, 255})
+	test.T(t, shifted.RGBAAt(2, 2), color.RGBA{})
+	test.T(t, NewConvolveFilter(2, []float64{1}).Apply(img, 1.0).RGBAAt(2, 2), color.RGBA{255, 0, 0, 255})
+
+	dilated := NewDilateFilter(1.0).Apply(img, 1.0)
+	test.T(t, dilated.RGBAAt(1, 1), color.RGBA{255, 0, 0, 255})
+	test.T(t, dilated.RGBAAt(0, 2), color.RGBA{})
+	test.T(t, NewErodeFilter(1.0).Apply(dilated, 1.0).RGBAAt(2, 2), color.RGBA{255, 0, 0, 255})
+	test.T(t, NewErodeFilter(1.0).Apply(dilated, 1.0).RGBAAt(1, 1), color.RGBA{})
+
+	c := New(100.0, 100.0)
+	ctx := NewContext(c)
+	ctx.BeginFilter(NewBlurFilter(1.0))
+	ctx.DrawPath(0.0, 0.0, Rectangle(10.0, 10.0))
+	ctx.EndFilter()
+	ctx.EndFilter()
+	test.T(t, len(c.layers[0]), 3)
+	test.That(t, c.layers[0][0].beginGroup)
+	test.T(t, c.layers[0][0].filter, Filter(NewBlurFilter(1.0)))
+	test.That(t, c.layers[0][2].endGroup)
+
+	// renderers without filter support receive the drawing operations without the filter
+	c2 := New(100.0, 100.0)
+	c.RenderTo(struct{ Renderer }{c2})
+	test.T(t, len(c2.layers[0]), 1)
+	test.That(t, c2.layers[0][0].path != nil)
+}
+
+func TestContextImageFilter(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+
+	c := New(100, 100)
+	ctx := NewContext(c)
+	ctx.DrawImage(0.0, 0.0, img, 1.0)
+	ctx.SetImageFilter(NearestNeighbor)
+	ctx.DrawImage(0.0, 0.0, img, 1.0)
+	test.T(t, ImageFilterOf(c.layers[0][0].img), DefaultImageFilter)
+	test.T(t, ImageFilterOf(c.layers[0][1].img), NearestNeighbor)
+
+	// the default filter of the context keeps the filter of the image
+	ctx.SetImageFilter(DefaultImageFilter)
+	ctx.DrawImage(0.0, 0.0, Image{Image: img, Filter: NearestNeighbor}, 1.0)
+	test.T(t, ImageFilterOf(c.layers[0][2].img), NearestNeighbor)
+	ctx.SetImageFilter(Bilinear)
+	ctx.DrawImage(0.0, 0.0, Image{Image: img, Filter: NearestNeighbor}, 1.0)
+	test.T(t, ImageFilterOf(c.layers[0][3].img), Bilinear)
+}
+
+func TestContextDrawImageNineSlice(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+
+	c := New(100, 100)
+	ctx := NewContext(c)
+	ctx.DrawImageNineSlice(Rect{10.0, 10.0, 20.0, 10.0}, img, 1, 1, 1, 1, 1.0)
+	test.T(t, len(c.layers[0]), 9)
+
+	bounds := Rect{}
+	for i, l := range c.layers[0] {
+		size := l.img.Bounds().Size()
+		rect := Rect{0.0, 0.0, float64(size.X), float64(size.Y)}.Transform(l.m)
+		if i == 0 {
+			test.T(t, rect, Rect{10.0, 19.0, 1.0, 1.0}) // top-left corner is unscaled
+			bounds = rect
+		} else {
+			bounds = bounds.Add(rect)
+		}
+	}
+	test.T(t, bounds, Rect{10.0, 10.0, 20.0, 10.0})
+}
+
+func TestContextDrawClippedImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{255, 0, 0, 255}), image.Point{}, draw.Src)
+
+	c := New(100, 100)
+	ctx := NewContext(c)
+	ctx.DrawClippedImage(10.0, 10.0, img, 1.0, Rectangle(5.0, 10.0)) // left half
+	test.T(t, len(c.layers[0]), 1)
+
+	clipped := c.layers[0][0].img
+	_, _, _, a := clipped.At(2, 5).RGBA()
+	test.T(t, a, uint32(0xffff))
+	_, _, _, a = clipped.At(7, 5).RGBA()
+	test.T(t, a, uint32(0))
+}
+
+func TestOrientImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	img.Set(0, 0, color.RGBA{255, 0, 0, 255}) // top-left is red
+
+	var tests = []struct {
+		orientation int
+		size        image.Point
+		red         image.Point
+	}{
+		{1, image.Point{3, 2}, image.Point{0, 0}},
+		{2, image.Point{3, 2}, image.Point{2, 0}},
+		{3, image.Point{3, 2}, image.Point{2, 1}},
+		{4, image.Point{3, 2}, image.Point{0, 1}},
+		{5, image.Point{2, 3}, image.Point{0, 0}},
+		{6, image.Point{2, 3}, image.Point{1, 0}},
+		{7, image.Point{2, 3}, image.Point{1, 2}},
+		{8, image.Point{2, 3}, image.Point{0, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.orientation), func(t *testing.T) {
+			oriented := OrientImage(img, tt.orientation)
+			test.T(t, oriented.Bounds().Size(), tt.size)
+			r, _, _, _ := oriented.At(tt.red.X, tt.red.Y).RGBA()
+			test.T(t, r, uint32(0xffff))
+		})
+	}
+}
+
+func TestJPEGExifOrientation(t *testing.T) {
+	exif := []byte("Exif\x00\x00MM\x00\x2A\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00\x06\x00\x00\x00\x00\x00\x00")
+	b := []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, byte(len(exif) + 2)}
+	b = append(b, exif...)
+	b = append(b, 0xFF, 0xD9)
+	test.T(t, jpegExifOrientation(b), 6)
+	test.T(t, jpegExifOrientation([]byte{0xFF, 0xD8, 0xFF, 0xD9}), 1)
+}
+
+func TestContextDrawImageQuad(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	q := [4]Point{{0.0, 10.0}, {10.0, 10.0}, {10.0, 0.0}, {0.0, 0.0}}
+
+	c := New(100, 100)
+	ctx := NewContext(c)
+	ctx.DrawImageQuad(img, q)
+	test.T(t, len(c.layers[0]), 1)
+	test.T(t, *c.layers[0][0].quad, q)
+
+	// affine fallback for renderers without projective support
+	c2 := New(100, 100)
+	c.RenderTo(struct{ Renderer }{c2})
+	test.T(t, len(c2.layers[0]), 1)
+	test.T(t, Rect{0.0, 0.0, 4.0, 4.0}.Transform(c2.layers[0][0].m), Rect{0.0, 0.0, 10.0, 10.0})
+
+	// mesh fallback for perspective
+	c2 = New(100, 100)
+	NewContext(struct{ Renderer }{c2}).DrawImageQuad(img, [4]Point{{2.0, 10.0}, {8.0, 10.0}, {10.0, 0.0}, {0.0, 0.0}})
+	test.T(t, len(c2.layers[0]), 16)
+
+	// degenerate quadrilaterals are skipped
+	c2 = New(100, 100)
+	NewContext(c2).DrawImageQuad(img, [4]Point{{0.0, 0.0}, {5.0, 5.0}, {10.0, 10.0}, {5.0, 5.0}})
+	NewContext(struct{ Renderer }{c2}).DrawImageQuad(img, [4]Point{{0.0, 10.0}, {0.0, 10.0}, {10.0, 0.0}, {0.0, 0.0}})
+	NewContext(struct{ Renderer }{c2}).DrawImageQuad(img, [4]Point{{0.0, 0.0}, {5.0, 0.0}, {10.0, 0.0}, {5.0, 10.0}})
+	test.T(t, len(c2.layers[0]), 0)
+}
+
+func TestContextPalette(t *testing.T) {
+	spot := NewSpotSwatch("Brand Red", color.CMYK{0, 255, 200, 0})
+	palette := NewPalette(NewSwatch("Sky", RGB(135, 206, 235)), spot)
+	test.T(t, palette.Names(), []string{"Brand Red", "Sky"})
+	test.T(t, len(palette.Spots()), 1)
+
+	c := New(100, 100)
+	ctx := NewContext(c)
+	ctx.SetPalette(palette)
+	ctx.SetFill("Brand Red")
+	test.T(t, ctx.Style.Fill.Swatch, spot)
+	test.T(t, ctx.Style.Fill.Color, spot.Color)
+	ctx.SetStrokeSwatch("Sky")
+	test.T(t, ctx.Style.Stroke.Color, RGB(135, 206, 235))
+	ctx.SetStrokeSwatch("Unknown")
+	test.That(t, !ctx.Style.Stroke.Has())
+	ctx.SetFillColor(Red)
+	test.That(t, ctx.Style.Fill.Swatch == nil)
+
+	ctx.Push()
+	ctx.SetPalette(nil)
+	ctx.Pop()
+	test.T(t, ctx.Palette(), palette) // palette is part of the draw state
+
+	test.That(t, !Paint{Color: spot.Color}.Equal(Paint{Color: spot.Color, Swatch: spot}))
+}
+
+func TestLayoutGrid(t *testing.T) {
+	c := New(210, 297)
+	g := c.LayoutGrid(3, 2).SetMargins(20.0, 10.0, 20.0, 10.0).SetGutters(5.0, 7.0).SetBaseline(10.0, 5.0)
+	test.T(t, g.Content(), Rect{10.0, 20.0, 190.0, 257.0})
+	test.Float(t, g.ColumnWidth(), 60.0)
+	test.Float(t, g.RowHeight(), 125.0)
+	test.T(t, g.GridCell(0, 0), Rect{10.0, 152.0, 60.0, 125.0})
+	test.T(t, g.GridCell(2, 1), Rect{140.0, 20.0, 60.0, 125.0})
+	test.T(t, g.GridArea(1, 0, 2, 2), Rect{75.0, 20.0, 125.0, 257.0})
+
+	test.Float(t, g.SnapX(68.0), 70.0)
+	test.Float(t, g.SnapY(150.0), 152.0)
+	test.T(t, len(g.Baselines()), 26)
+	test.Float(t, g.SnapBaseline(270.0), 272.0)
+	test.T(t, g.Snap(Point{12.0, 258.0}), Point{10.0, 262.0})
+
+	ctx := NewContext(c)
+	g.Draw(ctx)
+	test.T(t, len(c.layers[0]), 6+26+1)
+}
+
+type capabilitiesRenderer struct {
+	*Canvas
+	caps Capabilities
+}
+
+func (r capabilitiesRenderer) Capabilities() Capabilities {
+	return r.caps
+}
+
+func TestContextCapabilities(t *testing.T) {
+	test.T(t, RendererCapabilities(struct{ Renderer }{New(100, 100)}), DefaultCapabilities)
+
+	gradient := NewLinearGradient(Point{0.0, 0.0}, Point{10.0, 0.0})
+	gradient.Add(0.0, Red)
+	gradient.Add(1.0, Blue)
+
+	c := New(100, 100)
+	ctx := NewContext(capabilitiesRenderer{c, Capabilities{}})
+	ctx.SetFill(gradient)
+	ctx.SetStroke(color.RGBA{0, 0, 128, 128})
+	ctx.DrawPath(0.0, 0.0, Rectangle(10.0, 10.0))
+	test.T(t, len(c.layers[0]), 2)
+	test.That(t, c.layers[0][0].img != nil, "gradient must be rasterized")
+	test.T(t, c.layers[0][0].img.Bounds().Dx(), 60)
+	test.T(t, c.layers[0][1].style.Stroke.Color, color.RGBA{127, 127, 255, 255})
+	test.That(t, !c.layers[0][1].style.HasFill())
+
+	// recorded drawing operations are converted when rendering
+	c = New(100, 100)
+	ctx = NewContext(c)
+	ctx.SetFill(gradient)
+	ctx.DrawPath(0.0, 0.0, Rectangle(10.0, 10.0))
+	test.That(t, c.layers[0][0].path != nil)
+
+	c2 := New(100, 100)
+	c.RenderTo(capabilitiesRenderer{c2, Capabilities{Transparency: true}})
+	test.That(t, c2.layers[0][0].img != nil, "gradient must be rasterized")
+}
+
+func TestCanvasPattern(t *testing.T) {
+	tile := New(10.0, 10.0)
+	ctx := NewContext(tile)
+	ctx.DrawPath(0.0, 0.0, Rectangle(5.0, 5.0))
+	ctx.DrawPath(8.0, 8.0, Rectangle(5.0, 5.0)) // clipped by the tile
+
+	pattern := NewCanvasPattern(tile, Identity, 0.0)
+	tiles := pattern.Tile(Rectangle(20.0, 19.0))
+	test.T(t, len(tiles.layers[0]), 8)
+	test.T(t, tiles.layers[0][0].bounds(), Rect{0.0, 0.0, 5.0, 5.0})
+	test.T(t, tiles.layers[0][1].bounds(), Rect{8.0, 8.0, 2.0, 2.0})
+	test.T(t, tiles.layers[0][6].bounds(), Rect{10.0, 10.0, 5.0, 5.0})
+	test.T(t, tiles.layers[0][7].bounds(), Rect{18.0, 18.0, 2.0, 1.0})
+
+	pattern = NewCanvasPattern(tile, Identity.Rotate(90.0), 5.0)
+	test.T(t, pattern.Cell(), Identity.Rotate(90.0).Scale(15.0, 15.0))
+	tiles = pattern.Tile(Rectangle(12.0, 12.0).Translate(-12.0, 0.0))
+	test.T(t, len(tiles.layers[0]), 2)
+
+	// renderers without support for patterns receive the clipped tiles
+	c := New(100, 100)
+	ctx = NewContext(capabilitiesRenderer{c, Capabilities{}})
+	ctx.SetFill(NewCanvasPattern(tile, Identity, 0.0))
+	ctx.SetStroke(Red)
+	ctx.DrawPath(0.0, 0.0, Rectangle(20.0, 20.0))
+	test.T(t, len(c.layers[0]), 9)
+	test.T(t, c.layers[0][8].style.Stroke, Paint{Color: Red})
+	test.That(t, !c.layers[0][8].style.HasFill())
+}
+
+type errorRenderer struct {
+	*Canvas
+	err error
+}
+
+func (r errorRenderer) Err() error {
+	return r.err
+}
+
+func TestContextErr(t *testing.T) {
+	c := New(100, 100)
+	ctx := NewContext(c)
+	test.Error(t, ctx.Err())
+	ctx.DrawPath(0.0, 0.0, Line(math.NaN(), 1.0))
+	ctx.DrawPath(0.0, 0.0, Rectangle(10.0, 10.0))
+	test.T(t, ctx.Err(), ErrInvalidPath)
+	test.T(t, len(c.layers[0]), 1)
+
+	errWrite := fmt.Errorf("write error")
+	ctx = NewContext(errorRenderer{New(100, 100), errWrite})
+	ctx.BeginGroup(0.5)
+	test.T(t, ctx.Err(), errWrite)
+	ctx.EndGroup()
+}
+
+func TestContextGroup(t *testing.T) {
+	c := New(100, 100)
+	ctx := NewContext(c)
+	ctx.SetFill(Red)
+	ctx.BeginGroup(0.5)
+	ctx.DrawPath(0.0, 0.0, Rectangle(10.0, 10.0))
+	ctx.EndGroup()
+	test.T(t, len(c.layers[0]), 3)
+	test.That(t, c.layers[0][0].beginGroup)
+	test.T(t, c.layers[0][0].opacity, 0.5)
+	test.That(t, c.layers[0][2].endGroup)
+
+	// renderers without group support multiply the alpha
+	c2 := New(100, 100)
+	c.RenderTo(struct{ Renderer }{c2})
+	test.T(t, len(c2.layers[0]), 1)
+	test.T(t, c2.layers[0][0].style.Fill.Color, color.RGBA{128, 0, 0, 128})
+
+	c2 = New(100, 100)
+	ctx = NewContext(struct{ Renderer }{c2})
+	ctx.SetFill(Red)
+	ctx.BeginGroup(0.5)
+	ctx.BeginGroup(0.5)
+	ctx.DrawPath(0.0, 0.0, Rectangle(10.0, 10.0))
+	ctx.EndGroup()
+	ctx.DrawPath(0.0, 0.0, Rectangle(10.0, 10.0))
+	ctx.EndGroup()
+	ctx.DrawPath(0.0, 0.0, Rectangle(10.0, 10.0))
+	test.T(t, len(c2.layers[0]), 3)
+	test.T(t, c2.layers[0][0].style.Fill.Color, color.RGBA{64, 0, 0, 64})
+	test.T(t, c2.layers[0][1].style.Fill.Color, color.RGBA{128, 0, 0, 128})
+	test.T(t, c2.layers[0][2].style.Fill.Color, Red)
+}
+
+func TestContextPushGroup(t *testing.T) {
+	c := New(100, 100)
+	ctx := NewContext(c)
+	ctx.SetFill(Red)
+	ctx.PushGroup()
+	ctx.DrawPath(0.0, 0.0, Rectangle(10.0, 10.0))
+	ctx.DrawPath(5.0, 5.0, Rectangle(10.0, 10.0))
+	test.T(t, len(c.layers[0]), 0)
+	ctx.PopGroup(0.5)
+	test.T(t, len(c.layers[0]), 4)
+	test.That(t, c.layers[0][0].beginGroup)
+	test.T(t, c.layers[0][0].opacity, 0.5)
+	test.T(t, c.layers[0][2].m, Identity.Translate(5.0, 5.0))
+	test.That(t, c.layers[0][3].endGroup)
+
+	// empty groups and unbalanced pops are ignored
+	ctx.PushGroup()
+	ctx.PopGroup(0.5)
+	ctx.PopGroup(0.5)
+	test.T(t, len(c.layers[0]), 4)
+}
+
+func TestContextUnit(t *testing.T) {
+	test.Float(t, Inch.ToMM(2.0), 50.8)
+	test.Float(t, Pt.FromMM(25.4), 72.0)
+	test.Float(t, Px(DPI(96.0)).ToMM(96.0), 25.4)
+
+	c := New(100, 100)
+	ctx := NewContext(c)
+	ctx.SetUnit(Cm)
+	ctx.SetStrokeWidth(0.1)
+	ctx.DrawPath(1.0, 2.0, Rectangle(3.0, 4.0))
+	test.T(t, c.layers[0][0].path.Transform(c.layers[0][0].m).Bounds(), Rect{10.0, 20.0, 30.0, 40.0})
+	test.T(t, c.layers[0][0].m, Identity.Translate(10.0, 20.0).Scale(10.0, 10.0))
+
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	ctx.DrawImage(1.0, 2.0, img, 1.0)
+	test.T(t, Rect{0.0, 0.0, 10.0, 10.0}.Transform(c.layers[0][1].m), Rect{10.0, 20.0, 10.0, 10.0})
+
+	ctx.Push()
+	ctx.SetUnit(Mm)
+	test.T(t, ctx.Unit(), Mm)
+	ctx.Pop()
+	test.T(t, ctx.Unit(), Cm)
+}
+
+func TestCanvasMetadata(t *testing.T) {
+	metadata := Metadata{
+		Title:        "A & B",
+		Creator:      "Author",
+		CreationDate: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Language:     "en",
+	}
+	test.That(t, Metadata{}.Empty())
+	test.That(t, !metadata.Empty())
+	test.String(t, metadata.RDF(), `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"><rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:xmp="http://ns.adobe.com/xap/1.0/"><dc:title><rdf:Alt><rdf:li xml:lang="x-default">A &amp; B</rdf:li></rdf:Alt></dc:title><dc:creator><rdf:Seq><rdf:li>Author</rdf:li></rdf:Seq></dc:creator><dc:language><rdf:Bag><rdf:li>en</rdf:li></rdf:Bag></dc:language><xmp:CreateDate>2020-01-02T03:04:05Z</xmp:CreateDate></rdf:Description></rdf:RDF>`)
+
+	c := New(100, 100)
+	c.Metadata = metadata
+	c2 := New(100, 100)
+	c.RenderTo(c2)
+	test.T(t, c2.Metadata, metadata)
+}
+
+func TestCanvasForPrint(t *testing.T) {
+	c := New(100, 50)
+	ctx := NewContext(c)
+	ctx.DrawPath(0.0, 0.0, Rectangle(100.0, 50.0))
+
+	page := c.ForPrint(PrintSetup{Bleed: 3.0})
+	test.T(t, page.W, 106.0)
+	test.T(t, page.TrimBox, Rect{3.0, 3.0, 100.0, 50.0})
+	test.T(t, page.BleedBox, Rect{0.0, 0.0, 106.0, 56.0})
+	test.T(t, len(page.layers[0]), 1)
+
+	page = c.ForPrint(PrintSetup{Bleed: 3.0, CropMarks: true, RegistrationMarks: true, ColorBars: true})
+	test.T(t, page.W, 120.0)
+	test.T(t, page.TrimBox, Rect{10.0, 10.0, 100.0, 50.0})
+	test.T(t, page.BleedBox, Rect{7.0, 7.0, 106.0, 56.0})
+	test.T(t, len(page.layers[0]), 1)
+	test.T(t, len(page.layers[1]), 8+4+10)
+}
@@ -0,0 +1,294 @@
+package canvas
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+// Capabilities specifies which constructs a renderer supports natively. Drawing operations using unsupported constructs are converted by the canvas into equivalent geometry or raster images before being passed to the renderer.
+type Capabilities struct {
+	Gradients      bool // gradient fills and strokes, otherwise they are rasterized into images clipped to the path
+	GouraudShading bool // gradients with per-vertex colors, see GouraudGradient and CoonsGradient, otherwise they are rasterized into images clipped to the path
+	Patterns       bool // pattern fills and strokes that repeat a canvas, see CanvasPattern, otherwise the tiles are clipped to the path by the canvas; other patterns are always converted
+	BlendModes     bool // composite modes other than CompositeSrcOver, see CompositeMode, otherwise paths are drawn over
+	Shadows        bool // drop shadows of paths, see Shadow, otherwise they are rasterized into blurred images below the paths
+	Clipping       bool // clipping paths
+	NativeText     bool // text objects, otherwise text is converted to paths by the canvas
+	Transparency   bool // colors with an alpha channel, otherwise colors are composited onto white
+}
+
+// DefaultCapabilities are the capabilities assumed for renderers that do not implement CapabilitiesRenderer.
+var DefaultCapabilities = Capabilities{
+	Gradients:    true,
+	NativeText:   true,
+	Transparency: true,
+}
+
+// CapabilitiesRenderer is an interface that renderers may implement to report which constructs they support natively, see Capabilities.
+type CapabilitiesRenderer interface {
+	Capabilities() Capabilities
+}
+
+// RendererCapabilities returns the capabilities of the renderer, or DefaultCapabilities if the renderer does not implement CapabilitiesRenderer.
+func RendererCapabilities(r Renderer) Capabilities {
+	if cr, ok := r.(CapabilitiesRenderer); ok {
+		return cr.Capabilities()
+	}
+	return DefaultCapabilities
+}
+
+// gradientFallbackResolution is the resolution of gradients that are rasterized for renderers without support for gradients.
+var gradientFallbackResolution = DPI(150.0)
+
+// fallbackRenderer passes drawing operations to the renderer, converting the constructs that are not supported by the renderer.
+type fallbackRenderer struct {
+	Renderer
+}
+
+func (r fallbackRenderer) RenderPath(path *Path, style Style, m Matrix) {
+	renderPath(r.Renderer, path, style, m)
+}
+
+func (r fallbackRenderer) RenderText(text *Text, m Matrix) {
+	renderText(r.Renderer, text, m)
+}
+
+// renderPath renders a path, converting gradients and transparency if they are not supported by the renderer.
+func renderPath(r Renderer, path *Path, style Style, m Matrix) {
+	if g, ok := style.Fill.Gradient.(*PathGradient); ok && style.Fill.IsGradient() {
+		style.Fill = Paint{Color: g.At(0.0, 0.0)}
+	}
+	if g, ok := style.Stroke.Gradient.(*PathGradient); ok && style.Stroke.IsGradient() {
+		if style.HasFill() {
+			fillStyle := style
+			fillStyle.Stroke = Paint{}
+			renderPath(r, path, fillStyle, m)
+		}
+		renderPathGradient(r, path, style, g, m)
+		return
+	}
+
+	caps := RendererCapabilities(r)
+	if !caps.Transparency {
+		style.Fill = paintOnWhite(style.Fill)
+		style.Stroke = paintOnWhite(style.Stroke)
+	}
+	if !caps.BlendModes {
+		style.CompositeMode = CompositeSrcOver
+	}
+	if style.Shadow != nil && !caps.Shadows {
+		r.RenderImage(style.Shadow.Image(path, style, m, gradientFallbackResolution))
+		style.Shadow = nil
+	}
+	_, fillTiling := style.Fill.Pattern.(*CanvasPattern)
+	_, strokeTiling := style.Stroke.Pattern.(*CanvasPattern)
+	if style.HasFill() && style.Fill.IsPattern() && (!caps.Patterns || !fillTiling) || style.HasStroke() && style.Stroke.IsPattern() && (!caps.Patterns || !strokeTiling) {
+		renderPattern(r, path, style, m)
+		return
+	}
+	fillGouraud, strokeGouraud := isMeshGradient(style.Fill.Gradient), isMeshGradient(style.Stroke.Gradient)
+	if caps.Gradients && (caps.GouraudShading || !fillGouraud && !strokeGouraud) || !style.Fill.IsGradient() && !style.Stroke.IsGradient() {
+		r.RenderPath(path, style, m)
+		return
+	}
+
+	// render fill and stroke separately to keep the stroke on top
+	if style.HasFill() {
+		if style.Fill.IsGradient() {
+			renderGradient(r, path.Settle(style.FillRule).Transform(m), style.Fill.Gradient)
+		} else {
+			fillStyle := style
+			fillStyle.Stroke = Paint{}
+			r.RenderPath(path, fillStyle, m)
+		}
+	}
+	if style.HasStroke() {
+		if style.Stroke.IsGradient() {
+			tolerance := PixelTolerance / gradientFallbackResolution.DPMM()
+			stroke := path
+			if 0 < len(style.Dashes) {
+				stroke = stroke.Dash(style.DashOffset, style.Dashes...)
+			}
+			stroke = stroke.Stroke(style.StrokeWidth, style.StrokeCapper, style.StrokeJoiner, tolerance)
+			renderGradient(r, stroke.Transform(m), style.Stroke.Gradient)
+		} else {
+			strokeStyle := style
+			strokeStyle.Fill = Paint{}
+			r.RenderPath(path, strokeStyle, m)
+		}
+	}
+}
+
+// renderPattern renders the fill and stroke of the path separately, where fills and strokes with a pattern are rendered by the pattern clipped to the filled path or the stroke outline.
+func renderPattern(r Renderer, path *Path, style Style, m Matrix) {
+	if style.HasFill() {
+		if style.Fill.IsPattern() {
+			style.Fill.Pattern.ClipTo(fallbackRenderer{r}, path.Settle(style.FillRule).Transform(m))
+		} else {
+			fillStyle := style
+			fillStyle.Stroke = Paint{}
+			renderPath(r, path, fillStyle, m)
+		}
+	}
+	if style.HasStroke() {
+		if style.Stroke.IsPattern() {
+			stroke := path
+			if 0 < len(style.Dashes) {
+				stroke = stroke.Dash(style.DashOffset, style.Dashes...)
+			}
+			stroke = stroke.Stroke(style.StrokeWidth, style.StrokeCapper, style.StrokeJoiner, Tolerance)
+			style.Stroke.Pattern.ClipTo(fallbackRenderer{r}, stroke.Transform(m))
+		} else {
+			strokeStyle := style
+			strokeStyle.Fill = Paint{}
+			renderPath(r, path, strokeStyle, m)
+		}
+	}
+}
+
+// pathGradientPieces is the number of pieces into which a stroke with a gradient along the path is split, in addition to the pieces between color stops.
+const pathGradientPieces = 64
+
+// renderPathGradient renders the stroke of the path with a gradient along the path by splitting the path into pieces, which are stroked and filled by linear gradients between the colors at their ends.
+func renderPathGradient(r Renderer, path *Path, style Style, g *PathGradient, m Matrix) {
+	length := path.Length()
+	if Equal(length, 0.0) {
+		return
+	}
+	stops := g.Stops.Resample(g.Interpolation)
+	crStart, crEnd := startEndCappers(style.StrokeCapper)
+
+	pieceStyle := style
+	pieceStyle.Stroke = Paint{}
+	pieceStyle.FillRule = NonZero
+	pieceStyle.Dashes = nil
+
+	T := 0.0 // length of the preceding subpaths
+	for _, ps := range path.Split() {
+		L := ps.Length()
+		if Equal(L, 0.0) {
+			continue
+		}
+
+		// split at regular intervals and at the color stops
+		ts := []float64{}
+		for i := int(math.Floor(T/length*pathGradientPieces)) + 1; float64(i)/pathGradientPieces*length < T+L; i++ {
+			ts = append(ts, float64(i)/pathGradientPieces*length-T)
+		}
+		for _, stop := range stops {
+			if t := stop.Offset*length - T; 0.0 < t && t < L {
+				ts = append(ts, t)
+			}
+		}
+		sort.Float64s(ts)
+		for i := len(ts) - 1; 0 < i; i-- {
+			if Equal(ts[i], ts[i-1]) {
+				ts = append(ts[:i], ts[i+1:]...)
+			}
+		}
+
+		pieces := ps.SplitAt(ts...)
+		s := 0.0 // length of the preceding pieces
+		for i, piece := range pieces {
+			l := piece.Length()
+
+			// only the ends of open subpaths are capped
+			cr := StartEndCapper{ButtCap, ButtCap}
+			if !ps.Closed() && i == 0 {
+				cr.Start = crStart
+			}
+			if !ps.Closed() && i == len(pieces)-1 {
+				cr.End = crEnd
+			}
+			stroke := piece
+			if 0 < len(style.Dashes) {
+				stroke = stroke.Dash(style.DashOffset+s, style.Dashes...)
+				cr = StartEndCapper{crStart, crEnd}
+			}
+			stroke = stroke.Stroke(style.StrokeWidth, cr, style.StrokeJoiner, Tolerance)
+
+			c0 := stops.At((T + s) / length)
+			c1 := stops.At((T + s + l) / length)
+			start, end := m.Dot(piece.StartPos()), m.Dot(piece.Pos())
+			if c0 == c1 || start.Equals(end) {
+				pieceStyle.Fill = Paint{Color: stops.At((T + s + l/2.0) / length)}
+			} else {
+				gradient := NewLinearGradient(start, end)
+				gradient.Stops = Stops{{0.0, c0}, {1.0, c1}}
+				pieceStyle.Fill = Paint{Gradient: gradient}
+			}
+			renderPath(r, stroke, pieceStyle, m)
+			s += l
+		}
+		T += L
+	}
+}
+
+// renderText renders text, converting it to paths if native text is not supported by the renderer.
+func renderText(r Renderer, text *Text, m Matrix) {
+	caps := RendererCapabilities(r)
+	if caps.NativeText {
+		r.RenderText(text, m)
+		return
+	}
+	text.RenderAsPath(fallbackRenderer{r}, m, 0.0)
+}
+
+// renderGradient renders the gradient as an image clipped to the path, which is in millimeters.
+func renderGradient(r Renderer, path *Path, gradient Gradient) {
+	bounds := path.Bounds()
+	dpmm := gradientFallbackResolution.DPMM()
+	w, h := int(math.Ceil(bounds.W*dpmm)), int(math.Ceil(bounds.H*dpmm))
+	if w <= 0 || h <= 0 {
+		return
+	}
+
+	x0, y1 := bounds.X, bounds.Y+float64(h)/dpmm
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			img.SetRGBA(i, j, gradient.At(x0+(float64(i)+0.5)/dpmm, y1-(float64(j)+0.5)/dpmm))
+		}
+	}
+	clipped := ClipImage(img, path.Translate(-bounds.X, -bounds.Y), gradientFallbackResolution)
+	r.RenderImage(clipped, Identity.Translate(bounds.X, bounds.Y).Scale(1.0/dpmm, 1.0/dpmm))
+}
+
+// colorOnWhite composites a color with an alpha channel onto white.
+func colorOnWhite(col color.RGBA) color.RGBA {
+	if col.A == 0 || col.A == 255 {
+		return col
+	}
+	return color.RGBA{col.R + 255 - col.A, col.G + 255 - col.A, col.B + 255 - col.A, 255}
+}
+
+func paintOnWhite(paint Paint) Paint {
+	paint.Color = colorOnWhite(paint.Color)
+	if g, ok := paint.Gradient.(*LinearGradient); ok {
+		gradient := *g
+		gradient.Stops = append(Stops{}, g.Stops...)
+		for i := range gradient.Stops {
+			gradient.Stops[i].Color = colorOnWhite(gradient.Stops[i].Color)
+		}
+		paint.Gradient = &gradient
+	} else if g, ok := paint.Gradient.(*RadialGradient); ok {
+		gradient := *g
+		gradient.Stops = append(Stops{}, g.Stops...)
+		for i := range gradient.Stops {
+			gradient.Stops[i].Color = colorOnWhite(gradient.Stops[i].Color)
+		}
+		paint.Gradient = &gradient
+	}
+	return paint
+}
+
+// isMeshGradient returns true for gradients with per-vertex colors, which require GouraudShading.
+func isMeshGradient(gradient Gradient) bool {
+	switch gradient.(type) {
+	case *GouraudGradient, *CoonsGradient:
+		return true
+	}
+	return false
+}
@@ -3,6 +3,7 @@ package canvas
 import (
 	"image/color"
 	"math"
+	"strconv"
 )
 
 // RGB returns a color given by red, green, and blue ∈ [0,255].
@@ -64,6 +65,330 @@ func Hex(s string) color.RGBA {
 	return Black
 }
 
+// HSL returns a color given by hue ∈ [0,360) in degrees, saturation ∈ [0,1], and lightness ∈ [0,1].
+func HSL(h, s, l float64) color.RGBA {
+	return HSLA(h, s, l, 1.0)
+}
+
+// HSLA returns a color given by hue ∈ [0,360) in degrees, saturation ∈ [0,1], lightness ∈ [0,1], and alpha ∈ [0,1].
+func HSLA(h, s, l, a float64) color.RGBA {
+	r, g, b := hslToRGB(h, s, l)
+	return rgbaFromFloats(r, g, b, a)
+}
+
+// Lab returns a color given by the CIE L*a*b* coordinates (D65 white point) with lightness L ∈ [0,100] and a and b typically ∈ [-128,127]. Colors outside of the sRGB gamut are clipped.
+func Lab(l, a, b float64) color.RGBA {
+	R, G, B := labToLinearRGB(l, a, b)
+	return rgbaFromFloats(linearToSRGB(R), linearToSRGB(G), linearToSRGB(B), 1.0)
+}
+
+// OKLCH returns a color given by the OKLCH coordinates, the polar form of the OKLab perceptual color space, with lightness ∈ [0,1], chroma typically ∈ [0,0.4], and hue ∈ [0,360) in degrees. Colors outside of the sRGB gamut are clipped.
+func OKLCH(l, c, h float64) color.RGBA {
+	R, G, B := oklabToLinearRGB(lchToLab(l, c, h))
+	return rgbaFromFloats(linearToSRGB(R), linearToSRGB(G), linearToSRGB(B), 1.0)
+}
+
+// ToHSL returns the hue ∈ [0,360) in degrees, saturation ∈ [0,1], and lightness ∈ [0,1] of a color.
+func ToHSL(col color.Color) (float64, float64, float64) {
+	r, g, b, _ := floatsFromColor(col)
+	return rgbToHSL(r, g, b)
+}
+
+// ToLab returns the CIE L*a*b* coordinates (D65 white point) of a color.
+func ToLab(col color.Color) (float64, float64, float64) {
+	r, g, b, _ := floatsFromColor(col)
+	return linearRGBToLab(sRGBToLinear(r), sRGBToLinear(g), sRGBToLinear(b))
+}
+
+// ToOKLCH returns the OKLCH coordinates, being lightness ∈ [0,1], chroma, and hue ∈ [0,360) in degrees, of a color.
+func ToOKLCH(col color.Color) (float64, float64, float64) {
+	r, g, b, _ := floatsFromColor(col)
+	return labToLCH(linearRGBToOKLab(sRGBToLinear(r), sRGBToLinear(g), sRGBToLinear(b)))
+}
+
+// floatsFromColor returns the non alpha premultiplied red, green, blue, and alpha components ∈ [0,1].
+func floatsFromColor(col color.Color) (float64, float64, float64, float64) {
+	R, G, B, A := col.RGBA()
+	if A == 0 {
+		return 0.0, 0.0, 0.0, 0.0
+	}
+	return float64(R) / float64(A), float64(G) / float64(A), float64(B) / float64(A), float64(A) / 0xffff
+}
+
+// rgbaFromFloats returns an alpha premultiplied color from non alpha premultiplied red, green, blue, and alpha components ∈ [0,1], which are clipped.
+func rgbaFromFloats(r, g, b, a float64) color.RGBA {
+	clip := func(c float64) float64 {
+		return math.Max(0.0, math.Min(1.0, c))
+	}
+	a = clip(a)
+	return color.RGBA{
+		uint8(clip(r)*a*255.0 + 0.5),
+		uint8(clip(g)*a*255.0 + 0.5),
+		uint8(clip(b)*a*255.0 + 0.5),
+		uint8(a*255.0 + 0.5),
+	}
+}
+
+func sRGBToLinear(c float64) float64 {
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(c float64) float64 {
+	if c <= 0.0031308 {
+		return 12.92 * c
+	}
+	return 1.055*math.Pow(c, 1.0/2.4) - 0.055
+}
+
+func hslToRGB(h, s, l float64) (float64, float64, float64) {
+	h = math.Mod(h, 360.0)
+	if h < 0.0 {
+		h += 360.0
+	}
+	c := (1.0 - math.Abs(2.0*l-1.0)) * s
+	x := c * (1.0 - math.Abs(math.Mod(h/60.0, 2.0)-1.0))
+	m := l - c/2.0
+	switch {
+	case h < 60.0:
+		return c + m, x + m, m
+	case h < 120.0:
+		return x + m, c + m, m
+	case h < 180.0:
+		return m, c + m, x + m
+	case h < 240.0:
+		return m, x + m, c + m
+	case h < 300.0:
+		return x + m, m, c + m
+	}
+	return c + m, m, x + m
+}
+
+func rgbToHSL(r, g, b float64) (float64, float64, float64) {
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	l := (max + min) / 2.0
+	d := max - min
+	if Equal(d, 0.0) {
+		return 0.0, 0.0, l // achromatic
+	}
+
+	s := d / (1.0 - math.Abs(2.0*l-1.0))
+	var h float64
+	switch max {
+	case r:
+		h = math.Mod((g-b)/d, 6.0)
+	case g:
+		h = (b-r)/d + 2.0
+	default:
+		h = (r-g)/d + 4.0
+	}
+	h *= 60.0
+	if h < 0.0 {
+		h += 360.0
+	}
+	return h, s, l
+}
+
+// D65 white point
+const labXn, labYn, labZn = 0.95047, 1.0, 1.08883
+
+func linearRGBToLab(r, g, b float64) (float64, float64, float64) {
+	x := (0.4124564*r + 0.3575761*g + 0.1804375*b) / labXn
+	y := (0.2126729*r + 0.7151522*g + 0.0721750*b) / labYn
+	z := (0.0193339*r + 0.1191920*g + 0.9503041*b) / labZn
+	f := func(t float64) float64 {
+		if 216.0/24389.0 < t {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27.0*t + 16.0) / 116.0
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return 116.0*fy - 16.0, 500.0 * (fx - fy), 200.0 * (fy - fz)
+}
+
+func labToLinearRGB(l, a, b float64) (float64, float64, float64) {
+	fy := (l + 16.0) / 116.0
+	fx := fy + a/500.0
+	fz := fy - b/200.0
+	finv := func(t float64) float64 {
+		if 6.0/29.0 < t {
+			return t * t * t
+		}
+		return (116.0*t - 16.0) * 27.0 / 24389.0
+	}
+	x, y, z := finv(fx)*labXn, finv(fy)*labYn, finv(fz)*labZn
+	return 3.2404542*x - 1.5371385*y - 0.4985314*z,
+		-0.9692660*x + 1.8760108*y + 0.0415560*z,
+		0.0556434*x - 0.2040259*y + 1.0572252*z
+}
+
+// see https://bottosson.github.io/posts/oklab/
+func linearRGBToOKLab(r, g, b float64) (float64, float64, float64) {
+	l := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	m := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	s := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+	return 0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		0.0259040371*l + 0.7827717662*m - 0.8086757660*s
+}
+
+func oklabToLinearRGB(L, a, b float64) (float64, float64, float64) {
+	l := L + 0.3963377774*a + 0.2158037573*b
+	m := L - 0.1055613458*a - 0.0638541728*b
+	s := L - 0.0894841775*a - 1.2914855480*b
+	l, m, s = l*l*l, m*m*m, s*s*s
+	return 4.0767416621*l - 3.3077115913*m + 0.2309699292*s,
+		-1.2684380046*l + 2.6097574011*m - 0.3413193965*s,
+		-0.0041960863*l - 0.7034186147*m + 1.7076147010*s
+}
+
+func labToLCH(l, a, b float64) (float64, float64, float64) {
+	h := math.Atan2(b, a) * 180.0 / math.Pi
+	if h < 0.0 {
+		h += 360.0
+	}
+	return l, math.Hypot(a, b), h
+}
+
+func lchToLab(l, c, h float64) (float64, float64, float64) {
+	sin, cos := math.Sincos(h * math.Pi / 180.0)
+	return l, c * cos, c * sin
+}
+
+// ColorInterpolation is the color space in which colors are interpolated, such as between the stops of gradients.
+type ColorInterpolation int
+
+// see ColorInterpolation
+const (
+	InterpolateSRGB ColorInterpolation = iota // default, as most renderers
+	InterpolateLinearRGB
+	InterpolateHSL
+	InterpolateLab
+	InterpolateOKLab
+	InterpolateOKLCH
+)
+
+// see ColorInterpolation, the hue interpolation method for the HSL and OKLCH color spaces that is combined with the color space, e.g. InterpolateOKLCH|LongerHue
+const (
+	ShorterHue    ColorInterpolation = iota << 8 // default, along the shorter arc
+	LongerHue                                    // along the longer arc
+	IncreasingHue                                // with increasing hue angles
+	DecreasingHue                                // with decreasing hue angles
+
+	hueInterpolationMask ColorInterpolation = 0xff00
+)
+
+func (interp ColorInterpolation) String() string {
+	switch interp & hueInterpolationMask {
+	case LongerHue:
+		return (interp &^ hueInterpolationMask).String() + "|LongerHue"
+	case IncreasingHue:
+		return (interp &^ hueInterpolationMask).String() + "|IncreasingHue"
+	case DecreasingHue:
+		return (interp &^ hueInterpolationMask).String() + "|DecreasingHue"
+	}
+	switch interp {
+	case InterpolateSRGB:
+		return "sRGB"
+	case InterpolateLinearRGB:
+		return "LinearRGB"
+	case InterpolateHSL:
+		return "HSL"
+	case InterpolateLab:
+		return "Lab"
+	case InterpolateOKLab:
+		return "OKLab"
+	case InterpolateOKLCH:
+		return "OKLCH"
+	}
+	return "Invalid(" + strconv.Itoa(int(interp)) + ")"
+}
+
+// InterpolateColor returns the color at t ∈ [0,1] between c0 and c1 interpolated in the given color space. Perceptual color spaces such as OKLab or OKLCH avoid the dark and desaturated (muddy) colors halfway when interpolating in sRGB. Hues are interpolated along the shorter arc unless another hue interpolation method is given, such as InterpolateOKLCH|LongerHue. Colors are interpolated with premultiplied alpha, so that transparent colors don't affect the color.
+func InterpolateColor(c0, c1 color.RGBA, t float64, interp ColorInterpolation) color.RGBA {
+	hue := interp & hueInterpolationMask
+	interp &^= hueInterpolationMask
+	if interp == InterpolateSRGB || c0 == c1 {
+		return colorLerp(c0, c1, t)
+	}
+
+	r0, g0, b0, a0 := floatsFromColor(c0)
+	r1, g1, b1, a1 := floatsFromColor(c1)
+	if a0 == 0.0 {
+		r0, g0, b0 = r1, g1, b1 // fully transparent colors have no hue
+	} else if a1 == 0.0 {
+		r1, g1, b1 = r0, g0, b0
+	}
+	a := (1.0-t)*a0 + t*a1
+
+	mix := func(x0, x1 float64) float64 {
+		return (1.0-t)*x0 + t*x1
+	}
+	mixHue := func(h0, c0, h1, c1 float64) float64 {
+		if Equal(c0, 0.0) {
+			return h1 // achromatic
+		} else if Equal(c1, 0.0) {
+			return h0
+		}
+		switch hue {
+		case ShorterHue:
+			if 180.0 < h1-h0 {
+				h0 += 360.0
+			} else if 180.0 < h0-h1 {
+				h1 += 360.0
+			}
+		case LongerHue:
+			if 0.0 < h1-h0 && h1-h0 < 180.0 {
+				h0 += 360.0
+			} else if -180.0 < h1-h0 && h1-h0 <= 0.0 {
+				h1 += 360.0
+			}
+		case IncreasingHue:
+			if h1 < h0 {
+				h1 += 360.0
+			}
+		case DecreasingHue:
+			if h0 < h1 {
+				h0 += 360.0
+			}
+		}
+		return mix(h0, h1)
+	}
+
+	var r, g, b float64
+	switch interp {
+	case InterpolateLinearRGB:
+		r = linearToSRGB(mix(sRGBToLinear(r0), sRGBToLinear(r1)))
+		g = linearToSRGB(mix(sRGBToLinear(g0), sRGBToLinear(g1)))
+		b = linearToSRGB(mix(sRGBToLinear(b0), sRGBToLinear(b1)))
+	case InterpolateHSL:
+		h0, s0, l0 := rgbToHSL(r0, g0, b0)
+		h1, s1, l1 := rgbToHSL(r1, g1, b1)
+		r, g, b = hslToRGB(mixHue(h0, s0, h1, s1), mix(s0, s1), mix(l0, l1))
+	case InterpolateLab:
+		L0, A0, B0 := linearRGBToLab(sRGBToLinear(r0), sRGBToLinear(g0), sRGBToLinear(b0))
+		L1, A1, B1 := linearRGBToLab(sRGBToLinear(r1), sRGBToLinear(g1), sRGBToLinear(b1))
+		r, g, b = labToLinearRGB(mix(L0, L1), mix(A0, A1), mix(B0, B1))
+		r, g, b = linearToSRGB(r), linearToSRGB(g), linearToSRGB(b)
+	case InterpolateOKLab:
+		L0, A0, B0 := linearRGBToOKLab(sRGBToLinear(r0), sRGBToLinear(g0), sRGBToLinear(b0))
+		L1, A1, B1 := linearRGBToOKLab(sRGBToLinear(r1), sRGBToLinear(g1), sRGBToLinear(b1))
+		r, g, b = oklabToLinearRGB(mix(L0, L1), mix(A0, A1), mix(B0, B1))
+		r, g, b = linearToSRGB(r), linearToSRGB(g), linearToSRGB(b)
+	case InterpolateOKLCH:
+		L0, C0, H0 := labToLCH(linearRGBToOKLab(sRGBToLinear(r0), sRGBToLinear(g0), sRGBToLinear(b0)))
+		L1, C1, H1 := labToLCH(linearRGBToOKLab(sRGBToLinear(r1), sRGBToLinear(g1), sRGBToLinear(b1)))
+		r, g, b = oklabToLinearRGB(lchToLab(mix(L0, L1), mix(C0, C1), mixHue(H0, C0, H1, C1)))
+		r, g, b = linearToSRGB(r), linearToSRGB(g), linearToSRGB(b)
+	default:
+		return colorLerp(c0, c1, t)
+	}
+	return rgbaFromFloats(r, g, b, a)
+}
+
 // Gradient is a gradient pattern for filling.
 type Gradient interface {
 	SetView(Matrix) Gradient
@@ -98,6 +423,11 @@ func (stops *Stops) Add(t float64, color color.RGBA) {
 
 // At returns the color at position t ∈ [0,1].
 func (stops Stops) At(t float64) color.RGBA {
+	return stops.Interpolate(t, InterpolateSRGB)
+}
+
+// Interpolate returns the color at position t ∈ [0,1] interpolated in the given color space.
+func (stops Stops) Interpolate(t float64, interp ColorInterpolation) color.RGBA {
 	if len(stops) == 0 {
 		return Transparent
 	} else if t <= 0.0 || len(stops) == 1 {
@@ -108,12 +438,66 @@ func (stops Stops) At(t float64) color.RGBA {
 	for i, stop := range stops[1:] {
 		if t < stop.Offset {
 			t = (t - stops[i].Offset) / (stop.Offset - stops[i].Offset)
-			return colorLerp(stops[i].Color, stop.Color, t)
+			return InterpolateColor(stops[i].Color, stop.Color, t, interp)
 		}
 	}
 	return stops[len(stops)-1].Color
 }
 
+// Resample returns stops that approximate interpolation in the given color space when interpolated in sRGB, by adding intermediate stops. This is used by renderers whose output formats only support interpolation in sRGB.
+func (stops Stops) Resample(interp ColorInterpolation) Stops {
+	if interp&^hueInterpolationMask == InterpolateSRGB || len(stops) < 2 {
+		return stops
+	}
+
+	const n = 8 // number of segments between two stops
+	resampled := make(Stops, 0, (len(stops)-1)*n+1)
+	for i, stop := range stops[1:] {
+		for j := 0; j < n; j++ {
+			t := float64(j) / n
+			offset := (1.0-t)*stops[i].Offset + t*stop.Offset
+			resampled = append(resampled, Stop{offset, InterpolateColor(stops[i].Color, stop.Color, t, interp)})
+		}
+	}
+	return append(resampled, stops[len(stops)-1])
+}
+
+// Spread is the method by which gradients fill the area beyond their start and end, that is for offsets outside of [0,1].
+type Spread int
+
+// see Spread
+const (
+	PadSpread     Spread = iota // default, extend the colors at offset 0 and 1
+	ReflectSpread               // repeat the gradient, reversing it every other period
+	RepeatSpread                // repeat the gradient
+)
+
+func (spread Spread) String() string {
+	switch spread {
+	case PadSpread:
+		return "Pad"
+	case ReflectSpread:
+		return "Reflect"
+	case RepeatSpread:
+		return "Repeat"
+	}
+	return "Invalid(" + strconv.Itoa(int(spread)) + ")"
+}
+
+// Offset returns the offset t mapped into [0,1] according to the spread method. Offsets are left unchanged for PadSpread, since stops clamp offsets to [0,1].
+func (spread Spread) Offset(t float64) float64 {
+	switch spread {
+	case ReflectSpread:
+		t = math.Mod(math.Abs(t), 2.0)
+		if 1.0 < t {
+			t = 2.0 - t
+		}
+	case RepeatSpread:
+		t -= math.Floor(t)
+	}
+	return t
+}
+
 func colorLerp(c0, c1 color.RGBA, t float64) color.RGBA {
 	r0, g0, b0, a0 := c0.RGBA()
 	r1, g1, b1, a1 := c1.RGBA()
@@ -129,10 +513,12 @@ func lerp(a, b uint32, t float64) uint8 {
 	return uint8(uint32((1.0-t)*float64(a)+t*float64(b)) >> 8)
 }
 
-// LinearGradient is a linear gradient pattern between the given start and end points. The color at offset 0 corresponds to the start position, and offset 1 to the end position. Start and end points are in the canvas's coordinate system.
+// LinearGradient is a linear gradient pattern between the given start and end points. The color at offset 0 corresponds to the start position, and offset 1 to the end position. Start and end points are in the canvas's coordinate system. Spread sets how the area before the start and after the end is filled.
 type LinearGradient struct {
 	Start, End Point
 	Stops
+	Interpolation ColorInterpolation
+	Spread        Spread
 
 	d  Point
 	d2 float64
@@ -170,7 +556,10 @@ func (g *LinearGradient) SetColorSpace(colorSpace ColorSpace) Gradient {
 		return g
 	}
 
+	// interpolate in the given color space before conversion to linear
 	gradient := *g
+	gradient.Stops = append(Stops{}, g.Stops.Resample(g.Interpolation)...)
+	gradient.Interpolation = InterpolateSRGB
 	for i := range gradient.Stops {
 		gradient.Stops[i].Color = colorSpace.ToLinear(gradient.Stops[i].Color)
 	}
@@ -183,21 +572,25 @@ func (g *LinearGradient) At(x, y float64) color.RGBA {
 		return Transparent
 	}
 
+	var t float64
 	p := Point{x, y}.Sub(g.Start)
 	if Equal(g.d.Y, 0.0) && !Equal(g.d.X, 0.0) {
-		return g.Stops.At(p.X / g.d.X) // horizontal
+		t = p.X / g.d.X // horizontal
 	} else if !Equal(g.d.Y, 0.0) && Equal(g.d.X, 0.0) {
-		return g.Stops.At(p.Y / g.d.Y) // vertical
+		t = p.Y / g.d.Y // vertical
+	} else {
+		t = p.Dot(g.d) / g.d2
 	}
-	t := p.Dot(g.d) / g.d2
-	return g.Stops.At(t)
+	return g.Stops.Interpolate(g.Spread.Offset(t), g.Interpolation)
 }
 
-// RadialGradient is a radial gradient pattern between two circles defined by their center points and radii. Color stop at offset 0 corresponds to the first circle and offset 1 to the second circle.
+// RadialGradient is a radial gradient pattern between two circles defined by their center points and radii. Color stop at offset 0 corresponds to the first circle and offset 1 to the second circle. Spread sets how the area inside the first circle and outside the second circle is filled.
 type RadialGradient struct {
 	C0, C1 Point
 	R0, R1 float64
 	Stops
+	Interpolation ColorInterpolation
+	Spread        Spread
 
 	cd    Point
 	dr, a float64
@@ -219,16 +612,20 @@ func NewRadialGradient(c0 Point, r0 float64, c1 Point, r1 float64) *RadialGradie
 	}
 }
 
-// SetView sets the view. Automatically called by Canvas for coordinate system transformations.
+// SetView sets the view. Automatically called by Canvas for coordinate system transformations. The radii are scaled by the geometric mean of the view's scale factors, since the circles cannot be skewed or scaled non-uniformly.
 func (g *RadialGradient) SetView(view Matrix) Gradient {
 	if view == Identity {
 		return g
 	}
 
+	scale := math.Sqrt(math.Abs(view.Det()))
 	gradient := *g
 	gradient.C0 = view.Dot(gradient.C0)
 	gradient.C1 = view.Dot(gradient.C1)
+	gradient.R0 *= scale
+	gradient.R1 *= scale
 	gradient.cd = gradient.C1.Sub(gradient.C0)
+	gradient.dr = gradient.R1 - gradient.R0
 	gradient.a = gradient.cd.Dot(gradient.cd) - gradient.dr*gradient.dr
 	return &gradient
 }
@@ -239,7 +636,10 @@ func (g *RadialGradient) SetColorSpace(colorSpace ColorSpace) Gradient {
 		return g
 	}
 
+	// interpolate in the given color space before conversion to linear
 	gradient := *g
+	gradient.Stops = append(Stops{}, g.Stops.Resample(g.Interpolation)...)
+	gradient.Interpolation = InterpolateSRGB
 	for i := range gradient.Stops {
 		gradient.Stops[i].Color = colorSpace.ToLinear(gradient.Stops[i].Color)
 	}
@@ -259,13 +659,359 @@ func (g *RadialGradient) At(x, y float64) color.RGBA {
 	c := pd.Dot(pd) - g.R0*g.R0
 	t0, t1 := solveQuadraticFormula(g.a, -2.0*b, c)
 	if !math.IsNaN(t1) {
-		return g.Stops.At(t1)
+		return g.Stops.Interpolate(g.Spread.Offset(t1), g.Interpolation)
 	} else if !math.IsNaN(t0) {
-		return g.Stops.At(t0)
+		return g.Stops.Interpolate(g.Spread.Offset(t0), g.Interpolation)
 	}
 	return Transparent
 }
 
+// PathGradient is a gradient for strokes whose color varies along the length of the stroked path, so that the color at offset 0 corresponds to the start of the path and offset 1 to the end of the path, across all subpaths. The stroke is split into pieces that are each filled with a linear gradient between the colors at their ends, which is supported by all renderers. When used for fills, it fills with the color at offset 0.
+type PathGradient struct {
+	Stops
+	Interpolation ColorInterpolation
+}
+
+// NewPathGradient returns a new gradient along the path.
+func NewPathGradient() *PathGradient {
+	return &PathGradient{}
+}
+
+// SetView sets the view. The gradient is independent of the view.
+func (g *PathGradient) SetView(view Matrix) Gradient {
+	return g
+}
+
+// SetColorSpace sets the color space. Automatically called by the rasterizer.
+func (g *PathGradient) SetColorSpace(colorSpace ColorSpace) Gradient {
+	if _, ok := colorSpace.(LinearColorSpace); ok {
+		return g
+	}
+
+	// interpolate in the given color space before conversion to linear
+	gradient := *g
+	gradient.Stops = append(Stops{}, g.Stops.Resample(g.Interpolation)...)
+	gradient.Interpolation = InterpolateSRGB
+	for i := range gradient.Stops {
+		gradient.Stops[i].Color = colorSpace.ToLinear(gradient.Stops[i].Color)
+	}
+	return &gradient
+}
+
+// At returns the color at offset 0, since the color depends on the position along the path and not on the position (x,y).
+func (g *PathGradient) At(x, y float64) color.RGBA {
+	return g.Stops.Interpolate(0.0, g.Interpolation)
+}
+
+// GouraudGradient is a gradient over a triangle mesh with a color at each vertex, where the colors are interpolated linearly across each triangle, also known as Gouraud shading. This is useful for heat maps and smooth data-driven coloring. Triangles index into Vertices and Colors, which must have the same length, and should not overlap. The gradient is transparent outside of the triangles, and is usually used to fill the path returned by Path.
+type GouraudGradient struct {
+	Vertices  []Point
+	Colors    []color.RGBA
+	Triangles [][3]int
+
+	grid *gouraudGrid
+}
+
+// NewGouraudGradient returns a new gradient over the triangle mesh with the given vertex colors.
+func NewGouraudGradient(vertices []Point, colors []color.RGBA, triangles [][3]int) *GouraudGradient {
+	g := &GouraudGradient{
+		Vertices:  vertices,
+		Colors:    colors,
+		Triangles: triangles,
+	}
+	g.grid = newGouraudGrid(g)
+	return g
+}
+
+// Path returns the triangles as a path, with each triangle as a counter clockwise subpath.
+func (g *GouraudGradient) Path() *Path {
+	p := &Path{}
+	for _, tri := range g.Triangles {
+		p0, p1, p2 := g.Vertices[tri[0]], g.Vertices[tri[1]], g.Vertices[tri[2]]
+		if p1.Sub(p0).PerpDot(p2.Sub(p0)) < 0.0 {
+			p1, p2 = p2, p1
+		}
+		p.MoveTo(p0.X, p0.Y)
+		p.LineTo(p1.X, p1.Y)
+		p.LineTo(p2.X, p2.Y)
+		p.Close()
+	}
+	return p
+}
+
+// SetView sets the view. Automatically called by Canvas for coordinate system transformations.
+func (g *GouraudGradient) SetView(view Matrix) Gradient {
+	if view == Identity {
+		return g
+	}
+
+	gradient := *g
+	gradient.Vertices = make([]Point, len(g.Vertices))
+	for i, vertex := range g.Vertices {
+		gradient.Vertices[i] = view.Dot(vertex)
+	}
+	gradient.grid = newGouraudGrid(&gradient)
+	return &gradient
+}
+
+// SetColorSpace sets the color space. Automatically called by the rasterizer.
+func (g *GouraudGradient) SetColorSpace(colorSpace ColorSpace) Gradient {
+	if _, ok := colorSpace.(LinearColorSpace); ok {
+		return g
+	}
+
+	gradient := *g
+	gradient.Colors = make([]color.RGBA, len(g.Colors))
+	for i, col := range g.Colors {
+		gradient.Colors[i] = colorSpace.ToLinear(col)
+	}
+	return &gradient
+}
+
+// At returns the color at position (x,y), or transparent if it is outside of the triangles.
+func (g *GouraudGradient) At(x, y float64) color.RGBA {
+	p := Point{x, y}
+	if g.grid == nil {
+		for _, tri := range g.Triangles {
+			if col, ok := g.triangleAt(tri, p); ok {
+				return col
+			}
+		}
+		return Transparent
+	}
+	for _, i := range g.grid.triangles(p) {
+		if col, ok := g.triangleAt(g.Triangles[i], p); ok {
+			return col
+		}
+	}
+	return Transparent
+}
+
+// triangleAt returns the interpolated color at the point if it is inside the triangle.
+func (g *GouraudGradient) triangleAt(tri [3]int, p Point) (color.RGBA, bool) {
+	p0, p1, p2 := g.Vertices[tri[0]], g.Vertices[tri[1]], g.Vertices[tri[2]]
+	denom := p1.Sub(p0).PerpDot(p2.Sub(p0))
+	if Equal(denom, 0.0) {
+		return Transparent, false
+	}
+
+	// barycentric coordinates
+	u := p1.Sub(p).PerpDot(p2.Sub(p)) / denom
+	v := p2.Sub(p).PerpDot(p0.Sub(p)) / denom
+	w := 1.0 - u - v
+	if u < -Epsilon || v < -Epsilon || w < -Epsilon {
+		return Transparent, false
+	}
+
+	c0, c1, c2 := g.Colors[tri[0]], g.Colors[tri[1]], g.Colors[tri[2]]
+	mix := func(a, b, c uint8) uint8 {
+		return uint8(math.Max(0.0, math.Min(255.0, u*float64(a)+v*float64(b)+w*float64(c)+0.5)))
+	}
+	return color.RGBA{mix(c0.R, c1.R, c2.R), mix(c0.G, c1.G, c2.G), mix(c0.B, c1.B, c2.B), mix(c0.A, c1.A, c2.A)}, true
+}
+
+// gouraudGrid is a uniform grid over the bounds of a triangle mesh, where each cell lists the triangles that overlap it, to speed up looking up the triangle at a point.
+type gouraudGrid struct {
+	bounds Rect
+	n      int
+	cells  [][]int
+}
+
+func newGouraudGrid(g *GouraudGradient) *gouraudGrid {
+	if len(g.Triangles) == 0 {
+		return nil
+	}
+
+	bounds := Rect{g.Vertices[g.Triangles[0][0]].X, g.Vertices[g.Triangles[0][0]].Y, 0.0, 0.0}
+	for _, tri := range g.Triangles {
+		for _, i := range tri {
+			bounds = bounds.AddPoint(g.Vertices[i])
+		}
+	}
+	n := int(math.Ceil(math.Sqrt(float64(len(g.Triangles)))))
+	grid := &gouraudGrid{
+		bounds: bounds,
+		n:      n,
+		cells:  make([][]int, n*n),
+	}
+	for k, tri := range g.Triangles {
+		triBounds := Rect{g.Vertices[tri[0]].X, g.Vertices[tri[0]].Y, 0.0, 0.0}
+		triBounds = triBounds.AddPoint(g.Vertices[tri[1]]).AddPoint(g.Vertices[tri[2]])
+		i0, j0 := grid.cell(Point{triBounds.X, triBounds.Y})
+		i1, j1 := grid.cell(Point{triBounds.X + triBounds.W, triBounds.Y + triBounds.H})
+		for j := j0; j <= j1; j++ {
+			for i := i0; i <= i1; i++ {
+				grid.cells[j*n+i] = append(grid.cells[j*n+i], k)
+			}
+		}
+	}
+	return grid
+}
+
+func (grid *gouraudGrid) cell(p Point) (int, int) {
+	i, j := 0, 0
+	if 0.0 < grid.bounds.W {
+		i = int((p.X - grid.bounds.X) / grid.bounds.W * float64(grid.n))
+	}
+	if 0.0 < grid.bounds.H {
+		j = int((p.Y - grid.bounds.Y) / grid.bounds.H * float64(grid.n))
+	}
+	return min(max(i, 0), grid.n-1), min(max(j, 0), grid.n-1)
+}
+
+func (grid *gouraudGrid) triangles(p Point) []int {
+	if p.X < grid.bounds.X-Epsilon || grid.bounds.X+grid.bounds.W+Epsilon < p.X || p.Y < grid.bounds.Y-Epsilon || grid.bounds.Y+grid.bounds.H+Epsilon < p.Y {
+		return nil
+	}
+	i, j := grid.cell(p)
+	return grid.cells[j*grid.n+i]
+}
+
+// coonsDivisions is the number of divisions along each side of a Coons patch when it is approximated by a triangle mesh.
+const coonsDivisions = 16
+
+// CoonsPatch is a patch bounded by four cubic Béziers with a color at each of its four corners. Points are the twelve control points of the boundary in order, starting at a corner, where the corners are Points[0], Points[3], Points[6], and Points[9] with two control points of the Bézier between them, and the last Bézier runs from Points[9] back to Points[0]. Colors are the colors at the corners in the same order.
+type CoonsPatch struct {
+	Points [12]Point
+	Colors [4]color.RGBA
+}
+
+// NewCoonsPatch returns a patch with straight sides between the four corners, which can be moved to curve the sides.
+func NewCoonsPatch(p0, p1, p2, p3 Point, c0, c1, c2, c3 color.RGBA) CoonsPatch {
+	patch := CoonsPatch{Colors: [4]color.RGBA{c0, c1, c2, c3}}
+	corners := [4]Point{p0, p1, p2, p3}
+	for i, a := range corners {
+		b := corners[(i+1)%4]
+		patch.Points[3*i] = a
+		patch.Points[3*i+1] = a.Interpolate(b, 1.0/3.0)
+		patch.Points[3*i+2] = a.Interpolate(b, 2.0/3.0)
+	}
+	return patch
+}
+
+// Pos returns the position in the patch at parameters u and v in [0,1], where u runs along the first side and v along the last side in reverse. The sides are blended as for a bilinearly blended Coons surface.
+func (patch CoonsPatch) Pos(u, v float64) Point {
+	p := patch.Points
+	c0 := cubicBezierPos(p[0], p[1], p[2], p[3], u)
+	c1 := cubicBezierPos(p[9], p[8], p[7], p[6], u)
+	d0 := cubicBezierPos(p[0], p[11], p[10], p[9], v)
+	d1 := cubicBezierPos(p[3], p[4], p[5], p[6], v)
+	corners := p[0].Mul((1.0 - u) * (1.0 - v)).Add(p[3].Mul(u * (1.0 - v))).Add(p[6].Mul(u * v)).Add(p[9].Mul((1.0 - u) * v))
+	return c0.Mul(1.0 - v).Add(c1.Mul(v)).Add(d0.Mul(1.0 - u)).Add(d1.Mul(u)).Sub(corners)
+}
+
+// Color returns the color in the patch at parameters u and v in [0,1], which is interpolated bilinearly between the corner colors.
+func (patch CoonsPatch) Color(u, v float64) color.RGBA {
+	c := patch.Colors
+	w := [4]float64{(1.0 - u) * (1.0 - v), u * (1.0 - v), u * v, (1.0 - u) * v}
+	mix := func(a, b, c, d uint8) uint8 {
+		return uint8(math.Max(0.0, math.Min(255.0, w[0]*float64(a)+w[1]*float64(b)+w[2]*float64(c)+w[3]*float64(d)+0.5)))
+	}
+	return color.RGBA{mix(c[0].R, c[1].R, c[2].R, c[3].R), mix(c[0].G, c[1].G, c[2].G, c[3].G), mix(c[0].B, c[1].B, c[2].B, c[3].B), mix(c[0].A, c[1].A, c[2].A, c[3].A)}
+}
+
+// CoonsGradient is a gradient over a mesh of Coons patches, which are bounded by cubic Béziers with a color at each corner, where the colors are interpolated smoothly across each patch following its curved sides. This gives smooth color transitions between many points over curved shapes, such as for shaded surfaces and freeform color blends. Patches should not overlap. The gradient is transparent outside of the patches, and is usually used to fill the path returned by Path. Renderers without native support use a fine triangulation of the patches, see Mesh.
+type CoonsGradient struct {
+	Patches []CoonsPatch
+
+	mesh *GouraudGradient
+}
+
+// NewCoonsGradient returns a new gradient over the given patches.
+func NewCoonsGradient(patches []CoonsPatch) *CoonsGradient {
+	g := &CoonsGradient{
+		Patches: patches,
+	}
+	g.mesh = g.Mesh(coonsDivisions)
+	return g
+}
+
+// Path returns the outlines of the patches as a path, with each patch as a subpath.
+func (g *CoonsGradient) Path() *Path {
+	p := &Path{}
+	for _, patch := range g.Patches {
+		p.MoveTo(patch.Points[0].X, patch.Points[0].Y)
+		for i := 0; i < 12; i += 3 {
+			cp1, cp2, end := patch.Points[i+1], patch.Points[i+2], patch.Points[(i+3)%12]
+			p.CubeTo(cp1.X, cp1.Y, cp2.X, cp2.Y, end.X, end.Y)
+		}
+		p.Close()
+	}
+	return p
+}
+
+// Mesh returns the patches approximated by a triangle mesh, where each patch is divided n times along each side. The mesh is used by renderers without native support for Coons patches.
+func (g *CoonsGradient) Mesh(n int) *GouraudGradient {
+	n = max(n, 1)
+	vertices := make([]Point, 0, len(g.Patches)*(n+1)*(n+1))
+	colors := make([]color.RGBA, 0, len(g.Patches)*(n+1)*(n+1))
+	triangles := make([][3]int, 0, len(g.Patches)*2*n*n)
+	for _, patch := range g.Patches {
+		k := len(vertices)
+		for j := 0; j <= n; j++ {
+			v := float64(j) / float64(n)
+			for i := 0; i <= n; i++ {
+				u := float64(i) / float64(n)
+				vertices = append(vertices, patch.Pos(u, v))
+				colors = append(colors, patch.Color(u, v))
+			}
+		}
+		for j := 0; j < n; j++ {
+			for i := 0; i < n; i++ {
+				a := k + j*(n+1) + i
+				triangles = append(triangles, [3]int{a, a + 1, a + n + 2}, [3]int{a, a + n + 2, a + n + 1})
+			}
+		}
+	}
+	return NewGouraudGradient(vertices, colors, triangles)
+}
+
+// SetView sets the view. Automatically called by Canvas for coordinate system transformations.
+func (g *CoonsGradient) SetView(view Matrix) Gradient {
+	if view == Identity {
+		return g
+	}
+
+	gradient := &CoonsGradient{
+		Patches: make([]CoonsPatch, len(g.Patches)),
+	}
+	for i, patch := range g.Patches {
+		for j, point := range patch.Points {
+			patch.Points[j] = view.Dot(point)
+		}
+		gradient.Patches[i] = patch
+	}
+	gradient.mesh = gradient.Mesh(coonsDivisions)
+	return gradient
+}
+
+// SetColorSpace sets the color space. Automatically called by the rasterizer.
+func (g *CoonsGradient) SetColorSpace(colorSpace ColorSpace) Gradient {
+	if _, ok := colorSpace.(LinearColorSpace); ok {
+		return g
+	}
+
+	gradient := &CoonsGradient{
+		Patches: make([]CoonsPatch, len(g.Patches)),
+	}
+	for i, patch := range g.Patches {
+		for j, col := range patch.Colors {
+			patch.Colors[j] = colorSpace.ToLinear(col)
+		}
+		gradient.Patches[i] = patch
+	}
+	gradient.mesh = gradient.Mesh(coonsDivisions)
+	return gradient
+}
+
+// At returns the color at position (x,y), or transparent if it is outside of the patches. It is approximated using the triangle mesh of the patches.
+func (g *CoonsGradient) At(x, y float64) color.RGBA {
+	if g.mesh == nil {
+		return g.Mesh(coonsDivisions).At(x, y)
+	}
+	return g.mesh.At(x, y)
+}
+
 // ImagePattern is an image tiling pattern of an image drawn from an origin with a certain resolution. Higher resolution will give smaller tilings.
 //type ImagePattern struct {
 //	img    *image.RGBA
@@ -0,0 +1,157 @@
+package canvas
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+)
+
+// CompositeMode is the way the colors of a drawing operation (the source) are combined with the colors already drawn (the backdrop or destination). The Porter-Duff operators combine the source and backdrop depending on their coverage, and the blend modes mix their colors where both overlap and otherwise draw the source over the backdrop. Only the area covered by the drawing operation is affected.
+type CompositeMode int
+
+// see CompositeMode
+const (
+	CompositeSrcOver    CompositeMode = iota // default, draw the source over the backdrop
+	CompositeClear                           // clear the backdrop
+	CompositeSrc                             // replace the backdrop by the source
+	CompositeDst                             // keep the backdrop
+	CompositeDstOver                         // draw the backdrop over the source
+	CompositeSrcIn                           // draw the source where the backdrop is
+	CompositeDstIn                           // keep the backdrop where the source is
+	CompositeSrcOut                          // draw the source where the backdrop is not
+	CompositeDstOut                          // keep the backdrop where the source is not
+	CompositeSrcAtop                         // draw the source over the backdrop where the backdrop is
+	CompositeDstAtop                         // draw the backdrop over the source where the source is
+	CompositeXor                             // draw the source and backdrop where they do not overlap
+	CompositeMultiply                        // multiply the colors, which darkens
+	CompositeScreen                          // multiply the complements of the colors, which lightens
+	CompositeOverlay                         // multiply or screen depending on the backdrop color
+	CompositeDarken                          // keep the darker color
+	CompositeLighten                         // keep the lighter color
+	CompositeColorDodge                      // brighten the backdrop to reflect the source
+	CompositeColorBurn                       // darken the backdrop to reflect the source
+	CompositeHardLight                       // multiply or screen depending on the source color
+	CompositeSoftLight                       // darken or lighten depending on the source color
+	CompositeDifference                      // subtract the darker from the lighter color
+	CompositeExclusion                       // like difference but with lower contrast
+)
+
+var compositeModeNames = []string{"SrcOver", "Clear", "Src", "Dst", "DstOver", "SrcIn", "DstIn", "SrcOut", "DstOut", "SrcAtop", "DstAtop", "Xor", "Multiply", "Screen", "Overlay", "Darken", "Lighten", "ColorDodge", "ColorBurn", "HardLight", "SoftLight", "Difference", "Exclusion"}
+
+func (mode CompositeMode) String() string {
+	if mode < 0 || int(mode) >= len(compositeModeNames) {
+		return "Invalid(" + strconv.Itoa(int(mode)) + ")"
+	}
+	return compositeModeNames[mode]
+}
+
+// IsBlend returns true for the blend modes, which mix the colors of the source and backdrop, as opposed to the Porter-Duff operators.
+func (mode CompositeMode) IsBlend() bool {
+	return CompositeMultiply <= mode && mode <= CompositeExclusion
+}
+
+// Composite returns the color of the source composited with the backdrop, where both colors have premultiplied alpha.
+func (mode CompositeMode) Composite(src, dst color.RGBA) color.RGBA {
+	as, ab := float64(src.A)/255.0, float64(dst.A)/255.0
+	cs := [3]float64{float64(src.R) / 255.0, float64(src.G) / 255.0, float64(src.B) / 255.0}
+	cb := [3]float64{float64(dst.R) / 255.0, float64(dst.G) / 255.0, float64(dst.B) / 255.0}
+
+	var co [3]float64
+	var ao float64
+	if mode.IsBlend() {
+		// see W3C Compositing and Blending, the mixed color is drawn over the backdrop
+		ao = as + ab*(1.0-as)
+		for i := range co {
+			co[i] = cs[i]*(1.0-ab) + cb[i]*(1.0-as)
+			if 0.0 < as && 0.0 < ab {
+				co[i] += as * ab * mode.blend(cb[i]/ab, cs[i]/as)
+			}
+		}
+	} else {
+		// the Porter-Duff operators are a weighted sum of the source and backdrop
+		var fa, fb float64
+		switch mode {
+		case CompositeSrcOver:
+			fa, fb = 1.0, 1.0-as
+		case CompositeSrc:
+			fa, fb = 1.0, 0.0
+		case CompositeDst:
+			fa, fb = 0.0, 1.0
+		case CompositeDstOver:
+			fa, fb = 1.0-ab, 1.0
+		case CompositeSrcIn:
+			fa, fb = ab, 0.0
+		case CompositeDstIn:
+			fa, fb = 0.0, as
+		case CompositeSrcOut:
+			fa, fb = 1.0-ab, 0.0
+		case CompositeDstOut:
+			fa, fb = 0.0, 1.0-as
+		case CompositeSrcAtop:
+			fa, fb = ab, 1.0-as
+		case CompositeDstAtop:
+			fa, fb = 1.0-ab, as
+		case CompositeXor:
+			fa, fb = 1.0-ab, 1.0-as
+		}
+		ao = fa*as + fb*ab
+		for i := range co {
+			co[i] = fa*cs[i] + fb*cb[i]
+		}
+	}
+
+	clamp := func(v float64) uint8 {
+		return uint8(math.Max(0.0, math.Min(1.0, v))*255.0 + 0.5)
+	}
+	a := clamp(ao)
+	return color.RGBA{min(clamp(co[0]), a), min(clamp(co[1]), a), min(clamp(co[2]), a), a}
+}
+
+// blend returns the mixed color component of the backdrop and source, which are not premultiplied.
+func (mode CompositeMode) blend(cb, cs float64) float64 {
+	switch mode {
+	case CompositeMultiply:
+		return cb * cs
+	case CompositeScreen:
+		return cb + cs - cb*cs
+	case CompositeOverlay:
+		return CompositeHardLight.blend(cs, cb)
+	case CompositeDarken:
+		return math.Min(cb, cs)
+	case CompositeLighten:
+		return math.Max(cb, cs)
+	case CompositeColorDodge:
+		if cb == 0.0 {
+			return 0.0
+		} else if cs == 1.0 {
+			return 1.0
+		}
+		return math.Min(1.0, cb/(1.0-cs))
+	case CompositeColorBurn:
+		if cb == 1.0 {
+			return 1.0
+		} else if cs == 0.0 {
+			return 0.0
+		}
+		return 1.0 - math.Min(1.0, (1.0-cb)/cs)
+	case CompositeHardLight:
+		if cs <= 0.5 {
+			return cb * 2.0 * cs
+		}
+		return CompositeScreen.blend(cb, 2.0*cs-1.0)
+	case CompositeSoftLight:
+		if cs <= 0.5 {
+			return cb - (1.0-2.0*cs)*cb*(1.0-cb)
+		}
+		d := math.Sqrt(cb)
+		if cb <= 0.25 {
+			d = ((16.0*cb-12.0)*cb + 4.0) * cb
+		}
+		return cb + (2.0*cs-1.0)*(d-cb)
+	case CompositeDifference:
+		return math.Abs(cb - cs)
+	case CompositeExclusion:
+		return cb + cs - 2.0*cb*cs
+	}
+	return cs
+}
@@ -0,0 +1,192 @@
+package canvas
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// Router routes connectors between anchor points that avoid a set of obstacles, as used for the edges in diagrams. Connectors consist of horizontal and vertical lines that keep at least Padding away from the bounding boxes of the obstacles, and prefer few bends by adding BendPenalty (in millimeters) to the length for every bend. Corners are rounded with CornerRadius for curved connectors. Obstacles whose padded bounding box contains an anchor are only avoided by their bounding box, and obstacles whose bounding box contains an anchor are ignored, so that anchors can be on or inside the shapes they connect.
+type Router struct {
+	Obstacles    []*Path
+	Padding      float64
+	BendPenalty  float64
+	CornerRadius float64
+}
+
+// NewRouter returns a new router for obstacles with the given padding in millimeters.
+func NewRouter(obstacles []*Path, padding float64) *Router {
+	return &Router{
+		Obstacles:   obstacles,
+		Padding:     padding,
+		BendPenalty: 5.0,
+	}
+}
+
+// Route returns the connector from start to end. It returns a straight line when there is no route, such as when the end is enclosed by obstacles.
+func (r *Router) Route(start, end Point) *Path {
+	boxes := []Rect{}
+	for _, obstacle := range r.Obstacles {
+		bounds := obstacle.Bounds()
+		padded := Rect{bounds.X - r.Padding, bounds.Y - r.Padding, bounds.W + 2.0*r.Padding, bounds.H + 2.0*r.Padding}
+		if rectContains(bounds, start) || rectContains(bounds, end) {
+			continue
+		} else if rectContains(padded, start) || rectContains(padded, end) {
+			padded = bounds
+		}
+		boxes = append(boxes, padded)
+	}
+
+	points := routeOrthogonal(start, end, boxes, r.BendPenalty)
+	if points == nil {
+		points = []Point{start, end}
+	}
+
+	// remove points between collinear lines
+	corners := []Point{points[0]}
+	for i := 1; i < len(points)-1; i++ {
+		if !Equal(points[i].Sub(corners[len(corners)-1]).PerpDot(points[i+1].Sub(points[i])), 0.0) {
+			corners = append(corners, points[i])
+		}
+	}
+	corners = append(corners, points[len(points)-1])
+	return roundedPolyline(corners, r.CornerRadius)
+}
+
+// RouteArrow returns the connector from start to end stroked with the given width and with an arrow head at the end.
+func (r *Router) RouteArrow(start, end Point, width float64) *Path {
+	return r.Route(start, end).Stroke(width, StartEndCapper{ButtCap, ArrowCap}, MiterJoin, Tolerance)
+}
+
+// rectContains returns true if the point is strictly inside the rectangle.
+func rectContains(rect Rect, p Point) bool {
+	return rect.X < p.X && p.X < rect.X+rect.W && rect.Y < p.Y && p.Y < rect.Y+rect.H
+}
+
+type routeItem struct {
+	node, dir int // dir is 0 for none, 1 for horizontal, and 2 for vertical
+	cost      float64
+}
+
+type routeQueue []routeItem
+
+func (q routeQueue) Len() int            { return len(q) }
+func (q routeQueue) Less(i, j int) bool  { return q[i].cost < q[j].cost }
+func (q routeQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *routeQueue) Push(x interface{}) { *q = append(*q, x.(routeItem)) }
+func (q *routeQueue) Pop() interface{} {
+	item := (*q)[len(*q)-1]
+	*q = (*q)[:len(*q)-1]
+	return item
+}
+
+// routeOrthogonal returns the shortest orthogonal polyline from start to end that does not pass through the interior of the boxes, with a penalty for every bend, or nil if there is none. It searches the grid formed by the coordinates of the anchors and the box edges using Dijkstra's algorithm.
+func routeOrthogonal(start, end Point, boxes []Rect, bendPenalty float64) []Point {
+	xs, ys := []float64{start.X, end.X}, []float64{start.Y, end.Y}
+	for _, box := range boxes {
+		xs = append(xs, box.X, box.X+box.W)
+		ys = append(ys, box.Y, box.Y+box.H)
+	}
+	xs, ys = uniqueFloats(xs), uniqueFloats(ys)
+	nx := len(xs)
+	index := func(vs []float64, v float64) int {
+		return sort.SearchFloat64s(vs, v)
+	}
+	blocked := func(p, q Point) bool {
+		for _, box := range boxes {
+			if p.Y == q.Y {
+				if box.Y < p.Y && p.Y < box.Y+box.H && box.X < math.Max(p.X, q.X) && math.Min(p.X, q.X) < box.X+box.W {
+					return true
+				}
+			} else if box.X < p.X && p.X < box.X+box.W && box.Y < math.Max(p.Y, q.Y) && math.Min(p.Y, q.Y) < box.Y+box.H {
+				return true
+			}
+		}
+		return false
+	}
+	point := func(node int) Point {
+		return Point{xs[node%nx], ys[node/nx]}
+	}
+
+	// state is node*3+dir
+	source := index(ys, start.Y)*nx + index(xs, start.X)
+	target := index(ys, end.Y)*nx + index(xs, end.X)
+	costs := map[int]float64{source * 3: 0.0}
+	prev := map[int]int{}
+	queue := &routeQueue{{source, 0, 0.0}}
+	for 0 < queue.Len() {
+		item := heap.Pop(queue).(routeItem)
+		if costs[item.node*3+item.dir] < item.cost {
+			continue
+		} else if item.node == target {
+			points := []Point{point(target)}
+			for state := item.node*3 + item.dir; state != source*3; state = prev[state] {
+				points = append(points, point(prev[state]/3))
+			}
+			for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+				points[i], points[j] = points[j], points[i]
+			}
+			return points
+		}
+
+		i, j := item.node%nx, item.node/nx
+		for _, d := range [4][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+			ni, nj := i+d[0], j+d[1]
+			if ni < 0 || nx <= ni || nj < 0 || len(ys) <= nj {
+				continue
+			}
+			node := nj*nx + ni
+			p, q := point(item.node), point(node)
+			if blocked(p, q) {
+				continue
+			}
+
+			dir := 1
+			if d[0] == 0 {
+				dir = 2
+			}
+			cost := item.cost + q.Sub(p).Length()
+			if item.dir != 0 && item.dir != dir {
+				cost += bendPenalty
+			}
+			state := node*3 + dir
+			if c, ok := costs[state]; !ok || cost < c {
+				costs[state] = cost
+				prev[state] = item.node*3 + item.dir
+				heap.Push(queue, routeItem{node, dir, cost})
+			}
+		}
+	}
+	return nil
+}
+
+func uniqueFloats(vs []float64) []float64 {
+	sort.Float64s(vs)
+	unique := vs[:0]
+	for _, v := range vs {
+		if len(unique) == 0 || v != unique[len(unique)-1] {
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
+
+// roundedPolyline returns the polyline through the points with its corners rounded by quadratic Béziers of at most the given radius.
+func roundedPolyline(points []Point, radius float64) *Path {
+	p := &Path{}
+	p.MoveTo(points[0].X, points[0].Y)
+	for i := 1; i < len(points)-1; i++ {
+		p0, p1, p2 := points[i-1], points[i], points[i+1]
+		r := math.Min(radius, math.Min(p1.Sub(p0).Length(), p2.Sub(p1).Length())/2.0)
+		if r <= 0.0 || Equal(p1.Sub(p0).PerpDot(p2.Sub(p1)), 0.0) {
+			p.LineTo(p1.X, p1.Y)
+			continue
+		}
+		a := p1.Sub(p1.Sub(p0).Norm(r))
+		b := p1.Add(p2.Sub(p1).Norm(r))
+		p.LineTo(a.X, a.Y)
+		p.QuadTo(p1.X, p1.Y, b.X, b.Y)
+	}
+	p.LineTo(points[len(points)-1].X, points[len(points)-1].Y)
+	return p
+}
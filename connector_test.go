@@ -0,0 +1,22 @@
+package canvas
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestRouter(t *testing.T) {
+	obstacle := Rectangle(10.0, 13.0).Translate(10.0, -5.0)
+	router := NewRouter([]*Path{obstacle}, 1.0)
+	test.T(t, router.Route(Point{0.0, 0.0}, Point{30.0, 0.0}), MustParseSVGPath("M0 0L0 -6L30 -6L30 0"))
+	test.T(t, router.Route(Point{0.0, 20.0}, Point{30.0, 20.0}), MustParseSVGPath("M0 20L30 20"))
+
+	// anchors on the boundary of an obstacle
+	test.T(t, router.Route(Point{20.0, 0.0}, Point{30.0, 0.0}), MustParseSVGPath("M20 0L30 0"))
+
+	router.CornerRadius = 2.0
+	test.T(t, router.Route(Point{0.0, 0.0}, Point{30.0, 0.0}), MustParseSVGPath("M0 0L0 -4Q0 -6 2 -6L28 -6Q30 -6 30 -4L30 0"))
+
+	test.That(t, !router.RouteArrow(Point{0.0, 0.0}, Point{30.0, 0.0}, 0.5).Empty(), "arrow must not be empty")
+}
@@ -0,0 +1,117 @@
+package canvas
+
+import (
+	"math"
+)
+
+// Contour returns the iso-contour of a scalar field at the given level using the marching squares algorithm, such as for isolines in heatmaps or terrain maps. The field is sampled on a regular grid where values[j][i] is the value at (i,j), and the contour is returned in grid coordinates so that it can be scaled and translated to the plot. Contours are oriented such that higher values are on their left, so that contours around maxima are counter clockwise. Contours that leave the grid or enter cells with NaN values are open, and all others are closed. Ambiguous saddle cells are resolved by the average of their corners. When smooth is set, the contours are smoothed by cubic Béziers through the points.
+func Contour(values [][]float64, level float64, smooth bool) *Path {
+	ny := len(values)
+	if ny < 2 {
+		return &Path{}
+	}
+	nx := len(values[0])
+	for _, row := range values[1:] {
+		nx = min(nx, len(row))
+	}
+	if nx < 2 {
+		return &Path{}
+	}
+
+	// edges are identified by their first grid point and whether they are horizontal or vertical
+	horizontal := func(i, j int) int { return 2 * (j*nx + i) }
+	vertical := func(i, j int) int { return 2*(j*nx+i) + 1 }
+	point := func(edge int) Point {
+		i, j := (edge/2)%nx, (edge/2)/nx
+		a := values[j][i]
+		if edge%2 == 0 {
+			return Point{float64(i) + (level-a)/(values[j][i+1]-a), float64(j)}
+		}
+		return Point{float64(i), float64(j) + (level-a)/(values[j+1][i]-a)}
+	}
+
+	next := make([]int, 2*nx*ny)
+	hasPrev := make([]bool, 2*nx*ny)
+	for k := range next {
+		next[k] = -1
+	}
+	for j := 0; j < ny-1; j++ {
+		for i := 0; i < nx-1; i++ {
+			// corners and edges in counter clockwise order
+			corners := [4]float64{values[j][i], values[j][i+1], values[j+1][i+1], values[j+1][i]}
+			edges := [4]int{horizontal(i, j), vertical(i+1, j), horizontal(i, j+1), vertical(i, j)}
+			if math.IsNaN(corners[0]) || math.IsNaN(corners[1]) || math.IsNaN(corners[2]) || math.IsNaN(corners[3]) {
+				continue
+			}
+
+			// contours go from edges where the values go from high to low to edges where they go from low to high
+			down, up := []int{}, []int{}
+			for k := 0; k < 4; k++ {
+				if a, b := level <= corners[k], level <= corners[(k+1)%4]; a && !b {
+					down = append(down, k)
+				} else if !a && b {
+					up = append(up, k)
+				}
+			}
+			if len(down) == 1 {
+				next[edges[down[0]]] = edges[up[0]]
+				hasPrev[edges[up[0]]] = true
+			} else if len(down) == 2 {
+				// saddle, connect the high corners through the center when it is high
+				center := (corners[0] + corners[1] + corners[2] + corners[3]) / 4.0
+				for _, k := range down {
+					m := (k + 3) % 4
+					if level <= center {
+						m = (k + 1) % 4
+					}
+					next[edges[k]] = edges[m]
+					hasPrev[edges[m]] = true
+				}
+			}
+		}
+	}
+
+	p := &Path{}
+	trace := func(start int) {
+		points := []Point{point(start)}
+		closed := false
+		for edge := start; ; {
+			edge, next[edge] = next[edge], -1
+			if edge == -1 {
+				break
+			} else if edge == start {
+				closed = true
+				break
+			}
+			points = append(points, point(edge))
+		}
+
+		if smooth {
+			catmullRom(p, points, closed)
+			return
+		}
+		for i, q := range points {
+			if i == 0 {
+				p.MoveTo(q.X, q.Y)
+			} else {
+				p.LineTo(q.X, q.Y)
+			}
+		}
+		if closed {
+			p.Close()
+		}
+	}
+
+	// open contours start at edges without an incoming contour, all others are closed
+	for edge := range next {
+		if next[edge] != -1 && !hasPrev[edge] {
+			trace(edge)
+		}
+	}
+	for edge := range next {
+		if next[edge] != -1 {
+			trace(edge)
+		}
+	}
+	return p
+}
@@ -0,0 +1,31 @@
+package canvas
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestContour(t *testing.T) {
+	var tts = []struct {
+		values [][]float64
+		level  float64
+		path   string
+	}{
+		{[][]float64{{0, 0, 0}, {0, 1, 0}, {0, 0, 0}}, 0.5, "M1 0.5L1.5 1L1 1.5L0.5 1z"},
+		{[][]float64{{0, 0, 0}, {0, -1, 0}, {0, 0, 0}}, -0.5, "M1 0.5L0.5 1L1 1.5L1.5 1z"},
+		{[][]float64{{0, 1}, {0, 1}}, 0.5, "M0.5 1L0.5 0"},
+		{[][]float64{{0, 1}, {0, 1}}, 2.0, ""},
+		{[][]float64{{0, 0, 0}, {0, math.NaN(), 0}, {0, 0, 0}}, 0.5, ""},
+	}
+	for _, tt := range tts {
+		t.Run(tt.path, func(t *testing.T) {
+			test.T(t, Contour(tt.values, tt.level, false), MustParseSVGPath(tt.path))
+		})
+	}
+
+	smooth := Contour([][]float64{{0, 0, 0}, {0, 1, 0}, {0, 0, 0}}, 0.5, true)
+	test.That(t, smooth.Closed())
+	test.T(t, smooth.StartPos(), Point{1.0, 0.5})
+}
@@ -0,0 +1,119 @@
+package canvas
+
+import (
+	"fmt"
+)
+
+// PageRenderer is a renderer that writes documents with multiple pages, such as the PDF and PostScript renderers. NewPage ends the current page and starts a new page of the given size in millimeters, and Close finishes the document.
+type PageRenderer interface {
+	Renderer
+	NewPage(width, height float64)
+	Close() error
+}
+
+// PageMetadata is page-level metadata, which renderers embed where the output format supports it.
+type PageMetadata struct {
+	Label string // label shown by viewers instead of the page number, such as "iv" or "A-1"
+}
+
+// PageMetadataRenderer is an interface that renderers may implement to embed the metadata of the current page, see PageMetadata.
+type PageMetadataRenderer interface {
+	SetPageMetadata(metadata PageMetadata)
+}
+
+// OutlineRenderer is an interface that renderers may implement to add items to the document outline, see Document.AddOutline.
+type OutlineRenderer interface {
+	AddOutline(level int, title string, page int, y float64)
+}
+
+type outlineItem struct {
+	level int
+	title string
+	page  int
+	y     float64
+}
+
+// Document writes a document with multiple pages, such as a PDF or PostScript file. Each page is a canvas that is rendered to the page renderer when the next page is started or when the document is closed, so that only one page is held in memory at a time. See pdf.NewDocument and ps.NewDocument.
+type Document struct {
+	newRenderer func(float64, float64) PageRenderer
+	r           PageRenderer
+	metadata    Metadata
+
+	page         *Canvas
+	pageMetadata PageMetadata
+	pages        int
+	outlines     []outlineItem
+}
+
+// NewDocument returns a new document that writes to the renderer returned by newRenderer, which is called with the size of the first page in millimeters.
+func NewDocument(newRenderer func(width, height float64) PageRenderer) *Document {
+	return &Document{
+		newRenderer: newRenderer,
+	}
+}
+
+// SetMetadata sets the document-level metadata, which is embedded by renderers that implement MetadataRenderer.
+func (d *Document) SetMetadata(metadata Metadata) {
+	d.metadata = metadata
+}
+
+// NewPage writes the current page and returns the canvas of a new page with width and height in millimeters.
+func (d *Document) NewPage(width, height float64) *Canvas {
+	d.writePage()
+	d.page = New(width, height)
+	d.pageMetadata = PageMetadata{}
+	return d.page
+}
+
+// SetPageMetadata sets the metadata of the current page, which is embedded by renderers that implement PageMetadataRenderer.
+func (d *Document) SetPageMetadata(metadata PageMetadata) {
+	d.pageMetadata = metadata
+}
+
+// AddOutline adds an item to the document outline, also known as bookmarks, which links to height y in millimeters on the page with the given index starting at zero. Items are nested under the preceding item of a lower level, where the top level is zero. The outline is embedded by renderers that implement OutlineRenderer.
+func (d *Document) AddOutline(level int, title string, page int, y float64) {
+	d.outlines = append(d.outlines, outlineItem{level, title, page, y})
+}
+
+// Pages returns the number of pages started so far.
+func (d *Document) Pages() int {
+	if d.page != nil {
+		return d.pages + 1
+	}
+	return d.pages
+}
+
+// Close writes the current page and finishes the document. It returns an error if no page was started.
+func (d *Document) Close() error {
+	d.writePage()
+	if d.r == nil {
+		return fmt.Errorf("document has no pages")
+	}
+	if or, ok := d.r.(OutlineRenderer); ok {
+		for _, item := range d.outlines {
+			or.AddOutline(item.level, item.title, item.page, item.y)
+		}
+	}
+	return d.r.Close()
+}
+
+// writePage renders the current page to the renderer.
+func (d *Document) writePage() {
+	if d.page == nil {
+		return
+	}
+	if d.r == nil {
+		d.r = d.newRenderer(d.page.W, d.page.H)
+		if mr, ok := d.r.(MetadataRenderer); ok && !d.metadata.Empty() {
+			mr.SetMetadata(d.metadata)
+		}
+	} else {
+		d.r.NewPage(d.page.W, d.page.H)
+	}
+	if pr, ok := d.r.(PageMetadataRenderer); ok && d.pageMetadata != (PageMetadata{}) {
+		pr.SetPageMetadata(d.pageMetadata)
+	}
+	d.page.RenderTo(d.r)
+	d.page = nil
+	d.pages++
+}
@@ -0,0 +1,57 @@
+package canvas
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+type pageRenderer struct {
+	*Canvas
+	sizes  [][2]float64
+	labels []string
+	closed bool
+}
+
+func (r *pageRenderer) NewPage(width, height float64) {
+	r.sizes = append(r.sizes, [2]float64{width, height})
+	r.labels = append(r.labels, "")
+}
+
+func (r *pageRenderer) SetPageMetadata(metadata PageMetadata) {
+	r.labels[len(r.labels)-1] = metadata.Label
+}
+
+func (r *pageRenderer) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestDocument(t *testing.T) {
+	var r *pageRenderer
+	doc := NewDocument(func(width, height float64) PageRenderer {
+		r = &pageRenderer{Canvas: New(width, height)}
+		r.NewPage(width, height)
+		return r
+	})
+	test.That(t, doc.Close() != nil, "expected error without pages")
+
+	doc.SetMetadata(Metadata{Title: "Title"})
+	page := doc.NewPage(100.0, 50.0)
+	NewContext(page).DrawPath(0.0, 0.0, Rectangle(10.0, 10.0))
+	test.T(t, r, (*pageRenderer)(nil)) // pages are written when finished
+	test.T(t, doc.Pages(), 1)
+
+	page = doc.NewPage(20.0, 30.0)
+	doc.SetPageMetadata(PageMetadata{Label: "ii"})
+	NewContext(page).DrawPath(0.0, 0.0, Rectangle(10.0, 10.0))
+	test.T(t, len(r.layers[0]), 1)
+	test.T(t, doc.Pages(), 2)
+
+	test.Error(t, doc.Close())
+	test.T(t, r.closed, true)
+	test.T(t, r.Metadata.Title, "Title")
+	test.T(t, r.sizes, [][2]float64{{100.0, 50.0}, {20.0, 30.0}})
+	test.T(t, r.labels, []string{"", "ii"})
+	test.T(t, len(r.layers[0]), 2)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/tdewolff/canvas"
+	canvasEbiten "github.com/tdewolff/canvas/renderers/ebiten"
+)
+
+type game struct {
+	c *canvasEbiten.Ebiten
+}
+
+func (g *game) Update() error {
+	return nil
+}
+
+func (g *game) Draw(screen *ebiten.Image) {
+	g.c.DrawTo(screen, nil)
+}
+
+func (g *game) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return 800, 400
+}
+
+func main() {
+	c := canvasEbiten.New(200.0, 100.0, canvas.DPMM(4.0))
+	ctx := canvas.NewContext(c)
+	if err := canvas.DrawPreview(ctx); err != nil {
+		panic(err)
+	}
+
+	ebiten.SetWindowSize(800, 400)
+	ebiten.SetWindowTitle("Canvas")
+	if err := ebiten.RunGame(&game{c}); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"os"
+
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/canvas/renderers/typst"
+)
+
+func main() {
+	f, err := os.Create("out.typ")
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+
+	c := typst.New(f, 100, 50)
+	defer c.Close()
+
+	ctx := canvas.NewContext(c)
+	ctx.SetCoordView(canvas.Identity.Scale(0.5, 0.5))
+	ctx.SetView(canvas.Identity.Scale(0.5, 0.5))
+	if err := canvas.DrawPreview(ctx); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,3 @@
+= Section
+
+#include "out.typ"
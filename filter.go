@@ -0,0 +1,303 @@
+package canvas
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"golang.org/x/image/vector"
+)
+
+// Shadow is a drop shadow that is drawn below the fill and stroke of a path, such as for cards and buttons in user interfaces. It is the silhouette of the path in the shadow color, displaced by the offset and blurred by a Gaussian blur with the given standard deviation. The offset and blur are in millimeters of the output and are not affected by the view, like for HTML canvas.
+type Shadow struct {
+	Offset Point // positive Y is up
+	Blur   float64
+	Color  color.RGBA
+}
+
+// Image returns the shadow of the path drawn with the given style and transformation, as an image with the given resolution and the transformation that places it on the canvas. It is used by renderers without support for shadows.
+func (shadow Shadow) Image(path *Path, style Style, m Matrix, resolution Resolution) (image.Image, Matrix) {
+	var fill, stroke *Path
+	bounds := Rect{}
+	if style.HasFill() {
+		fill = path.Settle(style.FillRule).Transform(m)
+		bounds = fill.Bounds()
+	}
+	if style.HasStroke() {
+		tolerance := PixelTolerance / resolution.DPMM()
+		stroke = path
+		if 0 < len(style.Dashes) {
+			stroke = stroke.Dash(style.DashOffset, style.Dashes...)
+		}
+		stroke = stroke.Stroke(style.StrokeWidth, style.StrokeCapper, style.StrokeJoiner, tolerance).Transform(m)
+		if fill == nil {
+			bounds = stroke.Bounds()
+		} else {
+			bounds = bounds.Add(stroke.Bounds())
+		}
+	}
+
+	dpmm := resolution.DPMM()
+	margin := 3.0 * math.Max(shadow.Blur, 0.0)
+	x0, y0 := bounds.X+shadow.Offset.X-margin, bounds.Y+shadow.Offset.Y-margin
+	w, h := int(math.Ceil((bounds.W+2.0*margin)*dpmm)), int(math.Ceil((bounds.H+2.0*margin)*dpmm))
+	if w <= 0 || h <= 0 {
+		return image.NewRGBA(image.Rectangle{}), Identity
+	}
+
+	// the silhouette is the union of the fill and stroke, which are rasterized separately since their windings may cancel
+	mask := image.NewAlpha(image.Rect(0, 0, w, h))
+	for _, p := range []*Path{fill, stroke} {
+		if p != nil {
+			ras := vector.NewRasterizer(w, h)
+			p.Translate(shadow.Offset.X-x0, shadow.Offset.Y-y0).ToRasterizer(ras, resolution)
+			ras.Draw(mask, mask.Bounds(), image.Opaque, image.Point{})
+		}
+	}
+	img := image.NewRGBA(mask.Bounds())
+	draw.DrawMask(img, img.Bounds(), image.NewUniform(shadow.Color), image.Point{}, mask, image.Point{}, draw.Src)
+	return GaussianBlur(img, shadow.Blur*dpmm), Identity.Translate(x0, y0).Scale(1.0/dpmm, 1.0/dpmm)
+}
+
+// GaussianBlur returns the image blurred by a Gaussian kernel with standard deviation sigma in pixels. The blur is separable and is applied horizontally and then vertically, where pixels outside of the image are transparent.
+func GaussianBlur(img image.Image, sigma float64) *image.RGBA {
+	dst := cloneRGBA(img)
+	if sigma <= 0.0 || dst.Rect.Empty() {
+		return dst
+	}
+
+	radius := int(math.Ceil(3.0 * sigma))
+	kernel := make([]float64, 2*radius+1)
+	sum := 0.0
+	for i := range kernel {
+		x := float64(i - radius)
+		kernel[i] = math.Exp(-x * x / (2.0 * sigma * sigma))
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+
+	tmp := image.NewRGBA(dst.Rect)
+	convolve1D(tmp, dst, kernel, 1, 0)
+	convolve1D(dst, tmp, kernel, 0, 1)
+	return dst
+}
+
+// convolve1D convolves the source image with a one-dimensional kernel centered on each pixel in the direction (dx,dy), and writes the result to the destination image of the same bounds.
+func convolve1D(dst, src *image.RGBA, kernel []float64, dx, dy int) {
+	radius := len(kernel) / 2
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	parallelFor(h, func(y int) {
+		for x := 0; x < w; x++ {
+			var sum [4]float64
+			for k, weight := range kernel {
+				xk, yk := x+(k-radius)*dx, y+(k-radius)*dy
+				if xk < 0 || w <= xk || yk < 0 || h <= yk {
+					continue
+				}
+				i := yk*src.Stride + xk*4
+				for c := 0; c < 4; c++ {
+					sum[c] += weight * float64(src.Pix[i+c])
+				}
+			}
+			i := y*dst.Stride + x*4
+			for c := 0; c < 4; c++ {
+				dst.Pix[i+c] = uint8(math.Min(255.0, sum[c]+0.5))
+			}
+		}
+	})
+}
+
+// Filter is an image filter that is applied to a group of drawing operations as a whole, see Context.BeginFilter. Apply returns the filtered image of the drawing operations rasterized at the given resolution, where colors have premultiplied alpha. Renderers that implement FilterRenderer apply filters to a rasterized image or export them natively, such as the SVG renderer for the filters of this package.
+type Filter interface {
+	Apply(img *image.RGBA, resolution Resolution) *image.RGBA
+}
+
+// BlurFilter blurs by a Gaussian blur with a standard deviation in millimeters, like feGaussianBlur for SVG.
+type BlurFilter struct {
+	StdDev float64
+}
+
+// NewBlurFilter returns a new Gaussian blur filter with the given standard deviation in millimeters.
+func NewBlurFilter(stdDev float64) *BlurFilter {
+	return &BlurFilter{stdDev}
+}
+
+// Apply returns the blurred image.
+func (f *BlurFilter) Apply(img *image.RGBA, resolution Resolution) *image.RGBA {
+	return GaussianBlur(img, f.StdDev*resolution.DPMM())
+}
+
+// ColorMatrixFilter transforms colors by a matrix, where the rows give the red, green, blue, and alpha components respectively as a weighted sum of the red, green, blue, and alpha components and a constant. Components are in [0,1] and have no premultiplied alpha, like feColorMatrix for SVG.
+type ColorMatrixFilter struct {
+	Matrix [4][5]float64
+}
+
+// NewColorMatrixFilter returns a new color matrix filter.
+func NewColorMatrixFilter(matrix [4][5]float64) *ColorMatrixFilter {
+	return &ColorMatrixFilter{matrix}
+}
+
+// NewSaturateFilter returns a color matrix filter that changes the saturation, where zero turns colors into grays and one keeps colors unchanged.
+func NewSaturateFilter(s float64) *ColorMatrixFilter {
+	return &ColorMatrixFilter{[4][5]float64{
+		{0.213 + 0.787*s, 0.715 - 0.715*s, 0.072 - 0.072*s, 0.0, 0.0},
+		{0.213 - 0.213*s, 0.715 + 0.285*s, 0.072 - 0.072*s, 0.0, 0.0},
+		{0.213 - 0.213*s, 0.715 - 0.715*s, 0.072 + 0.928*s, 0.0, 0.0},
+		{0.0, 0.0, 0.0, 1.0, 0.0},
+	}}
+}
+
+// Apply returns the image with its colors transformed.
+func (f *ColorMatrixFilter) Apply(img *image.RGBA, resolution Resolution) *image.RGBA {
+	dst := cloneRGBA(img)
+	w, h := dst.Rect.Dx(), dst.Rect.Dy()
+	parallelFor(h, func(y int) {
+		for x := 0; x < w; x++ {
+			i := y*dst.Stride + x*4
+			a := float64(dst.Pix[i+3]) / 255.0
+			src := [5]float64{0.0, 0.0, 0.0, a, 1.0}
+			if 0.0 < a {
+				for c := 0; c < 3; c++ {
+					src[c] = float64(dst.Pix[i+c]) / 255.0 / a
+				}
+			}
+
+			var res [4]float64
+			for c := range res {
+				for k, v := range src {
+					res[c] += f.Matrix[c][k] * v
+				}
+				res[c] = math.Max(0.0, math.Min(1.0, res[c]))
+			}
+			for c := 0; c < 3; c++ {
+				dst.Pix[i+c] = uint8(res[c]*res[3]*255.0 + 0.5)
+			}
+			dst.Pix[i+3] = uint8(res[3]*255.0 + 0.5)
+		}
+	})
+	return dst
+}
+
+// ConvolveFilter convolves the image with a kernel of Order by Order cells given row by row from the top, like feConvolveMatrix for SVG. The result is divided by the sum of the kernel, or by one if the sum is zero. Kernel cells are pixels of the output, so that the result depends on the resolution, and pixels outside of the image are transparent.
+type ConvolveFilter struct {
+	Order  int
+	Kernel []float64
+}
+
+// NewConvolveFilter returns a new convolution filter with a kernel of order by order cells. Filters with a kernel of a different length have no effect.
+func NewConvolveFilter(order int, kernel []float64) *ConvolveFilter {
+	return &ConvolveFilter{order, kernel}
+}
+
+// Apply returns the convolved image.
+func (f *ConvolveFilter) Apply(img *image.RGBA, resolution Resolution) *image.RGBA {
+	if f.Order <= 0 || len(f.Kernel) != f.Order*f.Order {
+		return cloneRGBA(img)
+	}
+
+	divisor := 0.0
+	for _, v := range f.Kernel {
+		divisor += v
+	}
+	if divisor == 0.0 {
+		divisor = 1.0
+	}
+
+	// the kernel is rotated by 180 degrees as for SVG, which makes it a convolution rather than a correlation
+	dst := image.NewRGBA(img.Rect)
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	target := f.Order / 2
+	parallelFor(h, func(y int) {
+		for x := 0; x < w; x++ {
+			var sum [4]float64
+			for ky := 0; ky < f.Order; ky++ {
+				for kx := 0; kx < f.Order; kx++ {
+					xk, yk := x-target+kx, y-target+ky
+					if xk < 0 || w <= xk || yk < 0 || h <= yk {
+						continue
+					}
+					weight := f.Kernel[(f.Order-ky-1)*f.Order+f.Order-kx-1]
+					i := yk*img.Stride + xk*4
+					for c := 0; c < 4; c++ {
+						sum[c] += weight * float64(img.Pix[i+c])
+					}
+				}
+			}
+			i := y*dst.Stride + x*4
+			a := math.Max(0.0, math.Min(255.0, sum[3]/divisor))
+			for c := 0; c < 3; c++ {
+				dst.Pix[i+c] = uint8(math.Max(0.0, math.Min(a, sum[c]/divisor)) + 0.5)
+			}
+			dst.Pix[i+3] = uint8(a + 0.5)
+		}
+	})
+	return dst
+}
+
+// MorphologyFilter thins (erodes) or fattens (dilates) the image by taking the minimum or maximum respectively of each color component over a square around each pixel, like feMorphology for SVG. The radius is in millimeters.
+type MorphologyFilter struct {
+	Radius float64
+	Dilate bool
+}
+
+// NewErodeFilter returns a new morphology filter that thins the image with the given radius in millimeters.
+func NewErodeFilter(radius float64) *MorphologyFilter {
+	return &MorphologyFilter{radius, false}
+}
+
+// NewDilateFilter returns a new morphology filter that fattens the image with the given radius in millimeters.
+func NewDilateFilter(radius float64) *MorphologyFilter {
+	return &MorphologyFilter{radius, true}
+}
+
+// Apply returns the eroded or dilated image.
+func (f *MorphologyFilter) Apply(img *image.RGBA, resolution Resolution) *image.RGBA {
+	dst := cloneRGBA(img)
+	radius := int(f.Radius*resolution.DPMM() + 0.5)
+	if radius <= 0 || dst.Rect.Empty() {
+		return dst
+	}
+
+	// the square is separable into a horizontal and a vertical pass
+	tmp := image.NewRGBA(dst.Rect)
+	morphology1D(tmp, dst, radius, f.Dilate, 1, 0)
+	morphology1D(dst, tmp, radius, f.Dilate, 0, 1)
+	return dst
+}
+
+// morphology1D takes the minimum or maximum of each color component of the source image over a line of pixels within the radius in the direction (dx,dy), and writes the result to the destination image of the same bounds.
+func morphology1D(dst, src *image.RGBA, radius int, dilate bool, dx, dy int) {
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	parallelFor(h, func(y int) {
+		for x := 0; x < w; x++ {
+			i := y*src.Stride + x*4
+			res := [4]uint8{src.Pix[i], src.Pix[i+1], src.Pix[i+2], src.Pix[i+3]}
+			for k := -radius; k <= radius; k++ {
+				xk, yk := x+k*dx, y+k*dy
+				if xk < 0 || w <= xk || yk < 0 || h <= yk {
+					continue
+				}
+				i := yk*src.Stride + xk*4
+				for c := 0; c < 4; c++ {
+					if dilate {
+						res[c] = max(res[c], src.Pix[i+c])
+					} else {
+						res[c] = min(res[c], src.Pix[i+c])
+					}
+				}
+			}
+			copy(dst.Pix[y*dst.Stride+x*4:], res[:])
+		}
+	})
+}
+
+// cloneRGBA returns a copy of the image as RGBA.
+func cloneRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, img, bounds.Min, draw.Src)
+	return dst
+}
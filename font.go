@@ -8,6 +8,7 @@ import (
 	"math"
 	"os"
 	"reflect"
+	"runtime"
 	"sync"
 
 	"github.com/tdewolff/canvas/text"
@@ -232,11 +233,26 @@ type Font struct {
 	*font.SFNT
 	name       string
 	style      FontStyle
-	shaper     text.Shaper
+	shapers    chan text.Shaper // idle shapers, a shaper can only be used by one goroutine at a time
 	variations string
 	features   string
+
+	shapeMu    sync.Mutex
+	shapeCache map[shapeKey][]text.Glyph
+}
+
+type shapeKey struct {
+	s                    string
+	ppem                 uint16
+	direction            text.Direction
+	script               text.Script
+	language             string
+	features, variations string
 }
 
+// maxShapeCache is the maximum number of shaped strings cached per font.
+const maxShapeCache = 4096
+
 // LoadLocalFont loads a font from the system's fonts.
 func LoadLocalFont(name string, style FontStyle) (*Font, error) {
 	log.Println("WARNING: github.com/tdewolff/canvas/LoadLocalFont is deprecated, please use github.com/tdewolff/canvas/LoadSystemFont") // TODO: remove
@@ -298,17 +314,88 @@ NameLoop:
 	}
 
 	font := &Font{
-		SFNT:   SFNT,
-		name:   name,
-		style:  style,
-		shaper: shaper,
+		SFNT:       SFNT,
+		name:       name,
+		style:      style,
+		shapers:    make(chan text.Shaper, runtime.GOMAXPROCS(0)),
+		shapeCache: map[shapeKey][]text.Glyph{},
 	}
+	font.shapers <- shaper
 	return font, nil
 }
 
-// Destroy should be called when using HarfBuzz to free the C resources.
+// Destroy should be called when using HarfBuzz to free the C resources. It frees the idle shapers only, so it must be called when the font is no longer used for shaping by other goroutines.
 func (f *Font) Destroy() {
-	f.shaper.Destroy()
+	for {
+		select {
+		case shaper := <-f.shapers:
+			shaper.Destroy()
+		default:
+			return
+		}
+	}
+}
+
+// shape shapes the string into glyphs. It is safe for concurrent use: results are cached and each goroutine shapes using its own shaper, which are created as needed and kept for reuse. If no shaper can be created, the string is mapped to glyphs without shaping. The returned glyphs may be modified by the caller.
+func (f *Font) shape(s string, ppem uint16, direction text.Direction, script text.Script, language string) []text.Glyph {
+	key := shapeKey{s, ppem, direction, script, language, f.features, f.variations}
+	f.shapeMu.Lock()
+	glyphs, ok := f.shapeCache[key]
+	f.shapeMu.Unlock()
+	if ok {
+		return append([]text.Glyph{}, glyphs...)
+	}
+
+	var shaper text.Shaper
+	select {
+	case shaper = <-f.shapers:
+	default:
+		var err error
+		if shaper, err = text.NewShaperSFNT(f.SFNT); err != nil {
+			return f.unshaped(s, direction)
+		}
+	}
+	glyphs = shaper.Shape(s, ppem, direction, script, language, f.features, f.variations)
+	select {
+	case f.shapers <- shaper:
+	default:
+		shaper.Destroy()
+	}
+
+	f.shapeMu.Lock()
+	if maxShapeCache <= len(f.shapeCache) {
+		clear(f.shapeCache)
+	}
+	f.shapeCache[key] = glyphs
+	f.shapeMu.Unlock()
+	return append([]text.Glyph{}, glyphs...)
+}
+
+// unshaped maps the string to glyphs using the character map and advances of the font, without kerning, ligatures, or other substitutions.
+func (f *Font) unshaped(s string, direction text.Direction) []text.Glyph {
+	vertical := direction == text.TopToBottom || direction == text.BottomToTop
+	glyphs := make([]text.Glyph, 0, len(s))
+	for i, r := range s {
+		id := f.SFNT.GlyphIndex(r)
+		glyph := text.Glyph{
+			ID:      id,
+			Cluster: uint32(i),
+			Text:    r,
+		}
+		if vertical {
+			glyph.YAdvance = -int32(f.SFNT.GlyphVerticalAdvance(id))
+		} else {
+			glyph.XAdvance = int32(f.SFNT.GlyphAdvance(id))
+		}
+		glyphs = append(glyphs, glyph)
+	}
+	if direction == text.RightToLeft || direction == text.BottomToTop {
+		// glyphs are in visual order
+		for i, j := 0, len(glyphs)-1; i < j; i, j = i+1, j-1 {
+			glyphs[i], glyphs[j] = glyphs[j], glyphs[i]
+		}
+	}
+	return glyphs
 }
 
 // Name returns the name of the font.
@@ -347,6 +434,8 @@ func (f *Font) Face(size float64, ifill interface{}, deco ...FontDecorator) *Fon
 		face.Fill = Paint{Pattern: pattern}
 	} else if gradient, ok := ifill.(Gradient); ok {
 		face.Fill = Paint{Gradient: gradient}
+	} else if swatch, ok := ifill.(*Swatch); ok {
+		face.Fill = swatchPaint(swatch)
 	} else if col, ok := ifill.(color.Color); ok {
 		face.Fill = Paint{Color: rgbaColor(col)}
 	}
@@ -501,6 +590,8 @@ func (family *FontFamily) Face(size float64, args ...interface{}) *FontFace {
 			face.Fill = Paint{Pattern: arg}
 		case Gradient:
 			face.Fill = Paint{Gradient: arg}
+		case *Swatch:
+			face.Fill = swatchPaint(arg)
 		case color.Color:
 			face.Fill = Paint{Color: rgbaColor(arg)}
 		case FontStyle:
@@ -698,7 +789,7 @@ func (face *FontFace) LineHeight() float64 {
 // TextWidth returns the width of a given string in millimeters.
 func (face *FontFace) TextWidth(s string) float64 {
 	ppem := face.PPEM(DefaultResolution)
-	glyphs := face.Font.shaper.Shape(s, ppem, face.Direction, face.Script, face.Language, face.Font.features, face.Font.variations)
+	glyphs := face.Font.shape(s, ppem, face.Direction, face.Script, face.Language)
 	return face.textWidth(glyphs)
 }
 
@@ -742,7 +833,7 @@ func (face *FontFace) Decorate(width float64) *Path {
 // ToPath converts a string to its glyph paths.
 func (face *FontFace) ToPath(s string) (*Path, float64, error) {
 	ppem := face.PPEM(DefaultResolution)
-	glyphs := face.Font.shaper.Shape(s, ppem, face.Direction, face.Script, face.Language, face.Font.features, face.Font.variations)
+	glyphs := face.Font.shape(s, ppem, face.Direction, face.Script, face.Language)
 	return face.toPath(glyphs, ppem)
 }
 
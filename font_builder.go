@@ -0,0 +1,451 @@
+package canvas
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// FontBuilder builds a TrueType font from glyph paths, such as for icon fonts or handwriting fonts. Glyph paths are in font units with the origin at the baseline and the Y axis pointing up, and are filled using the NonZero fill rule. Cubic Béziers and arcs are converted to quadratic Béziers, and coordinates are rounded to whole font units.
+type FontBuilder struct {
+	Family     string
+	Style      FontStyle
+	UnitsPerEm uint16
+	Ascender   float64 // in font units, positive above the baseline
+	Descender  float64 // in font units, negative below the baseline
+	LineGap    float64 // in font units
+	Tolerance  float64 // maximum deviation in font units when converting curves to quadratic Béziers
+
+	glyphs map[rune]builderGlyph
+}
+
+type builderGlyph struct {
+	path    *Path
+	advance float64
+}
+
+// NewFontBuilder returns a new font builder for a font family with the given units per em, and with an ascender and descender of 80% and 20% of the em respectively.
+func NewFontBuilder(family string, unitsPerEm uint16) *FontBuilder {
+	return &FontBuilder{
+		Family:     family,
+		Style:      FontRegular,
+		UnitsPerEm: unitsPerEm,
+		Ascender:   0.8 * float64(unitsPerEm),
+		Descender:  -0.2 * float64(unitsPerEm),
+		Tolerance:  0.5,
+		glyphs:     map[rune]builderGlyph{},
+	}
+}
+
+// AddGlyph adds or replaces the glyph for a rune with the given outline and advance width in font units.
+func (b *FontBuilder) AddGlyph(r rune, path *Path, advance float64) {
+	b.glyphs[r] = builderGlyph{path, advance}
+}
+
+// Font returns the built font, which can be used to draw text.
+func (b *FontBuilder) Font() (*Font, error) {
+	buf := &bytes.Buffer{}
+	if err := b.Write(buf); err != nil {
+		return nil, err
+	}
+	return LoadFont(buf.Bytes(), 0, b.Style)
+}
+
+// ttfGlyph is a glyph encoded for the glyf table.
+type ttfGlyph struct {
+	data         []byte
+	advance      uint16
+	bounds       [4]int16 // xMin, yMin, xMax, yMax
+	points, ends int
+	empty        bool
+}
+
+// Write writes the font in the TrueType format.
+func (b *FontBuilder) Write(w io.Writer) error {
+	if b.UnitsPerEm < 16 || 16384 < b.UnitsPerEm {
+		return fmt.Errorf("units per em must be in [16,16384]")
+	}
+	runes := make([]rune, 0, len(b.glyphs))
+	for r := range b.glyphs {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	if 65535 <= len(runes) {
+		return fmt.Errorf("too many glyphs")
+	}
+
+	// glyph 0 is .notdef
+	glyphs := []ttfGlyph{{advance: b.UnitsPerEm / 2, empty: true}}
+	for _, r := range runes {
+		glyph, err := b.encodeGlyph(b.glyphs[r])
+		if err != nil {
+			return fmt.Errorf("glyph %q: %w", r, err)
+		}
+		glyphs = append(glyphs, glyph)
+	}
+
+	// font-wide metrics
+	bounds := [4]int16{math.MaxInt16, math.MaxInt16, math.MinInt16, math.MinInt16}
+	var advanceMax uint16
+	var maxPoints, maxContours int
+	minLSB, minRSB, maxExtent := int16(math.MaxInt16), int16(math.MaxInt16), int16(math.MinInt16)
+	advanceSum := 0
+	for _, glyph := range glyphs {
+		advanceMax = max(advanceMax, glyph.advance)
+		advanceSum += int(glyph.advance)
+		if glyph.empty {
+			continue
+		}
+		bounds[0], bounds[1] = min(bounds[0], glyph.bounds[0]), min(bounds[1], glyph.bounds[1])
+		bounds[2], bounds[3] = max(bounds[2], glyph.bounds[2]), max(bounds[3], glyph.bounds[3])
+		maxPoints, maxContours = max(maxPoints, glyph.points), max(maxContours, glyph.ends)
+		minLSB = min(minLSB, glyph.bounds[0])
+		minRSB = min(minRSB, int16(int(glyph.advance)-int(glyph.bounds[2])))
+		maxExtent = max(maxExtent, glyph.bounds[2])
+	}
+	if bounds[0] == math.MaxInt16 {
+		bounds = [4]int16{}
+		minLSB, minRSB, maxExtent = 0, 0, 0
+	}
+	ascender, descender := int16(math.Round(b.Ascender)), int16(math.Round(b.Descender))
+	lineGap := int16(math.Round(b.LineGap))
+
+	tables := map[string][]byte{}
+
+	// glyf and loca
+	glyf := &bytes.Buffer{}
+	loca := &bytes.Buffer{}
+	for _, glyph := range glyphs {
+		binary.Write(loca, binary.BigEndian, uint32(glyf.Len()))
+		glyf.Write(glyph.data)
+	}
+	binary.Write(loca, binary.BigEndian, uint32(glyf.Len()))
+	tables["glyf"], tables["loca"] = glyf.Bytes(), loca.Bytes()
+
+	// head
+	macStyle := uint16(0)
+	if FontBold <= b.Style.Weight() && b.Style.Weight() <= FontBlack {
+		macStyle |= 0x01
+	}
+	if b.Style.Italic() {
+		macStyle |= 0x02
+	}
+	tables["head"] = ttfPack(
+		uint32(0x00010000), uint32(0x00010000), uint32(0), uint32(0x5F0F3CF5),
+		uint16(0x000B), b.UnitsPerEm, int64(0), int64(0),
+		bounds[0], bounds[1], bounds[2], bounds[3],
+		macStyle, uint16(8), int16(2), int16(1), int16(0),
+	)
+
+	// hhea
+	tables["hhea"] = ttfPack(
+		uint32(0x00010000), ascender, descender, lineGap,
+		advanceMax, minLSB, minRSB, maxExtent,
+		int16(1), int16(0), int16(0), [4]int16{}, int16(0), uint16(len(glyphs)),
+	)
+
+	// maxp
+	tables["maxp"] = ttfPack(
+		uint32(0x00010000), uint16(len(glyphs)), uint16(maxPoints), uint16(maxContours),
+		uint16(0), uint16(0), uint16(2), [8]uint16{},
+	)
+
+	// hmtx
+	hmtx := &bytes.Buffer{}
+	for _, glyph := range glyphs {
+		binary.Write(hmtx, binary.BigEndian, glyph.advance)
+		binary.Write(hmtx, binary.BigEndian, glyph.bounds[0])
+	}
+	tables["hmtx"] = hmtx.Bytes()
+
+	// OS/2
+	fsSelection := uint16(0x0040) // regular
+	if macStyle != 0 {
+		fsSelection = macStyle&0x01<<5 | macStyle&0x02>>1 // bold and italic
+	}
+	firstChar, lastChar := uint16(0xFFFF), uint16(0)
+	if 0 < len(runes) {
+		firstChar, lastChar = uint16(min(runes[0], 0xFFFF)), uint16(min(runes[len(runes)-1], 0xFFFF))
+	}
+	em := func(percentage int) int16 {
+		return int16(int(b.UnitsPerEm) * percentage / 100)
+	}
+	tables["OS/2"] = ttfPack(
+		uint16(4), int16(advanceSum/len(glyphs)), uint16(b.Style.CSS()), uint16(5), uint16(0),
+		em(65), em(60), int16(0), em(7), // subscript
+		em(65), em(60), int16(0), em(35), // superscript
+		em(5), em(26), int16(0), // strikeout and family class
+		[10]byte{}, [4]uint32{1, 0, 0, 0}, [4]byte{'N', 'O', 'N', 'E'},
+		fsSelection, firstChar, lastChar,
+		ascender, descender, lineGap, uint16(max(ascender, bounds[3])), uint16(max(-descender, -bounds[1])),
+		uint32(1), uint32(0), // Latin 1 code page
+		em(50), em(70), uint16(0), uint16(' '), uint16(1),
+	)
+
+	// cmap with a format 4 subtable for the BMP and a format 12 subtable for all runes
+	segments := [][3]uint32{} // start, end, glyph ID
+	for i, r := range runes {
+		if n := len(segments); 0 < n && segments[n-1][1]+1 == uint32(r) {
+			segments[n-1][1]++
+		} else {
+			segments = append(segments, [3]uint32{uint32(r), uint32(r), uint32(i + 1)})
+		}
+	}
+	bmp := [][3]uint32{}
+	for _, segment := range segments {
+		if segment[0] <= 0xFFFE {
+			segment[1] = min(segment[1], 0xFFFE)
+			bmp = append(bmp, segment)
+		}
+	}
+	bmp = append(bmp, [3]uint32{0xFFFF, 0xFFFF, 0})
+	segCount := len(bmp)
+	searchRange := 1 << uint(math.Floor(math.Log2(float64(segCount))))
+	format4 := &bytes.Buffer{}
+	binary.Write(format4, binary.BigEndian, []uint16{4, uint16(16 + 8*segCount), 0, uint16(2 * segCount), uint16(2 * searchRange), uint16(math.Log2(float64(searchRange))), uint16(2 * (segCount - searchRange))})
+	for _, segment := range bmp {
+		binary.Write(format4, binary.BigEndian, uint16(segment[1]))
+	}
+	binary.Write(format4, binary.BigEndian, uint16(0))
+	for _, segment := range bmp {
+		binary.Write(format4, binary.BigEndian, uint16(segment[0]))
+	}
+	for _, segment := range bmp {
+		idDelta := uint16(segment[2] - segment[0]) // modulo 65536
+		if segment[0] == 0xFFFF {
+			idDelta = 1
+		}
+		binary.Write(format4, binary.BigEndian, idDelta)
+	}
+	for range bmp {
+		binary.Write(format4, binary.BigEndian, uint16(0))
+	}
+	format12 := &bytes.Buffer{}
+	binary.Write(format12, binary.BigEndian, []uint16{12, 0})
+	binary.Write(format12, binary.BigEndian, []uint32{uint32(16 + 12*len(segments)), 0, uint32(len(segments))})
+	binary.Write(format12, binary.BigEndian, segments)
+	cmap := &bytes.Buffer{}
+	binary.Write(cmap, binary.BigEndian, []uint16{0, 2, 3, 1, 0, 20, 3, 10})
+	binary.Write(cmap, binary.BigEndian, uint32(20+format4.Len()))
+	cmap.Write(format4.Bytes())
+	cmap.Write(format12.Bytes())
+	tables["cmap"] = cmap.Bytes()
+
+	// name
+	subfamily := b.Style.String()
+	names := []string{1: b.Family, 2: subfamily, 4: b.Family + " " + subfamily, 6: strings.ReplaceAll(b.Family+"-"+subfamily, " ", "")}
+	records := &bytes.Buffer{}
+	strs := &bytes.Buffer{}
+	count := 0
+	for id, name := range names {
+		if name == "" {
+			continue
+		}
+		str := utf16.Encode([]rune(name))
+		binary.Write(records, binary.BigEndian, []uint16{3, 1, 0x0409, uint16(id), uint16(2 * len(str)), uint16(strs.Len())})
+		binary.Write(strs, binary.BigEndian, str)
+		count++
+	}
+	name := &bytes.Buffer{}
+	binary.Write(name, binary.BigEndian, []uint16{0, uint16(count), uint16(6 + 12*count)})
+	name.Write(records.Bytes())
+	name.Write(strs.Bytes())
+	tables["name"] = name.Bytes()
+
+	// post without glyph names
+	tables["post"] = ttfPack(uint32(0x00030000), int32(0), -em(10), em(5), uint32(0), [4]uint32{})
+
+	return ttfWrite(w, tables)
+}
+
+// encodeGlyph converts a glyph's path to quadratic contours and encodes it for the glyf table.
+func (b *FontBuilder) encodeGlyph(glyph builderGlyph) (ttfGlyph, error) {
+	if glyph.advance < 0.0 || 65535.0 < glyph.advance {
+		return ttfGlyph{}, fmt.Errorf("advance out of range")
+	}
+	g := ttfGlyph{advance: uint16(math.Round(glyph.advance))}
+
+	type point struct {
+		x, y    int16
+		onCurve bool
+	}
+	round := func(p Point) (int16, int16, error) {
+		x, y := math.Round(p.X), math.Round(p.Y)
+		if x < math.MinInt16 || math.MaxInt16 < x || y < math.MinInt16 || math.MaxInt16 < y {
+			return 0, 0, fmt.Errorf("coordinates out of range")
+		}
+		return int16(x), int16(y), nil
+	}
+
+	contours := [][]point{}
+	if glyph.path != nil {
+		tolerance := math.Max(b.Tolerance, 0.01)
+		for _, subpath := range glyph.path.Split() {
+			contour := []point{}
+			add := func(p Point, onCurve bool) error {
+				x, y, err := round(p)
+				if err != nil {
+					return err
+				}
+				if n := len(contour); 0 < n && contour[n-1].x == x && contour[n-1].y == y && contour[n-1].onCurve && onCurve {
+					return nil
+				}
+				contour = append(contour, point{x, y, onCurve})
+				return nil
+			}
+			var err error
+			scanner := subpath.Scanner()
+			for scanner.Scan() && err == nil {
+				start, end := scanner.Start(), scanner.End()
+				switch scanner.Cmd() {
+				case MoveToCmd, LineToCmd, CloseCmd:
+					err = add(end, true)
+				case QuadToCmd:
+					if err = add(scanner.CP1(), false); err == nil {
+						err = add(end, true)
+					}
+				case CubeToCmd:
+					for _, quad := range cubicToQuadraticBeziers(start, scanner.CP1(), scanner.CP2(), end, tolerance, 0) {
+						if err = add(quad[0], false); err == nil {
+							err = add(quad[1], true)
+						}
+					}
+				case ArcToCmd:
+					rx, ry, phi, large, sweep := scanner.Arc()
+					for _, quad := range ellipseToQuadraticBeziers(start, rx, ry, phi, large, sweep, end) {
+						if err = add(quad[1], false); err == nil {
+							err = add(quad[2], true)
+						}
+					}
+				}
+			}
+			if err != nil {
+				return ttfGlyph{}, err
+			}
+			// contours are closed implicitly
+			if n := len(contour); 1 < n && contour[0] == contour[n-1] {
+				contour = contour[:n-1]
+			}
+			if 2 < len(contour) {
+				contours = append(contours, contour)
+			}
+		}
+	}
+	if len(contours) == 0 {
+		g.empty = true
+		return g, nil
+	} else if math.MaxInt16 < len(contours) {
+		return ttfGlyph{}, fmt.Errorf("too many contours")
+	}
+
+	g.bounds = [4]int16{math.MaxInt16, math.MaxInt16, math.MinInt16, math.MinInt16}
+	endPts := []uint16{}
+	flags, xs, ys := []byte{}, []int16{}, []int16{}
+	var x0, y0 int16
+	for _, contour := range contours {
+		for _, p := range contour {
+			flag := byte(0)
+			if p.onCurve {
+				flag = 0x01
+			}
+			flags = append(flags, flag)
+			xs, ys = append(xs, p.x-x0), append(ys, p.y-y0)
+			x0, y0 = p.x, p.y
+			g.bounds[0], g.bounds[1] = min(g.bounds[0], p.x), min(g.bounds[1], p.y)
+			g.bounds[2], g.bounds[3] = max(g.bounds[2], p.x), max(g.bounds[3], p.y)
+		}
+		g.points += len(contour)
+		if 65535 < g.points {
+			return ttfGlyph{}, fmt.Errorf("too many points")
+		}
+		endPts = append(endPts, uint16(g.points-1))
+	}
+	g.ends = len(contours)
+
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.BigEndian, int16(len(contours)))
+	binary.Write(buf, binary.BigEndian, g.bounds)
+	binary.Write(buf, binary.BigEndian, endPts)
+	binary.Write(buf, binary.BigEndian, uint16(0)) // no instructions
+	buf.Write(flags)
+	binary.Write(buf, binary.BigEndian, xs)
+	binary.Write(buf, binary.BigEndian, ys)
+	for buf.Len()%4 != 0 {
+		buf.WriteByte(0)
+	}
+	g.data = buf.Bytes()
+	return g, nil
+}
+
+// cubicToQuadraticBeziers approximates a cubic Bézier by quadratic Béziers within the tolerance, returning their control and end points.
+func cubicToQuadraticBeziers(p0, p1, p2, p3 Point, tolerance float64, depth int) [][2]Point {
+	// the error of the quadratic with control point (3(p1+p2)-p0-p3)/4 is at most √3/36 |p3-3p2+3p1-p0|
+	d := p3.Sub(p2.Mul(3.0)).Add(p1.Mul(3.0)).Sub(p0)
+	if d.Length()*math.Sqrt(3.0)/36.0 <= tolerance || 16 <= depth {
+		cp := p1.Add(p2).Mul(3.0).Sub(p0).Sub(p3).Div(4.0)
+		return [][2]Point{{cp, p3}}
+	}
+	q0, q1, q2, q3, r0, r1, r2, r3 := cubicBezierSplit(p0, p1, p2, p3, 0.5)
+	return append(cubicToQuadraticBeziers(q0, q1, q2, q3, tolerance, depth+1), cubicToQuadraticBeziers(r0, r1, r2, r3, tolerance, depth+1)...)
+}
+
+// ttfPack encodes the values in big endian.
+func ttfPack(vals ...interface{}) []byte {
+	buf := &bytes.Buffer{}
+	for _, val := range vals {
+		binary.Write(buf, binary.BigEndian, val)
+	}
+	return buf.Bytes()
+}
+
+// ttfChecksum returns the checksum of a table.
+func ttfChecksum(b []byte) uint32 {
+	sum := uint32(0)
+	for i := 0; i < len(b); i += 4 {
+		word := [4]byte{}
+		copy(word[:], b[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
+
+// ttfWrite writes the table directory and the tables, and sets the checksum adjustment of the head table.
+func ttfWrite(w io.Writer, tables map[string][]byte) error {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	n := len(tags)
+	searchRange := 1 << uint(math.Floor(math.Log2(float64(n))))
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.BigEndian, []uint16{0x0001, 0x0000, uint16(n), uint16(16 * searchRange), uint16(math.Log2(float64(searchRange))), uint16(16 * (n - searchRange))})
+
+	headOffset := 0
+	offset := 12 + 16*n
+	for _, tag := range tags {
+		if tag == "head" {
+			headOffset = offset
+		}
+		buf.WriteString(tag)
+		binary.Write(buf, binary.BigEndian, []uint32{ttfChecksum(tables[tag]), uint32(offset), uint32(len(tables[tag]))})
+		offset += (len(tables[tag]) + 3) &^ 3
+	}
+	for _, tag := range tags {
+		buf.Write(tables[tag])
+		for buf.Len()%4 != 0 {
+			buf.WriteByte(0)
+		}
+	}
+
+	font := buf.Bytes()
+	binary.BigEndian.PutUint32(font[headOffset+8:], 0xB1B0AFBA-ttfChecksum(font))
+	_, err := w.Write(font)
+	return err
+}
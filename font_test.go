@@ -1,8 +1,11 @@
 package canvas
 
 import (
+	"os"
+	"sync"
 	"testing"
 
+	"github.com/tdewolff/canvas/text"
 	"github.com/tdewolff/test"
 )
 
@@ -28,6 +31,34 @@ func TestFontFamily(t *testing.T) {
 	//test.T(t, face.Style.CSS(), 1000)
 }
 
+func TestFontMalformed(t *testing.T) {
+	b, err := os.ReadFile("resources/DejaVuSerif.ttf")
+	test.Error(t, err)
+	_, err = LoadFont(b[:len(b)/2], 0, FontRegular)
+	test.That(t, err != nil, "truncated font must fail to load")
+
+	corrupt := append([]byte{}, b...)
+	corrupt[len(corrupt)/2] ^= 0xFF
+	_, err = LoadFont(corrupt, 0, FontRegular)
+	test.That(t, err != nil, "corrupted font must fail to load")
+
+	// glyphs that cannot be read are reported instead of panicking
+	family := NewFontFamily("dejavu-serif")
+	test.Error(t, family.LoadFont(b, 0, FontRegular))
+	face := family.Face(12.0*ptPerMm, Black, FontRegular, FontNormal)
+	text := NewTextLine(face, "a", Left)
+	text.lines[0].spans[0].Glyphs[0].ID = 0xFFFF
+
+	c := New(100.0, 100.0)
+	ctx := NewContext(c)
+	ctx.DrawText(0.0, 0.0, text)
+	test.That(t, ctx.Err() != nil)
+	test.T(t, len(c.layers[0]), 0)
+
+	text.RenderAsPath(c, Identity, 0.0)
+	test.T(t, len(c.layers[0]), 0)
+}
+
 func TestFontFace(t *testing.T) {
 	family := NewFontFamily("dejavu-serif")
 	if err := family.LoadFontFile("resources/DejaVuSerif.ttf", FontRegular); err != nil {
@@ -87,3 +118,63 @@ func TestFontDecoration(t *testing.T) {
 	test.T(t, face.Decorate(809.0), MustParseSVGPath("M0 -265L809 -265L809 -175L0 -175z"))
 	test.T(t, face.Decorate(810.0), MustParseSVGPath("M0 -265L270 -265L270 -175L0 -175zM540 -265L810 -265L810 -175L540 -175z"))
 }
+
+func TestFontConcurrentShaping(t *testing.T) {
+	family := NewFontFamily("dejavu-serif")
+	if err := family.LoadFontFile("resources/DejaVuSerif.ttf", FontRegular); err != nil {
+		test.Error(t, err)
+	}
+	face := family.Face(12.0, Black, FontRegular, FontNormal)
+	p, w, _ := face.ToPath("concurrent")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p2, w2, _ := face.ToPath("concurrent")
+			test.T(t, p2, p)
+			test.Float(t, w2, w)
+		}()
+	}
+	wg.Wait()
+
+	// cached glyphs are copied
+	glyphs := face.Font.shape("concurrent", face.PPEM(DefaultResolution), face.Direction, face.Script, face.Language)
+	glyphs[0].XAdvance = 0
+	test.T(t, face.TextWidth("concurrent"), w)
+}
+
+func TestFontUnshaped(t *testing.T) {
+	font, err := LoadFontFile("resources/DejaVuSerif.ttf", FontRegular)
+	test.Error(t, err)
+
+	glyphs := font.unshaped("aé", text.LeftToRight)
+	test.T(t, len(glyphs), 2)
+	test.T(t, glyphs[0].ID, font.GlyphIndex('a'))
+	test.T(t, glyphs[0].XAdvance, int32(font.GlyphAdvance(glyphs[0].ID)))
+	test.T(t, glyphs[1].Cluster, uint32(1))
+	test.T(t, glyphs[1].Text, 'é')
+
+	glyphs = font.unshaped("aé", text.RightToLeft)
+	test.T(t, glyphs[0].Cluster, uint32(1))
+	test.T(t, glyphs[1].Cluster, uint32(0))
+}
+
+func TestFontBuilder(t *testing.T) {
+	b := NewFontBuilder("squares", 1000)
+	b.AddGlyph('A', Rectangle(600.0, 700.0).Translate(50.0, 0.0), 700.0)
+	b.AddGlyph('B', Circle(300.0).Translate(350.0, 350.0), 700.0)
+	b.AddGlyph(' ', nil, 250.0)
+
+	font, err := b.Font()
+	test.Error(t, err)
+	test.T(t, font.Head.UnitsPerEm, uint16(1000))
+
+	face := font.Face(ptPerMm*1000.0, Black)
+	test.Float(t, face.TextWidth("AB A"), 2350.0)
+
+	p, _, err := face.ToPath("A")
+	test.Error(t, err)
+	test.T(t, p.Bounds(), Rect{50.0, 0.0, 600.0, 700.0})
+}
@@ -0,0 +1,177 @@
+package canvas
+
+import (
+	"math"
+	"sort"
+)
+
+// ConvexHull returns the convex hull of the points as a counter clockwise polygon. Collinear points on the hull are omitted.
+func ConvexHull(points []Point) *Path {
+	if len(points) < 3 {
+		return &Path{}
+	}
+	points = append([]Point{}, points...)
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].X < points[j].X || points[i].X == points[j].X && points[i].Y < points[j].Y
+	})
+
+	// Andrew's monotone chain algorithm, building the lower and upper hull
+	hull := []Point{}
+	for k := 0; k < 2; k++ {
+		start := len(hull)
+		for _, p := range points {
+			for start+2 <= len(hull) && hull[len(hull)-1].Sub(hull[len(hull)-2]).PerpDot(p.Sub(hull[len(hull)-2])) <= 0.0 {
+				hull = hull[:len(hull)-1]
+			}
+			hull = append(hull, p)
+		}
+		hull = hull[:len(hull)-1] // the last point is the first point of the other half
+		for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+			points[i], points[j] = points[j], points[i]
+		}
+	}
+
+	p := &Path{}
+	if len(hull) < 3 {
+		return p
+	}
+	for i, point := range hull {
+		if i == 0 {
+			p.MoveTo(point.X, point.Y)
+		} else {
+			p.LineTo(point.X, point.Y)
+		}
+	}
+	p.Close()
+	return p
+}
+
+// AlphaShape returns the concave hull of the points, also known as the alpha shape, which is useful to outline scattered points in plots and maps. The Delaunay triangles of the points are kept when their circumradius is at most alpha, and the boundary of the kept triangles is returned. Small values of alpha give a tight outline that may split into several polygons and contain holes, while large values approach the convex hull. Outer boundaries are counter clockwise and holes are clockwise.
+func AlphaShape(points []Point, alpha float64) *Path {
+	vertices, triangles := delaunay(points)
+
+	// directed edges of the kept triangles, where an edge is on the boundary if its twin is absent
+	edges := map[[2]int]bool{}
+	for _, tri := range triangles {
+		if tri.r2 <= alpha*alpha {
+			for i := 0; i < 3; i++ {
+				edges[[2]int{tri.v[i], tri.v[(i+1)%3]}] = true
+			}
+		}
+	}
+	outgoing := map[int][]int{}
+	for edge := range edges {
+		if !edges[[2]int{edge[1], edge[0]}] {
+			outgoing[edge[0]] = append(outgoing[edge[0]], edge[1])
+		}
+	}
+
+	starts := make([]int, 0, len(outgoing))
+	for v := range outgoing {
+		starts = append(starts, v)
+		sort.Ints(outgoing[v])
+	}
+	sort.Ints(starts) // deterministic output
+
+	angle := func(a, b int) float64 {
+		d := vertices[b].Sub(vertices[a])
+		return math.Atan2(d.Y, d.X)
+	}
+	p := &Path{}
+	for _, v0 := range starts {
+		for 0 < len(outgoing[v0]) {
+			p.MoveTo(vertices[v0].X, vertices[v0].Y)
+			a, b := v0, outgoing[v0][0]
+			outgoing[v0] = outgoing[v0][1:]
+			for b != v0 {
+				p.LineTo(vertices[b].X, vertices[b].Y)
+
+				// at vertices where triangles touch, take the first edge clockwise from the incoming edge to keep the rings simple
+				k, kAngle := 0, 0.0
+				back := angle(b, a)
+				for i, c := range outgoing[b] {
+					d := math.Mod(back-angle(b, c)+2.0*math.Pi, 2.0*math.Pi)
+					if i == 0 || d < kAngle {
+						k, kAngle = i, d
+					}
+				}
+				a, b = b, outgoing[b][k]
+				outgoing[a] = append(outgoing[a][:k:k], outgoing[a][k+1:]...)
+			}
+			p.Close()
+		}
+	}
+	return p
+}
+
+type delaunayTriangle struct {
+	v  [3]int // counter clockwise
+	c  Point  // circumcenter
+	r2 float64
+}
+
+func newDelaunayTriangle(vertices []Point, a, b, c int) delaunayTriangle {
+	p0, p1, p2 := vertices[a], vertices[b], vertices[c]
+	d1, d2 := p1.Sub(p0), p2.Sub(p0)
+	denom := 2.0 * d1.PerpDot(d2)
+	center := Point{
+		(d2.Y*d1.Dot(d1) - d1.Y*d2.Dot(d2)) / denom,
+		(d1.X*d2.Dot(d2) - d2.X*d1.Dot(d1)) / denom,
+	}
+	return delaunayTriangle{[3]int{a, b, c}, p0.Add(center), center.Dot(center)}
+}
+
+// delaunay returns the Delaunay triangulation of the points using the Bowyer-Watson algorithm, and the vertices that are the points without duplicates.
+func delaunay(points []Point) ([]Point, []delaunayTriangle) {
+	vertices := []Point{}
+	seen := map[Point]bool{}
+	for _, p := range points {
+		if !seen[p] {
+			seen[p] = true
+			vertices = append(vertices, p)
+		}
+	}
+	n := len(vertices)
+	if n < 3 {
+		return vertices, nil
+	}
+
+	// super triangle that contains all points
+	bounds := Rect{vertices[0].X, vertices[0].Y, 0.0, 0.0}
+	for _, p := range vertices[1:] {
+		bounds = bounds.AddPoint(p)
+	}
+	c := Point{bounds.X + bounds.W/2.0, bounds.Y + bounds.H/2.0}
+	m := math.Max(math.Max(bounds.W, bounds.H), 1.0)
+	vertices = append(vertices, Point{c.X - 20.0*m, c.Y - m}, Point{c.X + 20.0*m, c.Y - m}, Point{c.X, c.Y + 20.0*m})
+	triangles := []delaunayTriangle{newDelaunayTriangle(vertices, n, n+1, n+2)}
+
+	for i, p := range vertices[:n] {
+		// remove triangles whose circumcircle contains the point and fill the cavity
+		edges := map[[2]int]int{}
+		kept := triangles[:0]
+		for _, tri := range triangles {
+			if d := p.Sub(tri.c); d.Dot(d) < tri.r2 {
+				for j := 0; j < 3; j++ {
+					edges[[2]int{tri.v[j], tri.v[(j+1)%3]}]++
+				}
+			} else {
+				kept = append(kept, tri)
+			}
+		}
+		triangles = kept
+		for edge := range edges {
+			if edges[[2]int{edge[1], edge[0]}] == 0 {
+				triangles = append(triangles, newDelaunayTriangle(vertices, edge[0], edge[1], i))
+			}
+		}
+	}
+
+	kept := triangles[:0]
+	for _, tri := range triangles {
+		if tri.v[0] < n && tri.v[1] < n && tri.v[2] < n {
+			kept = append(kept, tri)
+		}
+	}
+	return vertices[:n], kept
+}
@@ -0,0 +1,34 @@
+package canvas
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestConvexHull(t *testing.T) {
+	hull := ConvexHull([]Point{{0.0, 0.0}, {5.0, 5.0}, {10.0, 0.0}, {10.0, 10.0}, {0.0, 10.0}, {5.0, 0.0}, {10.0, 0.0}})
+	test.T(t, hull, MustParseSVGPath("M0 0L10 0L10 10L0 10z"))
+	test.T(t, ConvexHull([]Point{{0.0, 0.0}, {5.0, 5.0}, {10.0, 10.0}}), &Path{})
+	test.T(t, ConvexHull(nil), &Path{})
+	test.T(t, ConvexHull([]Point{}), &Path{})
+	test.T(t, ConvexHull([]Point{{1.0, 2.0}}), &Path{})
+}
+
+func TestAlphaShape(t *testing.T) {
+	points := []Point{}
+	for _, x := range []float64{0.0, 20.0} {
+		points = append(points, Point{x, 0.0}, Point{x + 1.0, 0.1}, Point{x + 1.1, 1.0}, Point{x, 0.9}, Point{x + 0.5, 0.5})
+	}
+
+	shape := AlphaShape(points, 1.0)
+	test.T(t, len(shape.Split()), 2)
+	test.T(t, shape.Bounds(), Rect{0.0, 0.0, 21.1, 1.0})
+	test.That(t, shape.CCW(), "outer boundaries must be counter clockwise")
+
+	hull := AlphaShape(points, 1000.0)
+	test.T(t, len(hull.Split()), 1)
+	test.T(t, hull.Bounds(), Rect{0.0, 0.0, 21.1, 1.0})
+
+	test.T(t, AlphaShape(points, 0.1), &Path{})
+}
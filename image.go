@@ -2,10 +2,16 @@ package canvas
 
 import (
 	"bytes"
+	"encoding/binary"
 	"image"
+	"image/color"
+	"image/draw"
 	"image/jpeg"
 	"image/png"
 	"io"
+	"math"
+
+	"golang.org/x/image/vector"
 )
 
 // ImageEncoding defines whether the embedded image shall be embedded as lossless (typically PNG) or lossy (typically JPG).
@@ -17,11 +23,74 @@ const (
 	Lossy
 )
 
-// Image is a raster image. Keeping the original bytes allows the renderer to optimize rendering in some cases.
+// ImageFilter is the resampling filter used when rendering transformed (e.g. scaled or rotated) raster images. Not all renderers support all filters, in which case they fall back to smooth interpolation. DefaultImageFilter uses the renderer's default, which is Catmull-Rom for the rasterizer.
+type ImageFilter int
+
+// see ImageFilter
+const (
+	DefaultImageFilter ImageFilter = iota
+	NearestNeighbor
+	Bilinear
+	CatmullRom
+	Lanczos
+)
+
+func (filter ImageFilter) String() string {
+	switch filter {
+	case NearestNeighbor:
+		return "NearestNeighbor"
+	case Bilinear:
+		return "Bilinear"
+	case CatmullRom:
+		return "CatmullRom"
+	case Lanczos:
+		return "Lanczos"
+	}
+	return "Default"
+}
+
+// Image is a raster image. Keeping the original bytes allows the renderer to optimize rendering in some cases. Filter is the resampling filter that renderers should use.
 type Image struct {
 	image.Image
 	Mimetype string
 	Bytes    []byte
+	Filter   ImageFilter
+}
+
+// ImageFilterOf returns the resampling filter of an image, which is set only for images of type Image.
+func ImageFilterOf(img image.Image) ImageFilter {
+	if cimg, ok := img.(Image); ok {
+		return cimg.Filter
+	}
+	return DefaultImageFilter
+}
+
+// imageWithFilter returns the image with the given resampling filter. The default filter does not override the filter of an Image.
+func imageWithFilter(img image.Image, filter ImageFilter) image.Image {
+	if filter == DefaultImageFilter {
+		return img
+	} else if cimg, ok := img.(Image); ok {
+		cimg.Filter = filter
+		return cimg
+	}
+	return Image{Image: img, Filter: filter}
+}
+
+// subImage returns the part of the image within the given rectangle.
+func subImage(img image.Image, rect image.Rectangle) image.Image {
+	if cimg, ok := img.(Image); ok {
+		cimg.Image = subImage(cimg.Image, rect)
+		cimg.Mimetype = ""
+		cimg.Bytes = nil
+		return cimg
+	} else if subimg, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return subimg.SubImage(rect)
+	}
+	dst := image.NewRGBA(rect)
+	draw.Draw(dst, rect, img, rect.Min, draw.Src)
+	return dst
 }
 
 // NewJPEGImage parses a JPEG image.
@@ -45,3 +114,266 @@ func newImage(mimetype string, decode func(io.Reader) (image.Image, error), r io
 		Mimetype: mimetype,
 	}, err
 }
+
+// ExifOrientation returns the EXIF orientation of a JPEG image, which is a value between 1 and 8 that specifies how the image must be rotated and/or flipped for display. It returns 1 (no transformation) if the orientation is not present.
+func (img Image) ExifOrientation() int {
+	if img.Mimetype != "image/jpeg" {
+		return 1
+	}
+	return jpegExifOrientation(img.Bytes)
+}
+
+func jpegExifOrientation(b []byte) int {
+	if len(b) < 4 || b[0] != 0xFF || b[1] != 0xD8 {
+		return 1
+	}
+	i := 2
+	for i+4 <= len(b) && b[i] == 0xFF {
+		marker := b[i+1]
+		n := int(binary.BigEndian.Uint16(b[i+2:]))
+		if marker == 0xDA || marker == 0xD9 || n < 2 || len(b) < i+2+n {
+			break // start of scan or end of image
+		} else if marker == 0xE1 && 14 <= n && string(b[i+4:i+10]) == "Exif\x00\x00" {
+			return tiffOrientation(b[i+10 : i+2+n])
+		}
+		i += 2 + n
+	}
+	return 1
+}
+
+func tiffOrientation(b []byte) int {
+	if len(b) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	if string(b[:2]) == "II" {
+		order = binary.LittleEndian
+	} else if string(b[:2]) == "MM" {
+		order = binary.BigEndian
+	} else {
+		return 1
+	}
+	ifd := int(order.Uint32(b[4:]))
+	if ifd < 8 || len(b) < ifd+2 {
+		return 1
+	}
+	n := int(order.Uint16(b[ifd:]))
+	for i := 0; i < n; i++ {
+		entry := ifd + 2 + i*12
+		if len(b) < entry+12 {
+			break
+		} else if order.Uint16(b[entry:]) == 0x0112 {
+			if orientation := int(order.Uint16(b[entry+8:])); 1 <= orientation && orientation <= 8 {
+				return orientation
+			}
+			break
+		}
+	}
+	return 1
+}
+
+// OrientImage returns the image rotated and/or flipped according to the given EXIF orientation, so that it is displayed upright.
+func OrientImage(img image.Image, orientation int) image.Image {
+	if orientation < 2 || 8 < orientation {
+		return img
+	}
+
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if 5 <= orientation {
+		w, h = h, w // rotated by 90 degrees
+	}
+	W, H := bounds.Dx()-1, bounds.Dy()-1
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // flip horizontally
+				sx, sy = W-x, y
+			case 3: // rotate 180 degrees
+				sx, sy = W-x, H-y
+			case 4: // flip vertically
+				sx, sy = x, H-y
+			case 5: // transpose
+				sx, sy = y, x
+			case 6: // rotate 90 degrees clockwise
+				sx, sy = y, H-x
+			case 7: // transverse
+				sx, sy = W-y, H-x
+			case 8: // rotate 90 degrees counter clockwise
+				sx, sy = W-y, x
+			}
+			dst.Set(x, y, img.At(bounds.Min.X+sx, bounds.Min.Y+sy))
+		}
+	}
+	return imageWithFilter(dst, ImageFilterOf(img))
+}
+
+// ClipImage returns the image with all pixels outside of the clipping path made transparent. The clipping path is in millimeters with the origin at the bottom-left of the image, and the image has the given resolution.
+func ClipImage(img image.Image, clip *Path, resolution Resolution) image.Image {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return img
+	}
+
+	mask := image.NewAlpha(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	ras := vector.NewRasterizer(bounds.Dx(), bounds.Dy())
+	clip.ToRasterizer(ras, resolution)
+	ras.Draw(mask, mask.Bounds(), image.Opaque, image.Point{})
+
+	dst := image.NewRGBA(mask.Bounds())
+	draw.DrawMask(dst, dst.Bounds(), img, bounds.Min, mask, image.Point{}, draw.Over)
+	return imageWithFilter(dst, ImageFilterOf(img))
+}
+
+// FeatherMask returns a soft alpha mask of the filled path for an image of the given size in pixels, such as for vignettes or soft clipping. The alpha fades smoothly from opaque at radius millimeters inside the outline to transparent at radius millimeters outside the outline, following the signed distance to the outline. The path is in millimeters with the origin at the bottom-left of the image, and the image has the given resolution. The mask can be used with draw.DrawMask or FeatherImage.
+func FeatherMask(path *Path, radius float64, size image.Point, resolution Resolution) *image.Alpha {
+	mask := image.NewAlpha(image.Rect(0, 0, size.X, size.Y))
+	if size.X <= 0 || size.Y <= 0 {
+		return mask
+	}
+	ras := vector.NewRasterizer(size.X, size.Y)
+	path.ToRasterizer(ras, resolution)
+	ras.Draw(mask, mask.Bounds(), image.Opaque, image.Point{})
+	if radius <= 0.0 {
+		return mask
+	}
+
+	inside := make([]bool, len(mask.Pix))
+	outside := make([]bool, len(mask.Pix))
+	for i, a := range mask.Pix {
+		inside[i] = 128 <= a
+		outside[i] = !inside[i]
+	}
+	distInside := distanceTransform(inside, size.X, size.Y)
+	distOutside := distanceTransform(outside, size.X, size.Y)
+
+	dpmm := resolution.DPMM()
+	for i := range mask.Pix {
+		// signed distance in millimeters from the pixel center to the outline, positive inside
+		var d float64
+		if inside[i] {
+			d = (math.Sqrt(distOutside[i]) - 0.5) / dpmm
+		} else {
+			d = -(math.Sqrt(distInside[i]) - 0.5) / dpmm
+		}
+		t := math.Min(math.Max(0.5+0.5*d/radius, 0.0), 1.0)
+		mask.Pix[i] = uint8(t*t*(3.0-2.0*t)*255.0 + 0.5)
+	}
+	return mask
+}
+
+// FeatherImage returns the image with a soft clipping path applied, so that pixels fade out towards the outline of the path, see FeatherMask.
+func FeatherImage(img image.Image, path *Path, radius float64, resolution Resolution) image.Image {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return img
+	}
+
+	mask := FeatherMask(path, radius, bounds.Size(), resolution)
+	dst := image.NewRGBA(mask.Bounds())
+	draw.DrawMask(dst, dst.Bounds(), img, bounds.Min, mask, image.Point{}, draw.Over)
+	return imageWithFilter(dst, ImageFilterOf(img))
+}
+
+// distanceTransform returns the squared Euclidean distance in pixels from each pixel to the nearest pixel in the set, using the algorithm of Felzenszwalb and Huttenlocher. Distances are very large when the set is empty.
+func distanceTransform(set []bool, w, h int) []float64 {
+	const inf = 1e20
+	dist := make([]float64, w*h)
+	for i, in := range set {
+		if !in {
+			dist[i] = inf
+		}
+	}
+
+	n := max(w, h)
+	f, d := make([]float64, n), make([]float64, n)
+	v, z := make([]int, n), make([]float64, n+1)
+	transform := func(f, d []float64) {
+		// lower envelope of the parabolas rooted at each f
+		k := 0
+		v[0], z[0], z[1] = 0, -inf, inf
+		for q := 1; q < len(f); q++ {
+			s := ((f[q] + float64(q*q)) - (f[v[k]] + float64(v[k]*v[k]))) / float64(2*q-2*v[k])
+			for s <= z[k] {
+				k--
+				s = ((f[q] + float64(q*q)) - (f[v[k]] + float64(v[k]*v[k]))) / float64(2*q-2*v[k])
+			}
+			k++
+			v[k], z[k], z[k+1] = q, s, inf
+		}
+		k = 0
+		for q := range f {
+			for z[k+1] < float64(q) {
+				k++
+			}
+			d[q] = float64((q-v[k])*(q-v[k])) + f[v[k]]
+		}
+	}
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			f[y] = dist[y*w+x]
+		}
+		transform(f[:h], d[:h])
+		for y := 0; y < h; y++ {
+			dist[y*w+x] = d[y]
+		}
+	}
+	for y := 0; y < h; y++ {
+		transform(dist[y*w:(y+1)*w], d[:w])
+		copy(dist[y*w:(y+1)*w], d[:w])
+	}
+	return dist
+}
+
+// renderImageQuad renders an image mapped onto a quadrilateral, see ImageQuadRenderer. If the renderer doesn't support projective transformations, the image is split into a mesh of slices that are each transformed affinely. Degenerate quadrilaterals cover no area and are skipped.
+func renderImageQuad(r Renderer, img image.Image, q [4]Point) {
+	if IsDegenerateQuad(q) {
+		return
+	} else if qr, ok := r.(ImageQuadRenderer); ok {
+		qr.RenderImageQuad(img, q)
+		return
+	}
+
+	// maps the unit square to the quadrilateral, with (0,0) the image's top-left
+	t := SquareToQuad(q)
+	size := img.Bounds().Size()
+	w, h := float64(size.X), float64(size.Y)
+	n := 1
+	if !t.IsAffine() {
+		n = 16
+	}
+	nx, ny := min(n, size.X), min(n, size.Y)
+	filter := ImageFilterOf(img)
+	for j := 0; j < ny; j++ {
+		y0, y1 := size.Y*j/ny, size.Y*(j+1)/ny
+		for i := 0; i < nx; i++ {
+			x0, x1 := size.X*i/nx, size.X*(i+1)/nx
+			rect := image.Rect(x0, y0, x1, y1).Add(img.Bounds().Min)
+
+			// map the slice's bottom-left, bottom-right, and top-left corners
+			p0 := t.Dot(Point{float64(x0) / w, float64(y1) / h})
+			p1 := t.Dot(Point{float64(x1) / w, float64(y1) / h})
+			p2 := t.Dot(Point{float64(x0) / w, float64(y0) / h})
+			dx, dy := float64(x1-x0), float64(y1-y0)
+			m := Matrix{
+				{(p1.X - p0.X) / dx, (p2.X - p0.X) / dy, p0.X},
+				{(p1.Y - p0.Y) / dx, (p2.Y - p0.Y) / dy, p0.Y},
+			}
+			r.RenderImage(imageWithFilter(subImage(img, rect), filter), m)
+		}
+	}
+}
+
+// imageWithOpacity returns the image with its alpha multiplied by opacity ∈ [0,1].
+func imageWithOpacity(img image.Image, opacity float64) image.Image {
+	if 1.0 <= opacity {
+		return img
+	}
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	mask := image.NewUniform(color.Alpha{uint8(opacity*255.0 + 0.5)})
+	draw.DrawMask(dst, dst.Bounds(), img, bounds.Min, mask, image.Point{}, draw.Src)
+	return imageWithFilter(dst, ImageFilterOf(img))
+}
@@ -0,0 +1,22 @@
+package canvas
+
+import (
+	"image"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestFeatherMask(t *testing.T) {
+	rect := Rectangle(50.0, 50.0).Translate(25.0, 25.0)
+	mask := FeatherMask(rect, 10.0, image.Point{100, 100}, DPMM(1.0))
+	test.T(t, mask.AlphaAt(50, 50).A, uint8(255))
+	test.T(t, mask.AlphaAt(1, 1).A, uint8(0))
+	test.T(t, mask.AlphaAt(50, 74).A, uint8(137)) // 0.5mm inside
+	test.T(t, mask.AlphaAt(50, 75).A, uint8(118)) // 0.5mm outside
+	test.That(t, mask.AlphaAt(50, 70).A < mask.AlphaAt(50, 65).A)
+
+	mask = FeatherMask(rect, 0.0, image.Point{100, 100}, DPMM(1.0))
+	test.T(t, mask.AlphaAt(50, 74).A, uint8(255))
+	test.T(t, mask.AlphaAt(50, 75).A, uint8(0))
+}
@@ -0,0 +1,209 @@
+package canvas
+
+import (
+	"image"
+	"math"
+	"math/bits"
+)
+
+// TraceOptions specifies how an image is traced into paths, see TraceImage.
+type TraceOptions struct {
+	Threshold       float64 // in [0,1], pixels with a luminance below the threshold are traced, where transparent pixels are white
+	Invert          bool    // trace pixels with a luminance above the threshold instead
+	MinArea         float64 // area in pixels of regions and holes that are removed, such as specks
+	Tolerance       float64 // maximum deviation in pixels of the polygon from the outline through the midpoints of the pixel edges, below 0.42 to keep sharp corners
+	CornerThreshold float64 // in [0,4/3], corners with smaller angles are drawn as curves, where zero keeps all corners sharp and 4/3 draws no corners at all
+}
+
+// DefaultTraceOptions are the default options for tracing images, similar to those of potrace.
+var DefaultTraceOptions = TraceOptions{
+	Threshold:       0.5,
+	MinArea:         2.0,
+	Tolerance:       0.35,
+	CornerThreshold: 1.0,
+}
+
+// TraceImage converts an image into paths by tracing the outlines of its dark pixels, such as for scanned logos or masks. The pixels are thresholded by their luminance and the outlines between dark and light pixels are followed, where diagonally touching dark pixels are connected. The outlines are simplified into polygons and their vertices are fitted with cubic Béziers except for sharp corners, similar to potrace. The paths are in millimeters with the origin at the bottom-left of the image, and the image has the given resolution. Outer outlines are counter clockwise and holes are clockwise, so that the paths can be filled using either the NonZero or EvenOdd fill rule.
+func TraceImage(img image.Image, resolution Resolution, options TraceOptions) *Path {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w == 0 || h == 0 {
+		return &Path{}
+	}
+
+	dark := make([]bool, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// luminance of premultiplied color over a white background
+			r, g, b, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			lum := (0.2126*float64(r)+0.7152*float64(g)+0.0722*float64(b))/65535.0 + 1.0 - float64(a)/65535.0
+			dark[y*w+x] = (lum < options.Threshold) != options.Invert
+		}
+	}
+	isDark := func(x, y int) bool {
+		return 0 <= x && x < w && 0 <= y && y < h && dark[y*w+x]
+	}
+
+	// outgoing edges of the pixel corners as bits for right, down, left, and up respectively, with the Y axis pointing down and the dark pixels on the left side of the edges
+	dx := [4]int{1, 0, -1, 0}
+	dy := [4]int{0, 1, 0, -1}
+	out := make([]uint8, (w+1)*(h+1))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if !dark[y*w+x] {
+				continue
+			}
+			if !isDark(x, y-1) {
+				out[y*(w+1)+x+1] |= 1 << 2
+			}
+			if !isDark(x-1, y) {
+				out[y*(w+1)+x] |= 1 << 1
+			}
+			if !isDark(x, y+1) {
+				out[(y+1)*(w+1)+x] |= 1 << 0
+			}
+			if !isDark(x+1, y) {
+				out[(y+1)*(w+1)+x+1] |= 1 << 3
+			}
+		}
+	}
+
+	p := &Path{}
+	for v := range out {
+		// start at corners with one outgoing edge, every outline has at least one
+		for bits.OnesCount8(out[v]) == 1 {
+			x, y := v%(w+1), v/(w+1)
+			d := bits.TrailingZeros8(out[v])
+			points := []Point{}
+			area := 0.0
+			for {
+				out[y*(w+1)+x] &^= 1 << d
+				points = append(points, Point{float64(x) + 0.5*float64(dx[d]), float64(y) + 0.5*float64(dy[d])})
+				area += float64(x*(y+dy[d]) - (x+dx[d])*y)
+				x, y = x+dx[d], y+dy[d]
+
+				// prefer turning right to connect diagonally touching pixels
+				avail := out[y*(w+1)+x]
+				if avail == 0 {
+					break
+				}
+				for _, turn := range []int{1, 0, 3} {
+					if next := (d + turn) % 4; avail&(1<<next) != 0 {
+						d = next
+						break
+					}
+				}
+			}
+			if math.Abs(area)/2.0 <= options.MinArea {
+				continue
+			}
+
+			polygon := simplifyPolygon(points, math.Max(options.Tolerance, 0.0))
+			polygon = traceCorners(polygon)
+			if len(polygon) < 3 {
+				continue
+			}
+			traceSmooth(p, polygon, options.CornerThreshold)
+		}
+	}
+
+	dpmm := resolution.DPMM()
+	return p.Transform(Identity.Scale(1.0/dpmm, -1.0/dpmm).Translate(0.0, -float64(h)))
+}
+
+// simplifyPolygon simplifies a closed polygon using the Ramer-Douglas-Peucker algorithm so that it deviates no more than tolerance.
+func simplifyPolygon(points []Point, tolerance float64) []Point {
+	if len(points) < 3 {
+		return points
+	}
+
+	// split at the point farthest from the first point, which are both kept
+	far, farDist := 0, 0.0
+	for i, point := range points {
+		if dist := point.Sub(points[0]).Length(); farDist < dist {
+			far, farDist = i, dist
+		}
+	}
+	if far == 0 {
+		return points[:1]
+	}
+	a := simplifyPolyline(points[:far+1:far+1], tolerance)
+	b := simplifyPolyline(append(points[far:len(points):len(points)], points[0]), tolerance)
+	polygon := append([]Point{}, a...)
+	return append(polygon, b[1:len(b)-1]...)
+}
+
+// traceCorners restores the corners of a traced polygon. Outlines through the midpoints of the pixel edges cut off corners by a short segment, which is replaced by the intersection of the neighbouring long segments.
+func traceCorners(polygon []Point) []Point {
+	n := len(polygon)
+	if n < 4 {
+		return polygon
+	}
+	length := func(i int) float64 {
+		return polygon[(i+1)%n].Sub(polygon[i%n]).Length()
+	}
+
+	corners := make([]Point, n)
+	cut := make([]bool, n)
+	for i := 0; i < n; i++ {
+		if 1.0 < length(i) || length(i+n-1) <= 1.5 || length(i+1) <= 1.5 || cut[(i+n-1)%n] {
+			continue
+		}
+		a0, a1 := polygon[(i+n-1)%n], polygon[i]
+		b0, b1 := polygon[(i+1)%n], polygon[(i+2)%n]
+		da, db := a1.Sub(a0), b1.Sub(b0)
+		denom := da.PerpDot(db)
+		if Equal(denom, 0.0) {
+			continue
+		}
+		corner := a0.Add(da.Mul(b0.Sub(a0).PerpDot(db) / denom))
+		if 1.0 < corner.Sub(a1.Interpolate(b0, 0.5)).Length() {
+			continue
+		}
+		corners[i], cut[i] = corner, true
+	}
+
+	restored := []Point{}
+	for i := 0; i < n; i++ {
+		if cut[i] {
+			restored = append(restored, corners[i])
+		} else if !cut[(i+n-1)%n] {
+			restored = append(restored, polygon[i])
+		}
+	}
+	return restored
+}
+
+// traceSmooth appends the closed polygon to p, where vertices are replaced by cubic Béziers between the midpoints of their edges unless they form a corner, following potrace.
+func traceSmooth(p *Path, polygon []Point, cornerThreshold float64) {
+	n := len(polygon)
+	mid := func(i int) Point {
+		return polygon[i%n].Interpolate(polygon[(i+1)%n], 0.5)
+	}
+
+	start := mid(n - 1)
+	p.MoveTo(start.X, start.Y)
+	for i := 0; i < n; i++ {
+		prev, cur, next := polygon[(i+n-1)%n], polygon[i], polygon[(i+1)%n]
+		a, b := mid(i+n-1), mid(i)
+
+		alpha := 4.0 / 3.0
+		if denom := math.Abs(next.X-prev.X) + math.Abs(next.Y-prev.Y); denom != 0.0 {
+			dd := math.Abs(cur.Sub(prev).PerpDot(next.Sub(prev))) / denom
+			alpha = 0.0
+			if 1.0 < dd {
+				alpha = (1.0 - 1.0/dd) / 0.75
+			}
+		}
+		if cornerThreshold <= alpha {
+			p.LineTo(cur.X, cur.Y)
+			p.LineTo(b.X, b.Y)
+		} else {
+			alpha = math.Min(math.Max(alpha, 0.55), 1.0)
+			c1 := a.Add(cur.Sub(a).Mul(alpha))
+			c2 := b.Add(cur.Sub(b).Mul(alpha))
+			p.CubeTo(c1.X, c1.Y, c2.X, c2.Y, b.X, b.Y)
+		}
+	}
+	p.Close()
+}
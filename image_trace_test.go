@@ -0,0 +1,35 @@
+package canvas
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestTraceImage(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 10, 10))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(3, 2, 7, 6), image.Black, image.Point{}, draw.Src)
+	img.SetGray(9, 9, color.Gray{0}) // speck
+
+	p := TraceImage(img, DPMM(1.0), DefaultTraceOptions)
+	test.T(t, len(p.Split()), 1)
+	test.T(t, p.Bounds(), Rect{3.0, 4.0, 4.0, 4.0})
+	test.That(t, p.CCW())
+	test.That(t, p.Fills(5.0, 6.0, NonZero))
+
+	// hole
+	draw.Draw(img, image.Rect(4, 3, 6, 5), image.White, image.Point{}, draw.Src)
+	p = TraceImage(img, DPMM(1.0), TraceOptions{Threshold: 0.5, Tolerance: 0.35, CornerThreshold: 1.0})
+	test.T(t, len(p.Split()), 3)
+	test.That(t, p.Fills(3.5, 6.0, NonZero))
+	test.That(t, !p.Fills(5.0, 6.0, NonZero))
+	test.That(t, p.Fills(9.5, 0.5, NonZero))
+
+	p = TraceImage(img, DPMM(1.0), TraceOptions{Threshold: 0.5, Invert: true})
+	test.That(t, !p.Fills(3.5, 6.0, NonZero))
+	test.That(t, p.Fills(5.0, 6.0, NonZero))
+}
@@ -0,0 +1,153 @@
+package canvas
+
+import (
+	"math"
+)
+
+// InkPoint is a sample of a stylus or pointer, with the time in seconds, the pressure in [0,1], and the tilt in degrees between the stylus and the normal of the surface.
+type InkPoint struct {
+	X, Y     float64
+	Time     float64
+	Pressure float64
+	Tilt     float64
+}
+
+// InkStroke builds a variable-width stroke from stylus samples, such as for note-taking and whiteboard applications. The samples are smoothed and the width varies with the pressure, tilt, and speed of the stylus. The outline is returned as a path that should be filled using the NonZero fill rule.
+type InkStroke struct {
+	Width       float64 // width at full pressure
+	Thinning    float64 // in [0,1], the effect of pressure on the width, where 0 ignores pressure and 1 gives zero width at zero pressure
+	Smoothing   float64 // in [0,1), the amount of smoothing of the positions and pressures, where 0 disables smoothing
+	SpeedFactor float64 // in seconds per millimeter, the width is divided by 1+SpeedFactor*speed so that faster strokes are thinner, where 0 disables it
+	TiltFactor  float64 // the width is multiplied by 1+TiltFactor*sin(tilt) so that tilted strokes are wider, where 0 disables it
+	TaperStart  float64 // the length in millimeters over which the start of the stroke tapers to zero width
+	TaperEnd    float64 // the length in millimeters over which the end of the stroke tapers to zero width
+
+	points []InkPoint
+}
+
+// NewInkStroke returns a new ink stroke of the given width at full pressure, with moderate thinning and smoothing.
+func NewInkStroke(width float64) *InkStroke {
+	return &InkStroke{
+		Width:     width,
+		Thinning:  0.5,
+		Smoothing: 0.5,
+	}
+}
+
+// Add adds a stylus sample to the stroke. Samples should be added in order of time.
+func (s *InkStroke) Add(p InkPoint) {
+	s.points = append(s.points, p)
+}
+
+// Points returns the stylus samples of the stroke.
+func (s *InkStroke) Points() []InkPoint {
+	return s.points
+}
+
+// smooth returns the positions and pressures smoothed by an exponential moving average, without coinciding positions.
+func (s *InkStroke) smooth() []InkPoint {
+	points := make([]InkPoint, 0, len(s.points))
+	for _, p := range s.points {
+		if 0 < len(points) {
+			prev := points[len(points)-1]
+			f := math.Min(math.Max(s.Smoothing, 0.0), 0.99)
+			p.X = f*prev.X + (1.0-f)*p.X
+			p.Y = f*prev.Y + (1.0-f)*p.Y
+			p.Pressure = f*prev.Pressure + (1.0-f)*p.Pressure
+			if (Point{p.X, p.Y}).Equals(Point{prev.X, prev.Y}) {
+				continue
+			}
+		}
+		points = append(points, p)
+	}
+	return points
+}
+
+// width returns the width at a sample, given the speed and the distance from the start and end of the stroke.
+func (s *InkStroke) width(p InkPoint, speed, fromStart, toEnd float64) float64 {
+	pressure := math.Min(math.Max(p.Pressure, 0.0), 1.0)
+	w := s.Width * (1.0 - s.Thinning*(1.0-pressure))
+	w *= 1.0 + s.TiltFactor*math.Sin(p.Tilt*math.Pi/180.0)
+	if 0.0 < s.SpeedFactor {
+		w /= 1.0 + s.SpeedFactor*speed
+	}
+	if 0.0 < s.TaperStart && fromStart < s.TaperStart {
+		t := fromStart / s.TaperStart
+		w *= t * (2.0 - t) // ease out
+	}
+	if 0.0 < s.TaperEnd && toEnd < s.TaperEnd {
+		t := toEnd / s.TaperEnd
+		w *= t * (2.0 - t)
+	}
+	return math.Max(w, 0.0)
+}
+
+// Path returns the outline of the stroke. The sides of the stroke are smoothed using quadratic Béziers through the midpoints between the samples, and the ends are rounded unless they taper.
+func (s *InkStroke) Path() *Path {
+	points := s.smooth()
+	if len(points) == 0 {
+		return &Path{}
+	} else if len(points) == 1 {
+		w := s.width(points[0], 0.0, s.TaperStart, s.TaperEnd)
+		if Equal(w, 0.0) {
+			return &Path{}
+		}
+		return Circle(w/2.0).Translate(points[0].X, points[0].Y)
+	}
+
+	// cumulative length along the samples
+	pos := make([]Point, len(points))
+	dist := make([]float64, len(points))
+	for i, p := range points {
+		pos[i] = Point{p.X, p.Y}
+		if 0 < i {
+			dist[i] = dist[i-1] + pos[i].Sub(pos[i-1]).Length()
+		}
+	}
+	length := dist[len(dist)-1]
+
+	left := make([]Point, len(points))
+	right := make([]Point, len(points))
+	widths := make([]float64, len(points))
+	for i, p := range points {
+		i0, i1 := max(i-1, 0), min(i+1, len(points)-1)
+		speed := 0.0
+		if dt := points[i1].Time - points[i0].Time; 0.0 < dt {
+			speed = (dist[i1] - dist[i0]) / dt
+		}
+		widths[i] = s.width(p, speed, dist[i], length-dist[i])
+		n := pos[i1].Sub(pos[i0]).Rot90CCW().Norm(widths[i] / 2.0)
+		left[i] = pos[i].Add(n)
+		right[i] = pos[i].Sub(n)
+	}
+
+	// left side, end cap, right side in reverse, and start cap
+	q := &Path{}
+	q.MoveTo(left[0].X, left[0].Y)
+	inkSide(q, left)
+	if r := widths[len(widths)-1] / 2.0; !Equal(r, 0.0) {
+		end := right[len(right)-1]
+		q.ArcTo(r, r, 0.0, false, false, end.X, end.Y)
+	} else {
+		q.LineTo(right[len(right)-1].X, right[len(right)-1].Y)
+	}
+	for i, j := 0, len(right)-1; i < j; i, j = i+1, j-1 {
+		right[i], right[j] = right[j], right[i]
+	}
+	inkSide(q, right)
+	if r := widths[0] / 2.0; !Equal(r, 0.0) {
+		q.ArcTo(r, r, 0.0, false, false, left[0].X, left[0].Y)
+	}
+	q.Close()
+	return q
+}
+
+// inkSide adds quadratic Béziers through the midpoints between the points, with the points as control points, starting at the first point and ending at the last point.
+func inkSide(p *Path, points []Point) {
+	for i := 1; i < len(points)-1; i++ {
+		mid := points[i].Interpolate(points[i+1], 0.5)
+		p.QuadTo(points[i].X, points[i].Y, mid.X, mid.Y)
+	}
+	end := points[len(points)-1]
+	p.LineTo(end.X, end.Y)
+}
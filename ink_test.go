@@ -0,0 +1,48 @@
+package canvas
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestInkStroke(t *testing.T) {
+	stroke := NewInkStroke(2.0)
+	stroke.Smoothing = 0.0
+	for i := 0; i <= 10; i++ {
+		stroke.Add(InkPoint{X: float64(i), Time: float64(i) / 10.0, Pressure: 1.0})
+	}
+	test.T(t, len(stroke.Points()), 11)
+	test.T(t, stroke.Path().Bounds(), Rect{-1.0, -1.0, 12.0, 2.0})
+
+	// half the pressure
+	stroke.Thinning = 0.5
+	for i := range stroke.points {
+		stroke.points[i].Pressure = 0.5
+	}
+	test.T(t, stroke.Path().Bounds(), Rect{-0.75, -0.75, 11.5, 1.5})
+
+	// faster strokes are thinner, 10 mm/s
+	stroke.SpeedFactor = 0.1
+	test.T(t, stroke.Path().Bounds(), Rect{-0.375, -0.375, 10.75, 0.75})
+	stroke.SpeedFactor = 0.0
+
+	// tapered ends
+	stroke.TaperStart = 2.0
+	stroke.TaperEnd = 2.0
+	test.T(t, stroke.Path().Bounds(), Rect{0.0, -0.75, 10.0, 1.5})
+	test.T(t, stroke.Path().StartPos(), Point{0.0, 0.0})
+
+	// smoothing pulls the stroke towards the earlier samples
+	stroke = NewInkStroke(2.0)
+	stroke.Smoothing = 0.5
+	stroke.Add(InkPoint{X: 0.0, Pressure: 1.0})
+	stroke.Add(InkPoint{X: 4.0, Pressure: 1.0})
+	test.T(t, stroke.Path().Bounds(), Rect{-1.0, -1.0, 4.0, 2.0})
+
+	// a single sample is a dot
+	stroke = NewInkStroke(2.0)
+	stroke.Add(InkPoint{X: 5.0, Y: 5.0, Pressure: 1.0})
+	test.T(t, stroke.Path().Bounds(), Rect{4.0, 4.0, 2.0, 2.0})
+	test.T(t, NewInkStroke(2.0).Path(), &Path{})
+}
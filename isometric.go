@@ -0,0 +1,148 @@
+package canvas
+
+import (
+	"math"
+	"sort"
+)
+
+// Axonometry is a parallel projection of 3D space onto the plane, such as isometric projection, given by the projections of the unit vectors along the X, Y and Z axes. It is used to draw diagrams and game assets of boxes, prisms and extruded paths, see also Camera for perspective projections.
+type Axonometry struct {
+	X, Y, Z Point
+}
+
+// Isometric is the isometric projection, where the X and Y axes point up at 30 degrees to the right and left respectively, and the Z axis points up. The viewer looks from the direction (-1,-1,1), so that the origin is the front corner of a box at the origin.
+var Isometric = NewAxonometry(30.0, 30.0, 1.0, 1.0, 1.0)
+
+// PixelIsometric is the dimetric projection used for pixel art, where the X and Y axes have a slope of 1:2 so that lines are drawn as two horizontal pixels for every vertical pixel.
+var PixelIsometric = NewAxonometry(math.Atan(0.5)*180.0/math.Pi, math.Atan(0.5)*180.0/math.Pi, 1.0, 1.0, 1.0)
+
+// NewAxonometry returns a parallel projection where the X axis points up at angleX degrees to the right and the Y axis points up at angleY degrees to the left, with the lengths of the unit vectors along the X, Y and Z axes scaled by scaleX, scaleY and scaleZ respectively. The Z axis points up.
+func NewAxonometry(angleX, angleY, scaleX, scaleY, scaleZ float64) Axonometry {
+	sinX, cosX := math.Sincos(angleX * math.Pi / 180.0)
+	sinY, cosY := math.Sincos(angleY * math.Pi / 180.0)
+	return Axonometry{
+		X: Point{scaleX * cosX, scaleX * sinX},
+		Y: Point{-scaleY * cosY, scaleY * sinY},
+		Z: Point{0.0, scaleZ},
+	}
+}
+
+// NewObliqueProjection returns an oblique projection where the X and Z axes are drawn to the right and up at true length, and the Y axis, which points into the drawing, is drawn at angle degrees with its length scaled by depth, such as 0.5 for cabinet projection and 1.0 for cavalier projection.
+func NewObliqueProjection(angle, depth float64) Axonometry {
+	sin, cos := math.Sincos(angle * math.Pi / 180.0)
+	return Axonometry{
+		X: Point{1.0, 0.0},
+		Y: Point{depth * cos, depth * sin},
+		Z: Point{0.0, 1.0},
+	}
+}
+
+// Project returns the projection of p.
+func (a Axonometry) Project(p Point3) Point {
+	return Point{
+		p.X*a.X.X + p.Y*a.Y.X + p.Z*a.Z.X,
+		p.X*a.X.Y + p.Y*a.Y.Y + p.Z*a.Z.Y,
+	}
+}
+
+// PlaneXY returns the transformation that projects paths in the XY plane at height z, such as to draw floor plans.
+func (a Axonometry) PlaneXY(z float64) Matrix {
+	return Matrix{
+		{a.X.X, a.Y.X, z * a.Z.X},
+		{a.X.Y, a.Y.Y, z * a.Z.Y},
+	}
+}
+
+// PlaneXZ returns the transformation that projects paths in the XZ plane at depth y, with the path's Y axis along the Z axis.
+func (a Axonometry) PlaneXZ(y float64) Matrix {
+	return Matrix{
+		{a.X.X, a.Z.X, y * a.Y.X},
+		{a.X.Y, a.Z.Y, y * a.Y.Y},
+	}
+}
+
+// PlaneYZ returns the transformation that projects paths in the YZ plane at x, with the path's X and Y axes along the Y and Z axes respectively.
+func (a Axonometry) PlaneYZ(x float64) Matrix {
+	return Matrix{
+		{a.Y.X, a.Z.X, x * a.X.X},
+		{a.Y.Y, a.Z.Y, x * a.X.Y},
+	}
+}
+
+// ViewDirection returns the unit vector pointing towards the viewer, which is along the direction that projects to a point. The viewer is assumed to look from above.
+func (a Axonometry) ViewDirection() Point3 {
+	v := Point3{a.X.X, a.Y.X, a.Z.X}.Cross(Point3{a.X.Y, a.Y.Y, a.Z.Y})
+	if v.Z < 0.0 {
+		v = v.Mul(-1.0)
+	}
+	return v.Norm(1.0)
+}
+
+// Facet is a projected planar face of a 3D solid together with its outward unit normal, which can be used to shade the face for a given light direction.
+type Facet struct {
+	Path   *Path
+	Normal Point3
+}
+
+// Box returns the visible faces of a box with width, depth and height along the X, Y and Z axes, with a corner at the origin, see Extrude.
+func (a Axonometry) Box(width, depth, height float64) []Facet {
+	return a.Extrude(Rectangle(width, depth), height)
+}
+
+// Prism returns the visible faces of a regular prism with n sides, circumradius r around the Z axis, and the given height, see Extrude.
+func (a Axonometry) Prism(n int, r, height float64) []Facet {
+	return a.Extrude(RegularPolygon(n, r, true), height)
+}
+
+// Extrude returns the visible faces of the solid formed by extruding path p in the XY plane along the Z axis by height, which may be negative. Curves are flattened, so that every segment forms a side face. The faces are sorted from back to front, so that drawing them in order hides the faces that are behind. The top face is drawn last.
+func (a Axonometry) Extrude(p *Path, height float64) []Facet {
+	if p.Empty() || height == 0.0 {
+		return nil
+	}
+	p = p.Flatten(Tolerance).Settle(NonZero) // filling subpaths are counter clockwise
+	view := a.ViewDirection()
+
+	type side struct {
+		Facet
+		depth float64
+	}
+	sides := []side{}
+	for _, ps := range p.Split() {
+		coords := ps.Coords()
+		if 1 < len(coords) && coords[0].Equals(coords[len(coords)-1]) {
+			coords = coords[:len(coords)-1]
+		}
+		for i := range coords {
+			p0, p1 := coords[i], coords[(i+1)%len(coords)]
+			normal := Point3{p1.Y - p0.Y, p0.X - p1.X, 0.0}.Norm(1.0)
+			if normal.Dot(view) <= Epsilon {
+				continue
+			}
+
+			q0, q1 := Point3{p0.X, p0.Y, 0.0}, Point3{p1.X, p1.Y, 0.0}
+			q2, q3 := Point3{p1.X, p1.Y, height}, Point3{p0.X, p0.Y, height}
+			face := &Path{}
+			for j, q := range []Point3{q0, q1, q2, q3} {
+				pos := a.Project(q)
+				if j == 0 {
+					face.MoveTo(pos.X, pos.Y)
+				} else {
+					face.LineTo(pos.X, pos.Y)
+				}
+			}
+			face.Close()
+			centroid := q0.Add(q1).Add(q2).Add(q3).Mul(0.25)
+			sides = append(sides, side{Facet{face, normal}, centroid.Dot(view)})
+		}
+	}
+	sort.SliceStable(sides, func(i, j int) bool {
+		return sides[i].depth < sides[j].depth
+	})
+
+	facets := make([]Facet, 0, len(sides)+1)
+	for _, side := range sides {
+		facets = append(facets, side.Facet)
+	}
+	facets = append(facets, Facet{p.Transform(a.PlaneXY(math.Max(0.0, height))), Point3{0.0, 0.0, 1.0}})
+	return facets
+}
@@ -0,0 +1,74 @@
+package canvas
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestAxonometry(t *testing.T) {
+	cos30 := math.Cos(30.0 * math.Pi / 180.0)
+	test.T(t, Isometric.Project(Point3{1, 0, 0}), Point{cos30, 0.5})
+	test.T(t, Isometric.Project(Point3{0, 1, 0}), Point{-cos30, 0.5})
+	test.T(t, Isometric.Project(Point3{1, 1, 1}), Point{0.0, 2.0})
+	test.T(t, Isometric.ViewDirection(), Point3{-1, -1, 1}.Norm(1.0))
+	test.Float(t, PixelIsometric.X.Y/PixelIsometric.X.X, 0.5)
+
+	p := Point3{2, 3, 4}
+	test.T(t, Isometric.PlaneXY(p.Z).Dot(Point{p.X, p.Y}), Isometric.Project(p))
+	test.T(t, Isometric.PlaneXZ(p.Y).Dot(Point{p.X, p.Z}), Isometric.Project(p))
+	test.T(t, Isometric.PlaneYZ(p.X).Dot(Point{p.Y, p.Z}), Isometric.Project(p))
+
+	cabinet := NewObliqueProjection(45.0, 0.5)
+	test.T(t, cabinet.Project(Point3{1, 0, 1}), Point{1.0, 1.0})
+	test.Float(t, cabinet.Project(Point3{0, 1, 0}).Length(), 0.5)
+}
+
+func TestAxonometryExtrude(t *testing.T) {
+	// box with its front faces at x=0 and y=0
+	facets := Isometric.Box(1.0, 2.0, 3.0)
+	test.T(t, len(facets), 3)
+	test.T(t, facets[2].Normal, Point3{0, 0, 1})
+	test.T(t, facets[2].Path.Bounds(), Rect{-2 * Isometric.X.X, 3.0, 3 * Isometric.X.X, 1.5})
+	normals := []Point3{facets[0].Normal, facets[1].Normal}
+	if normals[0].X == 0.0 {
+		normals[0], normals[1] = normals[1], normals[0]
+	}
+	test.T(t, normals, []Point3{{-1, 0, 0}, {0, -1, 0}})
+
+	// the faces tile the hexagonal outline of the box
+	area := 0.0
+	for _, facet := range facets {
+		area += facetArea(facet.Path)
+	}
+	outline := facets[0].Path.Or(facets[1].Path).Or(facets[2].Path)
+	test.Float(t, facetArea(outline), area)
+
+	// a hexagonal prism shows three sides, a square with a square hole shows two outer and two inner sides
+	test.T(t, len(Isometric.Prism(6, 1.0, 1.0)), 4)
+	hole := Rectangle(4.0, 4.0).Append(Rectangle(2.0, 2.0).Translate(1.0, 1.0).Reverse())
+	facets = Isometric.Extrude(hole, 1.0)
+	test.T(t, len(facets), 5)
+	test.Float(t, facetArea(facets[4].Path), 12.0*facetArea(Isometric.Box(1.0, 1.0, 1.0)[2].Path))
+
+	// sides are sorted from back to front
+	facets = Isometric.Extrude(hole, -1.0)
+	test.T(t, len(facets), 5)
+	test.Float(t, facets[0].Path.Bounds().W, 2.0*Isometric.X.X) // inner sides are at the back
+	test.Float(t, facets[1].Path.Bounds().W, 2.0*Isometric.X.X)
+	test.Float(t, facets[3].Path.Bounds().W, 4.0*Isometric.X.X)
+	test.T(t, len(Isometric.Extrude(&Path{}, 1.0)), 0)
+}
+
+func facetArea(p *Path) float64 {
+	// signed area of a polygonal path, holes are clockwise
+	area := 0.0
+	for _, ps := range p.Split() {
+		coords := ps.Coords()
+		for i := range coords {
+			area += coords[i].PerpDot(coords[(i+1)%len(coords)]) / 2.0
+		}
+	}
+	return area
+}
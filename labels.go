@@ -0,0 +1,190 @@
+package canvas
+
+import (
+	"math"
+	"sort"
+)
+
+// Label is a text label for a map or plot feature, which is a point when Path is nil, a line when Path is open, or an area when Path is closed. The size of the label is given by the bounds of Text, or by Bounds relative to the label's position when Text is nil.
+type Label struct {
+	Text   *Text
+	Bounds Rect
+	Anchor Point
+	Path   *Path
+}
+
+func (l Label) bounds() Rect {
+	if l.Text != nil {
+		return l.Text.Bounds()
+	}
+	return l.Bounds
+}
+
+// PlacedLabel is the position of a label as returned by LabelPlacer.Place. The label is drawn at Position, for example with Context.DrawText, and occupies Bounds. Leader is a line from the feature to the label when the label was displaced from its feature, and is nil otherwise. Placed is false when there was no position without overlap, in which case the label should be omitted.
+type PlacedLabel struct {
+	Placed   bool
+	Position Point
+	Bounds   Rect
+	Leader   *Path
+}
+
+// LabelPlacer positions labels next to their features so that labels do not overlap each other, point features, or the obstacles. Labels are placed greedily in the given order, so that more important labels should come first, and each label takes the first free position of a list of candidates in order of preference. Point labels prefer the top right, top left, bottom right, and bottom left of the point at distance Offset, followed by the right, left, top, and bottom. Line labels are placed above or below the middle of the line, and then further towards its ends. Area labels are centered in the area, and then at other positions inside the area closest to its center. Labels keep at least Padding away from each other. When Leaders is set, point labels that cannot be placed next to their point are displaced further away and connected by a leader line. When Bounds is non-zero, labels are kept within Bounds.
+type LabelPlacer struct {
+	Offset    float64
+	Padding   float64
+	Leaders   bool
+	Obstacles []Rect
+	Bounds    Rect
+}
+
+// NewLabelPlacer returns a new label placer with the given offset in millimeters between labels and their features.
+func NewLabelPlacer(offset float64) *LabelPlacer {
+	return &LabelPlacer{
+		Offset:  offset,
+		Padding: offset / 2.0,
+	}
+}
+
+type labelCandidate struct {
+	pos    Point
+	anchor Point
+	leader bool
+}
+
+// Place returns the placement of each label, in the same order as the labels.
+func (lp *LabelPlacer) Place(labels []Label) []PlacedLabel {
+	occupied := append([]Rect{}, lp.Obstacles...)
+	for _, label := range labels {
+		if label.Path == nil {
+			// keep labels from covering point features
+			r := lp.Offset / 2.0
+			occupied = append(occupied, Rect{label.Anchor.X - r, label.Anchor.Y - r, 2.0 * r, 2.0 * r})
+		}
+	}
+
+	placed := make([]PlacedLabel, len(labels))
+	for i, label := range labels {
+		bounds := label.bounds()
+		for _, candidate := range lp.candidates(label, bounds) {
+			rect := bounds.Move(candidate.pos)
+			if !lp.isFree(rect, occupied) {
+				continue
+			}
+
+			placed[i] = PlacedLabel{
+				Placed:   true,
+				Position: candidate.pos,
+				Bounds:   rect,
+			}
+			if candidate.leader {
+				end := Point{
+					math.Min(math.Max(candidate.anchor.X, rect.X), rect.X+rect.W),
+					math.Min(math.Max(candidate.anchor.Y, rect.Y), rect.Y+rect.H),
+				}
+				placed[i].Leader = Line(end.X-candidate.anchor.X, end.Y-candidate.anchor.Y).Translate(candidate.anchor.X, candidate.anchor.Y)
+			}
+			occupied = append(occupied, Rect{rect.X - lp.Padding, rect.Y - lp.Padding, rect.W + 2.0*lp.Padding, rect.H + 2.0*lp.Padding})
+			break
+		}
+	}
+	return placed
+}
+
+func (lp *LabelPlacer) isFree(rect Rect, occupied []Rect) bool {
+	if lp.Bounds.W != 0.0 || lp.Bounds.H != 0.0 {
+		if rect.X < lp.Bounds.X || rect.Y < lp.Bounds.Y || lp.Bounds.X+lp.Bounds.W < rect.X+rect.W || lp.Bounds.Y+lp.Bounds.H < rect.Y+rect.H {
+			return false
+		}
+	}
+	for _, r := range occupied {
+		if rect.Overlaps(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// candidates returns the positions for the label in order of preference.
+func (lp *LabelPlacer) candidates(label Label, bounds Rect) []labelCandidate {
+	// positions of the label such that its bounds are at the given side of p, where dx and dy are -1, 0, or 1
+	at := func(p Point, dx, dy, offset float64) Point {
+		x := p.X - bounds.X - bounds.W/2.0 + dx*(bounds.W/2.0+offset)
+		y := p.Y - bounds.Y - bounds.H/2.0 + dy*(bounds.H/2.0+offset)
+		return Point{x, y}
+	}
+
+	candidates := []labelCandidate{}
+	if label.Path == nil {
+		sides := [][2]float64{{1, 1}, {-1, 1}, {1, -1}, {-1, -1}, {1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+		for _, side := range sides {
+			candidates = append(candidates, labelCandidate{pos: at(label.Anchor, side[0], side[1], lp.Offset)})
+		}
+		if lp.Leaders {
+			for _, dist := range []float64{3.0, 6.0} {
+				for _, side := range sides {
+					candidates = append(candidates, labelCandidate{at(label.Anchor, side[0], side[1], dist*lp.Offset), label.Anchor, true})
+				}
+			}
+		}
+	} else if !label.Path.Closed() {
+		for _, p := range labelLinePoints(label.Path, []float64{0.5, 0.3, 0.7, 0.1, 0.9}) {
+			candidates = append(candidates, labelCandidate{pos: at(p, 0, 1, lp.Offset)})
+			candidates = append(candidates, labelCandidate{pos: at(p, 0, -1, lp.Offset)})
+		}
+	} else {
+		center := label.Path.InteriorPoint()
+		candidates = append(candidates, labelCandidate{pos: at(center, 0, 0, 0.0)})
+
+		// positions on a grid inside the area, closest to the center first
+		const n = 8
+		area := label.Path.Bounds()
+		points := []Point{}
+		for j := 0; j <= n; j++ {
+			for i := 0; i <= n; i++ {
+				p := Point{area.X + float64(i)/n*area.W, area.Y + float64(j)/n*area.H}
+				if label.Path.Fills(p.X, p.Y, NonZero) {
+					points = append(points, p)
+				}
+			}
+		}
+		sort.SliceStable(points, func(i, j int) bool {
+			return points[i].Sub(center).Length() < points[j].Sub(center).Length()
+		})
+		for _, p := range points {
+			candidates = append(candidates, labelCandidate{pos: at(p, 0, 0, 0.0)})
+		}
+	}
+	return candidates
+}
+
+// labelLinePoints returns the points on the subpaths of the path at the given fractions of their length, ordered by fraction.
+func labelLinePoints(p *Path, ts []float64) []Point {
+	polylines, _ := p.polylines(Tolerance)
+	lengths := make([][]float64, len(polylines))
+	for k, polyline := range polylines {
+		lengths[k] = make([]float64, len(polyline))
+		for i := 1; i < len(polyline); i++ {
+			lengths[k][i] = lengths[k][i-1] + polyline[i].Sub(polyline[i-1]).Length()
+		}
+	}
+
+	points := []Point{}
+	for _, t := range ts {
+		for k, polyline := range polylines {
+			if len(polyline) == 0 {
+				continue
+			}
+			s := t * lengths[k][len(polyline)-1]
+			i := sort.SearchFloat64s(lengths[k], s)
+			if i == 0 {
+				points = append(points, polyline[0])
+			} else if i == len(polyline) {
+				points = append(points, polyline[len(polyline)-1])
+			} else {
+				u := (s - lengths[k][i-1]) / (lengths[k][i] - lengths[k][i-1])
+				points = append(points, polyline[i-1].Interpolate(polyline[i], u))
+			}
+		}
+	}
+	return points
+}
@@ -0,0 +1,43 @@
+package canvas
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestLabelPlacer(t *testing.T) {
+	placer := NewLabelPlacer(1.0)
+	placed := placer.Place([]Label{
+		{Bounds: Rect{0.0, 0.0, 10.0, 5.0}, Anchor: Point{0.0, 0.0}},
+		{Bounds: Rect{0.0, 0.0, 10.0, 5.0}, Anchor: Point{2.0, 0.0}},
+		{Bounds: Rect{0.0, 0.0, 4.0, 2.0}, Path: MustParseSVGPath("M20 0L40 0")},
+		{Bounds: Rect{0.0, 0.0, 4.0, 2.0}, Path: Rectangle(10.0, 10.0).Translate(20.0, 10.0)},
+	})
+	test.T(t, len(placed), 4)
+	test.That(t, placed[0].Placed)
+	test.T(t, placed[0].Position, Point{1.0, 1.0})
+	test.T(t, placed[0].Bounds, Rect{1.0, 1.0, 10.0, 5.0})
+	test.T(t, placed[1].Position, Point{3.0, -6.0}) // below right, since above overlaps the first label
+	test.T(t, placed[2].Position, Point{28.0, 1.0})
+	test.T(t, placed[3].Position, Point{23.0, 14.0})
+	test.That(t, placed[3].Leader == nil)
+
+	placer.Bounds = Rect{0.0, 0.0, 5.0, 5.0}
+	placed = placer.Place([]Label{{Bounds: Rect{0.0, 0.0, 10.0, 5.0}}})
+	test.That(t, !placed[0].Placed)
+}
+
+func TestLabelPlacerLeaders(t *testing.T) {
+	placer := NewLabelPlacer(1.0)
+	placer.Leaders = true
+	placer.Obstacles = []Rect{{-10.0, -10.0, 20.0, 20.0}}
+	placed := placer.Place([]Label{{Bounds: Rect{0.0, 0.0, 2.0, 2.0}, Anchor: Point{0.0, 0.0}}})
+	test.That(t, !placed[0].Placed)
+
+	placer.Obstacles = []Rect{{-5.0, -5.0, 10.0, 10.0}}
+	placed = placer.Place([]Label{{Bounds: Rect{0.0, 0.0, 2.0, 2.0}, Anchor: Point{0.0, 0.0}}})
+	test.That(t, placed[0].Placed)
+	test.T(t, placed[0].Position, Point{6.0, 6.0})
+	test.T(t, placed[0].Leader, MustParseSVGPath("M0 0L6 6"))
+}
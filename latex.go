@@ -71,13 +71,17 @@ var preamble = `\nopagenumbers
 \def\frac#1#2{{{#1}\over{#2}}}
 `
 
-// ParseLaTeX parse a LaTeX formula (that what is between $...$) and returns a path.
+// ParseLaTeX parse a LaTeX formula (that what is between $...$) and returns a path. If LaTeXFallback is set, formulas that the builtin TeX engine can not handle are parsed by ParseLaTeXExternal instead.
 func ParseLaTeX(formula string) (*Path, error) {
 	r := strings.NewReader(fmt.Sprintf(`%s $%s$`, preamble, formula))
 	w := &bytes.Buffer{}
 	stdout := &bytes.Buffer{}
 	engine := tex.NewEngine(stdout, bytes.NewReader([]byte{}))
-	if err := engine.Process(w, r); err != nil {
+	err := engine.Process(w, r)
+	if LaTeXFallback && (err != nil || strings.Contains(stdout.String(), "\n! ")) {
+		// errors such as undefined control sequences are only reported in the log
+		return ParseLaTeXExternal("$" + formula + "$")
+	} else if err != nil {
 		fmt.Println(stdout.String())
 		return nil, err
 	}
@@ -3,50 +3,11 @@
 package canvas
 
 import (
-	"crypto/md5"
-	"fmt"
-	"os"
-	"os/exec"
-	"path"
 	"testing"
 
 	"github.com/tdewolff/test"
 )
 
-// from os/exec/exec_test.go
-func helperCommand(command string, args ...string) *exec.Cmd {
-	cs := []string{"-test.run=TestHelperProcess", "--", command}
-	cs = append(cs, args...)
-	cmd := exec.Command(os.Args[0], cs...)
-	cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1"}
-	return cmd
-}
-
-func TestHelperProcess(*testing.T) {
-	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
-		return
-	}
-	os.Exit(0)
-}
-
-func helperParseLaTeX(s string) (*Path, error) {
-	if err := os.MkdirAll(tempDir, os.ModePerm); err != nil {
-		panic(err)
-	}
-
-	hash := fmt.Sprintf("%x", md5.Sum([]byte(s)))
-	w, err := os.Create(path.Join(tempDir, hash+".svg"))
-	if err != nil {
-		panic(err)
-	}
-	w.Write([]byte(s))
-	w.Close()
-
-	execCommand = helperCommand
-	defer func() { execCommand = exec.Command }()
-	return ParseLaTeX(s)
-}
-
 func TestLaTeX(t *testing.T) {
 	// $x=\frac{5}{2}$
 	_, err := helperParseLaTeX(`<?xml version='1.0' encoding='UTF-8'?>
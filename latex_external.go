@@ -0,0 +1,193 @@
+package canvas
+
+// TODO: make LaTeX work for WASM target?
+
+import (
+	"bytes"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/parse/v2/strconv"
+	"github.com/tdewolff/parse/v2/xml"
+)
+
+var execCommand = exec.Command
+var tempDir = path.Join(os.TempDir(), "tdewolff-canvas")
+
+// LaTeXCommands are the commands of an external TeX installation that are used by ParseLaTeXExternal.
+type LaTeXCommands struct {
+	LaTeX    []string // command and arguments to compile the document read from stdin into a DVI file, the job name is added as -jobname=...
+	DVISVGM  []string // command and arguments to convert the DVI file into an SVG file with glyphs as paths, the DVI filename is appended
+	Preamble string   // document class and packages, such as \documentclass{article}\usepackage{amsmath}
+}
+
+// DefaultLaTeXCommands are the commands used by ParseLaTeXExternal, which by default require latex and dvisvgm to be installed on the machine.
+var DefaultLaTeXCommands = LaTeXCommands{
+	LaTeX:    []string{"latex", "-halt-on-error"},
+	DVISVGM:  []string{"dvisvgm", "--no-fonts"},
+	Preamble: `\documentclass{article}`,
+}
+
+// LaTeXFallback enables falling back to ParseLaTeXExternal when the builtin TeX engine used by ParseLaTeX reports errors, such as for macros or environments of LaTeX packages that it does not support. It is disabled by default, since it requires an external TeX installation, see DefaultLaTeXCommands.
+var LaTeXFallback = false
+
+// ParseLaTeXExternal parses a LaTeX formatted string into a path using an external TeX installation configured by DefaultLaTeXCommands, which compiles the string to DVI and converts it to SVG. Formulas must thus be surrounded by $...$. The content is surrounded by:
+//
+//	{{preamble}}
+//	\begin{document}
+//	\thispagestyle{empty}
+//	{{input}}
+//	\end{document}
+func ParseLaTeXExternal(s string) (*Path, error) {
+	cmds := DefaultLaTeXCommands
+	if len(cmds.LaTeX) == 0 || len(cmds.DVISVGM) == 0 {
+		return nil, errors.New("LaTeX commands not set")
+	}
+
+	if err := os.MkdirAll(tempDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	hash := fmt.Sprintf("%x", md5.Sum([]byte(s)))
+	document := cmds.Preamble + `
+\begin{document}
+\thispagestyle{empty}
+` + s + `
+\end{document}`
+
+	stdout := &bytes.Buffer{}
+	args := append([]string{"-jobname=" + hash}, cmds.LaTeX[1:]...)
+	cmd := execCommand(cmds.LaTeX[0], args...)
+	cmd.Dir = tempDir
+	cmd.Stdin = strings.NewReader(document)
+	cmd.Stdout = stdout
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, stdout.String())
+	}
+
+	stdout.Reset()
+	args = append(append([]string{}, cmds.DVISVGM[1:]...), hash+".dvi")
+	cmd = execCommand(cmds.DVISVGM[0], args...)
+	cmd.Dir = tempDir
+	cmd.Stdout = stdout
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, stdout.String())
+	}
+
+	r, err := os.Open(path.Join(tempDir, hash+".svg"))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return parseDVISVGM(r)
+}
+
+// parseDVISVGM returns the path of an SVG file produced by dvisvgm, with its first glyph's origin at (0,0).
+func parseDVISVGM(r io.Reader) (*Path, error) {
+	var x0, y0 float64
+	first := true
+	svgPaths := map[string]*Path{}
+
+	p := &Path{}
+	l := xml.NewLexer(parse.NewInput(r))
+	for {
+		tt, _ := l.Next()
+		switch tt {
+		case xml.ErrorToken:
+			if l.Err() != io.EOF {
+				return nil, l.Err()
+			}
+			p = p.Transform(Identity.Scale(mmPerPt, mmPerPt).ReflectY().Translate(-x0, -y0))
+			return p, nil
+		case xml.StartTagToken:
+			tag := string(l.Text())
+			attrs := map[string][]byte{}
+			for {
+				ttAttr, _ := l.Next()
+				if ttAttr != xml.AttributeToken {
+					break
+				}
+				val := l.AttrVal()
+				if len(val) > 1 && (val[0] == '\'' || val[0] == '"') && val[0] == val[len(val)-1] {
+					val = val[1 : len(val)-1]
+				}
+				attrs[string(l.Text())] = val
+			}
+
+			if tag == "path" {
+				id, ok := attrs["id"]
+				if !ok {
+					return nil, errors.New("unexpected SVG format: expected id attribute on path tag")
+				}
+
+				d, ok := attrs["d"]
+				if !ok {
+					return nil, errors.New("unexpected SVG format: expected d attribute on path tag")
+				}
+
+				svgPath, err := ParseSVGPath(string(d))
+				if err != nil {
+					return nil, err
+				}
+				svgPaths[string(id)] = svgPath
+			} else if tag == "use" {
+				x, n := strconv.ParseFloat(attrs["x"])
+				if n == 0 {
+					return nil, errors.New("unexpected SVG format: expected valid x attribute on use tag")
+				}
+
+				y, n := strconv.ParseFloat(attrs["y"])
+				if n == 0 {
+					return nil, errors.New("unexpected SVG format: expected valid y attribute on use tag")
+				}
+
+				if first {
+					x0 = x
+					y0 = y
+					first = false
+				}
+
+				id, ok := attrs["xlink:href"]
+				if !ok || len(id) == 0 || id[0] != '#' {
+					return nil, errors.New("unexpected SVG format: expected valid xlink:href attribute on use tag")
+				}
+
+				svgPath, ok := svgPaths[string(id[1:])]
+				if !ok {
+					return nil, errors.New("unexpected SVG format: xlink:href does not point to existing path")
+				}
+
+				p = p.Append(svgPath.Translate(x, y))
+			} else if tag == "rect" {
+				x, n := strconv.ParseFloat(attrs["x"])
+				if n == 0 {
+					return nil, errors.New("unexpected SVG format: expected valid x attribute on rect tag")
+				}
+
+				y, n := strconv.ParseFloat(attrs["y"])
+				if n == 0 {
+					return nil, errors.New("unexpected SVG format: expected valid y attribute on rect tag")
+				}
+
+				w, n := strconv.ParseFloat(attrs["width"])
+				if n == 0 {
+					return nil, errors.New("unexpected SVG format: expected valid width attribute on rect tag")
+				}
+
+				h, n := strconv.ParseFloat(attrs["height"])
+				if n == 0 {
+					return nil, errors.New("unexpected SVG format: expected valid height attribute on rect tag")
+				}
+				p = p.Append(Rectangle(w, h).Translate(x, y))
+			}
+		}
+	}
+}
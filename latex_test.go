@@ -0,0 +1,40 @@
+//go:build !latex
+
+package canvas
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestLaTeXFallback(t *testing.T) {
+	svg := `<?xml version='1.0' encoding='UTF-8'?>
+	<svg version='1.1' xmlns='http://www.w3.org/2000/svg' xmlns:xlink='http://www.w3.org/1999/xlink' width='10pt' height='10pt' viewBox='72 60 10 10'>
+	<defs>
+	<path id='g0-82' d='M0 0H6V-7H0Z'/>
+	</defs>
+	<g id='page1'>
+	<use x='72' y='68' xlink:href='#g0-82'/>
+	</g>
+	</svg>`
+
+	execCommand = helperCommand
+	LaTeXFallback = true
+	defer func() {
+		execCommand = exec.Command
+		LaTeXFallback = false
+	}()
+
+	// handled by the builtin engine, the external commands produce no output
+	p, err := ParseLaTeX(`x^2`)
+	test.Error(t, err)
+	test.That(t, !p.Empty())
+
+	// undefined control sequence
+	helperLaTeXSVG(`$\mathbb{R}$`, svg)
+	p, err = ParseLaTeX(`\mathbb{R}`)
+	test.Error(t, err)
+	test.T(t, p.Bounds(), Rect{0.0, 0.0, 6.0 * mmPerPt, 7.0 * mmPerPt})
+}
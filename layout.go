@@ -0,0 +1,181 @@
+package canvas
+
+import (
+	"image/color"
+	"math"
+)
+
+// LayoutGrid is a layout grid of a page, defining margins, columns, rows, and a baseline grid. All coordinates are in millimeters with the origin in the bottom-left corner of the page, but columns are numbered from the left and rows and baselines from the top, as is customary for page layout.
+type LayoutGrid struct {
+	W, H                                             float64 // page size
+	MarginTop, MarginRight, MarginBottom, MarginLeft float64
+	Columns, Rows                                    int
+	ColumnGutter, RowGutter                          float64 // spacing between columns and between rows
+	Baseline                                         float64 // baseline grid increment, zero for no baseline grid
+	BaselineOffset                                   float64 // position of the first baseline below the top margin
+}
+
+// NewLayoutGrid returns a layout grid of the given page size with columns and rows and no margins or gutters.
+func NewLayoutGrid(w, h float64, columns, rows int) *LayoutGrid {
+	return &LayoutGrid{
+		W:       w,
+		H:       h,
+		Columns: columns,
+		Rows:    rows,
+	}
+}
+
+// LayoutGrid returns a layout grid for the canvas with columns and rows and no margins or gutters.
+func (c *Canvas) LayoutGrid(columns, rows int) *LayoutGrid {
+	return NewLayoutGrid(c.W, c.H, columns, rows)
+}
+
+// SetMargins sets the margins in the same order as CSS.
+func (g *LayoutGrid) SetMargins(top, right, bottom, left float64) *LayoutGrid {
+	g.MarginTop, g.MarginRight, g.MarginBottom, g.MarginLeft = top, right, bottom, left
+	return g
+}
+
+// SetGutters sets the spacing between columns and between rows.
+func (g *LayoutGrid) SetGutters(column, row float64) *LayoutGrid {
+	g.ColumnGutter, g.RowGutter = column, row
+	return g
+}
+
+// SetBaseline sets the baseline grid increment and the position of the first baseline below the top margin.
+func (g *LayoutGrid) SetBaseline(increment, offset float64) *LayoutGrid {
+	g.Baseline, g.BaselineOffset = increment, offset
+	return g
+}
+
+// Content returns the area within the margins.
+func (g *LayoutGrid) Content() Rect {
+	return Rect{g.MarginLeft, g.MarginBottom, g.W - g.MarginLeft - g.MarginRight, g.H - g.MarginTop - g.MarginBottom}
+}
+
+// ColumnWidth returns the width of a single column.
+func (g *LayoutGrid) ColumnWidth() float64 {
+	columns := max(1, g.Columns)
+	return (g.Content().W - float64(columns-1)*g.ColumnGutter) / float64(columns)
+}
+
+// RowHeight returns the height of a single row.
+func (g *LayoutGrid) RowHeight() float64 {
+	rows := max(1, g.Rows)
+	return (g.Content().H - float64(rows-1)*g.RowGutter) / float64(rows)
+}
+
+// GridCell returns the area of the cell in column i and row j, counted from the top-left.
+func (g *LayoutGrid) GridCell(i, j int) Rect {
+	return g.GridArea(i, j, 1, 1)
+}
+
+// GridArea returns the area spanning the given number of columns and rows starting at the cell in column i and row j, counted from the top-left. The gutters between the spanned cells are included.
+func (g *LayoutGrid) GridArea(i, j, columns, rows int) Rect {
+	content := g.Content()
+	cw, rh := g.ColumnWidth(), g.RowHeight()
+	w := float64(columns)*cw + float64(columns-1)*g.ColumnGutter
+	h := float64(rows)*rh + float64(rows-1)*g.RowGutter
+	x := content.X + float64(i)*(cw+g.ColumnGutter)
+	y := content.Y + content.H - float64(j)*(rh+g.RowGutter) - h
+	return Rect{x, y, w, h}
+}
+
+// columnEdges returns the positions of the left and right edges of all columns.
+func (g *LayoutGrid) columnEdges() []float64 {
+	content := g.Content()
+	cw := g.ColumnWidth()
+	edges := []float64{}
+	for i := 0; i < max(1, g.Columns); i++ {
+		x := content.X + float64(i)*(cw+g.ColumnGutter)
+		edges = append(edges, x, x+cw)
+	}
+	return edges
+}
+
+// rowEdges returns the positions of the top and bottom edges of all rows.
+func (g *LayoutGrid) rowEdges() []float64 {
+	content := g.Content()
+	rh := g.RowHeight()
+	edges := []float64{}
+	for j := 0; j < max(1, g.Rows); j++ {
+		y := content.Y + content.H - float64(j)*(rh+g.RowGutter)
+		edges = append(edges, y, y-rh)
+	}
+	return edges
+}
+
+// Baselines returns the vertical positions of all baselines within the margins, from top to bottom.
+func (g *LayoutGrid) Baselines() []float64 {
+	if g.Baseline <= 0.0 {
+		return nil
+	}
+	content := g.Content()
+	ys := []float64{}
+	for y := content.Y + content.H - g.BaselineOffset; content.Y-Epsilon <= y; y -= g.Baseline {
+		ys = append(ys, y)
+	}
+	return ys
+}
+
+func snap(v float64, edges []float64) float64 {
+	snapped, dist := v, math.Inf(1)
+	for _, edge := range edges {
+		if d := math.Abs(edge - v); d < dist {
+			snapped, dist = edge, d
+		}
+	}
+	return snapped
+}
+
+// SnapX returns the nearest column edge to x.
+func (g *LayoutGrid) SnapX(x float64) float64 {
+	return snap(x, g.columnEdges())
+}
+
+// SnapY returns the nearest row edge to y.
+func (g *LayoutGrid) SnapY(y float64) float64 {
+	return snap(y, g.rowEdges())
+}
+
+// SnapBaseline returns the nearest baseline to y, or y if there is no baseline grid.
+func (g *LayoutGrid) SnapBaseline(y float64) float64 {
+	return snap(y, g.Baselines())
+}
+
+// Snap returns the nearest grid point to p, snapping x to the column edges and y to the baselines if there is a baseline grid and to the row edges otherwise.
+func (g *LayoutGrid) Snap(p Point) Point {
+	if 0.0 < g.Baseline {
+		return Point{g.SnapX(p.X), g.SnapBaseline(p.Y)}
+	}
+	return Point{g.SnapX(p.X), g.SnapY(p.Y)}
+}
+
+// Draw renders the grid for debugging purposes: the cells are filled translucently, and the margins and baselines are drawn as thin lines. The drawing state of the context is restored afterwards.
+func (g *LayoutGrid) Draw(ctx *Context) {
+	ctx.Push()
+	defer ctx.Pop()
+
+	ctx.SetStroke(Transparent)
+	ctx.SetFill(color.RGBA{0, 128, 255, 32}) // premultiplied
+	for j := 0; j < max(1, g.Rows); j++ {
+		for i := 0; i < max(1, g.Columns); i++ {
+			cell := g.GridCell(i, j)
+			ctx.DrawPath(cell.X, cell.Y, Rectangle(cell.W, cell.H))
+		}
+	}
+
+	ctx.SetFill(Transparent)
+	ctx.SetStrokeWidth(0.1)
+	if baselines := g.Baselines(); 0 < len(baselines) {
+		content := g.Content()
+		ctx.SetStroke(color.RGBA{0, 128, 128, 128})
+		for _, y := range baselines {
+			ctx.DrawPath(content.X, y, Line(content.W, 0.0))
+		}
+	}
+
+	content := g.Content()
+	ctx.SetStroke(color.RGBA{128, 0, 128, 128})
+	ctx.DrawPath(content.X, content.Y, Rectangle(content.W, content.H))
+}
@@ -0,0 +1,72 @@
+package canvas
+
+import (
+	"strings"
+)
+
+// LSystem is a Lindenmayer system, which is a string rewriting system that is drawn with turtle graphics to produce fractals and plant-like structures. Starting with the axiom, each iteration replaces every symbol that has a rule by its replacement. The expanded string is drawn by a turtle with the following symbols:
+//
+//	F, G  move forward by Length while drawing
+//	f, g  move forward by Length without drawing
+//	+     turn left by Angle
+//	-     turn right by Angle
+//	|     turn around
+//	[     push the turtle state
+//	]     pop the turtle state
+//
+// All other symbols, such as X and Y, are ignored when drawing and can be used to control the expansion.
+type LSystem struct {
+	Axiom  string
+	Rules  map[rune]string
+	Angle  float64 // in degrees
+	Length float64
+}
+
+// Expand returns the string after the given number of iterations.
+func (l LSystem) Expand(iterations int) string {
+	s := l.Axiom
+	for i := 0; i < iterations; i++ {
+		sb := strings.Builder{}
+		for _, r := range s {
+			if rule, ok := l.Rules[r]; ok {
+				sb.WriteString(rule)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+		s = sb.String()
+	}
+	return s
+}
+
+// Draw draws the expanded string with the given turtle, see LSystem for the meaning of the symbols.
+func (l LSystem) Draw(turtle *Turtle, s string) {
+	for _, r := range s {
+		switch r {
+		case 'F', 'G':
+			turtle.Forward(l.Length)
+		case 'f', 'g':
+			pen := turtle.Pen
+			turtle.PenUp()
+			turtle.Forward(l.Length)
+			turtle.Pen = pen
+		case '+':
+			turtle.Left(l.Angle)
+		case '-':
+			turtle.Right(l.Angle)
+		case '|':
+			turtle.Left(180.0)
+		case '[':
+			turtle.Push()
+		case ']':
+			turtle.Pop()
+		}
+	}
+}
+
+// Path returns the path drawn after the given number of iterations by a turtle starting at the origin heading along the X axis.
+func (l LSystem) Path(iterations int) *Path {
+	turtle := NewTurtle()
+	l.Draw(turtle, l.Expand(iterations))
+	return turtle.Path()
+}
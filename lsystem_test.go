@@ -0,0 +1,33 @@
+package canvas
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestLSystem(t *testing.T) {
+	koch := LSystem{
+		Axiom:  "F",
+		Rules:  map[rune]string{'F': "F+F--F+F"},
+		Angle:  60.0,
+		Length: 1.0,
+	}
+	test.T(t, koch.Expand(0), "F")
+	test.T(t, koch.Expand(2), "F+F--F+F+F+F--F+F--F+F--F+F+F+F--F+F")
+	test.T(t, koch.Path(1), MustParseSVGPath("M0 0L1 0L1.5 0.8660254037844386L2 0L3 0"))
+	test.Float(t, koch.Path(3).Length(), math.Pow(4.0, 3.0))
+	test.T(t, koch.Path(3).Pos(), Point{27.0, 0.0})
+
+	// branches and moves without drawing
+	plant := LSystem{
+		Axiom:  "X",
+		Rules:  map[rune]string{'X': "F[+X]f|F"},
+		Angle:  90.0,
+		Length: 1.0,
+	}
+	test.T(t, plant.Expand(1), "F[+X]f|F")
+	test.T(t, plant.Path(1), MustParseSVGPath("M0 0L1 0M2 0L1 0"))
+	test.T(t, plant.Path(2), MustParseSVGPath("M0 0L1 0L1 1M1 2L1 1M2 0L1 0"))
+}
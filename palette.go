@@ -0,0 +1,104 @@
+package canvas
+
+import (
+	"image/color"
+	"sort"
+)
+
+// Swatch is a named color. Process swatches are resolved to their RGB color by all renderers. Spot swatches are printed as a separate ink by renderers that support separations (PDF and EPS), using the CMYK or L*a*b* definition as the alternate color. Other renderers resolve spot swatches to their RGB color as well.
+type Swatch struct {
+	Name  string
+	Color color.RGBA  // RGB color used by renderers without support for separations
+	Spot  bool        // spot color printed as a separate ink
+	CMYK  *color.CMYK // alternate CMYK definition of the spot color
+	Lab   *[3]float64 // alternate CIE L*a*b* (D65) definition of the spot color
+}
+
+// NewSwatch returns a process color swatch.
+func NewSwatch(name string, col color.Color) *Swatch {
+	return &Swatch{
+		Name:  name,
+		Color: rgbaColor(col),
+	}
+}
+
+// NewSpotSwatch returns a spot color swatch with an alternate CMYK definition.
+func NewSpotSwatch(name string, cmyk color.CMYK) *Swatch {
+	return &Swatch{
+		Name:  name,
+		Color: rgbaColor(cmyk),
+		Spot:  true,
+		CMYK:  &cmyk,
+	}
+}
+
+// NewLabSpotSwatch returns a spot color swatch with an alternate CIE L*a*b* definition, with L in [0,100] and a,b roughly in [-128,127].
+func NewLabSpotSwatch(name string, l, a, b float64) *Swatch {
+	return &Swatch{
+		Name:  name,
+		Color: Lab(l, a, b),
+		Spot:  true,
+		Lab:   &[3]float64{l, a, b},
+	}
+}
+
+// RGBA implements the color.Color interface.
+func (s *Swatch) RGBA() (r, g, b, a uint32) {
+	return s.Color.RGBA()
+}
+
+// Equal returns true if both swatches have the same name and color definition.
+func (s *Swatch) Equal(other *Swatch) bool {
+	if s == nil || other == nil {
+		return s == other
+	}
+	return s.Name == other.Name && s.Color == other.Color && s.Spot == other.Spot && (s.CMYK == nil) == (other.CMYK == nil) && (s.CMYK == nil || *s.CMYK == *other.CMYK) && (s.Lab == nil) == (other.Lab == nil) && (s.Lab == nil || *s.Lab == *other.Lab)
+}
+
+// Palette is a collection of swatches referenced by name.
+type Palette struct {
+	swatches map[string]*Swatch
+}
+
+// NewPalette returns a palette with the given swatches.
+func NewPalette(swatches ...*Swatch) *Palette {
+	p := &Palette{
+		swatches: map[string]*Swatch{},
+	}
+	for _, swatch := range swatches {
+		p.Add(swatch)
+	}
+	return p
+}
+
+// Add adds a swatch to the palette, replacing any swatch with the same name.
+func (p *Palette) Add(swatch *Swatch) {
+	p.swatches[swatch.Name] = swatch
+}
+
+// Get returns the swatch by name.
+func (p *Palette) Get(name string) (*Swatch, bool) {
+	swatch, ok := p.swatches[name]
+	return swatch, ok
+}
+
+// Names returns the sorted names of all swatches in the palette.
+func (p *Palette) Names() []string {
+	names := make([]string, 0, len(p.swatches))
+	for name := range p.swatches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Spots returns all spot color swatches in the palette, sorted by name.
+func (p *Palette) Spots() []*Swatch {
+	spots := []*Swatch{}
+	for _, name := range p.Names() {
+		if swatch := p.swatches[name]; swatch.Spot {
+			spots = append(spots, swatch)
+		}
+	}
+	return spots
+}
@@ -6,6 +6,7 @@ import (
 	"math"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/tdewolff/parse/v2/strconv"
 	"golang.org/x/image/vector"
@@ -87,7 +88,8 @@ func fromArcFlags(large, sweep bool) float64 {
 // Path defines a vector path in 2D using a series of commands (MoveTo, LineTo, QuadTo, CubeTo, ArcTo and Close). Each command consists of a number of float64 values (depending on the command) that fully define the action. The first value is the command itself (as a float64). The last two values is the end point position of the pen after the action (x,y). QuadTo defined one control point (x,y) in between, CubeTo defines two control points, and ArcTo defines (rx,ry,phi,large+sweep) i.e. the radius in x and y, its rotation (in radians) and the large and sweep booleans in one float64.
 // Only valid commands are appended, so that LineTo has a non-zero length, QuadTo's and CubeTo's control point(s) don't (both) overlap with the start and end point, and ArcTo has non-zero radii and has non-zero length. For ArcTo we also make sure the angle is in the range [0, 2*PI) and we scale the radii up if they appear too small to fit the arc.
 type Path struct {
-	d []float64
+	d   []float64
+	gen uint64 // generation of the path data used by caches, zero when unassigned or after the path is modified
 	// TODO: optimization: cache bounds and path len until changes (clearCache()), set bounds directly for predefined shapes
 }
 
@@ -101,6 +103,16 @@ func (p *Path) Empty() bool {
 	return len(p.d) <= cmdLen(MoveToCmd)
 }
 
+// finite returns true if all coordinates are neither NaN nor infinite.
+func (p *Path) finite() bool {
+	for _, f := range p.d {
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return false
+		}
+	}
+	return true
+}
+
 // Equals returns true if p and q are equal within tolerance Epsilon.
 func (p *Path) Equals(q *Path) bool {
 	if len(p.d) != len(q.d) {
@@ -178,6 +190,44 @@ func (p *Path) Copy() *Path {
 	return q
 }
 
+// CopyTo copies p into q, reusing the backing array of q, and returns q. If q is nil a new path is allocated.
+func (p *Path) CopyTo(q *Path) *Path {
+	if q == nil {
+		q = &Path{}
+	}
+	q.d = append(q.d[:0], p.d...)
+	q.clearGeneration()
+	return q
+}
+
+// Reset clears the path while keeping its backing array for reuse.
+func (p *Path) Reset() {
+	p.clearGeneration()
+	p.d = p.d[:0]
+}
+
+// PathPool is a pool of paths whose backing arrays are reused, which reduces allocations when paths are created and discarded for every frame. The zero value is ready to use and it is safe for concurrent use.
+type PathPool struct {
+	pool sync.Pool
+}
+
+// Get returns an empty path from the pool or allocates a new one.
+func (pp *PathPool) Get() *Path {
+	if p, ok := pp.pool.Get().(*Path); ok {
+		return p
+	}
+	return &Path{}
+}
+
+// Put resets the path and returns it to the pool. The path must not be used afterwards.
+func (pp *PathPool) Put(p *Path) {
+	if p == nil {
+		return
+	}
+	p.Reset()
+	pp.pool.Put(p)
+}
+
 // Len returns the number of segments.
 func (p *Path) Len() int {
 	n := 0
@@ -195,7 +245,7 @@ func (p *Path) Append(q *Path) *Path {
 	} else if p == nil || p.Empty() {
 		return q
 	}
-	return &Path{append(p.d, q.d...)}
+	return &Path{d: append(p.d, q.d...)}
 }
 
 // Join joins path q to p and returns a new path if successful (otherwise either p or q are returned). It's like executing the commands in q to p in sequence, where if the first MoveTo of q doesn't coincide with p, or if p ends in Close, it will fallback to appending the paths.
@@ -207,7 +257,7 @@ func (p *Path) Join(q *Path) *Path {
 	}
 
 	if p.d[len(p.d)-1] == CloseCmd || !Equal(p.d[len(p.d)-3], q.d[1]) || !Equal(p.d[len(p.d)-2], q.d[2]) {
-		return &Path{append(p.d, q.d...)}
+		return &Path{d: append(p.d, q.d...)}
 	}
 
 	d := q.d[cmdLen(MoveToCmd):]
@@ -234,7 +284,7 @@ func (p *Path) Join(q *Path) *Path {
 
 	i := len(p.d)
 	end := p.StartPos()
-	p = &Path{append(p.d, d[cmdLen(cmd):]...)}
+	p = &Path{d: append(p.d, d[cmdLen(cmd):]...)}
 
 	// repair close commands
 	for i < len(p.d) {
@@ -347,7 +397,7 @@ func (p *Path) Direction(seg int, t float64) Point {
 		cmd := p.d[i]
 		if cmd == MoveToCmd {
 			if seg < curSeg {
-				pi := &Path{p.d[iStart:iEnd]}
+				pi := &Path{d: p.d[iStart:iEnd]}
 				return pi.direction(iSeg-iStart, t)
 			}
 			iStart = i
@@ -399,6 +449,7 @@ func (p *Path) CoordDirections() []Point {
 
 // MoveTo moves the path to (x,y) without connecting the path. It starts a new independent subpath. Multiple subpaths can be useful when negating parts of a previous path by overlapping it with a path in the opposite direction. The behaviour for overlapping paths depends on the FillRule.
 func (p *Path) MoveTo(x, y float64) {
+	p.clearGeneration()
 	if 0 < len(p.d) && p.d[len(p.d)-1] == MoveToCmd {
 		p.d[len(p.d)-3] = x
 		p.d[len(p.d)-2] = y
@@ -409,6 +460,7 @@ func (p *Path) MoveTo(x, y float64) {
 
 // LineTo adds a linear path to (x,y).
 func (p *Path) LineTo(x, y float64) {
+	p.clearGeneration()
 	start := p.Pos()
 	end := Point{x, y}
 	if start.Equals(end) {
@@ -435,6 +487,7 @@ func (p *Path) LineTo(x, y float64) {
 
 // QuadTo adds a quadratic Bézier path with control point (cpx,cpy) and end point (x,y).
 func (p *Path) QuadTo(cpx, cpy, x, y float64) {
+	p.clearGeneration()
 	start := p.Pos()
 	cp := Point{cpx, cpy}
 	end := Point{x, y}
@@ -455,6 +508,7 @@ func (p *Path) QuadTo(cpx, cpy, x, y float64) {
 
 // CubeTo adds a cubic Bézier path with control points (cpx1,cpy1) and (cpx2,cpy2) and end point (x,y).
 func (p *Path) CubeTo(cpx1, cpy1, cpx2, cpy2, x, y float64) {
+	p.clearGeneration()
 	start := p.Pos()
 	cp1 := Point{cpx1, cpy1}
 	cp2 := Point{cpx2, cpy2}
@@ -476,6 +530,7 @@ func (p *Path) CubeTo(cpx1, cpy1, cpx2, cpy2, x, y float64) {
 
 // ArcTo adds an arc with radii rx and ry, with rot the counter clockwise rotation with respect to the coordinate system in degrees, large and sweep booleans (see https://developer.mozilla.org/en-US/docs/Web/SVG/Tutorial/Paths#Arcs), and (x,y) the end position of the pen. The start position of the pen was given by a previous command's end point.
 func (p *Path) ArcTo(rx, ry, rot float64, large, sweep bool, x, y float64) {
+	p.clearGeneration()
 	start := p.Pos()
 	end := Point{x, y}
 	if start.Equals(end) {
@@ -543,6 +598,7 @@ func (p *Path) Arc(rx, ry, rot, theta0, theta1 float64) {
 
 // Close closes a (sub)path with a LineTo to the start of the path (the most recent MoveTo command). It also signals the path closes as opposed to being just a LineTo command, which can be significant for stroking purposes for example.
 func (p *Path) Close() {
+	p.clearGeneration()
 	if len(p.d) == 0 || p.d[len(p.d)-1] == CloseCmd {
 		// already closed or empty
 		return
@@ -578,6 +634,7 @@ func (p *Path) Close() {
 
 // optimizeClose removes a superfluous first line segment in-place of a subpath. If both the first and last segment are line segments and are colinear, move the start of the path forward one segment
 func (p *Path) optimizeClose() {
+	p.clearGeneration()
 	if len(p.d) == 0 || p.d[len(p.d)-1] != CloseCmd {
 		return
 	}
@@ -920,9 +977,9 @@ func (p *Path) FastBounds() Rect {
 			cp2 := Point{p.d[i+3], p.d[i+4]}
 			end = Point{p.d[i+5], p.d[i+6]}
 			xmin = math.Min(xmin, math.Min(cp1.X, math.Min(cp2.X, end.X)))
-			xmax = math.Max(xmax, math.Max(cp1.X, math.Min(cp2.X, end.X)))
+			xmax = math.Max(xmax, math.Max(cp1.X, math.Max(cp2.X, end.X)))
 			ymin = math.Min(ymin, math.Min(cp1.Y, math.Min(cp2.Y, end.Y)))
-			ymax = math.Max(ymax, math.Max(cp1.Y, math.Min(cp2.Y, end.Y)))
+			ymax = math.Max(ymax, math.Max(cp1.Y, math.Max(cp2.Y, end.Y)))
 		case ArcToCmd:
 			rx, ry, phi := p.d[i+1], p.d[i+2], p.d[i+3]
 			large, sweep := toArcFlags(p.d[i+4])
@@ -1066,8 +1123,13 @@ func (p *Path) Bounds() Rect {
 	return Rect{xmin, ymin, xmax - xmin, ymax - ymin}
 }
 
-// Length returns the length of the path in millimeters. The length is approximated for cubic Béziers.
+// Length returns the length of the path in millimeters. The length is approximated for cubic Béziers, and within Tolerance for elliptical arcs. See LengthTolerance for a different tolerance.
 func (p *Path) Length() float64 {
+	return p.LengthTolerance(Tolerance)
+}
+
+// LengthTolerance returns the length of the path in millimeters, where the length of elliptical arcs is within the given tolerance. The length is approximated for cubic Béziers.
+func (p *Path) LengthTolerance(tolerance float64) float64 {
 	d := 0.0
 	var start, end Point
 	for i := 0; i < len(p.d); {
@@ -1092,7 +1154,7 @@ func (p *Path) Length() float64 {
 			large, sweep := toArcFlags(p.d[i+4])
 			end = Point{p.d[i+5], p.d[i+6]}
 			_, _, theta1, theta2 := ellipseToCenter(start.X, start.Y, rx, ry, phi, large, sweep, end.X, end.Y)
-			d += ellipseLength(rx, ry, theta1, theta2)
+			d += ellipseLength(rx, ry, theta1, theta2, tolerance)
 		}
 		i += cmdLen(cmd)
 		start = end
@@ -1102,7 +1164,12 @@ func (p *Path) Length() float64 {
 
 // Transform transforms the path by the given transformation matrix and returns a new path.
 func (p *Path) Transform(m Matrix) *Path {
-	p = p.Copy()
+	return p.Copy().TransformInPlace(m)
+}
+
+// TransformInPlace transforms the path by the given transformation matrix, modifying p, and returns p.
+func (p *Path) TransformInPlace(m Matrix) *Path {
+	p.clearGeneration()
 	_, _, _, xscale, yscale, _ := m.Decompose()
 	for i := 0; i < len(p.d); {
 		cmd := p.d[i]
@@ -1188,6 +1255,41 @@ func (p *Path) Scale(x, y float64) *Path {
 	return p.Transform(Identity.Scale(x, y))
 }
 
+// TranslateInPlace translates the path by (x,y), modifying p, and returns p.
+func (p *Path) TranslateInPlace(x, y float64) *Path {
+	p.clearGeneration()
+	for i := 0; i < len(p.d); {
+		cmd := p.d[i]
+		switch cmd {
+		case MoveToCmd, LineToCmd, CloseCmd:
+			p.d[i+1] += x
+			p.d[i+2] += y
+		case QuadToCmd:
+			p.d[i+1] += x
+			p.d[i+2] += y
+			p.d[i+3] += x
+			p.d[i+4] += y
+		case CubeToCmd:
+			p.d[i+1] += x
+			p.d[i+2] += y
+			p.d[i+3] += x
+			p.d[i+4] += y
+			p.d[i+5] += x
+			p.d[i+6] += y
+		case ArcToCmd:
+			p.d[i+5] += x
+			p.d[i+6] += y
+		}
+		i += cmdLen(cmd)
+	}
+	return p
+}
+
+// ScaleInPlace scales the path by (x,y), modifying p, and returns p.
+func (p *Path) ScaleInPlace(x, y float64) *Path {
+	return p.TransformInPlace(Identity.Scale(x, y))
+}
+
 // Flat returns true if the path consists of solely line segments, that is only MoveTo, LineTo and Close commands.
 func (p *Path) Flat() bool {
 	for i := 0; i < len(p.d); {
@@ -1202,16 +1304,51 @@ func (p *Path) Flat() bool {
 
 // Flatten flattens all Bézier and arc curves into linear segments and returns a new path. It uses tolerance as the maximum deviation.
 func (p *Path) Flatten(tolerance float64) *Path {
-	quad := func(p0, p1, p2 Point) *Path {
-		return flattenQuadraticBezier(p0, p1, p2, tolerance)
-	}
-	cube := func(p0, p1, p2, p3 Point) *Path {
-		return flattenCubicBezier(p0, p1, p2, p3, tolerance)
+	return p.FlattenTo(nil, tolerance)
+}
+
+// FlattenTo flattens all Bézier and arc curves into linear segments like Flatten, but appends the result to dst and returns dst. The backing array of dst is reused so that flattening into a path obtained from a PathPool or cleared with Reset does not allocate once the array is large enough. If dst is nil a new path is allocated.
+func (p *Path) FlattenTo(dst *Path, tolerance float64) *Path {
+	defer traceRegion("flatten")()
+
+	if dst == nil {
+		dst = &Path{}
 	}
-	arc := func(start Point, rx, ry, phi float64, large, sweep bool, end Point) *Path {
-		return flattenEllipticArc(start, rx, ry, phi, large, sweep, end, tolerance)
+
+	var start Point
+	curve := false // previous segment was flattened
+	for i := 0; i < len(p.d); {
+		cmd := p.d[i]
+		switch cmd {
+		case MoveToCmd, LineToCmd:
+			dst.d = append(dst.d, p.d[i:i+cmdLen(cmd)]...)
+		case CloseCmd:
+			if curve {
+				dst.Close() // merges the last flattened segment with the close command
+			} else {
+				dst.d = append(dst.d, p.d[i:i+cmdLen(cmd)]...)
+			}
+		case QuadToCmd:
+			cp := Point{p.d[i+1], p.d[i+2]}
+			end := Point{p.d[i+3], p.d[i+4]}
+			appendFlattenedQuadraticBezier(dst, start, cp, end, tolerance)
+		case CubeToCmd:
+			cp1 := Point{p.d[i+1], p.d[i+2]}
+			cp2 := Point{p.d[i+3], p.d[i+4]}
+			end := Point{p.d[i+5], p.d[i+6]}
+			appendStrokedCubicBezier(dst, start, cp1, cp2, end, 0.0, tolerance)
+		case ArcToCmd:
+			rx, ry, phi := p.d[i+1], p.d[i+2], p.d[i+3]
+			large, sweep := toArcFlags(p.d[i+4])
+			end := Point{p.d[i+5], p.d[i+6]}
+			appendFlattenedEllipticArc(dst, start, rx, ry, phi, large, sweep, end, tolerance)
+		}
+		curve = cmd == QuadToCmd || cmd == CubeToCmd || cmd == ArcToCmd
+		i += cmdLen(cmd)
+		start = Point{p.d[i-3], p.d[i-2]}
 	}
-	return p.replace(nil, quad, cube, arc)
+	dst.clearGeneration()
+	return dst
 }
 
 // ReplaceArcs replaces ArcTo commands by CubeTo commands and returns a new path.
@@ -1278,7 +1415,7 @@ func (p *Path) replace(
 		}
 
 		if q != nil {
-			r := &Path{append([]float64{MoveToCmd, end.X, end.Y, MoveToCmd}, p.d[i+cmdLen(cmd):]...)}
+			r := &Path{d: append([]float64{MoveToCmd, end.X, end.Y, MoveToCmd}, p.d[i+cmdLen(cmd):]...)}
 
 			p.d = p.d[: i : i+cmdLen(cmd)] // make sure not to overwrite the rest of the path
 			p = p.Join(q)
@@ -1321,25 +1458,26 @@ func (p *Path) Markers(first, mid, last *Path, align bool) []*Path {
 	return markers
 }
 
-// Split splits the path into its independent subpaths. The path is split before each MoveTo command.
+// Split splits the path into its independent subpaths. The path is split before each MoveTo command. The subpaths share a copy of the path data, so that modifying them does not modify p.
 func (p *Path) Split() []*Path {
 	var i, j int
+	d := append([]float64(nil), p.d...)
 	ps := []*Path{}
-	for j < len(p.d) {
-		cmd := p.d[j]
+	for j < len(d) {
+		cmd := d[j]
 		if i < j && cmd == MoveToCmd {
-			ps = append(ps, &Path{p.d[i:j:j]})
+			ps = append(ps, &Path{d: d[i:j:j]})
 			i = j
 		}
 		j += cmdLen(cmd)
 	}
 	if i+cmdLen(MoveToCmd) < j {
-		ps = append(ps, &Path{p.d[i:j:j]})
+		ps = append(ps, &Path{d: d[i:j:j]})
 	}
 	return ps
 }
 
-// SplitAt splits the path into separate paths at the specified intervals (given in millimeters) along the path.
+// SplitAt splits the path into separate paths at the specified intervals (given in millimeters) along the path. See SplitAtParams to split at positions along the segments instead.
 func (p *Path) SplitAt(ts ...float64) []*Path {
 	if len(ts) == 0 {
 		return []*Path{p}
@@ -1360,18 +1498,16 @@ func (p *Path) SplitAt(ts ...float64) []*Path {
 		q = &Path{}
 	}
 
-	if 0 < len(p.d) && p.d[0] == MoveToCmd {
-		q.MoveTo(p.d[1], p.d[2])
-	}
 	for _, ps := range p.Split() {
 		var start, end Point
 		for i := 0; i < len(ps.d); {
 			cmd := ps.d[i]
 			switch cmd {
 			case MoveToCmd:
-				end = Point{p.d[i+1], p.d[i+2]}
+				end = Point{ps.d[i+1], ps.d[i+2]}
+				q.MoveTo(end.X, end.Y)
 			case LineToCmd, CloseCmd:
-				end = Point{p.d[i+1], p.d[i+2]}
+				end = Point{ps.d[i+1], ps.d[i+2]}
 
 				if j == len(ts) {
 					q.LineTo(end.X, end.Y)
@@ -1394,8 +1530,8 @@ func (p *Path) SplitAt(ts ...float64) []*Path {
 					T += dT
 				}
 			case QuadToCmd:
-				cp := Point{p.d[i+1], p.d[i+2]}
-				end = Point{p.d[i+3], p.d[i+4]}
+				cp := Point{ps.d[i+1], ps.d[i+2]}
+				end = Point{ps.d[i+3], ps.d[i+4]}
 
 				if j == len(ts) {
 					q.QuadTo(cp.X, cp.Y, end.X, end.Y)
@@ -1426,9 +1562,9 @@ func (p *Path) SplitAt(ts ...float64) []*Path {
 					T += dT
 				}
 			case CubeToCmd:
-				cp1 := Point{p.d[i+1], p.d[i+2]}
-				cp2 := Point{p.d[i+3], p.d[i+4]}
-				end = Point{p.d[i+5], p.d[i+6]}
+				cp1 := Point{ps.d[i+1], ps.d[i+2]}
+				cp2 := Point{ps.d[i+3], ps.d[i+4]}
+				end = Point{ps.d[i+5], ps.d[i+6]}
 
 				if j == len(ts) {
 					q.CubeTo(cp1.X, cp1.Y, cp2.X, cp2.Y, end.X, end.Y)
@@ -1461,23 +1597,20 @@ func (p *Path) SplitAt(ts ...float64) []*Path {
 					T += dT
 				}
 			case ArcToCmd:
-				rx, ry, phi := p.d[i+1], p.d[i+2], p.d[i+3]
-				large, sweep := toArcFlags(p.d[i+4])
-				end = Point{p.d[i+5], p.d[i+6]}
+				rx, ry, phi := ps.d[i+1], ps.d[i+2], ps.d[i+3]
+				large, sweep := toArcFlags(ps.d[i+4])
+				end = Point{ps.d[i+5], ps.d[i+6]}
 				cx, cy, theta1, theta2 := ellipseToCenter(start.X, start.Y, rx, ry, phi, large, sweep, end.X, end.Y)
 
 				if j == len(ts) {
 					q.ArcTo(rx, ry, phi*180.0/math.Pi, large, sweep, end.X, end.Y)
 				} else {
-					speed := func(theta float64) float64 {
-						return ellipseDeriv(rx, ry, 0.0, true, theta).Length()
-					}
-					invL, dT := invSpeedPolynomialChebyshevApprox(10, gaussLegendre7, speed, theta1, theta2)
+					dT := ellipseLength(rx, ry, theta1, theta2, Tolerance)
 
 					startTheta := theta1
 					nextLarge := large
 					for j < len(ts) && T < ts[j] && ts[j] <= T+dT {
-						theta := invL(ts[j] - T)
+						theta := ellipseLengthTheta(rx, ry, theta1, theta2, ts[j]-T, Tolerance)
 						mid, large1, large2, ok := ellipseSplit(rx, ry, phi, cx, cy, startTheta, theta2, theta)
 						if !ok {
 							panic("theta not in elliptic arc range for splitting")
@@ -1506,6 +1639,74 @@ func (p *Path) SplitAt(ts ...float64) []*Path {
 	return qs
 }
 
+// SplitAtParams splits the path into separate paths at the specified path parameters, where the integer part is the segment index and the fractional part is the position along the segment in [0,1), similar to Seg and T of PathIntersection. Segments are split exactly, including Béziers and elliptical arcs, and closed subpaths that are split are opened. See SplitAt to split at lengths along the path instead.
+func (p *Path) SplitAtParams(ts ...float64) []*Path {
+	ts = append([]float64{}, ts...)
+	sort.Float64s(ts)
+
+	j := 0 // index into ts
+	qs := []*Path{}
+	q := &Path{}
+	push := func(pos Point) {
+		if cmdLen(MoveToCmd) < len(q.d) {
+			qs = append(qs, q)
+		}
+		q = &Path{}
+		q.MoveTo(pos.X, pos.Y)
+	}
+
+	split := false // current subpath has been split
+	seg := 0
+	for i := 0; i < len(p.d); seg++ {
+		cmd := p.d[i]
+		n := cmdLen(cmd)
+		if cmd == MoveToCmd {
+			q.MoveTo(p.d[i+1], p.d[i+2])
+			for j < len(ts) && ts[j] < float64(seg+1) {
+				j++ // split positions on a MoveTo are ignored
+			}
+			split = false
+			i += n
+			continue
+		}
+
+		start := Point{p.d[i-3], p.d[i-2]}
+		d := p.d[i : i+n]
+		t0 := 0.0
+		for j < len(ts) && ts[j] < float64(seg+1) {
+			t := ts[j] - float64(seg)
+			j++
+			if t < t0 || Equal(t, t0) {
+				if Equal(t0, 0.0) && Equal(t, 0.0) && cmdLen(MoveToCmd) < len(q.d) {
+					push(start) // split at the start of the segment
+					split = true
+				}
+				continue
+			}
+
+			p0, p1 := cutSegment(start, d, (t-t0)/(1.0-t0))
+			q.d = append(q.d, p0.d[cmdLen(MoveToCmd):]...)
+			start = Point{p1.d[1], p1.d[2]}
+			d = p1.d[cmdLen(MoveToCmd):]
+			push(start)
+			split = true
+			t0 = t
+		}
+		if 0 < len(d) {
+			q.d = append(q.d, d...)
+			if cmd == CloseCmd && split {
+				q.d[len(q.d)-1] = LineToCmd
+				q.d[len(q.d)-cmdLen(CloseCmd)] = LineToCmd
+			}
+		}
+		i += n
+	}
+	if cmdLen(MoveToCmd) < len(q.d) {
+		qs = append(qs, q)
+	}
+	return qs
+}
+
 func dashStart(offset float64, d []float64) (int, float64) {
 	i0 := 0 // index in d
 	for d[i0] <= offset {
@@ -1658,6 +1859,48 @@ func (p *Path) Dash(offset float64, d ...float64) *Path {
 	return q
 }
 
+// DashFit returns a new path that consists of dashes like Dash, but the dash pattern is scaled slightly for each subpath so that an integer number of periods fits its length. Closed subpaths fit a whole number of periods so that the dashes join seamlessly at the start, and open subpaths fit a whole number of periods plus the first dash so that they start and end with a full dash when the offset is zero. The offset is scaled along with the pattern.
+func (p *Path) DashFit(offset float64, d ...float64) *Path {
+	offset, d = dashCanonical(offset, d)
+	if len(d) == 0 {
+		return p
+	} else if len(d) == 1 && d[0] == 0.0 {
+		return &Path{}
+	}
+
+	if len(d)%2 == 1 {
+		d = append(d, d...)
+	}
+	period := 0.0
+	for _, di := range d {
+		period += di
+	}
+
+	q := &Path{}
+	for _, ps := range p.Split() {
+		length := ps.Length()
+		if Equal(length, 0.0) {
+			continue
+		}
+
+		var scale float64
+		if ps.Closed() {
+			n := math.Max(1.0, math.Round(length/period))
+			scale = length / (n * period)
+		} else {
+			n := math.Max(0.0, math.Round((length-d[0])/period))
+			scale = length / (n*period + d[0])
+		}
+
+		ds := make([]float64, len(d))
+		for i := range d {
+			ds[i] = scale * d[i]
+		}
+		q = q.Append(ps.Dash(scale*offset, ds...))
+	}
+	return q
+}
+
 // Reverse returns a new path that is the same path as p but in the reverse direction.
 func (p *Path) Reverse() *Path {
 	rp := &Path{}
@@ -2034,63 +2277,196 @@ func (p *Path) String() string {
 	return sb.String()
 }
 
+// SVGPathFormat specifies how path data is written in the SVG path data format, see Path.ToSVGFormat. The zero value writes absolute commands with numbers at Precision significant digits.
+type SVGPathFormat struct {
+	Decimals  int  // number of decimals to round coordinates to, zero keeps Precision significant digits
+	Relative  bool // use relative commands when they are shorter than absolute commands
+	Shorthand bool // use the S and T commands for smooth Béziers, the H and V commands are always used
+	Compact   bool // omit repeated commands and separators that are not needed, such as before negative numbers
+}
+
 // ToSVG returns a string that represents the path in the SVG path data format with minification.
 func (p *Path) ToSVG() string {
+	return p.ToSVGFormat(SVGPathFormat{})
+}
+
+// ToSVGFormat returns a string that represents the path in the SVG path data format, where the format controls the numeric precision and the use of relative and shorthand commands to reduce its length.
+func (p *Path) ToSVGFormat(format SVGPathFormat) string {
 	if p.Empty() {
 		return ""
 	}
 
-	sb := strings.Builder{}
+	round := func(f float64) float64 { return f }
+	if 0 < format.Decimals {
+		scale := math.Pow(10.0, float64(format.Decimals))
+		round = func(f float64) float64 { return math.Round(f*scale) / scale }
+	}
+	w := svgPathWriter{format: format}
+
+	// positions are kept rounded so that relative coordinates do not accumulate rounding errors
 	var x, y float64
+	var cmdPrev float64
+	var cpPrev Point
 	for i := 0; i < len(p.d); {
 		cmd := p.d[i]
+		xStart, yStart := x, y
 		switch cmd {
 		case MoveToCmd:
-			x, y = p.d[i+1], p.d[i+2]
-			fmt.Fprintf(&sb, "M%v %v", num(x), num(y))
+			x, y = round(p.d[i+1]), round(p.d[i+2])
+			w.write('M', xStart, yStart, []float64{x, y}, svgIsX[:2])
 		case LineToCmd:
-			xStart, yStart := x, y
-			x, y = p.d[i+1], p.d[i+2]
+			x, y = round(p.d[i+1]), round(p.d[i+2])
 			if Equal(x, xStart) && Equal(y, yStart) {
 				// nothing
 			} else if Equal(x, xStart) {
-				fmt.Fprintf(&sb, "V%v", num(y))
+				w.write('V', xStart, yStart, []float64{y}, []bool{false})
 			} else if Equal(y, yStart) {
-				fmt.Fprintf(&sb, "H%v", num(x))
+				w.write('H', xStart, yStart, []float64{x}, []bool{true})
 			} else {
-				fmt.Fprintf(&sb, "L%v %v", num(x), num(y))
+				w.write('L', xStart, yStart, []float64{x, y}, svgIsX[:2])
 			}
 		case QuadToCmd:
-			x, y = p.d[i+3], p.d[i+4]
-			fmt.Fprintf(&sb, "Q%v %v %v %v", num(p.d[i+1]), num(p.d[i+2]), num(x), num(y))
+			cp := Point{round(p.d[i+1]), round(p.d[i+2])}
+			x, y = round(p.d[i+3]), round(p.d[i+4])
+			if format.Shorthand && cmdPrev == QuadToCmd && cp.Equals(Point{2.0*xStart - cpPrev.X, 2.0*yStart - cpPrev.Y}) {
+				w.write('T', xStart, yStart, []float64{x, y}, svgIsX[:2])
+			} else {
+				w.write('Q', xStart, yStart, []float64{cp.X, cp.Y, x, y}, svgIsX[:4])
+			}
+			cpPrev = cp
 		case CubeToCmd:
-			x, y = p.d[i+5], p.d[i+6]
-			fmt.Fprintf(&sb, "C%v %v %v %v %v %v", num(p.d[i+1]), num(p.d[i+2]), num(p.d[i+3]), num(p.d[i+4]), num(x), num(y))
+			cp1 := Point{round(p.d[i+1]), round(p.d[i+2])}
+			cp2 := Point{round(p.d[i+3]), round(p.d[i+4])}
+			x, y = round(p.d[i+5]), round(p.d[i+6])
+			if format.Shorthand && cmdPrev == CubeToCmd && cp1.Equals(Point{2.0*xStart - cpPrev.X, 2.0*yStart - cpPrev.Y}) {
+				w.write('S', xStart, yStart, []float64{cp2.X, cp2.Y, x, y}, svgIsX[:4])
+			} else {
+				w.write('C', xStart, yStart, []float64{cp1.X, cp1.Y, cp2.X, cp2.Y, x, y}, svgIsX[:6])
+			}
+			cpPrev = cp2
 		case ArcToCmd:
 			rx, ry := p.d[i+1], p.d[i+2]
 			rot := p.d[i+3] * 180.0 / math.Pi
 			large, sweep := toArcFlags(p.d[i+4])
-			x, y = p.d[i+5], p.d[i+6]
-			sLarge := "0"
-			if large {
-				sLarge = "1"
-			}
-			sSweep := "0"
-			if sweep {
-				sSweep = "1"
-			}
+			x, y = round(p.d[i+5]), round(p.d[i+6])
 			if 90.0 <= rot {
 				rx, ry = ry, rx
 				rot -= 90.0
 			}
-			fmt.Fprintf(&sb, "A%v %v %v %s%s%v %v", num(rx), num(ry), num(rot), sLarge, sSweep, num(p.d[i+5]), num(p.d[i+6]))
+			w.writeArc(xStart, yStart, round(rx), round(ry), round(rot), large, sweep, x, y)
 		case CloseCmd:
-			x, y = p.d[i+1], p.d[i+2]
-			fmt.Fprintf(&sb, "z")
+			x, y = round(p.d[i+1]), round(p.d[i+2])
+			w.write('Z', xStart, yStart, nil, nil)
 		}
+		cmdPrev = cmd
 		i += cmdLen(cmd)
 	}
-	return sb.String()
+	return w.String()
+}
+
+// svgIsX marks the x coordinates of the alternating x and y coordinates of SVG path data commands.
+var svgIsX = []bool{true, false, true, false, true, false}
+
+// svgPathWriter writes SVG path data commands, choosing between absolute and relative commands and omitting commands and separators depending on the format.
+type svgPathWriter struct {
+	strings.Builder
+	format SVGPathFormat
+
+	cmd     byte   // last written command
+	numPrev string // last written number
+}
+
+func (w *svgPathWriter) num(f float64) string {
+	if w.format.Decimals <= 0 {
+		return num(f).String()
+	}
+	s := fmt.Sprintf("%.*f", w.format.Decimals, f)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		s = "0"
+	} else if strings.HasPrefix(s, "0.") {
+		s = s[1:]
+	} else if strings.HasPrefix(s, "-0.") {
+		s = "-" + s[2:]
+	}
+	return s
+}
+
+// args formats the numbers of a command, where the x coordinates are relative to x0 and the y coordinates to y0 for relative commands.
+func (w *svgPathWriter) args(rel bool, x0, y0 float64, vals []float64, isX []bool) []string {
+	args := make([]string, len(vals))
+	for i, val := range vals {
+		if rel && isX[i] {
+			val -= x0
+		} else if rel {
+			val -= y0
+		}
+		args[i] = w.num(val)
+	}
+	return args
+}
+
+func (w *svgPathWriter) write(cmd byte, x0, y0 float64, vals []float64, isX []bool) {
+	if cmd == 'Z' {
+		w.writeCmd('z', nil)
+		return
+	}
+
+	args := w.args(false, x0, y0, vals, isX)
+	if w.format.Relative {
+		rel := w.args(true, x0, y0, vals, isX)
+		if svgArgsLength(rel) < svgArgsLength(args) {
+			cmd, args = cmd+'a'-'A', rel
+		}
+	}
+	w.writeCmd(cmd, args)
+}
+
+func (w *svgPathWriter) writeArc(x0, y0, rx, ry, rot float64, large, sweep bool, x, y float64) {
+	flags := "0"
+	if large {
+		flags = "1"
+	}
+	if sweep {
+		flags += "1"
+	} else {
+		flags += "0"
+	}
+
+	cmd := byte('A')
+	end := w.args(false, x0, y0, []float64{x, y}, []bool{true, false})
+	if w.format.Relative {
+		rel := w.args(true, x0, y0, []float64{x, y}, []bool{true, false})
+		if svgArgsLength(rel) < svgArgsLength(end) {
+			cmd, end = 'a', rel
+		}
+	}
+	w.writeCmd(cmd, []string{w.num(rx), w.num(ry), w.num(rot), flags + end[0], end[1]})
+}
+
+func svgArgsLength(args []string) int {
+	n := 0
+	for _, arg := range args {
+		n += len(arg) + 1
+	}
+	return n
+}
+
+func (w *svgPathWriter) writeCmd(cmd byte, args []string) {
+	// a moveto followed by coordinates is an implicit lineto
+	implicit := cmd != 'M' && cmd != 'm' && (cmd == w.cmd || w.cmd == 'M' && cmd == 'L' || w.cmd == 'm' && cmd == 'l')
+	if !w.format.Compact || !implicit || len(args) == 0 {
+		w.WriteByte(cmd)
+		w.numPrev = ""
+	}
+	for _, arg := range args {
+		if w.numPrev != "" && (!w.format.Compact || arg[0] != '-' && (arg[0] != '.' || !strings.ContainsRune(w.numPrev, '.') || strings.ContainsAny(w.numPrev, "eE"))) {
+			w.WriteByte(' ')
+		}
+		w.WriteString(arg)
+		w.numPrev = arg
+	}
+	w.cmd = cmd
 }
 
 // ToPS returns a string that represents the path in the PostScript data format.
@@ -2187,20 +2563,36 @@ func (p *Path) ToPDF() string {
 	return sb.String()[1:] // remove the first space
 }
 
+// flattenPool holds the flattened paths of ToRasterizer.
+var flattenPool PathPool
+
 // ToRasterizer rasterizes the path using the given rasterizer and resolution.
 func (p *Path) ToRasterizer(ras *vector.Rasterizer, resolution Resolution) {
+	defer traceRegion("rasterize")()
+
 	dpmm := resolution.DPMM()
-	p = p.Flatten(PixelTolerance / dpmm) // tolerance of 1/10 of a pixel
+	p = p.FlattenTo(flattenPool.Get(), PixelTolerance/dpmm) // tolerance of 1/10 of a pixel
+	defer flattenPool.Put(p)
 	// TODO: smoothen path using Ramer-...
+	p.rasterize(ras, Identity, dpmm)
+}
 
+// rasterize adds the flattened path transformed by m to the rasterizer, where dpmm is the resolution of the rasterizer.
+func (p *Path) rasterize(ras *vector.Rasterizer, m Matrix, dpmm float64) {
 	dy := float64(ras.Bounds().Size().Y)
 	for i := 0; i < len(p.d); {
 		cmd := p.d[i]
 		switch cmd {
-		case MoveToCmd:
-			ras.MoveTo(float32(p.d[i+1]*dpmm), float32(dy-p.d[i+2]*dpmm))
-		case LineToCmd:
-			ras.LineTo(float32(p.d[i+1]*dpmm), float32(dy-p.d[i+2]*dpmm))
+		case MoveToCmd, LineToCmd:
+			pos := Point{p.d[i+1], p.d[i+2]}
+			if m != Identity {
+				pos = m.Dot(pos)
+			}
+			if cmd == MoveToCmd {
+				ras.MoveTo(float32(pos.X*dpmm), float32(dy-pos.Y*dpmm))
+			} else {
+				ras.LineTo(float32(pos.X*dpmm), float32(dy-pos.Y*dpmm))
+			}
 		case CloseCmd:
 			ras.ClosePath()
 		default:
@@ -0,0 +1,299 @@
+package canvas
+
+import (
+	"math"
+	"sort"
+)
+
+// Arrangement is the planar graph formed by a set of line segments, where segments are split at their intersections so that edges only meet at vertices. It is used to polygonize soups of lines, such as extracting the regions enclosed by a road network, or for network analysis. Faces are the bounded regions enclosed by edges, the unbounded region outside all edges has index -1.
+type Arrangement struct {
+	Vertices []Point
+	Edges    []ArrangementEdge
+	Faces    []ArrangementFace
+}
+
+// ArrangementEdge is an edge between two vertices of an arrangement. Left and Right are the indices of the faces to the left and right of the edge when going from V0 to V1, or -1 for the unbounded face. Both are the same face for edges that dangle or are isolated.
+type ArrangementEdge struct {
+	V0, V1      int
+	Left, Right int
+}
+
+// ArrangementFace is a bounded face of an arrangement. The boundary is given by vertex indices in counter clockwise order, and holes, such as around isolated groups of edges inside the face, are given in clockwise order. Dangling edges are traversed on both sides and thus appear twice.
+type ArrangementFace struct {
+	Boundary []int
+	Holes    [][]int
+}
+
+// Arrangement returns the planar graph formed by all segments of the path, see Arrangement. Béziers and arcs are flattened using the tolerance and the path is not filled, so that open subpaths are kept as edges.
+func (p *Path) Arrangement(tolerance float64) *Arrangement {
+	lines := [][2]Point{}
+	for scanner := p.Flatten(tolerance).Scanner(); scanner.Scan(); {
+		if cmd := scanner.Cmd(); cmd == LineToCmd || cmd == CloseCmd {
+			lines = append(lines, [2]Point{scanner.Start(), scanner.End()})
+		}
+	}
+	return NewArrangement(lines)
+}
+
+// NewArrangement returns the planar graph formed by the line segments. Segments are split at their intersections and where they overlap, and vertices closer than Epsilon are merged.
+func NewArrangement(lines [][2]Point) *Arrangement {
+	a := &Arrangement{}
+
+	// find the intersections between segments, checking only segments that overlap horizontally
+	points := make([][]Point, len(lines))
+	order := make([]int, len(lines))
+	for i, line := range lines {
+		points[i] = []Point{line[0], line[1]}
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return math.Min(lines[order[i]][0].X, lines[order[i]][1].X) < math.Min(lines[order[j]][0].X, lines[order[j]][1].X)
+	})
+	for k, i := range order {
+		xmax := math.Max(lines[i][0].X, lines[i][1].X)
+		for _, j := range order[k+1:] {
+			if xmax+Epsilon < math.Min(lines[j][0].X, lines[j][1].X) {
+				break
+			}
+			for _, z := range intersectionLines(lines[i], lines[j]) {
+				points[i] = append(points[i], z)
+				points[j] = append(points[j], z)
+			}
+		}
+	}
+
+	// split the segments into edges between consecutive vertices
+	grid := map[[2]int64][]int{}
+	vertex := func(p Point) int {
+		x, y := int64(math.Floor(p.X/Epsilon)), int64(math.Floor(p.Y/Epsilon))
+		for dx := int64(-1); dx <= 1; dx++ {
+			for dy := int64(-1); dy <= 1; dy++ {
+				for _, v := range grid[[2]int64{x + dx, y + dy}] {
+					if a.Vertices[v].Sub(p).Length() <= Epsilon {
+						return v
+					}
+				}
+			}
+		}
+		a.Vertices = append(a.Vertices, p)
+		grid[[2]int64{x, y}] = append(grid[[2]int64{x, y}], len(a.Vertices)-1)
+		return len(a.Vertices) - 1
+	}
+	edges := map[[2]int]bool{}
+	for i, line := range lines {
+		dir := line[1].Sub(line[0])
+		sort.Slice(points[i], func(j, k int) bool {
+			return points[i][j].Sub(line[0]).Dot(dir) < points[i][k].Sub(line[0]).Dot(dir)
+		})
+		prev := -1
+		for _, p := range points[i] {
+			v := vertex(p)
+			if prev != -1 && v != prev {
+				key := [2]int{min(prev, v), max(prev, v)}
+				if !edges[key] {
+					edges[key] = true
+					a.Edges = append(a.Edges, ArrangementEdge{V0: key[0], V1: key[1]})
+				}
+			}
+			prev = v
+		}
+	}
+
+	a.buildFaces()
+	return a
+}
+
+// intersectionLines returns the points where two line segments intersect, which are the endpoints of the overlap for collinear segments.
+func intersectionLines(a, b [2]Point) []Point {
+	da, db := a[1].Sub(a[0]), b[1].Sub(b[0])
+	la, lb := da.Length(), db.Length()
+	if Equal(la, 0.0) || Equal(lb, 0.0) {
+		return nil
+	}
+
+	denom := da.PerpDot(db)
+	if math.Abs(denom) <= Epsilon*la*lb {
+		// parallel
+		if Epsilon < math.Abs(da.PerpDot(b[0].Sub(a[0])))/la {
+			return nil
+		}
+		zs := []Point{}
+		for _, p := range []Point{b[0], b[1]} {
+			if t := p.Sub(a[0]).Dot(da) / (la * la); 0.0 < t && t < 1.0 {
+				zs = append(zs, p)
+			}
+		}
+		for _, p := range []Point{a[0], a[1]} {
+			if t := p.Sub(b[0]).Dot(db) / (lb * lb); 0.0 < t && t < 1.0 {
+				zs = append(zs, p)
+			}
+		}
+		return zs
+	}
+
+	t := b[0].Sub(a[0]).PerpDot(db) / denom
+	s := b[0].Sub(a[0]).PerpDot(da) / denom
+	if t < -Epsilon/la || 1.0+Epsilon/la < t || s < -Epsilon/lb || 1.0+Epsilon/lb < s {
+		return nil
+	}
+	return []Point{a[0].Add(da.Mul(t))}
+}
+
+// buildFaces traces the faces of the arrangement by following the half-edges around each face, where the face is on the left of the half-edges. Half-edge 2*i goes from V0 to V1 of edge i, and half-edge 2*i+1 goes in the opposite direction.
+func (a *Arrangement) buildFaces() {
+	// sort the outgoing half-edges of every vertex by angle
+	outgoing := make([][]int, len(a.Vertices))
+	origin := func(h int) int {
+		if h%2 == 0 {
+			return a.Edges[h/2].V0
+		}
+		return a.Edges[h/2].V1
+	}
+	target := func(h int) int {
+		return origin(h ^ 1)
+	}
+	angle := func(h int) float64 {
+		d := a.Vertices[target(h)].Sub(a.Vertices[origin(h)])
+		return math.Atan2(d.Y, d.X)
+	}
+	for h := 0; h < 2*len(a.Edges); h++ {
+		outgoing[origin(h)] = append(outgoing[origin(h)], h)
+	}
+	position := make([]int, 2*len(a.Edges))
+	for _, hs := range outgoing {
+		sort.Slice(hs, func(i, j int) bool { return angle(hs[i]) < angle(hs[j]) })
+		for i, h := range hs {
+			position[h] = i
+		}
+	}
+
+	// the next half-edge of a face is the outgoing half-edge clockwise from the twin
+	next := func(h int) int {
+		hs := outgoing[target(h)]
+		return hs[(position[h^1]+len(hs)-1)%len(hs)]
+	}
+
+	cycles := [][]int{}
+	cycle := make([]int, 2*len(a.Edges))
+	for h := range cycle {
+		cycle[h] = -1
+	}
+	for h0 := range cycle {
+		if cycle[h0] != -1 {
+			continue
+		}
+		vs := []int{}
+		for h := h0; cycle[h] == -1; h = next(h) {
+			cycle[h] = len(cycles)
+			vs = append(vs, origin(h))
+		}
+		cycles = append(cycles, vs)
+	}
+
+	// cycles with a positive area are bounded faces, others are holes or the outer boundary of a connected component
+	face := make([]int, len(cycles))
+	holes := []int{}
+	for i, vs := range cycles {
+		if area := a.ringArea(vs); Epsilon < area {
+			face[i] = len(a.Faces)
+			a.Faces = append(a.Faces, ArrangementFace{Boundary: vs})
+		} else {
+			face[i] = -1
+			holes = append(holes, i)
+		}
+	}
+
+	// assign holes to the smallest bounded face that contains them
+	for _, i := range holes {
+		p := a.Vertices[cycles[i][0]]
+		k, kArea := -1, 0.0
+		for j, f := range a.Faces {
+			if area := a.ringArea(f.Boundary); (k == -1 || area < kArea) && a.ringContains(f.Boundary, p) {
+				k, kArea = j, area
+			}
+		}
+		if k != -1 {
+			face[i] = k
+			a.Faces[k].Holes = append(a.Faces[k].Holes, cycles[i])
+		}
+	}
+
+	for i := range a.Edges {
+		a.Edges[i].Left = face[cycle[2*i]]
+		a.Edges[i].Right = face[cycle[2*i+1]]
+	}
+}
+
+func (a *Arrangement) ringArea(vs []int) float64 {
+	area := 0.0
+	for i, v := range vs {
+		p, q := a.Vertices[v], a.Vertices[vs[(i+1)%len(vs)]]
+		area += p.PerpDot(q)
+	}
+	return area / 2.0
+}
+
+// ringContains returns true if the point is strictly inside the ring.
+func (a *Arrangement) ringContains(vs []int, p Point) bool {
+	inside := false
+	for i, v := range vs {
+		p0, p1 := a.Vertices[v], a.Vertices[vs[(i+1)%len(vs)]]
+		if p0.Equals(p) {
+			return false
+		} else if (p0.Y <= p.Y) != (p1.Y <= p.Y) {
+			if x := p0.X + (p.Y-p0.Y)/(p1.Y-p0.Y)*(p1.X-p0.X); p.X < x {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// FacePath returns the path of the face with its holes, where the boundary is counter clockwise and holes are clockwise.
+func (a *Arrangement) FacePath(i int) *Path {
+	p := &Path{}
+	for _, vs := range append([][]int{a.Faces[i].Boundary}, a.Faces[i].Holes...) {
+		for j, v := range vs {
+			if j == 0 {
+				p.MoveTo(a.Vertices[v].X, a.Vertices[v].Y)
+			} else {
+				p.LineTo(a.Vertices[v].X, a.Vertices[v].Y)
+			}
+		}
+		p.Close()
+	}
+	return p
+}
+
+// Neighbours returns the indices of the bounded faces that share an edge with the face, in increasing order.
+func (a *Arrangement) Neighbours(i int) []int {
+	neighbours := []int{}
+	for _, edge := range a.Edges {
+		j := -1
+		if edge.Left == i {
+			j = edge.Right
+		} else if edge.Right == i {
+			j = edge.Left
+		}
+		if j != -1 && j != i {
+			neighbours = append(neighbours, j)
+		}
+	}
+	sort.Ints(neighbours)
+	unique := neighbours[:0]
+	for k, j := range neighbours {
+		if k == 0 || j != neighbours[k-1] {
+			unique = append(unique, j)
+		}
+	}
+	return unique
+}
+
+// Polygons returns the paths of all bounded faces, which polygonizes the line segments.
+func (a *Arrangement) Polygons() []*Path {
+	ps := make([]*Path, len(a.Faces))
+	for i := range a.Faces {
+		ps[i] = a.FacePath(i)
+	}
+	return ps
+}
@@ -0,0 +1,42 @@
+package canvas
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestArrangement(t *testing.T) {
+	a := MustParseSVGPath("M0 0L10 0L10 10L0 10zM5 5L15 5L15 15L5 15z").Arrangement(Tolerance)
+	test.T(t, len(a.Vertices), 10)
+	test.T(t, len(a.Edges), 12)
+	test.T(t, len(a.Faces), 3)
+
+	k := -1
+	for i := range a.Faces {
+		if a.FacePath(i).Bounds() == (Rect{5.0, 5.0, 5.0, 5.0}) {
+			k = i
+		}
+	}
+	test.That(t, k != -1, "intersection face not found")
+	test.T(t, len(a.Faces[k].Boundary), 4)
+	test.T(t, len(a.Neighbours(k)), 2)
+}
+
+func TestArrangementHole(t *testing.T) {
+	a := NewArrangement([][2]Point{
+		{{0.0, 0.0}, {10.0, 0.0}},
+		{{10.0, 0.0}, {10.0, 10.0}},
+		{{10.0, 10.0}, {0.0, 10.0}},
+		{{0.0, 10.0}, {0.0, 0.0}},
+		{{5.0, 5.0}, {8.0, 5.0}},
+		{{2.0, 0.0}, {2.0, -5.0}},
+	})
+	test.T(t, len(a.Faces), 1)
+	test.T(t, len(a.Faces[0].Holes), 1)
+	test.T(t, a.Edges[len(a.Edges)-2].Left, 0)
+	test.T(t, a.Edges[len(a.Edges)-2].Right, 0)
+	test.T(t, a.Edges[len(a.Edges)-1].Left, -1)
+	test.T(t, a.Edges[len(a.Edges)-1].Right, -1)
+	test.T(t, a.Neighbours(0), []int{})
+}
@@ -0,0 +1,171 @@
+package canvas
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultFlattenCache is the cache used when flattening the operands of path intersections and collisions, and when converting paths to polylines. It is disabled when nil.
+var DefaultFlattenCache *FlattenCache
+
+// DefaultTrapezoidCache is the cache used when decomposing filled paths into trapezoids, so that repeatedly rasterizing the same path with other transformations or colors skips scanning the path data. It is disabled when nil.
+var DefaultTrapezoidCache *TrapezoidCache
+
+// pathGen is the last generation assigned to path data.
+var pathGen atomic.Uint64
+
+// generation returns the generation of the path data, assigning a new one if the path has none. Modifying a path through its methods clears its generation. Modifying the slice returned by Data does not, so such paths must not be used with the caches.
+func (p *Path) generation() uint64 {
+	if gen := atomic.LoadUint64(&p.gen); gen != 0 {
+		return gen
+	}
+	gen := pathGen.Add(1)
+	if !atomic.CompareAndSwapUint64(&p.gen, 0, gen) {
+		return atomic.LoadUint64(&p.gen)
+	}
+	return gen
+}
+
+// clearGeneration clears the generation of the path data after it is modified.
+func (p *Path) clearGeneration() {
+	atomic.StoreUint64(&p.gen, 0)
+}
+
+// pathCache holds values derived from paths. Entries are keyed on the generation of the path data rather than the path itself, so that cached paths can be garbage collected and modified paths miss the cache. The oldest entries are evicted first.
+type pathCache[K comparable, V any] struct {
+	mu      sync.Mutex
+	entries map[K]V
+	order   []K
+}
+
+func (c *pathCache[K, V]) get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.entries[key]
+	return v, ok
+}
+
+func (c *pathCache[K, V]) put(key K, v V, size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[K]V{}
+	}
+	if _, ok := c.entries[key]; !ok {
+		for 0 < len(c.order) && size <= len(c.entries) {
+			delete(c.entries, c.order[0])
+			c.order = c.order[1:]
+		}
+		if size <= 0 {
+			return
+		}
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = v
+}
+
+func (c *pathCache[K, V]) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+func (c *pathCache[K, V]) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[K]V{}
+	c.order = nil
+}
+
+// FlattenCache memoizes the results of Flatten per path and tolerance. Entries are keyed on the generation of the path data, so that a path that is modified after it was flattened is flattened again. The cache holds at most Size entries and evicts the oldest entries first. It is safe for concurrent use.
+type FlattenCache struct {
+	Size int
+
+	cache pathCache[flattenKey, *Path]
+}
+
+type flattenKey struct {
+	gen       uint64
+	tolerance float64
+}
+
+// NewFlattenCache returns a flattening cache that holds at most size entries.
+func NewFlattenCache(size int) *FlattenCache {
+	return &FlattenCache{
+		Size: size,
+	}
+}
+
+// Flatten returns the flattened path like p.Flatten(tolerance), using the cached result if p has not changed since it was flattened. The returned path is shared and must not be modified.
+func (c *FlattenCache) Flatten(p *Path, tolerance float64) *Path {
+	if p.Flat() {
+		return p
+	}
+
+	key := flattenKey{p.generation(), tolerance}
+	if flat, ok := c.cache.get(key); ok {
+		return flat
+	}
+	flat := p.Flatten(tolerance)
+	c.cache.put(key, flat, c.Size)
+	return flat
+}
+
+// Len returns the number of cached entries.
+func (c *FlattenCache) Len() int {
+	return c.cache.len()
+}
+
+// Reset removes all entries from the cache.
+func (c *FlattenCache) Reset() {
+	c.cache.reset()
+}
+
+// flatten flattens p using DefaultFlattenCache if set. The returned path must not be modified.
+func flatten(p *Path, tolerance float64) *Path {
+	if DefaultFlattenCache != nil {
+		return DefaultFlattenCache.Flatten(p, tolerance)
+	}
+	return p.Flatten(tolerance)
+}
+
+// TrapezoidCache memoizes the results of Trapezoids per path, fill rule, and tolerance. Entries are keyed on the generation of the path data, so that a path that is modified after it was decomposed is decomposed again. The cache holds at most Size entries and evicts the oldest entries first. It is safe for concurrent use.
+type TrapezoidCache struct {
+	Size int
+
+	cache pathCache[trapezoidKey, []Trapezoid]
+}
+
+type trapezoidKey struct {
+	gen       uint64
+	fillRule  FillRule
+	tolerance float64
+}
+
+// NewTrapezoidCache returns a trapezoid decomposition cache that holds at most size entries.
+func NewTrapezoidCache(size int) *TrapezoidCache {
+	return &TrapezoidCache{
+		Size: size,
+	}
+}
+
+// Trapezoids returns the decomposition like p.Trapezoids(fillRule, tolerance), using the cached result if p has not changed since it was decomposed. The returned slice is shared and must not be modified.
+func (c *TrapezoidCache) Trapezoids(p *Path, fillRule FillRule, tolerance float64) []Trapezoid {
+	key := trapezoidKey{p.generation(), fillRule, tolerance}
+	if trs, ok := c.cache.get(key); ok {
+		return trs
+	}
+	trs := p.Trapezoids(fillRule, tolerance)
+	c.cache.put(key, trs, c.Size)
+	return trs
+}
+
+// Len returns the number of cached entries.
+func (c *TrapezoidCache) Len() int {
+	return c.cache.len()
+}
+
+// Reset removes all entries from the cache.
+func (c *TrapezoidCache) Reset() {
+	c.cache.reset()
+}
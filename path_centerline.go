@@ -0,0 +1,403 @@
+package canvas
+
+import (
+	"math"
+	"sort"
+)
+
+// Centerline returns the centerline of a filled outline and its approximate width, which is the inverse of Stroke. This is useful for pen plotters, so that outlined shapes such as letterforms are drawn with a single line instead of twice along their outline. The outline is sampled on a grid with cells of size tolerance and is thinned to its medial axis, after which branches that run into the corners of the outline are removed and the result is simplified within tolerance. The centerline consists of open subpaths that end at the center of the caps, or closed subpaths for ring-like shapes. The width is the median width of the outline along the centerline.
+func (p *Path) Centerline(fillRule FillRule, tolerance float64) (*Path, float64) {
+	if p.Empty() || tolerance <= 0.0 {
+		return &Path{}, 0.0
+	}
+
+	grid := newCenterlineGrid(p, fillRule, tolerance)
+	dist := grid.distances()
+	skeleton := grid.thin()
+
+	var chains []centerlineChain
+	for {
+		// remove branches into corners until none are left, which may join other branches
+		chains = skeleton.chains()
+		pruned := false
+		next := make([]bool, len(skeleton.filled))
+		for _, chain := range chains {
+			if junction, ok := chain.spur(skeleton, dist); ok {
+				// keep the junction so that blobs reduce to a dot rather than disappearing
+				next[junction] = true
+				pruned = true
+				continue
+			}
+			for _, idx := range chain.pixels {
+				next[idx] = true
+			}
+		}
+		if !pruned {
+			break
+		}
+		skeleton.filled = next
+	}
+
+	q := &Path{}
+	widths := []float64{}
+	for _, chain := range chains {
+		points := make([]Point, 0, len(chain.pixels))
+		for _, idx := range chain.pixels {
+			points = append(points, grid.center(idx))
+			widths = append(widths, 2.0*math.Max(dist[idx]-0.5, 0.0)*grid.h)
+		}
+		if chain.closed {
+			points = append(points, points[0])
+		}
+		points = simplifyPolyline(points, tolerance)
+		if chain.closed {
+			points = points[:len(points)-1]
+		}
+		q.MoveTo(points[0].X, points[0].Y)
+		for _, point := range points[1:] {
+			q.LineTo(point.X, point.Y)
+		}
+		if chain.closed {
+			q.Close()
+		} else if len(points) == 1 {
+			// single pixel, such as the dot of an i, is a zero-length closed subpath since LineTo and Close drop it
+			q.d = append(q.d, CloseCmd, points[0].X, points[0].Y, CloseCmd)
+		}
+	}
+	if len(widths) == 0 {
+		return q, 0.0
+	}
+	sort.Float64s(widths)
+	return q, widths[len(widths)/2]
+}
+
+// centerlineGrid is a grid of pixels that are inside the filled outline. The outermost pixels are always outside.
+type centerlineGrid struct {
+	x0, y0, h float64
+	nx, ny    int
+	filled    []bool
+}
+
+func newCenterlineGrid(p *Path, fillRule FillRule, h float64) *centerlineGrid {
+	bounds := p.Bounds()
+	grid := &centerlineGrid{
+		x0: bounds.X - h,
+		y0: bounds.Y - h,
+		h:  h,
+		nx: int(math.Ceil(bounds.W/h)) + 2,
+		ny: int(math.Ceil(bounds.H/h)) + 2,
+	}
+	grid.filled = make([]bool, grid.nx*grid.ny)
+
+	// collect the edges of the flattened outline, closing open subpaths
+	edges := [][2]Point{}
+	for _, ps := range p.Flatten(h / 4.0).Split() {
+		coords := ps.Coords()
+		for i := range coords {
+			a, b := coords[i], coords[(i+1)%len(coords)]
+			if a.Y != b.Y {
+				edges = append(edges, [2]Point{a, b})
+			}
+		}
+	}
+
+	type crossing struct {
+		x   float64
+		dir int
+	}
+	crossings := []crossing{}
+	for j := 0; j < grid.ny; j++ {
+		y := grid.y0 + (float64(j)+0.5)*h
+		crossings = crossings[:0]
+		for _, edge := range edges {
+			a, b := edge[0], edge[1]
+			dir := 1
+			if b.Y < a.Y {
+				a, b = b, a
+				dir = -1
+			}
+			if a.Y <= y && y < b.Y {
+				x := a.X + (y-a.Y)*(b.X-a.X)/(b.Y-a.Y)
+				crossings = append(crossings, crossing{x, dir})
+			}
+		}
+		sort.Slice(crossings, func(a, b int) bool {
+			return crossings[a].x < crossings[b].x
+		})
+
+		windings := 0
+		for k := 0; k+1 < len(crossings); k++ {
+			windings += crossings[k].dir
+			if !fillRule.Fills(windings) {
+				continue
+			}
+			i0 := int(math.Ceil((crossings[k].x-grid.x0)/h - 0.5))
+			i1 := int(math.Ceil((crossings[k+1].x-grid.x0)/h - 0.5))
+			for i := max(i0, 1); i < min(i1, grid.nx-1); i++ {
+				grid.filled[j*grid.nx+i] = true
+			}
+		}
+	}
+	return grid
+}
+
+// center returns the center of a pixel.
+func (grid *centerlineGrid) center(idx int) Point {
+	i, j := idx%grid.nx, idx/grid.nx
+	return Point{grid.x0 + (float64(i)+0.5)*grid.h, grid.y0 + (float64(j)+0.5)*grid.h}
+}
+
+// distances returns the approximate distance of each pixel to the nearest pixel outside the outline in pixels, using a two-pass chamfer distance transform.
+func (grid *centerlineGrid) distances() []float64 {
+	nx := grid.nx
+	dist := make([]float64, len(grid.filled))
+	for idx, filled := range grid.filled {
+		if filled {
+			dist[idx] = math.Inf(1)
+		}
+	}
+	relax := func(idx, neighbour int, d float64) {
+		if dist[neighbour]+d < dist[idx] {
+			dist[idx] = dist[neighbour] + d
+		}
+	}
+	for j := 1; j < grid.ny-1; j++ {
+		for i := 1; i < nx-1; i++ {
+			idx := j*nx + i
+			relax(idx, idx-1, 1.0)
+			relax(idx, idx-nx-1, math.Sqrt2)
+			relax(idx, idx-nx, 1.0)
+			relax(idx, idx-nx+1, math.Sqrt2)
+		}
+	}
+	for j := grid.ny - 2; 1 <= j; j-- {
+		for i := nx - 2; 1 <= i; i-- {
+			idx := j*nx + i
+			relax(idx, idx+1, 1.0)
+			relax(idx, idx+nx+1, math.Sqrt2)
+			relax(idx, idx+nx, 1.0)
+			relax(idx, idx+nx-1, math.Sqrt2)
+		}
+	}
+	return dist
+}
+
+// neighbours returns the indices of the eight neighbouring pixels in clockwise order, starting at the pixel below.
+func (grid *centerlineGrid) neighbours(idx int) [8]int {
+	nx := grid.nx
+	return [8]int{idx - nx, idx - nx + 1, idx + 1, idx + nx + 1, idx + nx, idx + nx - 1, idx - 1, idx - nx - 1}
+}
+
+// thin returns a copy of the grid thinned to a skeleton of one pixel wide using the Zhang-Suen algorithm.
+func (grid *centerlineGrid) thin() *centerlineGrid {
+	skeleton := *grid
+	skeleton.filled = make([]bool, len(grid.filled))
+	copy(skeleton.filled, grid.filled)
+
+	filled := skeleton.filled
+	remove := []int{}
+	for changed := true; changed; {
+		changed = false
+		for step := 0; step < 2; step++ {
+			remove = remove[:0]
+			for j := 1; j < grid.ny-1; j++ {
+				for i := 1; i < grid.nx-1; i++ {
+					idx := j*grid.nx + i
+					if !filled[idx] {
+						continue
+					}
+					var n [8]bool
+					count, transitions := 0, 0
+					for k, neighbour := range grid.neighbours(idx) {
+						n[k] = filled[neighbour]
+						if n[k] {
+							count++
+						}
+					}
+					for k := 0; k < 8; k++ {
+						if !n[k] && n[(k+1)%8] {
+							transitions++
+						}
+					}
+					if count < 2 || 6 < count || transitions != 1 {
+						continue
+					}
+					if step == 0 && (n[0] && n[2] && n[4] || n[2] && n[4] && n[6]) {
+						continue
+					} else if step == 1 && (n[0] && n[2] && n[6] || n[0] && n[4] && n[6]) {
+						continue
+					}
+					remove = append(remove, idx)
+				}
+			}
+			for _, idx := range remove {
+				filled[idx] = false
+			}
+			if 0 < len(remove) {
+				changed = true
+			}
+		}
+	}
+	return &skeleton
+}
+
+// links returns the neighbouring pixels of a skeleton pixel. Diagonal neighbours are skipped when they are also connected through a horizontal or vertical neighbour, so that staircases form a simple chain.
+func (grid *centerlineGrid) links(idx int) []int {
+	n := grid.neighbours(idx)
+	links := []int{}
+	for k := 0; k < 8; k++ {
+		if !grid.filled[n[k]] {
+			continue
+		} else if k%2 == 1 && (grid.filled[n[k-1]] || grid.filled[n[(k+1)%8]]) {
+			continue
+		}
+		links = append(links, n[k])
+	}
+	return links
+}
+
+// centerlineChain is a chain of skeleton pixels between two end or junction pixels, or a loop of pixels.
+type centerlineChain struct {
+	pixels []int
+	closed bool
+}
+
+// chains splits the skeleton into chains at its end and junction pixels.
+func (grid *centerlineGrid) chains() []centerlineChain {
+	links := map[int][]int{}
+	for idx, filled := range grid.filled {
+		if filled {
+			links[idx] = grid.links(idx)
+		}
+	}
+	type edge [2]int
+	visited := map[edge]bool{}
+	visit := func(a, b int) bool {
+		if b < a {
+			a, b = b, a
+		}
+		if visited[edge{a, b}] {
+			return false
+		}
+		visited[edge{a, b}] = true
+		return true
+	}
+
+	// walk from the start pixel over the given neighbour until reaching an end or junction pixel, or the start pixel again
+	walk := func(start, next int) []int {
+		pixels := []int{start, next}
+		prev, cur := start, next
+		for cur != start && len(links[cur]) == 2 {
+			next = links[cur][0]
+			if next == prev {
+				next = links[cur][1]
+			}
+			if !visit(cur, next) {
+				break
+			}
+			pixels = append(pixels, next)
+			prev, cur = cur, next
+		}
+		return pixels
+	}
+
+	// sort the pixels to have a deterministic order
+	pixels := make([]int, 0, len(links))
+	for idx := range links {
+		pixels = append(pixels, idx)
+	}
+	sort.Ints(pixels)
+
+	chains := []centerlineChain{}
+	for _, idx := range pixels {
+		if len(links[idx]) == 0 {
+			chains = append(chains, centerlineChain{pixels: []int{idx}})
+			continue
+		} else if len(links[idx]) == 2 {
+			continue
+		}
+		for _, next := range links[idx] {
+			if visit(idx, next) {
+				chains = append(chains, centerlineChain{pixels: walk(idx, next)})
+			}
+		}
+	}
+	for _, idx := range pixels {
+		if len(links[idx]) != 2 || !visit(idx, links[idx][0]) {
+			continue
+		}
+		loop := walk(idx, links[idx][0])
+		if loop[len(loop)-1] == idx {
+			loop = loop[:len(loop)-1]
+		}
+		chains = append(chains, centerlineChain{pixels: loop, closed: true})
+	}
+	return chains
+}
+
+// spur returns the junction pixel and true if the chain runs from a junction to a free end and is not longer than twice the distance to the outline at the junction, which happens when the medial axis branches into the corners of the outline.
+func (chain centerlineChain) spur(skeleton *centerlineGrid, dist []float64) (int, bool) {
+	if chain.closed || len(chain.pixels) < 2 {
+		return 0, false
+	}
+	first, last := chain.pixels[0], chain.pixels[len(chain.pixels)-1]
+	nFirst, nLast := len(skeleton.links(first)), len(skeleton.links(last))
+	junction := first
+	if nFirst == 1 && 3 <= nLast {
+		junction = last
+	} else if !(3 <= nFirst && nLast == 1) {
+		return 0, false
+	}
+
+	length := 0.0
+	for i := 1; i < len(chain.pixels); i++ {
+		a, b := chain.pixels[i-1], chain.pixels[i]
+		if b-a == 1 || a-b == 1 || b-a == skeleton.nx || a-b == skeleton.nx {
+			length += 1.0
+		} else {
+			length += math.Sqrt2
+		}
+	}
+	return junction, length <= 2.0*dist[junction]
+}
+
+// simplifyPolyline simplifies a polyline using the Ramer-Douglas-Peucker algorithm so that it deviates no more than tolerance. The first and last points are kept.
+func simplifyPolyline(points []Point, tolerance float64) []Point {
+	if len(points) < 3 {
+		return points
+	}
+
+	keep := make([]bool, len(points))
+	keep[0], keep[len(points)-1] = true, true
+	var simplify func(int, int)
+	simplify = func(i0, i1 int) {
+		a, b := points[i0], points[i1]
+		d := b.Sub(a)
+		maxDist, maxIndex := 0.0, -1
+		for i := i0 + 1; i < i1; i++ {
+			var dist float64
+			if d.Equals(Point{}) {
+				dist = points[i].Sub(a).Length()
+			} else {
+				dist = math.Abs(d.PerpDot(points[i].Sub(a))) / d.Length()
+			}
+			if maxDist < dist {
+				maxDist, maxIndex = dist, i
+			}
+		}
+		if tolerance < maxDist {
+			keep[maxIndex] = true
+			simplify(i0, maxIndex)
+			simplify(maxIndex, i1)
+		}
+	}
+	simplify(0, len(points)-1)
+
+	simplified := []Point{}
+	for i, point := range points {
+		if keep[i] {
+			simplified = append(simplified, point)
+		}
+	}
+	return simplified
+}
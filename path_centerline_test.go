@@ -0,0 +1,37 @@
+package canvas
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestPathCenterline(t *testing.T) {
+	// stroke of a horizontal line with butt caps
+	p, width := Rectangle(10.0, 2.0).Centerline(NonZero, 0.1)
+	test.T(t, len(p.Split()), 1)
+	test.That(t, !p.Closed())
+	test.That(t, math.Abs(width-2.0) <= 0.2, "width", width)
+	bounds := p.Bounds()
+	test.That(t, bounds.H <= 0.2, "height", bounds.H)
+	test.That(t, math.Abs(bounds.Y-1.0) <= 0.2, "y", bounds.Y)
+	test.That(t, 0.5 <= bounds.X && bounds.X+bounds.W <= 9.5 && 7.0 <= bounds.W, "x", bounds.X, bounds.W)
+
+	// ring
+	p, width = Circle(5.0).Append(Circle(4.0)).Centerline(EvenOdd, 0.1)
+	test.T(t, len(p.Split()), 1)
+	test.That(t, p.Closed())
+	test.That(t, math.Abs(width-1.0) <= 0.2, "width", width)
+	bounds = p.Bounds()
+	test.That(t, math.Abs(bounds.W-9.0) <= 0.3 && math.Abs(bounds.H-9.0) <= 0.3, "size", bounds.W, bounds.H)
+
+	// square reduces to a dot
+	p, _ = Rectangle(2.0, 2.0).Centerline(NonZero, 0.1)
+	test.T(t, len(p.Split()), 1)
+	test.That(t, p.Bounds().W <= 0.5 && p.Bounds().H <= 0.5)
+
+	p, width = (&Path{}).Centerline(NonZero, 0.1)
+	test.That(t, p.Empty())
+	test.Float(t, width, 0.0)
+}
@@ -0,0 +1,164 @@
+package canvas
+
+import (
+	"golang.org/x/image/vector"
+)
+
+// DefaultChunkSize is the default number of values (float64s) after which a ChunkedPath starts a new chunk, which is 1M values or 8 MB.
+const DefaultChunkSize = 1 << 20
+
+// ChunkedPath is a path that is stored in a sequence of chunks instead of a single slice, for gigantic paths with hundreds of millions of vertices such as GIS contours. Each chunk is a Path that holds complete subpaths, and a new chunk is started by MoveTo once the current chunk holds more than the chunk size. Operations such as Flatten, Settle and ToRasterizer process the path chunk by chunk, so that no single allocation holds the whole path.
+type ChunkedPath struct {
+	chunkSize int
+	chunks    []*Path
+}
+
+// NewChunkedPath returns an empty chunked path that starts a new chunk after chunkSize values (float64s), or after DefaultChunkSize values if chunkSize is not positive.
+func NewChunkedPath(chunkSize int) *ChunkedPath {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	return &ChunkedPath{chunkSize: chunkSize}
+}
+
+func (cp *ChunkedPath) last() *Path {
+	if len(cp.chunks) == 0 {
+		cp.chunks = append(cp.chunks, &Path{})
+	}
+	return cp.chunks[len(cp.chunks)-1]
+}
+
+// Empty returns true if the path is empty or consists of only MoveTo commands.
+func (cp *ChunkedPath) Empty() bool {
+	for _, chunk := range cp.chunks {
+		if !chunk.Empty() {
+			return false
+		}
+	}
+	return true
+}
+
+// Len returns the number of segments.
+func (cp *ChunkedPath) Len() int {
+	n := 0
+	for _, chunk := range cp.chunks {
+		n += chunk.Len()
+	}
+	return n
+}
+
+// Chunks returns the chunks of the path, which must not be modified.
+func (cp *ChunkedPath) Chunks() []*Path {
+	return cp.chunks
+}
+
+// MoveTo moves the path to (x,y) without connecting the path, see Path.MoveTo. It starts a new chunk if the current chunk is full.
+func (cp *ChunkedPath) MoveTo(x, y float64) {
+	if p := cp.last(); cp.chunkSize <= len(p.d) && p.d[len(p.d)-1] != MoveToCmd {
+		cp.chunks = append(cp.chunks, &Path{})
+	}
+	cp.last().MoveTo(x, y)
+}
+
+// LineTo adds a linear path to (x,y), see Path.LineTo.
+func (cp *ChunkedPath) LineTo(x, y float64) {
+	cp.last().LineTo(x, y)
+}
+
+// QuadTo adds a quadratic Bézier path with control point (cpx,cpy) and end point (x,y), see Path.QuadTo.
+func (cp *ChunkedPath) QuadTo(cpx, cpy, x, y float64) {
+	cp.last().QuadTo(cpx, cpy, x, y)
+}
+
+// CubeTo adds a cubic Bézier path with control points (cpx1,cpy1) and (cpx2,cpy2) and end point (x,y), see Path.CubeTo.
+func (cp *ChunkedPath) CubeTo(cpx1, cpy1, cpx2, cpy2, x, y float64) {
+	cp.last().CubeTo(cpx1, cpy1, cpx2, cpy2, x, y)
+}
+
+// ArcTo adds an arc with radii rx and ry, with rot the counter clockwise rotation with respect to the coordinate system in degrees, large and sweep booleans, and (x,y) the end position of the pen, see Path.ArcTo.
+func (cp *ChunkedPath) ArcTo(rx, ry, rot float64, large, sweep bool, x, y float64) {
+	cp.last().ArcTo(rx, ry, rot, large, sweep, x, y)
+}
+
+// Close closes the current subpath, see Path.Close.
+func (cp *ChunkedPath) Close() {
+	cp.last().Close()
+}
+
+// AppendPath appends the subpaths of p, starting new chunks as needed.
+func (cp *ChunkedPath) AppendPath(p *Path) {
+	for _, ps := range p.Split() {
+		d := ps.d
+		x, y := 0.0, 0.0
+		if d[0] == MoveToCmd {
+			x, y = d[1], d[2]
+			d = d[cmdLen(MoveToCmd):]
+		}
+		cp.MoveTo(x, y)
+		last := cp.last()
+		last.d = append(last.d, d...)
+		last.clearGeneration()
+	}
+}
+
+// Path returns the path of all chunks concatenated, which is only feasible for paths that fit in memory as a single slice.
+func (cp *ChunkedPath) Path() *Path {
+	n := 0
+	for _, chunk := range cp.chunks {
+		n += len(chunk.d)
+	}
+	p := &Path{d: make([]float64, 0, n)}
+	for _, chunk := range cp.chunks {
+		p.d = append(p.d, chunk.d...)
+	}
+	return p
+}
+
+// FastBounds returns the maximum bounding box rectangle of the path, see Path.FastBounds.
+func (cp *ChunkedPath) FastBounds() Rect {
+	var r Rect
+	for i, chunk := range cp.chunks {
+		if i == 0 {
+			r = chunk.FastBounds()
+		} else {
+			r = r.Add(chunk.FastBounds())
+		}
+	}
+	return r
+}
+
+// Flatten flattens all Bézier and arc curves into linear segments chunk by chunk and returns a new chunked path, see Path.Flatten.
+func (cp *ChunkedPath) Flatten(tolerance float64) *ChunkedPath {
+	q := &ChunkedPath{chunkSize: cp.chunkSize, chunks: make([]*Path, len(cp.chunks))}
+	for i, chunk := range cp.chunks {
+		q.chunks[i] = chunk.Flatten(tolerance)
+	}
+	return q
+}
+
+// Settle returns the settled path chunk by chunk, see Path.Settle. Chunks whose bounding boxes overlap, directly or through other chunks, are settled together since their subpaths may intersect, while all other chunks are settled independently. The result has one chunk for each such group of chunks.
+func (cp *ChunkedPath) Settle(fillRule FillRule) *ChunkedPath {
+	boxes := make([]bbox, len(cp.chunks))
+	for i, chunk := range cp.chunks {
+		boxes[i] = pathBBox(chunk)
+	}
+
+	q := &ChunkedPath{chunkSize: cp.chunkSize}
+	for _, indices := range clusterBBoxes(boxes) {
+		group := &Path{}
+		for _, i := range indices {
+			group.d = append(group.d, cp.chunks[i].d...)
+		}
+		if p := group.Settle(fillRule); !p.Empty() {
+			q.chunks = append(q.chunks, p)
+		}
+	}
+	return q
+}
+
+// ToRasterizer rasterizes the path chunk by chunk using the given rasterizer and resolution, see Path.ToRasterizer.
+func (cp *ChunkedPath) ToRasterizer(ras *vector.Rasterizer, resolution Resolution) {
+	for _, chunk := range cp.chunks {
+		chunk.ToRasterizer(ras, resolution)
+	}
+}
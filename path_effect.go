@@ -0,0 +1,176 @@
+package canvas
+
+import (
+	"math"
+	"math/rand"
+)
+
+// polylines returns the flattened subpaths of the path as lists of points, where closed subpaths do not repeat their first point at the end.
+func (p *Path) polylines(tolerance float64) ([][]Point, []bool) {
+	polylines, closed := [][]Point{}, []bool{}
+	for _, ps := range p.Flatten(tolerance).Split() {
+		points := []Point{}
+		for scanner := ps.Scanner(); scanner.Scan(); {
+			if scanner.Cmd() != CloseCmd {
+				points = append(points, scanner.End())
+			}
+		}
+		isClosed := ps.Closed()
+		if isClosed && 1 < len(points) && points[0].Equals(points[len(points)-1]) {
+			points = points[:len(points)-1]
+		}
+		polylines = append(polylines, points)
+		closed = append(closed, isClosed)
+	}
+	return polylines, closed
+}
+
+// Roughen returns the path with a hand-drawn look, as for an xkcd or sketchy style. The path is flattened and resampled about every wavelength millimeters, including at its vertices, after which the points are displaced randomly by at most amplitude millimeters and connected by smooth cubic Béziers. The seed makes the result reproducible. It works on any path, such as shapes and text outlines, and the result can be filled and stroked.
+func (p *Path) Roughen(amplitude, wavelength float64, seed int64) *Path {
+	if amplitude <= 0.0 || wavelength <= 0.0 {
+		return p.Copy()
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	q := &Path{}
+	polylines, closed := p.polylines(Tolerance)
+	for k, points := range polylines {
+		// resample
+		n := len(points)
+		if !closed[k] {
+			n--
+		}
+		resampled := []Point{}
+		for i := 0; i < n; i++ {
+			a, b := points[i], points[(i+1)%len(points)]
+			m := math.Max(1.0, math.Ceil(b.Sub(a).Length()/wavelength))
+			for j := 0.0; j < m; j++ {
+				resampled = append(resampled, a.Interpolate(b, j/m))
+			}
+		}
+		if !closed[k] || len(resampled) == 0 {
+			resampled = append(resampled, points[len(points)-1])
+		}
+
+		// displace
+		for i := range resampled {
+			resampled[i].X += amplitude * (2.0*rng.Float64() - 1.0)
+			resampled[i].Y += amplitude * (2.0*rng.Float64() - 1.0)
+		}
+		catmullRom(q, resampled, closed[k])
+	}
+	return q
+}
+
+// Sketch returns the path roughened passes times with different random displacements, as if drawn with several quick strokes by hand. It is meant to be stroked, see Roughen.
+func (p *Path) Sketch(amplitude, wavelength float64, passes int, seed int64) *Path {
+	q := &Path{}
+	for i := 0; i < passes; i++ {
+		q = q.Append(p.Roughen(amplitude, wavelength, seed+int64(i)))
+	}
+	return q
+}
+
+// catmullRom appends a smooth curve through the points to p, using cubic Béziers that follow a Catmull-Rom spline.
+func catmullRom(p *Path, points []Point, closed bool) {
+	n := len(points)
+	if n == 0 {
+		return
+	}
+	at := func(i int) Point {
+		if closed {
+			return points[(i+n)%n]
+		}
+		return points[min(max(i, 0), n-1)]
+	}
+
+	p.MoveTo(points[0].X, points[0].Y)
+	m := n - 1
+	if closed {
+		m = n
+	}
+	for i := 0; i < m; i++ {
+		p0, p1, p2, p3 := at(i-1), at(i), at(i+1), at(i+2)
+		cp1 := p1.Add(p2.Sub(p0).Div(6.0))
+		cp2 := p2.Sub(p3.Sub(p1).Div(6.0))
+		p.CubeTo(cp1.X, cp1.Y, cp2.X, cp2.Y, p2.X, p2.Y)
+	}
+	if closed {
+		p.Close()
+	}
+}
+
+// Wave returns the path with every subpath replaced by a sine wave that follows the path, with the given amplitude and period in millimeters, such as for rivers on maps or decorative borders. The period is adjusted slightly so that an integer number of periods fits each subpath, which makes the wave start and end on the path and lets closed subpaths join seamlessly.
+func (p *Path) Wave(amplitude, period float64) *Path {
+	const samples = 16 // per period
+	return p.periodicEffect(period, samples, true, func(t float64) float64 {
+		return amplitude * math.Sin(2.0*math.Pi*t)
+	})
+}
+
+// Zigzag returns the path with every subpath replaced by a zigzag that follows the path, with the given amplitude and period in millimeters, such as for cliffs on maps or decorative borders. The period is adjusted slightly so that an integer number of periods fits each subpath, which makes the zigzag start and end on the path and lets closed subpaths join seamlessly.
+func (p *Path) Zigzag(amplitude, period float64) *Path {
+	return p.periodicEffect(period, 4, false, func(t float64) float64 {
+		return amplitude * []float64{0.0, 1.0, 0.0, -1.0}[int(math.Round(4.0*t))%4]
+	})
+}
+
+// periodicEffect returns the path where each subpath is sampled n times per period and displaced along its normal by f, which is given the position along the subpath in periods. The samples are connected smoothly or by lines.
+func (p *Path) periodicEffect(period float64, n int, smooth bool, f func(float64) float64) *Path {
+	if period <= 0.0 {
+		return p.Copy()
+	}
+
+	q := &Path{}
+	polylines, closed := p.polylines(Tolerance)
+	for k, points := range polylines {
+		if closed[k] {
+			points = append(points, points[0])
+		}
+		lengths := make([]float64, len(points))
+		for i := 1; i < len(points); i++ {
+			lengths[i] = lengths[i-1] + points[i].Sub(points[i-1]).Length()
+		}
+		length := lengths[len(lengths)-1]
+		if Equal(length, 0.0) {
+			continue
+		}
+
+		periods := math.Max(1.0, math.Round(length/period))
+		m := int(periods) * n
+		samples := []Point{}
+		for j, i := 0, 0; j <= m; j++ {
+			if j == m && closed[k] {
+				break
+			}
+			s := float64(j) / float64(m) * length
+			for i+2 < len(points) && lengths[i+1] < s {
+				i++
+			}
+			a, b := points[i], points[i+1]
+			if a.Equals(b) {
+				samples = append(samples, a)
+				continue
+			}
+			t := (s - lengths[i]) / (lengths[i+1] - lengths[i])
+			normal := b.Sub(a).Rot90CCW().Norm(1.0)
+			samples = append(samples, a.Interpolate(b, t).Add(normal.Mul(f(float64(j)/float64(n)))))
+		}
+
+		if smooth {
+			catmullRom(q, samples, closed[k])
+		} else {
+			for i, sample := range samples {
+				if i == 0 {
+					q.MoveTo(sample.X, sample.Y)
+				} else {
+					q.LineTo(sample.X, sample.Y)
+				}
+			}
+			if closed[k] {
+				q.Close()
+			}
+		}
+	}
+	return q
+}
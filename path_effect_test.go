@@ -0,0 +1,47 @@
+package canvas
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestPathRoughen(t *testing.T) {
+	p := MustParseSVGPath("M0 0L10 0L10 10L0 10zM20 0L30 0")
+	test.T(t, p.Roughen(0.0, 1.0, 0), p)
+
+	q := p.Roughen(0.5, 2.0, 1)
+	test.T(t, q, p.Roughen(0.5, 2.0, 1))
+	test.That(t, !q.Equals(p.Roughen(0.5, 2.0, 2)), "different seeds must give different paths")
+	test.T(t, len(q.Split()), 2)
+	test.That(t, q.Split()[0].Closed(), "closed subpaths must stay closed")
+	test.That(t, !q.Split()[1].Closed(), "open subpaths must stay open")
+
+	bounds := q.Bounds()
+	test.That(t, -1.0 <= bounds.X && -1.0 <= bounds.Y && bounds.X+bounds.W <= 31.0 && bounds.Y+bounds.H <= 11.0, "roughened path must stay near the original")
+}
+
+func TestPathSketch(t *testing.T) {
+	p := MustParseSVGPath("M0 0L10 0L10 10L0 10z")
+	test.T(t, len(p.Sketch(0.5, 2.0, 3, 1).Split()), 3)
+	test.T(t, p.Sketch(0.5, 2.0, 3, 1).Split()[1], p.Roughen(0.5, 2.0, 2))
+}
+
+func TestPathWave(t *testing.T) {
+	p := MustParseSVGPath("M0 0L8 0").Wave(1.0, 4.0)
+	test.T(t, p.StartPos(), Point{0.0, 0.0})
+	test.T(t, p.Pos(), Point{8.0, 0.0})
+	bounds := p.Bounds()
+	test.Float(t, bounds.X, 0.0)
+	test.Float(t, bounds.W, 8.0)
+	test.That(t, 0.95 < bounds.Y+bounds.H && bounds.Y+bounds.H < 1.05, "wave must reach its amplitude")
+
+	q := MustParseSVGPath("M0 0L10 0L10 10L0 10z").Wave(1.0, 4.0)
+	test.That(t, q.Closed(), "closed subpaths must stay closed")
+}
+
+func TestPathZigzag(t *testing.T) {
+	test.T(t, MustParseSVGPath("M0 0L8 0").Zigzag(1.0, 4.0), MustParseSVGPath("M0 0L1 1L3 -1L5 1L7 -1L8 0"))
+	test.T(t, MustParseSVGPath("M0 0L8 0").Zigzag(1.0, 5.0), MustParseSVGPath("M0 0L1 1L3 -1L5 1L7 -1L8 0"))
+	test.T(t, MustParseSVGPath("M0 0L4 0L4 4L0 4z").Zigzag(1.0, 4.0), MustParseSVGPath("M0 0L1 1L3 -1L4 0L3 1L5 3L4 4L3 3L1 5L0 4L1 3L-1 1z"))
+}
@@ -122,6 +122,8 @@ type nodeItem struct {
 // Settle simplifies a path by removing all self-intersections and overlapping parts. Open paths are not handled and returned as-is. The returned subpaths are oriented counter clock-wise when filled and clock-wise for holes. This means that the result is agnostic to the winding rule used for drawing. The result will only contain point-tangent intersections, but not parallel-tangent intersections or regular intersections.
 // See L. Subramaniam, "Partition of a non-simple polygon into simple pologons", 2003
 func (p *Path) Settle(fillRule FillRule) *Path {
+	defer traceRegion("settle")()
+
 	// TODO: handle tangent intersections, which should divide into inner/disjoint rings
 	// TODO: handle and remove parallel parts
 	// TODO: for EvenOdd, output filled polygons only, not fill-rings and hole-rings
@@ -146,24 +148,17 @@ func (p *Path) Settle(fillRule FillRule) *Path {
 		return open
 	}
 
-	// Flatten Bézier segments, this is justified since the primary usage of Settle is after
-	// applying stroke, which already flattened all Bézier curves. The other usages of Settle, such
-	// as for path boolean operations, suffer from the loss of precision (though this is very common
-	// among path manipulation libraries).
-	// Additionally, this ensures that the path X-monotone (which should be preserved if in the
-	// future we add support for quad/cube intersections).
+	// Split curves into X-monotone parts, which are intersected exactly so that they are kept in
+	// the output.
 	// TODO: can we delay XMonotone until after pathIntersections to avoid processing longer paths?
 	// TODO: can we undo XMonotone at the end so we don't end up with longer paths?
 	quad := func(p0, p1, p2 Point) *Path {
-		return flattenQuadraticBezier(p0, p1, p2, Tolerance)
+		return xmonotoneQuadraticBezier(p0, p1, p2)
 	}
 	cube := func(p0, p1, p2, p3 Point) *Path {
-		return flattenCubicBezier(p0, p1, p2, p3, Tolerance)
+		return xmonotoneCubicBezier(p0, p1, p2, p3)
 	}
 	arc := func(start Point, rx, ry, phi float64, large, sweep bool, end Point) *Path {
-		if !Equal(rx, ry) {
-			return flattenEllipticArc(start, rx, ry, phi, large, sweep, end, Tolerance)
-		}
 		return xmonotoneEllipticArc(start, rx, ry, phi, large, sweep, end)
 	}
 	p = p.replace(nil, quad, cube, arc)
@@ -439,6 +434,25 @@ func (p *Path) DivideBy(q *Path) *Path {
 	return boolean(p, pathOpDivide, q)
 }
 
+// Booleans are the results of all boolean path operations between path p and q, see Path.Booleans.
+type Booleans struct {
+	And, Or, Xor *Path
+	PNotQ        *Path // p NOT q
+	QNotP        *Path // q NOT p
+}
+
+// Booleans returns the results of all boolean path operations of path p and q at once. This is much faster than calling each operation separately, since the intersections between the paths, which dominate the cost, are found only once. Path q is implicitly closed.
+func (p *Path) Booleans(q *Path) Booleans {
+	R := booleans(p, []pathOp{pathOpAnd, pathOpOr, pathOpXor, pathOpNot, pathOpNotReversed}, q)
+	return Booleans{
+		And:   R[0],
+		Or:    R[1],
+		Xor:   R[2],
+		PNotQ: R[3],
+		QNotP: R[4],
+	}
+}
+
 type pathOp int
 
 const (
@@ -446,23 +460,40 @@ const (
 	pathOpOr
 	pathOpXor
 	pathOpNot
+	pathOpNotReversed // q NOT p
 	pathOpDivide
 )
 
-// path p can be open or closed paths (we handle them separately), path q is closed implicitly
 func boolean(p *Path, op pathOp, q *Path) *Path {
+	return booleans(p, []pathOp{op}, q)[0]
+}
+
+// path p can be open or closed paths (we handle them separately), path q is closed implicitly
+// the results for each operation are computed from the same intersections
+func booleans(p *Path, ops []pathOp, q *Path) []*Path {
+	defer traceRegion("boolean")()
+
+	R := make([]*Path, len(ops))
+	for k := range R {
+		R[k] = &Path{}
+	}
+
 	// return in case of one path is empty
 	if q.Empty() {
-		if op != pathOpAnd {
-			return p
+		for k, op := range ops {
+			if op != pathOpAnd && op != pathOpNotReversed {
+				R[k] = p
+			}
 		}
-		return &Path{}
+		return R
 	}
 	if p.Empty() {
-		if op == pathOpOr || op == pathOpXor {
-			return q
+		for k, op := range ops {
+			if op == pathOpOr || op == pathOpXor || op == pathOpNotReversed {
+				R[k] = q
+			}
 		}
-		return &Path{}
+		return R
 	}
 
 	// remove self-intersections within each path and make filling paths CCW
@@ -489,7 +520,10 @@ func boolean(p *Path, op pathOp, q *Path) *Path {
 	j := 0      // index into zp
 	p = &Path{} // collect all closed paths
 	offset, shift := 0, 0
-	Ropen := &Path{}
+	Ropen := make([]*Path, len(ops))
+	for k := range Ropen {
+		Ropen[k] = &Path{}
+	}
 	for i := 0; i < len(ps); i++ {
 		n := 0
 		length, closed := ps[i].Len(), ps[i].Closed()
@@ -526,20 +560,24 @@ func boolean(p *Path, op pathOp, q *Path) *Path {
 					k += cmdLen(ps[i].d[k])
 				}
 				inside := n != 0 // NonZero
-				if op == pathOpOr || inside && op == pathOpAnd || !inside && !boundary && (op == pathOpXor || op == pathOpNot) {
-					Ropen = Ropen.Append(ps[i])
+				for k, op := range ops {
+					if op == pathOpOr || inside && op == pathOpAnd || !inside && !boundary && (op == pathOpXor || op == pathOpNot) {
+						Ropen[k] = Ropen[k].Append(ps[i])
+					}
 				}
 			} else {
 				// paths cross, select the parts outside/inside depending on the operation
 				pss, _ := cut(ps[i], zp[j:j+n])
-				inside := !zp[j].Into
-				if op == pathOpOr || inside && op == pathOpAnd || !inside && (op == pathOpXor || op == pathOpNot) {
-					Ropen = Ropen.Append(pss[0])
-				}
-				for k := 1; k < len(pss); k++ {
-					inside := zp[j+k-1].Into
-					if !zp[j+k-1].Parallel && (op == pathOpOr || inside && op == pathOpAnd || !inside && (op == pathOpXor || op == pathOpNot)) {
-						Ropen = Ropen.Append(pss[k])
+				for k, op := range ops {
+					inside := !zp[j].Into
+					if op == pathOpOr || inside && op == pathOpAnd || !inside && (op == pathOpXor || op == pathOpNot) {
+						Ropen[k] = Ropen[k].Append(pss[0])
+					}
+					for l := 1; l < len(pss); l++ {
+						inside := zp[j+l-1].Into
+						if !zp[j+l-1].Parallel && (op == pathOpOr || inside && op == pathOpAnd || !inside && (op == pathOpXor || op == pathOpNot)) {
+							Ropen[k] = Ropen[k].Append(pss[l])
+						}
 					}
 				}
 			}
@@ -553,7 +591,9 @@ func boolean(p *Path, op pathOp, q *Path) *Path {
 
 	// handle intersecting subpaths
 	zs := pathIntersectionNodes(p, q, zp, zq)
-	R := booleanIntersections(op, zs)
+	for k, op := range ops {
+		R[k] = booleanIntersections(op, zs)
+	}
 
 	// handle the remaining subpaths that are non-intersecting but possibly overlapping, either one containing the other or by being equal
 	pIndex, qIndex := newSubpathIndexerSubpaths(ps), newSubpathIndexerSubpaths(qs)
@@ -569,8 +609,10 @@ func boolean(p *Path, op pathOp, q *Path) *Path {
 			for j, qi := range qs {
 				if !qHandled[j] {
 					if pi.Same(qi) {
-						if op == pathOpAnd || op == pathOpOr {
-							R = R.Append(pi)
+						for k, op := range ops {
+							if op == pathOpAnd || op == pathOpOr {
+								R[k] = R[k].Append(pi)
+							}
 						}
 						pHandled[i] = true
 						qHandled[j] = true
@@ -583,19 +625,23 @@ func boolean(p *Path, op pathOp, q *Path) *Path {
 	// contained paths
 	for i, pi := range ps {
 		if !pHandled[i] && pi.inside(q) {
-			if op == pathOpAnd || op == pathOpDivide {
-				R = R.Append(pi)
-			} else if op == pathOpXor {
-				R = R.Append(pi.Reverse())
+			for k, op := range ops {
+				if op == pathOpAnd || op == pathOpDivide {
+					R[k] = R[k].Append(pi)
+				} else if op == pathOpXor || op == pathOpNotReversed {
+					R[k] = R[k].Append(pi.Reverse())
+				}
 			}
 			pHandled[i] = true
 		}
 	}
 	// non-overlapping paths
-	if op != pathOpAnd {
-		for i, pi := range ps {
-			if !pHandled[i] {
-				R = R.Append(pi)
+	for k, op := range ops {
+		if op != pathOpAnd && op != pathOpNotReversed {
+			for i, pi := range ps {
+				if !pHandled[i] {
+					R[k] = R[k].Append(pi)
+				}
 			}
 		}
 	}
@@ -603,23 +649,28 @@ func boolean(p *Path, op pathOp, q *Path) *Path {
 	// contained paths
 	for i, qi := range qs {
 		if !qHandled[i] && qi.inside(p) {
-			if op == pathOpAnd || op == pathOpDivide {
-				R = R.Append(qi)
-			} else if op == pathOpXor || op == pathOpNot {
-				R = R.Append(qi.Reverse())
+			for k, op := range ops {
+				if op == pathOpAnd || op == pathOpDivide {
+					R[k] = R[k].Append(qi)
+				} else if op == pathOpXor || op == pathOpNot {
+					R[k] = R[k].Append(qi.Reverse())
+				}
 			}
 			qHandled[i] = true
 		}
 	}
 	// non-overlapping paths
-	if op == pathOpOr || op == pathOpXor {
-		for i, qi := range qs {
-			if !qHandled[i] {
-				R = R.Append(qi)
+	for k, op := range ops {
+		if op == pathOpOr || op == pathOpXor || op == pathOpNotReversed {
+			for i, qi := range qs {
+				if !qHandled[i] {
+					R[k] = R[k].Append(qi)
+				}
 			}
 		}
+		R[k] = R[k].Append(Ropen[k]) // add the open paths
 	}
-	return R.Append(Ropen) // add the open paths
+	return R
 }
 
 func booleanIntersections(op pathOp, zs []PathIntersectionNode) *Path {
@@ -637,6 +688,10 @@ func booleanIntersections(op pathOp, zs []PathIntersectionNode) *Path {
 		K = 2
 		invertP[1] = true
 		invertQ[1] = true
+	} else if op == pathOpNotReversed {
+		// run as (q NOT p)
+		invertP[0] = true
+		invertQ[0] = true
 	} else if op == pathOpDivide {
 		// run as (p NOT q) and then as (p AND q)
 		K = 2
@@ -718,9 +773,9 @@ func booleanIntersections(op pathOp, zs []PathIntersectionNode) *Path {
 	return R
 }
 
-// Cut cuts path p by path q and returns the parts.
+// Cut cuts path p by path q and returns the parts of p between the intersections, which are not closed. Closed subpaths of p are joined at their start, so that each part runs from one intersection to the next. This is useful to clip open paths such as strokes to a region, by keeping the parts that are inside the region. Curves are cut exactly and are not flattened.
 func (p *Path) Cut(q *Path) []*Path {
-	zs, _ := p.Intersections(q)
+	zs, _ := pathIntersections(p, q, false, false)
 	pi, _ := cut(p, zs)
 	return pi
 }
@@ -734,7 +789,7 @@ func (p *Path) Intersects(q *Path) bool {
 // Intersections for path p by path q, sorted for path p.
 func (p *Path) Intersections(q *Path) ([]PathIntersection, []PathIntersection) {
 	if !p.Flat() {
-		q = q.Flatten(Tolerance)
+		q = flatten(q, Tolerance)
 	}
 	return pathIntersections(p, q, false, false)
 }
@@ -748,7 +803,7 @@ func (p *Path) Touches(q *Path) bool {
 // Collisions (secants/intersections and tangents/touches) for path p by path q, sorted for path p.
 func (p *Path) Collisions(q *Path) ([]PathIntersection, []PathIntersection) {
 	if !p.Flat() {
-		q = q.Flatten(Tolerance)
+		q = flatten(q, Tolerance)
 	}
 	return pathIntersections(p, q, true, false)
 }
@@ -776,46 +831,12 @@ func (p *Path) RayIntersections(x, y float64) []PathIntersection {
 	Zs := []PathIntersection{}
 	for i := 0; i < len(p.d); {
 		cmd := p.d[i]
-		zs = zs[:0]
-		switch cmd {
-		case MoveToCmd:
-			end = Point{p.d[i+1], p.d[i+2]}
+		end = Point{p.d[i+cmdLen(cmd)-3], p.d[i+cmdLen(cmd)-2]}
+		if cmd == MoveToCmd {
 			k0 = len(Zs)
-		case LineToCmd, CloseCmd:
-			end = Point{p.d[i+1], p.d[i+2]}
-			ymin := math.Min(start.Y, end.Y)
-			ymax := math.Max(start.Y, end.Y)
-			xmax := math.Max(start.X, end.X)
-			if Interval(y, ymin, ymax) && x <= xmax+Epsilon {
-				zs = intersectionLineLine(zs, Point{x, y}, Point{xmax + 1.0, y}, start, end)
-			}
-		case QuadToCmd:
-			cp := Point{p.d[i+1], p.d[i+2]}
-			end = Point{p.d[i+3], p.d[i+4]}
-			ymin := math.Min(math.Min(start.Y, end.Y), cp.Y)
-			ymax := math.Max(math.Max(start.Y, end.Y), cp.Y)
-			xmax := math.Max(math.Max(start.X, end.X), cp.X)
-			if Interval(y, ymin, ymax) && x <= xmax+Epsilon {
-				zs = intersectionLineQuad(zs, Point{x, y}, Point{xmax + 1.0, y}, start, cp, end)
-			}
-		case CubeToCmd:
-			cp1 := Point{p.d[i+1], p.d[i+2]}
-			cp2 := Point{p.d[i+3], p.d[i+4]}
-			end = Point{p.d[i+5], p.d[i+6]}
-			ymin := math.Min(math.Min(start.Y, end.Y), math.Min(cp1.Y, cp2.Y))
-			ymax := math.Max(math.Max(start.Y, end.Y), math.Max(cp1.Y, cp2.Y))
-			xmax := math.Max(math.Max(start.X, end.X), math.Max(cp1.X, cp2.X))
-			if Interval(y, ymin, ymax) && x <= xmax+Epsilon {
-				zs = intersectionLineCube(zs, Point{x, y}, Point{xmax + 1.0, y}, start, cp1, cp2, end)
-			}
-		case ArcToCmd:
-			rx, ry, phi := p.d[i+1], p.d[i+2], p.d[i+3]
-			large, sweep := toArcFlags(p.d[i+4])
-			end = Point{p.d[i+5], p.d[i+6]}
-			cx, cy, theta0, theta1 := ellipseToCenter(start.X, start.Y, rx, ry, phi, large, sweep, end.X, end.Y)
-			if Interval(y, cy-math.Max(rx, ry), cy+math.Max(rx, ry)) && x <= cx+math.Max(rx, ry)+Epsilon {
-				zs = intersectionLineEllipse(zs, Point{x, y}, Point{cx + rx + 1.0, y}, Point{cx, cy}, Point{rx, ry}, phi, theta0, theta1)
-			}
+			zs = zs[:0]
+		} else {
+			zs = rayIntersectionSegment(zs[:0], x, y, start, p.d[i:i+cmdLen(cmd)])
 		}
 		for _, z := range zs {
 			Z := PathIntersection{
@@ -844,3 +865,45 @@ func (p *Path) RayIntersections(x, y float64) []PathIntersection {
 	})
 	return Zs
 }
+
+// rayIntersectionSegment appends the intersections of a ray from (x,y) towards (∞,y) with the segment d starting at start.
+func rayIntersectionSegment(zs []Intersection, x, y float64, start Point, d []float64) []Intersection {
+	switch d[0] {
+	case LineToCmd, CloseCmd:
+		end := Point{d[1], d[2]}
+		ymin := math.Min(start.Y, end.Y)
+		ymax := math.Max(start.Y, end.Y)
+		xmax := math.Max(start.X, end.X)
+		if Interval(y, ymin, ymax) && x <= xmax+Epsilon {
+			zs = intersectionLineLine(zs, Point{x, y}, Point{xmax + 1.0, y}, start, end)
+		}
+	case QuadToCmd:
+		cp := Point{d[1], d[2]}
+		end := Point{d[3], d[4]}
+		ymin := math.Min(math.Min(start.Y, end.Y), cp.Y)
+		ymax := math.Max(math.Max(start.Y, end.Y), cp.Y)
+		xmax := math.Max(math.Max(start.X, end.X), cp.X)
+		if Interval(y, ymin, ymax) && x <= xmax+Epsilon {
+			zs = intersectionLineQuad(zs, Point{x, y}, Point{xmax + 1.0, y}, start, cp, end)
+		}
+	case CubeToCmd:
+		cp1 := Point{d[1], d[2]}
+		cp2 := Point{d[3], d[4]}
+		end := Point{d[5], d[6]}
+		ymin := math.Min(math.Min(start.Y, end.Y), math.Min(cp1.Y, cp2.Y))
+		ymax := math.Max(math.Max(start.Y, end.Y), math.Max(cp1.Y, cp2.Y))
+		xmax := math.Max(math.Max(start.X, end.X), math.Max(cp1.X, cp2.X))
+		if Interval(y, ymin, ymax) && x <= xmax+Epsilon {
+			zs = intersectionLineCube(zs, Point{x, y}, Point{xmax + 1.0, y}, start, cp1, cp2, end)
+		}
+	case ArcToCmd:
+		rx, ry, phi := d[1], d[2], d[3]
+		large, sweep := toArcFlags(d[4])
+		end := Point{d[5], d[6]}
+		cx, cy, theta0, theta1 := ellipseToCenter(start.X, start.Y, rx, ry, phi, large, sweep, end.X, end.Y)
+		if Interval(y, cy-math.Max(rx, ry), cy+math.Max(rx, ry)) && x <= cx+math.Max(rx, ry)+Epsilon {
+			zs = intersectionLineEllipse(zs, Point{x, y}, Point{cx + rx + 1.0, y}, Point{cx, cy}, Point{rx, ry}, phi, theta0, theta1)
+		}
+	}
+	return zs
+}
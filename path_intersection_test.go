@@ -3,6 +3,7 @@ package canvas
 import (
 	"fmt"
 	"math"
+	"runtime"
 	"testing"
 
 	"github.com/tdewolff/test"
@@ -361,6 +362,15 @@ func TestIntersectionEllipseEllipse(t *testing.T) {
 			{Point{0.0, 10.0}, [2]float64{1.0, 0.5}, [2]float64{math.Pi, math.Pi}, true},
 		}},
 
+		// ellipses
+		{"M10 0A10 5 0 0 1 -10 0", "M0 -8A8 8 0 0 1 0 8", Intersections{
+			{Point{7.211102550927978, 3.4641016151377544}, [2]float64{0.24363210340012256, 0.6425494792958626}, [2]float64{2.6617455681005637, 2.018628723723829}, false},
+		}},
+		{"M10 0A10 5 0 0 1 -10 0", "M10 0A10 5 0 0 1 -10 0", Intersections{
+			{Point{10.0, 0.0}, [2]float64{0.0, 0.0}, [2]float64{0.5 * math.Pi, 0.5 * math.Pi}, true},
+			{Point{-10.0, 0.0}, [2]float64{1.0, 1.0}, [2]float64{1.5 * math.Pi, 1.5 * math.Pi}, true},
+		}},
+
 		{"M30.170131507649785 37.66143576791836A0.8700000000002787 0.8700000000002787 0 0 1 28.82999999999447 36.92939999999941", "M30.242341004748596 37.609669236818846A0.8700000000000001 0.8700000000000001 0 0 1 28.82999999999447 36.9294", Intersections{
 			{Point{30.170131507649785, 37.66143576791836}, [2]float64{0.0, 0.0455326614}, [2]float64{2.5707027528269983, 2.5707027528269983}, true},
 			{Point{28.82999999999447, 36.92939999999941}, [2]float64{1.0, 1.0}, [2]float64{1.5 * math.Pi, 1.5 * math.Pi}, true},
@@ -391,6 +401,72 @@ func TestIntersectionEllipseEllipse(t *testing.T) {
 	Epsilon = origEpsilon
 }
 
+func TestIntersectionCurveCurve(t *testing.T) {
+	var tts = []struct {
+		a, b string
+		zs   Intersections
+	}{
+		// secant
+		{"Q5 10 10 0", "M0 5Q5 -5 10 5", Intersections{
+			{Point{1.4644660940672627, 2.5}, [2]float64{0.14644660940672627, 0.14644660940672627}, [2]float64{0.9553166181245093, 5.327868689055077}, false},
+			{Point{8.535533905932738, 2.5}, [2]float64{0.8535533905932737, 0.8535533905932737}, [2]float64{5.327868689055077, 0.9553166181245093}, false},
+		}},
+		{"Q5 10 10 0", "M5 10A5 5 0 0 1 10 5", Intersections{}},
+
+		// fully parallel
+		{"Q5 10 10 0", "Q5 10 10 0", Intersections{
+			{Point{0.0, 0.0}, [2]float64{0.0, 0.0}, [2]float64{1.1071487177940904, 1.1071487177940904}, true},
+			{Point{10.0, 0.0}, [2]float64{1.0, 1.0}, [2]float64{5.176036589385496, 5.176036589385496}, true},
+		}},
+
+		// none
+		{"Q5 10 10 0", "M0 10Q5 20 10 10", Intersections{}},
+	}
+	origEpsilon := Epsilon
+	for _, tt := range tts {
+		t.Run(fmt.Sprint(tt.a, "x", tt.b), func(t *testing.T) {
+			Epsilon = origEpsilon
+			a := MustParseSVGPath(tt.a)
+			b := MustParseSVGPath(tt.b)
+			zs := intersectionSegment(nil, Point{a.d[1], a.d[2]}, a.d[4:], Point{b.d[1], b.d[2]}, b.d[4:])
+			Epsilon = 3.0 * origEpsilon
+			test.T(t, zs, tt.zs)
+		})
+	}
+	Epsilon = origEpsilon
+}
+
+func TestIntersectionCurveCurveOverlap(t *testing.T) {
+	var tts = []struct {
+		a, b    string
+		ts      [][2]float64
+		tangent bool
+	}{
+		// partly parallel
+		{"Q5 10 10 0", "Q2.5 5 5 5", [][2]float64{{0.0, 0.0}, {0.5, 1.0}}, true},
+		{"Q5 10 10 0", "M5 5Q7.5 5 10 0", [][2]float64{{0.5, 0.0}, {1.0, 1.0}}, true},
+		{"Q5 10 10 0", "M5 5Q2.5 5 0 0", [][2]float64{{0.0, 1.0}, {0.5, 0.0}}, true},
+		{"C0 10 10 10 10 0", "C0 5 2.5 7.5 5 7.5", [][2]float64{{0.0, 0.0}, {0.5, 1.0}}, true},
+
+		// touching
+		{"Q5 10 10 0", "M0 10Q5 0 10 10", [][2]float64{{0.5, 0.5}}, false},
+		{"C0 10 10 10 10 0", "M0 15C0 5 10 5 10 15", [][2]float64{{0.5, 0.5}}, false},
+	}
+	for _, tt := range tts {
+		t.Run(fmt.Sprint(tt.a, "x", tt.b), func(t *testing.T) {
+			a := MustParseSVGPath(tt.a)
+			b := MustParseSVGPath(tt.b)
+			zs := intersectionSegment(nil, Point{a.d[1], a.d[2]}, a.d[4:], Point{b.d[1], b.d[2]}, b.d[4:])
+			test.T(t, len(zs), len(tt.ts))
+			for i, z := range zs {
+				test.FloatDiff(t, z.T[0], tt.ts[i][0], 1e-6)
+				test.FloatDiff(t, z.T[1], tt.ts[i][1], 1e-6)
+				test.That(t, z.Tangent || !tt.tangent, "must be tangent")
+			}
+		})
+	}
+}
+
 func TestIntersections(t *testing.T) {
 	var tts = []struct {
 		p, q   string
@@ -891,6 +967,9 @@ func TestPathCut(t *testing.T) {
 		{"L2 0M2 1L4 1L4 3L2 3zM0 4L2 4", "M1 -1L1 5",
 			[]string{"L1 0", "M1 0L2 0M2 1L4 1L4 3L2 3zM0 4L1 4", "M1 4L2 4"},
 		},
+		{"M0 5Q5 -5 10 5", "Q5 10 10 0",
+			[]string{"M0 5Q0.7322330470336311 3.5355339059327378 1.4644660940672622 2.5", "M1.4644660940672622 2.5Q5 -2.5 8.535533905932738 2.5", "M8.535533905932738 2.5Q9.267766952966369 3.5355339059327378 10 5"},
+		},
 	}
 	for _, tt := range tts {
 		ttrs := []*Path{}
@@ -1079,6 +1158,60 @@ func TestPathAnd(t *testing.T) {
 	}
 }
 
+func TestPathAndEllipse(t *testing.T) {
+	// arcs are kept instead of being flattened
+	r := Ellipse(10.0, 5.0).And(Rectangle(20.0, 20.0).Translate(0.0, -10.0))
+	arcs := 0
+	for scanner := r.Scanner(); scanner.Scan(); {
+		if scanner.Cmd() == ArcToCmd {
+			arcs++
+		}
+		test.That(t, scanner.Cmd() != CubeToCmd && scanner.Cmd() != QuadToCmd)
+	}
+	test.That(t, 0 < arcs)
+	test.T(t, r.Bounds(), Rect{0.0, -5.0, 10.0, 10.0})
+}
+
+func TestPathAndBezier(t *testing.T) {
+	// curves are kept instead of being flattened
+	p := MustParseSVGPath("Q5 10 10 0z")
+	q := MustParseSVGPath("M0 5Q5 -5 10 5z")
+	r := p.And(q)
+	curves := 0
+	for scanner := r.Scanner(); scanner.Scan(); {
+		if scanner.Cmd() == QuadToCmd || scanner.Cmd() == CubeToCmd {
+			curves++
+		}
+	}
+	test.That(t, 0 < curves)
+	test.T(t, r.Bounds(), Rect{1.4644660940672627, 0.0, 7.0710678118654755, 5.0})
+}
+
+func TestPathBooleanBezierOverlap(t *testing.T) {
+	var tts = []struct {
+		p, q         string
+		and, or, not string
+	}{
+		// shared part of a cubic edge
+		{"C0 10 10 10 10 0z", "C0 5 2.5 7.5 5 7.5L5 0z", "L5 0L5 7.5C2.5 7.5 0 5 0 0z", "L10 0C10 10 0 10 0 0z", ""},
+		{"C0 10 10 10 10 0z", "M5 0L5 7.5C7.5 7.5 10 5 10 0L12 0L12 -2L5 -2z", "M5 0L10 0C10 5 7.5 7.5 5 7.5z", "M5 0L5 -2L12 -2L12 0L10 0C10 5 7.5 7.5 5 7.5C2.5 7.5 0 5 0 0z", "M5 0L5 7.5C2.5 7.5 0 5 0 0z"},
+
+		// tangent contact
+		{"C0 10 10 10 10 0z", "M0 15C0 5 10 5 10 15z", "", "L10 0C10 10 0 10 0 0zM0 15C0 5 10 5 10 15z", "L10 0C10 10 0 10 0 0z"},
+	}
+	for _, tt := range tts {
+		t.Run(fmt.Sprint(tt.p, "x", tt.q), func(t *testing.T) {
+			p := MustParseSVGPath(tt.p)
+			q := MustParseSVGPath(tt.q)
+			test.T(t, p.And(q), MustParseSVGPath(tt.and))
+			test.T(t, p.Or(q), MustParseSVGPath(tt.or))
+			if tt.not != "" {
+				test.T(t, p.Not(q), MustParseSVGPath(tt.not))
+			}
+		})
+	}
+}
+
 func TestPathOr(t *testing.T) {
 	var tts = []struct {
 		p, q string
@@ -1308,6 +1441,39 @@ func TestPathNot(t *testing.T) {
 	}
 }
 
+func TestPathBooleans(t *testing.T) {
+	var tts = []struct {
+		p, q  string
+		qNotP string
+	}{
+		{"L10 0L5 10z", "M0 5L10 5L5 15z", "M7.5 5L10 5L5 15L0 5L2.5 5L5 10z"}, // overlap
+		{"L2 0L2 2L0 2z", "M2 1L4 1L4 3L2 3z", "M2 1L4 1L4 3L2 3z"},            // touching edges
+		{"L10 0L5 10z", "M0 10L10 10L5 20z", "M0 10L10 10L5 20z"},              // no overlap
+		{"L10 0L5 10z", "M2 2L8 2L5 8z", ""},                                   // containment
+		{"M2 2L8 2L5 8z", "L10 0L5 10z", "M2 2L5 8L8 2zM0 0L10 0L5 10z"},       // containment
+		{"L10 0L5 10z", "L10 0L5 10z", ""},                                     // equal
+		{"L3 0L3 1L0 1z", "M1 0L2 0L2 1L1 1z", ""},                             // partly parallel
+		{"M1 0L3 0L3 4L1 4z", "M0 1L4 1L4 3L0 3zM2 2L2 5L5 5L5 2z", "M3 1L4 1L4 2L3 2zM3 3L4 3L4 2L5 2L5 5L2 5L2 4L3 4zM1 3L0 3L0 1L1 1z"}, // subpaths
+		{"L2 0L2 1L0 1zM0 2L2 2L2 3L0 3z", "M1 0L3 0L3 1L1 1zM0 2L2 2L2 3L0 3z", "M2 0L3 0L3 1L2 1z"},                                      // one overlapping, one equal
+		{"M5 5L5 15", "L10 0L10 10L0 10z", "M0 0L10 0L10 10L0 10z"},                                                                        // open
+		{"L10 0L10 10L0 10zM15 1L15 9", "M5 5L15 5L15 15L5 15z", "M10 5L15 5L15 15L5 15L5 10L10 10z"},                                      // closed and open
+		{"", "L10 0L5 10z", "L10 0L5 10z"}, // empty
+		{"L10 0L5 10z", "", ""},            // empty
+	}
+	for _, tt := range tts {
+		t.Run(fmt.Sprint(tt.p, "x", tt.q), func(t *testing.T) {
+			p := MustParseSVGPath(tt.p)
+			q := MustParseSVGPath(tt.q)
+			r := p.Booleans(q)
+			test.T(t, r.And, p.And(q), "and")
+			test.T(t, r.Or, p.Or(q), "or")
+			test.T(t, r.Xor, p.Xor(q), "xor")
+			test.T(t, r.PNotQ, p.Not(q), "p not q")
+			test.T(t, r.QNotP, MustParseSVGPath(tt.qNotP), "q not p")
+		})
+	}
+}
+
 func TestPathDivideBy(t *testing.T) {
 	var tts = []struct {
 		p, q string
@@ -1325,3 +1491,87 @@ func TestPathDivideBy(t *testing.T) {
 		})
 	}
 }
+
+func TestIntersectionPathSlabs(t *testing.T) {
+	defer func(threshold, procs int) {
+		IntersectionSlabThreshold = threshold
+		runtime.GOMAXPROCS(procs)
+	}(IntersectionSlabThreshold, runtime.GOMAXPROCS(4))
+
+	p := RegularStarPolygon(201, 50, 10.0, true)
+	p = p.Append(Circle(8.0).Translate(1.0, 0.0))
+	ps := p.Split()
+
+	IntersectionSlabThreshold = math.MaxInt
+	zs, segsP, segsQ := intersectionPath(ps[0], nil)
+	zs2, segsP2, segsQ2 := intersectionPath(ps[0], ps[1])
+
+	IntersectionSlabThreshold = 0
+	zsSlabs, segsPSlabs, segsQSlabs := intersectionPath(ps[0], nil)
+	test.That(t, 0 < len(zs))
+	test.T(t, zsSlabs, zs)
+	test.T(t, segsPSlabs, segsP)
+	test.T(t, segsQSlabs, segsQ)
+
+	zsSlabs, segsPSlabs, segsQSlabs = intersectionPath(ps[0], ps[1])
+	test.That(t, 0 < len(zs2))
+	test.T(t, zsSlabs, zs2)
+	test.T(t, segsPSlabs, segsP2)
+	test.T(t, segsQSlabs, segsQ2)
+}
+
+func TestPreparedPath(t *testing.T) {
+	p := &Path{}
+	for i := 0; i < 40; i++ {
+		p = p.Append(Circle(1.0).Translate(float64(i%8)*3.0, float64(i/8)*3.0))
+	}
+	p = p.Append(MustParseSVGPath("M-2 -2L-1 20L22 21L23 -3z"))
+	pp := NewPreparedPath(p)
+	test.That(t, 1 < len(pp.nodes))
+	test.T(t, pp.Bounds(), Rect{-2.0, -3.0, 25.0, 24.0})
+
+	for _, pos := range []Point{{0.0, 0.0}, {1.5, 1.5}, {3.0, 0.5}, {20.5, 11.9}, {-1.5, 0.0}, {-5.0, 5.0}, {0.5, 0.0}} {
+		test.T(t, pp.Contains(pos.X, pos.Y), p.Contains(pos.X, pos.Y), pos)
+	}
+
+	tts := []string{
+		"M0 0L1 1L0 1z",
+		"M1.2 1.2L1.8 1.2L1.8 1.8z",
+		"M-5 10L30 10",
+		"M1 0L1 5",
+		"M40 40L50 50L40 50z",
+	}
+	for _, tt := range tts {
+		q := MustParseSVGPath(tt)
+		test.T(t, pp.Intersects(q), p.Intersects(q), tt)
+	}
+
+	test.FloatDiff(t, pp.Distance(0.0, 0.0), 1.0, Tolerance) // circles are flattened
+	test.FloatDiff(t, pp.Distance(1.5, 1.5), 3.0*math.Sqrt(2.0)/2.0-1.0, Tolerance)
+	test.Float(t, pp.Distance(30.0, 10.0), 181.0/math.Sqrt(577.0))
+}
+
+func TestIncrementalUnion(t *testing.T) {
+	u := NewIncrementalUnion()
+	a := u.Insert(Rectangle(2.0, 2.0))
+	b := u.Insert(Rectangle(2.0, 2.0).Translate(1.0, 1.0))
+	c := u.Insert(Rectangle(1.0, 1.0).Translate(5.0, 0.0))
+	test.T(t, u.Len(), 3)
+	test.T(t, u.Clusters(), 2)
+	test.Float(t, u.Result().Length(), 16.0)
+
+	// move the distant square onto the others
+	test.That(t, u.Update(c, Rectangle(1.0, 1.0).Translate(2.5, 2.5)))
+	test.T(t, u.Clusters(), 1)
+	test.Float(t, u.Result().Length(), 14.0)
+
+	// removing the middle square splits the cluster
+	test.That(t, u.Remove(b))
+	test.T(t, u.Clusters(), 2)
+	test.Float(t, u.Result().Length(), 12.0)
+	test.That(t, !u.Remove(b))
+
+	p, ok := u.Path(a)
+	test.That(t, ok)
+	test.T(t, p, Rectangle(2.0, 2.0))
+}
@@ -3,7 +3,9 @@ package canvas
 import (
 	"fmt"
 	"math"
+	"runtime"
 	"sort"
+	"sync"
 )
 
 // see https://github.com/signavio/svg-intersections
@@ -260,10 +262,10 @@ func cut(p *Path, zs []PathIntersection) ([]*Path, subpathIndexer) {
 			if first != nil {
 				// there were intersections in the last subpath
 				if closed {
-					ps = append(ps, &Path{append(cur, first[4:]...)})
+					ps = append(ps, &Path{d: append(cur, first[4:]...)})
 					cur = nil
 				} else {
-					ps = append(ps[:k], append([]*Path{{first}}, ps[k:]...)...)
+					ps = append(ps[:k], append([]*Path{{d: first}}, ps[k:]...)...)
 				}
 			} else if closed {
 				cur[len(cur)-1] = CloseCmd
@@ -285,7 +287,7 @@ func cut(p *Path, zs []PathIntersection) ([]*Path, subpathIndexer) {
 				if first == nil {
 					first = cur // take aside the path to the first intersection to later append it
 				} else {
-					ps = append(ps, &Path{cur})
+					ps = append(ps, &Path{d: cur})
 				}
 				j++
 				t := (zs[j].T - zs[j-1].T) / (1.0 - zs[j-1].T)
@@ -299,7 +301,7 @@ func cut(p *Path, zs []PathIntersection) ([]*Path, subpathIndexer) {
 			if first == nil {
 				first = cur // take aside the path to the first intersection to later append it
 			} else {
-				ps = append(ps, &Path{cur})
+				ps = append(ps, &Path{d: cur})
 			}
 			cur = p1.d
 			j++
@@ -322,13 +324,13 @@ func cut(p *Path, zs []PathIntersection) ([]*Path, subpathIndexer) {
 		if closed {
 			cur = append(cur, first[4:]...)
 		} else {
-			ps = append(ps[:k], append([]*Path{{first}}, ps[k:]...)...)
+			ps = append(ps[:k], append([]*Path{{d: first}}, ps[k:]...)...)
 		}
 	} else if closed {
 		cur[len(cur)-1] = CloseCmd
 		cur[len(cur)-4] = CloseCmd
 	}
-	ps = append(ps, &Path{cur})
+	ps = append(ps, &Path{d: cur})
 	segs = append(segs, seg)
 	return ps, segs
 }
@@ -479,6 +481,8 @@ func (a pathIntersectionsSort) Less(i, j int) bool {
 
 // pathIntersections converts segment intersections into path intersections, resolving tangency at segment endpoints, collapsing runs of parallel/overlapping segments
 func pathIntersections(p, q *Path, withTangents, withParallelTangents bool) ([]PathIntersection, []PathIntersection) {
+	defer traceRegion("intersections")()
+
 	self := q == nil
 
 	// TODO: pass []*Path?
@@ -801,6 +805,70 @@ func (a intersectionPathSort) Less(i, j int) bool {
 	return posPi < posPj
 }
 
+// intersectionSeg is a path segment for finding intersections, with its index into the path data, its segment number, its start point, and its horizontal extent.
+type intersectionSeg struct {
+	i, n, seg  int
+	start      Point
+	xmin, xmax float64
+}
+
+// intersectionSegs returns all segments of a path, excluding point-closed close commands.
+func intersectionSegs(p *Path) []intersectionSeg {
+	segs := []intersectionSeg{}
+	seg := 1
+	for i := 4; i < len(p.d); {
+		n := cmdLen(p.d[i])
+		start := Point{p.d[i-3], p.d[i-2]}
+		end := Point{p.d[i+n-3], p.d[i+n-2]}
+		if p.d[i] != CloseCmd || !start.Equals(end) {
+			xmin, xmax := math.Min(start.X, end.X), math.Max(start.X, end.X)
+			switch p.d[i] {
+			case QuadToCmd, CubeToCmd:
+				// control points form a convex hull
+				for k := i + 1; k < i+n-3; k += 2 {
+					xmin, xmax = math.Min(xmin, p.d[k]), math.Max(xmax, p.d[k])
+				}
+			case ArcToCmd:
+				// the arc is within its radius from the center, which is within its radius from the endpoints
+				r := 2.0 * math.Max(p.d[i+1], p.d[i+2])
+				xmin, xmax = xmin-r, xmax+r
+			}
+			segs = append(segs, intersectionSeg{i, n, seg, start, xmin - Epsilon, xmax + Epsilon})
+		}
+		i += n
+		seg++
+	}
+	return segs
+}
+
+// intersectionSegPair returns the intersections between two segments of P and Q. If self is set, P and Q are the same path and tangent intersections between adjacent segments are removed.
+func intersectionSegPair(zs Intersections, p, q *Path, a, b intersectionSeg, self bool) Intersections {
+	k := len(zs)
+	i, pn, j, qn := a.i, a.n, b.i, b.n
+	zs = intersectionSegment(zs, a.start, p.d[i:i+pn], b.start, q.d[j:j+qn])
+	if self && (i+pn == j || i == 4) {
+		// remove tangent intersections for adjacent segments on the same subpath
+		for k1 := len(zs) - 1; k <= k1; k1-- {
+			if !zs[k1].Tangent {
+				continue
+			}
+
+			// segments are joined if either j comes after i, or if i is first and j is last (or before last if last is point-closed)
+			joined := i+pn == j && Equal(zs[k1].T[0], 1.0) && Equal(zs[k1].T[1], 0.0) ||
+				i == 4 && Equal(zs[k1].T[0], 0.0) && Equal(zs[k1].T[1], 1.0) &&
+					(q.d[j] == CloseCmd || j+qn < len(q.d) && q.d[j+qn] == CloseCmd &&
+						Point{q.d[j+qn-3], q.d[j+qn-2]}.Equals(Point{q.d[j+qn+1], q.d[j+qn+2]}))
+			if joined {
+				zs = append(zs[:k1], zs[k1+1:]...)
+			}
+		}
+	}
+	return zs
+}
+
+// IntersectionSlabThreshold is the number of segment pairs above which intersections within a path are found in parallel, by partitioning the path into vertical slabs that are processed concurrently. This speeds up boolean operations on single paths with very many segments, such as detailed country outlines.
+var IntersectionSlabThreshold = 1 << 20
+
 // intersectionPath returns all intersections along a path including the path segments associated.
 // If q is nil, it returns all intersections (non-tangent) within the same path (faster).
 // All intersections are sorted by path P and then by path Q. P and Q must not have subpaths.
@@ -815,64 +883,114 @@ func intersectionPath(p, q *Path) (Intersections, []int, []int) {
 
 	// TODO: uses O(N^2), try sweep line or bently-ottman to reduce to O((N+K) log N) (or better yet https://dl.acm.org/doi/10.1145/147508.147511)
 	// see https://www.webcitation.org/6ahkPQIsN        Bentley-Ottmann
-	segP, segQ := 1, 1
-	for i := 4; i < len(p.d); {
-		pn := cmdLen(p.d[i])
-		p0 := Point{p.d[i-3], p.d[i-2]}
-		if p.d[i] == CloseCmd && p0.Equals(Point{p.d[i+1], p.d[i+2]}) {
-			// point-closed
-			i += pn
-			segP++
-			continue
-		} else if self && p.d[i] == CubeToCmd {
-			// TODO: find intersections in Cube after we support non-flat paths
-		}
+	ap := intersectionSegs(p)
+	bq := ap
+	if !self {
+		bq = intersectionSegs(q)
+	}
+	if IntersectionSlabThreshold < len(ap)*len(bq) && 1 < runtime.GOMAXPROCS(0) {
+		return intersectionPathSlabs(p, q, ap, bq, self)
+	}
 
-		j := 4
-		segQ = 1
+	for ia, a := range ap {
+		// TODO: find self-intersections in Cube after we support non-flat paths
+		ib := 0
 		if self {
-			segQ = segP + 1
-			j = i + pn
-		}
-		for j < len(q.d) {
-			qn := cmdLen(q.d[j])
-			q0 := Point{q.d[j-3], q.d[j-2]}
-			if q.d[j] == CloseCmd && q0.Equals(Point{q.d[j+1], q.d[j+2]}) {
-				// point-closed
-				j += qn
-				segQ++
+			ib = ia + 1
+		}
+		for _, b := range bq[ib:] {
+			if b.xmax < a.xmin || a.xmax < b.xmin {
 				continue
 			}
 
 			k := len(zs)
-			zs = intersectionSegment(zs, p0, p.d[i:i+pn], q0, q.d[j:j+qn])
-			if self && (i+pn == j || i == 4) {
-				// remove tangent intersections for adjacent segments on the same subpath
-				for k1 := len(zs) - 1; k <= k1; k1-- {
-					if !zs[k1].Tangent {
+			zs = intersectionSegPair(zs, p, q, a, b, self)
+			for ; k < len(zs); k++ {
+				segsP = append(segsP, a.seg)
+				segsQ = append(segsQ, b.seg)
+			}
+		}
+	}
+	return zs, segsP, segsQ
+}
+
+// intersectionPathSlabs is like intersectionPath, but partitions the horizontal extent of the segments into vertical slabs that are processed in parallel. Each pair of segments is tested only in the slab that contains the left edge of their overlapping extent, and the results are stitched together in the same order as intersectionPath.
+func intersectionPathSlabs(p, q *Path, ap, bq []intersectionSeg, self bool) (Intersections, []int, []int) {
+	xmin, xmax := math.Inf(1), math.Inf(-1)
+	for _, segs := range [][]intersectionSeg{ap, bq} {
+		for _, seg := range segs {
+			xmin, xmax = math.Min(xmin, seg.xmin), math.Max(xmax, seg.xmax)
+		}
+	}
+
+	n := 4 * runtime.GOMAXPROCS(0)
+	dx := (xmax - xmin) / float64(n)
+	slab := func(x float64) int {
+		if dx <= 0.0 {
+			return 0
+		}
+		return max(0, min(n-1, int((x-xmin)/dx)))
+	}
+
+	// assign segment indices to all slabs that they overlap
+	slabsP, slabsQ := make([][]int, n), make([][]int, n)
+	for ia, a := range ap {
+		for k := slab(a.xmin); k <= slab(a.xmax); k++ {
+			slabsP[k] = append(slabsP[k], ia)
+		}
+	}
+	for ib, b := range bq {
+		for k := slab(b.xmin); k <= slab(b.xmax); k++ {
+			slabsQ[k] = append(slabsQ[k], ib)
+		}
+	}
+
+	type pairIntersections struct {
+		ia, ib int
+		zs     Intersections
+	}
+	results := make([][]pairIntersections, n)
+	wg := sync.WaitGroup{}
+	for k := 0; k < n; k++ {
+		wg.Add(1)
+		go func(k int) {
+			defer wg.Done()
+			for _, ia := range slabsP[k] {
+				a := ap[ia]
+				for _, ib := range slabsQ[k] {
+					b := bq[ib]
+					if self && ib <= ia || b.xmax < a.xmin || a.xmax < b.xmin || slab(math.Max(a.xmin, b.xmin)) != k {
 						continue
 					}
-
-					// segments are joined if either j comes after i, or if i is first and j is last (or before last if last is point-closed)
-					joined := i+pn == j && Equal(zs[k1].T[0], 1.0) && Equal(zs[k1].T[1], 0.0) ||
-						i == 4 && Equal(zs[k1].T[0], 0.0) && Equal(zs[k1].T[1], 1.0) &&
-							(q.d[j] == CloseCmd || j+qn < len(q.d) && q.d[j+qn] == CloseCmd &&
-								Point{q.d[j+qn-3], q.d[j+qn-2]}.Equals(Point{q.d[j+qn+1], q.d[j+qn+2]}))
-					if joined {
-						zs = append(zs[:k1], zs[k1+1:]...)
+					if zs := intersectionSegPair(nil, p, q, a, b, self); 0 < len(zs) {
+						results[k] = append(results[k], pairIntersections{ia, ib, zs})
 					}
 				}
 			}
-			for ; k < len(zs); k++ {
-				segsP = append(segsP, segP)
-				segsQ = append(segsQ, segQ)
-			}
+		}(k)
+	}
+	wg.Wait()
 
-			j += qn
-			segQ++
+	// stitch results of all slabs in order of the segments of P and then Q
+	pairs := []pairIntersections{}
+	for k := range results {
+		pairs = append(pairs, results[k]...)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].ia == pairs[j].ia {
+			return pairs[i].ib < pairs[j].ib
+		}
+		return pairs[i].ia < pairs[j].ia
+	})
+
+	var zs Intersections
+	var segsP, segsQ []int
+	for _, pair := range pairs {
+		zs = append(zs, pair.zs...)
+		for range pair.zs {
+			segsP = append(segsP, ap[pair.ia].seg)
+			segsQ = append(segsQ, bq[pair.ib].seg)
 		}
-		i += pn
-		segP++
 	}
 	return zs, segsP, segsQ
 }
@@ -891,7 +1009,7 @@ func intersectionSegment(zs Intersections, a0 Point, a []float64, b0 Point, b []
 		} else if b[0] == ArcToCmd {
 			rx := b[1]
 			ry := b[2]
-			phi := b[3] * math.Pi / 180.0
+			phi := b[3]
 			large, sweep := toArcFlags(b[4])
 			cx, cy, theta0, theta1 := ellipseToCenter(b0.X, b0.Y, rx, ry, phi, large, sweep, b[5], b[6])
 			zs = intersectionLineEllipse(zs, a0, Point{a[1], a[2]}, Point{cx, cy}, Point{rx, ry}, phi, theta0, theta1)
@@ -901,40 +1019,40 @@ func intersectionSegment(zs Intersections, a0 Point, a []float64, b0 Point, b []
 			zs = intersectionLineQuad(zs, b0, Point{b[1], b[2]}, a0, Point{a[1], a[2]}, Point{a[3], a[4]})
 			swapCurves = true
 		} else if b[0] == QuadToCmd {
-			panic("unsupported intersection for quad-quad")
+			zs = intersectionCurveCurve(zs, newIntersectionCurve(a0, a), newIntersectionCurve(b0, b))
 		} else if b[0] == CubeToCmd {
-			panic("unsupported intersection for quad-cube")
+			zs = intersectionCurveCurve(zs, newIntersectionCurve(a0, a), newIntersectionCurve(b0, b))
 		} else if b[0] == ArcToCmd {
-			panic("unsupported intersection for quad-arc")
+			zs = intersectionCurveCurve(zs, newIntersectionCurve(a0, a), newIntersectionCurve(b0, b))
 		}
 	} else if a[0] == CubeToCmd {
 		if b[0] == LineToCmd || b[0] == CloseCmd {
 			zs = intersectionLineCube(zs, b0, Point{b[1], b[2]}, a0, Point{a[1], a[2]}, Point{a[3], a[4]}, Point{a[5], a[6]})
 			swapCurves = true
 		} else if b[0] == QuadToCmd {
-			panic("unsupported intersection for cube-quad")
+			zs = intersectionCurveCurve(zs, newIntersectionCurve(a0, a), newIntersectionCurve(b0, b))
 		} else if b[0] == CubeToCmd {
-			panic("unsupported intersection for cube-cube")
+			zs = intersectionCurveCurve(zs, newIntersectionCurve(a0, a), newIntersectionCurve(b0, b))
 		} else if b[0] == ArcToCmd {
-			panic("unsupported intersection for cube-arc")
+			zs = intersectionCurveCurve(zs, newIntersectionCurve(a0, a), newIntersectionCurve(b0, b))
 		}
 	} else if a[0] == ArcToCmd {
 		rx := a[1]
 		ry := a[2]
-		phi := a[3] * math.Pi / 180.0
+		phi := a[3]
 		large, sweep := toArcFlags(a[4])
 		cx, cy, theta0, theta1 := ellipseToCenter(a0.X, a0.Y, rx, ry, phi, large, sweep, a[5], a[6])
 		if b[0] == LineToCmd || b[0] == CloseCmd {
 			zs = intersectionLineEllipse(zs, b0, Point{b[1], b[2]}, Point{cx, cy}, Point{rx, ry}, phi, theta0, theta1)
 			swapCurves = true
 		} else if b[0] == QuadToCmd {
-			panic("unsupported intersection for arc-quad")
+			zs = intersectionCurveCurve(zs, newIntersectionCurve(a0, a), newIntersectionCurve(b0, b))
 		} else if b[0] == CubeToCmd {
-			panic("unsupported intersection for arc-cube")
+			zs = intersectionCurveCurve(zs, newIntersectionCurve(a0, a), newIntersectionCurve(b0, b))
 		} else if b[0] == ArcToCmd {
 			rx2 := b[1]
 			ry2 := b[2]
-			phi2 := b[3] * math.Pi / 180.0
+			phi2 := b[3]
 			large2, sweep2 := toArcFlags(b[4])
 			cx2, cy2, theta20, theta21 := ellipseToCenter(b0.X, b0.Y, rx2, ry2, phi2, large2, sweep2, b[5], b[6])
 			zs = intersectionEllipseEllipse(zs, Point{cx, cy}, Point{rx, ry}, phi, theta0, theta1, Point{cx2, cy2}, Point{rx2, ry2}, phi2, theta20, theta21)
@@ -1399,8 +1517,10 @@ func intersectionLineEllipse(zs Intersections, l0, l1, center, radius Point, phi
 
 func intersectionEllipseEllipse(zs Intersections, c0, r0 Point, phi0, thetaStart0, thetaEnd0 float64, c1, r1 Point, phi1, thetaStart1, thetaEnd1 float64) Intersections {
 	// TODO: needs more testing
-	if !Equal(r0.X, r0.Y) || !Equal(r1.X, r1.Y) {
-		panic("not handled") // ellipses
+	circles := Equal(r0.X, r0.Y) && Equal(r1.X, r1.Y)
+	parallel := c0.Equals(c1) && r0.Equals(r1) && (circles || angleEqual(phi0, phi1))
+	if !circles && !parallel {
+		return intersectionEllipseEllipseNumerical(zs, c0, r0, phi0, thetaStart0, thetaEnd0, c1, r1, phi1, thetaStart1, thetaEnd1)
 	}
 
 	arcAngle := func(theta float64, sweep bool) float64 {
@@ -1419,8 +1539,14 @@ func intersectionEllipseEllipse(zs Intersections, c0, r0 Point, phi0, thetaStart
 	thetaStart1 = angleNorm(thetaStart1 + phi1)
 	thetaEnd1 = thetaStart1 + dtheta1
 
-	if c0.Equals(c1) && r0.Equals(r1) {
-		// parallel
+	if parallel {
+		posAt := func(theta float64) Point {
+			return EllipsePos(r0.X, r0.Y, phi0, c0.X, c0.Y, theta-phi0)
+		}
+		dirAt := func(theta float64, sweep bool) float64 {
+			return ellipseDeriv(r0.X, r0.Y, phi0, sweep, theta-phi0).Angle()
+		}
+
 		tOffset1 := 0.0
 		dirOffset1 := 0.0
 		if (0.0 <= dtheta0) != (0.0 <= dtheta1) {
@@ -1432,26 +1558,26 @@ func intersectionEllipseEllipse(zs Intersections, c0, r0 Point, phi0, thetaStart
 		// will add either 1 (when touching) or 2 (when overlapping) intersections
 		if t := angleTime(thetaStart0, thetaStart1, thetaEnd1); Interval(t, 0.0, 1.0) {
 			// ellipse0 starts within/on border of ellipse1
-			dir := arcAngle(thetaStart0, 0.0 <= dtheta0)
-			pos := EllipsePos(r0.X, r0.Y, 0.0, c0.X, c0.Y, thetaStart0)
+			dir := dirAt(thetaStart0, 0.0 <= dtheta0)
+			pos := posAt(thetaStart0)
 			zs = zs.add(pos, 0.0, math.Abs(t-tOffset1), dir, angleNorm(dir+dirOffset1), true)
 		}
 		if t := angleTime(thetaStart1, thetaStart0, thetaEnd0); IntervalExclusive(t, 0.0, 1.0) {
 			// ellipse1 starts within ellipse0
-			dir := arcAngle(thetaStart1, 0.0 <= dtheta0)
-			pos := EllipsePos(r0.X, r0.Y, 0.0, c0.X, c0.Y, thetaStart1)
+			dir := dirAt(thetaStart1, 0.0 <= dtheta0)
+			pos := posAt(thetaStart1)
 			zs = zs.add(pos, t, tOffset1, dir, angleNorm(dir+dirOffset1), true)
 		}
 		if t := angleTime(thetaEnd1, thetaStart0, thetaEnd0); IntervalExclusive(t, 0.0, 1.0) {
 			// ellipse1 ends within ellipse0
-			dir := arcAngle(thetaEnd1, 0.0 <= dtheta0)
-			pos := EllipsePos(r0.X, r0.Y, 0.0, c0.X, c0.Y, thetaEnd1)
+			dir := dirAt(thetaEnd1, 0.0 <= dtheta0)
+			pos := posAt(thetaEnd1)
 			zs = zs.add(pos, t, 1.0-tOffset1, dir, angleNorm(dir+dirOffset1), true)
 		}
 		if t := angleTime(thetaEnd0, thetaStart1, thetaEnd1); Interval(t, 0.0, 1.0) {
 			// ellipse0 ends within/on border of ellipse1
-			dir := arcAngle(thetaEnd0, 0.0 <= dtheta0)
-			pos := EllipsePos(r0.X, r0.Y, 0.0, c0.X, c0.Y, thetaEnd0)
+			dir := dirAt(thetaEnd0, 0.0 <= dtheta0)
+			pos := posAt(thetaEnd0)
 			zs = zs.add(pos, 1.0, math.Abs(t-tOffset1), dir, angleNorm(dir+dirOffset1), true)
 		}
 		return zs
@@ -1500,8 +1626,360 @@ func intersectionEllipseEllipse(zs Intersections, c0, r0 Point, phi0, thetaStart
 	return zs
 }
 
-// TODO: bezier-bezier intersection
-// TODO: bezier-ellipse intersection
+// intersectionEllipseEllipseNumerical returns the intersections between two elliptic arcs that are not both circular. Intersections are the roots of the implicit equation of the second ellipse along the first arc, which are bracketed by sampling the first arc and refined by bisection. Touching intersections are found at the local minima of the implicit equation.
+func intersectionEllipseEllipseNumerical(zs Intersections, c0, r0 Point, phi0, thetaStart0, thetaEnd0 float64, c1, r1 Point, phi1, thetaStart1, thetaEnd1 float64) Intersections {
+	// implicit equation of the second ellipse, which is negative inside
+	f := func(theta float64) float64 {
+		q := EllipsePos(r0.X, r0.Y, phi0, c0.X, c0.Y, theta).Sub(c1).Rot(-phi1, Origin)
+		return q.X*q.X/(r1.X*r1.X) + q.Y*q.Y/(r1.Y*r1.Y) - 1.0
+	}
+
+	const n = 64
+	thetas := make([]float64, n+1)
+	fs := make([]float64, n+1)
+	for i := range thetas {
+		thetas[i] = thetaStart0 + (thetaEnd0-thetaStart0)*float64(i)/n
+		fs[i] = f(thetas[i])
+	}
+
+	roots := []float64{}
+	addRoot := func(theta float64) {
+		if len(roots) == 0 || !Equal(roots[len(roots)-1], theta) {
+			roots = append(roots, theta)
+		}
+	}
+	for i := range thetas {
+		if math.Abs(fs[i]) < Epsilon {
+			addRoot(thetas[i])
+		} else if 0 < i && Epsilon <= math.Abs(fs[i-1]) && (fs[i-1] < 0.0) != (fs[i] < 0.0) {
+			// crossing
+			a, b, fa := thetas[i-1], thetas[i], fs[i-1]
+			for k := 0; k < 64; k++ {
+				m := (a + b) / 2.0
+				if fm := f(m); (fa < 0.0) == (fm < 0.0) {
+					a, fa = m, fm
+				} else {
+					b = m
+				}
+			}
+			addRoot((a + b) / 2.0)
+		} else if 0 < i && i < n && math.Abs(fs[i]) < math.Abs(fs[i-1]) && math.Abs(fs[i]) < math.Abs(fs[i+1]) && (fs[i-1] < 0.0) == (fs[i] < 0.0) && (fs[i] < 0.0) == (fs[i+1] < 0.0) {
+			// possibly touching, find the minimum by golden section search
+			a, b := thetas[i-1], thetas[i+1]
+			for k := 0; k < 64; k++ {
+				m0, m1 := b-(b-a)/math.Phi, a+(b-a)/math.Phi
+				if math.Abs(f(m0)) < math.Abs(f(m1)) {
+					b = m1
+				} else {
+					a = m0
+				}
+			}
+			if theta := (a + b) / 2.0; math.Abs(f(theta)) < Epsilon {
+				addRoot(theta)
+			}
+		}
+	}
+
+	for _, theta := range roots {
+		pos := EllipsePos(r0.X, r0.Y, phi0, c0.X, c0.Y, theta)
+		q := pos.Sub(c1).Rot(-phi1, Origin)
+		angle := math.Atan2(q.Y/r1.Y, q.X/r1.X)
+		t1 := angleTime(angle, thetaStart1, thetaEnd1)
+		if !Interval(t1, 0.0, 1.0) {
+			continue
+		}
+
+		t0 := (theta - thetaStart0) / (thetaEnd0 - thetaStart0)
+		if Equal(t0, 0.0) {
+			t0 = 0.0
+		} else if Equal(t0, 1.0) {
+			t0 = 1.0
+		}
+		dir0 := ellipseDeriv(r0.X, r0.Y, phi0, thetaStart0 <= thetaEnd0, theta).Angle()
+		dir1 := ellipseDeriv(r1.X, r1.Y, phi1, thetaStart1 <= thetaEnd1, angle).Angle()
+		endpoint := Equal(t0, 0.0) || Equal(t0, 1.0) || Equal(t1, 0.0) || Equal(t1, 1.0)
+		tangent := angleEqual(dir0, dir1) || angleEqual(dir0, dir1+math.Pi)
+		zs = zs.add(pos, t0, t1, dir0, dir1, tangent || endpoint)
+	}
+	return zs
+}
+
+// intersectionCurve is a quadratic or cubic Bézier, or an elliptic arc, that is used to find intersections between curves. Quadratic Béziers are elevated to cubic Béziers.
+type intersectionCurve struct {
+	arc bool
+
+	// cubic Bézier
+	p0, p1, p2, p3 Point
+
+	// elliptic arc
+	c, r           Point
+	phi            float64
+	theta0, theta1 float64
+}
+
+func newIntersectionCurve(start Point, d []float64) intersectionCurve {
+	end := Point{d[len(d)-3], d[len(d)-2]}
+	switch d[0] {
+	case QuadToCmd:
+		cp := Point{d[1], d[2]}
+		return intersectionCurve{p0: start, p1: start.Interpolate(cp, 2.0/3.0), p2: end.Interpolate(cp, 2.0/3.0), p3: end}
+	case CubeToCmd:
+		return intersectionCurve{p0: start, p1: Point{d[1], d[2]}, p2: Point{d[3], d[4]}, p3: end}
+	}
+	large, sweep := toArcFlags(d[4])
+	cx, cy, theta0, theta1 := ellipseToCenter(start.X, start.Y, d[1], d[2], d[3], large, sweep, end.X, end.Y)
+	return intersectionCurve{arc: true, p0: start, p3: end, c: Point{cx, cy}, r: Point{d[1], d[2]}, phi: d[3], theta0: theta0, theta1: theta1}
+}
+
+func (c intersectionCurve) pos(t float64) Point {
+	if c.arc {
+		return EllipsePos(c.r.X, c.r.Y, c.phi, c.c.X, c.c.Y, c.theta0+t*(c.theta1-c.theta0))
+	}
+	return cubicBezierPos(c.p0, c.p1, c.p2, c.p3, t)
+}
+
+func (c intersectionCurve) deriv(t float64) Point {
+	if c.arc {
+		return ellipseDeriv(c.r.X, c.r.Y, c.phi, true, c.theta0+t*(c.theta1-c.theta0)).Mul(c.theta1 - c.theta0)
+	}
+	return cubicBezierDeriv(c.p0, c.p1, c.p2, c.p3, t)
+}
+
+// ccw returns true if the curve turns counter clockwise at t.
+func (c intersectionCurve) ccw(t float64) bool {
+	if c.arc {
+		return c.theta0 <= c.theta1
+	}
+	return 0.0 <= c.deriv(t).PerpDot(cubicBezierDeriv2(c.p0, c.p1, c.p2, c.p3, t))
+}
+
+// bounds returns a rectangle that contains the curve between t0 and t1.
+func (c intersectionCurve) bounds(t0, t1 float64) Rect {
+	if c.arc {
+		// the arc deviates at most its sagitta from its chord
+		a, b := c.pos(t0), c.pos(t1)
+		dtheta := math.Min(math.Abs(c.theta1-c.theta0)*(t1-t0), math.Pi)
+		d := math.Max(c.r.X, c.r.Y) * (1.0 - math.Cos(dtheta/2.0))
+		return Rect{math.Min(a.X, b.X) - d, math.Min(a.Y, b.Y) - d, math.Abs(b.X-a.X) + 2.0*d, math.Abs(b.Y-a.Y) + 2.0*d}
+	}
+
+	// control points of the curve between t0 and t1 form a convex hull
+	p0, p1, p2, p3, _, _, _, _ := cubicBezierSplit(c.p0, c.p1, c.p2, c.p3, t1)
+	if 0.0 < t1 {
+		_, _, _, _, p0, p1, p2, p3 = cubicBezierSplit(p0, p1, p2, p3, t0/t1)
+	}
+	r := Rect{p0.X, p0.Y, 0.0, 0.0}
+	return r.AddPoint(p1).AddPoint(p2).AddPoint(p3)
+}
+
+func (c intersectionCurve) equals(o intersectionCurve) bool {
+	if c.arc != o.arc {
+		return false
+	} else if c.arc {
+		return c.c.Equals(o.c) && c.r.Equals(o.r) && angleEqual(c.phi, o.phi) && Equal(c.theta0, o.theta0) && Equal(c.theta1, o.theta1)
+	}
+	return c.p0.Equals(o.p0) && c.p1.Equals(o.p1) && c.p2.Equals(o.p2) && c.p3.Equals(o.p3)
+}
+
+func (c intersectionCurve) reverse() intersectionCurve {
+	c.p0, c.p1, c.p2, c.p3 = c.p3, c.p2, c.p1, c.p0
+	c.theta0, c.theta1 = c.theta1, c.theta0
+	return c
+}
+
+// param returns the parameter of the point on the curve closest to p, and whether p lies on the curve within the tolerance.
+func (c intersectionCurve) param(p Point, tolerance float64) (float64, bool) {
+	const n = 32
+	t, dist := 0.0, math.Inf(1)
+	for i := 0; i <= n; i++ {
+		if d := c.pos(float64(i) / n).Sub(p).Length(); d < dist {
+			t, dist = float64(i)/n, d
+		}
+	}
+
+	// find the minimum around the closest sample by golden section search
+	t0, t1 := math.Max(0.0, t-1.0/n), math.Min(t+1.0/n, 1.0)
+	for k := 0; k < 64; k++ {
+		m0, m1 := t1-(t1-t0)/math.Phi, t0+(t1-t0)/math.Phi
+		if c.pos(m0).Sub(p).Length() < c.pos(m1).Sub(p).Length() {
+			t1 = m1
+		} else {
+			t0 = m0
+		}
+	}
+	t = (t0 + t1) / 2.0
+	if c.p0.Sub(p).Length() < tolerance {
+		t = 0.0
+	} else if c.p3.Sub(p).Length() < tolerance {
+		t = 1.0
+	}
+	return t, c.pos(t).Sub(p).Length() < tolerance
+}
+
+// intersectionCurveOverlap returns the parameters on both curves of the start and end of the part where they overlap, which starts and ends at an endpoint of either curve.
+func intersectionCurveOverlap(a, b intersectionCurve, tolerance float64) ([2][2]float64, bool) {
+	ends := [][2]float64{}
+	addEnd := func(s, t float64) {
+		for _, end := range ends {
+			if Equal(end[0], s) {
+				return
+			}
+		}
+		ends = append(ends, [2]float64{s, t})
+	}
+	if t, ok := b.param(a.p0, tolerance); ok {
+		addEnd(0.0, t)
+	}
+	if t, ok := b.param(a.p3, tolerance); ok {
+		addEnd(1.0, t)
+	}
+	if s, ok := a.param(b.p0, tolerance); ok {
+		addEnd(s, 0.0)
+	}
+	if s, ok := a.param(b.p3, tolerance); ok {
+		addEnd(s, 1.0)
+	}
+	if len(ends) != 2 {
+		return [2][2]float64{}, false
+	} else if ends[1][0] < ends[0][0] {
+		ends[0], ends[1] = ends[1], ends[0]
+	}
+
+	// the curves overlap when they coincide in between
+	for _, f := range []float64{0.25, 0.5, 0.75} {
+		s := ends[0][0] + f*(ends[1][0]-ends[0][0])
+		if _, ok := b.param(a.pos(s), tolerance); !ok {
+			return [2][2]float64{}, false
+		}
+	}
+	return [2][2]float64{ends[0], ends[1]}, true
+}
+
+// intersectionCurveCurve returns the intersections between two curves that are Béziers or elliptic arcs. Candidate intersections are found by recursively subdividing the curves where their bounds overlap, after which they are refined with Newton's method. Curves that overlap return tangent intersections at the start and end of the overlapping part, like parallel lines.
+func intersectionCurveCurve(zs Intersections, a, b intersectionCurve) Intersections {
+	const tolerance = 1e-6
+	add := func(s, t float64) {
+		pos := a.pos(s)
+		derivA, derivB := a.deriv(s), b.deriv(t)
+		dira, dirb := derivA.Angle(), derivB.Angle()
+		endpoint := Equal(s, 0.0) || Equal(s, 1.0) || Equal(t, 0.0) || Equal(t, 1.0)
+		tangent := angleEqual(dira, dirb) || angleEqual(dira, dirb+math.Pi)
+		if endpoint {
+			// deviate angles slightly at endpoints when aligned to properly set Into
+			deviate := func(dir float64, ccw, start bool) float64 {
+				if ccw == start {
+					return angleNorm(dir + Epsilon*2.0) // t=0 and CCW, or t=1 and CW
+				}
+				return angleNorm(dir - Epsilon*2.0) // t=0 and CW, or t=1 and CCW
+			}
+			if Equal(s, 0.0) || Equal(s, 1.0) {
+				dira = deviate(dira, a.ccw(s), Equal(s, 0.0) || !Equal(s, 1.0) && Equal(t, 0.0))
+			}
+			if Equal(t, 0.0) || Equal(t, 1.0) {
+				dirb = deviate(dirb, b.ccw(t), Equal(t, 0.0) || !Equal(t, 1.0) && Equal(s, 0.0))
+			}
+		}
+		zs = zs.add(pos, s, t, dira, dirb, endpoint || tangent)
+	}
+
+	if a.equals(b) {
+		add(0.0, 0.0)
+		add(1.0, 1.0)
+		return zs
+	} else if a.equals(b.reverse()) {
+		add(0.0, 1.0)
+		add(1.0, 0.0)
+		return zs
+	} else if ends, ok := intersectionCurveOverlap(a, b, tolerance); ok {
+		add(ends[0][0], ends[0][1])
+		add(ends[1][0], ends[1][1])
+		return zs
+	}
+
+	// subdivide the curves until the overlapping parts are small enough
+	const maxPairs = 1 << 12
+	type pair struct{ s0, s1, t0, t1 float64 }
+	candidates := []pair{}
+	stack := []pair{{0.0, 1.0, 0.0, 1.0}}
+	for n := 0; 0 < len(stack); n++ {
+		if maxPairs < n {
+			// stop subdividing, which happens around tangent intersections, and refine the remaining pairs
+			candidates = append(candidates, stack...)
+			break
+		}
+		q := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		ra, rb := a.bounds(q.s0, q.s1), b.bounds(q.t0, q.t1)
+		if rb.X+rb.W < ra.X-Epsilon || ra.X+ra.W < rb.X-Epsilon || rb.Y+rb.H < ra.Y-Epsilon || ra.Y+ra.H < rb.Y-Epsilon {
+			continue
+		}
+		sizeA, sizeB := math.Max(ra.W, ra.H), math.Max(rb.W, rb.H)
+		if sizeA < tolerance && sizeB < tolerance {
+			candidates = append(candidates, q)
+		} else if sizeB <= sizeA {
+			s := (q.s0 + q.s1) / 2.0
+			stack = append(stack, pair{q.s0, s, q.t0, q.t1}, pair{s, q.s1, q.t0, q.t1})
+		} else {
+			t := (q.t0 + q.t1) / 2.0
+			stack = append(stack, pair{q.s0, q.s1, q.t0, t}, pair{q.s0, q.s1, t, q.t1})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].s0 < candidates[j].s0
+	})
+
+	// merge adjacent candidates, which happens for tangent intersections, and refine
+	solutions := [][2]float64{}
+	for i := 0; i < len(candidates); {
+		j := i + 1
+		tmin, tmax := candidates[i].t0, candidates[i].t1
+		for j < len(candidates) && candidates[j].s0 <= candidates[j-1].s1 && candidates[j].t0 <= tmax && tmin <= candidates[j].t1 {
+			tmin, tmax = math.Min(tmin, candidates[j].t0), math.Max(tmax, candidates[j].t1)
+			j++
+		}
+		q := candidates[(i+j)/2]
+		s, t := (q.s0+q.s1)/2.0, (q.t0+q.t1)/2.0
+		for k := 0; k < 16; k++ {
+			d := a.pos(s).Sub(b.pos(t))
+			derivA, derivB := a.deriv(s), b.deriv(t)
+			det := -derivA.PerpDot(derivB)
+			if d.Length() < Epsilon*Epsilon || Equal(det, 0.0) {
+				break
+			}
+			s = math.Min(math.Max(s+d.PerpDot(derivB)/det, 0.0), 1.0)
+			t = math.Min(math.Max(t+d.PerpDot(derivA)/det, 0.0), 1.0)
+		}
+		// snap to the endpoints, which converge slowly for tangent intersections such as at cusps
+		if Equal(s, 0.0) || a.pos(s).Sub(a.p0).Length() < tolerance {
+			s = 0.0
+		} else if Equal(s, 1.0) || a.pos(s).Sub(a.p3).Length() < tolerance {
+			s = 1.0
+		}
+		if Equal(t, 0.0) || b.pos(t).Sub(b.p0).Length() < tolerance {
+			t = 0.0
+		} else if Equal(t, 1.0) || b.pos(t).Sub(b.p3).Length() < tolerance {
+			t = 1.0
+		}
+		if a.pos(s).Sub(b.pos(t)).Length() < tolerance {
+			duplicate := false
+			for _, sol := range solutions {
+				if Equal(sol[0], s) && Equal(sol[1], t) {
+					duplicate = true
+					break
+				}
+			}
+			if !duplicate {
+				solutions = append(solutions, [2]float64{s, t})
+			}
+		}
+		i = j
+	}
+	for _, sol := range solutions {
+		add(sol[0], sol[1])
+	}
+	return zs
+}
 
 // For Bézier-Bézier interesections:
 // see T.W. Sederberg, "Computer Aided Geometric Design", 2012
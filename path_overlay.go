@@ -0,0 +1,80 @@
+package canvas
+
+// OverlayFace is a face of the overlay of two sets of paths, see Overlay. P and Q are the indices of the paths in the first and second set that contain the face, or -1 when the face is outside all paths of that set.
+type OverlayFace struct {
+	*Path
+	P, Q int
+}
+
+// InP returns true if the face is inside a path of the first set.
+func (face OverlayFace) InP() bool {
+	return face.P != -1
+}
+
+// InQ returns true if the face is inside a path of the second set.
+func (face OverlayFace) InQ() bool {
+	return face.Q != -1
+}
+
+// Overlay returns all faces of the planar subdivision formed by overlaying two sets of paths, such as two thematic layers of a map with land use and administrative regions. Every face is labelled with the path of each set that contains it, so that faces in the intersection of both sets have two labels and faces in only one set have one label. Paths within a set should not overlap, otherwise the faces of overlapping paths are returned once for each path. Each face is a single outer ring together with its holes, and the paths are treated as filled regions using the NonZero fill rule.
+func Overlay(ps, qs []*Path) []OverlayFace {
+	unionP, unionQ := &Path{}, &Path{}
+	for _, p := range ps {
+		unionP = unionP.Or(p)
+	}
+	for _, q := range qs {
+		unionQ = unionQ.Or(q)
+	}
+
+	faces := []OverlayFace{}
+	for i, p := range ps {
+		for j, q := range qs {
+			if p.FastBounds().Overlaps(q.FastBounds()) {
+				faces = appendOverlayFaces(faces, p.And(q), i, j)
+			}
+		}
+		faces = appendOverlayFaces(faces, p.Not(unionQ), i, -1)
+	}
+	for j, q := range qs {
+		faces = appendOverlayFaces(faces, q.Not(unionP), -1, j)
+	}
+	return faces
+}
+
+func appendOverlayFaces(faces []OverlayFace, p *Path, i, j int) []OverlayFace {
+	for _, face := range splitFaces(p) {
+		faces = append(faces, OverlayFace{face, i, j})
+	}
+	return faces
+}
+
+// splitFaces splits a settled path into faces, where each face is an outer ring (counter clockwise) together with the holes (clockwise) directly inside it.
+func splitFaces(p *Path) []*Path {
+	outers, holes := []*Path{}, []*Path{}
+	for _, ring := range p.Split() {
+		if !ring.Closed() {
+			continue
+		} else if ring.CCW() {
+			outers = append(outers, ring)
+		} else {
+			holes = append(holes, ring)
+		}
+	}
+
+	faces := make([]*Path, len(outers))
+	copy(faces, outers)
+	for _, hole := range holes {
+		// add the hole to the smallest outer ring that contains it
+		k, area := -1, 0.0
+		for i, outer := range outers {
+			bounds := outer.FastBounds()
+			if (k == -1 || bounds.W*bounds.H < area) && outer.ContainsPath(hole) {
+				k, area = i, bounds.W*bounds.H
+			}
+		}
+		if k != -1 {
+			faces[k] = faces[k].Append(hole)
+		}
+	}
+	return faces
+}
@@ -0,0 +1,35 @@
+package canvas
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestOverlay(t *testing.T) {
+	ps := []*Path{Rectangle(10.0, 10.0)}
+	qs := []*Path{Rectangle(10.0, 10.0).Translate(5.0, 0.0), Rectangle(2.0, 2.0).Translate(20.0, 0.0)}
+
+	faces := Overlay(ps, qs)
+	test.T(t, len(faces), 4)
+	test.T(t, faces[0].P, 0)
+	test.T(t, faces[0].Q, 0)
+	test.T(t, faces[0].Bounds(), Rect{5.0, 0.0, 5.0, 10.0})
+	test.T(t, faces[1].P, 0)
+	test.T(t, faces[1].Q, -1)
+	test.T(t, faces[1].Bounds(), Rect{0.0, 0.0, 5.0, 10.0})
+	test.T(t, faces[2].InP(), false)
+	test.T(t, faces[2].Q, 0)
+	test.T(t, faces[2].Bounds(), Rect{10.0, 0.0, 5.0, 10.0})
+	test.T(t, faces[3].InP(), false)
+	test.T(t, faces[3].Q, 1)
+}
+
+func TestSplitFaces(t *testing.T) {
+	p := MustParseSVGPath("M0 0L10 0L10 10L0 10zM2 2L2 8L8 8L8 2zM4 4L6 4L6 6L4 6zM20 0L30 0L30 10L20 10z")
+	faces := splitFaces(p)
+	test.T(t, len(faces), 3)
+	test.T(t, len(faces[0].Split()), 2)
+	test.T(t, len(faces[1].Split()), 1)
+	test.T(t, len(faces[2].Split()), 1)
+}
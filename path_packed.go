@@ -0,0 +1,113 @@
+package canvas
+
+import "golang.org/x/image/vector"
+
+// PackedPath is a compact, read-only encoding of a path in which each run of consecutive commands of the same type is stored as the command and the number of repetitions, followed by the values of all commands in the run. Unlike Path, which stores the command before and after the values of every segment, long polylines such as flattened paths take about half the memory, which is useful when storing many paths. Flat packed paths are rasterized directly by ToRasterizer, and Unpack converts it back to a path.
+type PackedPath struct {
+	d []float64
+}
+
+// Pack returns the packed encoding of the path.
+func (p *Path) Pack() *PackedPath {
+	pp := &PackedPath{}
+	header := -1
+	for i := 0; i < len(p.d); {
+		cmd := p.d[i]
+		n := cmdLen(cmd)
+		if header == -1 || pp.d[header] != cmd {
+			header = len(pp.d)
+			pp.d = append(pp.d, cmd, 0.0)
+		}
+		pp.d[header+1]++
+		pp.d = append(pp.d, p.d[i+1:i+n-1]...)
+		i += n
+	}
+	return pp
+}
+
+// Empty returns true if the packed path is empty.
+func (pp *PackedPath) Empty() bool {
+	return len(pp.d) == 0
+}
+
+// Size returns the number of values (float64s) of the packed path.
+func (pp *PackedPath) Size() int {
+	return len(pp.d)
+}
+
+// Walk calls f for every segment of the path with its command, start and end point, and the values of the command excluding the command itself. These are the end point for MoveTo, LineTo and Close, the control point(s) and end point for QuadTo and CubeTo, and the radii, rotation, flags and end point for ArcTo, as in Path. The values must not be modified.
+func (pp *PackedPath) Walk(f func(cmd float64, start, end Point, values []float64)) {
+	var start Point
+	for i := 0; i < len(pp.d); {
+		cmd, count := pp.d[i], int(pp.d[i+1])
+		m := cmdLen(cmd) - 2
+		i += 2
+		for j := 0; j < count; j++ {
+			values := pp.d[i : i+m]
+			end := Point{values[m-2], values[m-1]}
+			f(cmd, start, end, values)
+			start = end
+			i += m
+		}
+	}
+}
+
+// Unpack returns the path of the packed encoding.
+func (pp *PackedPath) Unpack() *Path {
+	p := &Path{d: make([]float64, 0, 2*len(pp.d))}
+	pp.Walk(func(cmd float64, _, _ Point, values []float64) {
+		p.d = append(p.d, cmd)
+		p.d = append(p.d, values...)
+		p.d = append(p.d, cmd)
+	})
+	return p
+}
+
+// flat returns true if the packed path has no Bézier or arc segments.
+func (pp *PackedPath) flat() bool {
+	for i := 0; i < len(pp.d); {
+		cmd, count := pp.d[i], int(pp.d[i+1])
+		if cmd == QuadToCmd || cmd == CubeToCmd || cmd == ArcToCmd {
+			return false
+		}
+		i += 2 + count*(cmdLen(cmd)-2)
+	}
+	return true
+}
+
+// ToRasterizer rasterizes the packed path using the given rasterizer and resolution, see Path.ToRasterizer. Flat packed paths, such as flattened paths, are rasterized without unpacking them and each run of line segments is passed to the rasterizer without checking the command of every segment. Other packed paths are unpacked and flattened first.
+func (pp *PackedPath) ToRasterizer(ras *vector.Rasterizer, resolution Resolution) {
+	if !pp.flat() {
+		pp.Unpack().ToRasterizer(ras, resolution)
+		return
+	}
+	defer traceRegion("rasterize")()
+
+	dpmm := resolution.DPMM()
+	dy := float64(ras.Bounds().Size().Y)
+	closed := false
+	for i := 0; i < len(pp.d); {
+		cmd, count := pp.d[i], int(pp.d[i+1])
+		i += 2
+		end := i + 2*count
+		switch cmd {
+		case MoveToCmd:
+			for ; i < end; i += 2 {
+				ras.MoveTo(float32(pp.d[i]*dpmm), float32(dy-pp.d[i+1]*dpmm))
+			}
+		case LineToCmd:
+			for ; i < end; i += 2 {
+				ras.LineTo(float32(pp.d[i]*dpmm), float32(dy-pp.d[i+1]*dpmm))
+			}
+		case CloseCmd:
+			for ; i < end; i += 2 {
+				ras.ClosePath()
+			}
+		}
+		closed = cmd == CloseCmd
+	}
+	if 0 < len(pp.d) && !closed {
+		// implicitly close path
+		ras.ClosePath()
+	}
+}
@@ -0,0 +1,319 @@
+package canvas
+
+import (
+	"math"
+	"sort"
+)
+
+// PreparedPath is a path with precomputed acceleration structures for repeated queries against the same path, such as testing many paths for intersection with one large path. It groups the path's segments into monotone chains and stores them in a bounding volume hierarchy, so that queries only process the segments near the query.
+type PreparedPath struct {
+	p      *Path
+	flat   bool
+	chains []preparedChain
+	nodes  []bvhNode
+}
+
+// bbox is an axis-aligned bounding box. Unlike Rect it handles boxes of zero width or height.
+type bbox struct {
+	x0, y0, x1, y1 float64
+}
+
+func (b bbox) add(c bbox) bbox {
+	return bbox{math.Min(b.x0, c.x0), math.Min(b.y0, c.y0), math.Max(b.x1, c.x1), math.Max(b.y1, c.y1)}
+}
+
+func (b bbox) overlaps(c bbox) bool {
+	return b.x0 <= c.x1+Epsilon && c.x0 <= b.x1+Epsilon && b.y0 <= c.y1+Epsilon && c.y0 <= b.y1+Epsilon
+}
+
+// distance returns the distance from the point to the box, which is zero inside the box.
+func (b bbox) distance(p Point) float64 {
+	dx := math.Max(0.0, math.Max(b.x0-p.X, p.X-b.x1))
+	dy := math.Max(0.0, math.Max(b.y0-p.Y, p.Y-b.y1))
+	return math.Hypot(dx, dy)
+}
+
+// segmentBBox returns the bounding box of the segment's end points and control points, or of the full ellipse for arcs.
+func segmentBBox(start Point, d []float64) bbox {
+	end := Point{d[len(d)-3], d[len(d)-2]}
+	b := bbox{math.Min(start.X, end.X), math.Min(start.Y, end.Y), math.Max(start.X, end.X), math.Max(start.Y, end.Y)}
+	switch d[0] {
+	case QuadToCmd, CubeToCmd:
+		for k := 1; k < len(d)-3; k += 2 {
+			b = b.add(bbox{d[k], d[k+1], d[k], d[k+1]})
+		}
+	case ArcToCmd:
+		rx, ry, phi := d[1], d[2], d[3]
+		large, sweep := toArcFlags(d[4])
+		cx, cy, _, _ := ellipseToCenter(start.X, start.Y, rx, ry, phi, large, sweep, end.X, end.Y)
+		r := math.Max(rx, ry)
+		b = b.add(bbox{cx - r, cy - r, cx + r, cy + r})
+	}
+	return b
+}
+
+// preparedChain is a sequence of segments of one subpath. Line segments are grouped while they are monotone in both X and Y, curves form a chain by themselves.
+type preparedChain struct {
+	i0, i1  int // range of segments in Path.d
+	start   Point
+	subpath int
+	box     bbox
+	coords  []Point // flattened coordinates, including the start
+}
+
+// maxChainLength is the maximum number of segments in a monotone chain.
+const maxChainLength = 16
+
+// bvhNode is a node in the bounding volume hierarchy of chains. Leaves have no children and hold the chains in [i0,i1).
+type bvhNode struct {
+	box         bbox
+	left, right int
+	i0, i1      int
+}
+
+// NewPreparedPath returns a prepared path of a copy of p.
+func NewPreparedPath(p *Path) *PreparedPath {
+	p = p.Copy()
+	pp := &PreparedPath{
+		p:    p,
+		flat: p.Flat(),
+	}
+
+	subpath := -1
+	var start Point
+	var chain *preparedChain
+	var sx, sy float64
+	for i := 0; i < len(p.d); {
+		cmd := p.d[i]
+		n := cmdLen(cmd)
+		end := Point{p.d[i+n-3], p.d[i+n-2]}
+		if cmd == MoveToCmd {
+			subpath++
+			chain = nil
+		} else {
+			dx, dy := sign(end.X-start.X), sign(end.Y-start.Y)
+			line := cmd == LineToCmd || cmd == CloseCmd
+			if !line || chain == nil || maxChainLength*cmdLen(LineToCmd) <= chain.i1-chain.i0 ||
+				dx != 0.0 && sx != 0.0 && dx != sx || dy != 0.0 && sy != 0.0 && dy != sy {
+				pp.chains = append(pp.chains, preparedChain{
+					i0:      i,
+					i1:      i,
+					start:   start,
+					subpath: subpath,
+					box:     bbox{start.X, start.Y, start.X, start.Y},
+					coords:  []Point{start},
+				})
+				chain = &pp.chains[len(pp.chains)-1]
+				sx, sy = 0.0, 0.0
+			}
+			if dx != 0.0 {
+				sx = dx
+			}
+			if dy != 0.0 {
+				sy = dy
+			}
+
+			chain.i1 = i + n
+			chain.box = chain.box.add(segmentBBox(start, p.d[i:i+n]))
+			if line {
+				chain.coords = append(chain.coords, end)
+			} else {
+				seg := &Path{d: append([]float64{MoveToCmd, start.X, start.Y, MoveToCmd}, p.d[i:i+n]...)}
+				chain.coords = append(chain.coords, seg.Flatten(Tolerance).Coords()[1:]...)
+				chain = nil // curves are not extended
+			}
+		}
+		i += n
+		start = end
+	}
+	if 0 < len(pp.chains) {
+		pp.build(0, len(pp.chains))
+	}
+	return pp
+}
+
+func sign(f float64) float64 {
+	if Equal(f, 0.0) {
+		return 0.0
+	} else if f < 0.0 {
+		return -1.0
+	}
+	return 1.0
+}
+
+// build builds the bounding volume hierarchy for the chains in [i0,i1) and returns the index of its node, by splitting at the median along the longest axis of the chains' centers.
+func (pp *PreparedPath) build(i0, i1 int) int {
+	box := pp.chains[i0].box
+	center := bbox{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	for _, chain := range pp.chains[i0:i1] {
+		box = box.add(chain.box)
+		cx, cy := (chain.box.x0+chain.box.x1)/2.0, (chain.box.y0+chain.box.y1)/2.0
+		center = center.add(bbox{cx, cy, cx, cy})
+	}
+
+	k := len(pp.nodes)
+	pp.nodes = append(pp.nodes, bvhNode{box, -1, -1, i0, i1})
+	if i1-i0 <= 4 {
+		return k
+	}
+
+	chains := pp.chains[i0:i1]
+	if center.y1-center.y0 < center.x1-center.x0 {
+		sort.Slice(chains, func(i, j int) bool {
+			return chains[i].box.x0+chains[i].box.x1 < chains[j].box.x0+chains[j].box.x1
+		})
+	} else {
+		sort.Slice(chains, func(i, j int) bool {
+			return chains[i].box.y0+chains[i].box.y1 < chains[j].box.y0+chains[j].box.y1
+		})
+	}
+	m := (i0 + i1) / 2
+	left := pp.build(i0, m)
+	right := pp.build(m, i1)
+	pp.nodes[k].left, pp.nodes[k].right = left, right
+	return k
+}
+
+// query calls f for every chain for which inside returns true for both the chain's bounding box and that of all its parent nodes.
+func (pp *PreparedPath) query(inside func(bbox) bool, f func(*preparedChain) bool) {
+	if len(pp.nodes) == 0 {
+		return
+	}
+	stack := []int{0}
+	for 0 < len(stack) {
+		node := pp.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		if !inside(node.box) {
+			continue
+		} else if node.left != -1 {
+			stack = append(stack, node.left, node.right)
+			continue
+		}
+		for i := node.i0; i < node.i1; i++ {
+			if inside(pp.chains[i].box) && !f(&pp.chains[i]) {
+				return
+			}
+		}
+	}
+}
+
+// Path returns the prepared path.
+func (pp *PreparedPath) Path() *Path {
+	return pp.p
+}
+
+// Bounds returns the bounding box of the path, which may be larger than the exact bounds for paths with curves.
+func (pp *PreparedPath) Bounds() Rect {
+	if len(pp.nodes) == 0 {
+		return Rect{}
+	}
+	b := pp.nodes[0].box
+	return Rect{b.x0, b.y0, b.x1 - b.x0, b.y1 - b.y0}
+}
+
+// Contains returns whether the path contains the point (x,y) in any of its subpaths, see Path.Contains.
+func (pp *PreparedPath) Contains(x, y float64) bool {
+	var zs []Intersection
+	zsSubpath := map[int][]PathIntersection{}
+	pp.query(func(b bbox) bool {
+		return b.y0-Epsilon <= y && y <= b.y1+Epsilon && x <= b.x1+Epsilon
+	}, func(chain *preparedChain) bool {
+		start := chain.start
+		for i := chain.i0; i < chain.i1; {
+			n := cmdLen(pp.p.d[i])
+			zs = rayIntersectionSegment(zs[:0], x, y, start, pp.p.d[i:i+n])
+			for _, z := range zs {
+				zsSubpath[chain.subpath] = append(zsSubpath[chain.subpath], PathIntersection{
+					Point:    z.Point,
+					T:        z.T[1],
+					Dir:      z.Dir[1],
+					Tangent:  Equal(z.T[0], 0.0),
+					Parallel: z.Aligned() || z.AntiAligned(),
+					Into:     z.Into(),
+				})
+			}
+			start = Point{pp.p.d[i+n-3], pp.p.d[i+n-2]}
+			i += n
+		}
+		return true
+	})
+	for _, Zs := range zsSubpath {
+		if n, _ := windings(Zs); n%2 == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// Intersects returns true if path q intersects the prepared path, see Path.Intersects. Only the segments of both paths whose bounding boxes overlap are intersected. When intersections are found at segment end points or are tangent, the result is determined by Path.Intersects.
+func (pp *PreparedPath) Intersects(q *Path) bool {
+	if !pp.flat {
+		q = q.Flatten(Tolerance)
+	}
+
+	var zs Intersections
+	found, ambiguous := false, false
+	var start Point
+	for j := 0; j < len(q.d) && !found; {
+		cmd := q.d[j]
+		n := cmdLen(cmd)
+		end := Point{q.d[j+n-3], q.d[j+n-2]}
+		if cmd != MoveToCmd && (cmd != CloseCmd || !start.Equals(end)) {
+			d := q.d[j : j+n]
+			box := segmentBBox(start, d)
+			pp.query(box.overlaps, func(chain *preparedChain) bool {
+				a0 := chain.start
+				for i := chain.i0; i < chain.i1; {
+					m := cmdLen(pp.p.d[i])
+					a1 := Point{pp.p.d[i+m-3], pp.p.d[i+m-2]}
+					if (pp.p.d[i] != CloseCmd || !a0.Equals(a1)) && segmentBBox(a0, pp.p.d[i:i+m]).overlaps(box) {
+						zs = intersectionSegment(zs[:0], a0, pp.p.d[i:i+m], start, d)
+						for _, z := range zs {
+							if !z.Tangent && Interval(z.T[0], Epsilon, 1.0-Epsilon) && Interval(z.T[1], Epsilon, 1.0-Epsilon) {
+								found = true
+								return false
+							}
+							ambiguous = true
+						}
+					}
+					a0 = a1
+					i += m
+				}
+				return true
+			})
+		}
+		start = end
+		j += n
+	}
+	if found {
+		return true
+	} else if ambiguous {
+		return pp.p.Intersects(q)
+	}
+	return false
+}
+
+// Distance returns the shortest distance from the point (x,y) to the path's outline. Curves are approximated by line segments within Tolerance.
+func (pp *PreparedPath) Distance(x, y float64) float64 {
+	pos := Point{x, y}
+	dist := math.Inf(1)
+	pp.query(func(b bbox) bool {
+		return b.distance(pos) < dist
+	}, func(chain *preparedChain) bool {
+		for i := 1; i < len(chain.coords); i++ {
+			dist = math.Min(dist, segmentDistance(chain.coords[i-1], chain.coords[i], pos))
+		}
+		return true
+	})
+	return dist
+}
+
+// segmentDistance returns the distance from point p to the line segment (a,b).
+func segmentDistance(a, b, p Point) float64 {
+	ab := b.Sub(a)
+	t := 0.0
+	if l := ab.Dot(ab); l != 0.0 {
+		t = math.Max(0.0, math.Min(1.0, p.Sub(a).Dot(ab)/l))
+	}
+	return p.Sub(a.Add(ab.Mul(t))).Length()
+}
@@ -1,6 +1,7 @@
 package canvas
 
 import (
+	"fmt"
 	"math"
 )
 
@@ -64,6 +65,76 @@ func (SquareCapper) String() string {
 	return "Square"
 }
 
+// ArrowCap caps the start or end of a path by an arrow head that is three times as wide and long as the stroke width.
+var ArrowCap Capper = ArrowCapper{3.0, 3.0}
+
+// ArrowCapper is an arrow head capper. Width and Length are the width of the arrow head base and the length of the arrow head as a multiple of the stroke width. The tip of the arrow head extends beyond the end of the path by Length.
+type ArrowCapper struct {
+	Width, Length float64
+}
+
+// Cap adds a cap to path p of width 2*halfWidth, at a pivot point and initial normal direction of n0.
+func (c ArrowCapper) Cap(p *Path, halfWidth float64, pivot, n0 Point) {
+	e := n0.Rot90CCW().Mul(2.0 * c.Length)
+	w := n0.Mul(c.Width)
+	corner1 := pivot.Add(w)
+	tip := pivot.Add(e)
+	corner2 := pivot.Sub(w)
+	end := pivot.Sub(n0)
+	p.LineTo(corner1.X, corner1.Y)
+	p.LineTo(tip.X, tip.Y)
+	p.LineTo(corner2.X, corner2.Y)
+	p.LineTo(end.X, end.Y)
+}
+
+func (ArrowCapper) String() string {
+	return "Arrow"
+}
+
+// StartEndCapper caps the start of a path using Start and the end of a path using End, which allows for different caps on either end of an open path. When used as a regular capper, it will use End.
+type StartEndCapper struct {
+	Start, End Capper
+}
+
+// Cap adds a cap to path p of width 2*halfWidth, at a pivot point and initial normal direction of n0.
+func (c StartEndCapper) Cap(p *Path, halfWidth float64, pivot, n0 Point) {
+	_, crEnd := startEndCappers(c)
+	crEnd.Cap(p, halfWidth, pivot, n0)
+}
+
+func (c StartEndCapper) String() string {
+	return fmt.Sprintf("%v-%v", c.Start, c.End)
+}
+
+// startEndCappers returns the cappers for the start and end of a path.
+func startEndCappers(cr Capper) (Capper, Capper) {
+	if se, ok := cr.(StartEndCapper); ok {
+		crStart, crEnd := se.Start, se.End
+		if crStart == nil {
+			crStart = ButtCap
+		}
+		if crEnd == nil {
+			crEnd = ButtCap
+		}
+		return crStart, crEnd
+	}
+	return cr, cr
+}
+
+// NativeCapper returns the cappers for the start and end of a path, and whether both are butt, round, or square cappers, which are the only ones supported natively by most output formats. Renderers can use the native line cap only when start and end are equal.
+func NativeCapper(cr Capper) (start, end Capper, ok bool) {
+	start, end = startEndCappers(cr)
+	return start, end, isNativeCapper(start) && isNativeCapper(end)
+}
+
+func isNativeCapper(cr Capper) bool {
+	switch cr.(type) {
+	case ButtCapper, RoundCapper, SquareCapper:
+		return true
+	}
+	return false
+}
+
 ////////////////
 
 // Joiner implements Join, with rhs the right path and lhs the left path to append to, pivot the intersection of both path elements, n0 and n1 the normals at the start and end of the path respectively. The length of n0 and n1 are equal to the halfWidth.
@@ -113,11 +184,13 @@ func (RoundJoiner) String() string {
 	return "Round"
 }
 
-// MiterJoin connects two path elements by extending the ends of the paths as lines until they meet. If this point is further than the limit, this will result in a bevel join (MiterJoin) or they will meet at the limit (MiterClipJoin).
+// MiterJoin connects two path elements by extending the ends of the paths as lines until they meet. If this point is further than the limit, this will result in a bevel join. This corresponds to SVG's miter line join.
 var MiterJoin Joiner = MiterJoiner{BevelJoin, 4.0}
+
+// MiterClipJoin connects two path elements by extending the ends of the paths as lines until they meet. If this point is further than the limit, the join will be clipped at the limit. This corresponds to SVG2's miter-clip line join.
 var MiterClipJoin Joiner = MiterJoiner{nil, 4.0}
 
-// MiterJoiner is a miter joiner.
+// MiterJoiner is a miter joiner. Limit is the miter limit, being the ratio of the miter length to the stroke width (as in SVG's stroke-miterlimit). When the limit is exceeded, GapJoiner is used to join the path elements, or the miter is clipped at the limit if GapJoiner is nil.
 type MiterJoiner struct {
 	GapJoiner Joiner
 	Limit     float64
@@ -180,11 +253,13 @@ func (j MiterJoiner) String() string {
 	return "Miter"
 }
 
-// ArcsJoin connects two path elements by extending the ends of the paths as circle arcs until they meet. If this point is further than the limit, this will result in a bevel join (ArcsJoin) or they will meet at the limit (ArcsClipJoin).
+// ArcsJoin connects two path elements by extending the ends of the paths as circle arcs until they meet. If this point is further than the limit, this will result in a bevel join.
 var ArcsJoin Joiner = ArcsJoiner{BevelJoin, 4.0}
+
+// ArcsClipJoin connects two path elements by extending the ends of the paths as circle arcs until they meet. If this point is further than the limit, the join will be clipped at the limit. This corresponds to SVG2's arcs line join.
 var ArcsClipJoin Joiner = ArcsJoiner{nil, 4.0}
 
-// ArcsJoiner is an arcs joiner.
+// ArcsJoiner is an arcs joiner. Limit and GapJoiner behave the same as for MiterJoiner.
 type ArcsJoiner struct {
 	GapJoiner Joiner
 	Limit     float64
@@ -378,6 +453,18 @@ func (j ArcsJoiner) String() string {
 	return "Arcs"
 }
 
+// joinerWithLimit returns the joiner with its miter limit replaced if it is a miter or arcs joiner.
+func joinerWithLimit(jr Joiner, limit float64) Joiner {
+	if miter, ok := jr.(MiterJoiner); ok {
+		miter.Limit = limit
+		return miter
+	} else if arcs, ok := jr.(ArcsJoiner); ok {
+		arcs.Limit = limit
+		return arcs
+	}
+	return jr
+}
+
 type pathStrokeState struct {
 	cmd    float64
 	p0, p1 Point   // position of start and end
@@ -538,10 +625,11 @@ func (p *Path) offset(halfWidth float64, cr Capper, jr Joiner, strokeOpen bool,
 		lhs.Close()
 		lhs.optimizeClose()
 	} else if strokeOpen {
+		crStart, crEnd := startEndCappers(cr)
 		lhs = lhs.Reverse()
-		cr.Cap(rhs, halfWidth, states[len(states)-1].p1, states[len(states)-1].n1)
+		crEnd.Cap(rhs, halfWidth, states[len(states)-1].p1, states[len(states)-1].n1)
 		rhs = rhs.Join(lhs)
-		cr.Cap(rhs, halfWidth, states[0].p0, states[0].n0.Neg())
+		crStart.Cap(rhs, halfWidth, states[0].p0, states[0].n0.Neg())
 		rhs.Close()
 		rhs.optimizeClose()
 		lhs = nil
@@ -549,8 +637,13 @@ func (p *Path) offset(halfWidth float64, cr Capper, jr Joiner, strokeOpen bool,
 	return rhs, lhs
 }
 
-// Offset offsets the path to expand by w and returns a new path. If w is negative it will contract. For open paths, a positive w will offset the path to the right-hand side. The tolerance is the maximum deviation from the actual offset when flattening Béziers and optimizing the path. Subpaths may not (self-)intersect, use Settle to remove (self-)intersections.
+// Offset offsets the path to expand by w and returns a new path. If w is negative it will contract. For open paths, a positive w will offset the path to the right-hand side. The tolerance is the maximum deviation from the actual offset when flattening Béziers and optimizing the path. Subpaths may not (self-)intersect, use Settle to remove (self-)intersections. Corners are joined by round joins, see OffsetJoin.
 func (p *Path) Offset(w float64, fillRule FillRule, tolerance float64) *Path {
+	return p.OffsetJoin(w, RoundJoin, fillRule, tolerance)
+}
+
+// OffsetJoin offsets the path to expand by w like Offset, but joins corners using jr, such as MiterJoin for sharp corners or BevelJoin for flat corners as used for CNC tool paths. Offset subpaths that overlap each other are merged, such as nearby shapes that grow together or holes that disappear, so that the result consists of non-overlapping rings that are counter clockwise for filled regions and clockwise for holes.
+func (p *Path) OffsetJoin(w float64, jr Joiner, fillRule FillRule, tolerance float64) *Path {
 	if Equal(w, 0.0) {
 		return p
 	}
@@ -558,12 +651,13 @@ func (p *Path) Offset(w float64, fillRule FillRule, tolerance float64) *Path {
 	positive := 0.0 < w
 	w = math.Abs(w)
 
-	q := &Path{}
+	q, open := &Path{}, &Path{}
+	ps := p.Split()
 	filling := p.Filling(fillRule)
-	for i, pi := range p.Split() {
+	for i, pi := range ps {
 		r := &Path{}
 		ccw, closed := pi.CCW(), pi.Closed()
-		rhs, lhs := pi.offset(w, ButtCap, RoundJoin, false, tolerance)
+		rhs, lhs := pi.offset(w, ButtCap, jr, false, tolerance)
 		if !closed || (ccw != filling[i]) != positive {
 			r = rhs
 		} else {
@@ -579,14 +673,30 @@ func (p *Path) Offset(w float64, fillRule FillRule, tolerance float64) *Path {
 			if !filling[i] {
 				r = r.Reverse()
 			}
+			q = q.Append(r)
+		} else {
+			open = open.Append(r)
 		}
-		q = q.Append(r)
 	}
-	return q
+	if !q.Empty() {
+		// merge overlapping and self-overlapping rings, filling rings are CCW and holes are CW
+		q = q.Settle(NonZero)
+	}
+	return q.Append(open)
 }
 
-// Stroke converts a path into a stroke of width w and returns a new path. It uses cr to cap the start and end of the path, and jr to join all path elements. If the path closes itself, it will use a join between the start and end instead of capping them. The tolerance is the maximum deviation from the original path when flattening Béziers and optimizing the stroke.
+// Buffer returns the region within distance d of the path as closed rings, such as to buffer roads or rivers on maps. Unlike Stroke, overlaps of the stroke with itself or with other subpaths are dissolved, so that the result consists of non-overlapping rings that are counter clockwise for filled regions and clockwise for holes. The ends of open subpaths are capped by cr, such as RoundCap, SquareCap, or ButtCap for flat ends, and segments are joined by round joins. Closed subpaths are buffered on both sides, use Offset to expand a filled region instead. The tolerance is the maximum deviation from the actual buffer when flattening Béziers and optimizing the path.
+func (p *Path) Buffer(d float64, cr Capper, tolerance float64) *Path {
+	if d <= 0.0 {
+		return &Path{}
+	}
+	return p.Stroke(2.0*d, cr, RoundJoin, tolerance).Settle(NonZero)
+}
+
+// Stroke converts a path into a stroke of width w and returns a new path. It uses cr to cap the start and end of the path (use StartEndCapper for different caps at the start and end), and jr to join all path elements. If the path closes itself, it will use a join between the start and end instead of capping them. The tolerance is the maximum deviation from the original path when flattening Béziers and optimizing the stroke.
 func (p *Path) Stroke(w float64, cr Capper, jr Joiner, tolerance float64) *Path {
+	defer traceRegion("stroke")()
+
 	if cr == nil {
 		cr = ButtCap
 	}
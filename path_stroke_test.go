@@ -23,6 +23,10 @@ func TestPathStroke(t *testing.T) {
 		{"M10 10L10 5", 2.0, ButtCap, RoundJoin, "M9 10L9 5L11 5L11 10z"},
 		{"M10 10L10 5", 2.0, SquareCap, RoundJoin, "M9 4L11 4L11 5L11 10L11 11L9 11z"},
 
+		{"M10 10L10 5", 2.0, StartEndCapper{ButtCap, SquareCap}, RoundJoin, "M9 10L9 5L9 4L11 4L11 5L11 10z"},
+		{"M10 10L10 5", 2.0, StartEndCapper{SquareCap, ButtCap}, RoundJoin, "M9 5L11 5L11 10L11 11L9 11L9 10z"},
+		{"L10 0", 2.0, ArrowCapper{2.0, 1.0}, RoundJoin, "M0 -1L10 -1L10 -2L12 0L10 2L10 1L0 1L0 2L-2 0L0 -2z"},
+
 		{"L10 0L20 0", 2.0, ButtCap, RoundJoin, "M0 -1L10 -1L20 -1L20 1L10 1L0 1z"},
 		{"L10 0L10 10", 2.0, ButtCap, RoundJoin, "M9 1L0 1L0 -1L10 -1A1 1 0 0 1 11 0L11 10L9 10z"},
 		{"L10 0L10 -10", 2.0, ButtCap, RoundJoin, "M9 -1L9 -10L11 -10L11 0A1 1 0 0 1 10 1L0 1L0 -1z"},
@@ -80,6 +84,39 @@ func TestPathStroke(t *testing.T) {
 	Epsilon = origEpsilon
 }
 
+func TestJoinerWithLimit(t *testing.T) {
+	test.T(t, joinerWithLimit(MiterJoin, 2.0), Joiner(MiterJoiner{BevelJoin, 2.0}))
+	test.T(t, joinerWithLimit(MiterClipJoin, 2.0), Joiner(MiterJoiner{nil, 2.0}))
+	test.T(t, joinerWithLimit(ArcsClipJoin, 2.0), Joiner(ArcsJoiner{nil, 2.0}))
+	test.T(t, joinerWithLimit(RoundJoin, 2.0), RoundJoin)
+
+	// miter join exceeding the limit of sqrt(2) for a right angle falls back to a bevel join
+	p := MustParseSVGPath("L10 0L10 10")
+	test.T(t, p.Stroke(2.0, ButtCap, joinerWithLimit(MiterJoin, 1.4), 1.0), p.Stroke(2.0, ButtCap, BevelJoin, 1.0))
+}
+
+func TestNativeCapper(t *testing.T) {
+	var tests = []struct {
+		cr         Capper
+		start, end Capper
+		ok         bool
+	}{
+		{RoundCap, RoundCap, RoundCap, true},
+		{StartEndCapper{RoundCap, RoundCap}, RoundCap, RoundCap, true},
+		{StartEndCapper{nil, SquareCap}, ButtCap, SquareCap, true},
+		{StartEndCapper{ButtCap, ArrowCap}, ButtCap, ArrowCap, false},
+		{ArrowCap, ArrowCap, ArrowCap, false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.cr), func(t *testing.T) {
+			start, end, ok := NativeCapper(tt.cr)
+			test.T(t, start, tt.start)
+			test.T(t, end, tt.end)
+			test.T(t, ok, tt.ok)
+		})
+	}
+}
+
 func TestPathStrokeEllipse(t *testing.T) {
 	rx, ry := 20.0, 10.0
 	nphi := 12
@@ -114,3 +151,57 @@ func TestPathOffset(t *testing.T) {
 		})
 	}
 }
+
+func TestPathOffsetJoin(t *testing.T) {
+	offset := MustParseSVGPath("L10 0L10 10L0 10z").OffsetJoin(1.0, MiterJoin, NonZero, 0.01)
+	test.T(t, offset.Bounds(), Rect{-1.0, -1.0, 12.0, 12.0})
+	test.That(t, offset.Fills(-0.9, -0.9, NonZero), "corner must be sharp")
+
+	// nearby shapes grow together
+	offset = MustParseSVGPath("L10 0L10 10L0 10zM11 0L21 0L21 10L11 10z").OffsetJoin(1.0, MiterJoin, NonZero, 0.01)
+	test.T(t, len(offset.Split()), 1)
+	test.T(t, offset.Bounds(), Rect{-1.0, -1.0, 23.0, 12.0})
+	test.That(t, offset.Fills(10.5, 5.0, NonZero), "gap must be filled")
+
+	// arms of a U-shape collapse when insetting
+	offset = MustParseSVGPath("L20 0L20 30L16 30L16 10L4 10L4 30L0 30z").OffsetJoin(-3.0, MiterJoin, NonZero, 0.01)
+	test.T(t, len(offset.Split()), 1)
+	test.T(t, offset.Bounds(), Rect{3.0, 3.0, 14.0, 4.0})
+	test.That(t, !offset.Fills(2.0, 20.0, NonZero), "arm must be removed")
+
+	// open subpaths are not merged with the rings
+	offset = MustParseSVGPath("L10 0L10 10L0 10zM11 0L21 0L21 10L11 10zM30 0L40 0").OffsetJoin(1.0, MiterJoin, NonZero, 0.01)
+	ps := offset.Split()
+	test.T(t, len(ps), 2)
+	test.T(t, ps[1], MustParseSVGPath("M30 -1L40 -1"))
+}
+
+func TestPathBuffer(t *testing.T) {
+	buffer := MustParseSVGPath("L10 0M5 -5L5 5").Buffer(1.0, ButtCap, 0.01)
+	test.T(t, len(buffer.Split()), 1)
+	test.T(t, buffer.Bounds(), Rect{0.0, -5.0, 10.0, 10.0})
+	test.That(t, buffer.Fills(5.0, 0.0, NonZero), "center must be inside")
+	test.That(t, buffer.Fills(1.0, 0.5, NonZero), "horizontal bar must be inside")
+	test.That(t, !buffer.Fills(2.0, 3.0, NonZero), "corner must be outside")
+
+	// closed subpaths are buffered on both sides
+	buffer = MustParseSVGPath("L10 0L10 10L0 10z").Buffer(1.0, ButtCap, 0.01)
+	rings := buffer.Split()
+	test.T(t, len(rings), 2)
+	test.That(t, rings[0].CCW() != rings[1].CCW(), "hole must have the opposite orientation")
+	test.T(t, buffer.Bounds(), Rect{-1.0, -1.0, 12.0, 12.0})
+	test.That(t, buffer.Fills(10.5, 5.0, NonZero), "outside of edge must be inside")
+	test.That(t, buffer.Fills(9.5, 5.0, NonZero), "inside of edge must be inside")
+	test.That(t, !buffer.Fills(5.0, 5.0, NonZero), "interior must be a hole")
+
+	// overlaps of a self-crossing polyline are dissolved
+	buffer = MustParseSVGPath("L10 10L10 0L0 10").Buffer(1.0, ButtCap, 0.01)
+	test.T(t, len(buffer.Split()), 2)
+	windings, _ := buffer.Windings(5.0, 5.0)
+	test.T(t, windings, 1, "crossing must be covered once")
+	windings, _ = buffer.Windings(10.0, 10.0)
+	test.T(t, windings, 1, "join must be covered once")
+	test.That(t, !buffer.Fills(8.0, 5.0, NonZero), "enclosed triangle must be a hole")
+
+	test.T(t, MustParseSVGPath("L10 0").Buffer(0.0, ButtCap, 0.01), &Path{})
+}
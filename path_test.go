@@ -1,13 +1,16 @@
 package canvas
 
 import (
+	"expvar"
 	"fmt"
+	"image"
 	"math"
 	"os"
 	"strings"
 	"testing"
 
 	"github.com/tdewolff/test"
+	"golang.org/x/image/vector"
 	"gonum.org/v1/plot"
 	"gonum.org/v1/plot/plotter"
 	"gonum.org/v1/plot/vg"
@@ -388,6 +391,26 @@ func TestPathBounds(t *testing.T) {
 	Epsilon = origEpsilon
 }
 
+func TestPathFastBounds(t *testing.T) {
+	var tts = []struct {
+		p      string
+		bounds Rect
+	}{
+		{"", Rect{}},
+		{"Q50 100 100 0", Rect{0, 0, 100, 100}},
+		{"C0 100 100 100 100 0", Rect{0, 0, 100, 100}},
+		{"C0 0 0 100 10 0", Rect{0, 0, 10, 100}},
+		{"C0 0 0 -100 -10 0", Rect{-10, -100, 10, 100}},
+	}
+	for _, tt := range tts {
+		t.Run(tt.p, func(t *testing.T) {
+			p := MustParseSVGPath(tt.p)
+			test.T(t, p.FastBounds(), tt.bounds)
+			test.T(t, p.FastBounds().Add(p.Bounds()), tt.bounds) // contains the exact bounds
+		})
+	}
+}
+
 // for quadratic Bézier use https://www.wolframalpha.com/input/?i=length+of+the+curve+%7Bx%3D2*(1-t)*t*50.00+%2B+t%5E2*100.00,+y%3D2*(1-t)*t*66.67+%2B+t%5E2*0.00%7D+from+0+to+1
 // for cubic Bézier use https://www.wolframalpha.com/input/?i=length+of+the+curve+%7Bx%3D3*(1-t)%5E2*t*0.00+%2B+3*(1-t)*t%5E2*100.00+%2B+t%5E3*100.00,+y%3D3*(1-t)%5E2*t*66.67+%2B+3*(1-t)*t%5E2*66.67+%2B+t%5E3*0.00%7D+from+0+to+1
 // for ellipse use https://www.wolframalpha.com/input/?i=length+of+the+curve+%7Bx%3D10.00*cos(t),+y%3D20.0*sin(t)%7D+from+0+to+pi
@@ -438,6 +461,212 @@ func TestPathTransform(t *testing.T) {
 	}
 }
 
+func TestPathInPlace(t *testing.T) {
+	p := MustParseSVGPath("L10 0Q15 10 20 0C23 10 27 10 30 0A10 10 0 0 0 50 0z")
+	q := p.Copy()
+	test.T(t, q.TranslateInPlace(0, 100), p.Translate(0, 100))
+	test.T(t, p.CopyTo(q).TransformInPlace(Identity.Rotate(120).Scale(1, -2)), p.Transform(Identity.Rotate(120).Scale(1, -2)))
+	test.T(t, p.CopyTo(q).ScaleInPlace(2, 3), p.Scale(2, 3))
+
+	d := q.Data()
+	p.Translate(5, 5).CopyTo(q)
+	test.That(t, &q.Data()[0] == &d[0], "backing array must be reused")
+	test.T(t, p.CopyTo(nil), p)
+
+	q.Reset()
+	test.That(t, q.Empty())
+	test.T(t, len(q.Data()), 0)
+
+	var pool PathPool
+	r := pool.Get()
+	test.That(t, r.Empty())
+	r.MoveTo(5, 5)
+	r.LineTo(10, 10)
+	pool.Put(r)
+	test.That(t, pool.Get().Empty())
+}
+
+func TestPathFlattenTo(t *testing.T) {
+	var tts = []struct {
+		orig string
+		flat string
+	}{
+		{"L10 0Q15 10 20 0C23 10 27 10 30 0z", "M0 0L10 0L16.6874 4.4305L20 0L25.9427 7.2574L29.7778 0.7169L30 0z"},
+		{"M10 0A10 10 0 0 1 -10 0A10 10 0 0 1 10 0zM5 0L0 5L-5 0z", "M10 0L5.9683 9.2176L-5.9683 9.2176L-10 0L-5.9683 -9.2176L5.9683 -9.2176zM5 0L0 5L-5 0z"},
+		{"A10 5 30 0 0 20 0L20 10C10 0 20 0 10 10", "M0 0L9.4647 7.1041L16.4952 8.75L21.8603 5.3579L20 0L20 10L16 5.065L14 5.065L10 10"},
+	}
+	origEpsilon := Epsilon
+	for _, tt := range tts {
+		t.Run(tt.orig, func(t *testing.T) {
+			p := MustParseSVGPath(tt.orig)
+			dst := MustParseSVGPath("M0 50L10 50")
+
+			Epsilon = 1e-3
+			test.T(t, p.FlattenTo(dst, 1.0), MustParseSVGPath("M0 50L10 50"+tt.flat))
+			test.T(t, p.FlattenTo(nil, 1.0), MustParseSVGPath(tt.flat))
+		})
+	}
+	Epsilon = origEpsilon
+
+	p := MustParseSVGPath(tts[1].orig)
+	dst := p.FlattenTo(nil, 0.01)
+	allocs := testing.AllocsPerRun(10, func() {
+		dst.Reset()
+		p.FlattenTo(dst, 0.01)
+	})
+	test.T(t, allocs, 0.0)
+}
+
+func TestFlattenCache(t *testing.T) {
+	c := NewFlattenCache(2)
+	p := MustParseSVGPath("M10 0A10 10 0 0 1 -10 0A10 10 0 0 1 10 0z")
+	flat := c.Flatten(p, 0.01)
+	test.T(t, flat, p.Flatten(0.01))
+	test.That(t, c.Flatten(p, 0.01) == flat, "must return cached path")
+	test.That(t, c.Flatten(p, 0.1) != flat, "must flatten for other tolerance")
+	test.T(t, c.Len(), 2)
+
+	p.QuadTo(0, 10, 10, 10) // modification invalidates entry
+	test.T(t, c.Flatten(p, 0.01), p.Flatten(0.01))
+	test.T(t, c.Len(), 2)
+
+	flat = c.Flatten(p, 0.01)
+	p.TranslateInPlace(5.0, 0.0) // in-place transformation invalidates entry
+	test.That(t, c.Flatten(p, 0.01) != flat, "must flatten modified path")
+	test.T(t, c.Flatten(p, 0.01), p.Flatten(0.01))
+
+	flat = c.Flatten(p, 0.01)
+	p.Split()[0].TranslateInPlace(5.0, 0.0) // subpaths do not share the data of p
+	test.That(t, c.Flatten(p, 0.01) == flat, "must return cached path")
+	test.T(t, flat, p.Flatten(0.01))
+
+	dst := MustParseSVGPath("M0 20Q10 30 20 20")
+	c.Flatten(dst, 0.01)
+	MustParseSVGPath("M30 20L40 20").FlattenTo(dst, 0.01) // appending invalidates entry
+	test.T(t, c.Flatten(dst, 0.01), dst.Flatten(0.01))
+
+	q := MustParseSVGPath("Q10 10 20 0")
+	c.Flatten(q, 0.01) // evicts oldest entry
+	test.T(t, c.Len(), 2)
+
+	line := MustParseSVGPath("L10 0L10 10z")
+	test.That(t, c.Flatten(line, 0.01) == line, "flat path must be returned as is")
+
+	c.Reset()
+	test.T(t, c.Len(), 0)
+}
+
+func TestPathPack(t *testing.T) {
+	p := MustParseSVGPath("M10 0A10 10 0 0 1 -10 0L0 -5L5 -5zM20 0Q25 5 30 0C30 5 20 5 20 0z")
+	test.T(t, p.Pack().Unpack(), p)
+	test.That(t, (&Path{}).Pack().Empty())
+
+	flat := p.Flatten(0.01)
+	pp := flat.Pack()
+	test.T(t, pp.Unpack(), flat)
+	test.That(t, pp.Size() < len(flat.d)*6/10, "flattened path must take about half the memory")
+
+	n := 0
+	pp.Walk(func(cmd float64, start, end Point, values []float64) {
+		n++
+	})
+	test.T(t, n, flat.Len())
+
+	// rasterizing packed paths
+	for _, q := range []*Path{flat, p} {
+		ras := vector.NewRasterizer(40, 20)
+		q.Pack().ToRasterizer(ras, DPMM(1.0))
+		a := image.NewAlpha(ras.Bounds())
+		ras.Draw(a, a.Bounds(), image.Opaque, image.Point{})
+
+		ras.Reset(40, 20)
+		q.ToRasterizer(ras, DPMM(1.0))
+		b := image.NewAlpha(ras.Bounds())
+		ras.Draw(b, b.Bounds(), image.Opaque, image.Point{})
+		test.T(t, a.Pix, b.Pix)
+	}
+}
+
+func TestChunkedPath(t *testing.T) {
+	cp := NewChunkedPath(16)
+	cp.AppendPath(MustParseSVGPath("L10 0L10 10L0 10zM2 2L8 2L8 8L2 8z"))
+	cp.MoveTo(20, 0)
+	cp.LineTo(30, 0)
+	cp.QuadTo(30, 10, 20, 10)
+	cp.Close()
+	cp.MoveTo(25, 5)
+	cp.ArcTo(5, 5, 0, false, true, 35, 5)
+	cp.Close()
+	test.T(t, len(cp.Chunks()), 4) // every subpath exceeds the chunk size
+	test.T(t, cp.Len(), 17)
+	test.T(t, cp.FastBounds(), Rect{0, 0, 35, 10})
+
+	p := cp.Path()
+	test.T(t, cp.Flatten(0.1).Path(), p.Flatten(0.1))
+
+	settled := cp.Settle(NonZero)
+	test.T(t, len(settled.Chunks()), 2) // the first two and last two chunks overlap
+	test.T(t, settled.Chunks()[0], p.Split()[0].Append(p.Split()[1]).Settle(NonZero))
+	test.T(t, NewChunkedPath(0).Empty(), true)
+
+	cp = NewChunkedPath(0)
+	cp.AppendPath(MustParseSVGPath("L10 0L10 10z"))
+	gen := cp.Chunks()[0].generation()
+	cp.AppendPath(MustParseSVGPath("M20 0L30 0"))
+	test.That(t, cp.Chunks()[0].generation() != gen, "appending must invalidate cached results")
+}
+
+func TestTransformedPath(t *testing.T) {
+	p := MustParseSVGPath("M10 0A10 10 0 0 1 -10 0L0 -5z")
+	m := Identity.Translate(20, 20).Rotate(30).Scale(2, 1)
+	tp := NewTransformedPath(p, Identity).Transform(Identity.Scale(2, 1)).Transform(Identity.Rotate(30)).Translate(20, 20)
+	test.T(t, tp.Source(), p)
+	test.T(t, tp.Matrix(), m)
+	test.T(t, tp.Path(), p.Transform(m))
+	test.T(t, tp.FastBounds(), p.FastBounds().Transform(m))
+
+	ras := vector.NewRasterizer(50, 50)
+	tp.ToRasterizer(ras, DPMM(1.0))
+	a := image.NewAlpha(ras.Bounds())
+	ras.Draw(a, a.Bounds(), image.Opaque, image.Point{})
+
+	ras.Reset(50, 50)
+	p.Transform(m).ToRasterizer(ras, DPMM(1.0))
+	b := image.NewAlpha(ras.Bounds())
+	ras.Draw(b, b.Bounds(), image.Opaque, image.Point{})
+	for i := range a.Pix {
+		test.That(t, math.Abs(float64(a.Pix[i])-float64(b.Pix[i])) < 64.0, "coverage must match that of the transformed path within flattening tolerance")
+	}
+}
+
+func TestTracing(t *testing.T) {
+	SetTracing(true)
+	defer SetTracing(false)
+	test.That(t, Tracing())
+
+	calls := func() int64 {
+		if v, ok := traceMetrics.Get("flatten.calls").(*expvar.Int); ok {
+			return v.Value()
+		}
+		return 0
+	}
+	n := calls()
+	MustParseSVGPath("Q10 10 20 0z").Flatten(0.1)
+	test.T(t, calls(), n+1)
+	test.That(t, traceMetrics.Get("flatten.ns") != nil)
+
+	SetTracing(false)
+	MustParseSVGPath("Q10 10 20 0z").Flatten(0.1)
+	test.T(t, calls(), n+1)
+
+	// names that are already published do not panic
+	m := expvar.NewMap("canvas-test-map")
+	test.That(t, publishedMap("canvas-test-map") == m)
+	expvar.NewString("canvas-test-string")
+	test.That(t, publishedMap("canvas-test-string") != nil)
+	test.That(t, publishedMap("canvas-test-new") == expvar.Get("canvas-test-new"))
+}
+
 func TestPathReplace(t *testing.T) {
 	line := func(p0, p1 Point) *Path {
 		p := &Path{}
@@ -587,7 +816,7 @@ func TestPathSplit(t *testing.T) {
 		})
 	}
 
-	ps := (&Path{[]float64{MoveToCmd, 5.0, 5.0, MoveToCmd, MoveToCmd, 10.0, 10.0, MoveToCmd, CloseCmd, 10.0, 10.0, CloseCmd}}).Split()
+	ps := (&Path{d: []float64{MoveToCmd, 5.0, 5.0, MoveToCmd, MoveToCmd, 10.0, 10.0, MoveToCmd, CloseCmd, 10.0, 10.0, CloseCmd}}).Split()
 	test.T(t, ps[0].String(), "M5 5")
 	test.T(t, ps[1].String(), "M10 10z")
 }
@@ -607,7 +836,8 @@ func TestPathSplitAt(t *testing.T) {
 		{"C0 10 20 10 20 0", []float64{13.947108}, []string{"C0 5 5 7.5 10 7.5", "M10 7.5C15 7.5 20 5 20 0"}},
 		{"A10 10 0 0 1 -20 0", []float64{15.707963}, []string{"A10 10 0 0 1 -10 10", "M-10 10A10 10 0 0 1 -20 0"}},
 		{"A10 10 0 0 0 20 0", []float64{15.707963}, []string{"A10 10 0 0 0 10 10", "M10 10A10 10 0 0 0 20 0"}},
-		{"A10 10 0 1 0 2.9289 -7.0711", []float64{15.707963}, []string{"A10 10 0 0 0 10.024 9.9999", "M10.024 9.9999A10 10 0 1 0 2.9289 -7.0711"}},
+		{"A10 10 0 1 0 2.9289 -7.0711", []float64{15.707963}, []string{"A10 10 0 0 0 10 10", "M10 10A10 10 0 1 0 2.9289 -7.0711"}},
+		{"L10 0M0 10L10 10", []float64{15.0}, []string{"L10 0M0 10L5 10", "M5 10L10 10"}},
 	}
 	origEpsilon := Epsilon
 	for _, tt := range tts {
@@ -632,6 +862,50 @@ func TestPathSplitAt(t *testing.T) {
 	Epsilon = origEpsilon
 }
 
+func TestPathSplitAtEllipse(t *testing.T) {
+	// pieces of long and thin elliptical arcs have the same length as used by Length
+	for _, s := range []string{"A10 20 0 0 0 20 0", "A100 1 0 0 1 200 0", "A50 2 30 1 1 20 0"} {
+		t.Run(s, func(t *testing.T) {
+			p := MustParseSVGPath(s)
+			length := p.Length()
+			ps := p.SplitAt(length/4.0, length/2.0, 3.0*length/4.0)
+			test.T(t, len(ps), 4)
+			for _, q := range ps {
+				test.FloatDiff(t, q.Length(), length/4.0, 1e-3*length)
+			}
+		})
+	}
+
+	p := MustParseSVGPath("A100 1 0 0 1 200 0")
+	test.FloatDiff(t, p.LengthTolerance(1e-9), 200.0549164862, 1e-9)
+}
+
+func TestPathSplitAtParams(t *testing.T) {
+	var tts = []struct {
+		p  string
+		ts []float64
+		rs []string
+	}{
+		{"L10 0L10 10z", []float64{}, []string{"L10 0L10 10z"}},
+		{"L10 0L10 10z", []float64{0.5, 2.0}, []string{"L10 0", "M10 0L10 10L0 0"}},
+		{"L10 0L10 10z", []float64{3.5, 1.5}, []string{"L5 0", "M5 0L10 0L10 10L5 5", "M5 5L0 0"}},
+		{"C0 10 20 10 20 0", []float64{1.5}, []string{"C0 5 5 7.5 10 7.5", "M10 7.5C15 7.5 20 5 20 0"}},
+		{"A10 10 0 0 0 20 0", []float64{1.5}, []string{"A10 10 0 0 0 10 10", "M10 10A10 10 0 0 0 20 0"}},
+		{"L10 0M0 10L10 10", []float64{1.5, 3.5}, []string{"L5 0", "M5 0L10 0M0 10L5 10", "M5 10L10 10"}},
+	}
+	for _, tt := range tts {
+		t.Run(tt.p, func(t *testing.T) {
+			ps := MustParseSVGPath(tt.p).SplitAtParams(tt.ts...)
+			test.T(t, len(ps), len(tt.rs))
+			for i := range ps {
+				if i < len(tt.rs) {
+					test.T(t, ps[i], MustParseSVGPath(tt.rs[i]))
+				}
+			}
+		})
+	}
+}
+
 func TestDashCanonical(t *testing.T) {
 	var tts = []struct {
 		origOffset float64
@@ -706,6 +980,27 @@ func TestPathDash(t *testing.T) {
 	}
 }
 
+func TestPathDashFit(t *testing.T) {
+	var tts = []struct {
+		p      string
+		offset float64
+		d      []float64
+		dashes string
+	}{
+		{"", 0.0, []float64{0.0}, ""},
+		{"L10 0", 0.0, []float64{}, "L10 0"},
+		{"L10 0", 0.0, []float64{3.0, 2.0}, "L3.75 0M6.25 0L10 0"},
+		{"L10 0", 0.0, []float64{2.0}, "L2 0M4 0L6 0M8 0L10 0"},
+		{"L10 0L10 10L0 10z", 0.0, []float64{5.7, 3.8}, "L6 0M10 0L10 6M10 10L4 10M0 10L0 4"},
+		{"L10 0M0 10L16.2 10", 0.0, []float64{3.0, 2.0}, "L3.75 0M6.25 0L10 0M0 10L2.7 10M4.5 10L7.2 10M9 10L11.7 10M13.5 10L16.2 10"},
+	}
+	for _, tt := range tts {
+		t.Run(tt.p, func(t *testing.T) {
+			test.T(t, MustParseSVGPath(tt.p).DashFit(tt.offset, tt.d...), MustParseSVGPath(tt.dashes))
+		})
+	}
+}
+
 func TestPathReverse(t *testing.T) {
 	var tts = []struct {
 		p string
@@ -819,6 +1114,48 @@ func TestPathToSVG(t *testing.T) {
 	}
 }
 
+func TestPathToSVGFormat(t *testing.T) {
+	var tts = []struct {
+		p      string
+		format SVGPathFormat
+		svg    string
+	}{
+		{"M0.123 1.456L2.999 3", SVGPathFormat{Decimals: 2}, "M.12 1.46L3 3"},
+		{"M10 10L11 12L11 20", SVGPathFormat{Relative: true}, "M10 10l1 2v8"},
+		{"M-30.6 -48.9L27.4 21.1Q30 25 35 20", SVGPathFormat{Relative: true}, "M-30.6 -48.9l58 70Q30 25 35 20"},
+		{"M0 0C0 10 10 10 10 0C10 -10 20 -10 20 0", SVGPathFormat{Shorthand: true}, "M0 0C0 10 10 10 10 0S20 -10 20 0"},
+		{"M0 0Q5 10 10 0Q15 -10 20 0", SVGPathFormat{Shorthand: true}, "M0 0Q5 10 10 0T20 0"},
+		{"M0 0L10 -5L20 -5L30 0.5L40.5 0.25", SVGPathFormat{Compact: true}, "M0 0 10-5H20L30 .5 40.5.25"},
+	}
+	for _, tt := range tts {
+		t.Run(tt.p, func(t *testing.T) {
+			p := MustParseSVGPath(tt.p)
+			test.T(t, p.ToSVGFormat(tt.format), tt.svg)
+		})
+	}
+}
+
+func TestPathToSVGFormatRoundTrip(t *testing.T) {
+	p := MustParseSVGPath("M-30.6 -48.9L27.4 21.1L30 25L30 35L20 35Q25 40 30 45T40 45C45 50 50 40 55 45S65 50 70 45A10 20 30 0 1 80 50zM-10 -20L-5 -40z")
+	origEpsilon := Epsilon
+	Epsilon = 1e-6
+	defer func() { Epsilon = origEpsilon }()
+	for _, relative := range []bool{false, true} {
+		for _, shorthand := range []bool{false, true} {
+			for _, compact := range []bool{false, true} {
+				for _, decimals := range []int{0, 1} {
+					format := SVGPathFormat{Decimals: decimals, Relative: relative, Shorthand: shorthand, Compact: compact}
+					t.Run(fmt.Sprintf("%+v", format), func(t *testing.T) {
+						q, err := ParseSVGPath(p.ToSVGFormat(format))
+						test.Error(t, err)
+						test.T(t, q, p)
+					})
+				}
+			}
+		}
+	}
+}
+
 func TestPathToPS(t *testing.T) {
 	var tts = []struct {
 		p  string
@@ -944,7 +1281,7 @@ func TestPathLengthParametrization(t *testing.T) {
 		return ellipseDeriv(rx, ry, phi, sweep, theta).Length()
 	}
 	length = func(theta float64) float64 {
-		return ellipseLength(rx, ry, theta1, theta)
+		return ellipseLength(rx, ry, theta1, theta, Tolerance)
 	}
 	plotPathLengthParametrization("test/len_param_ellipse.png", 20, speed, length, theta1, theta2)
 }
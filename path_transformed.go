@@ -0,0 +1,66 @@
+package canvas
+
+import (
+	"math"
+
+	"golang.org/x/image/vector"
+)
+
+// TransformedPath is a view of a path under a transformation that is composed lazily: the coordinates are only computed when the path is materialized or rasterized. This avoids copying the path's data when drawing the same large path under many transformations, such as for tiles or symbols. The underlying path must not be modified while it is in use by a view.
+type TransformedPath struct {
+	p *Path
+	m Matrix
+}
+
+// NewTransformedPath returns a view of path p transformed by m.
+func NewTransformedPath(p *Path, m Matrix) TransformedPath {
+	return TransformedPath{p, m}
+}
+
+// Source returns the underlying, untransformed path.
+func (tp TransformedPath) Source() *Path {
+	return tp.p
+}
+
+// Matrix returns the transformation of the view.
+func (tp TransformedPath) Matrix() Matrix {
+	return tp.m
+}
+
+// Transform returns a view with the transformation m applied after the current transformation, without touching the path.
+func (tp TransformedPath) Transform(m Matrix) TransformedPath {
+	return TransformedPath{tp.p, m.Mul(tp.m)}
+}
+
+// Translate returns a view translated by (x,y), without touching the path.
+func (tp TransformedPath) Translate(x, y float64) TransformedPath {
+	return tp.Transform(Identity.Translate(x, y))
+}
+
+// Empty returns true if the path is empty or consists of only MoveTo commands.
+func (tp TransformedPath) Empty() bool {
+	return tp.p.Empty()
+}
+
+// FastBounds returns a bounding box of the transformed path, which is the transformed maximum bounding box of the path and may thus be larger than the exact bounds.
+func (tp TransformedPath) FastBounds() Rect {
+	return tp.p.FastBounds().Transform(tp.m)
+}
+
+// Path returns a new path with the transformation applied.
+func (tp TransformedPath) Path() *Path {
+	return tp.p.Transform(tp.m)
+}
+
+// ToRasterizer rasterizes the transformed path using the given rasterizer and resolution. The path is flattened in its own coordinates, using the DefaultFlattenCache if set, so that the flattened path is shared between all transformations of the same path, and the transformation is applied to the vertices while rasterizing.
+func (tp TransformedPath) ToRasterizer(ras *vector.Rasterizer, resolution Resolution) {
+	defer traceRegion("rasterize")()
+
+	dpmm := resolution.DPMM()
+	_, _, _, sx, sy, _ := tp.m.Decompose()
+	scale := math.Max(math.Abs(sx), math.Abs(sy))
+	if scale == 0.0 {
+		return
+	}
+	flatten(tp.p, PixelTolerance/dpmm/scale).rasterize(ras, tp.m, dpmm)
+}
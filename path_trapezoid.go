@@ -0,0 +1,156 @@
+package canvas
+
+import (
+	"math"
+	"sort"
+)
+
+// Trapezoid is a quadrilateral with a horizontal bottom and top edge. Filled paths are decomposed into trapezoids that are y-monotone and do not overlap, which can be rasterized or tessellated directly.
+type Trapezoid struct {
+	Y0, Y1 float64 // bottom and top
+	L0, R0 float64 // left and right at the bottom
+	L1, R1 float64 // left and right at the top
+}
+
+// Area returns the area of the trapezoid.
+func (t Trapezoid) Area() float64 {
+	return 0.5 * ((t.R0 - t.L0) + (t.R1 - t.L1)) * (t.Y1 - t.Y0)
+}
+
+// AppendTrapezoids appends the trapezoids as counter clock-wise oriented subpaths to p and returns p. This converts a decomposition back into a path, for example into a path obtained from a PathPool.
+func (p *Path) AppendTrapezoids(trs []Trapezoid) *Path {
+	for _, t := range trs {
+		p.MoveTo(t.L0, t.Y0)
+		p.LineTo(t.R0, t.Y0)
+		p.LineTo(t.R1, t.Y1)
+		p.LineTo(t.L1, t.Y1)
+		p.Close()
+	}
+	return p
+}
+
+type trapezoidEdge struct {
+	x0, y0, x1, y1 float64 // y0 < y1
+	winding        int
+}
+
+func (e trapezoidEdge) x(y float64) float64 {
+	return e.x0 + (e.x1-e.x0)*(y-e.y0)/(e.y1-e.y0)
+}
+
+// Trapezoids decomposes the area filled by the path into trapezoids, using the given fill rule. The path is flattened with the given tolerance and all subpaths are considered closed. The plane is swept from bottom to top in bands between the vertices and the intersections of the edges, and each band is split into the trapezoids between the edges where the fill rule changes. The trapezoids are ordered by their bottom and then by their left edge.
+func (p *Path) Trapezoids(fillRule FillRule, tolerance float64) []Trapezoid {
+	if p.Empty() {
+		return nil
+	}
+
+	edges := []trapezoidEdge{}
+	ys := []float64{}
+	addEdge := func(a, b Point) {
+		if a.Y == b.Y {
+			return // horizontal edges do not change the winding
+		} else if a.Y < b.Y {
+			edges = append(edges, trapezoidEdge{a.X, a.Y, b.X, b.Y, -1})
+		} else {
+			edges = append(edges, trapezoidEdge{b.X, b.Y, a.X, a.Y, 1})
+		}
+		ys = append(ys, a.Y, b.Y)
+	}
+
+	var start, end Point
+	closed := true
+	for scanner := flatten(p, tolerance).Scanner(); scanner.Scan(); {
+		switch scanner.Cmd() {
+		case MoveToCmd:
+			if !closed {
+				addEdge(end, start)
+			}
+			start = scanner.End()
+			closed = true
+		case LineToCmd, CloseCmd:
+			addEdge(scanner.Start(), scanner.End())
+			closed = scanner.Cmd() == CloseCmd
+		}
+		end = scanner.End()
+	}
+	if !closed {
+		addEdge(end, start)
+	}
+	if len(edges) == 0 {
+		return nil
+	}
+
+	sort.Float64s(ys)
+	sort.Slice(edges, func(i, j int) bool {
+		return edges[i].y0 < edges[j].y0
+	})
+
+	trs := []Trapezoid{}
+	active := []trapezoidEdge{}
+	next := 0
+	for i := 0; i+1 < len(ys); i++ {
+		ya, yb := ys[i], ys[i+1]
+		if ya == yb {
+			continue
+		}
+
+		// update the edges that span the band, since all vertices are band boundaries no edge starts or ends within a band
+		n := 0
+		for _, e := range active {
+			if yb <= e.y1 {
+				active[n] = e
+				n++
+			}
+		}
+		active = active[:n]
+		for next < len(edges) && edges[next].y0 <= ya {
+			if yb <= edges[next].y1 {
+				active = append(active, edges[next])
+			}
+			next++
+		}
+
+		for y0 := ya; y0 < yb; {
+			sort.Slice(active, func(i, j int) bool {
+				xi, xj := active[i].x(y0), active[j].x(y0)
+				if xi != xj {
+					return xi < xj
+				}
+				return active[i].x(yb) < active[j].x(yb)
+			})
+
+			// edges only cross between neighbours, split the band at the lowest crossing
+			y1 := yb
+			for j := 0; j+1 < len(active); j++ {
+				a, b := active[j], active[j+1]
+				da, db := a.x(y1)-b.x(y1), a.x(y0)-b.x(y0)
+				if Epsilon < da && db < da {
+					if y := y0 + (y1-y0)*-db/(da-db); y0+Epsilon < y {
+						y1 = math.Min(y, y1)
+					}
+				}
+			}
+
+			windings := 0
+			var left trapezoidEdge
+			for _, e := range active {
+				filled := fillRule.Fills(windings)
+				windings += e.winding
+				if !filled && fillRule.Fills(windings) {
+					left = e
+				} else if filled && !fillRule.Fills(windings) {
+					trs = append(trs, Trapezoid{
+						Y0: y0,
+						Y1: y1,
+						L0: left.x(y0),
+						R0: e.x(y0),
+						L1: left.x(y1),
+						R1: e.x(y1),
+					})
+				}
+			}
+			y0 = y1
+		}
+	}
+	return trs
+}
@@ -0,0 +1,76 @@
+package canvas
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestPathTrapezoids(t *testing.T) {
+	var tts = []struct {
+		p        string
+		fillRule FillRule
+		trs      []Trapezoid
+	}{
+		{"L10 0L10 10L0 10z", NonZero, []Trapezoid{{0, 10, 0, 10, 0, 10}}},
+		{"L10 0L10 10L0 10", NonZero, []Trapezoid{{0, 10, 0, 10, 0, 10}}},
+		{"L10 0L5 10z", NonZero, []Trapezoid{{0, 10, 0, 10, 5, 5}}},
+		{"L10 0L10 10L0 10zM2 2L8 2L8 8L2 8z", NonZero, []Trapezoid{
+			{0, 2, 0, 10, 0, 10},
+			{2, 8, 0, 10, 0, 10},
+			{8, 10, 0, 10, 0, 10},
+		}},
+		{"L10 0L10 10L0 10zM2 2L8 2L8 8L2 8z", EvenOdd, []Trapezoid{
+			{0, 2, 0, 10, 0, 10},
+			{2, 8, 0, 2, 0, 2},
+			{2, 8, 8, 10, 8, 10},
+			{8, 10, 0, 10, 0, 10},
+		}},
+		{"L10 0L10 10L0 10zM2 2L8 2L8 8L2 8z", Negative, []Trapezoid{}},
+		{"L10 10L10 0L0 10z", NonZero, []Trapezoid{ // bow tie
+			{0, 5, 0, 0, 0, 5},
+			{0, 5, 10, 10, 5, 10},
+			{5, 10, 0, 5, 0, 0},
+			{5, 10, 5, 10, 10, 10},
+		}},
+	}
+	for _, tt := range tts {
+		t.Run(tt.p, func(t *testing.T) {
+			p := MustParseSVGPath(tt.p)
+			test.T(t, p.Trapezoids(tt.fillRule, 0.01), tt.trs)
+		})
+	}
+
+	area := 0.0
+	for _, tr := range Circle(10.0).Trapezoids(NonZero, 0.01) {
+		area += tr.Area()
+	}
+	test.That(t, math.Abs(area-math.Pi*100.0) < 1.0, "area of decomposed circle must match")
+
+	test.T(t, (&Path{}).Trapezoids(NonZero, 0.01), []Trapezoid(nil))
+	test.T(t, (&Path{}).AppendTrapezoids([]Trapezoid{{0, 10, 0, 10, 5, 5}}), MustParseSVGPath("L10 0L5 10L5 10z"))
+}
+
+func TestTrapezoidCache(t *testing.T) {
+	c := NewTrapezoidCache(2)
+	p := Circle(10.0)
+	trs := c.Trapezoids(p, NonZero, 0.01)
+	test.T(t, trs, p.Trapezoids(NonZero, 0.01))
+	test.That(t, &c.Trapezoids(p, NonZero, 0.01)[0] == &trs[0], "must return cached decomposition")
+	test.That(t, &c.Trapezoids(p, EvenOdd, 0.01)[0] != &trs[0], "must decompose for other fill rule")
+	test.T(t, c.Len(), 2)
+
+	p.MoveTo(20.0, 0.0)
+	p.LineTo(30.0, 0.0)
+	p.LineTo(30.0, 10.0)
+	p.Close() // modification invalidates entry
+	test.T(t, c.Trapezoids(p, NonZero, 0.01), p.Trapezoids(NonZero, 0.01))
+	test.T(t, c.Len(), 2)
+
+	c.Trapezoids(Rectangle(5.0, 5.0), NonZero, 0.01) // evicts oldest entry
+	test.T(t, c.Len(), 2)
+
+	c.Reset()
+	test.T(t, c.Len(), 0)
+}
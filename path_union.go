@@ -0,0 +1,233 @@
+package canvas
+
+import (
+	"slices"
+	"sort"
+)
+
+// IncrementalUnion maintains the union of a set of paths that can be inserted, removed and updated one at a time. Operands are grouped into clusters of operands with overlapping bounding boxes, and the union of every cluster is cached. Inserting an operand only merges it with the cached unions of the clusters it overlaps, and removing an operand only recomputes the union of its own cluster, so that moving one shape in a large scene does not recompute the union of all shapes.
+type IncrementalUnion struct {
+	operands map[int]*unionOperand
+	clusters map[*unionCluster]bool
+	nextID   int
+	result   *Path
+}
+
+type unionOperand struct {
+	p       *Path
+	box     bbox
+	cluster *unionCluster
+}
+
+type unionCluster struct {
+	ids   []int
+	box   bbox
+	union *Path
+}
+
+// NewIncrementalUnion returns an empty incremental union.
+func NewIncrementalUnion() *IncrementalUnion {
+	return &IncrementalUnion{
+		operands: map[int]*unionOperand{},
+		clusters: map[*unionCluster]bool{},
+	}
+}
+
+func pathBBox(p *Path) bbox {
+	r := p.FastBounds()
+	return bbox{r.X, r.Y, r.X + r.W, r.Y + r.H}
+}
+
+// clusterBBoxes groups the boxes into clusters of boxes that overlap directly or through other boxes, using a sweep along X and a union-find. It returns the indices of the boxes of each cluster, both the clusters and the indices within them are ordered by the left edge of the boxes.
+func clusterBBoxes(boxes []bbox) [][]int {
+	order := make([]int, len(boxes))
+	parent := make([]int, len(boxes))
+	for i := range boxes {
+		order[i] = i
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return boxes[order[i]].x0 < boxes[order[j]].x0
+	})
+	active := []int{}
+	for _, i := range order {
+		k := 0
+		for _, j := range active {
+			if boxes[i].x0 <= boxes[j].x1+Epsilon {
+				if boxes[i].overlaps(boxes[j]) {
+					parent[find(i)] = find(j)
+				}
+				active[k] = j
+				k++
+			}
+		}
+		active = append(active[:k], i)
+	}
+
+	clusters := [][]int{}
+	cluster := map[int]int{} // root to index in clusters
+	for _, i := range order {
+		root := find(i)
+		c, ok := cluster[root]
+		if !ok {
+			c = len(clusters)
+			cluster[root] = c
+			clusters = append(clusters, nil)
+		}
+		clusters[c] = append(clusters[c], i)
+	}
+	return clusters
+}
+
+// Len returns the number of operands.
+func (u *IncrementalUnion) Len() int {
+	return len(u.operands)
+}
+
+// Clusters returns the number of clusters of overlapping operands.
+func (u *IncrementalUnion) Clusters() int {
+	return len(u.clusters)
+}
+
+// Insert adds a path to the union and returns its identifier. The path is implicitly closed and filled using the NonZero fill rule.
+func (u *IncrementalUnion) Insert(p *Path) int {
+	id := u.nextID
+	u.nextID++
+	u.insert(id, p)
+	return id
+}
+
+func (u *IncrementalUnion) insert(id int, p *Path) {
+	op := &unionOperand{p: p, box: pathBBox(p)}
+	u.operands[id] = op
+	u.result = nil
+	if p.Empty() {
+		return
+	}
+
+	// merge all overlapping clusters, reusing their unions
+	cluster := &unionCluster{ids: []int{id}, box: op.box, union: p.Settle(NonZero)}
+	for _, c := range u.sortedClusters() {
+		if c.box.overlaps(op.box) {
+			delete(u.clusters, c)
+			cluster.ids = append(cluster.ids, c.ids...)
+			cluster.box = cluster.box.add(c.box)
+			cluster.union = c.union.Or(cluster.union)
+		}
+	}
+	for _, i := range cluster.ids {
+		u.operands[i].cluster = cluster
+	}
+	u.clusters[cluster] = true
+}
+
+// Remove removes the path with the given identifier from the union, and returns false if it does not exist.
+func (u *IncrementalUnion) Remove(id int) bool {
+	op, ok := u.operands[id]
+	if !ok {
+		return false
+	}
+	delete(u.operands, id)
+	u.result = nil
+	if op.cluster == nil {
+		return true
+	}
+
+	// recompute the cluster, which may fall apart into several clusters
+	delete(u.clusters, op.cluster)
+	ids := op.cluster.ids[:0:0]
+	for _, i := range op.cluster.ids {
+		if i != id {
+			ids = append(ids, i)
+		}
+	}
+	sort.Ints(ids)
+	for _, i := range ids {
+		other := u.operands[i]
+		other.cluster = nil
+		u.insert(i, other.p)
+	}
+	return true
+}
+
+// Update replaces the path with the given identifier, such as after it has moved, and returns false if it does not exist.
+func (u *IncrementalUnion) Update(id int, p *Path) bool {
+	if !u.Remove(id) {
+		return false
+	}
+	u.insert(id, p)
+	return true
+}
+
+// Path returns the path with the given identifier.
+func (u *IncrementalUnion) Path(id int) (*Path, bool) {
+	op, ok := u.operands[id]
+	if !ok {
+		return nil, false
+	}
+	return op.p, true
+}
+
+// Result returns the union of all operands.
+func (u *IncrementalUnion) Result() *Path {
+	if u.result == nil {
+		u.result = &Path{}
+		for _, c := range u.sortedClusters() {
+			u.result = u.result.Append(c.union)
+		}
+	}
+	return u.result.Copy()
+}
+
+// sortedClusters returns the clusters in a deterministic order, sorted by their lowest operand identifier.
+func (u *IncrementalUnion) sortedClusters() []*unionCluster {
+	clusters := make([]*unionCluster, 0, len(u.clusters))
+	for c := range u.clusters {
+		clusters = append(clusters, c)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		return slices.Min(clusters[i].ids) < slices.Min(clusters[j].ids)
+	})
+	return clusters
+}
+
+// UnionAll returns the union of all paths, which are implicitly closed and filled using the NonZero fill rule. This is much faster than folding the paths with Or when there are many paths, since operands are grouped into clusters with overlapping bounding boxes and the operands of each cluster are merged pairwise in a balanced tree, so that each segment takes part in a logarithmic number of boolean operations instead of a linear number.
+func UnionAll(ps ...*Path) *Path {
+	operands := []*Path{}
+	boxes := []bbox{}
+	for _, p := range ps {
+		if !p.Empty() {
+			operands = append(operands, p)
+			boxes = append(boxes, pathBBox(p))
+		}
+	}
+
+	// merge the operands of each cluster pairwise, in order along X to keep merged operands small
+	r := &Path{}
+	for _, indices := range clusterBBoxes(boxes) {
+		cluster := make([]*Path, len(indices))
+		for i, index := range indices {
+			cluster[i] = operands[index].Settle(NonZero)
+		}
+		for 1 < len(cluster) {
+			merged := cluster[:0]
+			for i := 0; i < len(cluster); i += 2 {
+				if i+1 < len(cluster) {
+					merged = append(merged, cluster[i].Or(cluster[i+1]))
+				} else {
+					merged = append(merged, cluster[i])
+				}
+			}
+			cluster = merged
+		}
+		r = r.Append(cluster[0])
+	}
+	return r
+}
@@ -0,0 +1,16 @@
+package canvas
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestUnionAll(t *testing.T) {
+	test.T(t, UnionAll(), &Path{})
+
+	p := MustParseSVGPath("L10 0L5 10z")
+	q := MustParseSVGPath("M0 5L10 5L5 15z")
+	r := MustParseSVGPath("M20 0L22 0L22 2L20 2z")
+	test.T(t, UnionAll(r, p, q), MustParseSVGPath("M7.5 5L10 5L5 15L0 5L2.5 5L0 0L10 0zM20 0L22 0L22 2L20 2z"))
+}
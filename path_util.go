@@ -50,15 +50,41 @@ func ellipseNormal(rx, ry, phi float64, sweep bool, theta, d float64) Point {
 }
 
 // ellipseLength calculates the length of the elliptical arc
-// it uses Gauss-Legendre (n=5) and has an error of ~1% or less (empirical)
-func ellipseLength(rx, ry, theta1, theta2 float64) float64 {
+// it uses adaptive Gauss-Legendre quadrature (n=7) until the error is below tolerance, circular arcs are exact
+func ellipseLength(rx, ry, theta1, theta2, tolerance float64) float64 {
 	if theta2 < theta1 {
 		theta1, theta2 = theta2, theta1
 	}
+	if rx == ry {
+		return rx * (theta2 - theta1)
+	}
 	speed := func(theta float64) float64 {
 		return ellipseDeriv(rx, ry, 0.0, true, theta).Length()
 	}
-	return gaussLegendre5(speed, theta1, theta2)
+	return gaussLegendreAdaptive(speed, theta1, theta2, tolerance)
+}
+
+// ellipseLengthTheta returns the angle between theta1 and theta2 at which the length of the elliptical arc from theta1 equals l, using bisection until the length is within tolerance, circular arcs are exact
+func ellipseLengthTheta(rx, ry, theta1, theta2, l, tolerance float64) float64 {
+	if rx == ry {
+		if theta2 < theta1 {
+			return theta1 - l/rx
+		}
+		return theta1 + l/rx
+	}
+	lo, hi := theta1, theta2
+	for i := 0; i < 64; i++ {
+		theta := (lo + hi) / 2.0
+		d := ellipseLength(rx, ry, theta1, theta, tolerance) - l
+		if math.Abs(d) <= tolerance {
+			return theta
+		} else if d < 0.0 {
+			lo = theta
+		} else {
+			hi = theta
+		}
+	}
+	return (lo + hi) / 2.0
 }
 
 // ellipseToCenter converts to the center arc format and returns (centerX, centerY, angleFrom, angleTo) with angles in radians. When angleFrom with range [0, 2*PI) is bigger than angleTo with range (-2*PI, 4*PI), the ellipse runs clockwise. The angles are from before the ellipse has been stretched and rotated. See https://www.w3.org/TR/SVG/implnote.html#ArcImplementationNotes
@@ -336,7 +362,7 @@ func xmonotoneEllipticArc(start Point, rx, ry, phi float64, large, sweep bool, e
 		t += sign * dt
 
 		pos := EllipsePos(rx, ry, phi, cx, cy, t)
-		p.ArcTo(rx, ry, phi*180.0/math.Pi, large, sweep, pos.X, pos.Y)
+		p.ArcTo(rx, ry, phi*180.0/math.Pi, false, sweep, pos.X, pos.Y) // each part spans at most half the ellipse
 		left = !left
 	}
 	return p
@@ -344,46 +370,59 @@ func xmonotoneEllipticArc(start Point, rx, ry, phi float64, large, sweep bool, e
 
 func flattenEllipticArc(start Point, rx, ry, phi float64, large, sweep bool, end Point, tolerance float64) *Path {
 	if Equal(rx, ry) {
-		// circle
-		r := rx
-		cx, cy, theta0, theta1 := ellipseToCenter(start.X, start.Y, rx, ry, phi, large, sweep, end.X, end.Y)
-		theta0 += phi
-		theta1 += phi
-
-		// draw line segments from arc+tolerance to arc+tolerance, touching arc-tolerance in between
-		// we start and end at the arc itself
-		dtheta := math.Abs(theta1 - theta0)
-		thetam := math.Acos(r / (r + tolerance))     // half angle of first/last segment
-		thetat := math.Acos(r / (r + 2.0*tolerance)) // half angle of middle segments
-		n := math.Ceil((dtheta - thetam*2.0) / (thetat * 2.0))
-
-		// evenly space out points along arc
-		ratio := dtheta / (thetam*2.0 + thetat*2.0*n)
-		thetam *= ratio
-		thetat *= ratio
-
-		// adjust distance from arc to lower total deviation area, add points on the outer circle
-		// of the tolerance since the middle of the line segment touches the inner circle and thus
-		// even out. Ratio < 1 is when the line segments are shorter (and thus not touch the inner
-		// tolerance circle).
-		r += ratio * tolerance
-
 		p := &Path{}
 		p.MoveTo(start.X, start.Y)
-		theta := thetam + thetat
-		for i := 0; i < int(n); i++ {
-			t := theta0 + math.Copysign(theta, theta1-theta0)
-			pos := PolarPoint(t, r).Add(Point{cx, cy})
-			p.LineTo(pos.X, pos.Y)
-			theta += 2.0 * thetat
-		}
-		p.LineTo(end.X, end.Y)
+		appendFlattenedEllipticArc(p, start, rx, ry, phi, large, sweep, end, tolerance)
 		return p
 	}
 	// TODO: (flatten ellipse) use direct algorithm
 	return arcToCube(start, rx, ry, phi, large, sweep, end).Flatten(tolerance)
 }
 
+// appendFlattenedEllipticArc adds the flattened arc to p, which must be positioned at start.
+func appendFlattenedEllipticArc(p *Path, start Point, rx, ry, phi float64, large, sweep bool, end Point, tolerance float64) {
+	if !Equal(rx, ry) {
+		// TODO: (flatten ellipse) use direct algorithm
+		for _, bezier := range ellipseToCubicBeziers(start, rx, ry, phi, large, sweep, end) {
+			appendStrokedCubicBezier(p, bezier[0], bezier[1], bezier[2], bezier[3], 0.0, tolerance)
+		}
+		return
+	}
+
+	// circle
+	r := rx
+	cx, cy, theta0, theta1 := ellipseToCenter(start.X, start.Y, rx, ry, phi, large, sweep, end.X, end.Y)
+	theta0 += phi
+	theta1 += phi
+
+	// draw line segments from arc+tolerance to arc+tolerance, touching arc-tolerance in between
+	// we start and end at the arc itself
+	dtheta := math.Abs(theta1 - theta0)
+	thetam := math.Acos(r / (r + tolerance))     // half angle of first/last segment
+	thetat := math.Acos(r / (r + 2.0*tolerance)) // half angle of middle segments
+	n := math.Ceil((dtheta - thetam*2.0) / (thetat * 2.0))
+
+	// evenly space out points along arc
+	ratio := dtheta / (thetam*2.0 + thetat*2.0*n)
+	thetam *= ratio
+	thetat *= ratio
+
+	// adjust distance from arc to lower total deviation area, add points on the outer circle
+	// of the tolerance since the middle of the line segment touches the inner circle and thus
+	// even out. Ratio < 1 is when the line segments are shorter (and thus not touch the inner
+	// tolerance circle).
+	r += ratio * tolerance
+
+	theta := thetam + thetat
+	for i := 0; i < int(n); i++ {
+		t := theta0 + math.Copysign(theta, theta1-theta0)
+		pos := PolarPoint(t, r).Add(Point{cx, cy})
+		p.LineTo(pos.X, pos.Y)
+		theta += 2.0 * thetat
+	}
+	p.LineTo(end.X, end.Y)
+}
+
 ////////////////////////////////////////////////////////////////
 // Béziers /////////////////////////////////////////////////////
 ////////////////////////////////////////////////////////////////
@@ -706,10 +745,16 @@ func xmonotoneQuadraticBezier(p0, p1, p2 Point) *Path {
 }
 
 func flattenQuadraticBezier(p0, p1, p2 Point, tolerance float64) *Path {
-	// see Flat, precise flattening of cubic Bézier path and offset curves, by T.F. Hain et al., 2005,  https://www.sciencedirect.com/science/article/pii/S0097849305001287
-	t := 0.0
 	p := &Path{}
 	p.MoveTo(p0.X, p0.Y)
+	appendFlattenedQuadraticBezier(p, p0, p1, p2, tolerance)
+	return p
+}
+
+// appendFlattenedQuadraticBezier adds the flattened Bézier to p, which must be positioned at p0.
+func appendFlattenedQuadraticBezier(p *Path, p0, p1, p2 Point, tolerance float64) {
+	// see Flat, precise flattening of cubic Bézier path and offset curves, by T.F. Hain et al., 2005,  https://www.sciencedirect.com/science/article/pii/S0097849305001287
+	t := 0.0
 	for t < 1.0 {
 		D := p1.Sub(p0)
 		if p0.Equals(p1) {
@@ -729,7 +774,6 @@ func flattenQuadraticBezier(p0, p1, p2 Point, tolerance float64) *Path {
 		p.LineTo(p0.X, p0.Y)
 	}
 	p.LineTo(p2.X, p2.Y)
-	return p
 }
 
 func xmonotoneCubicBezier(p0, p1, p2, p3 Point) *Path {
@@ -876,14 +920,19 @@ func strokeCubicBezier(p0, p1, p2, p3 Point, d, tolerance float64) *Path {
 	p := &Path{}
 	start := p0.Add(cubicBezierNormal(p0, p1, p2, p3, 0.0, d))
 	p.MoveTo(start.X, start.Y)
+	appendStrokedCubicBezier(p, p0, p1, p2, p3, d, tolerance)
+	return p
+}
 
+// appendStrokedCubicBezier adds the flattened Bézier offset by d to p, which must be positioned at the start of the offset curve.
+func appendStrokedCubicBezier(p *Path, p0, p1, p2, p3 Point, d, tolerance float64) {
 	// 0 <= t1 <= 1 if t1 exists
 	// 0 <= t1 <= t2 <= 1 if t1 and t2 both exist
 	t1, t2 := findInflectionPointsCubicBezier(p0, p1, p2, p3)
 	if math.IsNaN(t1) && math.IsNaN(t2) {
 		// There are no inflection points or cusps, approximate linearly by subdivision.
 		flattenSmoothCubicBezier(p, p0, p1, p2, p3, d, tolerance)
-		return p
+		return
 	}
 
 	// t1min <= t1max; with 0 <= t1max and t1min <= 1
@@ -894,7 +943,7 @@ func strokeCubicBezier(p0, p1, p2, p3 Point, d, tolerance float64) *Path {
 	if math.IsNaN(t2) && t1min <= 0.0 && 1.0 <= t1max {
 		// There is no second inflection point, and the first inflection point can be entirely approximated linearly.
 		addCubicBezierLine(p, p0, p1, p2, p3, 1.0, d)
-		return p
+		return
 	}
 
 	if 0.0 < t1min {
@@ -910,12 +959,12 @@ func strokeCubicBezier(p0, p1, p2, p3 Point, d, tolerance float64) *Path {
 		if 1.0 <= t2min {
 			// No t2 present, approximate the rest linearly by subdivision
 			flattenSmoothCubicBezier(p, q0, q1, q2, q3, d, tolerance)
-			return p
+			return
 		}
 	} else if 1.0 <= t2min {
 		// No t2 present and t1max is past the end of the curve, approximate linearly
 		addCubicBezierLine(p, p0, p1, p2, p3, 1.0, d)
-		return p
+		return
 	}
 
 	// t1 and t2 exist and ranges might overlap
@@ -942,5 +991,4 @@ func strokeCubicBezier(p0, p1, p2, p3 Point, d, tolerance float64) *Path {
 		// t2max extends beyond 1
 		addCubicBezierLine(p, p0, p1, p2, p3, 1.0, d)
 	}
-	return p
 }
@@ -23,7 +23,11 @@ func TestEllipse(t *testing.T) {
 	test.T(t, ellipseNormal(2.0, 1.0, math.Pi/2.0, false, 0.0, 1.0), Point{0.0, -1.0})
 
 	// https://www.wolframalpha.com/input/?i=arclength+x%28t%29%3D2*cos+t%2C+y%28t%29%3Dsin+t+for+t%3D0+to+0.5pi
-	test.Float(t, ellipseLength(2.0, 1.0, 0.0, math.Pi/2.0), 2.4221102220)
+	test.Float(t, ellipseLength(2.0, 1.0, 0.0, math.Pi/2.0, 1e-9), 2.4221120551)
+	test.Float(t, ellipseLength(2.0, 2.0, math.Pi/2.0, 0.0, 1e-9), math.Pi)
+
+	// https://www.wolframalpha.com/input/?i=arclength+x%28t%29%3D100*cos+t%2C+y%28t%29%3Dsin+t+for+t%3D0+to+2pi
+	test.Float(t, ellipseLength(100.0, 1.0, 0.0, 2.0*math.Pi, 1e-9), 400.1098329723)
 
 	test.Float(t, ellipseRadiiCorrection(Point{0.0, 0.0}, 0.1, 0.1, 0.0, Point{1.0, 0.0}), 5.0)
 }
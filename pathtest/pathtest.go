@@ -0,0 +1,218 @@
+package pathtest
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/tdewolff/canvas"
+)
+
+// Generator generates random paths to stress path operations such as booleans, stroking, and flattening. Paths are reproducible given the same seed and sequence of calls, so that failing cases can be reported by their seed.
+type Generator struct {
+	Size float64 // coordinates are in [0,Size]
+
+	rng *rand.Rand
+}
+
+// New returns a new generator with the given seed and a size of 100.
+func New(seed int64) *Generator {
+	return &Generator{
+		Size: 100.0,
+		rng:  rand.New(rand.NewSource(seed)),
+	}
+}
+
+func (g *Generator) point() canvas.Point {
+	return canvas.Point{g.Size * g.rng.Float64(), g.Size * g.rng.Float64()}
+}
+
+func polygon(points []canvas.Point) *canvas.Path {
+	p := &canvas.Path{}
+	for i, point := range points {
+		if i == 0 {
+			p.MoveTo(point.X, point.Y)
+		} else {
+			p.LineTo(point.X, point.Y)
+		}
+	}
+	p.Close()
+	return p
+}
+
+// vertices returns the coordinates of a polygon without repeating the start point for the close command.
+func vertices(p *canvas.Path) []canvas.Point {
+	coords := p.Coords()
+	if 1 < len(coords) && p.Closed() && coords[0].Equals(coords[len(coords)-1]) {
+		coords = coords[:len(coords)-1]
+	}
+	return coords
+}
+
+// SimplePolygon returns a random simple polygon of n vertices in counter clockwise order. The polygon is star-shaped with respect to the center, with random angles and radii.
+func (g *Generator) SimplePolygon(n int) *canvas.Path {
+	if n < 3 {
+		return &canvas.Path{}
+	}
+
+	angles := make([]float64, n)
+	for i := range angles {
+		angles[i] = 2.0 * math.Pi * g.rng.Float64()
+	}
+	sort.Float64s(angles)
+
+	c := g.Size / 2.0
+	points := make([]canvas.Point, n)
+	for i, angle := range angles {
+		r := c * (0.1 + 0.9*g.rng.Float64())
+		sin, cos := math.Sincos(angle)
+		points[i] = canvas.Point{c + r*cos, c + r*sin}
+	}
+	return polygon(points)
+}
+
+// Polygon returns a polygon of n random vertices, which is generally self-intersecting.
+func (g *Generator) Polygon(n int) *canvas.Path {
+	if n < 3 {
+		return &canvas.Path{}
+	}
+
+	points := make([]canvas.Point, n)
+	for i := range points {
+		points[i] = g.point()
+	}
+	return polygon(points)
+}
+
+// StarPolygon returns a random self-intersecting star polygon of n vertices with density d, where every dth vertex on a circle is connected. The vertices are perturbed by a random fraction of the radius given by jitter, where zero gives a regular star polygon with many intersections at the same point.
+func (g *Generator) StarPolygon(n, d int, jitter float64) *canvas.Path {
+	c := g.Size / 2.0
+	star := canvas.RegularStarPolygon(n, d, c, g.rng.Intn(2) == 0)
+	if star.Empty() {
+		return star
+	}
+
+	points := vertices(star)
+	for i := range points {
+		points[i].X += c + jitter*c*(2.0*g.rng.Float64()-1.0)
+		points[i].Y += c + jitter*c*(2.0*g.rng.Float64()-1.0)
+	}
+	return polygon(points)
+}
+
+// GridPolygon returns a polygon of n random vertices snapped to a grid of the given number of cells in each direction. A coarse grid gives many degenerate configurations, such as coincident vertices, collinear and overlapping segments, and segments that touch at a vertex.
+func (g *Generator) GridPolygon(n, cells int) *canvas.Path {
+	if n < 3 || cells < 1 {
+		return &canvas.Path{}
+	}
+
+	cell := g.Size / float64(cells)
+	points := make([]canvas.Point, n)
+	for i := range points {
+		points[i] = canvas.Point{cell * float64(g.rng.Intn(cells+1)), cell * float64(g.rng.Intn(cells+1))}
+	}
+	return polygon(points)
+}
+
+// CollinearPolygon returns a random simple polygon of n vertices where every edge is subdivided by m extra vertices that are nearly collinear, displaced from the edge by at most eps. Note that Path.LineTo merges exactly collinear segments, so that eps must be larger than Epsilon to keep the extra vertices.
+func (g *Generator) CollinearPolygon(n, m int, eps float64) *canvas.Path {
+	if n < 3 {
+		return &canvas.Path{}
+	}
+
+	coords := vertices(g.SimplePolygon(n))
+	points := []canvas.Point{}
+	for i, a := range coords {
+		b := coords[(i+1)%len(coords)]
+		normal := b.Sub(a).Rot90CCW().Norm(1.0)
+		points = append(points, a)
+		for j := 0; j < m; j++ {
+			t := (float64(j) + g.rng.Float64()) / float64(m)
+			offset := eps * (2.0*g.rng.Float64() - 1.0)
+			points = append(points, a.Interpolate(b, t).Add(normal.Mul(offset)))
+		}
+	}
+	return polygon(points)
+}
+
+// TinySegments returns a random simple polygon of n vertices where every vertex is replaced by m vertices within a distance of size, resulting in tiny segments and possibly tiny self-intersections.
+func (g *Generator) TinySegments(n, m int, size float64) *canvas.Path {
+	if n < 3 {
+		return &canvas.Path{}
+	}
+
+	points := []canvas.Point{}
+	for _, a := range vertices(g.SimplePolygon(n)) {
+		points = append(points, a)
+		for j := 0; j < m; j++ {
+			angle := 2.0 * math.Pi * g.rng.Float64()
+			sin, cos := math.Sincos(angle)
+			r := size * g.rng.Float64()
+			points = append(points, canvas.Point{a.X + r*cos, a.Y + r*sin})
+		}
+	}
+	return polygon(points)
+}
+
+// Curves returns a closed path of n random segments, each a line, quadratic Bézier, cubic Bézier, or elliptical arc, which is generally self-intersecting.
+func (g *Generator) Curves(n int) *canvas.Path {
+	if n < 1 {
+		return &canvas.Path{}
+	}
+
+	p := &canvas.Path{}
+	start := g.point()
+	p.MoveTo(start.X, start.Y)
+	for i := 0; i < n; i++ {
+		end := g.point()
+		if i == n-1 {
+			end = start
+		}
+		switch g.rng.Intn(4) {
+		case 0:
+			p.LineTo(end.X, end.Y)
+		case 1:
+			cp := g.point()
+			p.QuadTo(cp.X, cp.Y, end.X, end.Y)
+		case 2:
+			cp1, cp2 := g.point(), g.point()
+			p.CubeTo(cp1.X, cp1.Y, cp2.X, cp2.Y, end.X, end.Y)
+		case 3:
+			rx := g.Size * (0.1 + 0.9*g.rng.Float64())
+			ry := g.Size * (0.1 + 0.9*g.rng.Float64())
+			rot := 360.0 * g.rng.Float64()
+			p.ArcTo(rx, ry, rot, g.rng.Intn(2) == 0, g.rng.Intn(2) == 0, end.X, end.Y)
+		}
+	}
+	p.Close()
+	return p
+}
+
+// Perturb returns the path with all its coordinates, including control points, displaced randomly by at most eps in each direction, such as to turn exact degeneracies into near-degeneracies.
+func (g *Generator) Perturb(p *canvas.Path, eps float64) *canvas.Path {
+	q := &canvas.Path{}
+	jitter := func(p canvas.Point) canvas.Point {
+		return canvas.Point{p.X + eps*(2.0*g.rng.Float64()-1.0), p.Y + eps*(2.0*g.rng.Float64()-1.0)}
+	}
+	for scanner := p.Scanner(); scanner.Scan(); {
+		end := jitter(scanner.End())
+		switch scanner.Cmd() {
+		case canvas.MoveToCmd:
+			q.MoveTo(end.X, end.Y)
+		case canvas.LineToCmd:
+			q.LineTo(end.X, end.Y)
+		case canvas.QuadToCmd:
+			cp := jitter(scanner.CP1())
+			q.QuadTo(cp.X, cp.Y, end.X, end.Y)
+		case canvas.CubeToCmd:
+			cp1, cp2 := jitter(scanner.CP1()), jitter(scanner.CP2())
+			q.CubeTo(cp1.X, cp1.Y, cp2.X, cp2.Y, end.X, end.Y)
+		case canvas.ArcToCmd:
+			rx, ry, rot, large, sweep := scanner.Arc()
+			q.ArcTo(rx, ry, rot, large, sweep, end.X, end.Y)
+		case canvas.CloseCmd:
+			q.Close()
+		}
+	}
+	return q
+}
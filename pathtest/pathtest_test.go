@@ -0,0 +1,32 @@
+package pathtest
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestGenerator(t *testing.T) {
+	test.T(t, New(1).Curves(5), New(1).Curves(5))
+	test.That(t, !New(1).Polygon(5).Equals(New(2).Polygon(5)))
+
+	g := New(1)
+	p := g.SimplePolygon(10)
+	test.T(t, len(vertices(p)), 10)
+	test.That(t, p.CCW())
+	test.That(t, p.Bounds().X >= 0.0 && p.Bounds().X+p.Bounds().W <= g.Size)
+
+	test.T(t, len(vertices(g.StarPolygon(5, 2, 0.0))), 5)
+	test.T(t, len(vertices(g.CollinearPolygon(4, 3, 1e-3))), 16)
+	test.T(t, len(vertices(g.TinySegments(4, 2, 1e-6))), 12)
+	test.That(t, g.SimplePolygon(2).Empty())
+
+	p = g.GridPolygon(20, 2)
+	for _, coord := range p.Coords() {
+		test.That(t, coord.X == 0.0 || coord.X == 50.0 || coord.X == 100.0)
+	}
+
+	p = g.Curves(10)
+	test.That(t, !g.Perturb(p, 1e-3).Equals(p))
+	test.T(t, g.Perturb(p, 0.0), p)
+}
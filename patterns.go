@@ -11,25 +11,92 @@ type Pattern interface {
 	ClipTo(Renderer, *Path)
 }
 
-//type CanvasPattern struct {
-//	c    *Canvas
-//	cell Matrix
-//}
-//
-//func NewPattern(c *Canvas, cell Matrix) *CanvasPattern {
-//	return &CanvasPattern{
-//		c:    c,
-//		cell: cell,
-//	}
-//}
-//
-//func (p *CanvasPattern) ClipTo(r Renderer, clip *Path) {
-//	//fmt.Println("src", p.c.Size())
-//	//fmt.Println("dst", r.Size())
-//	//fmt.Println("matrix", p.m)
-//	// TODO: tile
-//	p.c.RenderViewTo(r, p.cell)
-//}
+// CanvasPattern is a pattern that repeats the drawing of a canvas, such as for textures, wallpapers, or symbols on maps. Each tile is the canvas' area of W×H millimeters, and tiles are separated by Spacing millimeters horizontally and vertically. The tiles are transformed by View into the coordinate system of the canvas that is filled, and drawing operations outside of a tile's area are clipped.
+type CanvasPattern struct {
+	Canvas  *Canvas
+	View    Matrix
+	Spacing float64
+}
+
+// NewCanvasPattern returns a new pattern that repeats the canvas transformed by m, with spacing in millimeters between the tiles.
+func NewCanvasPattern(c *Canvas, m Matrix, spacing float64) *CanvasPattern {
+	return &CanvasPattern{
+		Canvas:  c,
+		View:    m,
+		Spacing: spacing,
+	}
+}
+
+// Cell returns the primitive cell of the pattern, which maps the unit square to the area of a tile including its spacing.
+func (p *CanvasPattern) Cell() Matrix {
+	return p.View.Scale(p.Canvas.W+p.Spacing, p.Canvas.H+p.Spacing)
+}
+
+// SetView sets the view. Automatically called by Canvas for coordinate system transformations.
+func (p *CanvasPattern) SetView(view Matrix) Pattern {
+	if view == Identity {
+		return p
+	}
+
+	pattern := *p
+	pattern.View = view.Mul(p.View)
+	return &pattern
+}
+
+// SetColorSpace sets the color space. Automatically called by the rasterizer. The tiles are drawn by the renderer, which converts their colors.
+func (p *CanvasPattern) SetColorSpace(colorSpace ColorSpace) Pattern {
+	return p
+}
+
+// Tile returns a canvas with the tiles of the pattern that cover the clipping path, clipped by the path. Paths and text that cross the border of the clipping path are clipped using the path boolean operations, where their stroke is converted into a filled path, and images are clipped by making the pixels outside of the path transparent. Images mapped onto a quadrilateral are not clipped.
+func (p *CanvasPattern) Tile(clip *Path) *Canvas {
+	dst := clip.FastBounds()
+	tiles := New(dst.X+dst.W, dst.Y+dst.H)
+	cell := p.Cell()
+	if clip.Empty() || Equal(cell.Det(), 0.0) {
+		return tiles
+	}
+
+	// find extremes along cell axes
+	invCell := cell.Inv()
+	points := []Point{
+		invCell.Dot(Point{dst.X, dst.Y}),
+		invCell.Dot(Point{dst.X + dst.W, dst.Y}),
+		invCell.Dot(Point{dst.X + dst.W, dst.Y + dst.H}),
+		invCell.Dot(Point{dst.X, dst.Y + dst.H}),
+	}
+	x0, x1 := points[0].X, points[0].X
+	y0, y1 := points[0].Y, points[0].Y
+	for _, point := range points[1:] {
+		x0 = math.Min(x0, point.X)
+		x1 = math.Max(x1, point.X)
+		y0 = math.Min(y0, point.Y)
+		y1 = math.Max(y1, point.Y)
+	}
+
+	rect := Rectangle(p.Canvas.W, p.Canvas.H)
+	zindices := p.Canvas.ZIndices()
+	for j := math.Floor(y0); j < y1; j++ {
+		for i := math.Floor(x0); i < x1; i++ {
+			view := p.View.Translate(i*(p.Canvas.W+p.Spacing), j*(p.Canvas.H+p.Spacing))
+			tileClip := clip.Transform(view.Inv()).And(rect)
+			if tileClip.Empty() {
+				continue
+			}
+			for _, zindex := range zindices {
+				for _, l := range p.Canvas.layers[zindex] {
+					tiles.layers[zindex] = append(tiles.layers[zindex], clipLayer(l, tileClip, view)...)
+				}
+			}
+		}
+	}
+	return tiles
+}
+
+// ClipTo tiles the pattern within the clipping path and renders it to the renderer.
+func (p *CanvasPattern) ClipTo(r Renderer, clip *Path) {
+	p.Tile(clip).RenderTo(r)
+}
 
 //type ImagePattern struct {
 //	img  *image.RGBA
@@ -49,6 +116,11 @@ type HatchPattern struct {
 	Thickness float64
 	cell      Matrix
 	hatch     Hatcher
+
+	// parameters of the hatches of NewLineHatch, NewCrossHatch, and NewShapeHatch, used for recording
+	kind   string
+	params []float64
+	shape  *Path
 }
 
 // Hatcher is a hatch pattern along the cell's axes. The rectangle (x0,y0)-(x1,y1) is expressed in the unit cell's coordinate system, and the returned path should be transformed by the cell to obtain the final hatch pattern.
@@ -135,7 +207,7 @@ func (p *HatchPattern) ClipTo(r Renderer, clip *Path) {
 // NewLineHatch returns a new line hatch pattern with lines at an angle with a spacing of distance. Thickness is the stroke thickness applied to the shape; stroking is ignored with thickness is zero.
 func NewLineHatch(ifill interface{}, angle, distance, thickness float64) *HatchPattern {
 	cell := Identity.Rotate(angle).Scale(distance, distance)
-	return NewHatchPattern(ifill, thickness, cell, func(x0, y0, x1, y1 float64) *Path {
+	hatch := NewHatchPattern(ifill, thickness, cell, func(x0, y0, x1, y1 float64) *Path {
 		p := &Path{}
 		for y := math.Floor(y0); y <= y1; y += 1.0 {
 			p.MoveTo(x0, y)
@@ -143,6 +215,8 @@ func NewLineHatch(ifill interface{}, angle, distance, thickness float64) *HatchP
 		}
 		return p
 	})
+	hatch.kind, hatch.params = "line", []float64{angle, distance}
+	return hatch
 }
 
 // NewCrossHatch returns a new cross hatch pattern of two regular line hatches at different angles and with different distance intervals. Thickness is the stroke thickness applied to the shape; stroking is ignored with thickness is zero.
@@ -151,7 +225,7 @@ func NewCrossHatch(ifill interface{}, angle0, angle1, distance0, distance1, thic
 		Point{distance0, 0.0}.Rot(angle0*math.Pi/180.0, Origin),
 		Point{distance1, 0.0}.Rot(angle1*math.Pi/180.0, Origin),
 	)
-	return NewHatchPattern(ifill, thickness, cell, func(x0, y0, x1, y1 float64) *Path {
+	hatch := NewHatchPattern(ifill, thickness, cell, func(x0, y0, x1, y1 float64) *Path {
 		p := &Path{}
 		for y := math.Floor(y0); y <= y1; y += 1.0 {
 			p.MoveTo(x0, y)
@@ -163,13 +237,15 @@ func NewCrossHatch(ifill interface{}, angle0, angle1, distance0, distance1, thic
 		}
 		return p
 	})
+	hatch.kind, hatch.params = "cross", []float64{angle0, angle1, distance0, distance1}
+	return hatch
 }
 
 // NewShapeHatch returns a new shape hatch that repeats the given shape over a rhombus primitive cell with sides of length distance. Thickness is the stroke thickness applied to the shape; stroking is ignored with thickness is zero.
 func NewShapeHatch(ifill interface{}, shape *Path, distance, thickness float64) *HatchPattern {
 	d := distance * math.Sin(60.0*math.Pi/180.0)
 	cell := SquareCell(1.0)
-	return NewHatchPattern(ifill, thickness, cell, func(x0, y0, x1, y1 float64) *Path {
+	hatch := NewHatchPattern(ifill, thickness, cell, func(x0, y0, x1, y1 float64) *Path {
 		p := &Path{}
 		for y := math.Floor(y0/distance) * distance; y <= y1; y += 2.0 * d {
 			for x := math.Floor(x0/distance) * distance; x <= x1; x += distance {
@@ -181,4 +257,6 @@ func NewShapeHatch(ifill interface{}, shape *Path, distance, thickness float64)
 		}
 		return p
 	})
+	hatch.kind, hatch.params, hatch.shape = "shape", []float64{distance}, shape
+	return hatch
 }
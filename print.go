@@ -0,0 +1,90 @@
+package canvas
+
+import (
+	"image/color"
+	"math"
+)
+
+// PrintSetup specifies the bleed and printer's marks for print production, see Canvas.ForPrint.
+type PrintSetup struct {
+	Bleed             float64 // bleed in mm around the trim box
+	CropMarks         bool    // crop marks at the corners of the trim box
+	RegistrationMarks bool    // registration marks at the center of each side
+	ColorBars         bool    // color bars of process and tint colors at the top
+}
+
+// DefaultPrintSetup is the default print setup with a bleed of 3mm and crop marks.
+var DefaultPrintSetup = PrintSetup{
+	Bleed:     3.0,
+	CropMarks: true,
+}
+
+const (
+	printMarkOffset = 3.0            // minimum distance in mm of the marks from the trim box
+	printMarkLength = 5.0            // length in mm of the marks
+	printMarkWidth  = 0.25 * mmPerPt // stroke width of the marks
+)
+
+// ForPrint returns a new canvas for print production with the canvas as the trimmed page. The page is extended by the bleed and a margin for the printer's marks, which are drawn outside of the bleed box. The trim and bleed boxes are set accordingly, so that renderers implementing PageBoxRenderer (such as PDF) will embed them. Any drawing operations of the canvas that extend beyond the trim box (up to the bleed) are kept.
+func (c *Canvas) ForPrint(setup PrintSetup) *Canvas {
+	bleed := math.Max(0.0, setup.Bleed)
+	offset := math.Max(bleed, printMarkOffset)
+	margin := bleed
+	if setup.CropMarks || setup.RegistrationMarks || setup.ColorBars {
+		margin = offset + printMarkLength + 2.0
+	}
+
+	page := New(c.W+2.0*margin, c.H+2.0*margin)
+	page.Metadata = c.Metadata
+	page.TrimBox = Rect{margin, margin, c.W, c.H}
+	page.BleedBox = Rect{margin - bleed, margin - bleed, c.W + 2.0*bleed, c.H + 2.0*bleed}
+	c.RenderViewTo(page, Identity.Translate(margin, margin))
+
+	// draw marks on top of the page
+	ctx := NewContext(page)
+	if zindices := page.ZIndices(); 0 < len(zindices) {
+		ctx.SetZIndex(zindices[len(zindices)-1] + 1)
+	}
+	ctx.SetFill(Transparent)
+	ctx.SetStroke(Black) // registration color
+	ctx.SetStrokeWidth(printMarkWidth)
+	x0, y0 := margin, margin
+	x1, y1 := margin+c.W, margin+c.H
+	if setup.CropMarks {
+		for _, x := range []float64{x0, x1} {
+			ctx.DrawPath(x, y0-offset, Line(0.0, -printMarkLength))
+			ctx.DrawPath(x, y1+offset, Line(0.0, printMarkLength))
+		}
+		for _, y := range []float64{y0, y1} {
+			ctx.DrawPath(x0-offset, y, Line(-printMarkLength, 0.0))
+			ctx.DrawPath(x1+offset, y, Line(printMarkLength, 0.0))
+		}
+	}
+	if setup.RegistrationMarks {
+		r := printMarkLength / 2.0
+		mark := Circle(r * 0.6)
+		mark = mark.Append(Line(2.0*r, 0.0).Translate(-r, 0.0))
+		mark = mark.Append(Line(0.0, 2.0*r).Translate(0.0, -r))
+		xm, ym := (x0+x1)/2.0, (y0+y1)/2.0
+		ctx.DrawPath(xm, y0-offset-r, mark)
+		ctx.DrawPath(xm, y1+offset+r, mark)
+		ctx.DrawPath(x0-offset-r, ym, mark)
+		ctx.DrawPath(x1+offset+r, ym, mark)
+	}
+	if setup.ColorBars {
+		colors := []color.RGBA{Cyan, Magenta, Yellow, Black, Red, Lime, Blue, RGB(64, 64, 64), RGB(128, 128, 128), RGB(191, 191, 191)}
+		x := x0 + printMarkLength
+		if setup.RegistrationMarks {
+			x = (x0+x1)/2.0 + printMarkLength // right of the registration mark
+		}
+		size := math.Min(printMarkLength, (x1-printMarkLength-x)/float64(len(colors)))
+		if 1.0 <= size { // patches shrink for narrow pages
+			ctx.SetStroke(Transparent)
+			for i, col := range colors {
+				ctx.SetFill(col)
+				ctx.DrawPath(x+float64(i)*size, y1+offset, Rectangle(size, size))
+			}
+		}
+	}
+	return page
+}
@@ -0,0 +1,287 @@
+package canvas
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Point3 is a coordinate in 3D space, used for projecting 3D geometry onto paths.
+type Point3 struct {
+	X, Y, Z float64
+}
+
+// Add adds Q to P.
+func (p Point3) Add(q Point3) Point3 {
+	return Point3{p.X + q.X, p.Y + q.Y, p.Z + q.Z}
+}
+
+// Sub subtracts Q from P.
+func (p Point3) Sub(q Point3) Point3 {
+	return Point3{p.X - q.X, p.Y - q.Y, p.Z - q.Z}
+}
+
+// Mul multiplies x, y and z by f.
+func (p Point3) Mul(f float64) Point3 {
+	return Point3{f * p.X, f * p.Y, f * p.Z}
+}
+
+// Dot returns the dot product between P and Q, i.e. |P| |Q| cos(angle).
+func (p Point3) Dot(q Point3) float64 {
+	return p.X*q.X + p.Y*q.Y + p.Z*q.Z
+}
+
+// Cross returns the cross product of P and Q, which is perpendicular to both.
+func (p Point3) Cross(q Point3) Point3 {
+	return Point3{p.Y*q.Z - p.Z*q.Y, p.Z*q.X - p.X*q.Z, p.X*q.Y - p.Y*q.X}
+}
+
+// Length returns the length of the vector from the origin to point P.
+func (p Point3) Length() float64 {
+	return math.Sqrt(p.X*p.X + p.Y*p.Y + p.Z*p.Z)
+}
+
+// Norm normalized OP to be of given length.
+func (p Point3) Norm(length float64) Point3 {
+	d := p.Length()
+	if Equal(d, 0.0) {
+		return Point3{}
+	}
+	return Point3{p.X / d * length, p.Y / d * length, p.Z / d * length}
+}
+
+// Equals returns true if P and Q are equal within Epsilon.
+func (p Point3) Equals(q Point3) bool {
+	return Equal(p.X, q.X) && Equal(p.Y, q.Y) && Equal(p.Z, q.Z)
+}
+
+func (p Point3) String() string {
+	return fmt.Sprintf("(%g,%g,%g)", p.X, p.Y, p.Z)
+}
+
+// Camera projects 3D geometry onto paths, for technical illustrations and plotter art. The camera at Eye looks at Target with Up pointing upwards in the projection, and the projection plane's X and Y axes point to the right and upwards respectively.
+type Camera struct {
+	Eye, Target, Up Point3
+	Orthographic    bool    // orthographic projection instead of perspective projection
+	Scale           float64 // for perspective projections the focal length, which is the projected length of a unit length at unit distance from the eye, and for orthographic projections the projected length of a unit length
+	Near            float64 // minimum distance from the eye along the view direction, closer geometry is clipped
+}
+
+// NewPerspectiveCamera returns a camera with a perspective projection at eye looking at target, with the Z axis pointing upwards, and with the given focal length.
+func NewPerspectiveCamera(eye, target Point3, focalLength float64) Camera {
+	return Camera{
+		Eye:    eye,
+		Target: target,
+		Up:     Point3{0.0, 0.0, 1.0},
+		Scale:  focalLength,
+		Near:   1e-3,
+	}
+}
+
+// NewOrthographicCamera returns a camera with an orthographic projection at eye looking at target, with the Z axis pointing upwards, and with the given projected length of a unit length.
+func NewOrthographicCamera(eye, target Point3, scale float64) Camera {
+	return Camera{
+		Eye:          eye,
+		Target:       target,
+		Up:           Point3{0.0, 0.0, 1.0},
+		Orthographic: true,
+		Scale:        scale,
+	}
+}
+
+// basis returns the camera's right, up and forward unit vectors.
+func (c Camera) basis() (Point3, Point3, Point3) {
+	forward := c.Target.Sub(c.Eye).Norm(1.0)
+	right := forward.Cross(c.Up)
+	if Equal(right.Length(), 0.0) {
+		// up is parallel to the view direction
+		right = forward.Cross(Point3{0.0, 1.0, 0.0})
+		if Equal(right.Length(), 0.0) {
+			right = forward.Cross(Point3{1.0, 0.0, 0.0})
+		}
+	}
+	right = right.Norm(1.0)
+	return right, right.Cross(forward), forward
+}
+
+// view returns the coordinates of p in camera space.
+func (c Camera) view(p Point3, right, up, forward Point3) Point3 {
+	d := p.Sub(c.Eye)
+	return Point3{d.Dot(right), d.Dot(up), d.Dot(forward)}
+}
+
+func (c Camera) project(q Point3) Point {
+	if c.Orthographic {
+		return Point{c.Scale * q.X, c.Scale * q.Y}
+	}
+	return Point{c.Scale * q.X / q.Z, c.Scale * q.Y / q.Z}
+}
+
+// Project returns the projection of p and its depth, which is the distance from the eye along the view direction. Points at or behind the eye cannot be projected by perspective projections.
+func (c Camera) Project(p Point3) (Point, float64) {
+	right, up, forward := c.basis()
+	q := c.view(p, right, up, forward)
+	return c.project(q), q.Z
+}
+
+// clipNear returns the intersection of the segment from a to b in camera space with the near plane.
+func (c Camera) clipNear(a, b Point3) Point3 {
+	t := (c.near() - a.Z) / (b.Z - a.Z)
+	return a.Add(b.Sub(a).Mul(t))
+}
+
+func (c Camera) near() float64 {
+	if c.Orthographic {
+		return c.Near
+	}
+	return math.Max(c.Near, Epsilon)
+}
+
+// Polyline returns the projection of the polyline through the points as an open path. Parts closer than the near plane are clipped.
+func (c Camera) Polyline(points []Point3) *Path {
+	right, up, forward := c.basis()
+	near := c.near()
+
+	p := &Path{}
+	var prev Point3
+	for i, point := range points {
+		q := c.view(point, right, up, forward)
+		if i == 0 {
+			if near <= q.Z {
+				pos := c.project(q)
+				p.MoveTo(pos.X, pos.Y)
+			}
+		} else if near <= prev.Z && near <= q.Z {
+			pos := c.project(q)
+			p.LineTo(pos.X, pos.Y)
+		} else if near <= prev.Z {
+			pos := c.project(c.clipNear(prev, q))
+			p.LineTo(pos.X, pos.Y)
+		} else if near <= q.Z {
+			pos0, pos1 := c.project(c.clipNear(prev, q)), c.project(q)
+			p.MoveTo(pos0.X, pos0.Y)
+			p.LineTo(pos1.X, pos1.Y)
+		}
+		prev = q
+	}
+	return p
+}
+
+// polygon returns the polygon in camera space clipped by the near plane.
+func (c Camera) polygon(points []Point3, right, up, forward Point3) []Point3 {
+	near := c.near()
+	poly := make([]Point3, 0, len(points))
+	for i := range points {
+		a := c.view(points[i], right, up, forward)
+		b := c.view(points[(i+1)%len(points)], right, up, forward)
+		if near <= a.Z {
+			poly = append(poly, a)
+		}
+		if (near <= a.Z) != (near <= b.Z) {
+			poly = append(poly, c.clipNear(a, b))
+		}
+	}
+	return poly
+}
+
+func (c Camera) polygonPath(poly []Point3, closed bool) *Path {
+	p := &Path{}
+	for i, q := range poly {
+		pos := c.project(q)
+		if i == 0 {
+			p.MoveTo(pos.X, pos.Y)
+		} else {
+			p.LineTo(pos.X, pos.Y)
+		}
+	}
+	if closed {
+		p.Close()
+	}
+	return p
+}
+
+// Polygon returns the projection of the polygon through the points as a closed path. Parts closer than the near plane are clipped.
+func (c Camera) Polygon(points []Point3) *Path {
+	right, up, forward := c.basis()
+	return c.polygonPath(c.polygon(points, right, up, forward), true)
+}
+
+type projectedFace struct {
+	index int
+	poly  []Point3
+	depth float64
+}
+
+// faces returns the faces in camera space sorted from far to near by the depth of their centroids.
+func (c Camera) faces(faces [][]Point3) []projectedFace {
+	right, up, forward := c.basis()
+	projected := make([]projectedFace, 0, len(faces))
+	for i, face := range faces {
+		poly := c.polygon(face, right, up, forward)
+		if len(poly) < 3 {
+			continue
+		}
+		depth := 0.0
+		for _, q := range poly {
+			depth += q.Z
+		}
+		projected = append(projected, projectedFace{i, poly, depth / float64(len(poly))})
+	}
+	sort.SliceStable(projected, func(i, j int) bool {
+		return projected[j].depth < projected[i].depth
+	})
+	return projected
+}
+
+// Mesh returns the projections of the polygonal faces of a mesh as closed paths, sorted from far to near by the depth of the faces' centroids together with the faces' indices. Drawing the faces with a fill in order will thus hide the faces that are behind (painter's algorithm), which works well for meshes with faces of similar size but may fail for intersecting or long faces.
+func (c Camera) Mesh(faces [][]Point3) ([]*Path, []int) {
+	projected := c.faces(faces)
+	paths := make([]*Path, len(projected))
+	indices := make([]int, len(projected))
+	for i, face := range projected {
+		paths[i] = c.polygonPath(face.poly, true)
+		indices[i] = face.index
+	}
+	return paths, indices
+}
+
+// HiddenLines returns the visible edges of the polygonal faces of a mesh as an open path, for plotting 3D objects as line drawings. The faces are processed from near to far, and the edges of each face are clipped by the projections of the nearer faces using boolean operations. Edges shared by multiple faces are included once.
+func (c Camera) HiddenLines(faces [][]Point3) *Path {
+	type edge struct {
+		a, b Point3
+	}
+	projected := c.faces(faces)
+	visited := map[edge]bool{}
+
+	lines := &Path{}
+	occluder := &Path{}
+	for i := len(projected) - 1; 0 <= i; i-- {
+		poly := projected[i].poly
+		edges := &Path{}
+		for j := range poly {
+			a, b := poly[j], poly[(j+1)%len(poly)]
+			if b.X < a.X || b.X == a.X && (b.Y < a.Y || b.Y == a.Y && b.Z < a.Z) {
+				a, b = b, a
+			}
+			if visited[edge{a, b}] {
+				continue
+			}
+			visited[edge{a, b}] = true
+
+			pos0, pos1 := c.project(poly[j]), c.project(poly[(j+1)%len(poly)])
+			if edges.Empty() || !edges.Pos().Equals(pos0) {
+				edges.MoveTo(pos0.X, pos0.Y)
+			}
+			edges.LineTo(pos1.X, pos1.Y)
+		}
+		lines = lines.Append(edges.Not(occluder))
+
+		polygon := c.polygonPath(poly, true)
+		if occluder.Empty() {
+			occluder = polygon.Settle(NonZero)
+		} else {
+			occluder = occluder.Or(polygon)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,65 @@
+package canvas
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+var cube = [][]Point3{
+	{{0, 0, 0}, {0, 1, 0}, {1, 1, 0}, {1, 0, 0}}, // bottom
+	{{0, 0, 1}, {1, 0, 1}, {1, 1, 1}, {0, 1, 1}}, // top
+	{{0, 0, 0}, {1, 0, 0}, {1, 0, 1}, {0, 0, 1}}, // front
+	{{0, 1, 0}, {0, 1, 1}, {1, 1, 1}, {1, 1, 0}}, // back
+	{{0, 0, 0}, {0, 0, 1}, {0, 1, 1}, {0, 1, 0}}, // left
+	{{1, 0, 0}, {1, 1, 0}, {1, 1, 1}, {1, 0, 1}}, // right
+}
+
+func TestCameraProject(t *testing.T) {
+	// looking along the Y axis with Z upwards
+	camera := NewOrthographicCamera(Point3{0, -10, 0}, Point3{0, 0, 0}, 2.0)
+	p, depth := camera.Project(Point3{1, 5, 3})
+	test.T(t, p, Point{2, 6})
+	test.Float(t, depth, 15.0)
+
+	camera = NewPerspectiveCamera(Point3{0, -10, 0}, Point3{0, 0, 0}, 2.0)
+	p, depth = camera.Project(Point3{1, 0, 3})
+	test.T(t, p, Point{0.2, 0.6})
+	test.Float(t, depth, 10.0)
+	p, _ = camera.Project(Point3{1, 10, 3})
+	test.T(t, p, Point{0.1, 0.3})
+
+	// looking down the Z axis, which is parallel to up
+	camera = NewOrthographicCamera(Point3{0, 0, 10}, Point3{0, 0, 0}, 1.0)
+	p, _ = camera.Project(Point3{1, 2, 0})
+	test.T(t, p, Point{1, 2})
+}
+
+func TestCameraPolyline(t *testing.T) {
+	camera := NewPerspectiveCamera(Point3{0, 0, 0}, Point3{0, 1, 0}, 1.0)
+	test.T(t, camera.Polyline([]Point3{{-1, 1, 0}, {1, 1, 0}, {1, 2, 1}}), MustParseSVGPath("M-1 0L1 0L0.5 0.5"))
+
+	// clipped by the near plane
+	camera.Near = 0.5
+	test.T(t, camera.Polyline([]Point3{{0, -1, 0}, {0, 1, 1}, {1, 2, 2}}), MustParseSVGPath("M0 1.5L0 1L0.5 1"))
+	test.T(t, camera.Polygon([]Point3{{-1, 1, 1}, {1, 1, -1}, {0, -1, 1}}), MustParseSVGPath("M-1 1L1 -1L1.5 -1L-1.5 2z"))
+}
+
+func TestCameraMesh(t *testing.T) {
+	camera := NewOrthographicCamera(Point3{0.5, -10, 0.5}, Point3{0.5, 0, 0.5}, 1.0)
+	paths, indices := camera.Mesh(cube)
+	test.T(t, len(paths), 6)
+	test.T(t, indices[0], 3) // back
+	test.T(t, indices[5], 2) // front
+
+	// from the corner (1,1,1) only three faces and nine edges are visible
+	camera = NewOrthographicCamera(Point3{10, 10, 10}, Point3{0, 0, 0}, 1.0)
+	lines := camera.HiddenLines(cube)
+	test.Float(t, lines.Length(), 9.0*math.Sqrt(2.0/3.0))
+
+	// from the front only the outline of the front face is visible
+	camera = NewPerspectiveCamera(Point3{0.5, -10, 0.5}, Point3{0.5, 0, 0.5}, 10.0)
+	lines = camera.HiddenLines(cube)
+	test.Float(t, lines.Length(), 4.0)
+}
@@ -0,0 +1,723 @@
+package canvas
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// RecordingVersion is the version of the recording format written by Canvas.MarshalBinary and Canvas.MarshalJSON. Recordings of later versions cannot be read.
+const RecordingVersion = 1
+
+// recordingMagic precedes the binary recording format.
+var recordingMagic = []byte("CNVSREC")
+
+// ErrRecordingVersion is returned when reading a recording of an unsupported version.
+var ErrRecordingVersion = errors.New("unsupported recording version")
+
+// ErrInvalidRecording is returned when reading a recording that is malformed.
+var ErrInvalidRecording = errors.New("invalid recording")
+
+// recording is the serialized form of a canvas. Paths are stored packed (see PackedPath) and images are stored once as PNG and referenced by their index plus one.
+type recording struct {
+	Version  int           `json:"version"`
+	W        float64       `json:"width"`
+	H        float64       `json:"height"`
+	Metadata Metadata      `json:"metadata"`
+	TrimBox  Rect          `json:"trimBox"`
+	BleedBox Rect          `json:"bleedBox"`
+	Images   [][]byte      `json:"images,omitempty"`
+	Layers   []recordLayer `json:"layers"`
+}
+
+type recordLayer struct {
+	ZIndex     int           `json:"z,omitempty"`
+	M          Matrix        `json:"m"`
+	Path       []float64     `json:"path,omitempty"`
+	Style      *recordStyle  `json:"style,omitempty"`
+	Image      int           `json:"image,omitempty"`
+	Quad       *[4]Point     `json:"quad,omitempty"`
+	BeginGroup bool          `json:"beginGroup,omitempty"`
+	EndGroup   bool          `json:"endGroup,omitempty"`
+	Opacity    float64       `json:"opacity,omitempty"`
+	Filter     *recordFilter `json:"filter,omitempty"`
+}
+
+type recordStyle struct {
+	Fill             recordPaint   `json:"fill"`
+	Stroke           recordPaint   `json:"stroke"`
+	StrokeWidth      float64       `json:"strokeWidth"`
+	StrokeCapper     *recordCapper `json:"strokeCapper,omitempty"`
+	StrokeJoiner     *recordJoiner `json:"strokeJoiner,omitempty"`
+	DashOffset       float64       `json:"dashOffset,omitempty"`
+	Dashes           []float64     `json:"dashes,omitempty"`
+	FillRule         FillRule      `json:"fillRule"`
+	NonScalingStroke bool          `json:"nonScalingStroke,omitempty"`
+	DashFit          bool          `json:"dashFit,omitempty"`
+	CompositeMode    CompositeMode `json:"compositeMode,omitempty"`
+	Shadow           *Shadow       `json:"shadow,omitempty"`
+}
+
+type recordPaint struct {
+	Color    color.RGBA      `json:"color"`
+	Swatch   *Swatch         `json:"swatch,omitempty"`
+	Gradient *recordGradient `json:"gradient,omitempty"`
+	Pattern  *recordPattern  `json:"pattern,omitempty"`
+}
+
+type recordPattern struct {
+	Type      string       `json:"type"` // canvas, lineHatch, crossHatch, or shapeHatch
+	Canvas    *recording   `json:",omitempty"`
+	View      Matrix       `json:",omitempty"`
+	Spacing   float64      `json:",omitempty"`
+	Fill      *recordPaint `json:",omitempty"`
+	Thickness float64      `json:",omitempty"`
+	Params    []float64    `json:",omitempty"`
+	Shape     []float64    `json:",omitempty"`
+}
+
+type recordGradient struct {
+	Type          string             `json:"type"` // linear, radial, path, gouraud, or coons
+	Start, End    Point              `json:",omitempty"`
+	C0, C1        Point              `json:",omitempty"`
+	R0, R1        float64            `json:",omitempty"`
+	Stops         Stops              `json:",omitempty"`
+	Interpolation ColorInterpolation `json:",omitempty"`
+	Spread        Spread             `json:",omitempty"`
+	Vertices      []Point            `json:",omitempty"`
+	Colors        []color.RGBA       `json:",omitempty"`
+	Triangles     [][3]int           `json:",omitempty"`
+	Patches       []CoonsPatch       `json:",omitempty"`
+}
+
+type recordFilter struct {
+	Type   string         `json:"type"` // blur, colorMatrix, convolve, erode, or dilate
+	StdDev float64        `json:",omitempty"`
+	Matrix *[4][5]float64 `json:",omitempty"`
+	Order  int            `json:",omitempty"`
+	Kernel []float64      `json:",omitempty"`
+	Radius float64        `json:",omitempty"`
+}
+
+type recordCapper struct {
+	Type          string        `json:"type"` // butt, round, square, arrow, or startEnd
+	Width, Length float64       `json:",omitempty"`
+	Start, End    *recordCapper `json:",omitempty"`
+}
+
+type recordJoiner struct {
+	Type  string        `json:"type"` // bevel, round, miter, or arcs
+	Limit float64       `json:",omitempty"`
+	Gap   *recordJoiner `json:",omitempty"`
+}
+
+// MarshalBinary returns the drawing operations of the canvas in a compact binary format, so that drawings can be cached to disk and rendered later to any renderer without re-running the code that generated them. Text is recorded as the paths of its glyph outlines, since fonts are not serialized. Capper, joiner, filter, and gradient implementations other than those of this package cannot be serialized, and neither can patterns other than CanvasPattern and the hatch patterns of NewLineHatch, NewCrossHatch, and NewShapeHatch. Use UnmarshalBinary to read it back.
+func (c *Canvas) MarshalBinary() ([]byte, error) {
+	rec, err := c.recording()
+	if err != nil {
+		return nil, err
+	}
+	b := &bytes.Buffer{}
+	b.Write(recordingMagic)
+	if err := gob.NewEncoder(b).Encode(rec); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// UnmarshalBinary replaces the canvas by the drawing operations of a recording in the binary format, see MarshalBinary.
+func (c *Canvas) UnmarshalBinary(b []byte) error {
+	if !bytes.HasPrefix(b, recordingMagic) {
+		return ErrInvalidRecording
+	}
+	rec := recording{}
+	if err := gob.NewDecoder(bytes.NewReader(b[len(recordingMagic):])).Decode(&rec); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRecording, err)
+	}
+	return c.setRecording(rec)
+}
+
+// MarshalJSON returns the drawing operations of the canvas in JSON, which is useful for diffing and for consumers in other languages. It records the same as MarshalBinary.
+func (c *Canvas) MarshalJSON() ([]byte, error) {
+	rec, err := c.recording()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(rec)
+}
+
+// UnmarshalJSON replaces the canvas by the drawing operations of a recording in JSON, see MarshalJSON.
+func (c *Canvas) UnmarshalJSON(b []byte) error {
+	rec := recording{}
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRecording, err)
+	}
+	return c.setRecording(rec)
+}
+
+// WriteRecording writes the canvas in the binary recording format, see Canvas.MarshalBinary. It can be used as a Writer.
+func WriteRecording(w io.Writer, c *Canvas) error {
+	b, err := c.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// ReadRecording reads a canvas in the binary recording format, see Canvas.MarshalBinary.
+func ReadRecording(r io.Reader) (*Canvas, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	c := New(0.0, 0.0)
+	if err := c.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Canvas) recording() (recording, error) {
+	rec := recording{
+		Version:  RecordingVersion,
+		W:        c.W,
+		H:        c.H,
+		Metadata: c.Metadata,
+		TrimBox:  c.TrimBox,
+		BleedBox: c.BleedBox,
+		Layers:   []recordLayer{},
+	}
+	images := map[string]int{}
+	for _, zindex := range c.ZIndices() {
+		for _, l := range c.layers[zindex] {
+			if l.path != nil && l.path.Empty() {
+				continue // draws nothing
+			} else if l.text != nil {
+				// record text by its outlines
+				tc := New(c.W, c.H)
+				l.text.RenderAsPath(tc, l.m, DefaultResolution)
+				for _, tl := range tc.layers[0] {
+					if tl.path != nil && tl.path.Empty() {
+						continue
+					}
+					rl, err := recordLayerOf(tl, images, &rec)
+					if err != nil {
+						return recording{}, err
+					}
+					rl.ZIndex = zindex
+					rec.Layers = append(rec.Layers, rl)
+				}
+				continue
+			}
+
+			rl, err := recordLayerOf(l, images, &rec)
+			if err != nil {
+				return recording{}, err
+			}
+			rl.ZIndex = zindex
+			rec.Layers = append(rec.Layers, rl)
+		}
+	}
+	return rec, nil
+}
+
+func recordLayerOf(l layer, images map[string]int, rec *recording) (recordLayer, error) {
+	rl := recordLayer{
+		M:          l.m,
+		Quad:       l.quad,
+		BeginGroup: l.beginGroup,
+		EndGroup:   l.endGroup,
+		Opacity:    l.opacity,
+	}
+	if l.filter != nil {
+		filter, err := recordFilterOf(l.filter)
+		if err != nil {
+			return recordLayer{}, err
+		}
+		rl.Filter = filter
+	}
+	if l.path != nil {
+		rl.Path = l.path.Pack().d
+		style, err := recordStyleOf(l.style)
+		if err != nil {
+			return recordLayer{}, err
+		}
+		rl.Style = &style
+	} else if l.img != nil {
+		b := &bytes.Buffer{}
+		if err := png.Encode(b, l.img); err != nil {
+			return recordLayer{}, err
+		}
+		index, ok := images[b.String()]
+		if !ok {
+			rec.Images = append(rec.Images, b.Bytes())
+			index = len(rec.Images)
+			images[b.String()] = index
+		}
+		rl.Image = index
+	}
+	return rl, nil
+}
+
+func recordStyleOf(style Style) (recordStyle, error) {
+	fill, err := recordPaintOf(style.Fill)
+	if err != nil {
+		return recordStyle{}, err
+	}
+	stroke, err := recordPaintOf(style.Stroke)
+	if err != nil {
+		return recordStyle{}, err
+	}
+	cr, err := recordCapperOf(style.StrokeCapper)
+	if err != nil {
+		return recordStyle{}, err
+	}
+	jr, err := recordJoinerOf(style.StrokeJoiner)
+	if err != nil {
+		return recordStyle{}, err
+	}
+	return recordStyle{
+		Fill:             fill,
+		Stroke:           stroke,
+		StrokeWidth:      style.StrokeWidth,
+		StrokeCapper:     cr,
+		StrokeJoiner:     jr,
+		DashOffset:       style.DashOffset,
+		Dashes:           style.Dashes,
+		FillRule:         style.FillRule,
+		NonScalingStroke: style.NonScalingStroke,
+		DashFit:          style.DashFit,
+		CompositeMode:    style.CompositeMode,
+		Shadow:           style.Shadow,
+	}, nil
+}
+
+func recordPaintOf(paint Paint) (recordPaint, error) {
+	rp := recordPaint{
+		Color:  paint.Color,
+		Swatch: paint.Swatch,
+	}
+	if paint.Pattern != nil {
+		pattern, err := recordPatternOf(paint.Pattern)
+		if err != nil {
+			return recordPaint{}, err
+		}
+		rp.Pattern = pattern
+	}
+	switch g := paint.Gradient.(type) {
+	case nil:
+	case *LinearGradient:
+		rp.Gradient = &recordGradient{Type: "linear", Start: g.Start, End: g.End, Stops: g.Stops, Interpolation: g.Interpolation, Spread: g.Spread}
+	case *RadialGradient:
+		rp.Gradient = &recordGradient{Type: "radial", C0: g.C0, C1: g.C1, R0: g.R0, R1: g.R1, Stops: g.Stops, Interpolation: g.Interpolation, Spread: g.Spread}
+	case *PathGradient:
+		rp.Gradient = &recordGradient{Type: "path", Stops: g.Stops, Interpolation: g.Interpolation}
+	case *GouraudGradient:
+		rp.Gradient = &recordGradient{Type: "gouraud", Vertices: g.Vertices, Colors: g.Colors, Triangles: g.Triangles}
+	case *CoonsGradient:
+		rp.Gradient = &recordGradient{Type: "coons", Patches: g.Patches}
+	default:
+		return recordPaint{}, fmt.Errorf("unsupported gradient %T in recording", g)
+	}
+	return rp, nil
+}
+
+func recordPatternOf(pattern Pattern) (*recordPattern, error) {
+	switch p := pattern.(type) {
+	case *CanvasPattern:
+		rec, err := p.Canvas.recording()
+		if err != nil {
+			return nil, err
+		}
+		return &recordPattern{Type: "canvas", Canvas: &rec, View: p.View, Spacing: p.Spacing}, nil
+	case *HatchPattern:
+		if p.kind == "" {
+			break // custom hatcher
+		}
+		fill, err := recordPaintOf(p.Fill)
+		if err != nil {
+			return nil, err
+		}
+		rp := &recordPattern{Type: p.kind + "Hatch", Fill: &fill, Thickness: p.Thickness, Params: p.params}
+		if p.shape != nil {
+			rp.Shape = p.shape.Pack().d
+		}
+		return rp, nil
+	}
+	return nil, fmt.Errorf("unsupported pattern %T in recording", pattern)
+}
+
+func recordFilterOf(filter Filter) (*recordFilter, error) {
+	switch f := filter.(type) {
+	case *BlurFilter:
+		return &recordFilter{Type: "blur", StdDev: f.StdDev}, nil
+	case *ColorMatrixFilter:
+		matrix := f.Matrix
+		return &recordFilter{Type: "colorMatrix", Matrix: &matrix}, nil
+	case *ConvolveFilter:
+		return &recordFilter{Type: "convolve", Order: f.Order, Kernel: f.Kernel}, nil
+	case *MorphologyFilter:
+		if f.Dilate {
+			return &recordFilter{Type: "dilate", Radius: f.Radius}, nil
+		}
+		return &recordFilter{Type: "erode", Radius: f.Radius}, nil
+	}
+	return nil, fmt.Errorf("unsupported filter %T in recording", filter)
+}
+
+func recordCapperOf(cr Capper) (*recordCapper, error) {
+	switch c := cr.(type) {
+	case nil:
+		return nil, nil
+	case ButtCapper:
+		return &recordCapper{Type: "butt"}, nil
+	case RoundCapper:
+		return &recordCapper{Type: "round"}, nil
+	case SquareCapper:
+		return &recordCapper{Type: "square"}, nil
+	case ArrowCapper:
+		return &recordCapper{Type: "arrow", Width: c.Width, Length: c.Length}, nil
+	case StartEndCapper:
+		start, err := recordCapperOf(c.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := recordCapperOf(c.End)
+		if err != nil {
+			return nil, err
+		}
+		return &recordCapper{Type: "startEnd", Start: start, End: end}, nil
+	}
+	return nil, fmt.Errorf("unsupported capper %T in recording", cr)
+}
+
+func recordJoinerOf(jr Joiner) (*recordJoiner, error) {
+	switch j := jr.(type) {
+	case nil:
+		return nil, nil
+	case BevelJoiner:
+		return &recordJoiner{Type: "bevel"}, nil
+	case RoundJoiner:
+		return &recordJoiner{Type: "round"}, nil
+	case MiterJoiner:
+		gap, err := recordJoinerOf(j.GapJoiner)
+		if err != nil {
+			return nil, err
+		}
+		return &recordJoiner{Type: "miter", Limit: j.Limit, Gap: gap}, nil
+	case ArcsJoiner:
+		gap, err := recordJoinerOf(j.GapJoiner)
+		if err != nil {
+			return nil, err
+		}
+		return &recordJoiner{Type: "arcs", Limit: j.Limit, Gap: gap}, nil
+	}
+	return nil, fmt.Errorf("unsupported joiner %T in recording", jr)
+}
+
+func (c *Canvas) setRecording(rec recording) error {
+	if rec.Version < 1 || RecordingVersion < rec.Version {
+		return ErrRecordingVersion
+	}
+
+	images := make([]image.Image, len(rec.Images))
+	for i, b := range rec.Images {
+		img, err := png.Decode(bytes.NewReader(b))
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidRecording, err)
+		}
+		images[i] = img
+	}
+
+	layers := map[int][]layer{}
+	for _, rl := range rec.Layers {
+		l, err := rl.layer(images)
+		if err != nil {
+			return err
+		}
+		layers[rl.ZIndex] = append(layers[rl.ZIndex], l)
+	}
+
+	c.layers = layers
+	c.zindex = 0
+	c.W, c.H = rec.W, rec.H
+	c.Metadata = rec.Metadata
+	c.TrimBox, c.BleedBox = rec.TrimBox, rec.BleedBox
+	return nil
+}
+
+func (rl recordLayer) layer(images []image.Image) (layer, error) {
+	l := layer{
+		m:          rl.M,
+		quad:       rl.Quad,
+		beginGroup: rl.BeginGroup,
+		endGroup:   rl.EndGroup,
+		opacity:    rl.Opacity,
+	}
+	if rl.Filter != nil {
+		if !rl.BeginGroup {
+			return layer{}, ErrInvalidRecording
+		}
+		filter, err := rl.Filter.filter()
+		if err != nil {
+			return layer{}, err
+		}
+		l.filter = filter
+	}
+	if rl.Path != nil {
+		pp, err := packedPathFromData(rl.Path)
+		if err != nil {
+			return layer{}, err
+		}
+		l.path = pp.Unpack()
+		if rl.Style != nil {
+			if l.style, err = rl.Style.style(); err != nil {
+				return layer{}, err
+			}
+		}
+	} else if rl.Image != 0 {
+		if rl.Image < 0 || len(images) < rl.Image {
+			return layer{}, ErrInvalidRecording
+		}
+		l.img = images[rl.Image-1]
+	} else if !rl.BeginGroup && !rl.EndGroup {
+		return layer{}, ErrInvalidRecording
+	}
+	if l.quad != nil && l.img == nil {
+		return layer{}, ErrInvalidRecording
+	}
+	return l, nil
+}
+
+// packedPathFromData returns the packed path of the data after checking that it is well-formed and finite.
+func packedPathFromData(d []float64) (*PackedPath, error) {
+	for i := 0; i < len(d); {
+		if len(d) < i+2 {
+			return nil, ErrInvalidRecording
+		}
+		cmd, count := d[i], d[i+1]
+		switch cmd {
+		case MoveToCmd, LineToCmd, QuadToCmd, CubeToCmd, ArcToCmd, CloseCmd:
+		default:
+			return nil, ErrInvalidRecording
+		}
+		if count < 1.0 || count != math.Trunc(count) || float64(len(d)-i-2) < count*float64(cmdLen(cmd)-2) {
+			return nil, ErrInvalidRecording
+		}
+		i += 2 + int(count)*(cmdLen(cmd)-2)
+	}
+	for _, f := range d {
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, ErrInvalidRecording
+		}
+	}
+	return &PackedPath{d}, nil
+}
+
+func (rs recordStyle) style() (Style, error) {
+	fill, err := rs.Fill.paint()
+	if err != nil {
+		return Style{}, err
+	}
+	stroke, err := rs.Stroke.paint()
+	if err != nil {
+		return Style{}, err
+	}
+	cr, err := rs.StrokeCapper.capper()
+	if err != nil {
+		return Style{}, err
+	}
+	jr, err := rs.StrokeJoiner.joiner()
+	if err != nil {
+		return Style{}, err
+	}
+	dashes := rs.Dashes
+	if dashes == nil {
+		dashes = []float64{}
+	}
+	return Style{
+		Fill:             fill,
+		Stroke:           stroke,
+		StrokeWidth:      rs.StrokeWidth,
+		StrokeCapper:     cr,
+		StrokeJoiner:     jr,
+		DashOffset:       rs.DashOffset,
+		Dashes:           dashes,
+		FillRule:         rs.FillRule,
+		NonScalingStroke: rs.NonScalingStroke,
+		DashFit:          rs.DashFit,
+		CompositeMode:    rs.CompositeMode,
+		Shadow:           rs.Shadow,
+	}, nil
+}
+
+func (rp recordPaint) paint() (Paint, error) {
+	paint := Paint{
+		Color:  rp.Color,
+		Swatch: rp.Swatch,
+	}
+	if g := rp.Gradient; g != nil {
+		switch g.Type {
+		case "linear":
+			gradient := NewLinearGradient(g.Start, g.End)
+			gradient.Stops, gradient.Interpolation, gradient.Spread = g.Stops, g.Interpolation, g.Spread
+			paint.Gradient = gradient
+		case "radial":
+			gradient := NewRadialGradient(g.C0, g.R0, g.C1, g.R1)
+			gradient.Stops, gradient.Interpolation, gradient.Spread = g.Stops, g.Interpolation, g.Spread
+			paint.Gradient = gradient
+		case "path":
+			gradient := NewPathGradient()
+			gradient.Stops, gradient.Interpolation = g.Stops, g.Interpolation
+			paint.Gradient = gradient
+		case "gouraud":
+			if len(g.Vertices) != len(g.Colors) {
+				return Paint{}, ErrInvalidRecording
+			}
+			for _, tri := range g.Triangles {
+				for _, i := range tri {
+					if i < 0 || len(g.Vertices) <= i {
+						return Paint{}, ErrInvalidRecording
+					}
+				}
+			}
+			paint.Gradient = NewGouraudGradient(g.Vertices, g.Colors, g.Triangles)
+		case "coons":
+			paint.Gradient = NewCoonsGradient(g.Patches)
+		default:
+			return Paint{}, ErrInvalidRecording
+		}
+	}
+	if rp.Pattern != nil {
+		pattern, err := rp.Pattern.pattern()
+		if err != nil {
+			return Paint{}, err
+		}
+		paint.Pattern = pattern
+	}
+	return paint, nil
+}
+
+func (rp *recordPattern) pattern() (Pattern, error) {
+	if rp.Type == "canvas" {
+		if rp.Canvas == nil {
+			return nil, ErrInvalidRecording
+		}
+		c := New(0.0, 0.0)
+		if err := c.setRecording(*rp.Canvas); err != nil {
+			return nil, err
+		}
+		return NewCanvasPattern(c, rp.View, rp.Spacing), nil
+	}
+
+	if rp.Fill == nil {
+		return nil, ErrInvalidRecording
+	}
+	fill, err := rp.Fill.paint()
+	if err != nil {
+		return nil, err
+	} else if fill.IsPattern() {
+		return nil, ErrInvalidRecording
+	}
+	switch rp.Type {
+	case "lineHatch":
+		if len(rp.Params) != 2 {
+			return nil, ErrInvalidRecording
+		}
+		return NewLineHatch(fill, rp.Params[0], rp.Params[1], rp.Thickness), nil
+	case "crossHatch":
+		if len(rp.Params) != 4 {
+			return nil, ErrInvalidRecording
+		}
+		return NewCrossHatch(fill, rp.Params[0], rp.Params[1], rp.Params[2], rp.Params[3], rp.Thickness), nil
+	case "shapeHatch":
+		if len(rp.Params) != 1 {
+			return nil, ErrInvalidRecording
+		}
+		pp, err := packedPathFromData(rp.Shape)
+		if err != nil {
+			return nil, err
+		}
+		return NewShapeHatch(fill, pp.Unpack(), rp.Params[0], rp.Thickness), nil
+	}
+	return nil, ErrInvalidRecording
+}
+
+func (rf *recordFilter) filter() (Filter, error) {
+	switch rf.Type {
+	case "blur":
+		return NewBlurFilter(rf.StdDev), nil
+	case "colorMatrix":
+		if rf.Matrix == nil {
+			return nil, ErrInvalidRecording
+		}
+		return NewColorMatrixFilter(*rf.Matrix), nil
+	case "convolve":
+		if rf.Order <= 0 || len(rf.Kernel) != rf.Order*rf.Order {
+			return nil, ErrInvalidRecording
+		}
+		return NewConvolveFilter(rf.Order, rf.Kernel), nil
+	case "erode":
+		return NewErodeFilter(rf.Radius), nil
+	case "dilate":
+		return NewDilateFilter(rf.Radius), nil
+	}
+	return nil, ErrInvalidRecording
+}
+
+func (rc *recordCapper) capper() (Capper, error) {
+	if rc == nil {
+		return nil, nil
+	}
+	switch rc.Type {
+	case "butt":
+		return ButtCap, nil
+	case "round":
+		return RoundCap, nil
+	case "square":
+		return SquareCap, nil
+	case "arrow":
+		return ArrowCapper{rc.Width, rc.Length}, nil
+	case "startEnd":
+		start, err := rc.Start.capper()
+		if err != nil {
+			return nil, err
+		}
+		end, err := rc.End.capper()
+		if err != nil {
+			return nil, err
+		}
+		return StartEndCapper{start, end}, nil
+	}
+	return nil, ErrInvalidRecording
+}
+
+func (rj *recordJoiner) joiner() (Joiner, error) {
+	if rj == nil {
+		return nil, nil
+	}
+	switch rj.Type {
+	case "bevel":
+		return BevelJoin, nil
+	case "round":
+		return RoundJoin, nil
+	case "miter", "arcs":
+		gap, err := rj.Gap.joiner()
+		if err != nil {
+			return nil, err
+		}
+		if rj.Type == "miter" {
+			return MiterJoiner{gap, rj.Limit}, nil
+		}
+		return ArcsJoiner{gap, rj.Limit}, nil
+	}
+	return nil, ErrInvalidRecording
+}
@@ -0,0 +1,162 @@
+package canvas
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestRecording(t *testing.T) {
+	gradient := NewLinearGradient(Point{0.0, 0.0}, Point{10.0, 0.0})
+	gradient.Add(0.0, Red)
+	gradient.Add(1.0, Blue)
+
+	c := New(100.0, 50.0)
+	c.Metadata.Title = "Recording"
+	ctx := NewContext(c)
+	ctx.SetFillGradient(gradient)
+	ctx.SetStrokeColor(Black)
+	ctx.SetStrokeCapper(ArrowCap)
+	ctx.SetStrokeJoiner(ArcsClipJoin)
+	ctx.SetDashes(1.0, 2.0, 3.0)
+	ctx.DrawPath(10.0, 10.0, Rectangle(20.0, 10.0))
+	c.SetZIndex(1)
+	c.BeginGroup(0.5)
+	c.RenderImage(image.NewRGBA(image.Rect(0, 0, 2, 2)), Identity.Translate(5.0, 5.0))
+	c.RenderImage(image.NewRGBA(image.Rect(0, 0, 2, 2)), Identity)
+	c.EndGroup()
+
+	b, err := c.MarshalBinary()
+	test.Error(t, err)
+	cb := &Canvas{}
+	test.Error(t, cb.UnmarshalBinary(b))
+
+	b, err = json.Marshal(c)
+	test.Error(t, err)
+	cj := &Canvas{}
+	test.Error(t, json.Unmarshal(b, cj))
+
+	for _, r := range []*Canvas{cb, cj} {
+		test.T(t, r.W, 100.0)
+		test.T(t, r.Metadata.Title, "Recording")
+		test.T(t, r.ZIndices(), []int{0, 1})
+		test.T(t, len(r.layers[0]), 1)
+		test.T(t, len(r.layers[1]), 4)
+
+		l := r.layers[0][0]
+		test.T(t, l.path, c.layers[0][0].path)
+		test.T(t, l.m, c.layers[0][0].m)
+		test.T(t, l.style.Fill.Gradient.(*LinearGradient).Stops, gradient.Stops)
+		test.T(t, l.style.Stroke, Paint{Color: Black})
+		test.T(t, l.style.StrokeCapper, ArrowCap)
+		test.T(t, l.style.StrokeJoiner, ArcsClipJoin)
+		test.T(t, l.style.Dashes, []float64{2.0, 3.0})
+
+		test.That(t, r.layers[1][0].beginGroup)
+		test.T(t, r.layers[1][0].opacity, 0.5)
+		test.T(t, r.layers[1][1].img.Bounds(), image.Rect(0, 0, 2, 2))
+		test.T(t, r.layers[1][1].m, Identity.Translate(5.0, 5.0))
+		test.That(t, r.layers[1][3].endGroup)
+	}
+
+	// images are stored once
+	rec, err := c.recording()
+	test.Error(t, err)
+	test.T(t, len(rec.Images), 1)
+}
+
+func TestRecordingFilter(t *testing.T) {
+	c := New(10.0, 10.0)
+	c.BeginFilter(NewBlurFilter(0.5))
+	c.BeginFilter(NewConvolveFilter(1, []float64{2.0}))
+	c.RenderPath(Rectangle(5.0, 5.0), DefaultStyle, Identity)
+	c.EndFilter()
+	c.EndFilter()
+
+	b, err := json.Marshal(c)
+	test.Error(t, err)
+	r := &Canvas{}
+	test.Error(t, json.Unmarshal(b, r))
+	test.T(t, len(r.layers[0]), 5)
+	test.T(t, r.layers[0][0].filter, Filter(NewBlurFilter(0.5)))
+	test.T(t, r.layers[0][1].filter, Filter(NewConvolveFilter(1, []float64{2.0})))
+	test.That(t, r.layers[0][4].endGroup)
+
+	c.BeginFilter(struct{ Filter }{NewBlurFilter(0.5)})
+	_, err = c.MarshalBinary()
+	test.That(t, err != nil)
+}
+
+func TestRecordingPattern(t *testing.T) {
+	tile := New(2.0, 2.0)
+	tile.RenderPath(Circle(1.0).Translate(1.0, 1.0), DefaultStyle, Identity)
+
+	pathGradient := NewPathGradient()
+	pathGradient.Add(0.0, Red)
+	pathGradient.Add(1.0, Blue)
+
+	c := New(10.0, 10.0)
+	style := DefaultStyle
+	style.Fill = Paint{Pattern: NewLineHatch(Red, 45.0, 1.0, 0.1)}
+	c.RenderPath(Rectangle(5.0, 5.0), style, Identity)
+	style.Fill = Paint{Pattern: NewCrossHatch(Paint{Gradient: pathGradient}, 0.0, 90.0, 1.0, 2.0, 0.1)}
+	c.RenderPath(Rectangle(5.0, 5.0), style, Identity)
+	style.Fill = Paint{Pattern: NewShapeHatch(Blue, Circle(0.2), 1.0, 0.0)}
+	c.RenderPath(Rectangle(5.0, 5.0), style, Identity)
+	style.Fill = Paint{Pattern: NewCanvasPattern(tile, Identity.Rotate(30.0), 0.5)}
+	c.RenderPath(Rectangle(5.0, 5.0), style, Identity)
+	style.Fill = Paint{Gradient: pathGradient}
+	c.RenderPath(Rectangle(5.0, 5.0), style, Identity)
+
+	b, err := c.MarshalBinary()
+	test.Error(t, err)
+	cb := &Canvas{}
+	test.Error(t, cb.UnmarshalBinary(b))
+
+	b, err = json.Marshal(c)
+	test.Error(t, err)
+	cj := &Canvas{}
+	test.Error(t, json.Unmarshal(b, cj))
+
+	clip := Rectangle(5.0, 5.0)
+	for _, r := range []*Canvas{cb, cj} {
+		test.T(t, len(r.layers[0]), 5)
+		for i := 0; i < 3; i++ {
+			hatch := r.layers[0][i].style.Fill.Pattern.(*HatchPattern)
+			orig := c.layers[0][i].style.Fill.Pattern.(*HatchPattern)
+			test.T(t, hatch.Tile(clip), orig.Tile(clip))
+		}
+		test.T(t, r.layers[0][1].style.Fill.Pattern.(*HatchPattern).Fill.Gradient.(*PathGradient).Stops, pathGradient.Stops)
+
+		pattern := r.layers[0][3].style.Fill.Pattern.(*CanvasPattern)
+		test.T(t, pattern.View, Identity.Rotate(30.0))
+		test.T(t, pattern.Spacing, 0.5)
+		test.T(t, pattern.Canvas.W, 2.0)
+		test.T(t, pattern.Canvas.layers[0][0].path, tile.layers[0][0].path)
+
+		test.T(t, r.layers[0][4].style.Fill.Gradient.(*PathGradient).Stops, pathGradient.Stops)
+	}
+
+	// custom hatchers cannot be serialized
+	style.Fill = Paint{Pattern: NewHatchPattern(Red, 0.1, Identity, func(x0, y0, x1, y1 float64) *Path { return &Path{} })}
+	c.RenderPath(Rectangle(5.0, 5.0), style, Identity)
+	_, err = c.MarshalBinary()
+	test.That(t, err != nil)
+}
+
+func TestRecordingErrors(t *testing.T) {
+	c := &Canvas{}
+	test.T(t, c.UnmarshalBinary([]byte("invalid")), ErrInvalidRecording)
+	test.T(t, c.UnmarshalJSON([]byte(`{"version":2}`)), ErrRecordingVersion)
+	test.T(t, c.UnmarshalJSON([]byte(`{"version":1,"layers":[{"path":[2,3,0,0]}]}`)), ErrInvalidRecording)
+	test.T(t, c.UnmarshalJSON([]byte(`{"version":1,"layers":[{"image":1}]}`)), ErrInvalidRecording)
+
+	buf := &bytes.Buffer{}
+	test.Error(t, WriteRecording(buf, New(10.0, 10.0)))
+	r, err := ReadRecording(buf)
+	test.Error(t, err)
+	test.T(t, r.W, 10.0)
+}
@@ -0,0 +1,98 @@
+package ebiten
+
+import (
+	"image"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/canvas/renderers/rasterizer"
+	"golang.org/x/image/draw"
+)
+
+// Ebiten is a renderer that draws a canvas into an ebiten.Image, such as for vector HUDs and debug overlays in games. Draw onto it using canvas.NewContext, and mark the regions that changed since the last frame with Invalidate so that only those are rasterized and uploaded to the GPU. Set rasterizer.DefaultGlyphAtlas to share rasterized glyphs between frames, so that text is not rasterized again every frame.
+type Ebiten struct {
+	*canvas.Canvas
+	resolution canvas.Resolution
+	colorSpace canvas.ColorSpace
+
+	img   *image.RGBA
+	image *ebiten.Image
+	dirty image.Rectangle
+	buf   []byte
+}
+
+// New returns an Ebiten renderer of the given size in millimeters, whose image has the size multiplied by the resolution in pixels. Colors are in sRGB, as expected by Ebiten.
+func New(width, height float64, resolution canvas.Resolution) *Ebiten {
+	img := image.NewRGBA(image.Rect(0, 0, int(width*resolution.DPMM()+0.5), int(height*resolution.DPMM()+0.5)))
+	return &Ebiten{
+		Canvas:     canvas.New(width, height),
+		resolution: resolution,
+		colorSpace: canvas.DefaultColorSpace,
+		img:        img,
+		image:      ebiten.NewImage(img.Bounds().Dx(), img.Bounds().Dy()),
+		dirty:      img.Bounds(),
+	}
+}
+
+// Invalidate marks the rectangle in millimeters as changed, so that it is drawn again by the next call to Image.
+func (r *Ebiten) Invalidate(rect canvas.Rect) {
+	dpmm := r.resolution.DPMM()
+	height := r.img.Bounds().Dy()
+	x0 := int(math.Floor(rect.X*dpmm)) - 1 // include anti-aliased edges
+	x1 := int(math.Ceil((rect.X+rect.W)*dpmm)) + 1
+	y0 := height - int(math.Ceil((rect.Y+rect.H)*dpmm)) - 1
+	y1 := height - int(math.Floor(rect.Y*dpmm)) + 1
+	r.dirty = r.dirty.Union(image.Rect(x0, y0, x1, y1).Intersect(r.img.Bounds()))
+}
+
+// InvalidateAll marks the whole canvas as changed, so that it is drawn again by the next call to Image.
+func (r *Ebiten) InvalidateAll() {
+	r.dirty = r.img.Bounds()
+}
+
+// Image returns the Ebiten image of the canvas, after drawing and uploading the regions that were invalidated since the last call.
+func (r *Ebiten) Image() *ebiten.Image {
+	dirty, pix := r.redraw()
+	if dirty.Empty() {
+		return r.image
+	} else if dirty == r.img.Bounds() {
+		r.image.WritePixels(pix)
+	} else {
+		r.image.SubImage(dirty).(*ebiten.Image).WritePixels(pix)
+	}
+	return r.image
+}
+
+// redraw rasterizes the regions that were invalidated since the last call, and returns the dirty region and its pixels contiguous in memory as required for uploading.
+func (r *Ebiten) redraw() (image.Rectangle, []byte) {
+	if r.dirty.Empty() {
+		return image.Rectangle{}, nil
+	}
+	dirty := r.dirty
+	r.dirty = image.Rectangle{}
+
+	// rasterize the dirty region only, keeping the canvas coordinates of the full image
+	dpmm := r.resolution.DPMM()
+	sub := r.img.SubImage(dirty).(*image.RGBA)
+	draw.Draw(sub, dirty, image.Transparent, image.Point{}, draw.Src)
+	ras := rasterizer.FromImage(sub, r.resolution, r.colorSpace)
+	r.RenderViewTo(ras, canvas.Identity.Translate(0.0, float64(dirty.Dy()-r.img.Bounds().Dy())/dpmm))
+	ras.Close()
+
+	if dirty == r.img.Bounds() {
+		return dirty, r.img.Pix
+	}
+	n := 4 * dirty.Dx()
+	r.buf = r.buf[:0]
+	for y := 0; y < dirty.Dy(); y++ {
+		i := sub.PixOffset(dirty.Min.X, dirty.Min.Y+y)
+		r.buf = append(r.buf, sub.Pix[i:i+n]...)
+	}
+	return dirty, r.buf
+}
+
+// DrawTo draws the canvas onto dst, such as the screen in the Draw method of an ebiten.Game, using the given options which may be nil.
+func (r *Ebiten) DrawTo(dst *ebiten.Image, options *ebiten.DrawImageOptions) {
+	dst.DrawImage(r.Image(), options)
+}
@@ -0,0 +1,59 @@
+package ebiten
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/test"
+)
+
+func TestEbitenRedraw(t *testing.T) {
+	// the Ebiten image is not used, so that no graphics device is needed
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	r := &Ebiten{
+		Canvas:     canvas.New(10.0, 10.0),
+		resolution: canvas.DPMM(1.0),
+		colorSpace: canvas.LinearColorSpace{},
+		img:        img,
+		dirty:      img.Bounds(),
+	}
+	ctx := canvas.NewContext(r.Canvas)
+	ctx.SetFillColor(canvas.Red)
+	ctx.DrawPath(2.0, 2.0, canvas.Rectangle(3.0, 3.0))
+
+	dirty, pix := r.redraw()
+	test.T(t, dirty, img.Bounds())
+	test.T(t, len(pix), len(img.Pix))
+	test.T(t, img.RGBAAt(2, 5), canvas.Red)
+	test.T(t, img.RGBAAt(4, 7), canvas.Red)
+	test.T(t, img.RGBAAt(5, 7), color.RGBA{})
+
+	dirty, _ = r.redraw()
+	test.That(t, dirty.Empty(), "nothing must be redrawn without changes")
+
+	// dirty region in pixels from the top-left, including a pixel margin for anti-aliasing
+	r.Invalidate(canvas.Rect{X: 6.0, Y: 6.0, W: 2.0, H: 2.0})
+	test.T(t, r.dirty, image.Rect(5, 1, 9, 5))
+	r.Invalidate(canvas.Rect{X: 9.0, Y: 9.0, W: 2.0, H: 2.0})
+	test.T(t, r.dirty, image.Rect(5, 0, 10, 5)) // clipped to the image
+
+	ctx.SetFillColor(canvas.Blue)
+	ctx.DrawPath(6.0, 6.0, canvas.Rectangle(2.0, 2.0))
+	img.SetRGBA(2, 5, canvas.Green) // outside the dirty region, must remain untouched
+
+	dirty, pix = r.redraw()
+	test.T(t, dirty, image.Rect(5, 0, 10, 5))
+	test.T(t, len(pix), 4*5*5)
+	test.T(t, img.RGBAAt(6, 2), canvas.Blue)
+	test.T(t, img.RGBAAt(7, 3), canvas.Blue)
+	test.T(t, img.RGBAAt(8, 3), color.RGBA{})
+	test.T(t, img.RGBAAt(6, 4), color.RGBA{})
+	test.T(t, img.RGBAAt(2, 5), canvas.Green)
+
+	// uploaded pixels are contiguous rows of the dirty region
+	i := 4 * (2*5 + 1) // pixel (6,2)
+	test.T(t, color.RGBA{pix[i], pix[i+1], pix[i+2], pix[i+3]}, canvas.Blue)
+	test.T(t, color.RGBA{pix[0], pix[1], pix[2], pix[3]}, color.RGBA{})
+}
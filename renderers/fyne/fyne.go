@@ -1,8 +1,13 @@
 package fyne
 
 import (
+	"image"
+	"image/color"
+	"math"
+
 	"fyne.io/fyne/v2"
 	fyneCanvas "fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
 	"github.com/tdewolff/canvas"
 	"github.com/tdewolff/canvas/renderers/rasterizer"
 )
@@ -12,7 +17,7 @@ type Fyne struct {
 	resolution canvas.Resolution
 }
 
-// New returns a Fyne renderer.
+// New returns a Fyne renderer. The resolution sets the minimum size of its content in Fyne's device-independent pixels.
 func New(width, height float64, resolution canvas.Resolution) *Fyne {
 	return &Fyne{
 		Canvas:     canvas.New(width, height),
@@ -20,9 +25,241 @@ func New(width, height float64, resolution canvas.Resolution) *Fyne {
 	}
 }
 
-func (r *Fyne) Content() fyne.CanvasObject {
-	ras := rasterizer.New(r.W, r.H, r.resolution, canvas.LinearColorSpace{})
-	r.RenderTo(ras)
-	ras.Close()
-	return fyneCanvas.NewImageFromImage(ras)
+// Content returns a Fyne container with canvas objects that draw the canvas, scaled to fit the container while keeping its aspect ratio. Rectangles, circles, and straight lines with a color fill or stroke become native Fyne rectangles, circles, and lines, and images that are only scaled and translated become Fyne images. Other paths, text, and images are rasterized separately at their size in device pixels whenever Fyne draws them, so that they stay sharp when resized or on high-DPI screens. Call Content again after changing the canvas.
+func (r *Fyne) Content() *fyne.Container {
+	objects := &objects{
+		width:  r.W,
+		height: r.H,
+	}
+	r.RenderTo(objects)
+
+	dpmm := r.resolution.DPMM()
+	layout := &layout{
+		objects: objects,
+		minSize: fyne.NewSize(float32(r.W*dpmm), float32(r.H*dpmm)),
+	}
+	content := make([]fyne.CanvasObject, len(objects.list))
+	for i, o := range objects.list {
+		content[i] = o.obj
+	}
+	return container.New(layout, content...)
+}
+
+// object is a Fyne canvas object with its bounds in millimeters, its stroke width in millimeters, and its endpoints for lines.
+type object struct {
+	obj         fyne.CanvasObject
+	rect        canvas.Rect
+	strokeWidth float64
+	line        [2]canvas.Point
+}
+
+// objects is a renderer that converts drawing operations into Fyne canvas objects.
+type objects struct {
+	width, height float64
+	list          []object
+}
+
+// Size returns the size of the canvas in millimeters.
+func (r *objects) Size() (float64, float64) {
+	return r.width, r.height
+}
+
+// RenderPath renders a path to the canvas using a style and a transformation matrix.
+func (r *objects) RenderPath(path *canvas.Path, style canvas.Style, m canvas.Matrix) {
+	if r.renderNative(path, style, m) {
+		return
+	}
+
+	bounds := canvas.Rect{}
+	if style.HasFill() {
+		bounds = path.Transform(m).Bounds()
+	}
+	if style.HasStroke() {
+		stroke := path
+		if style.IsDashed() {
+			stroke = stroke.Dash(style.DashOffset, style.Dashes...)
+		}
+		stroke = stroke.Stroke(style.StrokeWidth, style.StrokeCapper, style.StrokeJoiner, canvas.Tolerance)
+		bounds = bounds.Add(stroke.Transform(m).Bounds())
+	}
+	r.renderRaster(bounds, func(c *canvas.Canvas, view canvas.Matrix) {
+		c.RenderPath(path, style, view.Mul(m))
+	})
+}
+
+// renderNative adds a Fyne rectangle, circle, or line for the path if possible, which requires a color fill and stroke, no dashes, and a transformation that preserves angles.
+func (r *objects) renderNative(path *canvas.Path, style canvas.Style, m canvas.Matrix) bool {
+	if style.HasFill() && !style.Fill.IsColor() || style.HasStroke() && (!style.Stroke.IsColor() || style.IsDashed()) || style.Shadow != nil || !m.IsSimilarity() {
+		return false
+	}
+
+	var fill, stroke color.Color = color.Transparent, color.Transparent
+	if style.HasFill() {
+		fill = style.Fill.Color
+	}
+	strokeWidth := 0.0
+	if style.HasStroke() {
+		stroke = style.Stroke.Color
+		strokeWidth = style.StrokeWidth * math.Sqrt(math.Abs(m.Det()))
+	}
+
+	path = path.Transform(m)
+	if rect, ok := rectangle(path); ok {
+		obj := fyneCanvas.NewRectangle(fill)
+		obj.StrokeColor = stroke
+		r.list = append(r.list, object{obj: obj, rect: rect, strokeWidth: strokeWidth})
+		return true
+	} else if rect, ok := circle(path); ok {
+		obj := fyneCanvas.NewCircle(fill)
+		obj.StrokeColor = stroke
+		r.list = append(r.list, object{obj: obj, rect: rect, strokeWidth: strokeWidth})
+		return true
+	} else if pts, ok := line(path); ok && !style.HasFill() && style.HasStroke() {
+		obj := fyneCanvas.NewLine(stroke)
+		r.list = append(r.list, object{obj: obj, strokeWidth: strokeWidth, line: pts})
+		return true
+	}
+	return false
+}
+
+// RenderText renders a text object to the canvas using a transformation matrix.
+func (r *objects) RenderText(text *canvas.Text, m canvas.Matrix) {
+	text.RenderAsPath(r, m, 0.0)
+}
+
+// RenderImage renders an image to the canvas using a transformation matrix.
+func (r *objects) RenderImage(img image.Image, m canvas.Matrix) {
+	size := img.Bounds().Size()
+	bounds := canvas.Rect{W: float64(size.X), H: float64(size.Y)}.Transform(m)
+	if m[0][1] == 0.0 && m[1][0] == 0.0 && 0.0 < m[0][0] && 0.0 < m[1][1] {
+		obj := fyneCanvas.NewImageFromImage(img)
+		obj.FillMode = fyneCanvas.ImageFillStretch
+		r.list = append(r.list, object{obj: obj, rect: bounds})
+		return
+	}
+	r.renderRaster(bounds, func(c *canvas.Canvas, view canvas.Matrix) {
+		c.RenderImage(img, view.Mul(m))
+	})
+}
+
+// renderRaster adds a Fyne raster for the bounds in millimeters, which rasterizes what render draws onto a canvas of the size of the bounds whenever Fyne draws it.
+func (r *objects) renderRaster(bounds canvas.Rect, render func(*canvas.Canvas, canvas.Matrix)) {
+	if bounds.W <= 0.0 || bounds.H <= 0.0 {
+		return
+	}
+	c := canvas.New(bounds.W, bounds.H)
+	render(c, canvas.Identity.Translate(-bounds.X, -bounds.Y))
+
+	var img *image.RGBA
+	obj := fyneCanvas.NewRaster(func(w, h int) image.Image {
+		if img == nil || img.Bounds().Dx() != w || img.Bounds().Dy() != h {
+			img = image.NewRGBA(image.Rect(0, 0, w, h))
+		}
+		if 0 < w && 0 < h {
+			rasterizer.DrawInto(img, c, canvas.DPMM(float64(w)/bounds.W), canvas.LinearColorSpace{})
+		}
+		return img
+	})
+	r.list = append(r.list, object{obj: obj, rect: bounds})
+}
+
+// layout positions the canvas objects, scaling the canvas to fit the container while keeping its aspect ratio and centering it.
+type layout struct {
+	objects *objects
+	minSize fyne.Size
+}
+
+// Layout positions and resizes the objects for the size of the container.
+func (l *layout) Layout(_ []fyne.CanvasObject, size fyne.Size) {
+	width, height := l.objects.width, l.objects.height
+	if width == 0.0 || height == 0.0 {
+		return
+	}
+	scale := math.Min(float64(size.Width)/width, float64(size.Height)/height)
+	dx := (float64(size.Width) - width*scale) / 2.0
+	dy := (float64(size.Height) - height*scale) / 2.0
+	pos := func(p canvas.Point) fyne.Position {
+		return fyne.NewPos(float32(dx+p.X*scale), float32(dy+(height-p.Y)*scale))
+	}
+
+	for _, o := range l.objects.list {
+		strokeWidth := float32(o.strokeWidth * scale)
+		switch obj := o.obj.(type) {
+		case *fyneCanvas.Line:
+			obj.Position1, obj.Position2 = pos(o.line[0]), pos(o.line[1])
+			obj.StrokeWidth = strokeWidth
+			continue
+		case *fyneCanvas.Rectangle:
+			obj.StrokeWidth = strokeWidth
+		case *fyneCanvas.Circle:
+			obj.StrokeWidth = strokeWidth
+		}
+		o.obj.Move(pos(canvas.Point{X: o.rect.X, Y: o.rect.Y + o.rect.H}))
+		o.obj.Resize(fyne.NewSize(float32(o.rect.W*scale), float32(o.rect.H*scale)))
+	}
+}
+
+// MinSize returns the size of the canvas at the renderer's resolution.
+func (l *layout) MinSize(_ []fyne.CanvasObject) fyne.Size {
+	return l.minSize
+}
+
+// rectangle returns the bounds of the path if it is a single closed axis-aligned rectangle.
+func rectangle(p *canvas.Path) (canvas.Rect, bool) {
+	if !p.Flat() || !p.Closed() || p.HasSubpaths() {
+		return canvas.Rect{}, false
+	}
+	coords := p.Coords()
+	if len(coords) != 5 || !coords[0].Equals(coords[4]) {
+		return canvas.Rect{}, false
+	}
+	for i := 0; i < 4; i++ {
+		a, b, c := coords[i], coords[i+1], coords[(i+2)%4]
+		horizontal := canvas.Equal(a.Y, b.Y) && !canvas.Equal(a.X, b.X)
+		vertical := canvas.Equal(a.X, b.X) && !canvas.Equal(a.Y, b.Y)
+		if !horizontal && !vertical || horizontal && !canvas.Equal(b.X, c.X) || vertical && !canvas.Equal(b.Y, c.Y) {
+			return canvas.Rect{}, false
+		}
+	}
+	return p.Bounds(), true
+}
+
+// circle returns the bounds of the path if it is a single closed circle made of arcs.
+func circle(p *canvas.Path) (canvas.Rect, bool) {
+	if p.Flat() || !p.Closed() || p.HasSubpaths() {
+		return canvas.Rect{}, false
+	}
+	bounds := p.Bounds()
+	radius := bounds.W / 2.0
+	if radius <= 0.0 || !canvas.Equal(bounds.W, bounds.H) {
+		return canvas.Rect{}, false
+	}
+	for scanner := p.Scanner(); scanner.Scan(); {
+		switch scanner.Cmd() {
+		case canvas.MoveToCmd:
+		case canvas.ArcToCmd:
+			if rx, ry, _, _, _ := scanner.Arc(); !canvas.Equal(rx, radius) || !canvas.Equal(ry, radius) {
+				return canvas.Rect{}, false
+			}
+		case canvas.CloseCmd:
+			if !scanner.Start().Equals(scanner.End()) {
+				return canvas.Rect{}, false
+			}
+		default:
+			return canvas.Rect{}, false
+		}
+	}
+	return bounds, true
+}
+
+// line returns the endpoints of the path if it is a single open straight line.
+func line(p *canvas.Path) ([2]canvas.Point, bool) {
+	if !p.Flat() || p.Closed() || p.HasSubpaths() {
+		return [2]canvas.Point{}, false
+	}
+	coords := p.Coords()
+	if len(coords) != 2 {
+		return [2]canvas.Point{}, false
+	}
+	return [2]canvas.Point{coords[0], coords[1]}, true
 }
@@ -0,0 +1,50 @@
+package fyne
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	fyneCanvas "fyne.io/fyne/v2/canvas"
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/test"
+)
+
+func TestFyneContent(t *testing.T) {
+	r := New(20.0, 10.0, canvas.DPMM(1.0))
+	ctx := canvas.NewContext(r)
+	ctx.SetFillColor(canvas.Red)
+	ctx.DrawPath(1.0, 1.0, canvas.Rectangle(4.0, 3.0))
+	ctx.DrawPath(10.0, 5.0, canvas.Circle(2.0))
+	ctx.SetFillColor(canvas.Transparent)
+	ctx.SetStrokeColor(canvas.Blue)
+	ctx.SetStrokeWidth(0.5)
+	ctx.DrawPath(0.0, 9.0, canvas.Line(20.0, 0.0))
+	ctx.SetFillColor(canvas.Green)
+	ctx.SetStrokeColor(canvas.Transparent)
+	ctx.DrawPath(17.0, 2.0, canvas.RegularPolygon(3, 2.0, true))
+
+	content := r.Content()
+	test.T(t, len(content.Objects), 4)
+	test.T(t, content.MinSize(), fyne.NewSize(20.0, 10.0))
+	rect, ok := content.Objects[0].(*fyneCanvas.Rectangle)
+	test.That(t, ok, "must be a native rectangle")
+	circle, ok := content.Objects[1].(*fyneCanvas.Circle)
+	test.That(t, ok, "must be a native circle")
+	line, ok := content.Objects[2].(*fyneCanvas.Line)
+	test.That(t, ok, "must be a native line")
+	_, ok = content.Objects[3].(*fyneCanvas.Raster)
+	test.That(t, ok, "other paths must be rasterized")
+	test.T(t, rect.FillColor, canvas.Red)
+	test.T(t, circle.FillColor, canvas.Red)
+	test.T(t, line.StrokeColor, canvas.Blue)
+
+	// objects are scaled to fit the container, with the origin at the bottom-left
+	content.Resize(fyne.NewSize(40.0, 20.0))
+	test.T(t, rect.Position(), fyne.NewPos(2.0, 12.0))
+	test.T(t, rect.Size(), fyne.NewSize(8.0, 6.0))
+	test.T(t, circle.Position(), fyne.NewPos(16.0, 6.0))
+	test.T(t, circle.Size(), fyne.NewSize(8.0, 8.0))
+	test.T(t, line.Position1, fyne.NewPos(0.0, 2.0))
+	test.T(t, line.Position2, fyne.NewPos(40.0, 2.0))
+	test.T(t, line.StrokeWidth, float32(1.0))
+}
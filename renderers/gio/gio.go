@@ -112,18 +112,79 @@ func (r *Gio) renderPath(path *canvas.Path, fill canvas.Paint) {
 	if fill.IsColor() {
 		paint.Fill(r.ops, toNRGBA(fill.Color))
 	} else if fill.IsGradient() {
-		if g, ok := fill.Gradient.(*canvas.LinearGradient); ok && len(g.Stops) == 2 {
-			linearGradient := paint.LinearGradientOp{}
-			linearGradient.Stop1 = r.point(g.Start)
-			linearGradient.Stop2 = r.point(g.End)
-			linearGradient.Color1 = toNRGBA(g.Stops[0].Color)
-			linearGradient.Color2 = toNRGBA(g.Stops[1].Color)
-			linearGradient.Add(r.ops)
-			paint.PaintOp{}.Add(r.ops)
+		if g, ok := fill.Gradient.(*canvas.LinearGradient); ok && 2 <= len(g.Stops) {
+			for _, strip := range gradientStrips(g, path.FastBounds()) {
+				// Gio only supports two stops, paint each pair of stops within its own strip
+				p := clip.Path{}
+				p.Begin(r.ops)
+				p.MoveTo(r.point(strip.quad[0]))
+				for _, q := range strip.quad[1:] {
+					p.LineTo(r.point(q))
+				}
+				p.Close()
+				stack := clip.Outline{p.End()}.Op().Push(r.ops)
+
+				if strip.stops[0].Color == strip.stops[1].Color {
+					paint.ColorOp{Color: toNRGBA(strip.stops[0].Color)}.Add(r.ops)
+				} else {
+					linearGradient := paint.LinearGradientOp{}
+					linearGradient.Stop1 = r.point(g.Start.Interpolate(g.End, strip.stops[0].Offset))
+					linearGradient.Stop2 = r.point(g.Start.Interpolate(g.End, strip.stops[1].Offset))
+					linearGradient.Color1 = toNRGBA(strip.stops[0].Color)
+					linearGradient.Color2 = toNRGBA(strip.stops[1].Color)
+					linearGradient.Add(r.ops)
+				}
+				paint.PaintOp{}.Add(r.ops)
+				stack.Pop()
+			}
 		}
 	}
 }
 
+// gradientStrip is the area between two consecutive stops of a linear gradient.
+type gradientStrip struct {
+	quad  [4]canvas.Point
+	stops [2]canvas.Stop
+}
+
+// gradientStrips splits a linear gradient into strips perpendicular to the gradient between each pair of consecutive stops, that cover the given bounds. The first and last strip extend beyond the first and last stop to pad with their colors.
+func gradientStrips(g *canvas.LinearGradient, bounds canvas.Rect) []gradientStrip {
+	axis := g.End.Sub(g.Start)
+	length := axis.Length()
+	if length == 0.0 {
+		last := g.Stops[len(g.Stops)-1]
+		return []gradientStrip{{
+			quad:  [4]canvas.Point{{bounds.X, bounds.Y}, {bounds.X + bounds.W, bounds.Y}, {bounds.X + bounds.W, bounds.Y + bounds.H}, {bounds.X, bounds.Y + bounds.H}},
+			stops: [2]canvas.Stop{last, last},
+		}}
+	}
+
+	// far is the distance from the start of the gradient beyond which the bounds lie
+	dir := axis.Div(length)
+	normal := dir.Rot90CCW()
+	far := bounds.W + bounds.H + g.Start.Sub(canvas.Point{bounds.X, bounds.Y}).Length() + 1.0
+
+	strips := []gradientStrip{}
+	for i := 0; i+1 < len(g.Stops); i++ {
+		t0, t1 := g.Stops[i].Offset*length, g.Stops[i+1].Offset*length
+		if i == 0 {
+			t0 = -far
+		}
+		if i+2 == len(g.Stops) {
+			t1 = far
+		}
+		if t1 <= t0 {
+			continue // hard color transition
+		}
+		p0, p1 := g.Start.Add(dir.Mul(t0)), g.Start.Add(dir.Mul(t1))
+		strips = append(strips, gradientStrip{
+			quad:  [4]canvas.Point{p0.Sub(normal.Mul(far)), p1.Sub(normal.Mul(far)), p1.Add(normal.Mul(far)), p0.Add(normal.Mul(far))},
+			stops: [2]canvas.Stop{g.Stops[i], g.Stops[i+1]},
+		})
+	}
+	return strips
+}
+
 // RenderPath renders a path to the canvas using a style and a transformation matrix.
 func (r *Gio) RenderPath(path *canvas.Path, style canvas.Style, m canvas.Matrix) {
 	if style.HasFill() {
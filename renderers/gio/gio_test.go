@@ -0,0 +1,54 @@
+package gio
+
+import (
+	"testing"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/test"
+)
+
+func TestGradientStrips(t *testing.T) {
+	g := canvas.NewLinearGradient(canvas.Point{0.0, 0.0}, canvas.Point{10.0, 0.0})
+	g.Add(0.0, canvas.Red)
+	g.Add(0.5, canvas.Green)
+	g.Add(1.0, canvas.Blue)
+
+	// the outer strips extend far beyond the bounds to pad with the first and last color
+	strips := gradientStrips(g, canvas.Rect{X: 0.0, Y: 0.0, W: 10.0, H: 10.0})
+	test.T(t, len(strips), 2)
+	test.T(t, strips[0].stops, [2]canvas.Stop{{0.0, canvas.Red}, {0.5, canvas.Green}})
+	test.T(t, strips[0].quad, [4]canvas.Point{{-21.0, -21.0}, {5.0, -21.0}, {5.0, 21.0}, {-21.0, 21.0}})
+	test.T(t, strips[1].stops, [2]canvas.Stop{{0.5, canvas.Green}, {1.0, canvas.Blue}})
+	test.T(t, strips[1].quad, [4]canvas.Point{{5.0, -21.0}, {21.0, -21.0}, {21.0, 21.0}, {5.0, 21.0}})
+
+	// hard color transitions have no strip
+	g.Stops = canvas.Stops{{0.0, canvas.Red}, {0.5, canvas.Red}, {0.5, canvas.Blue}, {1.0, canvas.Blue}}
+	strips = gradientStrips(g, canvas.Rect{X: 0.0, Y: 0.0, W: 10.0, H: 10.0})
+	test.T(t, len(strips), 2)
+	test.T(t, strips[0].stops, [2]canvas.Stop{{0.0, canvas.Red}, {0.5, canvas.Red}})
+	test.T(t, strips[1].stops, [2]canvas.Stop{{0.5, canvas.Blue}, {1.0, canvas.Blue}})
+
+	// gradients without length have the last color
+	g = canvas.NewLinearGradient(canvas.Point{5.0, 5.0}, canvas.Point{5.0, 5.0})
+	g.Add(0.0, canvas.Red)
+	g.Add(1.0, canvas.Blue)
+	strips = gradientStrips(g, canvas.Rect{X: 0.0, Y: 0.0, W: 10.0, H: 10.0})
+	test.T(t, len(strips), 1)
+	test.T(t, strips[0].stops, [2]canvas.Stop{{1.0, canvas.Blue}, {1.0, canvas.Blue}})
+}
+
+func TestGioRenderPath(t *testing.T) {
+	gtx := layout.Context{Ops: new(op.Ops)}
+	r := New(gtx, 10.0, 10.0)
+
+	g := canvas.NewLinearGradient(canvas.Point{0.0, 0.0}, canvas.Point{10.0, 0.0})
+	g.Add(0.0, canvas.Red)
+	g.Add(0.5, canvas.Green)
+	g.Add(1.0, canvas.Blue)
+	style := canvas.DefaultStyle
+	style.Fill = canvas.Paint{Gradient: g}
+	r.RenderPath(canvas.Rectangle(10.0, 10.0), style, canvas.Identity)
+	test.T(t, r.Dimensions().Size.X, 10)
+}
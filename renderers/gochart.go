@@ -23,33 +23,47 @@ type GoChart struct {
 	fontColor    drawing.Color
 	textRotation float64
 
-	fonts map[string]*canvas.FontFamily
+	fonts     map[string]*canvas.FontFamily
+	pathEmpty bool
 }
 
 // NewGoChart returns a new github.com/wcharczuk/go-chart renderer.
 func NewGoChart(writer canvas.Writer) func(int, int) (chart.Renderer, error) {
 	return func(w, h int) (chart.Renderer, error) {
 		c := canvas.New(float64(w)*mmPerPx, float64(h)*mmPerPx)
-		gochart := &GoChart{
-			c:         c,
-			ctx:       canvas.NewContext(c),
-			height:    float64(h) * mmPerPx,
-			writer:    writer,
-			dpi:       chart.DefaultDPI,
-			fontSize:  12.0, // uses default of github.com/golang/freetype/truetype
-			fontColor: drawing.ColorTransparent,
-			fonts:     map[string]*canvas.FontFamily{},
-		}
-		gochart.ctx.SetFillColor(canvas.Transparent)
-		gochart.ctx.SetStrokeWidth(chart.DefaultStrokeWidth * mmPerPx)
+		return newGoChart(c, canvas.NewContext(c), float64(h)*mmPerPx, writer)
+	}
+}
 
-		f, err := chart.GetDefaultFont()
-		if err != nil {
-			return nil, err
-		}
-		gochart.SetFont(f)
-		return gochart, nil
+// NewGoChartContext returns a new github.com/wcharczuk/go-chart renderer that draws onto an existing context instead of a new canvas, so that charts can be placed together with other content on a page and written using any of the renderers. The chart's bottom-left is placed at the context's origin, and its size in millimeters is its size in pixels multiplied by 25.4/96. Saving the chart restores the context's state and does nothing else.
+func NewGoChartContext(ctx *canvas.Context) func(int, int) (chart.Renderer, error) {
+	return func(w, h int) (chart.Renderer, error) {
+		return newGoChart(nil, ctx, float64(h)*mmPerPx, nil)
+	}
+}
+
+func newGoChart(c *canvas.Canvas, ctx *canvas.Context, height float64, writer canvas.Writer) (chart.Renderer, error) {
+	gochart := &GoChart{
+		c:         c,
+		ctx:       ctx,
+		height:    height,
+		writer:    writer,
+		dpi:       chart.DefaultDPI,
+		fontSize:  12.0, // uses default of github.com/golang/freetype/truetype
+		fontColor: drawing.ColorTransparent,
+		fonts:     map[string]*canvas.FontFamily{},
+		pathEmpty: true,
+	}
+	gochart.ctx.Push() // restored by Save
+	gochart.ctx.SetFillColor(canvas.Transparent)
+	gochart.ctx.SetStrokeWidth(chart.DefaultStrokeWidth * mmPerPx)
+
+	f, err := chart.GetDefaultFont()
+	if err != nil {
+		return nil, err
 	}
+	gochart.SetFont(f)
+	return gochart, nil
 }
 
 // ResetStyle resets any style related settings of the renderer.
@@ -99,16 +113,19 @@ func (r *GoChart) SetStrokeDashArray(dashArray []float64) {
 
 // MoveTo moves the cursor to a given point.
 func (r *GoChart) MoveTo(x, y int) {
+	r.pathEmpty = false
 	r.ctx.MoveTo(float64(x)*mmPerPx, r.height-float64(y)*mmPerPx)
 }
 
 // LineTo both starts a shape and draws a line to a given point from the previous point.
 func (r *GoChart) LineTo(x, y int) {
+	r.pathEmpty = false
 	r.ctx.LineTo(float64(x)*mmPerPx, r.height-float64(y)*mmPerPx)
 }
 
 // QuadCurveTo draws a quad curve. cx and cy represent the Bézier control points.
 func (r *GoChart) QuadCurveTo(cx, cy, x, y int) {
+	r.pathEmpty = false
 	r.ctx.QuadTo(float64(cx)*mmPerPx, r.height-float64(cy)*mmPerPx, float64(x)*mmPerPx, r.height-float64(y)*mmPerPx)
 }
 
@@ -118,11 +135,12 @@ func (r *GoChart) ArcTo(cx, cy int, rx, ry, startAngle, delta float64) {
 	delta = -delta
 
 	start := canvas.EllipsePos(rx*mmPerPx, ry*mmPerPx, 0.0, float64(cx)*mmPerPx, r.height-float64(cy)*mmPerPx, startAngle)
-	if r.c.Empty() {
+	if r.pathEmpty {
 		r.ctx.MoveTo(start.X, start.Y)
 	} else {
 		r.ctx.LineTo(start.X, start.Y)
 	}
+	r.pathEmpty = false
 
 	startAngle *= 180.0 / math.Pi
 	delta *= 180.0 / math.Pi
@@ -137,16 +155,19 @@ func (r *GoChart) Close() {
 
 // Stroke strokes the path.
 func (r *GoChart) Stroke() {
+	r.pathEmpty = true
 	r.ctx.Stroke()
 }
 
 // Fill fills the path, but does not stroke.
 func (r *GoChart) Fill() {
+	r.pathEmpty = true
 	r.ctx.Fill()
 }
 
 // FillStroke fills and strokes a path.
 func (r *GoChart) FillStroke() {
+	r.pathEmpty = true
 	r.ctx.FillStroke()
 }
 
@@ -217,7 +238,11 @@ func (r *GoChart) ClearTextRotation() {
 	r.textRotation = 0.0
 }
 
-// Save writes the image to the given writer.
+// Save restores the state of the context and writes the image to the given writer.
 func (r *GoChart) Save(w io.Writer) error {
+	r.ctx.Pop()
+	if r.writer == nil {
+		return nil // drawn onto an existing context
+	}
 	return r.writer(w, r.c)
 }
@@ -0,0 +1,36 @@
+package renderers
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/canvas/renderers/rasterizer"
+	"github.com/tdewolff/test"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+)
+
+func TestGoChart(t *testing.T) {
+	c := canvas.New(40.0*mmPerPx, 40.0*mmPerPx)
+	ctx := canvas.NewContext(c)
+	ctx.SetFillColor(canvas.Blue)
+	r, err := NewGoChartContext(ctx)(40, 40)
+	test.Error(t, err)
+
+	// chart coordinates are in pixels from the top-left
+	r.SetFillColor(drawing.ColorRed)
+	r.MoveTo(0, 0)
+	r.LineTo(20, 0)
+	r.LineTo(20, 20)
+	r.LineTo(0, 20)
+	r.Close()
+	r.Fill()
+	r.ResetStyle()
+	test.Error(t, r.Save(nil))
+	test.T(t, ctx.Style.Fill.Color, canvas.Blue) // style of the caller is restored
+
+	img := rasterizer.Draw(c, canvas.DPI(96.0), canvas.LinearColorSpace{})
+	test.T(t, img.Bounds().Size().X, 40)
+	test.T(t, img.RGBAAt(5, 5), canvas.Red)
+	test.T(t, img.RGBAAt(30, 30), color.RGBA{})
+}
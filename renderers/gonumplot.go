@@ -52,8 +52,8 @@ func (r *GonumPlot) SetLineWidth(length vg.Length) {
 // The initial dash pattern is a solid line.
 func (r *GonumPlot) SetLineDash(pattern []vg.Length, offset vg.Length) {
 	array := make([]float64, len(pattern))
-	for _, dash := range pattern {
-		array = append(array, float64(dash*mmPerPt))
+	for i, dash := range pattern {
+		array[i] = float64(dash * mmPerPt)
 	}
 	r.ctx.SetDashes(float64(offset*mmPerPt), array...)
 }
@@ -109,16 +109,31 @@ func (r *GonumPlot) Pop() {
 }
 
 func (r *GonumPlot) addPath(path vg.Path) {
-	for _, comp := range path {
+	for i, comp := range path {
 		switch comp.Type {
 		case vg.MoveComp:
 			r.ctx.MoveTo(float64(comp.Pos.X*mmPerPt), float64(comp.Pos.Y*mmPerPt))
 		case vg.LineComp:
 			r.ctx.LineTo(float64(comp.Pos.X*mmPerPt), float64(comp.Pos.Y*mmPerPt))
 		case vg.ArcComp:
+			// the arc is centered at Pos and connected to the current point by a line
+			x := float64((comp.Pos.X + comp.Radius*vg.Length(math.Cos(comp.Start))) * mmPerPt)
+			y := float64((comp.Pos.Y + comp.Radius*vg.Length(math.Sin(comp.Start))) * mmPerPt)
+			if i == 0 {
+				r.ctx.MoveTo(x, y)
+			} else {
+				r.ctx.LineTo(x, y)
+			}
 			r.ctx.Arc(float64(comp.Radius*mmPerPt), float64(comp.Radius*mmPerPt), 0.0, float64(comp.Start)*180.0/math.Pi, float64(comp.Start+comp.Angle)*180.0/math.Pi)
 		case vg.CurveComp:
-			r.ctx.CubeTo(float64(comp.Control[0].X*mmPerPt), float64(comp.Control[0].Y*mmPerPt), float64(comp.Control[1].X*mmPerPt), float64(comp.Control[1].Y*mmPerPt), float64(comp.Pos.X*mmPerPt), float64(comp.Pos.Y*mmPerPt))
+			switch len(comp.Control) {
+			case 1:
+				r.ctx.QuadTo(float64(comp.Control[0].X*mmPerPt), float64(comp.Control[0].Y*mmPerPt), float64(comp.Pos.X*mmPerPt), float64(comp.Pos.Y*mmPerPt))
+			case 2:
+				r.ctx.CubeTo(float64(comp.Control[0].X*mmPerPt), float64(comp.Control[0].Y*mmPerPt), float64(comp.Control[1].X*mmPerPt), float64(comp.Control[1].Y*mmPerPt), float64(comp.Pos.X*mmPerPt), float64(comp.Pos.Y*mmPerPt))
+			default:
+				panic("invalid number of control points")
+			}
 		case vg.CloseComp:
 			r.ctx.Close()
 		}
@@ -164,10 +179,11 @@ func (r *GonumPlot) FillString(f gonumFont.Face, pt vg.Point, text string) {
 		style |= canvas.FontItalic
 	}
 
+	// every font name has its own weight and style, load it as such to prevent faux bold and italic
 	fontFamily := r.fonts[f.Name()]
 	if fontFamily == nil {
 		fontFamily = canvas.NewFontFamily(f.Name())
-		if err := fontFamily.LoadFont(canvasFont.FromGoSFNT(f.Face), 0, canvas.FontRegular); err != nil {
+		if err := fontFamily.LoadFont(canvasFont.FromGoSFNT(f.Face), 0, style); err != nil {
 			panic(err)
 		}
 		r.fonts[f.Name()] = fontFamily
@@ -0,0 +1,42 @@
+package renderers
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/canvas/renderers/rasterizer"
+	"github.com/tdewolff/test"
+	"gonum.org/v1/plot/vg"
+)
+
+func TestGonumPlot(t *testing.T) {
+	mm := func(x, y float64) vg.Point {
+		return vg.Point{X: vg.Length(x * ptPerMm), Y: vg.Length(y * ptPerMm)}
+	}
+
+	c := canvas.New(10.0, 10.0)
+	dc := NewGonumPlot(c)
+	test.Float(t, float64(dc.Max.X*mmPerPt), 10.0)
+	test.Float(t, float64(dc.Max.Y*mmPerPt), 10.0)
+
+	dc.SetColor(canvas.Red)
+	var fill vg.Path
+	fill.Move(mm(0.0, 0.0))
+	fill.Line(mm(5.0, 0.0))
+	fill.QuadTo(mm(5.0, 5.0), mm(0.0, 5.0))
+	fill.Close()
+	dc.Fill(fill)
+
+	dc.SetColor(canvas.Blue)
+	dc.SetLineWidth(vg.Length(2.0 * ptPerMm))
+	var stroke vg.Path
+	stroke.Move(mm(0.0, 8.0))
+	stroke.Line(mm(10.0, 8.0))
+	dc.Stroke(stroke)
+
+	img := rasterizer.Draw(c, canvas.DPMM(1.0), canvas.LinearColorSpace{})
+	test.T(t, img.RGBAAt(1, 8), canvas.Red)
+	test.T(t, img.RGBAAt(5, 1), canvas.Blue)
+	test.T(t, img.RGBAAt(8, 5), color.RGBA{})
+}
@@ -38,6 +38,51 @@ func New(c js.Value, width, height, dpm float64) *HTMLCanvas {
 	}
 }
 
+// Capabilities returns the constructs that the HTML canvas supports natively, which are text, transparency, and most composite modes through globalCompositeOperation. Gradients, patterns, and shadows are passed as images.
+func (r *HTMLCanvas) Capabilities() canvas.Capabilities {
+	return canvas.Capabilities{
+		BlendModes:   true,
+		NativeText:   true,
+		Transparency: true,
+	}
+}
+
+// compositeOperations are the values of globalCompositeOperation for the composite modes, where CompositeClear and CompositeDst have no equivalent and draw over.
+var compositeOperations = map[canvas.CompositeMode]string{
+	canvas.CompositeSrcOver:    "source-over",
+	canvas.CompositeSrc:        "copy",
+	canvas.CompositeDstOver:    "destination-over",
+	canvas.CompositeSrcIn:      "source-in",
+	canvas.CompositeDstIn:      "destination-in",
+	canvas.CompositeSrcOut:     "source-out",
+	canvas.CompositeDstOut:     "destination-out",
+	canvas.CompositeSrcAtop:    "source-atop",
+	canvas.CompositeDstAtop:    "destination-atop",
+	canvas.CompositeXor:        "xor",
+	canvas.CompositeMultiply:   "multiply",
+	canvas.CompositeScreen:     "screen",
+	canvas.CompositeOverlay:    "overlay",
+	canvas.CompositeDarken:     "darken",
+	canvas.CompositeLighten:    "lighten",
+	canvas.CompositeColorDodge: "color-dodge",
+	canvas.CompositeColorBurn:  "color-burn",
+	canvas.CompositeHardLight:  "hard-light",
+	canvas.CompositeSoftLight:  "soft-light",
+	canvas.CompositeDifference: "difference",
+	canvas.CompositeExclusion:  "exclusion",
+}
+
+func (r *HTMLCanvas) setCompositeMode(mode canvas.CompositeMode) {
+	if mode != r.style.CompositeMode {
+		operation, ok := compositeOperations[mode]
+		if !ok {
+			operation = "source-over"
+		}
+		r.ctx.Set("globalCompositeOperation", operation)
+		r.style.CompositeMode = mode
+	}
+}
+
 // Size returns the size of the canvas in millimeters.
 func (r *HTMLCanvas) Size() (float64, float64) {
 	return r.width / r.dpm, r.height / r.dpm
@@ -85,7 +130,14 @@ func (r *HTMLCanvas) RenderPath(path *canvas.Path, style canvas.Style, m canvas.
 	} else {
 		strokeUnsupported = true
 	}
+	// HTML Canvas doesn't support different start and end caps or custom cappers
+	if start, end, ok := canvas.NativeCapper(style.StrokeCapper); !ok || start != end {
+		strokeUnsupported = true
+	} else {
+		style.StrokeCapper = start
+	}
 
+	r.setCompositeMode(style.CompositeMode)
 	if style.HasFill() || style.HasStroke() && !strokeUnsupported {
 		r.writePath(path.Transform(m).ReplaceArcs())
 	}
@@ -234,6 +286,7 @@ func (r *HTMLCanvas) RenderImage(img image.Image, m canvas.Matrix) {
 		panic("error while waiting for createImageBitmap promise")
 	}
 
+	r.setCompositeMode(canvas.CompositeSrcOver)
 	origin := m.Dot(canvas.Point{0, float64(img.Bounds().Size().Y)}).Mul(r.dpm)
 	m = m.Scale(r.dpm, r.dpm)
 	r.ctx.Call("setTransform", m[0][0], m[0][1], m[1][0], m[1][1], origin.X, r.height-origin.Y)
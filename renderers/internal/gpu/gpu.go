@@ -0,0 +1,225 @@
+// Package gpu builds the vertex and uniform buffers for the GPU renderers (WebGPU and Metal), independent of the graphics API. Paths filled or stroked with a color and gradients with per-vertex colors are tessellated into triangles, while everything else is rasterized into images. Both are recorded as layers that are drawn in order.
+package gpu
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/canvas/renderers/rasterizer"
+)
+
+// VertexSize is the number of float32 values per vertex, which are the position x and y in millimeters followed by the premultiplied color r, g, b, a in [0,1].
+const VertexSize = 6
+
+// UniformSize is the size in bytes of the uniform buffer, see Uniforms.
+const UniformSize = 16
+
+// coonsDivisions is the number of divisions along each side of a Coons patch when it is tessellated.
+const coonsDivisions = 16
+
+// Layer is a list of triangles or a rasterized image, of which only one is set.
+type Layer struct {
+	Vertices []float32   // triangles with per-vertex colors, see VertexSize
+	Image    *image.RGBA // rasterized paths, text, and images with premultiplied alpha, of the size of the scene in pixels
+}
+
+// Scene is a renderer that records drawing operations into layers for a GPU renderer. Consecutive tessellated drawing operations are recorded in the same layer of triangles, and consecutive rasterized drawing operations in the same image, so that drawing the layers in order keeps the drawing order.
+type Scene struct {
+	width, height float64
+	resolution    canvas.Resolution
+	size          image.Rectangle
+
+	Layers []Layer
+	images []*image.RGBA // images of the raster layers, which are reused after Clear
+	ras    *rasterizer.Rasterizer
+}
+
+// NewScene returns a scene of the given size in millimeters, where the images of the raster layers have the given resolution.
+func NewScene(width, height float64, resolution canvas.Resolution) *Scene {
+	return &Scene{
+		width:      width,
+		height:     height,
+		resolution: resolution,
+		size:       image.Rect(0, 0, int(width*resolution.DPMM()+0.5), int(height*resolution.DPMM()+0.5)),
+	}
+}
+
+// Bounds returns the size of the scene in pixels.
+func (s *Scene) Bounds() image.Rectangle {
+	return s.size
+}
+
+// Clear removes all layers, such as before rendering the next frame.
+func (s *Scene) Clear() {
+	s.Layers = s.Layers[:0]
+	s.ras = nil
+}
+
+// Capabilities returns the capabilities of the renderer, which are those of the rasterizer for the constructs that are not tessellated.
+func (s *Scene) Capabilities() canvas.Capabilities {
+	return canvas.Capabilities{
+		Gradients:      true,
+		GouraudShading: true,
+		BlendModes:     true,
+		Shadows:        true,
+		NativeText:     true,
+		Transparency:   true,
+	}
+}
+
+// Size returns the size of the canvas in millimeters.
+func (s *Scene) Size() (float64, float64) {
+	return s.width, s.height
+}
+
+// RenderPath renders a path to the canvas using a style and a transformation matrix. Fills and strokes with a color are tessellated into triangles, and so are fills with per-vertex colors (see canvas.GouraudGradient and canvas.CoonsGradient) of a path that is exactly the outline of the mesh, as returned by their Path method. Fills with per-vertex colors of other paths are rasterized so that they are clipped to the path, and so are all other paths.
+func (s *Scene) RenderPath(path *canvas.Path, style canvas.Style, m canvas.Matrix) {
+	var mesh *canvas.GouraudGradient
+	if style.HasFill() && style.Fill.IsGradient() {
+		mesh = meshOf(style.Fill.Gradient, path, m)
+	}
+	if style.Shadow != nil || style.CompositeMode != canvas.CompositeSrcOver || style.HasFill() && !style.Fill.IsColor() && mesh == nil || style.HasStroke() && !style.Stroke.IsColor() {
+		s.raster().RenderPath(path, style, m)
+		return
+	}
+
+	if style.HasFill() {
+		if mesh != nil {
+			s.appendGouraud(mesh)
+		} else {
+			s.appendTrapezoids(s.trapezoids(path, style.FillRule, m), style.Fill.Color, m)
+		}
+	}
+	if style.HasStroke() {
+		tolerance := canvas.PixelTolerance / s.resolution.DPMM()
+		stroke := path
+		if style.IsDashed() {
+			stroke = stroke.Dash(style.DashOffset, style.Dashes...)
+		}
+		stroke = stroke.Stroke(style.StrokeWidth, style.StrokeCapper, style.StrokeJoiner, tolerance)
+		s.appendTrapezoids(s.trapezoids(stroke, canvas.NonZero, m), style.Stroke.Color, m)
+	}
+}
+
+// RenderText renders a text object to the canvas using a transformation matrix. Text is rasterized.
+func (s *Scene) RenderText(text *canvas.Text, m canvas.Matrix) {
+	s.raster().RenderText(text, m)
+}
+
+// RenderImage renders an image to the canvas using a transformation matrix. Images are rasterized.
+func (s *Scene) RenderImage(img image.Image, m canvas.Matrix) {
+	s.raster().RenderImage(img, m)
+}
+
+// raster returns the rasterizer of the last layer, adding a raster layer if the last layer has triangles.
+func (s *Scene) raster() *rasterizer.Rasterizer {
+	if s.ras == nil {
+		n := 0
+		for _, layer := range s.Layers {
+			if layer.Image != nil {
+				n++
+			}
+		}
+		var img *image.RGBA
+		if n < len(s.images) {
+			img = s.images[n]
+			draw.Draw(img, img.Rect, image.Transparent, image.Point{}, draw.Src)
+		} else {
+			img = image.NewRGBA(s.size)
+			s.images = append(s.images, img)
+		}
+		s.Layers = append(s.Layers, Layer{Image: img})
+		s.ras = rasterizer.FromImage(img, s.resolution, nil)
+	}
+	return s.ras
+}
+
+// vertices returns the vertices of the last layer, adding a layer of triangles if the last layer is an image.
+func (s *Scene) vertices() *[]float32 {
+	if len(s.Layers) == 0 || s.Layers[len(s.Layers)-1].Image != nil {
+		s.Layers = append(s.Layers, Layer{Vertices: []float32{}})
+		s.ras = nil
+	}
+	return &s.Layers[len(s.Layers)-1].Vertices
+}
+
+// trapezoids decomposes the path in path coordinates, with a tolerance that is a pixel after transformation, so that the decomposition can be reused from canvas.DefaultTrapezoidCache for other transformations.
+func (s *Scene) trapezoids(path *canvas.Path, fillRule canvas.FillRule, m canvas.Matrix) []canvas.Trapezoid {
+	_, _, _, sx, sy, _ := m.Decompose()
+	scale := math.Max(math.Abs(sx), math.Abs(sy))
+	if scale == 0.0 {
+		return nil
+	}
+	tolerance := canvas.PixelTolerance / s.resolution.DPMM() / scale
+	if cache := canvas.DefaultTrapezoidCache; cache != nil {
+		return cache.Trapezoids(path, fillRule, tolerance)
+	}
+	return path.Trapezoids(fillRule, tolerance)
+}
+
+// appendTrapezoids appends two triangles for each trapezoid, transformed by m.
+func (s *Scene) appendTrapezoids(trs []canvas.Trapezoid, col color.RGBA, m canvas.Matrix) {
+	if len(trs) == 0 {
+		return
+	}
+	vertices := s.vertices()
+	for _, t := range trs {
+		p0 := m.Dot(canvas.Point{t.L0, t.Y0})
+		p1 := m.Dot(canvas.Point{t.R0, t.Y0})
+		p2 := m.Dot(canvas.Point{t.R1, t.Y1})
+		p3 := m.Dot(canvas.Point{t.L1, t.Y1})
+		for _, p := range [6]canvas.Point{p0, p1, p2, p0, p2, p3} {
+			*vertices = appendVertex(*vertices, p, col)
+		}
+	}
+}
+
+// appendGouraud appends the triangles of the gradient, whose vertices are in canvas coordinates.
+func (s *Scene) appendGouraud(g *canvas.GouraudGradient) {
+	if len(g.Triangles) == 0 {
+		return
+	}
+	vertices := s.vertices()
+	for _, tri := range g.Triangles {
+		for _, i := range tri {
+			*vertices = appendVertex(*vertices, g.Vertices[i], g.Colors[i])
+		}
+	}
+}
+
+func appendVertex(vertices []float32, p canvas.Point, col color.RGBA) []float32 {
+	return append(vertices,
+		float32(p.X), float32(p.Y),
+		float32(col.R)/255.0, float32(col.G)/255.0, float32(col.B)/255.0, float32(col.A)/255.0)
+}
+
+// meshOf returns the triangles with per-vertex colors of the gradient if the transformed path is exactly the outline of its mesh, or nil otherwise.
+func meshOf(gradient canvas.Gradient, path *canvas.Path, m canvas.Matrix) *canvas.GouraudGradient {
+	if g, ok := gradient.(*canvas.CoonsGradient); ok && path.Transform(m).Equals(g.Path()) {
+		return g.Mesh(coonsDivisions)
+	} else if g, ok := gradient.(*canvas.GouraudGradient); ok && path.Transform(m).Equals(g.Path()) {
+		return g
+	}
+	return nil
+}
+
+// Uniforms returns the uniform buffer of the vertex shader, which holds the scale and offset that transform positions in millimeters to clip space as two vec2<f32> in little-endian byte order.
+func (s *Scene) Uniforms() []byte {
+	return Float32Bytes([]float32{
+		float32(2.0 / s.width), float32(2.0 / s.height),
+		-1.0, -1.0,
+	})
+}
+
+// Float32Bytes returns the values in little-endian byte order, such as for uploading a vertex buffer.
+func Float32Bytes(values []float32) []byte {
+	b := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(v))
+	}
+	return b
+}
@@ -0,0 +1,151 @@
+package gpu
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/test"
+)
+
+// trianglesArea returns the total area of the triangles of the vertices.
+func trianglesArea(vertices []float32) float64 {
+	area := 0.0
+	for i := 0; i+3*VertexSize <= len(vertices); i += 3 * VertexSize {
+		x0, y0 := float64(vertices[i]), float64(vertices[i+1])
+		x1, y1 := float64(vertices[i+VertexSize]), float64(vertices[i+VertexSize+1])
+		x2, y2 := float64(vertices[i+2*VertexSize]), float64(vertices[i+2*VertexSize+1])
+		area += math.Abs((x1-x0)*(y2-y0)-(x2-x0)*(y1-y0)) / 2.0
+	}
+	return area
+}
+
+func TestSceneRenderPath(t *testing.T) {
+	s := NewScene(20.0, 10.0, canvas.DPMM(1.0))
+	test.T(t, s.Bounds(), image.Rect(0, 0, 20, 10))
+
+	style := canvas.DefaultStyle
+	style.Fill = canvas.Paint{Color: canvas.Red}
+	s.RenderPath(canvas.Rectangle(10.0, 5.0), style, canvas.Identity.Translate(2.0, 3.0))
+	test.T(t, len(s.Layers), 1)
+	test.T(t, s.Layers[0].Image, (*image.RGBA)(nil))
+	test.T(t, s.Layers[0].Vertices, []float32{
+		2, 3, 1, 0, 0, 1,
+		12, 3, 1, 0, 0, 1,
+		12, 8, 1, 0, 0, 1,
+		2, 3, 1, 0, 0, 1,
+		12, 8, 1, 0, 0, 1,
+		2, 8, 1, 0, 0, 1,
+	})
+
+	// hole is not covered
+	s.Clear()
+	path := canvas.Rectangle(10.0, 10.0)
+	path = path.Append(canvas.Rectangle(4.0, 4.0).Translate(3.0, 3.0).Reverse())
+	s.RenderPath(path, style, canvas.Identity)
+	test.Float(t, trianglesArea(s.Layers[0].Vertices), 84.0)
+
+	// stroke
+	s.Clear()
+	style = canvas.DefaultStyle
+	style.Fill = canvas.Paint{}
+	style.Stroke = canvas.Paint{Color: canvas.Blue}
+	style.StrokeWidth = 2.0
+	s.RenderPath(canvas.Line(10.0, 0.0), style, canvas.Identity.Translate(5.0, 5.0))
+	test.T(t, len(s.Layers), 1)
+	test.Float(t, trianglesArea(s.Layers[0].Vertices), 20.0)
+	test.T(t, s.Layers[0].Vertices[2:6], []float32{0, 0, 1, 1})
+}
+
+func TestSceneGouraud(t *testing.T) {
+	s := NewScene(20.0, 10.0, canvas.DPMM(1.0))
+	g := canvas.NewGouraudGradient(
+		[]canvas.Point{{1.0, 2.0}, {5.0, 2.0}, {1.0, 6.0}},
+		[]color.RGBA{canvas.Red, canvas.Lime, canvas.Blue},
+		[][3]int{{0, 1, 2}},
+	)
+	style := canvas.DefaultStyle
+	style.Fill = canvas.Paint{Gradient: g}
+	s.RenderPath(g.Path().Translate(-1.0, -2.0), style, canvas.Identity.Translate(1.0, 2.0))
+	test.T(t, len(s.Layers), 1)
+	test.T(t, s.Layers[0].Vertices, []float32{
+		1, 2, 1, 0, 0, 1,
+		5, 2, 0, 1, 0, 1,
+		1, 6, 0, 0, 1, 1,
+	})
+}
+
+func TestSceneMeshClip(t *testing.T) {
+	// mesh gradients filling other paths are rasterized and clipped to the path
+	patch := canvas.NewCoonsPatch(canvas.Point{0.0, 0.0}, canvas.Point{10.0, 0.0}, canvas.Point{10.0, 10.0}, canvas.Point{0.0, 10.0}, canvas.Red, canvas.Red, canvas.Red, canvas.Red)
+	coons := canvas.NewCoonsGradient([]canvas.CoonsPatch{patch})
+	style := canvas.DefaultStyle
+	style.Fill = canvas.Paint{Gradient: coons}
+
+	s := NewScene(20.0, 10.0, canvas.DPMM(1.0))
+	s.RenderPath(coons.Path(), style, canvas.Identity)
+	test.T(t, len(s.Layers), 1)
+	test.T(t, len(s.Layers[0].Vertices), 2*coonsDivisions*coonsDivisions*3*VertexSize)
+
+	s = NewScene(20.0, 10.0, canvas.DPMM(1.0))
+	s.RenderPath(canvas.Rectangle(5.0, 5.0), style, canvas.Identity)
+	test.T(t, len(s.Layers), 1)
+	test.That(t, s.Layers[0].Image != nil)
+	test.T(t, s.Layers[0].Image.RGBAAt(2, 7), canvas.Red)
+	test.T(t, s.Layers[0].Image.RGBAAt(8, 2), color.RGBA{})
+}
+
+func TestSceneLayers(t *testing.T) {
+	s := NewScene(20.0, 10.0, canvas.DPMM(1.0))
+	style := canvas.DefaultStyle
+	style.Fill = canvas.Paint{Color: canvas.Red}
+
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+
+	// drawing order is kept by alternating layers
+	s.RenderPath(canvas.Rectangle(2.0, 2.0), style, canvas.Identity)
+	s.RenderImage(img, canvas.Identity.Translate(4.0, 4.0))
+	s.RenderPath(canvas.Rectangle(2.0, 2.0), style, canvas.Identity.Translate(8.0, 0.0))
+	s.RenderPath(canvas.Rectangle(2.0, 2.0), style, canvas.Identity.Translate(12.0, 0.0))
+	test.T(t, len(s.Layers), 3)
+	test.T(t, len(s.Layers[0].Vertices), 6*VertexSize)
+	test.T(t, s.Layers[1].Image.Bounds(), image.Rect(0, 0, 20, 10))
+	test.T(t, s.Layers[1].Image.RGBAAt(5, 4), color.RGBA{255, 255, 255, 255})
+	test.T(t, s.Layers[1].Image.RGBAAt(0, 9), color.RGBA{})
+	test.T(t, len(s.Layers[2].Vertices), 12*VertexSize)
+
+	// gradients are rasterized
+	gradient := canvas.NewLinearGradient(canvas.Point{0.0, 0.0}, canvas.Point{2.0, 0.0})
+	gradient.Add(0.0, canvas.Red)
+	gradient.Add(1.0, canvas.Blue)
+	style.Fill = canvas.Paint{Gradient: gradient}
+	s.RenderPath(canvas.Rectangle(2.0, 2.0), style, canvas.Identity)
+	test.T(t, len(s.Layers), 4)
+	test.That(t, s.Layers[3].Image != nil)
+	test.That(t, s.Layers[3].Image != s.Layers[1].Image)
+
+	// images are reused and cleared
+	prev := s.Layers[1].Image
+	s.Clear()
+	test.T(t, len(s.Layers), 0)
+	s.RenderImage(img, canvas.Identity)
+	test.T(t, len(s.Layers), 1)
+	test.That(t, s.Layers[0].Image == prev)
+	test.T(t, s.Layers[0].Image.RGBAAt(5, 4), color.RGBA{})
+	test.T(t, s.Layers[0].Image.RGBAAt(1, 8), color.RGBA{255, 255, 255, 255})
+}
+
+func TestSceneUniforms(t *testing.T) {
+	s := NewScene(20.0, 10.0, canvas.DPMM(1.0))
+	b := s.Uniforms()
+	test.T(t, len(b), UniformSize)
+	test.T(t, b, Float32Bytes([]float32{0.1, 0.2, -1.0, -1.0}))
+	test.T(t, b[8:], []byte{0x00, 0x00, 0x80, 0xbf, 0x00, 0x00, 0x80, 0xbf})
+
+	test.T(t, Float32Bytes([]float32{1.0, -2.0}), []byte{0x00, 0x00, 0x80, 0x3f, 0x00, 0x00, 0x00, 0xc0})
+}
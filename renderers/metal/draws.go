@@ -0,0 +1,25 @@
+package metal
+
+import "github.com/tdewolff/canvas/renderers/internal/gpu"
+
+// sceneDraw is the draw call for a layer of the scene, which draws either an image texture or a mesh of triangles.
+type sceneDraw struct {
+	layer    int // index of the layer
+	texture  int // index of the image texture, or -1 for a mesh
+	vertices int // number of vertices of the mesh
+}
+
+// sceneDraws returns the draw calls for the layers of the scene, skipping empty layers, and the number of image textures that they use. Image textures are used in order so that they can be reused between frames.
+func sceneDraws(layers []gpu.Layer) ([]sceneDraw, int) {
+	draws := []sceneDraw{}
+	textures := 0
+	for i, layer := range layers {
+		if layer.Image != nil {
+			draws = append(draws, sceneDraw{layer: i, texture: textures})
+			textures++
+		} else if n := len(layer.Vertices) / gpu.VertexSize; 0 < n {
+			draws = append(draws, sceneDraw{layer: i, texture: -1, vertices: n})
+		}
+	}
+	return draws, textures
+}
@@ -0,0 +1,51 @@
+package metal
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/canvas/renderers/internal/gpu"
+	"github.com/tdewolff/test"
+)
+
+func TestSceneDraws(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	draws, textures := sceneDraws([]gpu.Layer{
+		{Vertices: make([]float32, 3*gpu.VertexSize)},
+		{Image: img},
+		{},
+		{Vertices: make([]float32, 6*gpu.VertexSize)},
+		{Image: img},
+	})
+	test.T(t, draws, []sceneDraw{
+		{layer: 0, texture: -1, vertices: 3},
+		{layer: 1, texture: 0},
+		{layer: 3, texture: -1, vertices: 6},
+		{layer: 4, texture: 1},
+	})
+	test.T(t, textures, 2)
+
+	draws, textures = sceneDraws(nil)
+	test.T(t, len(draws), 0)
+	test.T(t, textures, 0)
+}
+
+func TestSceneDrawsScene(t *testing.T) {
+	// a color fill is tessellated and text is rasterized into an image, which keeps the drawing order
+	scene := gpu.NewScene(10.0, 10.0, canvas.DPMM(1.0))
+	style := canvas.DefaultStyle
+	style.Fill = canvas.Paint{Color: color.RGBA{255, 0, 0, 255}}
+	scene.RenderPath(canvas.Rectangle(5.0, 5.0), style, canvas.Identity)
+	scene.RenderImage(image.NewRGBA(image.Rect(0, 0, 2, 2)), canvas.Identity)
+	scene.RenderPath(canvas.Rectangle(5.0, 5.0), style, canvas.Identity.Translate(5.0, 5.0))
+
+	draws, textures := sceneDraws(scene.Layers)
+	test.T(t, len(draws), 3)
+	test.T(t, draws[0].texture, -1)
+	test.That(t, 0 < draws[0].vertices && draws[0].vertices%3 == 0, "mesh must consist of triangles")
+	test.T(t, draws[1].texture, 0)
+	test.T(t, draws[2].texture, -1)
+	test.T(t, textures, 1)
+}
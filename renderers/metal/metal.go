@@ -0,0 +1,332 @@
+//go:build darwin && cgo
+
+package metal
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework Metal -framework QuartzCore -framework Foundation
+
+#import <Metal/Metal.h>
+#import <QuartzCore/CAMetalLayer.h>
+#include <stdlib.h>
+
+static const char *shaderSource =
+	"#include <metal_stdlib>\n"
+	"using namespace metal;\n"
+	"struct VertexOut { float4 position [[position]]; float2 uv; };\n"
+	"vertex VertexOut vs(uint i [[vertex_id]]) {\n"
+	"	const float2 pos[6] = {float2(-1.0, -1.0), float2(1.0, -1.0), float2(-1.0, 1.0), float2(1.0, -1.0), float2(1.0, 1.0), float2(-1.0, 1.0)};\n"
+	"	VertexOut out;\n"
+	"	out.position = float4(pos[i], 0.0, 1.0);\n"
+	"	out.uv = float2(0.5*pos[i].x + 0.5, 0.5 - 0.5*pos[i].y);\n"
+	"	return out;\n"
+	"}\n"
+	"fragment float4 fs(VertexOut in [[stage_in]], texture2d<float> tex [[texture(0)]]) {\n"
+	"	constexpr sampler s(filter::linear, address::clamp_to_edge);\n"
+	"	return tex.sample(s, in.uv);\n"
+	"}\n"
+	"struct MeshIn { float2 position [[attribute(0)]]; float4 color [[attribute(1)]]; };\n"
+	"struct MeshOut { float4 position [[position]]; float4 color; };\n"
+	"struct Uniforms { float2 scale; float2 offset; };\n"
+	"vertex MeshOut meshVS(MeshIn in [[stage_in]], constant Uniforms &uniforms [[buffer(1)]]) {\n"
+	"	MeshOut out;\n"
+	"	out.position = float4(in.position*uniforms.scale + uniforms.offset, 0.0, 1.0);\n"
+	"	out.color = in.color;\n"
+	"	return out;\n"
+	"}\n"
+	"fragment float4 meshFS(MeshOut in [[stage_in]]) {\n"
+	"	return in.color;\n"
+	"}\n";
+
+static void *newDevice() {
+	return MTLCreateSystemDefaultDevice();
+}
+
+static void *newCommandQueue(void *device) {
+	return [(id<MTLDevice>)device newCommandQueue];
+}
+
+static void *newTexture(void *device, int width, int height, int sampleCount, int renderTarget) {
+	MTLTextureDescriptor *desc = [MTLTextureDescriptor texture2DDescriptorWithPixelFormat:MTLPixelFormatRGBA8Unorm width:width height:height mipmapped:NO];
+	desc.usage = MTLTextureUsageShaderRead;
+	if (renderTarget) {
+		desc.usage |= MTLTextureUsageRenderTarget;
+	}
+	if (1 < sampleCount) {
+		desc.textureType = MTLTextureType2DMultisample;
+		desc.sampleCount = sampleCount;
+		desc.storageMode = MTLStorageModePrivate;
+		desc.usage = MTLTextureUsageRenderTarget;
+	}
+	return [(id<MTLDevice>)device newTextureWithDescriptor:desc];
+}
+
+static void uploadTexture(void *texture, void *pix, int width, int height) {
+	[(id<MTLTexture>)texture replaceRegion:MTLRegionMake2D(0, 0, width, height) mipmapLevel:0 withBytes:pix bytesPerRow:4*width];
+}
+
+static unsigned long textureFormat() {
+	return MTLPixelFormatRGBA8Unorm;
+}
+
+static unsigned long layerPixelFormat(void *layer) {
+	return ((CAMetalLayer *)layer).pixelFormat;
+}
+
+// newPipeline compiles a pipeline for the vertex and fragment functions. Meshes have vertices with a position and a color, see gpu.VertexSize, and are blended as premultiplied colors over the target.
+static void *newPipeline(void *device, const char *vsName, const char *fsName, unsigned long pixelFormat, int sampleCount, int mesh, int blend) {
+	NSError *err = nil;
+	id<MTLLibrary> library = [(id<MTLDevice>)device newLibraryWithSource:[NSString stringWithUTF8String:shaderSource] options:nil error:&err];
+	if (library == nil) {
+		return NULL;
+	}
+	id<MTLFunction> vs = [library newFunctionWithName:[NSString stringWithUTF8String:vsName]];
+	id<MTLFunction> fs = [library newFunctionWithName:[NSString stringWithUTF8String:fsName]];
+
+	MTLRenderPipelineDescriptor *desc = [[MTLRenderPipelineDescriptor alloc] init];
+	desc.vertexFunction = vs;
+	desc.fragmentFunction = fs;
+	desc.sampleCount = sampleCount;
+	desc.colorAttachments[0].pixelFormat = (MTLPixelFormat)pixelFormat;
+	if (mesh) {
+		MTLVertexDescriptor *vertexDesc = [MTLVertexDescriptor vertexDescriptor];
+		vertexDesc.attributes[0].format = MTLVertexFormatFloat2;
+		vertexDesc.attributes[0].offset = 0;
+		vertexDesc.attributes[0].bufferIndex = 0;
+		vertexDesc.attributes[1].format = MTLVertexFormatFloat4;
+		vertexDesc.attributes[1].offset = 8;
+		vertexDesc.attributes[1].bufferIndex = 0;
+		vertexDesc.layouts[0].stride = 24;
+		desc.vertexDescriptor = vertexDesc;
+	}
+	if (blend) {
+		desc.colorAttachments[0].blendingEnabled = YES;
+		desc.colorAttachments[0].rgbBlendOperation = MTLBlendOperationAdd;
+		desc.colorAttachments[0].alphaBlendOperation = MTLBlendOperationAdd;
+		desc.colorAttachments[0].sourceRGBBlendFactor = MTLBlendFactorOne;
+		desc.colorAttachments[0].sourceAlphaBlendFactor = MTLBlendFactorOne;
+		desc.colorAttachments[0].destinationRGBBlendFactor = MTLBlendFactorOneMinusSourceAlpha;
+		desc.colorAttachments[0].destinationAlphaBlendFactor = MTLBlendFactorOneMinusSourceAlpha;
+	}
+	id<MTLRenderPipelineState> pipeline = [(id<MTLDevice>)device newRenderPipelineStateWithDescriptor:desc error:&err];
+	[desc release];
+	[vs release];
+	[fs release];
+	[library release];
+	return pipeline;
+}
+
+// beginScene starts a render pass into the multisampled texture that is resolved into the target texture, and returns the command buffer and the encoder, which are released by endScene.
+static void *beginScene(void *queue, void *msaa, void *target, void **encoder) {
+	@autoreleasepool {
+		MTLRenderPassDescriptor *pass = [MTLRenderPassDescriptor renderPassDescriptor];
+		pass.colorAttachments[0].texture = (id<MTLTexture>)msaa;
+		pass.colorAttachments[0].resolveTexture = (id<MTLTexture>)target;
+		pass.colorAttachments[0].loadAction = MTLLoadActionClear;
+		pass.colorAttachments[0].clearColor = MTLClearColorMake(0.0, 0.0, 0.0, 0.0);
+		pass.colorAttachments[0].storeAction = MTLStoreActionMultisampleResolve;
+
+		id<MTLCommandBuffer> buffer = [[(id<MTLCommandQueue>)queue commandBuffer] retain];
+		*encoder = [[buffer renderCommandEncoderWithDescriptor:pass] retain];
+		return buffer;
+	}
+}
+
+static void drawImage(void *encoder, void *pipeline, void *texture) {
+	[(id<MTLRenderCommandEncoder>)encoder setRenderPipelineState:(id<MTLRenderPipelineState>)pipeline];
+	[(id<MTLRenderCommandEncoder>)encoder setFragmentTexture:(id<MTLTexture>)texture atIndex:0];
+	[(id<MTLRenderCommandEncoder>)encoder drawPrimitives:MTLPrimitiveTypeTriangle vertexStart:0 vertexCount:6];
+}
+
+static void drawMesh(void *encoder, void *pipeline, void *device, void *vertices, int length, int count, void *uniforms, int uniformsLength) {
+	id<MTLBuffer> buffer = [(id<MTLDevice>)device newBufferWithBytes:vertices length:length options:MTLResourceStorageModeShared];
+	[(id<MTLRenderCommandEncoder>)encoder setRenderPipelineState:(id<MTLRenderPipelineState>)pipeline];
+	[(id<MTLRenderCommandEncoder>)encoder setVertexBuffer:buffer offset:0 atIndex:0];
+	[(id<MTLRenderCommandEncoder>)encoder setVertexBytes:uniforms length:uniformsLength atIndex:1];
+	[(id<MTLRenderCommandEncoder>)encoder drawPrimitives:MTLPrimitiveTypeTriangle vertexStart:0 vertexCount:count];
+	[buffer release]; // retained by the command buffer
+}
+
+static void endScene(void *buffer, void *encoder) {
+	[(id<MTLRenderCommandEncoder>)encoder endEncoding];
+	[(id<MTLRenderCommandEncoder>)encoder release];
+	[(id<MTLCommandBuffer>)buffer commit];
+	[(id<MTLCommandBuffer>)buffer release];
+}
+
+static int draw(void *queue, void *pipeline, void *texture, void *layer) {
+	@autoreleasepool {
+		id<CAMetalDrawable> drawable = [(CAMetalLayer *)layer nextDrawable];
+		if (drawable == nil) {
+			return 0;
+		}
+
+		MTLRenderPassDescriptor *pass = [MTLRenderPassDescriptor renderPassDescriptor];
+		pass.colorAttachments[0].texture = drawable.texture;
+		pass.colorAttachments[0].loadAction = MTLLoadActionClear;
+		pass.colorAttachments[0].clearColor = MTLClearColorMake(0.0, 0.0, 0.0, 0.0);
+		pass.colorAttachments[0].storeAction = MTLStoreActionStore;
+
+		id<MTLCommandBuffer> buffer = [(id<MTLCommandQueue>)queue commandBuffer];
+		id<MTLRenderCommandEncoder> encoder = [buffer renderCommandEncoderWithDescriptor:pass];
+		[encoder setRenderPipelineState:(id<MTLRenderPipelineState>)pipeline];
+		[encoder setFragmentTexture:(id<MTLTexture>)texture atIndex:0];
+		[encoder drawPrimitives:MTLPrimitiveTypeTriangle vertexStart:0 vertexCount:6];
+		[encoder endEncoding];
+		[buffer presentDrawable:drawable];
+		[buffer commit];
+	}
+	return 1;
+}
+
+static void release(void *obj) {
+	[(id)obj release];
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"unsafe"
+
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/canvas/renderers/internal/gpu"
+)
+
+// ErrNoDevice is returned when there is no Metal device.
+var ErrNoDevice = errors.New("no Metal device")
+
+// ErrShaders is returned when the Metal shaders cannot be compiled.
+var ErrShaders = errors.New("failed to compile Metal shaders")
+
+// sampleCount is the number of samples per pixel for anti-aliasing the triangles.
+const sampleCount = 4
+
+// Metal is a renderer for macOS that draws to a Metal texture, which replaces OpenGL that is deprecated on macOS. Paths filled or stroked with a color are tessellated into triangles that are drawn by the GPU with 4x multisampling, while gradients, patterns, text, and images are rasterized into images that are uploaded as textures, see gpu.Scene. The result can be drawn to a CAMetalLayer with Draw, or be used by other Metal pipelines of the application with Texture.
+type Metal struct {
+	*gpu.Scene
+
+	device        unsafe.Pointer
+	queue         unsafe.Pointer
+	texture       unsafe.Pointer
+	msaa          unsafe.Pointer // multisampled texture that is resolved into texture
+	meshPipeline  unsafe.Pointer
+	imagePipeline unsafe.Pointer
+	imageTextures []unsafe.Pointer
+
+	// pipeline to draw the texture to a CAMetalLayer
+	pipeline unsafe.Pointer
+	format   C.ulong
+}
+
+// New returns a Metal renderer of the given size in millimeters that uses the system's default Metal device.
+func New(width, height float64, resolution canvas.Resolution) (*Metal, error) {
+	device := C.newDevice()
+	if device == nil {
+		return nil, ErrNoDevice
+	}
+	scene := gpu.NewScene(width, height, resolution)
+	w, h := C.int(scene.Bounds().Dx()), C.int(scene.Bounds().Dy())
+	r := &Metal{
+		Scene:   scene,
+		device:  device,
+		queue:   C.newCommandQueue(device),
+		texture: C.newTexture(device, w, h, 1, 1),
+		msaa:    C.newTexture(device, w, h, sampleCount, 1),
+	}
+	var err error
+	if r.meshPipeline, err = r.newPipeline("meshVS", "meshFS", C.textureFormat(), sampleCount, true, true); err != nil {
+		r.Release()
+		return nil, err
+	}
+	if r.imagePipeline, err = r.newPipeline("vs", "fs", C.textureFormat(), sampleCount, false, true); err != nil {
+		r.Release()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Metal) newPipeline(vs, fs string, format C.ulong, samples int, mesh, blend bool) (unsafe.Pointer, error) {
+	cvs, cfs := C.CString(vs), C.CString(fs)
+	defer C.free(unsafe.Pointer(cvs))
+	defer C.free(unsafe.Pointer(cfs))
+	pipeline := C.newPipeline(r.device, cvs, cfs, format, C.int(samples), cbool(mesh), cbool(blend))
+	if pipeline == nil {
+		return nil, ErrShaders
+	}
+	return pipeline, nil
+}
+
+// Device returns the id<MTLDevice>, which should be set as the device of the CAMetalLayer that is drawn to.
+func (r *Metal) Device() unsafe.Pointer {
+	return r.device
+}
+
+// Texture draws the layers of the scene into a texture and returns the id<MTLTexture>, which is in the RGBA8Unorm pixel format with premultiplied alpha. The drawing is committed to the renderer's command queue. The texture remains owned by the renderer.
+func (r *Metal) Texture() unsafe.Pointer {
+	var encoder unsafe.Pointer
+	buffer := C.beginScene(r.queue, r.msaa, r.texture, &encoder)
+	uniforms := r.Uniforms()
+	draws, textures := sceneDraws(r.Layers)
+	for len(r.imageTextures) < textures {
+		r.imageTextures = append(r.imageTextures, C.newTexture(r.device, C.int(r.Bounds().Dx()), C.int(r.Bounds().Dy()), 1, 0))
+	}
+	for _, draw := range draws {
+		layer := r.Layers[draw.layer]
+		if draw.texture != -1 {
+			texture := r.imageTextures[draw.texture]
+			C.uploadTexture(texture, unsafe.Pointer(&layer.Image.Pix[0]), C.int(layer.Image.Rect.Dx()), C.int(layer.Image.Rect.Dy()))
+			C.drawImage(encoder, r.imagePipeline, texture)
+		} else {
+			C.drawMesh(encoder, r.meshPipeline, r.device, unsafe.Pointer(&layer.Vertices[0]), C.int(4*len(layer.Vertices)), C.int(draw.vertices), unsafe.Pointer(&uniforms[0]), C.int(len(uniforms)))
+		}
+	}
+	C.endScene(buffer, encoder)
+	return r.texture
+}
+
+// Draw draws the layers of the scene into the texture and draws it to the next drawable of a CAMetalLayer, which must use the device of the renderer. It returns false if no drawable was available, and an error if the shaders for the pixel format of the layer cannot be compiled.
+func (r *Metal) Draw(layer unsafe.Pointer) (bool, error) {
+	r.Texture()
+	if format := C.layerPixelFormat(layer); r.pipeline == nil || format != r.format {
+		if r.pipeline != nil {
+			C.release(r.pipeline)
+			r.pipeline = nil
+		}
+		pipeline, err := r.newPipeline("vs", "fs", format, 1, false, false)
+		if err != nil {
+			return false, err
+		}
+		r.pipeline, r.format = pipeline, format
+	}
+	return C.draw(r.queue, r.pipeline, r.texture, layer) != 0, nil
+}
+
+// Release releases the Metal resources of the renderer.
+func (r *Metal) Release() {
+	if r.pipeline != nil {
+		C.release(r.pipeline)
+		r.pipeline = nil
+	}
+	for _, texture := range r.imageTextures {
+		C.release(texture)
+	}
+	r.imageTextures = nil
+	if r.meshPipeline != nil {
+		C.release(r.meshPipeline)
+	}
+	if r.imagePipeline != nil {
+		C.release(r.imagePipeline)
+	}
+	C.release(r.msaa)
+	C.release(r.texture)
+	C.release(r.queue)
+	C.release(r.device)
+}
+
+func cbool(b bool) C.int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -20,6 +20,20 @@ type OpenGL struct {
 	program uint32
 	vao     uint32
 	texture uint32
+
+	width, height float64
+	meshFills     []meshFill // fills drawn natively on top of the rasterized image
+	mesh          []float32  // vertices of triangles with per-vertex colors as x, y, r, g, b, a
+	meshProgram   uint32
+	meshVAO       uint32
+}
+
+// meshFill is a fill with per-vertex colors, which is rasterized instead of drawn natively if anything is drawn after it.
+type meshFill struct {
+	mesh  *canvas.GouraudGradient
+	path  *canvas.Path
+	style canvas.Style
+	m     canvas.Matrix
 }
 
 // New returns an open graphics library (OpenGL) renderer.
@@ -28,7 +42,81 @@ func New(width, height float64, resolution canvas.Resolution) *OpenGL {
 	return &OpenGL{
 		Rasterizer: rasterizer.FromImage(img, resolution, nil),
 		img:        img,
+		width:      width,
+		height:     height,
+	}
+}
+
+// coonsDivisions is the number of divisions along each side of a Coons patch when it is drawn as triangles.
+const coonsDivisions = 16
+
+// RenderPath renders a path to the canvas using a style and a transformation matrix. Fills with per-vertex colors (see canvas.GouraudGradient and canvas.CoonsGradient) of a path that is exactly the outline of the mesh, as returned by their Path method, are drawn natively as triangles with interpolated colors on top of the rasterized image. Such fills are rasterized instead when anything is drawn after them, so that the paint order is kept, and fills of other paths are rasterized so that they are clipped to the path.
+func (r *OpenGL) RenderPath(path *canvas.Path, style canvas.Style, m canvas.Matrix) {
+	if style.Fill.IsGradient() && !style.HasStroke() {
+		var mesh *canvas.GouraudGradient
+		var outline *canvas.Path
+		if g, ok := style.Fill.Gradient.(*canvas.CoonsGradient); ok {
+			mesh, outline = g.Mesh(coonsDivisions), g.Path()
+		} else if g, ok := style.Fill.Gradient.(*canvas.GouraudGradient); ok {
+			mesh, outline = g, g.Path()
+		}
+		if mesh != nil && path.Transform(m).Equals(outline) {
+			r.meshFills = append(r.meshFills, meshFill{mesh, path, style, m})
+			return
+		}
 	}
+	r.flushMeshes()
+	r.Rasterizer.RenderPath(path, style, m)
+}
+
+// RenderText renders a text object to the canvas using a transformation matrix.
+func (r *OpenGL) RenderText(text *canvas.Text, m canvas.Matrix) {
+	r.flushMeshes()
+	r.Rasterizer.RenderText(text, m)
+}
+
+// RenderImage renders an image to the canvas using a transformation matrix.
+func (r *OpenGL) RenderImage(img image.Image, m canvas.Matrix) {
+	r.flushMeshes()
+	r.Rasterizer.RenderImage(img, m)
+}
+
+// RenderImageQuad renders an image to the canvas mapped onto a quadrilateral using projective interpolation.
+func (r *OpenGL) RenderImageQuad(img image.Image, q [4]canvas.Point) {
+	r.flushMeshes()
+	r.Rasterizer.RenderImageQuad(img, q)
+}
+
+// BeginGroup starts a group of drawing operations that is composited with the given opacity.
+func (r *OpenGL) BeginGroup(opacity float64) {
+	r.flushMeshes()
+	r.Rasterizer.BeginGroup(opacity)
+}
+
+// EndGroup ends the last group and composites it.
+func (r *OpenGL) EndGroup() {
+	r.flushMeshes()
+	r.Rasterizer.EndGroup()
+}
+
+// BeginFilter starts a group of drawing operations to which the filter is applied.
+func (r *OpenGL) BeginFilter(filter canvas.Filter) {
+	r.flushMeshes()
+	r.Rasterizer.BeginFilter(filter)
+}
+
+// EndFilter ends the last group started with BeginFilter and applies the filter.
+func (r *OpenGL) EndFilter() {
+	r.flushMeshes()
+	r.Rasterizer.EndFilter()
+}
+
+// flushMeshes rasterizes the fills that would otherwise be drawn natively, since content is drawn on top of them.
+func (r *OpenGL) flushMeshes() {
+	for _, fill := range r.meshFills {
+		r.Rasterizer.RenderPath(fill.path, fill.style, fill.m)
+	}
+	r.meshFills = r.meshFills[:0]
 }
 
 func (r *OpenGL) Compile() {
@@ -102,6 +190,62 @@ func (r *OpenGL) Compile() {
 	r.program = program
 	r.vao = vao
 	r.texture = texture
+
+	r.mesh = r.mesh[:0]
+	for _, fill := range r.meshFills {
+		g := fill.mesh
+		for _, tri := range g.Triangles {
+			for _, i := range tri {
+				vertex, col := g.Vertices[i], g.Colors[i]
+				r.mesh = append(r.mesh,
+					float32(2.0*vertex.X/r.width-1.0), float32(2.0*vertex.Y/r.height-1.0),
+					float32(col.R)/255.0, float32(col.G)/255.0, float32(col.B)/255.0, float32(col.A)/255.0)
+			}
+		}
+	}
+	if 0 < len(r.mesh) {
+		r.compileMesh()
+	}
+}
+
+func (r *OpenGL) compileMesh() {
+	vertexShader, err := compileShader(meshVertexShaderSource, gl.VERTEX_SHADER)
+	if err != nil {
+		panic(err)
+	}
+	fragmentShader, err := compileShader(meshFragmentShaderSource, gl.FRAGMENT_SHADER)
+	if err != nil {
+		panic(err)
+	}
+
+	program := gl.CreateProgram()
+	gl.AttachShader(program, vertexShader)
+	gl.AttachShader(program, fragmentShader)
+	gl.LinkProgram(program)
+
+	var vbo uint32
+	gl.GenBuffers(1, &vbo)
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*len(r.mesh), gl.Ptr(r.mesh), gl.STATIC_DRAW)
+
+	var vao uint32
+	gl.GenVertexArrays(1, &vao)
+	gl.BindVertexArray(vao)
+
+	// attach attributes
+	vertexAttrib := uint32(gl.GetAttribLocation(program, gl.Str("position\x00")))
+	colorAttrib := uint32(gl.GetAttribLocation(program, gl.Str("vertColor\x00")))
+	gl.EnableVertexAttribArray(vertexAttrib)
+	gl.EnableVertexAttribArray(colorAttrib)
+	gl.VertexAttribPointer(vertexAttrib, 2, gl.FLOAT, false, 6*4, gl.PtrOffset(0))
+	gl.VertexAttribPointer(colorAttrib, 4, gl.FLOAT, false, 6*4, gl.PtrOffset(2*4))
+
+	// unbind
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.BindVertexArray(0)
+
+	r.meshProgram = program
+	r.meshVAO = vao
 }
 
 func (r *OpenGL) Draw() {
@@ -115,6 +259,20 @@ func (r *OpenGL) Draw() {
 	gl.BindTexture(gl.TEXTURE_2D, 0)
 	gl.BindVertexArray(0)
 	gl.UseProgram(0)
+
+	if 0 < len(r.mesh) {
+		// colors are premultiplied by alpha
+		gl.Enable(gl.BLEND)
+		gl.BlendFunc(gl.ONE, gl.ONE_MINUS_SRC_ALPHA)
+		gl.UseProgram(r.meshProgram)
+		gl.BindVertexArray(r.meshVAO)
+
+		gl.DrawArrays(gl.TRIANGLES, 0, int32(len(r.mesh)/6))
+
+		gl.BindVertexArray(0)
+		gl.UseProgram(0)
+		gl.Disable(gl.BLEND)
+	}
 }
 
 func compileShader(source string, shaderType uint32) (uint32, error) {
@@ -165,6 +323,30 @@ var fragmentShaderSource = `
 	}
 ` + "\x00"
 
+var meshVertexShaderSource = `
+	#version 410
+	in vec2 position;
+	in vec4 vertColor;
+
+	out vec4 fragColor;
+
+	void main() {
+		gl_Position = vec4(position, 0.0, 1.0);
+		fragColor = vertColor;
+	}
+` + "\x00"
+
+var meshFragmentShaderSource = `
+	#version 410
+	in vec4 fragColor;
+
+	out vec4 color;
+
+	void main() {
+		color = fragColor;
+	}
+` + "\x00"
+
 //import (
 //	"fmt"
 //	"image/color"
@@ -0,0 +1,55 @@
+//go:build cgo
+
+package opengl
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/test"
+)
+
+func TestOpenGLMesh(t *testing.T) {
+	// only the rasterized image is checked, so that no OpenGL context is needed
+	g := canvas.NewGouraudGradient(
+		[]canvas.Point{{0.0, 0.0}, {10.0, 0.0}, {10.0, 10.0}, {0.0, 10.0}},
+		[]color.RGBA{canvas.Red, canvas.Red, canvas.Red, canvas.Red},
+		[][3]int{{0, 1, 2}, {0, 2, 3}},
+	)
+	style := canvas.DefaultStyle
+	style.Fill = canvas.Paint{Gradient: g}
+
+	// the outline of the mesh is drawn natively
+	r := New(20.0, 20.0, canvas.DPMM(1.0))
+	r.RenderPath(g.Path(), style, canvas.Identity)
+	test.T(t, len(r.meshFills), 1)
+	test.T(t, r.img.RGBAAt(5, 15), color.RGBA{})
+
+	// and rasterized when anything is drawn on top of it
+	black := canvas.DefaultStyle
+	r.RenderPath(canvas.Rectangle(2.0, 2.0), black, canvas.Identity.Translate(15.0, 15.0))
+	test.T(t, len(r.meshFills), 0)
+	test.T(t, r.img.RGBAAt(5, 15), canvas.Red)
+	test.T(t, r.img.RGBAAt(16, 3), canvas.Black)
+
+	// other paths are clipped
+	r = New(20.0, 20.0, canvas.DPMM(1.0))
+	r.RenderPath(canvas.Rectangle(5.0, 5.0), style, canvas.Identity)
+	test.T(t, len(r.meshFills), 0)
+	test.T(t, r.img.RGBAAt(2, 17), canvas.Red)
+	test.T(t, r.img.RGBAAt(8, 12), color.RGBA{})
+
+	// the outline of Coons patches is drawn natively
+	patch := canvas.NewCoonsPatch(canvas.Point{0.0, 0.0}, canvas.Point{10.0, 0.0}, canvas.Point{10.0, 10.0}, canvas.Point{0.0, 10.0}, canvas.Red, canvas.Red, canvas.Red, canvas.Red)
+	coons := canvas.NewCoonsGradient([]canvas.CoonsPatch{patch})
+	style.Fill = canvas.Paint{Gradient: coons}
+	r = New(20.0, 20.0, canvas.DPMM(1.0))
+	r.RenderPath(coons.Path(), style, canvas.Identity)
+	test.T(t, len(r.meshFills), 1)
+	test.T(t, len(r.meshFills[0].mesh.Triangles), 2*coonsDivisions*coonsDivisions)
+
+	r.RenderPath(canvas.Rectangle(5.0, 5.0), style, canvas.Identity)
+	test.T(t, len(r.meshFills), 0)
+	test.T(t, r.img.RGBAAt(8, 12), canvas.Red)
+}
@@ -12,6 +12,8 @@ import (
 type Options struct {
 	Compress    bool
 	SubsetFonts bool
+	OutlineText bool // draw text as paths instead of using font operators, which renders identically without fonts but is not searchable or selectable
+	Tagged      bool // write a tagged PDF for accessibility, see Tag
 	canvas.ImageEncoding
 }
 
@@ -35,9 +37,11 @@ func New(w io.Writer, width, height float64, opts *Options) *PDF {
 		opts = &defaultOptions
 	}
 
-	page := newPDFWriter(w).NewPage(width, height)
-	page.pdf.SetCompression(opts.Compress)
-	page.pdf.SetFontSubsetting(opts.SubsetFonts)
+	pdf := newPDFWriter(w)
+	pdf.SetCompression(opts.Compress)
+	pdf.SetFontSubsetting(opts.SubsetFonts)
+	pdf.SetTagged(opts.Tagged)
+	page := pdf.NewPage(width, height)
 	return &PDF{
 		w:      page,
 		width:  width,
@@ -46,6 +50,30 @@ func New(w io.Writer, width, height float64, opts *Options) *PDF {
 	}
 }
 
+// NewDocument returns a multi-page document that is written as PDF to the writer, see canvas.Document. Pages are written to the writer as they are finished.
+func NewDocument(w io.Writer, opts *Options) *canvas.Document {
+	return canvas.NewDocument(func(width, height float64) canvas.PageRenderer {
+		return New(w, width, height, opts)
+	})
+}
+
+// Tag is a structure element of a tagged PDF, which gives the content its meaning and reading order for accessibility, such as for screen readers, and allows the PDF to comply with PDF/UA. Drawing operations between BeginTag and EndTag are the content of the element in the order they are drawn, and elements can be nested. Content outside of elements is marked as an artifact, such as page decorations. Tags are only written when Options.Tagged is set, and should not start or end within a group. Set the document's title and language using SetMetadata.
+type Tag struct {
+	Type     string // standard structure type, such as Sect, H1, P, Figure, Table, TR, TD, L, LI, or Span
+	Alt      string // alternate description, which is required for figures
+	Language string // BCP 47 language tag if different from the document's language
+}
+
+// BeginTag starts a structure element as a child of the current element, see Tag.
+func (r *PDF) BeginTag(tag Tag) {
+	r.w.BeginTag(tag)
+}
+
+// EndTag ends the current structure element, see Tag.
+func (r *PDF) EndTag() {
+	r.w.EndTag()
+}
+
 // SetImageEncoding sets the image encoding to Loss or Lossless.
 func (r *PDF) SetImageEncoding(enc canvas.ImageEncoding) {
 	r.opts.ImageEncoding = enc
@@ -60,9 +88,31 @@ func (r *PDF) SetInfo(title, subject, keywords, author, creator string) {
 	r.w.pdf.SetCreator(creator)
 }
 
+// SetMetadata sets the document's metadata, which is written to the document information dictionary and as an XMP metadata stream.
+func (r *PDF) SetMetadata(metadata canvas.Metadata) {
+	r.w.pdf.SetMetadata(metadata)
+}
+
+// SetPageBoxes sets the trim and bleed boxes of the current page for print production.
+func (r *PDF) SetPageBoxes(trimBox, bleedBox canvas.Rect) {
+	r.w.trimBox = trimBox
+	r.w.bleedBox = bleedBox
+}
+
+// SetPageMetadata sets the metadata of the current page, where the label is written to the document's page labels.
+func (r *PDF) SetPageMetadata(metadata canvas.PageMetadata) {
+	r.w.label = metadata.Label
+}
+
 // NewPage starts adds a new page where further rendering will be written to.
 func (r *PDF) NewPage(width, height float64) {
 	r.w = r.w.pdf.NewPage(width, height)
+	r.width, r.height = width, height
+}
+
+// AddOutline adds an item to the document outline, also known as bookmarks, which is shown by viewers as a navigable table of contents. The item links to height y in millimeters on the page with the given index starting at zero, which may be a page that is added later. Items are nested under the preceding item of a lower level, where the top level is zero.
+func (r *PDF) AddOutline(level int, title string, page int, y float64) {
+	r.w.pdf.AddOutline(level, title, page, y)
 }
 
 // AddLink adds a link to the PDF document.
@@ -75,6 +125,23 @@ func (r *PDF) Close() error {
 	return r.w.pdf.Close()
 }
 
+// Err returns the first error that occurred while writing.
+func (r *PDF) Err() error {
+	return r.w.pdf.err
+}
+
+// Capabilities returns the constructs that PDF supports natively, which are gradients including Gouraud and Coons shading, tiling patterns, blend modes, transparency, and text unless OutlineText is set. Shadows are passed as blurred images and Porter-Duff operators are drawn over.
+func (r *PDF) Capabilities() canvas.Capabilities {
+	return canvas.Capabilities{
+		Gradients:      true,
+		GouraudShading: true,
+		Patterns:       true,
+		BlendModes:     true,
+		NativeText:     !r.opts.OutlineText,
+		Transparency:   true,
+	}
+}
+
 // Size returns the size of the canvas in millimeters.
 func (r *PDF) Size() (float64, float64) {
 	return r.width, r.height
@@ -82,6 +149,14 @@ func (r *PDF) Size() (float64, float64) {
 
 // RenderPath renders a path to the canvas using a style and a transformation matrix.
 func (r *PDF) RenderPath(path *canvas.Path, style canvas.Style, m canvas.Matrix) {
+	r.w.SetBlendMode(style.CompositeMode)
+	if style.HasFill() && style.Fill.IsPattern() {
+		r.addTilingPattern(style.Fill.Pattern)
+	}
+	if style.HasStroke() && style.Stroke.IsPattern() {
+		r.addTilingPattern(style.Stroke.Pattern)
+	}
+
 	// PDFs don't support the arcs joiner, miter joiner (not clipped), or miter joiner (clipped) with non-bevel fallback
 	strokeUnsupported := false
 	if _, ok := style.StrokeJoiner.(canvas.ArcsJoiner); ok {
@@ -93,6 +168,12 @@ func (r *PDF) RenderPath(path *canvas.Path, style canvas.Style, m canvas.Matrix)
 			strokeUnsupported = true
 		}
 	}
+	// PDF doesn't support different start and end caps or custom cappers
+	if start, end, ok := canvas.NativeCapper(style.StrokeCapper); !ok || start != end {
+		strokeUnsupported = true
+	} else {
+		style.StrokeCapper = start
+	}
 	if !strokeUnsupported {
 		if m.IsSimilarity() {
 			scale := math.Sqrt(math.Abs(m.Det()))
@@ -216,8 +297,33 @@ func (r *PDF) RenderPath(path *canvas.Path, style canvas.Style, m canvas.Matrix)
 	}
 }
 
+// addTilingPattern renders the canvas of a pattern created with canvas.NewCanvasPattern as a tiling pattern, unless it was already added to the page.
+func (r *PDF) addTilingPattern(pattern canvas.Pattern) {
+	p, ok := pattern.(*canvas.CanvasPattern)
+	if !ok {
+		return
+	} else if _, ok := r.w.tilingPatterns[p]; ok {
+		return
+	}
+
+	tile := r.w.pdf.newPageWriter(p.Canvas.W, p.Canvas.H)
+	p.Canvas.RenderTo(&PDF{
+		w:      tile,
+		width:  p.Canvas.W,
+		height: p.Canvas.H,
+		opts:   r.opts,
+	})
+	r.w.addTilingPattern(p, tile)
+}
+
 // RenderText renders a text object to the canvas using a transformation matrix.
 func (r *PDF) RenderText(text *canvas.Text, m canvas.Matrix) {
+	if r.opts.OutlineText {
+		text.RenderAsPath(r, m, 0.0)
+		return
+	}
+	r.w.SetBlendMode(canvas.CompositeSrcOver)
+
 	text.WalkDecorations(func(fill canvas.Paint, p *canvas.Path) {
 		style := canvas.DefaultStyle
 		style.Fill = fill
@@ -241,6 +347,7 @@ func (r *PDF) RenderText(text *canvas.Text, m canvas.Matrix) {
 			} else {
 				r.w.SetTextRenderMode(0)
 			}
+			r.w.pdf.setGlyphText(span.Face.Font, span.Glyphs, span.Text)
 			r.w.WriteText(text.WritingMode, span.Glyphs)
 			r.w.EndTextObject()
 		} else {
@@ -253,5 +360,16 @@ func (r *PDF) RenderText(text *canvas.Text, m canvas.Matrix) {
 
 // RenderImage renders an image to the canvas using a transformation matrix.
 func (r *PDF) RenderImage(img image.Image, m canvas.Matrix) {
+	r.w.SetBlendMode(canvas.CompositeSrcOver)
 	r.w.DrawImage(img, r.opts.ImageEncoding, m)
 }
+
+// BeginGroup starts a group of drawing operations that is composited with the given opacity, using a transparency group.
+func (r *PDF) BeginGroup(opacity float64) {
+	r.w.BeginGroup(opacity)
+}
+
+// EndGroup ends the last group.
+func (r *PDF) EndGroup() {
+	r.w.EndGroup()
+}
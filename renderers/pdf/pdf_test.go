@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"image"
+	"image/color"
 	"io"
 	"os"
 	"strings"
@@ -113,6 +114,30 @@ func doTestPDFText(t *testing.T, subsetFonts bool, expectedSize int, filename st
 	test.That(t, expectedSize-1000 < written && written < expectedSize+1000, "Unexpected rendering result length")
 }
 
+func TestPDFOutlineText(t *testing.T) {
+	dejaVuSerif := canvas.NewFontFamily("dejavu-serif")
+	err := dejaVuSerif.LoadFontFile(fontDir+"DejaVuSerif.ttf", canvas.FontRegular)
+	test.Error(t, err)
+	text := canvas.NewTextLine(dejaVuSerif.Face(12.0, canvas.Black), "Text", canvas.Left)
+
+	buf := &bytes.Buffer{}
+	pdf := New(buf, 100, 50, &Options{Compress: false, SubsetFonts: true})
+	test.That(t, pdf.Capabilities().NativeText, "expected native text")
+	pdf.RenderText(text, canvas.Identity.Translate(10, 10))
+	pdf.Close()
+	test.That(t, strings.Contains(buf.String(), "]TJ"), `could not find "TJ" in output`)
+	test.That(t, strings.Contains(buf.String(), "/ToUnicode"), `could not find "/ToUnicode" in output`)
+	test.That(t, strings.Contains(buf.String(), "5 beginbfchar\n<0000> <FFFD>\n"), `could not find Unicode mapping in output`)
+
+	buf.Reset()
+	pdf = New(buf, 100, 50, &Options{Compress: false, OutlineText: true})
+	test.That(t, !pdf.Capabilities().NativeText, "expected no native text")
+	pdf.RenderText(text, canvas.Identity.Translate(10, 10))
+	pdf.Close()
+	test.That(t, !strings.Contains(buf.String(), "]TJ"), `unexpected "TJ" in output`)
+	test.That(t, !strings.Contains(buf.String(), "/ToUnicode"), `unexpected "/ToUnicode" in output`)
+}
+
 func TestPDFImage(t *testing.T) {
 	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
 
@@ -122,6 +147,38 @@ func TestPDFImage(t *testing.T) {
 	test.String(t, pdf.String(), " 2.8346457 0 0 2.8346457 0 0 cm q 0 0 2 2 re W n 0 0 m 0 2 l 2 2 l 2 0 l h W n 2 0 0 2 0 0 cm /Im0 Do Q")
 }
 
+func TestPDFGroup(t *testing.T) {
+	buf := &bytes.Buffer{}
+	pdf := newPDFWriter(buf).NewPage(210.0, 297.0)
+	pdf.BeginGroup(0.5)
+	pdf.SetFill(canvas.Paint{Color: canvas.Red})
+	pdf.EndGroup()
+	pdf.SetFill(canvas.Paint{Color: canvas.Red})
+	test.String(t, pdf.String(), " 2.8346457 0 0 2.8346457 0 0 cm q /A0 gs /Fm0 Do Q 1 0 0 rg")
+	test.That(t, strings.Contains(buf.String(), "/Subtype /Form"), `could not find "/Subtype /Form" in output`)
+	test.That(t, strings.Contains(buf.String(), "1 0 0 rg"), `could not find group content in output`)
+}
+
+func TestPDFTilingPattern(t *testing.T) {
+	tile := canvas.New(10.0, 10.0)
+	ctx := canvas.NewContext(tile)
+	ctx.SetFill(canvas.Red)
+	ctx.DrawPath(0.0, 0.0, canvas.Rectangle(5.0, 5.0))
+
+	buf := &bytes.Buffer{}
+	pdf := New(buf, 100.0, 100.0, &Options{Compress: false})
+	ctx = canvas.NewContext(pdf)
+	ctx.SetFill(canvas.NewCanvasPattern(tile, canvas.Identity, 5.0))
+	ctx.DrawPath(0.0, 0.0, canvas.Rectangle(50.0, 50.0))
+	test.Error(t, pdf.Close())
+
+	out := buf.String()
+	test.That(t, strings.Contains(out, "/Pattern cs /P0 scn"), `could not find "/Pattern cs /P0 scn" in output`)
+	test.That(t, strings.Contains(out, "/PatternType 1"), `could not find tiling pattern in output`)
+	test.That(t, strings.Contains(out, "/XStep 15"), `could not find "/XStep 15" in output`)
+	test.That(t, strings.Contains(out, "1 0 0 rg"), `could not find tile content in output`)
+}
+
 func TestPDFMultipage(t *testing.T) {
 	buf := &bytes.Buffer{}
 	pdf := New(buf, 210, 297, nil)
@@ -151,3 +208,326 @@ func TestPDFMetadata(t *testing.T) {
 	test.That(t, strings.Contains(out, "/Author (d4)"), `could not find "/Author (d4)" in output`)
 	test.That(t, strings.Contains(out, "/Creator (e5)"), `could not find "/Creator (e5)" in output`)
 }
+
+func TestPDFXMPMetadata(t *testing.T) {
+	buf := &bytes.Buffer{}
+	pdf := New(buf, 210, 297, &Options{Compress: false})
+	pdf.SetMetadata(canvas.Metadata{Title: "Title", Description: "Description", Creator: "Creator", Language: "nl"})
+	err := pdf.Close()
+	test.Error(t, err)
+	out := buf.String()
+
+	test.That(t, strings.Contains(out, "/Title (Title)"), `could not find "/Title (Title)" in output`)
+	test.That(t, strings.Contains(out, "/Subject (Description)"), `could not find "/Subject (Description)" in output`)
+	test.That(t, strings.Contains(out, "/Author (Creator)"), `could not find "/Author (Creator)" in output`)
+	test.That(t, strings.Contains(out, "/Lang (nl)"), `could not find "/Lang (nl)" in output`)
+	test.That(t, strings.Contains(out, "/Subtype /XML"), `could not find "/Subtype /XML" in output`)
+	test.That(t, strings.Contains(out, "<dc:title><rdf:Alt><rdf:li xml:lang=\"x-default\">Title</rdf:li></rdf:Alt></dc:title>"), `could not find XMP title in output`)
+}
+
+func TestPDFPageBoxes(t *testing.T) {
+	buf := &bytes.Buffer{}
+	pdf := New(buf, 210, 297, &Options{Compress: false})
+	pdf.SetPageBoxes(canvas.Rect{10.0, 10.0, 190.0, 277.0}, canvas.Rect{7.0, 7.0, 196.0, 283.0})
+	err := pdf.Close()
+	test.Error(t, err)
+	out := buf.String()
+
+	test.That(t, strings.Contains(out, "/TrimBox ["), `could not find "/TrimBox" in output`)
+	test.That(t, strings.Contains(out, "/BleedBox ["), `could not find "/BleedBox" in output`)
+}
+
+func TestPDFSeparation(t *testing.T) {
+	buf := &bytes.Buffer{}
+	pdf := New(buf, 210, 297, &Options{Compress: false})
+	ctx := canvas.NewContext(pdf)
+	ctx.SetFill(canvas.NewSpotSwatch("Brand Red", color.CMYK{0, 255, 200, 0}))
+	ctx.DrawPath(0.0, 0.0, canvas.Rectangle(10.0, 10.0))
+	err := pdf.Close()
+	test.Error(t, err)
+	out := buf.String()
+
+	test.That(t, strings.Contains(out, "/CS0 cs 1 scn"), `could not find "/CS0 cs 1 scn" in output`)
+	test.That(t, strings.Contains(out, "[/Separation /Brand#20Red /DeviceCMYK"), `could not find "/Separation" color space in output`)
+}
+
+func TestPDFGradientAlpha(t *testing.T) {
+	gradient := canvas.NewLinearGradient(canvas.Point{0.0, 0.0}, canvas.Point{10.0, 0.0})
+	gradient.Add(0.0, canvas.Red)
+	gradient.Add(1.0, canvas.Transparent)
+
+	buf := &bytes.Buffer{}
+	pdf := newPDFWriter(buf).NewPage(210.0, 297.0)
+	pdf.SetFill(canvas.Paint{Gradient: gradient})
+	pdf.SetFill(canvas.Paint{Color: canvas.Red})
+	test.String(t, pdf.String(), " 2.8346457 0 0 2.8346457 0 0 cm /Pattern cs /P0 scn /SM1 gs 1 0 0 rg /SM0 gs")
+	test.That(t, strings.Contains(buf.String(), "/CS /DeviceGray"), `could not find soft mask group in output`)
+}
+
+func TestPDFGradientSpread(t *testing.T) {
+	gradient := canvas.NewLinearGradient(canvas.Point{10.0, 0.0}, canvas.Point{20.0, 0.0})
+	gradient.Add(0.0, canvas.Red)
+	gradient.Add(1.0, canvas.Blue)
+	test.T(t, spreadGradient(gradient, canvas.Rect{W: 100.0, H: 100.0}), gradient)
+
+	gradient.Spread = canvas.ReflectSpread
+	spread := spreadGradient(gradient, canvas.Rect{W: 35.0, H: 100.0}).(*canvas.LinearGradient)
+	test.T(t, spread.Start, canvas.Point{0.0, 0.0})
+	test.T(t, spread.End, canvas.Point{40.0, 0.0})
+	test.T(t, spread.Stops, canvas.Stops{{0.0, canvas.Blue}, {0.25, canvas.Red}, {0.5, canvas.Blue}, {0.75, canvas.Red}, {1.0, canvas.Blue}})
+	for _, x := range []float64{2.5, 12.5, 27.5, 32.5} {
+		test.T(t, spread.At(x, 0.0), gradient.At(x, 0.0))
+	}
+
+	radial := canvas.NewRadialGradient(canvas.Point{0.0, 0.0}, 0.0, canvas.Point{0.0, 0.0}, 10.0)
+	radial.Add(0.0, canvas.Red)
+	radial.Add(1.0, canvas.Blue)
+	radial.Spread = canvas.RepeatSpread
+	spreadRadial := spreadGradient(radial, canvas.Rect{W: 25.0, H: 10.0}).(*canvas.RadialGradient)
+	test.Float(t, spreadRadial.R1, 30.0)
+	test.T(t, len(spreadRadial.Stops), 6)
+	test.T(t, spreadRadial.At(12.5, 0.0), radial.At(12.5, 0.0))
+}
+
+func TestPDFGouraudGradient(t *testing.T) {
+	vertices := []canvas.Point{{0.0, 0.0}, {10.0, 0.0}, {10.0, 10.0}}
+	gradient := canvas.NewGouraudGradient(vertices, []color.RGBA{canvas.Red, canvas.Lime, canvas.Transparent}, [][3]int{{0, 1, 2}})
+
+	buf := &bytes.Buffer{}
+	pdf := newPDFWriter(buf).NewPage(210.0, 297.0)
+	pdf.SetFill(canvas.Paint{Gradient: gradient})
+	pdf.SetFill(canvas.Paint{Color: canvas.Red})
+	pdf.SetFill(canvas.Paint{Gradient: gradient})
+	test.String(t, pdf.String(), " 2.8346457 0 0 2.8346457 0 0 cm /Pattern cs /P0 scn /SM1 gs 1 0 0 rg /SM0 gs /Pattern cs /P0 scn /SM1 gs")
+	test.T(t, strings.Count(buf.String(), "/ShadingType 4"), 2) // color and soft mask
+}
+
+func TestPDFCoonsGradient(t *testing.T) {
+	patch := canvas.NewCoonsPatch(canvas.Point{0.0, 0.0}, canvas.Point{10.0, 0.0}, canvas.Point{10.0, 10.0}, canvas.Point{0.0, 10.0}, canvas.Red, canvas.Lime, canvas.Blue, canvas.Black)
+	gradient := canvas.NewCoonsGradient([]canvas.CoonsPatch{patch})
+
+	stream := coonsShading(gradient, 1.0, false)
+	test.T(t, stream.dict["ShadingType"], 6)
+	test.T(t, len(stream.stream), 1+12*8+4*3)
+
+	buf := &bytes.Buffer{}
+	pdf := newPDFWriter(buf).NewPage(210.0, 297.0)
+	pdf.SetFill(canvas.Paint{Gradient: gradient})
+	test.String(t, pdf.String(), " 2.8346457 0 0 2.8346457 0 0 cm /Pattern cs /P0 scn")
+	test.T(t, strings.Count(buf.String(), "/ShadingType 6"), 1)
+}
+
+func TestPDFBlendMode(t *testing.T) {
+	buf := &bytes.Buffer{}
+	pdf := New(buf, 210.0, 297.0, nil)
+	style := canvas.DefaultStyle
+	style.CompositeMode = canvas.CompositeMultiply
+	pdf.RenderPath(canvas.Rectangle(10.0, 10.0), style, canvas.Identity)
+	style.CompositeMode = canvas.CompositeXor // unsupported
+	pdf.RenderPath(canvas.Rectangle(10.0, 10.0), style, canvas.Identity)
+	test.String(t, pdf.w.String(), " 2.8346457 0 0 2.8346457 0 0 cm /BMMultiply gs 0 0 m 10 0 l 10 10 l 0 10 l f /BMNormal gs 0 0 m 10 0 l 10 10 l 0 10 l f")
+}
+
+type pathRecorder struct {
+	paths  []*canvas.Path
+	styles []canvas.Style
+}
+
+func (r *pathRecorder) Size() (float64, float64) {
+	return 210.0, 297.0
+}
+
+func (r *pathRecorder) RenderPath(path *canvas.Path, style canvas.Style, m canvas.Matrix) {
+	r.paths = append(r.paths, path.Transform(m))
+	r.styles = append(r.styles, style)
+}
+
+func (r *pathRecorder) RenderText(text *canvas.Text, m canvas.Matrix) {}
+
+func (r *pathRecorder) RenderImage(img image.Image, m canvas.Matrix) {}
+
+// testRect compares rectangles within the precision of the numbers written to PDF.
+func testRect(t *testing.T, r, expected canvas.Rect) {
+	t.Helper()
+	test.FloatDiff(t, r.X, expected.X, 1e-6)
+	test.FloatDiff(t, r.Y, expected.Y, 1e-6)
+	test.FloatDiff(t, r.W, expected.W, 1e-6)
+	test.FloatDiff(t, r.H, expected.H, 1e-6)
+}
+
+func TestPDFReader(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		buf := &bytes.Buffer{}
+		pdf := New(buf, 100, 50, &Options{Compress: compress})
+		ctx := canvas.NewContext(pdf)
+		ctx.SetFillColor(canvas.Red)
+		ctx.DrawPath(10.0, 20.0, canvas.Rectangle(30.0, 10.0))
+		ctx.SetFill(nil)
+		ctx.SetStrokeColor(canvas.Blue)
+		ctx.SetStrokeWidth(2.0)
+		ctx.DrawPath(0.0, 0.0, canvas.MustParseSVGPath("M5 5L95 5"))
+		pdf.NewPage(20, 20)
+		test.Error(t, pdf.Close())
+
+		reader, err := NewReader(buf)
+		test.Error(t, err)
+		test.T(t, reader.NumPages(), 2)
+		w, h, err := reader.PageSize(1)
+		test.Error(t, err)
+		test.FloatDiff(t, w, 20.0, 1e-6)
+		test.FloatDiff(t, h, 20.0, 1e-6)
+
+		page, err := reader.Page(0)
+		test.Error(t, err)
+		test.FloatDiff(t, page.W, 100.0, 1e-6)
+		test.FloatDiff(t, page.H, 50.0, 1e-6)
+
+		r := &pathRecorder{}
+		page.RenderTo(r)
+		test.T(t, len(r.paths), 2)
+		testRect(t, r.paths[0].Bounds(), canvas.Rect{10.0, 20.0, 30.0, 10.0})
+		test.T(t, r.styles[0].Fill.Color, canvas.Red)
+		test.That(t, !r.styles[0].HasStroke())
+		testRect(t, r.paths[1].Bounds(), canvas.Rect{5.0, 5.0, 90.0, 0.0})
+		test.T(t, r.styles[1].Stroke.Color, canvas.Blue)
+		test.FloatDiff(t, r.styles[1].StrokeWidth, 2.0, 1e-6)
+	}
+
+	_, err := NewReader(bytes.NewReader([]byte("not a PDF")))
+	test.That(t, err != nil)
+}
+
+func TestPDFReaderStreamLength(t *testing.T) {
+	for _, length := range []string{"3", "-5", "100000", "99999999999999999999999", "x"} {
+		t.Run(length, func(t *testing.T) {
+			r := &Reader{data: []byte("1 0 obj <</Length " + length + ">> stream\nabc\nendstream endobj")}
+			val, err := r.readObjectAt(0)
+			test.Error(t, err)
+			stream, ok := val.(pdfStream)
+			test.That(t, ok)
+			test.T(t, strings.TrimSpace(string(stream.stream)), "abc")
+		})
+	}
+}
+
+func TestPDFReaderBounds(t *testing.T) {
+	r := &Reader{}
+	widths := r.cidWidths(pdfArray{-50000000.0, 2.0, 500.0, 70000.0, 10.0, 500.0, 65534.0, 1e12, 300.0})
+	test.T(t, widths, map[int]float64{0: 500.0, 1: 500.0, 2: 500.0, 65534: 300.0, 65535: 300.0})
+
+	// image sizes that overflow are not allocated
+	_, err := r.image(pdfStream{dict: pdfDict{
+		"Width":            3000000000.0,
+		"Height":           2000000000.0,
+		"BitsPerComponent": 8.0,
+		"ColorSpace":       pdfName("DeviceRGB"),
+	}, stream: make([]byte, 12)})
+	test.That(t, err != nil)
+	_, err = r.image(pdfStream{dict: pdfDict{
+		"Width":            2000000000.0,
+		"Height":           2000000000.0,
+		"BitsPerComponent": 8.0,
+		"ColorSpace":       pdfName("DeviceRGB"),
+	}, stream: make([]byte, 12)})
+	test.That(t, err != nil)
+}
+
+func TestPDFReaderText(t *testing.T) {
+	dejaVuSerif := canvas.NewFontFamily("dejavu-serif")
+	err := dejaVuSerif.LoadFontFile(fontDir+"DejaVuSerif.ttf", canvas.FontRegular)
+	test.Error(t, err)
+	text := canvas.NewTextLine(dejaVuSerif.Face(12.0, canvas.Red), "Text", canvas.Left)
+	expected := text.OutlineBounds().Move(canvas.Point{10.0, 10.0})
+
+	for _, subset := range []bool{false, true} {
+		buf := &bytes.Buffer{}
+		pdf := New(buf, 100, 50, &Options{Compress: true, SubsetFonts: subset})
+		pdf.RenderText(text, canvas.Identity.Translate(10, 10))
+		test.Error(t, pdf.Close())
+
+		page, err := Parse(buf)
+		test.Error(t, err)
+
+		r := &pathRecorder{}
+		page.RenderTo(r)
+		test.That(t, 0 < len(r.paths), "expected text paths")
+		p := &canvas.Path{}
+		for i, path := range r.paths {
+			test.T(t, r.styles[i].Fill.Color, canvas.Red)
+			p = p.Append(path)
+		}
+		bounds := p.Bounds()
+		test.FloatDiff(t, bounds.X, expected.X, 0.05)
+		test.FloatDiff(t, bounds.Y, expected.Y, 0.05)
+		test.FloatDiff(t, bounds.W, expected.W, 0.05)
+		test.FloatDiff(t, bounds.H, expected.H, 0.05)
+	}
+}
+
+func TestPDFDocument(t *testing.T) {
+	buf := &bytes.Buffer{}
+	doc := NewDocument(buf, &Options{Compress: false})
+	page := doc.NewPage(100, 50)
+	doc.SetPageMetadata(canvas.PageMetadata{Label: "cover"})
+	canvas.NewContext(page).DrawPath(10.0, 10.0, canvas.Rectangle(20.0, 10.0))
+	doc.NewPage(20, 30)
+	test.Error(t, doc.Close())
+	test.That(t, strings.Contains(buf.String(), "/PageLabels << /Nums [0 << /P (cover) >> 1 << /S /D /St 2 >>] >>"), "could not find page labels in output")
+
+	reader, err := NewReader(buf)
+	test.Error(t, err)
+	test.T(t, reader.NumPages(), 2)
+	w, h, err := reader.PageSize(1)
+	test.Error(t, err)
+	test.FloatDiff(t, w, 20.0, 1e-6)
+	test.FloatDiff(t, h, 30.0, 1e-6)
+}
+
+func TestPDFTagged(t *testing.T) {
+	buf := &bytes.Buffer{}
+	pdf := New(buf, 100, 50, &Options{Compress: false, Tagged: true})
+	pdf.SetMetadata(canvas.Metadata{Title: "Title", Language: "en"})
+	ctx := canvas.NewContext(pdf)
+	ctx.DrawPath(0.0, 0.0, canvas.Rectangle(100.0, 5.0)) // artifact
+	pdf.BeginTag(Tag{Type: "Sect"})
+	pdf.BeginTag(Tag{Type: "Figure", Alt: "A square"})
+	ctx.DrawPath(10.0, 10.0, canvas.Rectangle(10.0, 10.0))
+	pdf.EndTag()
+	pdf.EndTag()
+	test.Error(t, pdf.Close())
+
+	s := buf.String()
+	test.That(t, strings.Contains(s, " /Artifact BMC "), "expected artifact")
+	test.That(t, strings.Contains(s, " EMC /Sect <</MCID 0>> BDC EMC /Figure <</MCID 1>> BDC "), "expected marked content")
+	test.That(t, strings.Contains(s, "/StructParents 0"), "expected structure parents of page")
+	test.That(t, strings.Contains(s, "/Type /StructElem /Alt (A square) /K [<< /Type /MCR /MCID 1 /Pg "), "expected figure element")
+	test.That(t, strings.Contains(s, "/MarkInfo << /Marked true >>"), "expected mark info")
+	test.That(t, strings.Contains(s, "<pdfuaid:part>1</pdfuaid:part>"), "expected PDF/UA identification")
+
+	reader, err := NewReader(buf)
+	test.Error(t, err)
+	page, err := reader.Page(0)
+	test.Error(t, err)
+	r := &pathRecorder{}
+	page.RenderTo(r)
+	test.T(t, len(r.paths), 2)
+}
+
+func TestPDFOutline(t *testing.T) {
+	buf := &bytes.Buffer{}
+	doc := NewDocument(buf, &Options{Compress: false})
+	doc.NewPage(100, 50)
+	doc.NewPage(100, 50)
+	doc.AddOutline(0, "Chapter", 0, 50.0)
+	doc.AddOutline(1, "Section", 1, 25.0)
+	doc.AddOutline(0, "Appendix", 1, 10.0)
+	doc.AddOutline(0, "Missing", 2, 0.0)
+	test.Error(t, doc.Close())
+
+	s := buf.String()
+	test.That(t, strings.Contains(s, "/PageMode /UseOutlines"), "expected page mode")
+	test.That(t, strings.Contains(s, "<< /Type /Outlines /Count 3 "), "expected outline root")
+	test.That(t, strings.Contains(s, "/Count 1 /Dest [5 0 R /XYZ null 141.73228 null] /First "), "expected chapter")
+	test.That(t, strings.Contains(s, "/Dest [7 0 R /XYZ null 70.866142 null] /Parent "), "expected section")
+	test.That(t, !strings.Contains(s, "(Missing)"), "unexpected outline item for missing page")
+}
@@ -0,0 +1,1364 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/tdewolff/canvas"
+	canvasFont "github.com/tdewolff/font"
+)
+
+// pdfOperator is a keyword in a PDF file, such as an operator in a content stream.
+type pdfOperator string
+
+// Reader reads the pages of simple PDF documents as vector content, so that existing documents can be annotated, overlaid, or converted and written using any renderer. Pages can be rasterized by drawing them with the rasterizer. Paths, colors, opacity, line styles, form XObjects, images, and text are supported, where text is converted to paths using the embedded TrueType or OpenType font, or using a system font of the same name for fonts that are not embedded. Text with other fonts, clipping paths, shading patterns, and encrypted documents are not supported, and clipping paths are ignored.
+type Reader struct {
+	data    []byte
+	offsets map[pdfRef]int         // offsets of uncompressed objects
+	streams map[pdfRef][2]int      // object stream and index of compressed objects
+	cache   map[pdfRef]interface{} // parsed objects
+	fonts   map[pdfRef]*pdfFont    // loaded fonts
+	trailer pdfDict
+	pages   []pdfDict
+}
+
+// Parse reads the first page of a PDF document as a canvas, so that it can be written to any of the writers such as SVG or PNG. See Reader for reading other pages and for what is supported.
+func Parse(r io.Reader) (*canvas.Canvas, error) {
+	reader, err := NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return reader.Page(0)
+}
+
+// NewReader reads a PDF document.
+func NewReader(r io.Reader) (*Reader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	} else if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		return nil, fmt.Errorf("invalid PDF: bad header")
+	}
+
+	reader := &Reader{
+		data:    data,
+		offsets: map[pdfRef]int{},
+		streams: map[pdfRef][2]int{},
+		cache:   map[pdfRef]interface{}{},
+		fonts:   map[pdfRef]*pdfFont{},
+	}
+
+	i := bytes.LastIndex(data, []byte("startxref"))
+	if i == -1 {
+		return nil, fmt.Errorf("invalid PDF: missing startxref")
+	}
+	val, _, err := parseVal(data, i+9)
+	startxref, ok := val.(float64)
+	if err != nil || !ok {
+		return nil, fmt.Errorf("invalid PDF: bad startxref")
+	} else if reader.trailer, err = reader.readXref(int(startxref), 0); err != nil {
+		return nil, err
+	} else if _, ok := reader.trailer["Encrypt"]; ok {
+		return nil, fmt.Errorf("unsupported PDF: encrypted")
+	}
+
+	root, _ := reader.get(reader.trailer["Root"]).(pdfDict)
+	pages, ok := reader.get(root["Pages"]).(pdfDict)
+	if !ok {
+		return nil, fmt.Errorf("invalid PDF: bad page tree")
+	} else if err := reader.addPages(pages, pdfDict{}, 0); err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
+// NumPages returns the number of pages.
+func (r *Reader) NumPages() int {
+	return len(r.pages)
+}
+
+// PageSize returns the size of the page in millimeters, given by its media box.
+func (r *Reader) PageSize(index int) (float64, float64, error) {
+	if index < 0 || len(r.pages) <= index {
+		return 0.0, 0.0, fmt.Errorf("unknown page %d", index)
+	}
+	box := r.mediaBox(r.pages[index])
+	return box.W, box.H, nil
+}
+
+// Page returns the page as a canvas of the page's size, with its contents as vector paths and images.
+func (r *Reader) Page(index int) (*canvas.Canvas, error) {
+	if index < 0 || len(r.pages) <= index {
+		return nil, fmt.Errorf("unknown page %d", index)
+	}
+	page := r.pages[index]
+	box := r.mediaBox(page)
+	c := canvas.New(box.W, box.H)
+
+	contents, err := r.contents(page["Contents"])
+	if err != nil {
+		return nil, fmt.Errorf("bad page %d: %w", index, err)
+	}
+	resources, _ := r.get(page["Resources"]).(pdfDict)
+	state := newPDFGraphicsState(canvas.Identity.Translate(-box.X, -box.Y).Scale(mmPerPt, mmPerPt))
+	if err := r.interpret(c, contents, resources, state, 0); err != nil {
+		return nil, fmt.Errorf("bad page %d: %w", index, err)
+	}
+	return c, nil
+}
+
+// mediaBox returns the media box of the page in millimeters.
+func (r *Reader) mediaBox(page pdfDict) canvas.Rect {
+	box := r.numbers(page["MediaBox"])
+	if len(box) != 4 {
+		box = []float64{0.0, 0.0, 612.0, 792.0} // US Letter
+	}
+	x0, y0 := math.Min(box[0], box[2]), math.Min(box[1], box[3])
+	x1, y1 := math.Max(box[0], box[2]), math.Max(box[1], box[3])
+	return canvas.Rect{x0 * mmPerPt, y0 * mmPerPt, (x1 - x0) * mmPerPt, (y1 - y0) * mmPerPt}
+}
+
+// addPages adds the pages of the page tree, passing down the inheritable attributes.
+func (r *Reader) addPages(node, inherited pdfDict, depth int) error {
+	if 32 < depth {
+		return fmt.Errorf("invalid PDF: page tree too deep")
+	}
+	attrs := pdfDict{}
+	for key, val := range inherited {
+		attrs[key] = val
+	}
+	for _, key := range []pdfName{"MediaBox", "Resources"} {
+		if val, ok := node[key]; ok {
+			attrs[key] = val
+		}
+	}
+
+	if node["Type"] == pdfName("Page") {
+		page := pdfDict{}
+		for key, val := range node {
+			page[key] = val
+		}
+		for key, val := range attrs {
+			page[key] = val
+		}
+		r.pages = append(r.pages, page)
+		return nil
+	}
+	kids, _ := r.get(node["Kids"]).(pdfArray)
+	for _, kid := range kids {
+		if dict, ok := r.get(kid).(pdfDict); !ok {
+			return fmt.Errorf("invalid PDF: bad page tree")
+		} else if err := r.addPages(dict, attrs, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readXref reads the cross-reference table or stream at offset and the previous ones, and returns the trailer.
+func (r *Reader) readXref(offset, depth int) (pdfDict, error) {
+	if offset < 0 || len(r.data) <= offset || 32 < depth {
+		return nil, fmt.Errorf("invalid PDF: bad cross-reference table")
+	}
+
+	var trailer pdfDict
+	if i := skipWhiteSpace(r.data, offset); bytes.HasPrefix(r.data[i:], []byte("xref")) {
+		i += 4
+		for {
+			val, n, err := parseVal(r.data, i)
+			if err != nil {
+				return nil, fmt.Errorf("invalid PDF: bad cross-reference table")
+			} else if val == pdfOperator("trailer") {
+				val, _, err = parseVal(r.data, n)
+				if trailer, _ = val.(pdfDict); err != nil || trailer == nil {
+					return nil, fmt.Errorf("invalid PDF: bad trailer")
+				}
+				break
+			}
+			i = n
+
+			first, _ := val.(float64)
+			val, i, err = parseVal(r.data, i)
+			count, _ := val.(float64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid PDF: bad cross-reference table")
+			}
+			for j := 0; j < int(count); j++ {
+				entry := [3]interface{}{}
+				for k := range entry {
+					if entry[k], i, err = parseVal(r.data, i); err != nil {
+						return nil, fmt.Errorf("invalid PDF: bad cross-reference table")
+					}
+				}
+				ref := pdfRef(int(first) + j)
+				if _, ok := r.offsets[ref]; !ok && entry[2] == pdfOperator("n") {
+					// newer sections are read first
+					pos, _ := entry[0].(float64)
+					r.offsets[ref] = int(pos)
+				}
+			}
+		}
+	} else {
+		val, err := r.readObjectAt(offset)
+		if err != nil {
+			return nil, err
+		}
+		stream, ok := val.(pdfStream)
+		if !ok || stream.dict["Type"] != pdfName("XRef") {
+			return nil, fmt.Errorf("invalid PDF: bad cross-reference stream")
+		}
+		trailer = stream.dict
+
+		W := r.numbers(stream.dict["W"])
+		index := r.numbers(stream.dict["Index"])
+		if index == nil {
+			size, _ := r.get(stream.dict["Size"]).(float64)
+			index = []float64{0.0, size}
+		}
+		if len(W) != 3 {
+			return nil, fmt.Errorf("invalid PDF: bad cross-reference stream")
+		}
+		field := func(b []byte, n int, def int) int {
+			if n == 0 {
+				return def
+			}
+			v := 0
+			for _, c := range b[:n] {
+				v = v<<8 | int(c)
+			}
+			return v
+		}
+		w0, w1, w2 := int(W[0]), int(W[1]), int(W[2])
+		b := stream.stream
+		for k := 0; k+1 < len(index); k += 2 {
+			for j := 0; j < int(index[k+1]); j++ {
+				if len(b) < w0+w1+w2 {
+					return nil, fmt.Errorf("invalid PDF: bad cross-reference stream")
+				}
+				typ, v1, v2 := field(b, w0, 1), field(b[w0:], w1, 0), field(b[w0+w1:], w2, 0)
+				b = b[w0+w1+w2:]
+
+				ref := pdfRef(int(index[k]) + j)
+				if _, ok := r.offsets[ref]; ok {
+					continue
+				} else if _, ok := r.streams[ref]; ok {
+					continue
+				}
+				if typ == 1 {
+					r.offsets[ref] = v1
+				} else if typ == 2 {
+					r.streams[ref] = [2]int{v1, v2}
+				}
+			}
+		}
+	}
+
+	if prev, ok := r.get(trailer["Prev"]).(float64); ok {
+		if _, err := r.readXref(int(prev), depth+1); err != nil {
+			return nil, err
+		}
+	}
+	return trailer, nil
+}
+
+// get returns the value, resolving indirect references. It returns nil for missing or bad objects.
+func (r *Reader) get(val interface{}) interface{} {
+	for depth := 0; depth < 32; depth++ {
+		ref, ok := val.(pdfRef)
+		if !ok {
+			return val
+		}
+		var err error
+		if val, err = r.readObject(ref); err != nil {
+			return nil
+		}
+	}
+	return nil
+}
+
+// numbers returns the value as an array of numbers, or nil if it isn't.
+func (r *Reader) numbers(val interface{}) []float64 {
+	array, ok := r.get(val).(pdfArray)
+	if !ok {
+		return nil
+	}
+	nums := make([]float64, len(array))
+	for i, item := range array {
+		if nums[i], ok = r.get(item).(float64); !ok {
+			return nil
+		}
+	}
+	return nums
+}
+
+// readObject returns the object for an indirect reference.
+func (r *Reader) readObject(ref pdfRef) (interface{}, error) {
+	if val, ok := r.cache[ref]; ok {
+		return val, nil
+	}
+	r.cache[ref] = nil // prevent reference cycles
+
+	var val interface{}
+	var err error
+	if offset, ok := r.offsets[ref]; ok {
+		val, err = r.readObjectAt(offset)
+	} else if loc, ok := r.streams[ref]; ok {
+		stream, ok := r.get(pdfRef(loc[0])).(pdfStream)
+		if !ok {
+			return nil, fmt.Errorf("bad object stream for object %d", ref)
+		}
+		n, _ := r.get(stream.dict["N"]).(float64)
+		first, _ := r.get(stream.dict["First"]).(float64)
+		nums := make([]float64, 2*int(n))
+		for i, j := 0, 0; i < len(nums); i++ {
+			var num interface{}
+			if num, j, err = parseVal(stream.stream, j); err != nil {
+				return nil, fmt.Errorf("bad object stream for object %d", ref)
+			}
+			nums[i], _ = num.(float64)
+		}
+		if len(nums) <= 2*loc[1] || pdfRef(nums[2*loc[1]]) != ref {
+			return nil, fmt.Errorf("bad object stream for object %d", ref)
+		}
+		val, _, err = parseVal(stream.stream, int(first)+int(nums[2*loc[1]+1]))
+	} else {
+		return nil, fmt.Errorf("unknown object %d", ref)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("bad object %d: %w", ref, err)
+	}
+	r.cache[ref] = val
+	return val, nil
+}
+
+// readObjectAt reads the indirect object at offset, including stream data.
+func (r *Reader) readObjectAt(offset int) (interface{}, error) {
+	if offset < 0 || len(r.data) <= offset {
+		return nil, fmt.Errorf("bad offset")
+	}
+	i := offset
+	for _, expected := range []string{"", "", "obj"} {
+		var err error
+		var val interface{}
+		if val, i, err = parseVal(r.data, i); err != nil {
+			return nil, err
+		} else if _, ok := val.(float64); expected == "" && !ok || expected != "" && val != pdfOperator(expected) {
+			return nil, fmt.Errorf("bad object header")
+		}
+	}
+	val, i, err := parseVal(r.data, i)
+	if err != nil {
+		return nil, err
+	}
+	dict, ok := val.(pdfDict)
+	if !ok {
+		return val, nil
+	}
+	i = skipWhiteSpace(r.data, i)
+	if !bytes.HasPrefix(r.data[i:], []byte("stream")) {
+		return dict, nil
+	}
+
+	i += 6
+	if i < len(r.data) && r.data[i] == '\r' {
+		i++
+	}
+	if i < len(r.data) && r.data[i] == '\n' {
+		i++
+	}
+	length, ok := r.get(dict["Length"]).(float64)
+	if !ok || math.IsNaN(length) || length < 0.0 || float64(len(r.data)-i) < length {
+		// find the end of the stream
+		end := bytes.Index(r.data[i:], []byte("endstream"))
+		if end == -1 {
+			return nil, fmt.Errorf("bad stream")
+		}
+		length = float64(end)
+	}
+	data, err := r.decode(dict, r.data[i:i+int(length)])
+	if err != nil {
+		return nil, err
+	}
+	return pdfStream{dict: dict, stream: data}, nil
+}
+
+// decode decodes the stream data using its filters. The DCTDecode filter for JPEG images is left undecoded.
+func (r *Reader) decode(dict pdfDict, b []byte) ([]byte, error) {
+	var filters pdfArray
+	var params pdfArray
+	if filter, ok := r.get(dict["Filter"]).(pdfName); ok {
+		filters = pdfArray{filter}
+		params = pdfArray{dict["DecodeParms"]}
+	} else {
+		filters, _ = r.get(dict["Filter"]).(pdfArray)
+		params, _ = r.get(dict["DecodeParms"]).(pdfArray)
+	}
+
+	for i, filter := range filters {
+		var err error
+		switch r.get(filter) {
+		case pdfName(pdfFilterFlate):
+			var zr io.ReadCloser
+			if zr, err = zlib.NewReader(bytes.NewReader(b)); err == nil {
+				b, err = io.ReadAll(zr)
+				if err == io.ErrUnexpectedEOF && 0 < len(b) {
+					err = nil // be lenient for truncated streams
+				}
+			}
+			if err == nil && i < len(params) {
+				param, _ := r.get(params[i]).(pdfDict)
+				b, err = r.unpredict(param, b)
+			}
+		case pdfName(pdfFilterASCII85):
+			b = bytes.TrimSuffix(bytes.TrimSpace(b), []byte("~>"))
+			b, err = io.ReadAll(ascii85.NewDecoder(bytes.NewReader(b)))
+		case pdfName("ASCIIHexDecode"):
+			b = bytes.Map(func(c rune) rune {
+				if '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F' {
+					return c
+				}
+				return -1
+			}, bytes.TrimSuffix(bytes.TrimSpace(b), []byte(">")))
+			if len(b)%2 == 1 {
+				b = append(b, '0')
+			}
+			b, err = hex.DecodeString(string(b))
+		case pdfName("DCTDecode"):
+			if i != len(filters)-1 {
+				return nil, fmt.Errorf("unsupported filter after DCTDecode")
+			}
+		default:
+			return nil, fmt.Errorf("unsupported filter %v", filter)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("bad stream: %w", err)
+		}
+	}
+	return b, nil
+}
+
+// unpredict reverses the PNG predictors of Flate encoded data.
+func (r *Reader) unpredict(param pdfDict, b []byte) ([]byte, error) {
+	predictor, _ := r.get(param["Predictor"]).(float64)
+	if predictor < 10 {
+		if predictor == 2 {
+			return nil, fmt.Errorf("unsupported TIFF predictor")
+		}
+		return b, nil
+	}
+
+	columns, colors, bpc := 1, 1, 8
+	if v, ok := r.get(param["Columns"]).(float64); ok {
+		columns = int(v)
+	}
+	if v, ok := r.get(param["Colors"]).(float64); ok {
+		colors = int(v)
+	}
+	if v, ok := r.get(param["BitsPerComponent"]).(float64); ok {
+		bpc = int(v)
+	}
+	bpp := max((colors*bpc+7)/8, 1)
+	stride := (columns*colors*bpc + 7) / 8
+	if stride <= 0 || len(b)%(stride+1) != 0 {
+		return nil, fmt.Errorf("bad predictor")
+	}
+
+	rows := len(b) / (stride + 1)
+	out := make([]byte, rows*stride)
+	prev := make([]byte, stride)
+	for j := 0; j < rows; j++ {
+		filter, row := b[j*(stride+1)], out[j*stride:(j+1)*stride]
+		copy(row, b[j*(stride+1)+1:(j+1)*(stride+1)])
+		for k := range row {
+			var left, upLeft byte
+			if bpp <= k {
+				left, upLeft = row[k-bpp], prev[k-bpp]
+			}
+			switch filter {
+			case 1:
+				row[k] += left
+			case 2:
+				row[k] += prev[k]
+			case 3:
+				row[k] += byte((int(left) + int(prev[k])) / 2)
+			case 4:
+				p := int(left) + int(prev[k]) - int(upLeft)
+				pa, pb, pc := abs(p-int(left)), abs(p-int(prev[k])), abs(p-int(upLeft))
+				if pa <= pb && pa <= pc {
+					row[k] += left
+				} else if pb <= pc {
+					row[k] += prev[k]
+				} else {
+					row[k] += upLeft
+				}
+			}
+		}
+		prev = row
+	}
+	return out, nil
+}
+
+// contents returns the concatenated content streams.
+func (r *Reader) contents(val interface{}) ([]byte, error) {
+	val = r.get(val)
+	if val == nil {
+		return []byte{}, nil
+	} else if stream, ok := val.(pdfStream); ok {
+		return stream.stream, nil
+	} else if array, ok := val.(pdfArray); ok {
+		b := []byte{}
+		for _, item := range array {
+			stream, ok := r.get(item).(pdfStream)
+			if !ok {
+				return nil, fmt.Errorf("bad contents")
+			}
+			b = append(b, stream.stream...)
+			b = append(b, '\n')
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("bad contents")
+}
+
+////////////////////////////////////////////////////////////////
+
+// pdfGraphicsState is the graphics state of a content stream.
+type pdfGraphicsState struct {
+	m                      canvas.Matrix
+	fill, stroke           color.RGBA
+	fillAlpha, strokeAlpha float64
+	style                  canvas.Style
+	miterLimit             float64
+
+	// text state
+	font                     *pdfFont
+	fontSize                 float64
+	charSpacing, wordSpacing float64
+	horizontalScaling        float64
+	leading, rise            float64
+	renderMode               int
+}
+
+func newPDFGraphicsState(m canvas.Matrix) pdfGraphicsState {
+	style := canvas.DefaultStyle
+	style.Dashes = nil
+	return pdfGraphicsState{
+		m:           m,
+		fill:        canvas.Black,
+		stroke:      canvas.Black,
+		fillAlpha:   1.0,
+		strokeAlpha: 1.0,
+		style:       style,
+		miterLimit:  10.0,
+
+		horizontalScaling: 1.0,
+	}
+}
+
+// paint returns the style for filling and/or stroking.
+func (state pdfGraphicsState) paint(fill, stroke bool, fillRule canvas.FillRule) canvas.Style {
+	style := state.style
+	style.Fill, style.Stroke = canvas.Paint{}, canvas.Paint{}
+	if fill {
+		style.Fill = canvas.Paint{Color: withAlpha(state.fill, state.fillAlpha)}
+		style.FillRule = fillRule
+	}
+	if stroke {
+		style.Stroke = canvas.Paint{Color: withAlpha(state.stroke, state.strokeAlpha)}
+		if style.StrokeWidth == 0.0 {
+			style.StrokeWidth = 0.1 / mmPerPt // thinnest line of 0.1 mm
+		}
+		style.StrokeJoiner = canvas.MiterJoiner{GapJoiner: canvas.BevelJoin, Limit: state.miterLimit}
+		if _, ok := state.style.StrokeJoiner.(canvas.MiterJoiner); !ok {
+			style.StrokeJoiner = state.style.StrokeJoiner
+		}
+	}
+	return style
+}
+
+func withAlpha(col color.RGBA, alpha float64) color.RGBA {
+	alpha = math.Min(math.Max(alpha, 0.0), 1.0)
+	return color.RGBA{
+		uint8(float64(col.R)*alpha + 0.5),
+		uint8(float64(col.G)*alpha + 0.5),
+		uint8(float64(col.B)*alpha + 0.5),
+		uint8(float64(col.A)*alpha + 0.5),
+	}
+}
+
+// deviceColor returns the color of gray, RGB, or CMYK components in [0,1].
+func deviceColor(comps []float64) (color.RGBA, bool) {
+	c := func(v float64) uint8 {
+		return uint8(math.Min(math.Max(v, 0.0), 1.0)*255.0 + 0.5)
+	}
+	switch len(comps) {
+	case 1:
+		return color.RGBA{c(comps[0]), c(comps[0]), c(comps[0]), 255}, true
+	case 3:
+		return color.RGBA{c(comps[0]), c(comps[1]), c(comps[2]), 255}, true
+	case 4:
+		k := 1.0 - comps[3]
+		return color.RGBA{c((1.0 - comps[0]) * k), c((1.0 - comps[1]) * k), c((1.0 - comps[2]) * k), 255}, true
+	}
+	return color.RGBA{}, false
+}
+
+// interpret draws the content stream onto the canvas.
+func (r *Reader) interpret(c *canvas.Canvas, b []byte, resources pdfDict, state pdfGraphicsState, depth int) error {
+	if 16 < depth {
+		return fmt.Errorf("XObjects nested too deeply")
+	}
+
+	states := []pdfGraphicsState{}
+	path := &canvas.Path{}
+	operands := []interface{}{}
+	nums := func(n int) ([]float64, bool) {
+		if len(operands) < n {
+			return nil, false
+		}
+		vals := make([]float64, n)
+		for i, operand := range operands[len(operands)-n:] {
+			var ok bool
+			if vals[i], ok = operand.(float64); !ok {
+				return nil, false
+			}
+		}
+		return vals, true
+	}
+	resource := func(category pdfName) interface{} {
+		dict, _ := r.get(resources[category]).(pdfDict)
+		if len(operands) == 0 || dict == nil {
+			return nil
+		}
+		name, _ := operands[len(operands)-1].(pdfName)
+		return r.get(dict[name])
+	}
+	draw := func(closePath, fill, stroke bool, fillRule canvas.FillRule) {
+		if closePath {
+			path.Close()
+		}
+		if (fill || stroke) && !path.Empty() {
+			c.RenderPath(path, state.paint(fill, stroke, fillRule), state.m)
+		}
+		path = &canvas.Path{}
+	}
+
+	// text matrix and text line matrix
+	tm, tlm := canvas.Identity, canvas.Identity
+	nextLine := func(tx, ty float64) {
+		tlm = tlm.Mul(canvas.Identity.Translate(tx, ty))
+		tm = tlm
+	}
+	show := func(s []byte) {
+		font := state.font
+		if font == nil {
+			return
+		}
+
+		// glyphs in text space, where x is the position along the baseline without horizontal scaling
+		p := &canvas.Path{}
+		x := 0.0
+		for _, code := range font.codes(s) {
+			if font.sfnt != nil {
+				_ = font.sfnt.GlyphPath(p, font.glyphID(code), 0, x, 0.0, state.fontSize/float64(font.sfnt.Head.UnitsPerEm), canvasFont.NoHinting)
+			}
+			x += font.width(code)/1000.0*state.fontSize + state.charSpacing
+			if !font.twoByte && code == ' ' {
+				x += state.wordSpacing
+			}
+		}
+
+		mode := state.renderMode % 4 // clipping modes are drawn without clipping
+		if mode != 3 && !p.Empty() {
+			p = p.Transform(tm.Mul(canvas.Matrix{{state.horizontalScaling, 0.0, 0.0}, {0.0, 1.0, state.rise}}))
+			c.RenderPath(p, state.paint(mode == 0 || mode == 2, mode == 1 || mode == 2, canvas.NonZero), state.m)
+		}
+		tm = tm.Mul(canvas.Identity.Translate(x*state.horizontalScaling, 0.0))
+	}
+
+	for i := 0; ; {
+		val, n, err := parseVal(b, i)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		i = n
+
+		op, ok := val.(pdfOperator)
+		if !ok {
+			operands = append(operands, val)
+			continue
+		}
+
+		switch op {
+		case "q":
+			states = append(states, state)
+		case "Q":
+			if 0 < len(states) {
+				state = states[len(states)-1]
+				states = states[:len(states)-1]
+			}
+		case "cm":
+			if v, ok := nums(6); ok {
+				state.m = state.m.Mul(canvas.Matrix{{v[0], v[2], v[4]}, {v[1], v[3], v[5]}})
+			}
+		case "w":
+			if v, ok := nums(1); ok {
+				state.style.StrokeWidth = v[0]
+			}
+		case "J":
+			if v, ok := nums(1); ok {
+				state.style.StrokeCapper = []canvas.Capper{canvas.ButtCap, canvas.RoundCap, canvas.SquareCap}[min(max(int(v[0]), 0), 2)]
+			}
+		case "j":
+			if v, ok := nums(1); ok {
+				state.style.StrokeJoiner = []canvas.Joiner{canvas.MiterJoin, canvas.RoundJoin, canvas.BevelJoin}[min(max(int(v[0]), 0), 2)]
+			}
+		case "M":
+			if v, ok := nums(1); ok {
+				state.miterLimit = v[0]
+			}
+		case "d":
+			if 2 <= len(operands) {
+				state.style.Dashes = r.numbers(operands[len(operands)-2])
+				state.style.DashOffset, _ = operands[len(operands)-1].(float64)
+			}
+		case "gs":
+			if gs, ok := resource("ExtGState").(pdfDict); ok {
+				if v, ok := r.get(gs["CA"]).(float64); ok {
+					state.strokeAlpha = v
+				}
+				if v, ok := r.get(gs["ca"]).(float64); ok {
+					state.fillAlpha = v
+				}
+				if v, ok := r.get(gs["LW"]).(float64); ok {
+					state.style.StrokeWidth = v
+				}
+			}
+		case "m":
+			if v, ok := nums(2); ok {
+				path.MoveTo(v[0], v[1])
+			}
+		case "l":
+			if v, ok := nums(2); ok {
+				path.LineTo(v[0], v[1])
+			}
+		case "c":
+			if v, ok := nums(6); ok {
+				path.CubeTo(v[0], v[1], v[2], v[3], v[4], v[5])
+			}
+		case "v":
+			if v, ok := nums(4); ok {
+				start := path.Pos()
+				path.CubeTo(start.X, start.Y, v[0], v[1], v[2], v[3])
+			}
+		case "y":
+			if v, ok := nums(4); ok {
+				path.CubeTo(v[0], v[1], v[2], v[3], v[2], v[3])
+			}
+		case "h":
+			path.Close()
+		case "re":
+			if v, ok := nums(4); ok {
+				path.MoveTo(v[0], v[1])
+				path.LineTo(v[0]+v[2], v[1])
+				path.LineTo(v[0]+v[2], v[1]+v[3])
+				path.LineTo(v[0], v[1]+v[3])
+				path.Close()
+			}
+		case "S":
+			draw(false, false, true, canvas.NonZero)
+		case "s":
+			draw(true, false, true, canvas.NonZero)
+		case "f", "F":
+			draw(false, true, false, canvas.NonZero)
+		case "f*":
+			draw(false, true, false, canvas.EvenOdd)
+		case "B":
+			draw(false, true, true, canvas.NonZero)
+		case "B*":
+			draw(false, true, true, canvas.EvenOdd)
+		case "b":
+			draw(true, true, true, canvas.NonZero)
+		case "b*":
+			draw(true, true, true, canvas.EvenOdd)
+		case "n":
+			draw(false, false, false, canvas.NonZero)
+		case "g", "rg", "k", "sc", "scn":
+			if v, ok := nums(len(operands)); ok {
+				if col, ok := deviceColor(v); ok {
+					state.fill = col
+				}
+			}
+		case "G", "RG", "K", "SC", "SCN":
+			if v, ok := nums(len(operands)); ok {
+				if col, ok := deviceColor(v); ok {
+					state.stroke = col
+				}
+			}
+		case "cs":
+			state.fill = canvas.Black
+		case "CS":
+			state.stroke = canvas.Black
+		case "BT":
+			tm, tlm = canvas.Identity, canvas.Identity
+		case "Tc":
+			if v, ok := nums(1); ok {
+				state.charSpacing = v[0]
+			}
+		case "Tw":
+			if v, ok := nums(1); ok {
+				state.wordSpacing = v[0]
+			}
+		case "Tz":
+			if v, ok := nums(1); ok {
+				state.horizontalScaling = v[0] / 100.0
+			}
+		case "TL":
+			if v, ok := nums(1); ok {
+				state.leading = v[0]
+			}
+		case "Ts":
+			if v, ok := nums(1); ok {
+				state.rise = v[0]
+			}
+		case "Tr":
+			if v, ok := nums(1); ok {
+				state.renderMode = min(max(int(v[0]), 0), 7)
+			}
+		case "Tf":
+			if v, ok := nums(1); ok && 2 <= len(operands) {
+				fonts, _ := r.get(resources["Font"]).(pdfDict)
+				name, _ := operands[len(operands)-2].(pdfName)
+				state.font = r.font(fonts[name])
+				state.fontSize = v[0]
+			}
+		case "Td", "TD":
+			if v, ok := nums(2); ok {
+				if op == "TD" {
+					state.leading = -v[1]
+				}
+				nextLine(v[0], v[1])
+			}
+		case "Tm":
+			if v, ok := nums(6); ok {
+				tlm = canvas.Matrix{{v[0], v[2], v[4]}, {v[1], v[3], v[5]}}
+				tm = tlm
+			}
+		case "T*":
+			nextLine(0.0, -state.leading)
+		case "Tj", "'", "\"":
+			if len(operands) == 0 {
+				break
+			} else if op == "\"" && 3 <= len(operands) {
+				state.wordSpacing, _ = operands[len(operands)-3].(float64)
+				state.charSpacing, _ = operands[len(operands)-2].(float64)
+			}
+			if op != "Tj" {
+				nextLine(0.0, -state.leading)
+			}
+			if s, ok := operands[len(operands)-1].([]byte); ok {
+				show(s)
+			}
+		case "TJ":
+			if len(operands) == 0 {
+				break
+			}
+			array, _ := operands[len(operands)-1].(pdfArray)
+			for _, item := range array {
+				if s, ok := item.([]byte); ok {
+					show(s)
+				} else if adjustment, ok := item.(float64); ok {
+					tm = tm.Mul(canvas.Identity.Translate(-adjustment/1000.0*state.fontSize*state.horizontalScaling, 0.0))
+				}
+			}
+		case "Do":
+			xobj, ok := resource("XObject").(pdfStream)
+			if !ok {
+				break
+			}
+			if xobj.dict["Subtype"] == pdfName("Form") {
+				formState := state
+				if v := r.numbers(xobj.dict["Matrix"]); len(v) == 6 {
+					formState.m = formState.m.Mul(canvas.Matrix{{v[0], v[2], v[4]}, {v[1], v[3], v[5]}})
+				}
+				formResources, ok := r.get(xobj.dict["Resources"]).(pdfDict)
+				if !ok {
+					formResources = resources
+				}
+				if err := r.interpret(c, xobj.stream, formResources, formState, depth+1); err != nil {
+					return err
+				}
+			} else if xobj.dict["Subtype"] == pdfName("Image") {
+				if img, err := r.image(xobj); err == nil {
+					size := img.Bounds().Size()
+					c.RenderImage(img, state.m.Scale(1.0/float64(size.X), 1.0/float64(size.Y)))
+				}
+			}
+		case "BI":
+			// skip inline image
+			end := bytes.Index(b[i:], []byte("EI"))
+			for end != -1 && i+end+2 < len(b) && isRegular(b[i+end+2]) {
+				next := bytes.Index(b[i+end+2:], []byte("EI"))
+				if next == -1 {
+					end = -1
+				} else {
+					end += 2 + next
+				}
+			}
+			if end == -1 {
+				return fmt.Errorf("bad inline image")
+			}
+			i += end + 2
+		}
+		operands = operands[:0]
+	}
+	return nil
+}
+
+// pdfFont is a font of a content stream, which maps character codes to glyphs and their widths.
+type pdfFont struct {
+	sfnt         *canvasFont.SFNT // nil if the font is not available
+	twoByte      bool             // character codes of Type0 fonts are two bytes
+	firstChar    int
+	widths       []float64       // widths of simple fonts starting at firstChar, in thousandths of text space units
+	cidWidths    map[int]float64 // widths of Type0 fonts
+	defaultWidth float64
+	cidToGID     []byte // maps CIDs to glyph IDs as two-byte big-endian values, identity if nil
+}
+
+// codes returns the character codes of a string.
+func (font *pdfFont) codes(s []byte) []int {
+	codes := []int{}
+	if font.twoByte {
+		for i := 0; i+1 < len(s); i += 2 {
+			codes = append(codes, int(s[i])<<8|int(s[i+1]))
+		}
+	} else {
+		for _, c := range s {
+			codes = append(codes, int(c))
+		}
+	}
+	return codes
+}
+
+// glyphID returns the glyph ID of a character code. Codes of Type0 fonts are CIDs using the Identity-H encoding, and codes of simple fonts are looked up in the font's cmap as Latin-1 characters or as symbols.
+func (font *pdfFont) glyphID(code int) uint16 {
+	if font.twoByte {
+		if font.cidToGID == nil {
+			return uint16(code)
+		} else if 2*code+1 < len(font.cidToGID) {
+			return uint16(font.cidToGID[2*code])<<8 | uint16(font.cidToGID[2*code+1])
+		}
+		return 0
+	} else if glyphID := font.sfnt.GlyphIndex(rune(code)); glyphID != 0 {
+		return glyphID
+	}
+	return font.sfnt.GlyphIndex(0xF000 + rune(code))
+}
+
+// width returns the width of a character code in thousandths of text space units.
+func (font *pdfFont) width(code int) float64 {
+	if font.twoByte {
+		if width, ok := font.cidWidths[code]; ok {
+			return width
+		}
+	} else if i := code - font.firstChar; 0 <= i && i < len(font.widths) {
+		return font.widths[i]
+	} else if font.sfnt != nil {
+		return 1000.0 * float64(font.sfnt.GlyphAdvance(font.glyphID(code))) / float64(font.sfnt.Head.UnitsPerEm)
+	}
+	return font.defaultWidth
+}
+
+// font returns the font of a font dictionary, or nil if it isn't.
+func (r *Reader) font(val interface{}) *pdfFont {
+	ref, isRef := val.(pdfRef)
+	if font, ok := r.fonts[ref]; isRef && ok {
+		return font
+	}
+	dict, ok := r.get(val).(pdfDict)
+	if !ok {
+		return nil
+	}
+
+	font := &pdfFont{}
+	if r.get(dict["Subtype"]) == pdfName("Type0") {
+		descendants, _ := r.get(dict["DescendantFonts"]).(pdfArray)
+		if len(descendants) == 0 {
+			return nil
+		}
+		cidFont, _ := r.get(descendants[0]).(pdfDict)
+		font.twoByte = true
+		font.cidWidths = r.cidWidths(cidFont["W"])
+		font.defaultWidth = 1000.0
+		if width, ok := r.get(cidFont["DW"]).(float64); ok {
+			font.defaultWidth = width
+		}
+		if cidToGID, ok := r.get(cidFont["CIDToGIDMap"]).(pdfStream); ok {
+			font.cidToGID = cidToGID.stream
+		}
+		dict = cidFont
+	} else {
+		firstChar, _ := r.get(dict["FirstChar"]).(float64)
+		font.firstChar = int(firstChar)
+		font.widths = r.numbers(dict["Widths"])
+	}
+
+	descriptor, _ := r.get(dict["FontDescriptor"]).(pdfDict)
+	if width, ok := r.get(descriptor["MissingWidth"]).(float64); ok && !font.twoByte {
+		font.defaultWidth = width
+	}
+	for _, key := range []pdfName{"FontFile2", "FontFile3"} {
+		if program, ok := r.get(descriptor[key]).(pdfStream); ok {
+			if sfnt, err := canvasFont.ParseEmbeddedSFNT(program.stream, 0); err == nil {
+				font.sfnt = sfnt // subsetted fonts may lack tables such as cmap
+			}
+			break
+		}
+	}
+	if font.sfnt == nil && !font.twoByte && descriptor["FontFile"] == nil && descriptor["FontFile2"] == nil && descriptor["FontFile3"] == nil {
+		baseFont, _ := r.get(dict["BaseFont"]).(pdfName)
+		font.sfnt = systemFont(string(baseFont))
+	}
+
+	if isRef {
+		r.fonts[ref] = font
+	}
+	return font
+}
+
+// cidWidths returns the widths of a W array of a CID font, which consists of a first CID followed by an array of widths, or of a first and last CID followed by a width.
+func (r *Reader) cidWidths(val interface{}) map[int]float64 {
+	widths := map[int]float64{}
+	array, _ := r.get(val).(pdfArray)
+	for i := 0; i+1 < len(array); {
+		first, ok := r.get(array[i]).(float64)
+		if !ok {
+			break
+		} else if ws, ok := r.get(array[i+1]).(pdfArray); ok {
+			for j, w := range ws {
+				widths[int(first)+j], _ = r.get(w).(float64)
+			}
+			i += 2
+		} else if i+2 < len(array) {
+			last, _ := r.get(array[i+1]).(float64)
+			width, _ := r.get(array[i+2]).(float64)
+			if first = math.Max(first, 0.0); first <= last {
+				for cid := int(first); cid <= int(math.Min(last, 0xFFFF)); cid++ {
+					widths[cid] = width
+				}
+			}
+			i += 3
+		} else {
+			break
+		}
+	}
+	return widths
+}
+
+// systemFont returns the system font matching the base font name of a font that is not embedded, such as Helvetica-BoldOblique for one of the standard fonts, or nil if there is none.
+func systemFont(baseFont string) *canvasFont.SFNT {
+	if i := strings.IndexByte(baseFont, '+'); i == 6 {
+		baseFont = baseFont[i+1:] // subset prefix
+	}
+	name, style := baseFont, canvas.FontRegular
+	if i := strings.IndexAny(baseFont, "-,"); i != -1 {
+		name = baseFont[:i]
+		modifiers := strings.ToLower(baseFont[i+1:])
+		if strings.Contains(modifiers, "bold") {
+			style |= canvas.FontBold
+		}
+		if strings.Contains(modifiers, "italic") || strings.Contains(modifiers, "oblique") {
+			style |= canvas.FontItalic
+		}
+	}
+	if name == "" {
+		return nil
+	}
+	font, err := canvas.LoadSystemFont(name, style)
+	if err != nil {
+		return nil
+	}
+	return font.SFNT
+}
+
+// image decodes an image XObject with 8 bits per component in the DeviceGray, DeviceRGB, or DeviceCMYK color space, or a JPEG image, and its soft mask.
+func (r *Reader) image(xobj pdfStream) (image.Image, error) {
+	filters, _ := r.get(xobj.dict["Filter"]).(pdfArray)
+	if filter, ok := r.get(xobj.dict["Filter"]).(pdfName); ok {
+		filters = pdfArray{filter}
+	}
+	if 0 < len(filters) && r.get(filters[len(filters)-1]) == pdfName("DCTDecode") {
+		img, err := jpeg.Decode(bytes.NewReader(xobj.stream))
+		if err != nil {
+			return nil, err
+		}
+		return r.applySoftMask(img, xobj.dict["SMask"]), nil
+	}
+
+	width, _ := r.get(xobj.dict["Width"]).(float64)
+	height, _ := r.get(xobj.dict["Height"]).(float64)
+	bpc, _ := r.get(xobj.dict["BitsPerComponent"]).(float64)
+	if !(1.0 <= width && width <= math.MaxInt32 && 1.0 <= height && height <= math.MaxInt32) || bpc != 8 {
+		return nil, fmt.Errorf("unsupported image")
+	}
+	w, h := int(width), int(height)
+
+	comps := 0
+	cs := r.get(xobj.dict["ColorSpace"])
+	if array, ok := cs.(pdfArray); ok && len(array) == 2 && r.get(array[0]) == pdfName("ICCBased") {
+		if profile, ok := r.get(array[1]).(pdfStream); ok {
+			n, _ := r.get(profile.dict["N"]).(float64)
+			comps = int(n)
+		}
+	} else if name, ok := cs.(pdfName); ok {
+		comps = map[pdfName]int{"DeviceGray": 1, "DeviceRGB": 3, "DeviceCMYK": 4}[name]
+	}
+	if comps <= 0 || 4 < comps || len(xobj.stream)/(w*comps) < h {
+		return nil, fmt.Errorf("unsupported image")
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	v := make([]float64, comps)
+	for j := 0; j < w*h; j++ {
+		for k := range v {
+			v[k] = float64(xobj.stream[j*comps+k]) / 255.0
+		}
+		col, _ := deviceColor(v)
+		img.SetRGBA(j%w, j/w, col)
+	}
+	return r.applySoftMask(img, xobj.dict["SMask"]), nil
+}
+
+// applySoftMask applies an 8-bit soft mask of the same size to the image, if any.
+func (r *Reader) applySoftMask(img image.Image, val interface{}) image.Image {
+	smask, ok := r.get(val).(pdfStream)
+	size := img.Bounds().Size()
+	if !ok || len(smask.stream) < size.X*size.Y {
+		return img
+	}
+	width, _ := r.get(smask.dict["Width"]).(float64)
+	height, _ := r.get(smask.dict["Height"]).(float64)
+	if int(width) != size.X || int(height) != size.Y {
+		return img
+	}
+
+	masked := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			col := color.RGBAModel.Convert(img.At(img.Bounds().Min.X+x, img.Bounds().Min.Y+y)).(color.RGBA)
+			masked.SetRGBA(x, y, withAlpha(col, float64(smask.stream[y*size.X+x])/255.0))
+		}
+	}
+	return masked
+}
+
+////////////////////////////////////////////////////////////////
+
+func isWhiteSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f' || b == 0
+}
+
+func isDelimiter(b byte) bool {
+	return b == '(' || b == ')' || b == '<' || b == '>' || b == '[' || b == ']' || b == '{' || b == '}' || b == '/' || b == '%'
+}
+
+func isRegular(b byte) bool {
+	return !isWhiteSpace(b) && !isDelimiter(b)
+}
+
+func abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// skipWhiteSpace returns the position after white space and comments.
+func skipWhiteSpace(b []byte, i int) int {
+	for i < len(b) {
+		if isWhiteSpace(b[i]) {
+			i++
+		} else if b[i] == '%' {
+			for i < len(b) && b[i] != '\r' && b[i] != '\n' {
+				i++
+			}
+		} else {
+			break
+		}
+	}
+	return i
+}
+
+// parseVal parses the value at position i and returns it and the position after it. Numbers are returned as float64, names as pdfName, strings as []byte, indirect references as pdfRef, and keywords as pdfOperator. It returns io.EOF at the end of the data.
+func parseVal(b []byte, i int) (interface{}, int, error) {
+	i = skipWhiteSpace(b, i)
+	if len(b) <= i {
+		return nil, i, io.EOF
+	}
+
+	switch c := b[i]; {
+	case '0' <= c && c <= '9' || c == '+' || c == '-' || c == '.':
+		j := i + 1
+		for j < len(b) && ('0' <= b[j] && b[j] <= '9' || b[j] == '.') {
+			j++
+		}
+		num, err := strconv.ParseFloat(string(b[i:j]), 64)
+		if err != nil {
+			return nil, i, fmt.Errorf("bad number")
+		}
+		return num, j, nil
+	case c == '/':
+		j := i + 1
+		name := []byte{}
+		for j < len(b) && isRegular(b[j]) {
+			if b[j] == '#' && j+2 < len(b) {
+				if v, err := strconv.ParseUint(string(b[j+1:j+3]), 16, 8); err == nil {
+					name = append(name, byte(v))
+					j += 3
+					continue
+				}
+			}
+			name = append(name, b[j])
+			j++
+		}
+		return pdfName(name), j, nil
+	case c == '(':
+		s := []byte{}
+		level := 0
+		for j := i + 1; j < len(b); j++ {
+			switch b[j] {
+			case '(':
+				level++
+			case ')':
+				if level == 0 {
+					return s, j + 1, nil
+				}
+				level--
+			case '\\':
+				j++
+				if len(b) <= j {
+					return nil, i, fmt.Errorf("bad string")
+				} else if k := bytes.IndexByte([]byte("nrtbf()\\"), b[j]); k != -1 {
+					s = append(s, "\n\r\t\b\f()\\"[k])
+					continue
+				} else if '0' <= b[j] && b[j] <= '7' {
+					num, k := 0, 0
+					for ; k < 3 && j+k < len(b) && '0' <= b[j+k] && b[j+k] <= '7'; k++ {
+						num = num*8 + int(b[j+k]-'0')
+					}
+					s = append(s, byte(num))
+					j += k - 1
+					continue
+				} else if b[j] == '\r' || b[j] == '\n' {
+					if b[j] == '\r' && j+1 < len(b) && b[j+1] == '\n' {
+						j++
+					}
+					continue // line continuation
+				}
+			}
+			s = append(s, b[j])
+		}
+		return nil, i, fmt.Errorf("bad string")
+	case c == '<' && i+1 < len(b) && b[i+1] == '<':
+		dict := pdfDict{}
+		j := i + 2
+		for {
+			j = skipWhiteSpace(b, j)
+			if j+1 < len(b) && b[j] == '>' && b[j+1] == '>' {
+				return dict, j + 2, nil
+			}
+			key, n, err := parseVal(b, j)
+			if err != nil {
+				return nil, i, fmt.Errorf("bad dictionary")
+			} else if _, ok := key.(pdfName); !ok {
+				return nil, i, fmt.Errorf("bad dictionary")
+			}
+			val, n, err := parseVal(b, n)
+			if err != nil {
+				return nil, i, fmt.Errorf("bad dictionary")
+			}
+			val, n = parseRef(b, val, n)
+			dict[key.(pdfName)] = val
+			j = n
+		}
+	case c == '<':
+		j := bytes.IndexByte(b[i:], '>')
+		if j == -1 {
+			return nil, i, fmt.Errorf("bad string")
+		}
+		s := bytes.Map(func(c rune) rune {
+			if isWhiteSpace(byte(c)) {
+				return -1
+			}
+			return c
+		}, b[i+1:i+j])
+		if len(s)%2 == 1 {
+			s = append(s, '0')
+		}
+		s, err := hex.DecodeString(string(s))
+		if err != nil {
+			return nil, i, fmt.Errorf("bad string")
+		}
+		return s, i + j + 1, nil
+	case c == '[':
+		array := pdfArray{}
+		j := i + 1
+		for {
+			j = skipWhiteSpace(b, j)
+			if j < len(b) && b[j] == ']' {
+				return array, j + 1, nil
+			}
+			val, n, err := parseVal(b, j)
+			if err != nil {
+				return nil, i, fmt.Errorf("bad array")
+			}
+			val, j = parseRef(b, val, n)
+			array = append(array, val)
+		}
+	case isRegular(c) || c == '{' || c == '}':
+		j := i + 1
+		for j < len(b) && isRegular(b[j]) {
+			j++
+		}
+		switch keyword := string(b[i:j]); keyword {
+		case "true":
+			return true, j, nil
+		case "false":
+			return false, j, nil
+		case "null":
+			return nil, j, nil
+		default:
+			return pdfOperator(keyword), j, nil
+		}
+	}
+	return nil, i, fmt.Errorf("bad value")
+}
+
+// parseRef returns an indirect reference if the number val is followed by a generation number and R.
+func parseRef(b []byte, val interface{}, i int) (interface{}, int) {
+	object, ok := val.(float64)
+	if !ok {
+		return val, i
+	}
+	generation, j, err := parseVal(b, i)
+	if _, ok := generation.(float64); !ok || err != nil {
+		return val, i
+	}
+	if keyword, k, err := parseVal(b, j); err == nil && keyword == pdfOperator("R") {
+		return pdfRef(object), k
+	}
+	return val, i
+}
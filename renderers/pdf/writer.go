@@ -7,12 +7,14 @@ import (
 	"encoding/binary"
 	"fmt"
 	"image"
+	"image/color"
 	"io"
 	"math"
 	"reflect"
 	"sort"
 	"strings"
 	"time"
+	"unicode/utf16"
 
 	"github.com/tdewolff/canvas"
 	canvasText "github.com/tdewolff/canvas/text"
@@ -29,11 +31,14 @@ type pdfWriter struct {
 	pos        int
 	objOffsets []int
 	pages      []pdfRef
+	pageLabels []string
 
 	page       *pdfPageWriter
 	fontSubset map[*canvas.Font]*canvas.FontSubsetter
+	fontText   map[*canvas.Font]map[uint16]string
 	fontsH     map[*canvas.Font]pdfRef
 	fontsV     map[*canvas.Font]pdfRef
+	shadings   map[canvas.Gradient]pdfRef
 	compress   bool
 	subset     bool
 	title      string
@@ -41,6 +46,37 @@ type pdfWriter struct {
 	keywords   string
 	author     string
 	creator    string
+	metadata   *canvas.Metadata
+
+	structRoot    *pdfStructElem     // document element of a tagged PDF, nil if not tagged
+	structElem    *pdfStructElem     // current element
+	structParents []*pdfStructElem   // elements of the marked content of the current page by MCID
+	pageStructs   [][]*pdfStructElem // elements of the marked content of the written pages by MCID
+
+	outlines []*pdfOutline
+}
+
+// pdfOutline is an item of the document outline, also known as bookmarks.
+type pdfOutline struct {
+	level int
+	title string
+	page  int
+	y     float64
+	kids  []*pdfOutline
+	ref   pdfRef
+}
+
+// pdfStructElem is an element of the structure tree of a tagged PDF.
+type pdfStructElem struct {
+	tag    Tag
+	parent *pdfStructElem
+	kids   []interface{} // *pdfStructElem or pdfMarkedContent
+	ref    pdfRef
+}
+
+// pdfMarkedContent is a marked-content sequence of a page that is the content of a structure element.
+type pdfMarkedContent struct {
+	page, mcid int
 }
 
 func newPDFWriter(writer io.Writer) *pdfWriter {
@@ -48,8 +84,10 @@ func newPDFWriter(writer io.Writer) *pdfWriter {
 		w:          writer,
 		objOffsets: []int{0, 0, 0}, // catalog, metadata, page tree
 		fontSubset: map[*canvas.Font]*canvas.FontSubsetter{},
+		fontText:   map[*canvas.Font]map[uint16]string{},
 		fontsH:     map[*canvas.Font]pdfRef{},
 		fontsV:     map[*canvas.Font]pdfRef{},
+		shadings:   map[canvas.Gradient]pdfRef{},
 		compress:   true,
 		subset:     true,
 	}
@@ -93,6 +131,100 @@ func (w *pdfWriter) SetCreator(creator string) {
 	w.creator = creator
 }
 
+// AddOutline adds an item to the document outline with a destination at height y in millimeters on the page with the given index.
+func (w *pdfWriter) AddOutline(level int, title string, page int, y float64) {
+	w.outlines = append(w.outlines, &pdfOutline{level: level, title: title, page: page, y: y})
+}
+
+// writeOutlines writes the document outline and returns the reference to its root, or zero if there are no items. Items are nested under the preceding item of a lower level, and items with a destination on a page that doesn't exist are skipped.
+func (w *pdfWriter) writeOutlines() pdfRef {
+	root := &pdfOutline{level: -1}
+	stack := []*pdfOutline{root}
+	for _, item := range w.outlines {
+		if item.page < 0 || len(w.pages) <= item.page {
+			continue
+		}
+		for 1 < len(stack) && item.level <= stack[len(stack)-1].level {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1]
+		parent.kids = append(parent.kids, item)
+		stack = append(stack, item)
+	}
+	if len(root.kids) == 0 {
+		return 0
+	}
+
+	var reserve func(*pdfOutline)
+	reserve = func(item *pdfOutline) {
+		item.ref = w.reserveObject()
+		for _, kid := range item.kids {
+			reserve(kid)
+		}
+	}
+	reserve(root)
+
+	var write func(*pdfOutline, pdfDict) int
+	write = func(item *pdfOutline, dict pdfDict) int {
+		count := len(item.kids)
+		for i, kid := range item.kids {
+			kidDict := pdfDict{
+				"Title":  kid.title,
+				"Parent": item.ref,
+				"Dest":   pdfArray{w.pages[kid.page], pdfName("XYZ"), nil, kid.y * ptPerMm, nil},
+			}
+			if 0 < i {
+				kidDict["Prev"] = item.kids[i-1].ref
+			}
+			if i+1 < len(item.kids) {
+				kidDict["Next"] = item.kids[i+1].ref
+			}
+			count += write(kid, kidDict)
+		}
+		if item != root {
+			if 0 < len(item.kids) {
+				dict["First"] = item.kids[0].ref
+				dict["Last"] = item.kids[len(item.kids)-1].ref
+				dict["Count"] = count // open
+			}
+			w.writeObjectAt(item.ref, dict)
+		}
+		return count
+	}
+	count := write(root, nil)
+
+	w.writeObjectAt(root.ref, pdfDict{
+		"Type":  pdfName("Outlines"),
+		"First": root.kids[0].ref,
+		"Last":  root.kids[len(root.kids)-1].ref,
+		"Count": count,
+	})
+	return root.ref
+}
+
+// SetTagged enables writing a tagged PDF with a structure tree, see Tag.
+func (w *pdfWriter) SetTagged(tagged bool) {
+	w.structRoot, w.structElem = nil, nil
+	if tagged {
+		w.structRoot = &pdfStructElem{tag: Tag{Type: "Document"}}
+		w.structElem = w.structRoot
+	}
+}
+
+// SetMetadata sets the document's title, subject, author, creation date, and language, and enables writing an XMP metadata stream.
+func (w *pdfWriter) SetMetadata(metadata canvas.Metadata) {
+	if metadata.Title != "" {
+		w.title = metadata.Title
+	}
+	if metadata.Description != "" {
+		w.subject = metadata.Description
+	}
+	if metadata.Creator != "" {
+		w.author = metadata.Creator
+	}
+	w.metadata = &metadata
+}
+
 func (w *pdfWriter) writeBytes(b []byte) {
 	if w.err != nil {
 		return
@@ -128,6 +260,8 @@ const (
 
 func (w *pdfWriter) writeVal(i interface{}) {
 	switch v := i.(type) {
+	case nil:
+		w.write("null")
 	case bool:
 		if v {
 			w.write("true")
@@ -233,6 +367,87 @@ func (w *pdfWriter) writeObject(val interface{}) pdfRef {
 	return pdfRef(len(w.objOffsets))
 }
 
+// reserveObject returns a reference for an object that is written later using writeObjectAt, so that objects can refer to each other.
+func (w *pdfWriter) reserveObject() pdfRef {
+	w.objOffsets = append(w.objOffsets, 0)
+	return pdfRef(len(w.objOffsets))
+}
+
+func (w *pdfWriter) writeObjectAt(ref pdfRef, val interface{}) {
+	w.objOffsets[ref-1] = w.pos
+	w.write("%v 0 obj\n", ref)
+	w.writeVal(val)
+	w.write("\nendobj\n")
+}
+
+// writeStructTree writes the structure tree of a tagged PDF and returns the reference to its root.
+func (w *pdfWriter) writeStructTree() pdfRef {
+	var reserve func(*pdfStructElem)
+	reserve = func(elem *pdfStructElem) {
+		elem.ref = w.reserveObject()
+		for _, kid := range elem.kids {
+			if kid, ok := kid.(*pdfStructElem); ok {
+				reserve(kid)
+			}
+		}
+	}
+	reserve(w.structRoot)
+	root := w.reserveObject()
+
+	var write func(*pdfStructElem, pdfRef)
+	write = func(elem *pdfStructElem, parent pdfRef) {
+		kids := pdfArray{}
+		for _, kid := range elem.kids {
+			switch kid := kid.(type) {
+			case *pdfStructElem:
+				kids = append(kids, kid.ref)
+			case pdfMarkedContent:
+				kids = append(kids, pdfDict{
+					"Type": pdfName("MCR"),
+					"Pg":   w.pages[kid.page],
+					"MCID": kid.mcid,
+				})
+			}
+		}
+		dict := pdfDict{
+			"Type": pdfName("StructElem"),
+			"S":    pdfName(elem.tag.Type),
+			"P":    parent,
+			"K":    kids,
+		}
+		if elem.tag.Alt != "" {
+			dict["Alt"] = elem.tag.Alt
+		}
+		if elem.tag.Language != "" {
+			dict["Lang"] = elem.tag.Language
+		}
+		w.writeObjectAt(elem.ref, dict)
+		for _, kid := range elem.kids {
+			if kid, ok := kid.(*pdfStructElem); ok {
+				write(kid, elem.ref)
+			}
+		}
+	}
+	write(w.structRoot, root)
+
+	// the parent tree maps the marked content of each page to their structure elements
+	nums := pdfArray{}
+	for i, elems := range w.pageStructs {
+		refs := pdfArray{}
+		for _, elem := range elems {
+			refs = append(refs, elem.ref)
+		}
+		nums = append(nums, i, refs)
+	}
+	w.writeObjectAt(root, pdfDict{
+		"Type":              pdfName("StructTreeRoot"),
+		"K":                 w.structRoot.ref,
+		"ParentTree":        w.writeObject(pdfDict{"Nums": nums}),
+		"ParentTreeNextKey": len(w.pageStructs),
+	})
+	return root
+}
+
 func (w *pdfWriter) getFont(font *canvas.Font, vertical bool) pdfRef {
 	fonts := w.fontsH
 	if vertical {
@@ -250,6 +465,50 @@ func (w *pdfWriter) getFont(font *canvas.Font, vertical bool) pdfRef {
 	return ref
 }
 
+// setGlyphText records the text of glyphs that do not map to their characters through the font's cmap table, such as ligatures, so that the text can be extracted from the PDF. The glyphs and their text are those of a text span.
+func (w *pdfWriter) setGlyphText(font *canvas.Font, glyphs []canvasText.Glyph, text string) {
+	if len(glyphs) == 0 {
+		return
+	}
+
+	// clusters are byte offsets into the text, which may be in visual order
+	clusters := make([]uint32, 0, len(glyphs))
+	glyphsPerCluster := map[uint32]int{}
+	for _, glyph := range glyphs {
+		if glyphsPerCluster[glyph.Cluster] == 0 {
+			clusters = append(clusters, glyph.Cluster)
+		}
+		glyphsPerCluster[glyph.Cluster]++
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i] < clusters[j] })
+	offset := clusters[0]
+
+	fontText, ok := w.fontText[font]
+	if !ok {
+		fontText = map[uint16]string{}
+		w.fontText[font] = fontText
+	}
+	for _, glyph := range glyphs {
+		if glyphsPerCluster[glyph.Cluster] != 1 {
+			continue // a character is composed of several glyphs
+		} else if _, ok := fontText[glyph.ID]; ok {
+			continue
+		}
+		i := sort.Search(len(clusters), func(i int) bool { return glyph.Cluster < clusters[i] })
+		start, end := int(glyph.Cluster-offset), len(text)
+		if i < len(clusters) {
+			end = int(clusters[i] - offset)
+		}
+		if end <= start || len(text) < end {
+			continue
+		}
+		s := text[start:end]
+		if r := font.SFNT.Cmap.ToUnicode(glyph.ID); r == 0 || s != string(r) {
+			fontText[glyph.ID] = s
+		}
+	}
+}
+
 func (w *pdfWriter) writeFont(ref pdfRef, font *canvas.Font, vertical bool) {
 	// subset the font, we only write the used characters to the PDF CMap object to reduce its
 	// length. At the end of the function we add a CID to GID mapping to correctly select the
@@ -303,36 +562,49 @@ func (w *pdfWriter) writeFont(ref pdfRef, font *canvas.Font, vertical bool) {
 		W = append(W, i, arr)
 	}
 
-	// create ToUnicode CMap
+	// create ToUnicode CMap, glyphs without a Unicode mapping map to U+FFFD
+	codes := make([][]uint16, len(glyphIDs))
+	for subsetGlyphID, glyphID := range glyphIDs {
+		text, ok := w.fontText[font][glyphID]
+		if !ok {
+			if r := font.SFNT.Cmap.ToUnicode(glyphID); r != 0 {
+				text = string(r)
+			}
+		}
+		if subsetGlyphID == 0 || text == "" {
+			text = "\uFFFD"
+		}
+		codes[subsetGlyphID] = utf16.Encode([]rune(text))
+	}
+
+	// consecutive glyphs that map to consecutive characters are written as ranges, at most 100 entries per section
 	var bfRange, bfChar strings.Builder
-	var bfRangeCount, bfCharCount int
-	startGlyphID := uint16(0)
-	startUnicode := uint32('\uFFFD')
-	length := uint16(1)
-	for subsetGlyphID, glyphID := range glyphIDs[1:] {
-		unicode := uint32(font.SFNT.Cmap.ToUnicode(glyphID))
-		if 0x010000 <= unicode && unicode <= 0x10FFFF {
-			// UTF-16 surrogates
-			unicode -= 0x10000
-			unicode = (0xD800+(unicode>>10)&0x3FF)<<16 + 0xDC00 + unicode&0x3FF
-		}
-		if uint16(subsetGlyphID+1) == startGlyphID+length && unicode == startUnicode+uint32(length) {
-			length++
+	var ranges, chars []string
+	for i := 0; i < len(codes); {
+		j := i + 1
+		if len(codes[i]) == 1 {
+			for j < len(codes) && len(codes[j]) == 1 && codes[j][0] == codes[i][0]+uint16(j-i) && uint16(j-i) <= 0xFF-uint16(i&0xFF) && uint16(j-i) <= 0xFF-codes[i][0]&0xFF {
+				j++
+			}
+		}
+		if 1 < j-i {
+			ranges = append(ranges, fmt.Sprintf("<%04X> <%04X> <%04X>\n", i, j-1, codes[i][0]))
 		} else {
-			if 1 < length {
-				fmt.Fprintf(&bfRange, "<%04X> <%04X> <%04X>\n", startGlyphID, startGlyphID+length-1, startUnicode)
-			} else {
-				fmt.Fprintf(&bfChar, "<%04X> <%04X>\n", startGlyphID, startUnicode)
+			var hex strings.Builder
+			for _, code := range codes[i] {
+				fmt.Fprintf(&hex, "%04X", code)
 			}
-			startGlyphID = uint16(subsetGlyphID + 1)
-			startUnicode = unicode
-			length = 1
+			chars = append(chars, fmt.Sprintf("<%04X> <%s>\n", i, hex.String()))
 		}
+		i = j
 	}
-	if 1 < length {
-		fmt.Fprintf(&bfRange, "<%04X> <%04X> <%04X>\n", startGlyphID, startGlyphID+length-1, startUnicode)
-	} else {
-		fmt.Fprintf(&bfChar, "<%04X> <%04X>\n", startGlyphID, startUnicode)
+	for i := 0; i < len(ranges); i += 100 {
+		entries := ranges[i:min(i+100, len(ranges))]
+		fmt.Fprintf(&bfRange, "%d beginbfrange\n%sendbfrange\n", len(entries), strings.Join(entries, ""))
+	}
+	for i := 0; i < len(chars); i += 100 {
+		entries := chars[i:min(i+100, len(chars))]
+		fmt.Fprintf(&bfChar, "%d beginbfchar\n%sendbfchar\n", len(entries), strings.Join(entries, ""))
 	}
 
 	toUnicode := fmt.Sprintf(`/CIDInit /ProcSet findresource begin
@@ -348,14 +620,10 @@ begincmap
 1 begincodespacerange
 <0000> <FFFF>
 endcodespacerange
-%d beginbfrange
-%sendbfrange
-%d beginbfchar
-%sendbfchar
-endcmap
+%s%sendcmap
 CMapName currentdict /CMap defineresource pop
 end
-end`, bfRangeCount, bfRange.String(), bfCharCount, bfChar.String())
+end`, bfRange.String(), bfChar.String())
 	toUnicodeStream := pdfStream{
 		dict:   pdfDict{},
 		stream: []byte(toUnicode),
@@ -480,6 +748,7 @@ func (w *pdfWriter) Close() error {
 	// TODO: support cross reference table streams and compressed objects for all dicts
 	if w.page != nil {
 		w.pages = append(w.pages, w.page.writePage(pdfRef(3)))
+		w.pageLabels = append(w.pageLabels, w.page.label)
 	}
 
 	kids := pdfArray{}
@@ -491,20 +760,77 @@ func (w *pdfWriter) Close() error {
 	w.writeFonts(w.fontsH, false)
 	w.writeFonts(w.fontsV, false)
 
+	creationDate := time.Now()
+	if w.metadata != nil && !w.metadata.CreationDate.IsZero() {
+		creationDate = w.metadata.CreationDate
+	}
+
 	// document catalog
-	w.objOffsets[0] = w.pos
-	w.write("%v 0 obj\n", 1)
-	w.writeVal(pdfDict{
+	catalog := pdfDict{
 		"Type":  pdfName("Catalog"),
 		"Pages": pdfRef(3),
-		// TODO: add metadata?
-	})
+	}
+	if w.metadata != nil || w.structRoot != nil {
+		metadata := canvas.Metadata{
+			Title:        w.title,
+			Description:  w.subject,
+			Creator:      w.author,
+			CreationDate: creationDate,
+		}
+		if w.metadata != nil {
+			metadata.Language = w.metadata.Language
+		}
+		rdf := metadata.RDF()
+		if w.structRoot != nil {
+			// identify as PDF/UA
+			rdf = strings.Replace(rdf, `</rdf:RDF>`, `<rdf:Description rdf:about="" xmlns:pdfuaid="http://www.aiim.org/pdfua/ns/id/"><pdfuaid:part>1</pdfuaid:part></rdf:Description></rdf:RDF>`, 1)
+		}
+		xmp := "<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>" + `<x:xmpmeta xmlns:x="adobe:ns:meta/">` + rdf + `</x:xmpmeta><?xpacket end="r"?>`
+		catalog["Metadata"] = w.writeObject(pdfStream{
+			dict: pdfDict{
+				"Type":    pdfName("Metadata"),
+				"Subtype": pdfName("XML"),
+			},
+			stream: []byte(xmp),
+		})
+		if metadata.Language != "" {
+			catalog["Lang"] = metadata.Language
+		}
+	}
+	if outlines := w.writeOutlines(); outlines != 0 {
+		catalog["Outlines"] = outlines
+		catalog["PageMode"] = pdfName("UseOutlines")
+	}
+	if w.structRoot != nil {
+		catalog["StructTreeRoot"] = w.writeStructTree()
+		catalog["MarkInfo"] = pdfDict{"Marked": true}
+		catalog["ViewerPreferences"] = pdfDict{"DisplayDocTitle": true}
+	}
+	for _, label := range w.pageLabels {
+		if label != "" {
+			// pages without a label are labelled by their page number
+			nums := pdfArray{}
+			for i, label := range w.pageLabels {
+				if label != "" {
+					nums = append(nums, i, pdfDict{"P": label})
+				} else {
+					nums = append(nums, i, pdfDict{"S": pdfName("D"), "St": i + 1})
+				}
+			}
+			catalog["PageLabels"] = pdfDict{"Nums": nums}
+			break
+		}
+	}
+
+	w.objOffsets[0] = w.pos
+	w.write("%v 0 obj\n", 1)
+	w.writeVal(catalog)
 	w.write("\nendobj\n")
 
 	// metadata
 	info := pdfDict{
 		"Producer":     "tdewolff/canvas",
-		"CreationDate": time.Now().Format("D:20060102150405Z0700"),
+		"CreationDate": creationDate.Format("D:20060102150405Z0700"),
 	}
 	if w.title != "" {
 		info["Title"] = w.title
@@ -559,9 +885,13 @@ type pdfPageWriter struct {
 	width, height float64
 	resources     pdfDict
 	annots        pdfArray
+	trimBox       canvas.Rect
+	bleedBox      canvas.Rect
+	label         string
 
 	graphicsStates map[float64]pdfName
 	alpha          float64
+	blendMode      canvas.CompositeMode
 	fill           canvas.Paint
 	stroke         canvas.Paint
 	lineWidth      float64
@@ -576,16 +906,38 @@ type pdfPageWriter struct {
 	textPosition   canvas.Matrix
 	textCharSpace  float64
 	textRenderMode int
+	softMask       canvas.Gradient // gradient whose alpha is the active soft mask
+	softMasks      map[canvas.Gradient]pdfName
+	tilingPatterns map[canvas.Pattern]pdfName
+	groups         []pdfGroup
+}
+
+// pdfGroup is the state of the page writer before starting a group.
+type pdfGroup struct {
+	page    pdfPageWriter
+	opacity float64
 }
 
 // NewPage starts a new page.
 func (w *pdfWriter) NewPage(width, height float64) *pdfPageWriter {
 	if w.page != nil {
 		w.pages = append(w.pages, w.page.writePage(pdfRef(3)))
+		w.pageLabels = append(w.pageLabels, w.page.label)
 	}
 
+	w.page = w.newPageWriter(width, height)
+	m := canvas.Identity.Scale(ptPerMm, ptPerMm)
+	fmt.Fprintf(w.page, " %v %v %v %v %v %v cm", dec(m[0][0]), dec(m[1][0]), dec(m[0][1]), dec(m[1][1]), dec(m[0][2]), dec(m[1][2]))
+	if w.structRoot != nil {
+		w.page.beginMarkedContent()
+	}
+	return w.page
+}
+
+// newPageWriter returns a page writer with the default graphics state, which is used for pages and the content of tiling patterns.
+func (w *pdfWriter) newPageWriter(width, height float64) *pdfPageWriter {
 	// for defaults see https://help.adobe.com/pdfl_sdk/15/PDFL_SDK_HTMLHelp/PDFL_SDK_HTMLHelp/API_References/PDFL_API_Reference/PDFEdit_Layer/General.html#_t_PDEGraphicState
-	w.page = &pdfPageWriter{
+	return &pdfPageWriter{
 		Buffer:         &bytes.Buffer{},
 		pdf:            w,
 		width:          width,
@@ -607,14 +959,19 @@ func (w *pdfWriter) NewPage(width, height float64) *pdfPageWriter {
 		textPosition:   canvas.Identity,
 		textCharSpace:  0.0,
 		textRenderMode: 0,
+		tilingPatterns: map[canvas.Pattern]pdfName{},
 	}
-
-	m := canvas.Identity.Scale(ptPerMm, ptPerMm)
-	fmt.Fprintf(w.page, " %v %v %v %v %v %v cm", dec(m[0][0]), dec(m[1][0]), dec(m[0][1]), dec(m[1][1]), dec(m[0][2]), dec(m[1][2]))
-	return w.page
 }
 
 func (w *pdfPageWriter) writePage(parent pdfRef) pdfRef {
+	structParents := -1
+	if w.pdf.structRoot != nil {
+		w.endMarkedContent()
+		structParents = len(w.pdf.pageStructs)
+		w.pdf.pageStructs = append(w.pdf.pageStructs, w.pdf.structParents)
+		w.pdf.structParents = nil
+	}
+
 	b := w.Bytes()
 	if 0 < len(b) && b[0] == ' ' {
 		b = b[1:]
@@ -640,12 +997,67 @@ func (w *pdfPageWriter) writePage(parent pdfRef) pdfRef {
 		},
 		"Contents": contents,
 	}
+	if w.trimBox != (canvas.Rect{}) {
+		page["TrimBox"] = pdfArray{w.trimBox.X * ptPerMm, w.trimBox.Y * ptPerMm, (w.trimBox.X + w.trimBox.W) * ptPerMm, (w.trimBox.Y + w.trimBox.H) * ptPerMm}
+	}
+	if w.bleedBox != (canvas.Rect{}) {
+		page["BleedBox"] = pdfArray{w.bleedBox.X * ptPerMm, w.bleedBox.Y * ptPerMm, (w.bleedBox.X + w.bleedBox.W) * ptPerMm, (w.bleedBox.Y + w.bleedBox.H) * ptPerMm}
+	}
 	if 0 < len(w.annots) {
 		page["Annots"] = w.annots
 	}
+	if structParents != -1 {
+		page["StructParents"] = structParents
+	}
 	return w.pdf.writeObject(page)
 }
 
+// BeginTag starts a structure element as a child of the current element, see Tag.
+func (w *pdfPageWriter) BeginTag(tag Tag) {
+	if w.pdf.structRoot == nil {
+		return
+	}
+	w.endMarkedContent()
+	elem := &pdfStructElem{tag: tag, parent: w.pdf.structElem}
+	w.pdf.structElem.kids = append(w.pdf.structElem.kids, elem)
+	w.pdf.structElem = elem
+	w.beginMarkedContent()
+}
+
+// EndTag ends the current structure element.
+func (w *pdfPageWriter) EndTag() {
+	if w.pdf.structRoot == nil || w.pdf.structElem == w.pdf.structRoot {
+		return
+	}
+	w.endMarkedContent()
+	w.pdf.structElem = w.pdf.structElem.parent
+	w.beginMarkedContent()
+}
+
+// beginMarkedContent starts a marked-content sequence for the current structure element, or for an artifact outside of structure elements such as page decorations.
+func (w *pdfPageWriter) beginMarkedContent() {
+	if w.inTextObject {
+		w.EndTextObject()
+	}
+	elem := w.pdf.structElem
+	if elem == w.pdf.structRoot {
+		fmt.Fprintf(w, " /Artifact BMC")
+		return
+	}
+	mcid := len(w.pdf.structParents)
+	w.pdf.structParents = append(w.pdf.structParents, elem)
+	elem.kids = append(elem.kids, pdfMarkedContent{len(w.pdf.pages), mcid})
+	fmt.Fprintf(w, " /%v <</MCID %d>> BDC", elem.tag.Type, mcid)
+}
+
+// endMarkedContent ends the current marked-content sequence.
+func (w *pdfPageWriter) endMarkedContent() {
+	if w.inTextObject {
+		w.EndTextObject()
+	}
+	fmt.Fprintf(w, " EMC")
+}
+
 // AddAnnotation adds an annotation.
 func (w *pdfPageWriter) AddURIAction(uri string, rect canvas.Rect) {
 	annot := pdfDict{
@@ -662,6 +1074,64 @@ func (w *pdfPageWriter) AddURIAction(uri string, rect canvas.Rect) {
 	w.annots = append(w.annots, annot)
 }
 
+// BeginGroup starts writing drawing operations to a transparency group, which is composited with the given opacity at EndGroup.
+func (w *pdfPageWriter) BeginGroup(opacity float64) {
+	if w.inTextObject {
+		w.EndTextObject()
+	}
+	w.groups = append(w.groups, pdfGroup{*w, opacity})
+	w.Buffer = &bytes.Buffer{}
+	w.alpha = 1.0 // reset at the start of a transparency group
+	w.softMask = nil
+}
+
+// EndGroup ends the last group and draws it as a form XObject.
+func (w *pdfPageWriter) EndGroup() {
+	if len(w.groups) == 0 {
+		return
+	} else if w.inTextObject {
+		w.EndTextObject()
+	}
+	b := w.Bytes()
+	if 0 < len(b) && b[0] == ' ' {
+		b = b[1:]
+	}
+
+	// restore the graphics state, which is not affected by the group's content
+	group := w.groups[len(w.groups)-1]
+	annots := w.annots
+	*w = group.page
+	w.annots = annots
+
+	dict := pdfDict{
+		"Type":    pdfName("XObject"),
+		"Subtype": pdfName("Form"),
+		"BBox":    pdfArray{0.0, 0.0, w.width, w.height},
+		"Group": pdfDict{
+			"Type": pdfName("Group"),
+			"S":    pdfName("Transparency"),
+			"I":    true,
+			"CS":   pdfName("DeviceRGB"),
+		},
+		"Resources": w.resources,
+	}
+	if w.pdf.compress {
+		dict["Filter"] = pdfFilterFlate
+	}
+	ref := w.pdf.writeObject(pdfStream{
+		dict:   dict,
+		stream: b,
+	})
+
+	if _, ok := w.resources["XObject"]; !ok {
+		w.resources["XObject"] = pdfDict{}
+	}
+	name := pdfName(fmt.Sprintf("Fm%d", len(w.resources["XObject"].(pdfDict))))
+	w.resources["XObject"].(pdfDict)[name] = ref
+	w.setSoftMask(nil)
+	fmt.Fprintf(w, " q /%v gs /%v Do Q", w.getOpacityGS(group.opacity), name)
+}
+
 // SetAlpha sets the transparency value.
 func (w *pdfPageWriter) SetAlpha(alpha float64) {
 	if alpha != w.alpha {
@@ -671,16 +1141,34 @@ func (w *pdfPageWriter) SetAlpha(alpha float64) {
 	}
 }
 
+// SetBlendMode sets the blend mode. PDF supports only the blend modes of canvas.CompositeMode and not the Porter-Duff operators, which draw over.
+func (w *pdfPageWriter) SetBlendMode(mode canvas.CompositeMode) {
+	if !mode.IsBlend() {
+		mode = canvas.CompositeSrcOver
+	}
+	if mode != w.blendMode {
+		fmt.Fprintf(w, " /%v gs", w.getBlendModeGS(mode))
+		w.blendMode = mode
+	}
+}
+
 // SetFill sets the filling paint.
 func (w *pdfPageWriter) SetFill(fill canvas.Paint) {
 	if fill.Equal(w.fill) {
 		return
 	}
 	if fill.IsPattern() {
-		// TODO
+		if name, ok := w.tilingPatterns[fill.Pattern]; ok {
+			fmt.Fprintf(w, " /Pattern cs /%v scn", name)
+		}
+		w.SetAlpha(1.0)
 	} else if fill.IsGradient() {
 		// TODO: should we unset cs?
 		fmt.Fprintf(w, " /Pattern cs /%v scn", w.getPattern(fill.Gradient))
+		w.SetAlpha(1.0)
+	} else if fill.IsColor() && fill.Swatch != nil && fill.Swatch.Spot {
+		fmt.Fprintf(w, " /%v cs 1 scn", w.getSeparation(fill.Swatch))
+		w.SetAlpha(float64(fill.Color.A) / 255.0)
 	} else {
 		a := float64(fill.Color.A) / 255.0
 		if fill.Color.R == fill.Color.G && fill.Color.R == fill.Color.B {
@@ -690,6 +1178,7 @@ func (w *pdfPageWriter) SetFill(fill canvas.Paint) {
 		}
 		w.SetAlpha(a)
 	}
+	w.setSoftMask(fill.Gradient)
 	w.fill = fill
 }
 
@@ -699,10 +1188,17 @@ func (w *pdfPageWriter) SetStroke(stroke canvas.Paint) {
 		return
 	}
 	if stroke.IsPattern() {
-		// TODO
+		if name, ok := w.tilingPatterns[stroke.Pattern]; ok {
+			fmt.Fprintf(w, " /Pattern CS /%v SCN", name)
+		}
+		w.SetAlpha(1.0)
 	} else if stroke.IsGradient() {
 		// TODO: should we unset CS?
 		fmt.Fprintf(w, " /Pattern CS /%v SCN", w.getPattern(stroke.Gradient))
+		w.SetAlpha(1.0)
+	} else if stroke.IsColor() && stroke.Swatch != nil && stroke.Swatch.Spot {
+		fmt.Fprintf(w, " /%v CS 1 SCN", w.getSeparation(stroke.Swatch))
+		w.SetAlpha(float64(stroke.Color.A) / 255.0)
 	} else {
 		a := float64(stroke.Color.A) / 255.0
 		if stroke.Color.R == stroke.Color.G && stroke.Color.R == stroke.Color.B {
@@ -712,6 +1208,7 @@ func (w *pdfPageWriter) SetStroke(stroke canvas.Paint) {
 		}
 		w.SetAlpha(a)
 	}
+	w.setSoftMask(stroke.Gradient)
 	w.stroke = stroke
 }
 
@@ -1004,6 +1501,7 @@ func (w *pdfPageWriter) DrawImage(img image.Image, enc canvas.ImageEncoding, m c
 	br := m.Dot(canvas.Point{float64(size.X), 0})
 	tl := m.Dot(canvas.Point{0, float64(size.Y)})
 	tr := m.Dot(canvas.Point{float64(size.X), float64(size.Y)})
+	w.setSoftMask(nil)
 	fmt.Fprintf(w, " q %v %v %v %v re W n", dec(outerRect.X), dec(outerRect.Y), dec(outerRect.W), dec(outerRect.H))
 	fmt.Fprintf(w, " %v %v m %v %v l %v %v l %v %v l h W n", dec(bl.X), dec(bl.Y), dec(tl.X), dec(tl.Y), dec(tr.X), dec(tr.Y), dec(br.X), dec(br.Y))
 
@@ -1035,6 +1533,7 @@ func (w *pdfPageWriter) embedImage(img image.Image, enc canvas.ImageEncoding) pd
 		}
 	}
 
+	interpolate := canvas.ImageFilterOf(img) != canvas.NearestNeighbor
 	dict := pdfDict{
 		"Type":             pdfName("XObject"),
 		"Subtype":          pdfName("Image"),
@@ -1042,7 +1541,7 @@ func (w *pdfPageWriter) embedImage(img image.Image, enc canvas.ImageEncoding) pd
 		"Height":           size.Y,
 		"ColorSpace":       pdfName("DeviceRGB"),
 		"BitsPerComponent": 8,
-		"Interpolate":      true,
+		"Interpolate":      interpolate,
 		"Filter":           pdfFilterFlate,
 	}
 
@@ -1055,7 +1554,7 @@ func (w *pdfPageWriter) embedImage(img image.Image, enc canvas.ImageEncoding) pd
 				"Height":           size.Y,
 				"ColorSpace":       pdfName("DeviceGray"),
 				"BitsPerComponent": 8,
-				"Interpolate":      true,
+				"Interpolate":      interpolate,
 				"Filter":           pdfFilterFlate,
 			},
 			stream: bMask,
@@ -1093,26 +1592,81 @@ func (w *pdfPageWriter) getOpacityGS(a float64) pdfName {
 	return name
 }
 
-func (w *pdfPageWriter) getPattern(gradient canvas.Gradient) pdfName {
-	// TODO: support patterns/gradients with alpha channel
-	shading := pdfDict{
-		"ColorSpace": pdfName("DeviceRGB"),
+// getBlendModeGS returns the graphics state that sets the blend mode.
+func (w *pdfPageWriter) getBlendModeGS(mode canvas.CompositeMode) pdfName {
+	bm := pdfName("Normal")
+	if mode.IsBlend() {
+		bm = pdfName(mode.String())
 	}
-	if g, ok := gradient.(*canvas.LinearGradient); ok {
-		shading["ShadingType"] = 2
-		shading["Coords"] = pdfArray{g.Start.X * ptPerMm, g.Start.Y * ptPerMm, g.End.X * ptPerMm, g.End.Y * ptPerMm}
-		shading["Function"] = patternStopsFunction(g.Stops)
-		shading["Extend"] = pdfArray{true, true}
-	} else if g, ok := gradient.(*canvas.RadialGradient); ok {
-		shading["ShadingType"] = 3
-		shading["Coords"] = pdfArray{g.C0.X * ptPerMm, g.C0.Y * ptPerMm, g.R0 * ptPerMm, g.C1.X * ptPerMm, g.C1.Y * ptPerMm, g.R1 * ptPerMm}
-		shading["Function"] = patternStopsFunction(g.Stops)
-		shading["Extend"] = pdfArray{true, true}
+	name := "BM" + bm
+
+	if _, ok := w.resources["ExtGState"]; !ok {
+		w.resources["ExtGState"] = pdfDict{}
 	}
+	w.resources["ExtGState"].(pdfDict)[name] = pdfDict{
+		"BM": bm,
+	}
+	return name
+}
+
+// getSeparation returns the color space resource of a spot color, with the CMYK or L*a*b* definition as the alternate color space. The tint transform is linear between zero ink (white) and full ink.
+func (w *pdfPageWriter) getSeparation(swatch *canvas.Swatch) pdfName {
+	alternate, c0, c1 := interface{}(pdfName("DeviceRGB")), pdfArray{1.0, 1.0, 1.0}, pdfArray{}
+	if swatch.CMYK != nil {
+		alternate, c0 = pdfName("DeviceCMYK"), pdfArray{0.0, 0.0, 0.0, 0.0}
+		c1 = pdfArray{float64(swatch.CMYK.C) / 255.0, float64(swatch.CMYK.M) / 255.0, float64(swatch.CMYK.Y) / 255.0, float64(swatch.CMYK.K) / 255.0}
+	} else if swatch.Lab != nil {
+		alternate = pdfArray{pdfName("Lab"), pdfDict{
+			"WhitePoint": pdfArray{0.9505, 1.0, 1.089},
+			"Range":      pdfArray{-128.0, 127.0, -128.0, 127.0},
+		}}
+		c0 = pdfArray{100.0, 0.0, 0.0}
+		c1 = pdfArray{swatch.Lab[0], swatch.Lab[1], swatch.Lab[2]}
+	} else {
+		a := float64(swatch.Color.A) / 255.0
+		c1 = pdfArray{float64(swatch.Color.R) / 255.0 / a, float64(swatch.Color.G) / 255.0 / a, float64(swatch.Color.B) / 255.0 / a}
+	}
+	colorSpace := pdfArray{pdfName("Separation"), pdfName(pdfNameEscape(swatch.Name)), alternate, pdfDict{
+		"FunctionType": 2,
+		"Domain":       pdfArray{0, 1},
+		"N":            1,
+		"C0":           c0,
+		"C1":           c1,
+	}}
+
+	if _, ok := w.resources["ColorSpace"]; !ok {
+		w.resources["ColorSpace"] = pdfDict{}
+	}
+	for name, cs := range w.resources["ColorSpace"].(pdfDict) {
+		if reflect.DeepEqual(cs, colorSpace) {
+			return name
+		}
+	}
+	name := pdfName(fmt.Sprintf("CS%d", len(w.resources["ColorSpace"].(pdfDict))))
+	w.resources["ColorSpace"].(pdfDict)[name] = colorSpace
+	return name
+}
+
+// pdfNameEscape escapes whitespace, delimiters, and non-ASCII characters in a name using the #xx notation.
+func pdfNameEscape(name string) string {
+	sb := strings.Builder{}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c <= ' ' || '~' < c || strings.IndexByte("#()<>[]{}/%", c) != -1 {
+			fmt.Fprintf(&sb, "#%02X", c)
+		} else {
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+func (w *pdfPageWriter) getPattern(gradient canvas.Gradient) pdfName {
+	// the alpha channel is drawn as a soft mask, see setSoftMask
 	pattern := pdfDict{
 		"Type":        pdfName("Pattern"),
 		"PatternType": 2,
-		"Shading":     shading,
+		"Shading":     w.pdf.getShading(spreadGradient(gradient, canvas.Rect{W: w.width, H: w.height}), ptPerMm, false),
 	}
 
 	if _, ok := w.resources["Pattern"]; !ok {
@@ -1128,7 +1682,363 @@ func (w *pdfPageWriter) getPattern(gradient canvas.Gradient) pdfName {
 	return name
 }
 
-func patternStopsFunction(stops canvas.Stops) pdfDict {
+// addTilingPattern adds the tiling pattern that repeats the content of the tile, which is written by a page writer returned by newPageWriter, to the resources. The pattern is used when setting the fill or stroke to the pattern.
+func (w *pdfPageWriter) addTilingPattern(pattern *canvas.CanvasPattern, tile *pdfPageWriter) {
+	if tile.inTextObject {
+		tile.EndTextObject()
+	}
+	b := tile.Bytes()
+	if 0 < len(b) && b[0] == ' ' {
+		b = b[1:]
+	}
+
+	m := canvas.Identity.Scale(ptPerMm, ptPerMm).Mul(pattern.View)
+	dict := pdfDict{
+		"Type":        pdfName("Pattern"),
+		"PatternType": 1,
+		"PaintType":   1,
+		"TilingType":  1,
+		"BBox":        pdfArray{0.0, 0.0, tile.width, tile.height},
+		"XStep":       tile.width + pattern.Spacing,
+		"YStep":       tile.height + pattern.Spacing,
+		"Matrix":      pdfArray{m[0][0], m[1][0], m[0][1], m[1][1], m[0][2], m[1][2]},
+		"Resources":   tile.resources,
+	}
+	if w.pdf.compress {
+		dict["Filter"] = pdfFilterFlate
+	}
+	ref := w.pdf.writeObject(pdfStream{
+		dict:   dict,
+		stream: b,
+	})
+
+	if _, ok := w.resources["Pattern"]; !ok {
+		w.resources["Pattern"] = pdfDict{}
+	}
+	name := pdfName(fmt.Sprintf("P%d", len(w.resources["Pattern"].(pdfDict))))
+	w.resources["Pattern"].(pdfDict)[name] = ref
+	w.tilingPatterns[pattern] = name
+}
+
+// getShading returns the shading of a gradient, see gradientShading, where shadings that are streams are written as objects since they cannot be direct objects. The objects are reused for the same gradient.
+func (w *pdfWriter) getShading(gradient canvas.Gradient, scale float64, alpha bool) interface{} {
+	var stream pdfStream
+	switch g := gradient.(type) {
+	case *canvas.GouraudGradient:
+		if ref, ok := w.shadings[gradient]; ok && !alpha {
+			return ref
+		}
+		stream = gouraudShading(g, scale, alpha)
+	case *canvas.CoonsGradient:
+		if ref, ok := w.shadings[gradient]; ok && !alpha {
+			return ref
+		}
+		stream = coonsShading(g, scale, alpha)
+	default:
+		return gradientShading(gradient, scale, alpha)
+	}
+	if w.compress {
+		stream.dict["Filter"] = pdfFilterFlate
+	}
+	ref := w.writeObject(stream)
+	if !alpha {
+		w.shadings[gradient] = ref
+	}
+	return ref
+}
+
+// gouraudShading returns the free-form triangle mesh shading of a gradient with per-vertex colors, with coordinates scaled by scale, in the RGB color space or of the alpha channel in the Gray color space.
+func gouraudShading(g *canvas.GouraudGradient, scale float64, alpha bool) pdfStream {
+	bounds := canvas.Rect{}
+	for i, vertex := range g.Vertices {
+		if i == 0 {
+			bounds = canvas.Rect{X: vertex.X, Y: vertex.Y}
+		} else {
+			bounds = bounds.AddPoint(vertex)
+		}
+	}
+	x0, x1 := bounds.X*scale, math.Max((bounds.X+bounds.W)*scale, bounds.X*scale+1.0)
+	y0, y1 := bounds.Y*scale, math.Max((bounds.Y+bounds.H)*scale, bounds.Y*scale+1.0)
+
+	dict := pdfDict{
+		"ShadingType":       4,
+		"ColorSpace":        pdfName("DeviceRGB"),
+		"BitsPerCoordinate": 32,
+		"BitsPerComponent":  8,
+		"BitsPerFlag":       8,
+		"Decode":            pdfArray{x0, x1, y0, y1, 0, 1, 0, 1, 0, 1},
+	}
+	if alpha {
+		dict["ColorSpace"] = pdfName("DeviceGray")
+		dict["Decode"] = pdfArray{x0, x1, y0, y1, 0, 1}
+	}
+
+	// every vertex has flag zero so that each triangle is independent
+	b := &bytes.Buffer{}
+	for _, tri := range g.Triangles {
+		for _, i := range tri {
+			b.WriteByte(0)
+			writeMeshPoint(b, g.Vertices[i], scale, x0, x1, y0, y1)
+			writeMeshColor(b, g.Colors[i], alpha)
+		}
+	}
+	return pdfStream{
+		dict:   dict,
+		stream: b.Bytes(),
+	}
+}
+
+// coonsShading returns the Coons patch mesh shading of a gradient, with coordinates scaled by scale, in the RGB color space or of the alpha channel in the Gray color space.
+func coonsShading(g *canvas.CoonsGradient, scale float64, alpha bool) pdfStream {
+	bounds := canvas.Rect{}
+	for i, patch := range g.Patches {
+		for j, point := range patch.Points {
+			if i == 0 && j == 0 {
+				bounds = canvas.Rect{X: point.X, Y: point.Y}
+			} else {
+				bounds = bounds.AddPoint(point)
+			}
+		}
+	}
+	x0, x1 := bounds.X*scale, math.Max((bounds.X+bounds.W)*scale, bounds.X*scale+1.0)
+	y0, y1 := bounds.Y*scale, math.Max((bounds.Y+bounds.H)*scale, bounds.Y*scale+1.0)
+
+	dict := pdfDict{
+		"ShadingType":       6,
+		"ColorSpace":        pdfName("DeviceRGB"),
+		"BitsPerCoordinate": 32,
+		"BitsPerComponent":  8,
+		"BitsPerFlag":       8,
+		"Decode":            pdfArray{x0, x1, y0, y1, 0, 1, 0, 1, 0, 1},
+	}
+	if alpha {
+		dict["ColorSpace"] = pdfName("DeviceGray")
+		dict["Decode"] = pdfArray{x0, x1, y0, y1, 0, 1}
+	}
+
+	// every patch has flag zero so that each patch is independent, the points run along the boundary starting at a corner in the same order as for PDF
+	b := &bytes.Buffer{}
+	for _, patch := range g.Patches {
+		b.WriteByte(0)
+		for _, point := range patch.Points {
+			writeMeshPoint(b, point, scale, x0, x1, y0, y1)
+		}
+		for _, col := range patch.Colors {
+			writeMeshColor(b, col, alpha)
+		}
+	}
+	return pdfStream{
+		dict:   dict,
+		stream: b.Bytes(),
+	}
+}
+
+// writeMeshPoint writes the coordinates of a point of a mesh shading, scaled by scale and mapped from the decode ranges [x0,x1] and [y0,y1] to 32-bit integers.
+func writeMeshPoint(b *bytes.Buffer, point canvas.Point, scale, x0, x1, y0, y1 float64) {
+	coord := func(v, v0, v1 float64) uint32 {
+		return uint32(math.Round(math.Max(0.0, math.Min(1.0, (v-v0)/(v1-v0))) * math.MaxUint32))
+	}
+	binary.Write(b, binary.BigEndian, coord(point.X*scale, x0, x1))
+	binary.Write(b, binary.BigEndian, coord(point.Y*scale, y0, y1))
+}
+
+// writeMeshColor writes the color of a mesh shading as non-premultiplied RGB, or its alpha channel.
+func writeMeshColor(b *bytes.Buffer, col color.RGBA, alpha bool) {
+	if alpha {
+		b.WriteByte(col.A)
+	} else if col.A == 0 {
+		b.Write([]byte{0, 0, 0})
+	} else {
+		a := float64(col.A) / 255.0
+		b.WriteByte(uint8(math.Min(255.0, float64(col.R)/a+0.5)))
+		b.WriteByte(uint8(math.Min(255.0, float64(col.G)/a+0.5)))
+		b.WriteByte(uint8(math.Min(255.0, float64(col.B)/a+0.5)))
+	}
+}
+
+// gradientShading returns the shading dictionary of a gradient with coordinates scaled by scale, in the RGB color space or of the alpha channel in the Gray color space.
+func gradientShading(gradient canvas.Gradient, scale float64, alpha bool) pdfDict {
+	shading := pdfDict{
+		"ColorSpace": pdfName("DeviceRGB"),
+	}
+	if alpha {
+		shading["ColorSpace"] = pdfName("DeviceGray")
+	}
+	if g, ok := gradient.(*canvas.LinearGradient); ok {
+		shading["ShadingType"] = 2
+		shading["Coords"] = pdfArray{g.Start.X * scale, g.Start.Y * scale, g.End.X * scale, g.End.Y * scale}
+		shading["Function"] = patternStopsFunction(g.Stops.Resample(g.Interpolation), alpha)
+		shading["Extend"] = pdfArray{true, true}
+	} else if g, ok := gradient.(*canvas.RadialGradient); ok {
+		shading["ShadingType"] = 3
+		shading["Coords"] = pdfArray{g.C0.X * scale, g.C0.Y * scale, g.R0 * scale, g.C1.X * scale, g.C1.Y * scale, g.R1 * scale}
+		shading["Function"] = patternStopsFunction(g.Stops.Resample(g.Interpolation), alpha)
+		shading["Extend"] = pdfArray{true, true}
+	}
+	return shading
+}
+
+// maxSpreadPeriods is the maximum number of periods of a gradient with the reflect or repeat spread method that are written to cover the page.
+const maxSpreadPeriods = 256
+
+// spreadGradient returns a gradient with the pad spread method that equals the gradient within the area, by repeating its stops over the periods that cover the area, since shadings can only extend the colors at their start and end. Radial gradients are only converted when the second circle encloses the first, and the area within the first circle is filled by less than one period with the pad spread method.
+func spreadGradient(gradient canvas.Gradient, area canvas.Rect) canvas.Gradient {
+	corners := []canvas.Point{{area.X, area.Y}, {area.X + area.W, area.Y}, {area.X, area.Y + area.H}, {area.X + area.W, area.Y + area.H}}
+	switch g := gradient.(type) {
+	case *canvas.LinearGradient:
+		d := g.End.Sub(g.Start)
+		if g.Spread == canvas.PadSpread || len(g.Stops) == 0 || canvas.Equal(d.Dot(d), 0.0) {
+			return g
+		}
+
+		t0, t1 := math.Inf(1), math.Inf(-1)
+		for _, corner := range corners {
+			t := corner.Sub(g.Start).Dot(d) / d.Dot(d)
+			t0, t1 = math.Min(t0, t), math.Max(t1, t)
+		}
+		n0, n1 := math.Floor(t0), math.Max(math.Ceil(t1), math.Floor(t0)+1.0)
+		n1 = math.Min(n1, n0+maxSpreadPeriods)
+
+		spread := canvas.NewLinearGradient(g.Start.Add(d.Mul(n0)), g.Start.Add(d.Mul(n1)))
+		spread.Stops = spreadStops(g.Stops.Resample(g.Interpolation), g.Spread, n0, n1)
+		return spread
+	case *canvas.RadialGradient:
+		cd, dr := g.C1.Sub(g.C0), g.R1-g.R0
+		if g.Spread == canvas.PadSpread || len(g.Stops) == 0 || dr <= cd.Length() {
+			return g
+		}
+
+		// the circle at offset t encloses the corner when |corner-C0| + t|cd| <= R0 + t dr
+		t1 := 1.0
+		for _, corner := range corners {
+			t1 = math.Max(t1, (corner.Sub(g.C0).Length()-g.R0)/(dr-cd.Length()))
+		}
+		n0, n1 := -math.Floor(g.R0/dr), math.Ceil(t1)
+		n1 = math.Min(n1, n0+maxSpreadPeriods)
+
+		spread := canvas.NewRadialGradient(g.C0.Add(cd.Mul(n0)), g.R0+dr*n0, g.C0.Add(cd.Mul(n1)), g.R0+dr*n1)
+		spread.Stops = spreadStops(g.Stops.Resample(g.Interpolation), g.Spread, n0, n1)
+		return spread
+	}
+	return gradient
+}
+
+// spreadStops returns the stops repeated for the periods from n0 to n1, with every other period reversed for the reflect spread method, mapped to offsets in [0,1].
+func spreadStops(stops canvas.Stops, spread canvas.Spread, n0, n1 float64) canvas.Stops {
+	if !canvas.Equal(stops[0].Offset, 0.0) {
+		stops = append(canvas.Stops{{Offset: 0.0, Color: stops[0].Color}}, stops...)
+	}
+	if !canvas.Equal(stops[len(stops)-1].Offset, 1.0) {
+		stops = append(stops, canvas.Stop{Offset: 1.0, Color: stops[len(stops)-1].Color})
+	}
+
+	spreadStops := canvas.Stops{}
+	for k := n0; k < n1; k++ {
+		for i := range stops {
+			stop := stops[i]
+			if spread == canvas.ReflectSpread && math.Mod(k, 2.0) != 0.0 {
+				stop = stops[len(stops)-1-i]
+				stop.Offset = 1.0 - stop.Offset
+			}
+			if 0 < len(spreadStops) && i == 0 && stop.Color == spreadStops[len(spreadStops)-1].Color {
+				continue // skip duplicate stop between periods
+			}
+			stop.Offset = (k - n0 + stop.Offset) / (n1 - n0)
+			spreadStops = append(spreadStops, stop)
+		}
+	}
+	return spreadStops
+}
+
+// gradientOpaque returns true if the gradient has no transparent color stops.
+func gradientOpaque(gradient canvas.Gradient) bool {
+	var stops canvas.Stops
+	if g, ok := gradient.(*canvas.LinearGradient); ok {
+		stops = g.Stops
+	} else if g, ok := gradient.(*canvas.RadialGradient); ok {
+		stops = g.Stops
+	} else if g, ok := gradient.(*canvas.GouraudGradient); ok {
+		for _, col := range g.Colors {
+			if col.A != 255 {
+				return false
+			}
+		}
+	} else if g, ok := gradient.(*canvas.CoonsGradient); ok {
+		for _, patch := range g.Patches {
+			for _, col := range patch.Colors {
+				if col.A != 255 {
+					return false
+				}
+			}
+		}
+	}
+	for _, stop := range stops {
+		if stop.Color.A != 255 {
+			return false
+		}
+	}
+	return true
+}
+
+// setSoftMask sets the alpha channel of a gradient as the soft mask, or removes the soft mask if the gradient is nil or opaque. The soft mask is a luminosity mask of the gradient's alpha in the Gray color space, since shadings have no alpha channel.
+func (w *pdfPageWriter) setSoftMask(gradient canvas.Gradient) {
+	if gradient != nil && gradientOpaque(gradient) {
+		gradient = nil
+	}
+	if gradient == w.softMask {
+		return
+	}
+	w.softMask = gradient
+
+	if _, ok := w.resources["ExtGState"]; !ok {
+		w.resources["ExtGState"] = pdfDict{}
+	}
+	if gradient == nil {
+		w.resources["ExtGState"].(pdfDict)["SM0"] = pdfDict{
+			"SMask": pdfName("None"),
+		}
+		fmt.Fprintf(w, " /SM0 gs")
+		return
+	} else if w.softMasks == nil {
+		w.softMasks = map[canvas.Gradient]pdfName{}
+	}
+
+	name, ok := w.softMasks[gradient]
+	if !ok {
+		dict := pdfDict{
+			"Type":    pdfName("XObject"),
+			"Subtype": pdfName("Form"),
+			"BBox":    pdfArray{0.0, 0.0, w.width, w.height},
+			"Group": pdfDict{
+				"Type": pdfName("Group"),
+				"S":    pdfName("Transparency"),
+				"CS":   pdfName("DeviceGray"),
+			},
+			"Resources": pdfDict{
+				"Shading": pdfDict{
+					"Sh0": w.pdf.getShading(spreadGradient(gradient, canvas.Rect{W: w.width, H: w.height}), 1.0, true),
+				},
+			},
+		}
+		ref := w.pdf.writeObject(pdfStream{
+			dict:   dict,
+			stream: []byte("/Sh0 sh"),
+		})
+
+		name = pdfName(fmt.Sprintf("SM%d", len(w.softMasks)+1))
+		w.softMasks[gradient] = name
+		w.resources["ExtGState"].(pdfDict)[name] = pdfDict{
+			"SMask": pdfDict{
+				"Type": pdfName("Mask"),
+				"S":    pdfName("Luminosity"),
+				"G":    ref,
+			},
+		}
+	}
+	fmt.Fprintf(w, " /%v gs", name)
+}
+
+func patternStopsFunction(stops canvas.Stops, alpha bool) pdfDict {
 	if len(stops) < 2 {
 		return pdfDict{}
 	}
@@ -1137,20 +2047,21 @@ func patternStopsFunction(stops canvas.Stops) pdfDict {
 	encode := pdfArray{}
 	bounds := pdfArray{}
 	if !canvas.Equal(stops[0].Offset, 0.0) {
-		fs = append(fs, patternStopFunction(stops[0], stops[0]))
+		fs = append(fs, patternStopFunction(stops[0], stops[0], alpha))
 		encode = append(encode, 0, 1)
 		bounds = append(bounds, stops[0].Offset)
 	}
 	for i := 0; i < len(stops)-1; i++ {
-		fs = append(fs, patternStopFunction(stops[i], stops[i+1]))
+		fs = append(fs, patternStopFunction(stops[i], stops[i+1], alpha))
 		encode = append(encode, 0, 1)
 		if i != 0 {
-			bounds = append(bounds, stops[1].Offset)
+			bounds = append(bounds, stops[i].Offset)
 		}
 	}
 	if !canvas.Equal(stops[len(stops)-1].Offset, 1.0) {
-		fs = append(fs, patternStopFunction(stops[len(stops)-1], stops[len(stops)-1]))
+		fs = append(fs, patternStopFunction(stops[len(stops)-1], stops[len(stops)-1], alpha))
 		encode = append(encode, 0, 1)
+		bounds = append(bounds, stops[len(stops)-1].Offset)
 	}
 	if len(fs) == 1 {
 		return fs[0]
@@ -1164,14 +2075,27 @@ func patternStopsFunction(stops canvas.Stops) pdfDict {
 	}
 }
 
-func patternStopFunction(s0, s1 canvas.Stop) pdfDict {
-	a0 := float64(s0.Color.A) / 255.0
-	a1 := float64(s1.Color.A) / 255.0
+func patternStopFunction(s0, s1 canvas.Stop, alpha bool) pdfDict {
+	c0, c1 := s0.Color, s1.Color
+	if !alpha && c0.A == 0 {
+		c0 = c1 // transparent colors take the color of their neighbour
+	} else if !alpha && c1.A == 0 {
+		c1 = c0
+	}
+	components := func(c color.RGBA) pdfArray {
+		a := float64(c.A) / 255.0
+		if alpha {
+			return pdfArray{a}
+		} else if c.A == 0 {
+			return pdfArray{0.0, 0.0, 0.0}
+		}
+		return pdfArray{float64(c.R) / 255.0 / a, float64(c.G) / 255.0 / a, float64(c.B) / 255.0 / a}
+	}
 	return pdfDict{
 		"FunctionType": 2,
 		"Domain":       pdfArray{0, 1},
 		"N":            1,
-		"C0":           pdfArray{float64(s0.Color.R) / 255.0 / a0, float64(s0.Color.G) / 255.0 / a0, float64(s0.Color.B) / 255.0 / a0},
-		"C1":           pdfArray{float64(s1.Color.R) / 255.0 / a1, float64(s1.Color.G) / 255.0 / a1, float64(s1.Color.B) / 255.0 / a1},
+		"C0":           components(c0),
+		"C1":           components(c1),
 	}
 }
@@ -7,6 +7,7 @@ import (
 	"image"
 	"io"
 	"math"
+	"strconv"
 	"strings"
 	"time"
 
@@ -36,6 +37,7 @@ var DefaultOptions = Options{
 // PS is an PostScript renderer. Be aware that PostScript does not support transparency of colors.
 type PS struct {
 	w             io.Writer
+	ew            *canvas.ErrorWriter
 	width, height float64
 	opts          *Options
 
@@ -46,6 +48,10 @@ type PS struct {
 	lineJoin   canvas.Joiner
 	dashOffset float64
 	dashes     []float64
+
+	pages       int
+	pageStarted bool
+	label       string
 }
 
 // New returns an PostScript renderer.
@@ -55,6 +61,8 @@ func New(w io.Writer, width, height float64, opts *Options) *PS {
 		opts = &defaultOptions
 	}
 
+	ew := &canvas.ErrorWriter{Writer: w}
+	w = ew
 	if opts.Format == PostScript {
 		fmt.Fprintf(w, "%%!PS-Adobe-3.0\n")
 	} else if opts.Format == EncapsulatedPostScript {
@@ -63,6 +71,9 @@ func New(w io.Writer, width, height float64, opts *Options) *PS {
 	fmt.Fprintf(w, "%%%%Creator: tdewolff/canvas\n")
 	fmt.Fprintf(w, "%%%%CreationDate: %v\n", time.Now().Format(time.ANSIC))
 	fmt.Fprintf(w, "%%%%BoundingBox: 0 0 %v %v\n", dec(width), dec(height))
+	if opts.Format == PostScript {
+		fmt.Fprintf(w, "%%%%Pages: (atend)\n")
+	}
 
 	if opts.Format == EncapsulatedPostScript {
 		fmt.Fprintf(w, "%%%%EndComments\n")
@@ -73,6 +84,7 @@ func New(w io.Writer, width, height float64, opts *Options) *PS {
 
 	return &PS{
 		w:          w,
+		ew:         ew,
 		width:      width,
 		height:     height,
 		opts:       opts,
@@ -80,11 +92,77 @@ func New(w io.Writer, width, height float64, opts *Options) *PS {
 	}
 }
 
-func (r *PS) Close() error {
+// NewDocument returns a multi-page document that is written as PostScript to the writer, see canvas.Document. Pages are written to the writer as they are finished.
+func NewDocument(w io.Writer, opts *Options) *canvas.Document {
+	options := DefaultOptions
+	if opts != nil {
+		options = *opts
+	}
+	options.Format = PostScript
+	return canvas.NewDocument(func(width, height float64) canvas.PageRenderer {
+		return New(w, width, height, &options)
+	})
+}
+
+// NewPage ends the current page and starts a new page where further rendering will be written to. Encapsulated PostScript supports only a single page.
+func (r *PS) NewPage(width, height float64) {
 	if r.opts.Format == EncapsulatedPostScript {
+		if r.ew.Err == nil {
+			r.ew.Err = fmt.Errorf("EPS supports only a single page")
+		}
+		return
+	}
+	r.endPage()
+	r.width, r.height = width, height
+}
+
+// SetPageMetadata sets the metadata of the current page, where the label is written to the page comment.
+func (r *PS) SetPageMetadata(metadata canvas.PageMetadata) {
+	r.label = metadata.Label
+}
+
+// beginPage writes the page comment and size before the first drawing operation of a page, so that its label can be set until then.
+func (r *PS) beginPage() {
+	if r.opts.Format != PostScript || r.pageStarted {
+		return
+	}
+	r.pages++
+	label := strconv.Itoa(r.pages)
+	if r.label != "" {
+		label = "(" + escapeString(r.label) + ")"
+	}
+	fmt.Fprintf(r.w, "\n%%%%Page: %v %d\n%%%%PageBoundingBox: 0 0 %v %v\n<< /PageSize [%v %v] >> setpagedevice", label, r.pages, dec(r.width), dec(r.height), dec(r.width), dec(r.height))
+	r.pageStarted = true
+}
+
+// endPage shows the current page, which resets the graphics state.
+func (r *PS) endPage() {
+	r.beginPage()
+	fmt.Fprintf(r.w, "\nshowpage")
+	r.pageStarted = false
+	r.label = ""
+	r.paint = canvas.Paint{}
+	r.lineWidth = 0.0
+	r.miterLimit = 10.0
+	r.lineCap = nil
+	r.lineJoin = nil
+	r.dashOffset = 0.0
+	r.dashes = nil
+}
+
+func (r *PS) Close() error {
+	if r.opts.Format == PostScript {
+		r.endPage()
+		fmt.Fprintf(r.w, "\n%%%%Trailer\n%%%%Pages: %d\n%%%%EOF\n", r.pages)
+	} else if r.opts.Format == EncapsulatedPostScript {
 		fmt.Fprintf(r.w, "%%%%EOF")
 	}
-	return nil
+	return r.ew.Err
+}
+
+// Err returns the first error that occurred while writing.
+func (r *PS) Err() error {
+	return r.ew.Err
 }
 
 func (r *PS) setPaint(paint canvas.Paint) {
@@ -92,7 +170,9 @@ func (r *PS) setPaint(paint canvas.Paint) {
 		return
 	}
 	color := toNRGBA(paint.Color)
-	if color.R != r.paint.Color.R || color.G != r.paint.Color.G || color.B != r.paint.Color.B {
+	if paint.Swatch != nil && paint.Swatch.Spot {
+		fmt.Fprintf(r.w, " [/Separation (%v) cvn %v] setcolorspace 1 setcolor", escapeString(paint.Swatch.Name), separationAlternate(paint.Swatch))
+	} else if color.R != r.paint.Color.R || color.G != r.paint.Color.G || color.B != r.paint.Color.B || r.paint.Swatch != nil && r.paint.Swatch.Spot {
 		if color.R == color.G && color.R == color.B {
 			fmt.Fprintf(r.w, " %v setgray", dec(float64(color.R)/255.0))
 		} else {
@@ -102,6 +182,24 @@ func (r *PS) setPaint(paint canvas.Paint) {
 	r.paint = paint
 }
 
+// separationAlternate returns the alternate color space and tint transform of a spot color, which is linear between zero ink (white) and full ink.
+func separationAlternate(swatch *canvas.Swatch) string {
+	if swatch.CMYK != nil {
+		c, m, y, k := float64(swatch.CMYK.C)/255.0, float64(swatch.CMYK.M)/255.0, float64(swatch.CMYK.Y)/255.0, float64(swatch.CMYK.K)/255.0
+		return fmt.Sprintf("/DeviceCMYK {dup %v mul exch dup %v mul exch dup %v mul exch %v mul}", dec(c), dec(m), dec(y), dec(k))
+	}
+	color := toNRGBA(swatch.Color)
+	R, G, B := float64(color.R)/255.0-1.0, float64(color.G)/255.0-1.0, float64(color.B)/255.0-1.0
+	return fmt.Sprintf("/DeviceRGB {dup %v mul 1 add exch dup %v mul 1 add exch %v mul 1 add}", dec(R), dec(G), dec(B))
+}
+
+func escapeString(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `(`, `\(`, -1)
+	s = strings.Replace(s, `)`, `\)`, -1)
+	return s
+}
+
 func (r *PS) setLineWidth(width float64) {
 	if width != r.lineWidth {
 		fmt.Fprintf(r.w, " %v setlinewidth", dec(width))
@@ -163,6 +261,11 @@ func (r *PS) setDashes(offset float64, dashes []float64) {
 	}
 }
 
+// Capabilities returns the constructs that PostScript supports natively, which are none: gradients, patterns, composite modes, and shadows are converted to paths and images, text is drawn as paths, and transparent colors are composited onto white.
+func (r *PS) Capabilities() canvas.Capabilities {
+	return canvas.Capabilities{}
+}
+
 // Size returns the size of the canvas in millimeters.
 func (r *PS) Size() (float64, float64) {
 	return r.width, r.height
@@ -170,6 +273,7 @@ func (r *PS) Size() (float64, float64) {
 
 // RenderPath renders a path to the canvas using a style and a transformation matrix.
 func (r *PS) RenderPath(path *canvas.Path, style canvas.Style, m canvas.Matrix) {
+	r.beginPage()
 	// TODO: (EPS) use dither to fake transparency
 
 	strokeUnsupported := false
@@ -182,6 +286,12 @@ func (r *PS) RenderPath(path *canvas.Path, style canvas.Style, m canvas.Matrix)
 			strokeUnsupported = true
 		}
 	}
+	// PS doesn't support different start and end caps or custom cappers
+	if start, end, ok := canvas.NativeCapper(style.StrokeCapper); !ok || start != end {
+		strokeUnsupported = true
+	} else {
+		style.StrokeCapper = start
+	}
 	if !strokeUnsupported {
 		if m.IsSimilarity() {
 			scale := math.Sqrt(math.Abs(m.Det()))
@@ -247,6 +357,7 @@ func (r *PS) RenderText(text *canvas.Text, m canvas.Matrix) {
 
 // RenderImage renders an image to the canvas using a transformation matrix.
 func (r *PS) RenderImage(img image.Image, m canvas.Matrix) {
+	r.beginPage()
 	size := img.Bounds().Size()
 	sp := img.Bounds().Min // starting point
 	b := make([]byte, size.X*size.Y*3)
@@ -2,9 +2,12 @@ package ps
 
 import (
 	"bytes"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/test"
 )
 
 func TestPS(t *testing.T) {
@@ -13,3 +16,39 @@ func TestPS(t *testing.T) {
 	ps.setPaint(canvas.Paint{Color: canvas.Red})
 	//test.String(t, string(w.Bytes()), "")
 }
+
+type failWriter struct{}
+
+func (failWriter) Write(b []byte) (int, error) {
+	return 0, errors.New("write error")
+}
+
+func TestPSErr(t *testing.T) {
+	ps := New(failWriter{}, 100, 80, nil)
+	ctx := canvas.NewContext(ps)
+	ctx.DrawPath(0.0, 0.0, canvas.Rectangle(10.0, 10.0))
+	test.T(t, ctx.Err(), errors.New("write error"))
+	test.T(t, ps.Close(), errors.New("write error"))
+}
+
+func TestPSDocument(t *testing.T) {
+	w := &bytes.Buffer{}
+	doc := NewDocument(w, nil)
+	page := doc.NewPage(100, 80)
+	doc.SetPageMetadata(canvas.PageMetadata{Label: "i"})
+	canvas.NewContext(page).DrawPath(0.0, 0.0, canvas.Rectangle(10.0, 10.0))
+	doc.NewPage(50, 40)
+	test.Error(t, doc.Close())
+
+	ps := w.String()
+	test.That(t, strings.Contains(ps, "%%Page: (i) 1\n%%PageBoundingBox: 0 0 100 80\n"), "expected labelled first page")
+	test.That(t, strings.Contains(ps, "%%Page: 2 2\n%%PageBoundingBox: 0 0 50 40\n"), "expected second page")
+	test.That(t, strings.HasSuffix(ps, "showpage\n%%Trailer\n%%Pages: 2\n%%EOF\n"), "expected trailer")
+	test.T(t, strings.Count(ps, "showpage"), 2)
+}
+
+func TestPSDocumentEPS(t *testing.T) {
+	ps := New(&bytes.Buffer{}, 100, 80, &Options{Format: EncapsulatedPostScript})
+	ps.NewPage(100, 80)
+	test.That(t, ps.Close() != nil, "expected error for multiple pages in EPS")
+}
@@ -0,0 +1,220 @@
+package rasterizer
+
+import (
+	"image"
+	"math"
+	"sync"
+
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/font"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/vector"
+)
+
+// GlyphSubpixels is the number of subpixel positions per pixel, horizontally and vertically, at which glyphs are rasterized into the glyph atlas.
+const GlyphSubpixels = 4
+
+// glyphAtlasPageSize is the width and height of the atlas pages in pixels.
+const glyphAtlasPageSize = 1024
+
+// DefaultGlyphAtlas is the glyph atlas used by new rasterizers, including those of the OpenGL renderer, unless another atlas is set with SetGlyphAtlas. It is disabled when nil, so that text is drawn as paths.
+var DefaultGlyphAtlas *GlyphAtlas
+
+// GlyphAtlas caches rasterized glyphs per font, size and subpixel offset in pages of coverage masks, so that drawing text blits glyph masks instead of filling glyph outlines for every glyph. When the memory limit is reached, all pages are cleared. It is safe for concurrent use.
+type GlyphAtlas struct {
+	mu       sync.Mutex
+	maxBytes int
+	pages    []*image.Alpha
+	glyphs   map[glyphKey]atlasGlyph
+
+	// shelf packing state for the last page
+	x, y, shelfHeight int
+}
+
+type glyphKey struct {
+	sfnt                 *font.SFNT
+	id                   uint16
+	size                 float64 // in pixels per em
+	ppem                 uint16  // size rounded down, for hinting
+	fauxBold, fauxItalic float64
+	dx, dy               uint8 // subpixel offsets
+}
+
+// atlasGlyph is the location of a glyph's mask in the atlas, with (ox,oy) the glyph's origin relative to the top-left of the mask.
+type atlasGlyph struct {
+	page   int
+	rect   image.Rectangle
+	ox, oy int
+}
+
+// NewGlyphAtlas returns a glyph atlas that uses at most maxBytes of memory for its pages, with a minimum of one page.
+func NewGlyphAtlas(maxBytes int) *GlyphAtlas {
+	return &GlyphAtlas{
+		maxBytes: maxBytes,
+		glyphs:   map[glyphKey]atlasGlyph{},
+	}
+}
+
+// SetMaxBytes sets the memory limit of the atlas pages.
+func (a *GlyphAtlas) SetMaxBytes(maxBytes int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.maxBytes = maxBytes
+	if a.maxBytes < a.bytes() {
+		a.reset()
+	}
+}
+
+// Bytes returns the memory used by the atlas pages.
+func (a *GlyphAtlas) Bytes() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.bytes()
+}
+
+// Len returns the number of glyphs in the atlas.
+func (a *GlyphAtlas) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.glyphs)
+}
+
+// Reset removes all glyphs from the atlas.
+func (a *GlyphAtlas) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.reset()
+}
+
+func (a *GlyphAtlas) bytes() int {
+	return len(a.pages) * glyphAtlasPageSize * glyphAtlasPageSize
+}
+
+func (a *GlyphAtlas) reset() {
+	a.pages = a.pages[:0]
+	a.glyphs = map[glyphKey]atlasGlyph{}
+	a.x, a.y, a.shelfHeight = 0, 0, 0
+}
+
+// allocate reserves a w×h rectangle in the atlas, adding pages as needed.
+func (a *GlyphAtlas) allocate(w, h int) (int, image.Rectangle, bool) {
+	if glyphAtlasPageSize < w || glyphAtlasPageSize < h {
+		return 0, image.Rectangle{}, false
+	}
+	if glyphAtlasPageSize < a.x+w {
+		// next shelf
+		a.x, a.y = 0, a.y+a.shelfHeight
+		a.shelfHeight = 0
+	}
+	if len(a.pages) == 0 || glyphAtlasPageSize < a.y+h {
+		if 0 < len(a.pages) && a.maxBytes < a.bytes()+glyphAtlasPageSize*glyphAtlasPageSize {
+			a.reset()
+		}
+		a.pages = append(a.pages, image.NewAlpha(image.Rect(0, 0, glyphAtlasPageSize, glyphAtlasPageSize)))
+		a.x, a.y, a.shelfHeight = 0, 0, 0
+	}
+	rect := image.Rect(a.x, a.y, a.x+w, a.y+h)
+	a.x += w
+	a.shelfHeight = max(a.shelfHeight, h)
+	return len(a.pages) - 1, rect, true
+}
+
+// glyph returns the mask of the glyph, rasterizing it into the atlas if needed. The mask's origin is at (ox,oy) relative to its top-left.
+func (a *GlyphAtlas) glyph(key glyphKey) (*image.Alpha, image.Rectangle, int, int, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if g, ok := a.glyphs[key]; ok {
+		return a.pages[g.page], g.rect, g.ox, g.oy, true
+	}
+
+	// glyph outline in pixels with the origin at the subpixel offset
+	f := key.size / float64(key.sfnt.Head.UnitsPerEm)
+	p := &canvas.Path{}
+	if err := key.sfnt.GlyphPath(p, key.id, key.ppem, 0.0, 0.0, f, font.NoHinting); err != nil {
+		return nil, image.Rectangle{}, 0, 0, false
+	}
+	if key.fauxBold != 0.0 {
+		p = p.Offset(key.fauxBold*key.size, canvas.NonZero, canvas.Tolerance)
+	}
+	if key.fauxItalic != 0.0 {
+		p = p.Transform(canvas.Identity.Shear(key.fauxItalic, 0.0))
+	}
+	p = p.Translate(float64(key.dx)/GlyphSubpixels, -float64(key.dy)/GlyphSubpixels)
+
+	bounds := p.FastBounds()
+	x0, y0 := int(math.Floor(bounds.X))-1, int(math.Floor(bounds.Y))-1
+	x1, y1 := int(math.Ceil(bounds.X+bounds.W))+1, int(math.Ceil(bounds.Y+bounds.H))+1
+	w, h := x1-x0, y1-y0
+	g := atlasGlyph{ox: -x0, oy: y1}
+	if !p.Empty() {
+		page, rect, ok := a.allocate(w, h)
+		if !ok {
+			return nil, image.Rectangle{}, 0, 0, false
+		}
+		ras := vector.NewRasterizer(w, h)
+		p.Translate(-float64(x0), -float64(y0)).ToRasterizer(ras, canvas.DPMM(1.0))
+		ras.Draw(a.pages[page], rect, image.Opaque, image.Point{})
+		g.page, g.rect = page, rect
+	}
+	a.glyphs[key] = g
+	if len(a.pages) == 0 {
+		return nil, g.rect, g.ox, g.oy, true
+	}
+	return a.pages[g.page], g.rect, g.ox, g.oy, true
+}
+
+// SetGlyphAtlas sets the glyph atlas used to draw text, or disables the glyph atlas when nil so that text is drawn as paths.
+func (r *Rasterizer) SetGlyphAtlas(atlas *GlyphAtlas) {
+	r.atlas = atlas
+}
+
+// renderTextAtlas draws the text using the glyph atlas and returns false if the text cannot be drawn using the atlas, e.g. for rotated or vertical text, in which case nothing is drawn.
+func (r *Rasterizer) renderTextAtlas(text *canvas.Text, m canvas.Matrix) bool {
+	scale := m[0][0]
+	if r.atlas == nil || text.WritingMode != canvas.HorizontalTB || m[0][1] != 0.0 || m[1][0] != 0.0 || scale <= 0.0 || m[1][1] != scale {
+		return false
+	}
+	supported := true
+	text.WalkSpans(func(_, _ float64, span canvas.TextSpan) {
+		if !span.IsText() || span.Rotation != 0 || !span.Face.Fill.IsColor() {
+			supported = false
+		}
+	})
+	if !supported {
+		return false
+	}
+
+	text.WalkDecorations(func(paint canvas.Paint, p *canvas.Path) {
+		style := canvas.DefaultStyle
+		style.Fill = paint
+		r.RenderPath(p, style, m)
+	})
+
+	dpmm := r.resolution.DPMM()
+	height := float64(r.Bounds().Size().Y)
+	text.WalkSpans(func(x, y float64, span canvas.TextSpan) {
+		face := span.Face
+		src := image.NewUniform(r.colorSpace.ToLinear(face.Fill.Color))
+		size := math.Round(scale*dpmm*face.MmPerEm*float64(face.Font.Head.UnitsPerEm)*64.0) / 64.0
+		f := face.MmPerEm
+		var xu, yu int32
+		for _, glyph := range span.Glyphs {
+			pos := m.Dot(canvas.Point{x + f*float64(xu+glyph.XOffset), y + f*float64(yu+glyph.YOffset)})
+			px, py := pos.X*dpmm, height-pos.Y*dpmm
+			if face.Hinting != font.NoHinting {
+				py = math.Round(py) // grid-align vertically, as for RenderAsPath
+			}
+			px, py = math.Round(px*GlyphSubpixels), math.Round(py*GlyphSubpixels)
+			ix, iy := math.Floor(px/GlyphSubpixels), math.Floor(py/GlyphSubpixels)
+			dx, dy := uint8(px-ix*GlyphSubpixels), uint8(py-iy*GlyphSubpixels)
+			key := glyphKey{face.Font.SFNT, glyph.ID, size, uint16(size), face.FauxBold, face.FauxItalic, dx, dy}
+			if mask, rect, ox, oy, ok := r.atlas.glyph(key); ok && mask != nil {
+				dst := rect.Sub(rect.Min).Add(image.Point{int(ix) - ox, int(iy) - oy})
+				draw.DrawMask(r.Image, dst, src, image.Point{}, mask, rect.Min, draw.Over)
+			}
+			xu += glyph.XAdvance
+			yu += glyph.YAdvance
+		}
+	})
+	return true
+}
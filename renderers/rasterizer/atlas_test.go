@@ -0,0 +1,84 @@
+package rasterizer
+
+import (
+	"image"
+	"testing"
+
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/test"
+)
+
+func TestGlyphAtlasAllocate(t *testing.T) {
+	a := NewGlyphAtlas(glyphAtlasPageSize * glyphAtlasPageSize)
+	page, rect, ok := a.allocate(600, 10)
+	test.That(t, ok)
+	test.T(t, page, 0)
+	test.T(t, rect, image.Rect(0, 0, 600, 10))
+
+	_, rect, _ = a.allocate(300, 20)
+	test.T(t, rect, image.Rect(600, 0, 900, 20))
+
+	// next shelf starts below the highest glyph of the previous shelf
+	_, rect, _ = a.allocate(200, 5)
+	test.T(t, rect, image.Rect(0, 20, 200, 25))
+
+	_, _, ok = a.allocate(glyphAtlasPageSize+1, 1)
+	test.That(t, !ok, "glyph must not be larger than a page")
+
+	// a new page exceeds the memory limit, which clears the atlas
+	page, rect, _ = a.allocate(10, glyphAtlasPageSize)
+	test.T(t, page, 0)
+	test.T(t, rect, image.Rect(0, 0, 10, glyphAtlasPageSize))
+	test.T(t, a.Bytes(), glyphAtlasPageSize*glyphAtlasPageSize)
+}
+
+func TestGlyphAtlas(t *testing.T) {
+	family := canvas.NewFontFamily("dejavu-serif")
+	if err := family.LoadFontFile("../../resources/DejaVuSerif.ttf", canvas.FontRegular); err != nil {
+		test.Error(t, err)
+	}
+	face := family.Face(12.0, canvas.Black, canvas.FontRegular, canvas.FontNormal)
+
+	test.That(t, New(10.0, 10.0, canvas.DPMM(1.0), canvas.LinearColorSpace{}).atlas == nil, "atlas must be disabled by default")
+
+	a := NewGlyphAtlas(16 << 20)
+	key := glyphKey{sfnt: face.Font.SFNT, id: face.Font.GlyphIndex('A'), size: 16.0, ppem: 16}
+	mask, rect, ox, oy, ok := a.glyph(key)
+	test.That(t, ok && mask != nil)
+	test.That(t, !rect.Empty())
+	test.That(t, 0 < ox && 0 < oy)
+
+	// cached glyphs are reused
+	mask2, rect2, _, _, _ := a.glyph(key)
+	test.That(t, mask2 == mask, "must return the same page")
+	test.T(t, rect2, rect)
+	test.T(t, a.Len(), 1)
+
+	// other subpixel offsets are packed next to it
+	key.dx = 2
+	_, rect3, _, _, _ := a.glyph(key)
+	test.T(t, rect3.Min, image.Point{rect.Max.X, rect.Min.Y})
+	test.T(t, a.Len(), 2)
+
+	// text drawn from the atlas matches text drawn as paths
+	render := func(atlas *GlyphAtlas) *image.RGBA {
+		r := New(60.0, 20.0, canvas.DPMM(4.0), canvas.LinearColorSpace{})
+		r.SetGlyphAtlas(atlas)
+		r.RenderText(canvas.NewTextLine(face, "Glyph atlas", canvas.Left), canvas.Identity.Translate(5.0, 10.0))
+		return r.Image.(*image.RGBA)
+	}
+	a.Reset()
+	want := render(nil)
+	have := render(a)
+	test.That(t, 0 < a.Len(), "glyphs must be cached")
+
+	ink := 0
+	for i := 3; i < len(want.Pix); i += 4 {
+		ink += int(want.Pix[i])
+		if d := int(have.Pix[i]) - int(want.Pix[i]); d < -128 || 128 < d {
+			test.Fail(t, "pixel differs at", i%want.Stride/4, i/want.Stride)
+			break
+		}
+	}
+	test.That(t, 0 < ink, "text must be drawn")
+}
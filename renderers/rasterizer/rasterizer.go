@@ -2,6 +2,7 @@ package rasterizer
 
 import (
 	"image"
+	"image/color"
 	"math"
 
 	"github.com/tdewolff/canvas"
@@ -13,17 +14,178 @@ import (
 // Draw draws the canvas on a new image with given resolution (in dots-per-millimeter). Higher resolution will result in larger images.
 func Draw(c *canvas.Canvas, resolution canvas.Resolution, colorSpace canvas.ColorSpace) *image.RGBA {
 	img := image.NewRGBA(image.Rect(0, 0, int(c.W*resolution.DPMM()+0.5), int(c.H*resolution.DPMM()+0.5)))
-	ras := FromImage(img, resolution, colorSpace)
-	c.RenderTo(ras)
-	ras.Close()
+	DrawInto(img, c, resolution, colorSpace)
 	return img
 }
 
+// DrawInto draws the canvas on an existing image with given resolution (in dots-per-millimeter), such as a preallocated buffer that is reused for every frame or a memory-mapped framebuffer. The image is cleared first, and the bottom-left of the canvas is placed at the bottom-left of the image.
+func DrawInto(dst draw.Image, c *canvas.Canvas, resolution canvas.Resolution, colorSpace canvas.ColorSpace) {
+	DrawIntoRect(dst, dst.Bounds(), c, resolution, colorSpace)
+}
+
+// DrawIntoRect draws the canvas on the rectangle rect of an existing image with given resolution (in dots-per-millimeter), leaving the rest of the image untouched. The rectangle is cleared first, and the bottom-left of the canvas is placed at the bottom-left of the rectangle.
+func DrawIntoRect(dst draw.Image, rect image.Rectangle, c *canvas.Canvas, resolution canvas.Resolution, colorSpace canvas.ColorSpace) {
+	dst, rect = subImage(dst, rect)
+	if rect.Empty() {
+		return
+	}
+	draw.Draw(dst, rect, image.Transparent, image.Point{}, draw.Src)
+	drawRect(dst, rect, c, resolution, colorSpace)
+}
+
+// DrawOver draws the canvas over the existing contents of the rectangle rect of an image with given resolution (in dots-per-millimeter), such as for watermarking. The bottom-left of the canvas is placed at the bottom-left of the rectangle. When the color space is not linear, the contents of the rectangle are converted to linear colors for blending and back again, which may lose precision for 8-bit images.
+func DrawOver(dst draw.Image, rect image.Rectangle, c *canvas.Canvas, resolution canvas.Resolution, colorSpace canvas.ColorSpace) {
+	dst, rect = subImage(dst, rect)
+	if rect.Empty() {
+		return
+	}
+	if colorSpace == nil {
+		colorSpace = canvas.DefaultColorSpace
+	}
+	if _, ok := colorSpace.(canvas.LinearColorSpace); !ok {
+		// gamma decompress, the rasterizer compresses again when closed
+		changeColorSpace(dst, dst, colorSpace.ToLinear)
+	}
+	drawRect(dst, rect, c, resolution, colorSpace)
+}
+
+// ChangesTileSize is the size in pixels of the tiles that are redrawn by DrawChanges.
+var ChangesTileSize = 64
+
+// DrawChanges redraws only the regions of an image that differ between the previous and the current canvas, where the image contains the previous canvas as drawn by DrawInto with the same resolution and color space. This allows smooth redrawing of interactive or animated content without rendering the whole canvas for every frame. The changed regions are rounded out to tiles of ChangesTileSize pixels, which are cleared and redrawn, and are returned. If prev is nil, the whole image is redrawn.
+func DrawChanges(dst draw.Image, c, prev *canvas.Canvas, resolution canvas.Resolution, colorSpace canvas.ColorSpace) []image.Rectangle {
+	bounds := dst.Bounds()
+	dpmm := resolution.DPMM()
+	tile := ChangesTileSize
+
+	// mark the tiles that overlap changed regions, including a pixel of antialiasing
+	cols := (bounds.Dx() + tile - 1) / tile
+	rows := (bounds.Dy() + tile - 1) / tile
+	dirty := make([]bool, cols*rows)
+	for _, rect := range c.Changes(prev) {
+		x0 := int(math.Floor(rect.X*dpmm)) - 1
+		x1 := int(math.Ceil((rect.X+rect.W)*dpmm)) + 1
+		y0 := bounds.Dy() - int(math.Ceil((rect.Y+rect.H)*dpmm)) - 1
+		y1 := bounds.Dy() - int(math.Floor(rect.Y*dpmm)) + 1
+		x0, y0 = max(x0, 0), max(y0, 0)
+		x1, y1 = min(x1, bounds.Dx()), min(y1, bounds.Dy())
+		for j := y0 / tile; j*tile < y1; j++ {
+			for i := x0 / tile; i*tile < x1; i++ {
+				dirty[j*cols+i] = true
+			}
+		}
+	}
+
+	// redraw runs of dirty tiles per row
+	rects := []image.Rectangle{}
+	for j := 0; j < rows; j++ {
+		for i := 0; i < cols; i++ {
+			if !dirty[j*cols+i] {
+				continue
+			}
+			i0 := i
+			for i < cols && dirty[j*cols+i] {
+				i++
+			}
+			rect := image.Rect(i0*tile, j*tile, i*tile, (j+1)*tile).Add(bounds.Min).Intersect(bounds)
+			sub, _ := subImage(dst, rect)
+			draw.Draw(sub, rect, image.Transparent, image.Point{}, draw.Src)
+
+			// place the canvas as for the whole image
+			ras := FromImage(sub, resolution, colorSpace)
+			c.RenderViewTo(ras, canvas.Identity.Translate(float64(bounds.Min.X)/dpmm, float64(rect.Dy()-bounds.Max.Y)/dpmm))
+			ras.Close()
+			rects = append(rects, rect)
+		}
+	}
+	return rects
+}
+
+// subImage returns the sub-image of dst for rect, which is clipped to the image's bounds.
+func subImage(dst draw.Image, rect image.Rectangle) (draw.Image, image.Rectangle) {
+	rect = rect.Intersect(dst.Bounds())
+	if rect.Empty() || rect == dst.Bounds() {
+		return dst, rect
+	}
+	if sub, ok := dst.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		if img, ok := sub.SubImage(rect).(draw.Image); ok {
+			return img, rect
+		}
+	}
+	return windowImage{dst, rect}, rect
+}
+
+// windowImage is a view of the rectangle rect of an image that does not implement SubImage.
+type windowImage struct {
+	draw.Image
+	rect image.Rectangle
+}
+
+func (img windowImage) Bounds() image.Rectangle {
+	return img.rect
+}
+
+func (img windowImage) At(x, y int) color.Color {
+	if !(image.Point{x, y}).In(img.rect) {
+		return color.RGBA{}
+	}
+	return img.Image.At(x, y)
+}
+
+func (img windowImage) Set(x, y int, c color.Color) {
+	if (image.Point{x, y}).In(img.rect) {
+		img.Image.Set(x, y, c)
+	}
+}
+
+// drawRect draws the canvas on dst, which has bounds rect, with the bottom-left of the canvas at the bottom-left of rect.
+func drawRect(dst draw.Image, rect image.Rectangle, c *canvas.Canvas, resolution canvas.Resolution, colorSpace canvas.ColorSpace) {
+	// pixel coordinates are relative to the image's origin and the bottom-left of the canvas is at the image's height
+	dpmm := resolution.DPMM()
+	ras := FromImage(dst, resolution, colorSpace)
+	c.RenderViewTo(ras, canvas.Identity.Translate(float64(rect.Min.X)/dpmm, -float64(rect.Min.Y)/dpmm))
+	ras.Close()
+}
+
+// Drawer is a draw.Drawer that draws a canvas on top of the source image, so that canvas output can be composited into existing image pipelines, such as for thumbnails or watermarks, without intermediate images. The canvas is scaled to fit the destination rectangle while keeping its aspect ratio, and is centered within it.
+type Drawer struct {
+	Canvas     *canvas.Canvas
+	ColorSpace canvas.ColorSpace
+}
+
+// Draw draws src onto the rectangle r of dst as draw.Over does, aligning r.Min in dst with sp in src, and then draws the canvas over it. The source image may be nil to draw the canvas over the existing contents of dst.
+func (d Drawer) Draw(dst draw.Image, r image.Rectangle, src image.Image, sp image.Point) {
+	if src != nil {
+		draw.Draw(dst, r, src, sp, draw.Over)
+	}
+	r = r.Intersect(dst.Bounds())
+	if r.Empty() || d.Canvas.W <= 0.0 || d.Canvas.H <= 0.0 {
+		return
+	}
+
+	dpmm := math.Min(float64(r.Dx())/d.Canvas.W, float64(r.Dy())/d.Canvas.H)
+	dx := int((float64(r.Dx()) - d.Canvas.W*dpmm) / 2.0)
+	dy := int((float64(r.Dy()) - d.Canvas.H*dpmm) / 2.0)
+	DrawOver(dst, image.Rect(r.Min.X+dx, r.Min.Y+dy, r.Max.X-dx, r.Max.Y-dy), d.Canvas, canvas.DPMM(dpmm), d.ColorSpace)
+}
+
 // Rasterizer is a rasterizing renderer.
 type Rasterizer struct {
 	draw.Image
 	resolution canvas.Resolution
 	colorSpace canvas.ColorSpace
+	groups     []rasterizerGroup
+	atlas      *GlyphAtlas
+	snap       bool
+}
+
+// rasterizerGroup is the image that was drawn to before starting a group, and the opacity and filter of the group.
+type rasterizerGroup struct {
+	img     draw.Image
+	opacity float64
+	filter  canvas.Filter
 }
 
 // New returns a renderer that draws to a rasterized image. The final width and height of the image is the width and height (mm) multiplied by the resolution (px/mm), thus a higher resolution results in larger images. By default the linear color space is used, which assumes input and output colors are in linearRGB. If the sRGB color space is used for drawing with an average of gamma=2.2, the input and output colors are assumed to be in sRGB (a common assumption) and blending happens in linearRGB. Be aware that for text this results in thin stems for black-on-white (but wide stems for white-on-black).
@@ -48,6 +210,7 @@ func FromImage(img draw.Image, resolution canvas.Resolution, colorSpace canvas.C
 		Image:      img,
 		resolution: resolution,
 		colorSpace: colorSpace,
+		atlas:      DefaultGlyphAtlas,
 	}
 }
 
@@ -58,21 +221,118 @@ func (r *Rasterizer) Close() {
 	}
 }
 
+// Capabilities returns the constructs that the rasterizer draws itself, which are gradients including Gouraud shading, composite modes, shadows, text, and transparency. Patterns are tiled by the canvas.
+func (r *Rasterizer) Capabilities() canvas.Capabilities {
+	return canvas.Capabilities{
+		Gradients:      true,
+		GouraudShading: true,
+		BlendModes:     true,
+		Shadows:        true,
+		NativeText:     true,
+		Transparency:   true,
+	}
+}
+
 // Size returns the size of the canvas in millimeters.
 func (r *Rasterizer) Size() (float64, float64) {
 	size := r.Bounds().Size()
 	return float64(size.X) / r.resolution.DPMM(), float64(size.Y) / r.resolution.DPMM()
 }
 
-// RenderPath renders a path to the canvas using a style and a transformation matrix.
+// SetPixelSnapping sets whether polylines that are not rotated or sheared are aligned to the pixel grid, so that axis-aligned edges and thin strokes such as one pixel grid lines are drawn crisp instead of being smeared over two pixels by antialiasing. Fills are snapped to pixel boundaries, while strokes are rounded to a whole number of pixels, with a minimum of one pixel, and are snapped to pixel centers for an odd number of pixels. Paths with Béziers or arcs are not affected.
+func (r *Rasterizer) SetPixelSnapping(snap bool) {
+	r.snap = snap
+}
+
+// snapPath returns the path transformed to the canvas with its coordinates snapped to the pixel grid, and its style adjusted accordingly, see SetPixelSnapping.
+func (r *Rasterizer) snapPath(path *canvas.Path, style canvas.Style, m canvas.Matrix) (*canvas.Path, canvas.Style, canvas.Matrix) {
+	sx, sy := math.Abs(m[0][0]), math.Abs(m[1][1])
+	if m[0][1] != 0.0 || m[1][0] != 0.0 || !path.Flat() || style.HasStroke() && !canvas.Equal(sx, sy) {
+		return path, style, m
+	}
+
+	dpmm := r.resolution.DPMM()
+	offset := 0.0
+	if style.HasStroke() {
+		width := math.Max(1.0, math.Round(style.StrokeWidth*sx*dpmm))
+		if math.Mod(width, 2.0) == 1.0 {
+			offset = 0.5 // center on pixels
+		}
+		style.StrokeWidth = width / dpmm
+		if 0 < len(style.Dashes) {
+			dashes := make([]float64, len(style.Dashes))
+			for i, dash := range style.Dashes {
+				dashes[i] = dash * sx
+			}
+			style.Dashes = dashes
+			style.DashOffset *= sx
+		}
+	}
+	snap := func(p canvas.Point) canvas.Point {
+		p = m.Dot(p)
+		return canvas.Point{
+			(math.Floor(p.X*dpmm-offset+0.5) + offset) / dpmm,
+			(math.Floor(p.Y*dpmm-offset+0.5) + offset) / dpmm,
+		}
+	}
+
+	snapped := &canvas.Path{}
+	for scanner := path.Scanner(); scanner.Scan(); {
+		end := snap(scanner.End())
+		switch scanner.Cmd() {
+		case canvas.MoveToCmd:
+			snapped.MoveTo(end.X, end.Y)
+		case canvas.LineToCmd:
+			snapped.LineTo(end.X, end.Y)
+		case canvas.CloseCmd:
+			snapped.Close()
+		}
+	}
+	return snapped, style, canvas.Identity
+}
+
+// pathPool holds the temporary paths of RenderPath, avoiding allocations for every rendered path.
+var pathPool canvas.PathPool
+
+// RenderPath renders a path to the canvas using a style and a transformation matrix. Shadows are blurred at the resolution of the rasterizer.
 func (r *Rasterizer) RenderPath(path *canvas.Path, style canvas.Style, m canvas.Matrix) {
-	// TODO: use fill rule (EvenOdd, NonZero) for rasterizer
+	if style.Shadow != nil {
+		r.RenderImage(style.Shadow.Image(path, style, m, r.resolution))
+		style.Shadow = nil
+	}
+	if r.snap {
+		path, style, m = r.snapPath(path, style, m)
+	}
+
 	bounds := canvas.Rect{}
 	var fill, stroke *canvas.Path
+	var view canvas.TransformedPath // fill under m when fill is nil, transformed while rasterizing to avoid copying the path
 	if style.HasFill() {
-		fill = path.Transform(m)
+		if cache := canvas.DefaultTrapezoidCache; cache != nil && !style.Fill.IsPattern() {
+			// decompose in path coordinates so that the result is reused for other transformations
+			_, _, _, sx, sy, _ := m.Decompose()
+			if scale := math.Max(math.Abs(sx), math.Abs(sy)); 0.0 < scale {
+				trs := cache.Trapezoids(path, style.FillRule, canvas.PixelTolerance/r.resolution.DPMM()/scale)
+				fill = pathPool.Get().AppendTrapezoids(trs).TransformInPlace(m)
+			}
+		}
+		if fill != nil {
+			defer pathPool.Put(fill)
+		} else if style.FillRule == canvas.EvenOdd {
+			// the vector rasterizer fills using the non-zero fill rule, settle in canvas coordinates so that curves are only flattened by the rasterizer
+			fill = path.Transform(m).Settle(canvas.EvenOdd)
+		} else if !style.Fill.IsPattern() {
+			view = canvas.NewTransformedPath(path, m)
+		} else {
+			fill = path.CopyTo(pathPool.Get()).TransformInPlace(m)
+			defer pathPool.Put(fill)
+		}
 		if !style.HasStroke() {
-			bounds = fill.Bounds()
+			if fill != nil {
+				bounds = fill.Bounds()
+			} else {
+				bounds = view.FastBounds()
+			}
 		}
 	}
 	if style.HasStroke() {
@@ -81,8 +341,7 @@ func (r *Rasterizer) RenderPath(path *canvas.Path, style canvas.Style, m canvas.
 		if 0 < len(style.Dashes) {
 			stroke = stroke.Dash(style.DashOffset, style.Dashes...)
 		}
-		stroke = stroke.Stroke(style.StrokeWidth, style.StrokeCapper, style.StrokeJoiner, tolerance)
-		stroke = stroke.Transform(m)
+		stroke = stroke.Stroke(style.StrokeWidth, style.StrokeCapper, style.StrokeJoiner, tolerance).TransformInPlace(m)
 		bounds = stroke.Bounds()
 	}
 
@@ -127,8 +386,12 @@ func (r *Rasterizer) RenderPath(path *canvas.Path, style canvas.Style, m canvas.
 		}
 
 		ras := vector.NewRasterizer(w, h)
-		fill = fill.Translate(-float64(x)/dpmm, -float64(size.Y-y-h)/dpmm)
-		fill.ToRasterizer(ras, r.resolution)
+		if fill != nil {
+			fill.TranslateInPlace(-float64(x)/dpmm, -float64(size.Y-y-h)/dpmm)
+			fill.ToRasterizer(ras, r.resolution)
+		} else {
+			view.Translate(-float64(x)/dpmm, -float64(size.Y-y-h)/dpmm).ToRasterizer(ras, r.resolution)
+		}
 		var src image.Image
 		if style.Fill.IsColor() {
 			src = image.NewUniform(r.colorSpace.ToLinear(style.Fill.Color))
@@ -140,7 +403,7 @@ func (r *Rasterizer) RenderPath(path *canvas.Path, style canvas.Style, m canvas.
 			pattern.ClipTo(r, fill)
 		}
 		if src != nil {
-			ras.Draw(r.Image, image.Rect(x, y, x+w, y+h), src, image.Point{dx, dy})
+			r.draw(ras, image.Rect(x, y, x+w, y+h), src, image.Point{dx, dy}, style.CompositeMode)
 		}
 	}
 	if style.HasStroke() {
@@ -152,7 +415,7 @@ func (r *Rasterizer) RenderPath(path *canvas.Path, style canvas.Style, m canvas.
 		}
 
 		ras := vector.NewRasterizer(w, h)
-		stroke = stroke.Translate(-float64(x)/dpmm, -float64(size.Y-y-h)/dpmm)
+		stroke.TranslateInPlace(-float64(x)/dpmm, -float64(size.Y-y-h)/dpmm)
 		stroke.ToRasterizer(ras, r.resolution)
 		var src image.Image
 		if style.Stroke.IsColor() {
@@ -165,13 +428,47 @@ func (r *Rasterizer) RenderPath(path *canvas.Path, style canvas.Style, m canvas.
 			pattern.ClipTo(r, stroke)
 		}
 		if src != nil {
-			ras.Draw(r.Image, image.Rect(x, y, x+w, y+h), src, image.Point{dx, dy})
+			r.draw(ras, image.Rect(x, y, x+w, y+h), src, image.Point{dx, dy}, style.CompositeMode)
+		}
+	}
+}
+
+// draw draws the source masked by the coverage of the rasterizer onto the rectangle of the image using the composite mode, where sp in the source is aligned with the top-left of the rectangle.
+func (r *Rasterizer) draw(ras *vector.Rasterizer, rect image.Rectangle, src image.Image, sp image.Point, mode canvas.CompositeMode) {
+	if mode == canvas.CompositeSrcOver {
+		ras.Draw(r.Image, rect, src, sp)
+		return
+	}
+
+	mask := image.NewAlpha(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	ras.Draw(mask, mask.Bounds(), image.Opaque, image.Point{})
+	for j := 0; j < rect.Dy(); j++ {
+		for i := 0; i < rect.Dx(); i++ {
+			coverage := uint32(mask.AlphaAt(i, j).A)
+			if coverage == 0 {
+				continue
+			}
+			x, y := rect.Min.X+i, rect.Min.Y+j
+			srcCol := color.RGBAModel.Convert(src.At(sp.X+i, sp.Y+j)).(color.RGBA)
+			dstCol := color.RGBAModel.Convert(r.Image.At(x, y)).(color.RGBA)
+			col := mode.Composite(srcCol, dstCol)
+			if coverage != 255 {
+				// interpolate between the backdrop and the result for partial coverage at the edges
+				mix := func(a, b uint8) uint8 {
+					return uint8((uint32(a)*(255-coverage) + uint32(b)*coverage + 127) / 255)
+				}
+				col = color.RGBA{mix(dstCol.R, col.R), mix(dstCol.G, col.G), mix(dstCol.B, col.B), mix(dstCol.A, col.A)}
+			}
+			r.Image.Set(x, y, col)
 		}
 	}
 }
 
-// RenderText renders a text object to the canvas using a transformation matrix.
+// RenderText renders a text object to the canvas using a transformation matrix. If a glyph atlas is set, glyphs are blitted from the atlas for horizontal text that is only scaled and translated, and are otherwise rendered as paths.
 func (r *Rasterizer) RenderText(text *canvas.Text, m canvas.Matrix) {
+	if r.renderTextAtlas(text, m) {
+		return
+	}
 	text.RenderAsPath(r, m, r.resolution)
 }
 
@@ -198,5 +495,121 @@ func (r *Rasterizer) RenderImage(img image.Image, m canvas.Matrix) {
 
 	h := float64(r.Bounds().Size().Y)
 	aff3 := f64.Aff3{m[0][0], -m[0][1], origin.X, -m[1][0], m[1][1], h - origin.Y}
-	draw.CatmullRom.Transform(r, aff3, img2, img2.Bounds(), draw.Over, nil)
+	imageInterpolator(canvas.ImageFilterOf(img)).Transform(r, aff3, img2, img2.Bounds(), draw.Over, nil)
+}
+
+// RenderImageQuad renders an image to the canvas mapped onto a quadrilateral using projective interpolation.
+func (r *Rasterizer) RenderImageQuad(img image.Image, q [4]canvas.Point) {
+	// quadrilateral in pixel coordinates
+	dpmm := r.resolution.DPMM()
+	h := float64(r.Bounds().Size().Y)
+	rect := canvas.Rect{}
+	for i := range q {
+		q[i] = canvas.Point{q[i].X * dpmm, h - q[i].Y*dpmm}
+		if i == 0 {
+			rect = canvas.Rect{q[i].X, q[i].Y, 0.0, 0.0}
+		} else {
+			rect = rect.AddPoint(q[i])
+		}
+	}
+	bounds := image.Rect(int(math.Floor(rect.X)), int(math.Floor(rect.Y)), int(math.Ceil(rect.X+rect.W)), int(math.Ceil(rect.Y+rect.H))).Intersect(r.Bounds())
+	if bounds.Empty() || canvas.IsDegenerateQuad(q) {
+		return
+	}
+
+	// coverage of the quadrilateral for smooth borders
+	ras := vector.NewRasterizer(bounds.Dx(), bounds.Dy())
+	ras.MoveTo(float32(q[0].X)-float32(bounds.Min.X), float32(q[0].Y)-float32(bounds.Min.Y))
+	for _, p := range q[1:] {
+		ras.LineTo(float32(p.X)-float32(bounds.Min.X), float32(p.Y)-float32(bounds.Min.Y))
+	}
+	ras.ClosePath()
+	mask := image.NewAlpha(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	ras.Draw(mask, mask.Bounds(), image.Opaque, image.Point{})
+
+	size := img.Bounds().Size()
+	src := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+	draw.Draw(src, src.Bounds(), img, img.Bounds().Min, draw.Src)
+	if _, ok := r.colorSpace.(canvas.LinearColorSpace); !ok {
+		// gamma decompress
+		changeColorSpace(src, src, r.colorSpace.ToLinear)
+	}
+
+	// map each destination pixel back onto the image
+	inv := canvas.SquareToQuad(q).Inv()
+	nearest := canvas.ImageFilterOf(img) == canvas.NearestNeighbor
+	dst := image.NewRGBA(mask.Bounds())
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			if mask.AlphaAt(x, y).A == 0 {
+				continue
+			}
+			uv := inv.Dot(canvas.Point{float64(bounds.Min.X+x) + 0.5, float64(bounds.Min.Y+y) + 0.5})
+			sx, sy := uv.X*float64(size.X), uv.Y*float64(size.Y)
+			if nearest {
+				dst.SetRGBA(x, y, nearestRGBA(src, sx, sy))
+			} else {
+				dst.SetRGBA(x, y, bilinearRGBA(src, sx, sy))
+			}
+		}
+	}
+	draw.DrawMask(r, bounds, dst, image.Point{}, mask, image.Point{}, draw.Over)
+}
+
+// BeginGroup starts a group of drawing operations that is composited with the given opacity, by drawing to an intermediate image.
+func (r *Rasterizer) BeginGroup(opacity float64) {
+	r.groups = append(r.groups, rasterizerGroup{r.Image, opacity, nil})
+	r.Image = image.NewRGBA(r.Bounds())
+}
+
+// EndGroup ends the last group and composites it.
+func (r *Rasterizer) EndGroup() {
+	if len(r.groups) == 0 {
+		return
+	}
+	group := r.groups[len(r.groups)-1]
+	r.groups = r.groups[:len(r.groups)-1]
+
+	img := r.Image
+	r.Image = group.img
+	if group.filter != nil {
+		if rgba, ok := img.(*image.RGBA); ok {
+			img = group.filter.Apply(rgba, r.resolution)
+		}
+	}
+	mask := image.NewUniform(color.Alpha{uint8(math.Max(0.0, math.Min(1.0, group.opacity))*255.0 + 0.5)})
+	draw.DrawMask(r, r.Bounds(), img, img.Bounds().Min, mask, image.Point{}, draw.Over)
+}
+
+// BeginFilter starts a group of drawing operations to which the filter is applied, by drawing to an intermediate image.
+func (r *Rasterizer) BeginFilter(filter canvas.Filter) {
+	r.groups = append(r.groups, rasterizerGroup{r.Image, 1.0, filter})
+	r.Image = image.NewRGBA(r.Bounds())
+}
+
+// EndFilter ends the last group started with BeginFilter, and applies the filter to the intermediate image before compositing it.
+func (r *Rasterizer) EndFilter() {
+	r.EndGroup()
+}
+
+// lanczos is a Lanczos resampling kernel with a support of three.
+var lanczos = &draw.Kernel{Support: 3.0, At: func(t float64) float64 {
+	if t == 0.0 {
+		return 1.0
+	} else if 3.0 <= t {
+		return 0.0
+	}
+	return 3.0 * math.Sin(math.Pi*t) * math.Sin(math.Pi*t/3.0) / (math.Pi * math.Pi * t * t)
+}}
+
+func imageInterpolator(filter canvas.ImageFilter) draw.Interpolator {
+	switch filter {
+	case canvas.NearestNeighbor:
+		return draw.NearestNeighbor
+	case canvas.Bilinear:
+		return draw.BiLinear
+	case canvas.Lanczos:
+		return lanczos
+	}
+	return draw.CatmullRom
 }
@@ -0,0 +1,299 @@
+package rasterizer
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"testing"
+
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/test"
+)
+
+func TestRenderPathFillRule(t *testing.T) {
+	path := canvas.Circle(3.0).Append(canvas.Circle(1.5))
+	style := canvas.DefaultStyle
+	style.FillRule = canvas.EvenOdd
+	r := New(10.0, 10.0, canvas.DPMM(4.0), canvas.LinearColorSpace{})
+	r.RenderPath(path, style, canvas.Identity.Translate(5.0, 5.0))
+	img := r.Image.(*image.RGBA)
+	test.T(t, img.RGBAAt(20, 20), color.RGBA{}) // hole
+	test.T(t, img.RGBAAt(20, 30), color.RGBA{0, 0, 0, 255})
+
+	style.FillRule = canvas.NonZero
+	r = New(10.0, 10.0, canvas.DPMM(4.0), canvas.LinearColorSpace{})
+	r.RenderPath(path, style, canvas.Identity.Translate(5.0, 5.0))
+	img = r.Image.(*image.RGBA)
+	test.T(t, img.RGBAAt(20, 20), color.RGBA{0, 0, 0, 255})
+
+	// zoomed curves are as smooth for both fill rules
+	render := func(fillRule canvas.FillRule) *image.RGBA {
+		style.FillRule = fillRule
+		r := New(10.0, 10.0, canvas.DPMM(4.0), canvas.LinearColorSpace{})
+		r.RenderPath(canvas.Circle(0.04), style, canvas.Identity.Translate(5.0, 5.0).Scale(100.0, 100.0))
+		return r.Image.(*image.RGBA)
+	}
+	want, have := render(canvas.NonZero), render(canvas.EvenOdd)
+	for i := range want.Pix {
+		if d := int(have.Pix[i]) - int(want.Pix[i]); d < -2 || 2 < d {
+			test.Fail(t, "pixel differs at", i%want.Stride/4, i/want.Stride)
+			break
+		}
+	}
+}
+
+func TestRenderPathTrapezoidCache(t *testing.T) {
+	path := canvas.Circle(3.0).Append(canvas.Circle(1.5))
+	style := canvas.DefaultStyle
+	style.FillRule = canvas.EvenOdd
+	render := func() *image.RGBA {
+		r := New(20.0, 10.0, canvas.DPMM(4.0), canvas.LinearColorSpace{})
+		r.RenderPath(path, style, canvas.Identity.Translate(5.0, 5.0))
+		r.RenderPath(path, style, canvas.Identity.Translate(14.5, 5.25).Rotate(30.0))
+		return r.Image.(*image.RGBA)
+	}
+	want := render()
+
+	canvas.DefaultTrapezoidCache = canvas.NewTrapezoidCache(16)
+	defer func() { canvas.DefaultTrapezoidCache = nil }()
+	have := render()
+	test.T(t, canvas.DefaultTrapezoidCache.Len(), 1) // both transformations share the decomposition
+	test.T(t, have.Bounds(), want.Bounds())
+	for i := range want.Pix {
+		// both are flattened within PixelTolerance, but at other points since uncached fills are settled with their curves
+		if d := float64(int(have.Pix[i]) - int(want.Pix[i])); 2.0*canvas.PixelTolerance*255.0 < math.Abs(d) {
+			test.Fail(t, "pixel differs at", i%want.Stride/4, i/want.Stride)
+			break
+		}
+	}
+	test.T(t, have.RGBAAt(20, 20), color.RGBA{}) // hole
+	test.T(t, have.RGBAAt(20, 30), color.RGBA{0, 0, 0, 255})
+}
+
+func TestRenderPathTransformed(t *testing.T) {
+	path := canvas.Circle(3.0).Append(canvas.Rectangle(4.0, 2.0))
+	m := canvas.Identity.Translate(10.0, 5.0).Rotate(30.0).Scale(1.5, 1.0)
+	render := func(path *canvas.Path, m canvas.Matrix) *image.RGBA {
+		r := New(20.0, 10.0, canvas.DPMM(4.0), canvas.LinearColorSpace{})
+		r.RenderPath(path, canvas.DefaultStyle, m)
+		return r.Image.(*image.RGBA)
+	}
+	want, have := render(path.Transform(m), canvas.Identity), render(path, m)
+	for i := range want.Pix {
+		// the path is flattened in its own coordinates, but within PixelTolerance
+		if d := float64(int(have.Pix[i]) - int(want.Pix[i])); 2.0*canvas.PixelTolerance*255.0 < math.Abs(d) {
+			test.Fail(t, "pixel differs at", i%want.Stride/4, i/want.Stride)
+			break
+		}
+	}
+	test.T(t, path, canvas.Circle(3.0).Append(canvas.Rectangle(4.0, 2.0))) // path is untouched
+}
+
+func TestPixelSnapping(t *testing.T) {
+	resolution := canvas.DPI(150.0) // non-integer number of pixels per millimeter
+	dpmm := resolution.DPMM()
+	r := New(20.0, 20.0, resolution, canvas.LinearColorSpace{})
+	r.SetPixelSnapping(true)
+	onGrid := func(x float64) bool {
+		return math.Abs(x-math.Round(x)) < 1e-9
+	}
+
+	// fills are snapped to pixel boundaries
+	rect := canvas.Rectangle(3.3, 4.4)
+	path, _, m := r.snapPath(rect, canvas.DefaultStyle, canvas.Identity.Translate(1.03, 2.17))
+	test.T(t, m, canvas.Identity)
+	for _, p := range path.Coords() {
+		test.That(t, onGrid(p.X*dpmm) && onGrid(p.Y*dpmm), "must be on pixel boundary:", p.X*dpmm, p.Y*dpmm)
+	}
+
+	// odd stroke widths are snapped to pixel centers
+	style := canvas.DefaultStyle
+	style.Fill = canvas.Paint{}
+	style.Stroke = canvas.Paint{Color: canvas.Black}
+	style.StrokeWidth = 0.2
+	path, style, _ = r.snapPath(canvas.Line(15.0, 0.0), style, canvas.Identity.Translate(2.0, 3.1))
+	test.Float(t, style.StrokeWidth*dpmm, 1.0)
+	for _, p := range path.Coords() {
+		test.That(t, onGrid(p.X*dpmm-0.5) && onGrid(p.Y*dpmm-0.5), "must be on pixel center:", p.X*dpmm, p.Y*dpmm)
+	}
+
+	// rotated paths are not snapped
+	_, _, m = r.snapPath(rect, canvas.DefaultStyle, canvas.Identity.Rotate(30.0))
+	test.T(t, m, canvas.Identity.Rotate(30.0))
+
+	// hairlines cover exactly one row of pixels
+	style.StrokeWidth = 0.2
+	r.RenderPath(canvas.Line(15.0, 0.0), style, canvas.Identity.Translate(2.0, 3.1))
+	img := r.Image.(*image.RGBA)
+	rows := 0
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		if a := img.RGBAAt(50, y).A; a != 0 {
+			test.T(t, a, uint8(255))
+			rows++
+		}
+	}
+	test.T(t, rows, 1)
+}
+
+func TestRenderImageQuad(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	draw.Draw(img, img.Bounds(), image.NewUniform(canvas.Red), image.Point{}, draw.Src)
+
+	r := New(10.0, 10.0, canvas.DPMM(1.0), canvas.LinearColorSpace{})
+	r.RenderImageQuad(img, [4]canvas.Point{{1.0, 1.0}, {9.0, 1.0}, {7.0, 9.0}, {3.0, 9.0}})
+	dst := r.Image.(*image.RGBA)
+	test.T(t, dst.RGBAAt(5, 5), canvas.Red)
+	test.T(t, dst.RGBAAt(0, 0), color.RGBA{})
+
+	// degenerate quadrilaterals draw nothing
+	r = New(10.0, 10.0, canvas.DPMM(1.0), canvas.LinearColorSpace{})
+	r.RenderImageQuad(img, [4]canvas.Point{{0.0, 0.0}, {1.0, 1.0}, {2.0, 2.0}, {1.0, 1.0}})
+	r.RenderImageQuad(img, [4]canvas.Point{{1.0, 1.0}, {5.0, 1.0}, {9.0, 1.0}, {5.0, 9.0}})
+	test.T(t, r.Image.(*image.RGBA).RGBAAt(1, 9), color.RGBA{})
+	test.T(t, r.Image.(*image.RGBA).RGBAAt(5, 5), color.RGBA{})
+}
+
+func TestDrawInto(t *testing.T) {
+	c := canvas.New(10.0, 10.0)
+	ctx := canvas.NewContext(c)
+	ctx.SetFillColor(canvas.Red)
+	ctx.DrawPath(0.0, 0.0, canvas.Rectangle(5.0, 5.0))
+
+	for _, colorSpace := range []canvas.ColorSpace{canvas.LinearColorSpace{}, canvas.SRGBColorSpace{}} {
+		img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+		draw.Draw(img, img.Bounds(), image.NewUniform(canvas.Blue), image.Point{}, draw.Src)
+		DrawInto(img, c, canvas.DPMM(1.0), colorSpace)
+		test.T(t, img.RGBAAt(2, 7), canvas.Red)
+		test.T(t, img.RGBAAt(7, 2), color.RGBA{}) // cleared
+
+		// rectangle with a non-zero origin
+		img = image.NewRGBA(image.Rect(0, 0, 30, 30))
+		draw.Draw(img, img.Bounds(), image.NewUniform(canvas.Blue), image.Point{}, draw.Src)
+		DrawIntoRect(img, image.Rect(10, 10, 20, 20), c, canvas.DPMM(1.0), colorSpace)
+		test.T(t, img.RGBAAt(12, 17), canvas.Red)
+		test.T(t, img.RGBAAt(17, 12), color.RGBA{})
+		test.T(t, img.RGBAAt(5, 5), canvas.Blue)
+		test.T(t, img.RGBAAt(25, 15), canvas.Blue)
+		test.T(t, img.RGBAAt(15, 25), canvas.Blue)
+
+		// images that do not implement SubImage
+		draw.Draw(img, img.Bounds(), image.NewUniform(canvas.Blue), image.Point{}, draw.Src)
+		DrawIntoRect(struct{ draw.Image }{img}, image.Rect(10, 10, 20, 20), c, canvas.DPMM(1.0), colorSpace)
+		test.T(t, img.RGBAAt(12, 17), canvas.Red)
+		test.T(t, img.RGBAAt(17, 12), color.RGBA{})
+		test.T(t, img.RGBAAt(5, 5), canvas.Blue)
+		test.T(t, img.RGBAAt(25, 15), canvas.Blue)
+	}
+}
+
+func TestDrawChanges(t *testing.T) {
+	frame := func(x float64) *canvas.Canvas {
+		c := canvas.New(200.0, 10.0)
+		ctx := canvas.NewContext(c)
+		ctx.SetFillColor(canvas.Red)
+		ctx.DrawPath(x, 0.0, canvas.Rectangle(5.0, 5.0))
+		return c
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 200, 10))
+	draw.Draw(img, img.Bounds(), image.NewUniform(canvas.Blue), image.Point{}, draw.Src)
+	prev := frame(0.0)
+	test.T(t, DrawChanges(img, prev, nil, canvas.DPMM(1.0), canvas.LinearColorSpace{}), []image.Rectangle{img.Bounds()})
+	test.T(t, img.RGBAAt(2, 7), canvas.Red)
+	test.T(t, img.RGBAAt(150, 2), color.RGBA{}) // cleared
+
+	draw.Draw(img, image.Rect(150, 0, 200, 10), image.NewUniform(canvas.Blue), image.Point{}, draw.Src)
+	test.T(t, DrawChanges(img, frame(10.0), prev, canvas.DPMM(1.0), canvas.LinearColorSpace{}), []image.Rectangle{image.Rect(0, 0, 64, 10)})
+	test.T(t, img.RGBAAt(2, 7), color.RGBA{})
+	test.T(t, img.RGBAAt(12, 7), canvas.Red)
+	test.T(t, img.RGBAAt(150, 2), canvas.Blue) // untouched
+}
+
+func TestDrawOver(t *testing.T) {
+	c := canvas.New(10.0, 10.0)
+	ctx := canvas.NewContext(c)
+	ctx.SetFillColor(color.RGBA{128, 0, 0, 128})
+	ctx.DrawPath(0.0, 0.0, canvas.Rectangle(5.0, 5.0))
+
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	draw.Draw(img, img.Bounds(), image.NewUniform(canvas.Blue), image.Point{}, draw.Src)
+	DrawOver(img, image.Rect(10, 10, 20, 20), c, canvas.DPMM(1.0), canvas.LinearColorSpace{})
+	test.T(t, img.RGBAAt(12, 17), color.RGBA{128, 0, 127, 255})
+	test.T(t, img.RGBAAt(17, 12), canvas.Blue)
+	test.T(t, img.RGBAAt(5, 5), canvas.Blue)
+
+	// existing contents are kept in other color spaces
+	c = canvas.New(10.0, 10.0)
+	ctx = canvas.NewContext(c)
+	ctx.SetFillColor(canvas.Red)
+	ctx.DrawPath(0.0, 0.0, canvas.Rectangle(5.0, 5.0))
+	draw.Draw(img, img.Bounds(), image.NewUniform(canvas.Blue), image.Point{}, draw.Src)
+	DrawOver(img, image.Rect(10, 10, 20, 20), c, canvas.DPMM(1.0), canvas.SRGBColorSpace{})
+	test.T(t, img.RGBAAt(12, 17), canvas.Red)
+	test.T(t, img.RGBAAt(17, 12), canvas.Blue)
+	test.T(t, img.RGBAAt(5, 5), canvas.Blue)
+}
+
+func TestDrawer(t *testing.T) {
+	c := canvas.New(10.0, 10.0)
+	ctx := canvas.NewContext(c)
+	ctx.SetFillColor(canvas.Red)
+	ctx.DrawPath(0.0, 0.0, canvas.Rectangle(5.0, 5.0))
+
+	// the canvas is fitted and centered on top of the source
+	img := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	var drawer draw.Drawer = Drawer{Canvas: c, ColorSpace: canvas.LinearColorSpace{}}
+	drawer.Draw(img, img.Bounds(), image.NewUniform(canvas.Green), image.Point{})
+	test.T(t, img.RGBAAt(6, 8), canvas.Red)
+	test.T(t, img.RGBAAt(12, 2), canvas.Green)
+	test.T(t, img.RGBAAt(2, 8), canvas.Green)
+	test.T(t, img.RGBAAt(17, 8), canvas.Green)
+
+	// without a source the canvas is drawn over the existing contents
+	drawer.Draw(img, image.Rect(0, 0, 10, 10), nil, image.Point{})
+	test.T(t, img.RGBAAt(2, 8), canvas.Red)
+	test.T(t, img.RGBAAt(6, 8), canvas.Red)
+	test.T(t, img.RGBAAt(7, 2), canvas.Green)
+}
+
+type span struct {
+	y, x0, x1 int
+	coverage  float64
+}
+
+func collectSpans(p *canvas.Path, fillRule canvas.FillRule) []span {
+	spans := []span{}
+	Spans(p, fillRule, canvas.DPMM(1.0), func(y, x0, x1 int, coverage float64) {
+		spans = append(spans, span{y, x0, x1, coverage})
+	})
+	return spans
+}
+
+func TestSpans(t *testing.T) {
+	rect := canvas.Rectangle(4.0, 3.0).Translate(1.0, 2.0)
+	test.T(t, collectSpans(rect, canvas.NonZero), []span{
+		{2, 1, 5, 1.0},
+		{3, 1, 5, 1.0},
+		{4, 1, 5, 1.0},
+	})
+
+	// inner square has the same orientation as the outer square
+	hole := canvas.Rectangle(4.0, 4.0).Append(canvas.Rectangle(2.0, 2.0).Translate(1.0, 1.0))
+	test.T(t, collectSpans(hole, canvas.NonZero), []span{
+		{0, 0, 4, 1.0},
+		{1, 0, 4, 1.0},
+		{2, 0, 4, 1.0},
+		{3, 0, 4, 1.0},
+	})
+	test.T(t, collectSpans(hole, canvas.EvenOdd), []span{
+		{0, 0, 4, 1.0},
+		{1, 0, 1, 1.0},
+		{1, 3, 4, 1.0},
+		{2, 0, 1, 1.0},
+		{2, 3, 4, 1.0},
+		{3, 0, 4, 1.0},
+	})
+
+	test.T(t, len(collectSpans(&canvas.Path{}, canvas.NonZero)), 0)
+}
@@ -0,0 +1,55 @@
+package rasterizer
+
+import (
+	"image"
+	"math"
+
+	"github.com/tdewolff/canvas"
+	"golang.org/x/image/vector"
+)
+
+// SpanFunc receives a horizontal run of pixels [x0,x1) on row y that all have the same coverage in (0,1].
+type SpanFunc func(y, x0, x1 int, coverage float64)
+
+// Spans rasterizes the path at the given resolution and passes the resulting scanline spans to fn, without compositing onto an image. Pixel (x,y) covers the area [x,x+1)×[y,y+1) in units of pixels from the origin, with y pointing upwards as in canvas coordinates. Rows are passed in increasing y and spans in increasing x, and pixels with zero coverage are skipped. The path is filled using the given fill rule. This allows custom compositing, building hit masks, or exporting coverage to other systems.
+func Spans(p *canvas.Path, fillRule canvas.FillRule, resolution canvas.Resolution, fn SpanFunc) {
+	if p.Empty() {
+		return
+	}
+
+	// the vector rasterizer accumulates absolute winding, resolve the fill rule beforehand
+	p = p.Settle(fillRule)
+	if p.Empty() {
+		return
+	}
+
+	dpmm := resolution.DPMM()
+	bounds := p.FastBounds()
+	x0, y0 := int(math.Floor(bounds.X*dpmm)), int(math.Floor(bounds.Y*dpmm))
+	x1, y1 := int(math.Ceil((bounds.X+bounds.W)*dpmm)), int(math.Ceil((bounds.Y+bounds.H)*dpmm))
+	w, h := x1-x0, y1-y0
+	if w <= 0 || h <= 0 {
+		return
+	}
+
+	ras := vector.NewRasterizer(w, h)
+	p.Translate(-float64(x0)/dpmm, -float64(y0)/dpmm).ToRasterizer(ras, resolution)
+	mask := image.NewAlpha(image.Rect(0, 0, w, h))
+	ras.Draw(mask, mask.Bounds(), image.Opaque, image.Point{})
+
+	// image rows go downwards, so the last row is the lowest
+	for j := h - 1; 0 <= j; j-- {
+		row := mask.Pix[j*mask.Stride : j*mask.Stride+w]
+		for i := 0; i < w; {
+			a := row[i]
+			k := i + 1
+			for k < w && row[k] == a {
+				k++
+			}
+			if a != 0 {
+				fn(y0+h-1-j, x0+i, x0+k, float64(a)/255.0)
+			}
+			i = k
+		}
+	}
+}
@@ -3,6 +3,7 @@ package rasterizer
 import (
 	"image"
 	"image/color"
+	"math"
 
 	"github.com/tdewolff/canvas"
 	"golang.org/x/image/draw"
@@ -12,15 +13,17 @@ type colorFunc func(color.Color) color.RGBA
 
 func changeColorSpace(dst draw.Image, src image.Image, f colorFunc) {
 	if dstRGBA, ok := dst.(*image.RGBA); ok {
-		for j := 0; j < dst.Bounds().Max.Y; j++ {
-			for i := 0; i < dst.Bounds().Max.X; i++ {
+		bounds := dst.Bounds()
+		for j := bounds.Min.Y; j < bounds.Max.Y; j++ {
+			for i := bounds.Min.X; i < bounds.Max.X; i++ {
 				// TODO: parallelize
 				dstRGBA.SetRGBA(i, j, f(src.At(i, j)))
 			}
 		}
 	} else {
-		for j := 0; j < dst.Bounds().Max.Y; j++ {
-			for i := 0; i < dst.Bounds().Max.X; i++ {
+		bounds := dst.Bounds()
+		for j := bounds.Min.Y; j < bounds.Max.Y; j++ {
+			for i := bounds.Min.X; i < bounds.Max.X; i++ {
 				// TODO: parallelize
 				dst.Set(i, j, f(src.At(i, j)))
 			}
@@ -28,6 +31,39 @@ func changeColorSpace(dst draw.Image, src image.Image, f colorFunc) {
 	}
 }
 
+// nearestRGBA samples the image at (x,y) using nearest-neighbor interpolation, clamping to the image's borders.
+func nearestRGBA(img *image.RGBA, x, y float64) color.RGBA {
+	size := img.Bounds().Size()
+	i := max(0, min(size.X-1, int(math.Floor(x))))
+	j := max(0, min(size.Y-1, int(math.Floor(y))))
+	return img.RGBAAt(i, j)
+}
+
+// bilinearRGBA samples the image at (x,y) using bilinear interpolation between pixel centers, clamping to the image's borders.
+func bilinearRGBA(img *image.RGBA, x, y float64) color.RGBA {
+	size := img.Bounds().Size()
+	x, y = x-0.5, y-0.5
+	x0, y0 := math.Floor(x), math.Floor(y)
+	tx, ty := x-x0, y-y0
+	i0 := max(0, min(size.X-1, int(x0)))
+	i1 := max(0, min(size.X-1, int(x0)+1))
+	j0 := max(0, min(size.Y-1, int(y0)))
+	j1 := max(0, min(size.Y-1, int(y0)+1))
+
+	c00, c10 := img.RGBAAt(i0, j0), img.RGBAAt(i1, j0)
+	c01, c11 := img.RGBAAt(i0, j1), img.RGBAAt(i1, j1)
+	lerp := func(a, b, c, d uint8) uint8 {
+		v := (1.0-ty)*((1.0-tx)*float64(a)+tx*float64(b)) + ty*((1.0-tx)*float64(c)+tx*float64(d))
+		return uint8(v + 0.5)
+	}
+	return color.RGBA{
+		lerp(c00.R, c10.R, c01.R, c11.R),
+		lerp(c00.G, c10.G, c01.G, c11.G),
+		lerp(c00.B, c10.B, c01.B, c11.B),
+		lerp(c00.A, c10.A, c01.A, c11.A),
+	}
+}
+
 type GradientImage struct {
 	g        canvas.Gradient
 	zp, size image.Point
@@ -0,0 +1,151 @@
+package remote
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/tdewolff/canvas"
+	"golang.org/x/net/websocket"
+)
+
+// MaxFrameSize is the maximum size in bytes of a frame that is read, larger frames return ErrFrameSize.
+var MaxFrameSize = 1 << 28
+
+// ErrFrameSize is returned when reading a frame that is larger than MaxFrameSize.
+var ErrFrameSize = errors.New("frame too large")
+
+// Sender is a renderer that streams drawing operations over a connection to a remote viewer, such as a network connection or WebSocket. Drawing operations are recorded until Flush is called, which sends them as a frame that replaces the previous frame at the viewer. Each frame is a canvas recording (see canvas.Canvas.MarshalBinary) preceded by its length as a 4-byte big-endian integer. Frames are in the binary recording format, or in JSON when JSON is set, which is easier to consume in browsers without WebAssembly.
+type Sender struct {
+	*canvas.Canvas
+	JSON bool
+
+	w  io.Writer
+	mu sync.Mutex
+}
+
+// NewSender returns a renderer of the given size in millimeters that sends frames to the writer.
+func NewSender(w io.Writer, width, height float64) *Sender {
+	return &Sender{
+		Canvas: canvas.New(width, height),
+		w:      w,
+	}
+}
+
+// Flush sends the drawing operations since the last flush as a frame and resets the canvas for the next frame.
+func (s *Sender) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var b []byte
+	var err error
+	if s.JSON {
+		b, err = s.Canvas.MarshalJSON()
+	} else {
+		b, err = s.Canvas.MarshalBinary()
+	}
+	if err != nil {
+		return err
+	}
+	s.Canvas.Reset()
+	return writeFrame(s.w, b)
+}
+
+// Receiver reads frames sent by a Sender, see Sender.
+type Receiver struct {
+	r io.Reader
+}
+
+// NewReceiver returns a receiver that reads frames from the reader.
+func NewReceiver(r io.Reader) *Receiver {
+	return &Receiver{r}
+}
+
+// Next blocks until the next frame is received and returns it as a canvas, which can be rendered to any renderer such as a window or an HTML canvas. It returns io.EOF when the connection is closed between frames.
+func (r *Receiver) Next() (*canvas.Canvas, error) {
+	b, err := readFrame(r.r)
+	if err != nil {
+		return nil, err
+	}
+
+	c := canvas.New(0.0, 0.0)
+	if 0 < len(b) && b[0] == '{' {
+		err = c.UnmarshalJSON(b)
+	} else {
+		err = c.UnmarshalBinary(b)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Event is an input event that a viewer sends back to the sender, such as a mouse click or key press, so that the sender can respond to user interaction. Positions are in millimeters in the canvas' coordinate system.
+type Event struct {
+	Type   string  `json:"type"` // for example mousedown, mouseup, mousemove, keydown, keyup, or resize
+	X      float64 `json:"x,omitempty"`
+	Y      float64 `json:"y,omitempty"`
+	Button int     `json:"button,omitempty"`
+	Key    string  `json:"key,omitempty"`
+}
+
+// WriteEvent sends an event in JSON as a frame, see Sender.
+func WriteEvent(w io.Writer, event Event) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return writeFrame(w, b)
+}
+
+// ReadEvent blocks until the next event is received.
+func ReadEvent(r io.Reader) (Event, error) {
+	b, err := readFrame(r)
+	if err != nil {
+		return Event{}, err
+	}
+	event := Event{}
+	if err := json.Unmarshal(b, &event); err != nil {
+		return Event{}, err
+	}
+	return event, nil
+}
+
+// WebSocketHandler returns an HTTP handler that accepts WebSocket connections and calls f with a sender of the given size in millimeters for each connection, where the connection is closed when f returns. Frames are sent as binary WebSocket messages, and events sent by the viewer can be read from the connection using ReadEvent.
+func WebSocketHandler(width, height float64, f func(*Sender, io.Reader)) http.Handler {
+	return websocket.Handler(func(ws *websocket.Conn) {
+		ws.PayloadType = websocket.BinaryFrame
+		f(NewSender(ws, width, height), ws)
+	})
+}
+
+func writeFrame(w io.Writer, b []byte) error {
+	// write the frame at once so that each frame is a single WebSocket message
+	frame := bytes.NewBuffer(make([]byte, 0, 4+len(b)))
+	binary.Write(frame, binary.BigEndian, uint32(len(b)))
+	frame.Write(b)
+	_, err := w.Write(frame.Bytes())
+	return err
+}
+
+func readFrame(r io.Reader) ([]byte, error) {
+	var n uint32
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return nil, err
+	} else if MaxFrameSize < int(n) {
+		return nil, fmt.Errorf("%w: %d bytes", ErrFrameSize, n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return b, nil
+}
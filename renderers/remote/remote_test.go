@@ -0,0 +1,47 @@
+package remote
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/test"
+)
+
+func TestRemote(t *testing.T) {
+	buf := &bytes.Buffer{}
+	s := NewSender(buf, 20.0, 10.0)
+	ctx := canvas.NewContext(s)
+	ctx.DrawPath(0.0, 0.0, canvas.Rectangle(5.0, 5.0))
+	test.Error(t, s.Flush())
+	test.That(t, s.Empty(), "canvas must be reset after flush")
+
+	s.JSON = true
+	ctx.DrawPath(0.0, 0.0, canvas.Circle(2.0))
+	test.Error(t, s.Flush())
+
+	r := NewReceiver(buf)
+	for i := 0; i < 2; i++ {
+		c, err := r.Next()
+		test.Error(t, err)
+		test.T(t, c.W, 20.0)
+		test.T(t, c.H, 10.0)
+		test.That(t, !c.Empty(), "frame must not be empty")
+	}
+	_, err := r.Next()
+	test.T(t, err, io.EOF)
+}
+
+func TestRemoteEvent(t *testing.T) {
+	buf := &bytes.Buffer{}
+	test.Error(t, WriteEvent(buf, Event{Type: "mousedown", X: 1.0, Y: 2.0}))
+	event, err := ReadEvent(buf)
+	test.Error(t, err)
+	test.T(t, event, Event{Type: "mousedown", X: 1.0, Y: 2.0})
+
+	buf.Write([]byte{0xff, 0xff, 0xff, 0xff})
+	_, err = ReadEvent(buf)
+	test.That(t, errors.Is(err, ErrFrameSize), "must return ErrFrameSize")
+}
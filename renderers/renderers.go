@@ -1,14 +1,18 @@
 package renderers
 
 import (
+	"bytes"
 	"compress/flate"
+	"encoding/binary"
 	"fmt"
+	"hash/crc32"
 	"image/gif"
 	"image/jpeg"
 	"image/png"
 	"io"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/tdewolff/canvas"
 	"github.com/tdewolff/canvas/renderers/pdf"
@@ -16,6 +20,7 @@ import (
 	"github.com/tdewolff/canvas/renderers/rasterizer"
 	"github.com/tdewolff/canvas/renderers/svg"
 	"github.com/tdewolff/canvas/renderers/tex"
+	"github.com/tdewolff/canvas/renderers/typst"
 	"golang.org/x/image/bmp"
 	"golang.org/x/image/tiff"
 )
@@ -24,7 +29,7 @@ const mmPerPt = 25.4 / 72.0
 const ptPerMm = 72.0 / 25.4
 const mmPerPx = 25.4 / 96.0
 
-// Write renders the canvas and writes to a file. A renderer is chosen based on the filename extension. The options will be passed to the respective renderer. Supported extensions: .(png|jpe?g|gif|tiff?|bmp|webp|avif|svgz?|pdf|tex|pgf|ps|eps).
+// Write renders the canvas and writes to a file. A renderer is chosen based on the filename extension. The options will be passed to the respective renderer. Supported extensions: .(png|jpe?g|gif|tiff?|bmp|webp|avif|svgz?|pdf|tex|pgf|typ|ps|eps).
 func Write(filename string, c *canvas.Canvas, opts ...interface{}) error {
 	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
 	case ".png":
@@ -49,6 +54,8 @@ func Write(filename string, c *canvas.Canvas, opts ...interface{}) error {
 		return c.WriteFile(filename, PDF(opts...))
 	case ".tex", ".pgf":
 		return c.WriteFile(filename, TeX(opts...))
+	case ".typ":
+		return c.WriteFile(filename, Typst(opts...))
 	case ".ps":
 		return c.WriteFile(filename, PS(opts...))
 	case ".eps":
@@ -84,8 +91,68 @@ func PNG(opts ...interface{}) canvas.Writer {
 	}
 	return func(w io.Writer, c *canvas.Canvas) error {
 		img := rasterizer.Draw(c, resolution, colorSpace)
-		return encoder.Encode(w, img)
+		if c.Metadata.Empty() {
+			return encoder.Encode(w, img)
+		}
+
+		buf := &bytes.Buffer{}
+		if err := encoder.Encode(buf, img); err != nil {
+			return err
+		}
+		return writePNGMetadata(w, buf.Bytes(), c.Metadata)
+	}
+}
+
+// writePNGMetadata writes a PNG file with the metadata inserted as tEXt chunks (or iTXt for non-ASCII text) after the IHDR chunk.
+func writePNGMetadata(w io.Writer, b []byte, metadata canvas.Metadata) error {
+	const ihdrEnd = 8 + 4 + 4 + 13 + 4 // signature, length, type, data, and CRC
+	if len(b) < ihdrEnd {
+		return fmt.Errorf("invalid PNG")
+	}
+
+	texts := [][2]string{}
+	if metadata.Title != "" {
+		texts = append(texts, [2]string{"Title", metadata.Title})
+	}
+	if metadata.Description != "" {
+		texts = append(texts, [2]string{"Description", metadata.Description})
+	}
+	if metadata.Creator != "" {
+		texts = append(texts, [2]string{"Author", metadata.Creator})
+	}
+	if !metadata.CreationDate.IsZero() {
+		texts = append(texts, [2]string{"Creation Time", metadata.CreationDate.Format(time.RFC1123Z)})
+	}
+	if metadata.Language != "" {
+		texts = append(texts, [2]string{"Language", metadata.Language})
 	}
+
+	chunks := &bytes.Buffer{}
+	chunks.Write(b[:ihdrEnd])
+	for _, text := range texts {
+		typ := "tEXt"
+		data := append([]byte(text[0]), 0)
+		if strings.IndexFunc(text[1], func(r rune) bool { return 0x80 <= r }) != -1 {
+			// international text in UTF-8, uncompressed
+			typ = "iTXt"
+			data = append(data, 0, 0)
+			data = append(append(data, metadata.Language...), 0, 0)
+		}
+		data = append(data, text[1]...)
+
+		binary.Write(chunks, binary.BigEndian, uint32(len(data)))
+		crc := crc32.NewIEEE()
+		crc.Write([]byte(typ))
+		crc.Write(data)
+		chunks.WriteString(typ)
+		chunks.Write(data)
+		binary.Write(chunks, binary.BigEndian, crc.Sum32())
+	}
+	if _, err := w.Write(chunks.Bytes()); err != nil {
+		return err
+	}
+	_, err := w.Write(b[ihdrEnd:])
+	return err
 }
 
 // JPEG returns a JPEG writer and accepts the following options: canvas.Resolution, canvas.Colorspace, image/jpeg.*Options
@@ -253,6 +320,18 @@ func TeX(opts ...interface{}) canvas.Writer {
 	}
 }
 
+// Typst returns a Typst writer.
+func Typst(opts ...interface{}) canvas.Writer {
+	for _, opt := range opts {
+		return errorWriter(fmt.Errorf("unknown Typst option: %T(%v)", opt, opt))
+	}
+	return func(w io.Writer, c *canvas.Canvas) error {
+		typst := typst.New(w, c.W, c.H)
+		c.RenderTo(typst)
+		return typst.Close()
+	}
+}
+
 // PS returns a PostScript writer and accepts the following options: canvas/renderers/ps.*Options
 func PS(opts ...interface{}) canvas.Writer {
 	var options *ps.Options
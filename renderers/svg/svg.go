@@ -21,10 +21,12 @@ import (
 
 type Options struct {
 	Compression int
-	EmbedFonts  bool
+	EmbedFonts  bool // embed fonts of text elements using @font-face, otherwise fonts are referenced by name only
 	SubsetFonts bool
+	OutlineText bool // draw text as paths instead of text elements, which renders identically without fonts but is not searchable or editable
 	SizeUnits   string
 	canvas.ImageEncoding
+	canvas.SVGPathFormat // numeric precision and use of relative and shorthand commands in path data
 }
 
 var DefaultOptions = Options{
@@ -37,11 +39,12 @@ var DefaultOptions = Options{
 // SVG is a scalable vector graphics renderer.
 type SVG struct {
 	w             io.Writer
+	ew            *canvas.ErrorWriter
 	width, height float64
 	fonts         map[*canvas.Font]bool
 	fontSubset    map[*canvas.Font]*canvas.FontSubsetter
 	maskID        int
-	patterns      map[canvas.Gradient]string
+	patterns      map[interface{}]string // gradients, patterns, and filters
 	classes       []string
 	opts          *Options
 }
@@ -53,6 +56,8 @@ func New(w io.Writer, width, height float64, opts *Options) *SVG {
 		opts = &defaultOptions
 	}
 
+	ew := &canvas.ErrorWriter{Writer: w}
+	w = ew
 	if opts.Compression != 0 {
 		if opts.Compression < gzip.HuffmanOnly || gzip.BestCompression < opts.Compression {
 			opts.Compression = -1
@@ -63,11 +68,12 @@ func New(w io.Writer, width, height float64, opts *Options) *SVG {
 	fmt.Fprintf(w, `<svg version="1.1" width="%v%s" height="%v%s" viewBox="0 0 %v %v" xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink">`, dec(width), opts.SizeUnits, dec(height), opts.SizeUnits, dec(width), dec(height))
 	return &SVG{
 		w:          w,
+		ew:         ew,
 		width:      width,
 		height:     height,
 		fonts:      map[*canvas.Font]bool{},
 		fontSubset: map[*canvas.Font]*canvas.FontSubsetter{},
-		patterns:   map[canvas.Gradient]string{},
+		patterns:   map[interface{}]string{},
 		opts:       opts,
 	}
 }
@@ -77,11 +83,37 @@ func (r *SVG) Close() error {
 	if r.opts.EmbedFonts {
 		r.writeFonts()
 	}
-	_, err := fmt.Fprintf(r.w, "</svg>")
+	fmt.Fprintf(r.w, "</svg>")
 	if r.opts.Compression != 0 {
 		r.w.(*gzip.Writer).Close() // does not close underlying writer
 	}
-	return err
+	return r.ew.Err
+}
+
+// Err returns the first error that occurred while writing or encoding images.
+func (r *SVG) Err() error {
+	return r.ew.Err
+}
+
+func (r *SVG) setErr(err error) {
+	if r.ew.Err == nil {
+		r.ew.Err = err
+	}
+}
+
+// SetMetadata writes the document metadata as title, description, and RDF metadata elements.
+func (r *SVG) SetMetadata(metadata canvas.Metadata) {
+	if metadata.Title != "" {
+		fmt.Fprintf(r.w, "<title>")
+		xml.EscapeText(r.w, []byte(metadata.Title))
+		fmt.Fprintf(r.w, "</title>")
+	}
+	if metadata.Description != "" {
+		fmt.Fprintf(r.w, "<desc>")
+		xml.EscapeText(r.w, []byte(metadata.Description))
+		fmt.Fprintf(r.w, "</desc>")
+	}
+	fmt.Fprintf(r.w, "<metadata>%s</metadata>", metadata.RDF())
 }
 
 func (r *SVG) writeFonts() {
@@ -155,6 +187,18 @@ func (r *SVG) SetImageEncoding(enc canvas.ImageEncoding) {
 	r.opts.ImageEncoding = enc
 }
 
+// Capabilities returns the constructs that SVG supports natively, which are gradients, tiling patterns, blend modes through mix-blend-mode, drop shadows through filters, transparency, and text unless OutlineText is set. Gouraud shading is rasterized and Porter-Duff operators are drawn over.
+func (r *SVG) Capabilities() canvas.Capabilities {
+	return canvas.Capabilities{
+		Gradients:    true,
+		Patterns:     true,
+		BlendModes:   true,
+		Shadows:      true,
+		NativeText:   !r.opts.OutlineText,
+		Transparency: true,
+	}
+}
+
 // Size returns the size of the canvas in millimeters.
 func (r *SVG) Size() (float64, float64) {
 	return r.width, r.height
@@ -168,21 +212,41 @@ func (r *SVG) RenderPath(path *canvas.Path, style canvas.Style, m canvas.Matrix)
 	if style.HasStroke() && style.Stroke.IsGradient() {
 		r.getPattern(style.Stroke.Gradient)
 	}
+	if pattern, ok := style.Fill.Pattern.(*canvas.CanvasPattern); ok && style.HasFill() && style.Fill.IsPattern() {
+		r.getTilingPattern(pattern)
+	}
+	if pattern, ok := style.Stroke.Pattern.(*canvas.CanvasPattern); ok && style.HasStroke() && style.Stroke.IsPattern() {
+		r.getTilingPattern(pattern)
+	}
+	if style.Shadow != nil {
+		// group the fill and stroke so that they cast a single shadow
+		fmt.Fprintf(r.w, `<g filter="url(#%v)">`, r.getShadowFilter(*style.Shadow))
+		defer fmt.Fprintf(r.w, `</g>`)
+	}
 
 	stroke := path
 	path = path.Transform(canvas.Identity.ReflectYAbout(r.height / 2.0).Mul(m))
-	fmt.Fprintf(r.w, `<path d="%s`, path.ToSVG())
+	fmt.Fprintf(r.w, `<path d="%s`, path.ToSVGFormat(r.opts.SVGPathFormat))
 
+	// SVGs only support the arcs joiner that clips at the limit (SVG2), and the miter joiner that either falls back to a bevel join or clips at the limit (SVG2)
 	strokeUnsupported := false
-	if arcs, ok := style.StrokeJoiner.(canvas.ArcsJoiner); ok && math.IsNaN(arcs.Limit) {
-		strokeUnsupported = true
+	if arcs, ok := style.StrokeJoiner.(canvas.ArcsJoiner); ok {
+		if math.IsNaN(arcs.Limit) || arcs.GapJoiner != nil {
+			strokeUnsupported = true
+		}
 	} else if miter, ok := style.StrokeJoiner.(canvas.MiterJoiner); ok {
 		if math.IsNaN(miter.Limit) {
 			strokeUnsupported = true
-		} else if _, ok := miter.GapJoiner.(canvas.BevelJoiner); !ok {
+		} else if _, ok := miter.GapJoiner.(canvas.BevelJoiner); !ok && miter.GapJoiner != nil {
 			strokeUnsupported = true
 		}
 	}
+	// SVG doesn't support different start and end caps or custom cappers
+	if start, end, ok := canvas.NativeCapper(style.StrokeCapper); !ok || start != end {
+		strokeUnsupported = true
+	} else {
+		style.StrokeCapper = start
+	}
 	if !strokeUnsupported {
 		if m.IsSimilarity() {
 			scale := math.Sqrt(math.Abs(m.Det()))
@@ -213,6 +277,9 @@ func (r *SVG) RenderPath(path *canvas.Path, style canvas.Style, m canvas.Matrix)
 		} else {
 			fmt.Fprintf(r.w, `" fill="none`)
 		}
+		if blendMode, ok := mixBlendModes[style.CompositeMode]; ok {
+			fmt.Fprintf(r.w, `" style="mix-blend-mode:%s`, blendMode)
+		}
 	} else {
 		b := &strings.Builder{}
 		if style.HasFill() {
@@ -249,15 +316,18 @@ func (r *SVG) RenderPath(path *canvas.Path, style canvas.Style, m canvas.Matrix)
 				fmt.Fprintf(b, ";stroke-linejoin:bevel")
 			} else if _, ok := style.StrokeJoiner.(canvas.RoundJoiner); ok {
 				fmt.Fprintf(b, ";stroke-linejoin:round")
-			} else if arcs, ok := style.StrokeJoiner.(canvas.ArcsJoiner); ok && !math.IsNaN(arcs.Limit) {
+			} else if arcs, ok := style.StrokeJoiner.(canvas.ArcsJoiner); ok {
 				fmt.Fprintf(b, ";stroke-linejoin:arcs")
 				if !canvas.Equal(arcs.Limit, 4.0) {
 					fmt.Fprintf(b, ";stroke-miterlimit:%v", dec(arcs.Limit))
 				}
-			} else if miter, ok := style.StrokeJoiner.(canvas.MiterJoiner); ok && !math.IsNaN(miter.Limit) {
+			} else if miter, ok := style.StrokeJoiner.(canvas.MiterJoiner); ok {
 				// a miter line join is the default
-				if !canvas.Equal(miter.Limit*2.0/style.StrokeWidth, 4.0) {
-					fmt.Fprintf(b, ";stroke-miterlimit:%v", dec(miter.Limit*2.0/style.StrokeWidth))
+				if miter.GapJoiner == nil {
+					fmt.Fprintf(b, ";stroke-linejoin:miter-clip")
+				}
+				if !canvas.Equal(miter.Limit, 4.0) {
+					fmt.Fprintf(b, ";stroke-miterlimit:%v", dec(miter.Limit))
 				}
 			} else {
 				panic("SVG: line join not support")
@@ -273,6 +343,9 @@ func (r *SVG) RenderPath(path *canvas.Path, style canvas.Style, m canvas.Matrix)
 				}
 			}
 		}
+		if blendMode, ok := mixBlendModes[style.CompositeMode]; ok {
+			fmt.Fprintf(b, ";mix-blend-mode:%s", blendMode)
+		}
 		if 0 < b.Len() {
 			fmt.Fprintf(r.w, `" style="%s`, b.String()[1:])
 		}
@@ -287,7 +360,7 @@ func (r *SVG) RenderPath(path *canvas.Path, style canvas.Style, m canvas.Matrix)
 		}
 		stroke = stroke.Stroke(style.StrokeWidth, style.StrokeCapper, style.StrokeJoiner, canvas.Tolerance)
 		stroke = stroke.Transform(canvas.Identity.ReflectYAbout(r.height / 2.0).Mul(m))
-		fmt.Fprintf(r.w, `<path d="%s`, stroke.ToSVG())
+		fmt.Fprintf(r.w, `<path d="%s`, stroke.ToSVGFormat(r.opts.SVGPathFormat))
 		if !style.Stroke.IsColor() || style.Stroke.Color != canvas.Black {
 			fmt.Fprintf(r.w, `" fill="`)
 			r.writePaint(r.w, style.Stroke)
@@ -298,11 +371,29 @@ func (r *SVG) RenderPath(path *canvas.Path, style canvas.Style, m canvas.Matrix)
 		if style.FillRule == canvas.EvenOdd {
 			fmt.Fprintf(r.w, `" fill-rule="evenodd`)
 		}
+		if blendMode, ok := mixBlendModes[style.CompositeMode]; ok {
+			fmt.Fprintf(r.w, `" style="mix-blend-mode:%s`, blendMode)
+		}
 		r.writeClasses(r.w)
 		fmt.Fprintf(r.w, `"/>`)
 	}
 }
 
+// mixBlendModes are the CSS mix-blend-mode values of the blend modes, SVG does not support the Porter-Duff operators.
+var mixBlendModes = map[canvas.CompositeMode]string{
+	canvas.CompositeMultiply:   "multiply",
+	canvas.CompositeScreen:     "screen",
+	canvas.CompositeOverlay:    "overlay",
+	canvas.CompositeDarken:     "darken",
+	canvas.CompositeLighten:    "lighten",
+	canvas.CompositeColorDodge: "color-dodge",
+	canvas.CompositeColorBurn:  "color-burn",
+	canvas.CompositeHardLight:  "hard-light",
+	canvas.CompositeSoftLight:  "soft-light",
+	canvas.CompositeDifference: "difference",
+	canvas.CompositeExclusion:  "exclusion",
+}
+
 func (r *SVG) writeFontStyle(face, faceMain *canvas.FontFace, rtl bool) {
 	differences := 0
 	boldness := face.Style.CSS()
@@ -392,6 +483,9 @@ func (r *SVG) writeFontStyle(face, faceMain *canvas.FontFace, rtl bool) {
 func (r *SVG) RenderText(text *canvas.Text, m canvas.Matrix) {
 	if text.Empty() {
 		return
+	} else if r.opts.OutlineText {
+		text.RenderAsPath(r, m, 0.0)
+		return
 	}
 
 	text.WalkDecorations(func(paint canvas.Paint, p *canvas.Path) {
@@ -487,21 +581,46 @@ func (r *SVG) RenderImage(img image.Image, m canvas.Matrix) {
 		m.ToSVG(r.height), size.X, size.Y, mimetype)
 
 	encoder := base64.NewEncoder(base64.StdEncoding, r.w)
-	err := writeTo(encoder)
-	if err != nil {
-		panic(err)
-	}
-	if err := encoder.Close(); err != nil {
-		panic(err)
+	if err := writeTo(encoder); err != nil {
+		r.setErr(err)
+	} else if err := encoder.Close(); err != nil {
+		r.setErr(err)
 	}
 
 	if refMask != "" {
 		fmt.Fprintf(r.w, `" mask="url(#%s)`, refMask)
 	}
+	if filter := canvas.ImageFilterOf(img); filter == canvas.NearestNeighbor {
+		fmt.Fprintf(r.w, `" style="image-rendering:pixelated`)
+	}
 	r.writeClasses(r.w)
 	fmt.Fprintf(r.w, `"/>`)
 }
 
+// BeginGroup starts a group of drawing operations that is composited with the given opacity.
+func (r *SVG) BeginGroup(opacity float64) {
+	fmt.Fprintf(r.w, `<g opacity="%v">`, dec(opacity))
+}
+
+// EndGroup ends the last group.
+func (r *SVG) EndGroup() {
+	fmt.Fprintf(r.w, `</g>`)
+}
+
+// BeginFilter starts a group of drawing operations to which the filter is applied, which is written as a filter element. Filters other than those of the canvas package are ignored.
+func (r *SVG) BeginFilter(filter canvas.Filter) {
+	if ref := r.getFilter(filter); ref != "" {
+		fmt.Fprintf(r.w, `<g filter="url(#%v)">`, ref)
+	} else {
+		fmt.Fprintf(r.w, `<g>`)
+	}
+}
+
+// EndFilter ends the last group started with BeginFilter.
+func (r *SVG) EndFilter() {
+	fmt.Fprintf(r.w, `</g>`)
+}
+
 // return a WriterTo, a refMask and a mimetype
 func (r *SVG) encodableImage(img image.Image) (func(io.Writer) error, string, string) {
 	if cimg, ok := img.(canvas.Image); ok && 0 < len(cimg.Bytes) {
@@ -544,10 +663,9 @@ func (r *SVG) renderOpacityMask(img image.Image) (image.Image, string) {
 
 	encoder := base64.NewEncoder(base64.StdEncoding, r.w)
 	if err := jpeg.Encode(encoder, mask, nil); err != nil {
-		panic(err)
-	}
-	if err := encoder.Close(); err != nil {
-		panic(err)
+		r.setErr(err)
+	} else if err := encoder.Close(); err != nil {
+		r.setErr(err)
 	}
 	fmt.Fprintf(r.w, `"/></mask>`)
 	return opaque, refMask
@@ -580,6 +698,70 @@ func splitImageAlphaChannel(img image.Image) (image.Image, image.Image) {
 	return opaque, mask
 }
 
+// getShadowFilter returns the reference to a filter that draws a drop shadow, which covers the viewport so that it can be reused for all paths.
+func (r *SVG) getShadowFilter(shadow canvas.Shadow) string {
+	if ref, ok := r.patterns[shadow]; ok {
+		return ref
+	}
+
+	ref := fmt.Sprintf("f%v", len(r.patterns)+1)
+	r.patterns[shadow] = ref
+
+	col := color.RGBA{0, 0, 0, 255}
+	if a := float64(shadow.Color.A) / 255.0; 0.0 < a {
+		col = color.RGBA{uint8(float64(shadow.Color.R)/a + 0.5), uint8(float64(shadow.Color.G)/a + 0.5), uint8(float64(shadow.Color.B)/a + 0.5), 255}
+	}
+	fmt.Fprintf(r.w, `<defs><filter id="%v" filterUnits="userSpaceOnUse" x="0" y="0" width="100%%" height="100%%"><feDropShadow dx="%v" dy="%v" stdDeviation="%v" flood-color="%v"`, ref, dec(shadow.Offset.X), dec(-shadow.Offset.Y), dec(shadow.Blur), canvas.CSSColor(col))
+	if shadow.Color.A != 255 {
+		fmt.Fprintf(r.w, ` flood-opacity="%v"`, dec(float64(shadow.Color.A)/255.0))
+	}
+	fmt.Fprintf(r.w, `/></filter></defs>`)
+	return ref
+}
+
+// getFilter returns the reference to a filter element for the filter, or an empty string if it is not supported. The filter covers the viewport and applies to sRGB colors.
+func (r *SVG) getFilter(filter canvas.Filter) string {
+	var primitive string
+	switch f := filter.(type) {
+	case *canvas.BlurFilter:
+		primitive = fmt.Sprintf(`<feGaussianBlur stdDeviation="%v"/>`, dec(f.StdDev))
+	case *canvas.ColorMatrixFilter:
+		values := make([]string, 0, 20)
+		for _, row := range f.Matrix {
+			for _, v := range row {
+				values = append(values, dec(v).String())
+			}
+		}
+		primitive = fmt.Sprintf(`<feColorMatrix type="matrix" values="%v"/>`, strings.Join(values, " "))
+	case *canvas.ConvolveFilter:
+		if f.Order <= 0 || len(f.Kernel) != f.Order*f.Order {
+			return ""
+		}
+		values := make([]string, 0, len(f.Kernel))
+		for _, v := range f.Kernel {
+			values = append(values, dec(v).String())
+		}
+		primitive = fmt.Sprintf(`<feConvolveMatrix order="%v" kernelMatrix="%v" edgeMode="none"/>`, f.Order, strings.Join(values, " "))
+	case *canvas.MorphologyFilter:
+		operator := "erode"
+		if f.Dilate {
+			operator = "dilate"
+		}
+		primitive = fmt.Sprintf(`<feMorphology operator="%v" radius="%v"/>`, operator, dec(f.Radius))
+	default:
+		return ""
+	}
+
+	// only filters of the canvas package are used as key, which are comparable
+	if ref, ok := r.patterns[filter]; ok {
+		return ref
+	}
+	ref := fmt.Sprintf("f%v", len(r.patterns)+1)
+	r.patterns[filter] = ref
+	fmt.Fprintf(r.w, `<defs><filter id="%v" filterUnits="userSpaceOnUse" x="0" y="0" width="100%%" height="100%%" color-interpolation-filters="sRGB">%v</filter></defs>`, ref, primitive)
+	return ref
+}
+
 func (r *SVG) getPattern(gradient canvas.Gradient) string {
 	if ref, ok := r.patterns[gradient]; ok {
 		return ref
@@ -590,15 +772,15 @@ func (r *SVG) getPattern(gradient canvas.Gradient) string {
 
 	fmt.Fprintf(r.w, `<defs>`)
 	if linearGradient, ok := gradient.(*canvas.LinearGradient); ok {
-		fmt.Fprintf(r.w, `<linearGradient id="%v" gradientUnits="userSpaceOnUse" x1="%v" y1="%v" x2="%v" y2="%v">`, ref, dec(linearGradient.Start.X), dec(r.height-linearGradient.Start.Y), dec(linearGradient.End.X), dec(r.height-linearGradient.End.Y))
-		for _, stop := range linearGradient.Stops {
-			fmt.Fprintf(r.w, `<stop offset="%v" stop-color="%v"/>`, dec(stop.Offset), canvas.CSSColor(stop.Color))
+		fmt.Fprintf(r.w, `<linearGradient id="%v" gradientUnits="userSpaceOnUse" x1="%v" y1="%v" x2="%v" y2="%v"%v>`, ref, dec(linearGradient.Start.X), dec(r.height-linearGradient.Start.Y), dec(linearGradient.End.X), dec(r.height-linearGradient.End.Y), spreadMethod(linearGradient.Spread))
+		for _, stop := range linearGradient.Stops.Resample(linearGradient.Interpolation) {
+			r.writeStop(stop)
 		}
 		fmt.Fprintf(r.w, `</linearGradient>`)
 	} else if radialGradient, ok := gradient.(*canvas.RadialGradient); ok {
-		fmt.Fprintf(r.w, `<radialGradient id="%v" gradientUnits="userSpaceOnUse" fx="%v" fy="%v" fr="%v" cx="%v" cy="%v" r="%v">`, ref, dec(radialGradient.C0.X), dec(r.height-radialGradient.C0.Y), dec(radialGradient.R0), dec(radialGradient.C1.X), dec(r.height-radialGradient.C1.Y), dec(radialGradient.R1))
-		for _, stop := range radialGradient.Stops {
-			fmt.Fprintf(r.w, `<stop offset="%v" stop-color="%v"/>`, dec(stop.Offset), canvas.CSSColor(stop.Color))
+		fmt.Fprintf(r.w, `<radialGradient id="%v" gradientUnits="userSpaceOnUse" fx="%v" fy="%v" fr="%v" cx="%v" cy="%v" r="%v"%v>`, ref, dec(radialGradient.C0.X), dec(r.height-radialGradient.C0.Y), dec(radialGradient.R0), dec(radialGradient.C1.X), dec(r.height-radialGradient.C1.Y), dec(radialGradient.R1), spreadMethod(radialGradient.Spread))
+		for _, stop := range radialGradient.Stops.Resample(radialGradient.Interpolation) {
+			r.writeStop(stop)
 		}
 		fmt.Fprintf(r.w, `</radialGradient>`)
 	}
@@ -606,9 +788,56 @@ func (r *SVG) getPattern(gradient canvas.Gradient) string {
 	return ref
 }
 
+// getTilingPattern writes the pattern element that repeats the canvas of the pattern and returns its ID. The tile is drawn in coordinates with the Y axis pointing down from the origin, which are mapped by the pattern transform.
+func (r *SVG) getTilingPattern(pattern *canvas.CanvasPattern) string {
+	if ref, ok := r.patterns[pattern]; ok {
+		return ref
+	}
+
+	ref := fmt.Sprintf("p%v", len(r.patterns)+1)
+	r.patterns[pattern] = ref
+
+	w, h := pattern.Canvas.W+pattern.Spacing, pattern.Canvas.H+pattern.Spacing
+	m := pattern.View
+	fmt.Fprintf(r.w, `<defs><pattern id="%v" patternUnits="userSpaceOnUse" x="0" y="%v" width="%v" height="%v" patternTransform="matrix(%v,%v,%v,%v,%v,%v)">`, ref, dec(-h), dec(w), dec(h), dec(m[0][0]), dec(-m[1][0]), dec(-m[0][1]), dec(m[1][1]), dec(m[0][2]), dec(r.height-m[1][2]))
+	height := r.height
+	r.height = 0.0
+	pattern.Canvas.RenderTo(r)
+	r.height = height
+	fmt.Fprintf(r.w, `</pattern></defs>`)
+	return ref
+}
+
+// spreadMethod returns the spreadMethod attribute of a gradient, which is omitted for the default pad method.
+func spreadMethod(spread canvas.Spread) string {
+	switch spread {
+	case canvas.ReflectSpread:
+		return ` spreadMethod="reflect"`
+	case canvas.RepeatSpread:
+		return ` spreadMethod="repeat"`
+	}
+	return ""
+}
+
+// writeStop writes a gradient stop, with the alpha channel as stop-opacity since not all viewers support rgba() colors for stops.
+func (r *SVG) writeStop(stop canvas.Stop) {
+	c := stop.Color
+	if c.A == 255 {
+		fmt.Fprintf(r.w, `<stop offset="%v" stop-color="%v"/>`, dec(stop.Offset), canvas.CSSColor(c))
+		return
+	} else if c.A != 0 {
+		c = color.RGBA{uint8(uint32(c.R) * 255 / uint32(c.A)), uint8(uint32(c.G) * 255 / uint32(c.A)), uint8(uint32(c.B) * 255 / uint32(c.A)), 255}
+	} else {
+		c = canvas.Black
+	}
+	fmt.Fprintf(r.w, `<stop offset="%v" stop-color="%v" stop-opacity="%v"/>`, dec(stop.Offset), canvas.CSSColor(c), dec(float64(stop.Color.A)/255.0))
+}
+
 func (r *SVG) writePaint(w io.Writer, paint canvas.Paint) {
 	if paint.IsPattern() {
-		// TODO
+		if pattern, ok := paint.Pattern.(*canvas.CanvasPattern); ok {
+			fmt.Fprintf(w, "url(#%v)", r.getTilingPattern(pattern))
+		}
 	} else if paint.IsGradient() {
 		fmt.Fprintf(w, "url(#%v)", r.getPattern(paint.Gradient))
 	} else {
@@ -1,7 +1,13 @@
 package svg
 
 import (
+	"bytes"
+	"image/color"
+	"strings"
 	"testing"
+
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/test"
 )
 
 func TestSVGText(t *testing.T) {
@@ -30,3 +36,66 @@ func TestSVGText(t *testing.T) {
 	//s := regexp.MustCompile(`base64,.+'`).ReplaceAllString(buf.String(), "base64,'") // remove embedded font
 	//test.String(t, s, `<style>`+"\n"+`@font-face{font-family:'dejavu-serif';src:url('data:font/truetype;base64,');}`+"\n"+`@font-face{font-family:'eb-garamond';src:url('data:font/opentype;base64,');}`+"\n"+`</style><text x="0" y="0" style="font: 12px dejavu-serif"><tspan x="0" y="7.421875" style="font:8px dejavu-serif">dejaVu8</tspan><tspan x="0" y="20.453125" letter-spacing="1" style="font-style:italic;fill:#f00">glyphspacing</tspan><tspan x="0" y="33.725625" style="font:700 6.996px dejavu-serif">dejaVu12sub</tspan><tspan x="0" y="38.5" style="font:700 10px eb-garamond">garamond10</tspan></text><path d="M0 22.703125H91.71875V21.803125H0z" fill="#f00"/>`)
 }
+
+func TestSVGOutlineText(t *testing.T) {
+	family := canvas.NewFontFamily("dejavu-serif")
+	if err := family.LoadFontFile("../../resources/DejaVuSerif.ttf", canvas.FontRegular); err != nil {
+		test.Error(t, err)
+	}
+	face := family.Face(12.0, canvas.Black, canvas.FontRegular, canvas.FontNormal)
+
+	for _, outline := range []bool{false, true} {
+		buf := &bytes.Buffer{}
+		svg := New(buf, 100.0, 100.0, &Options{OutlineText: outline, SizeUnits: "mm"})
+		ctx := canvas.NewContext(svg)
+		ctx.DrawText(10.0, 10.0, canvas.NewTextLine(face, "text", canvas.Left))
+		test.Error(t, svg.Close())
+		test.T(t, strings.Contains(buf.String(), "<text"), !outline)
+		test.T(t, strings.Contains(buf.String(), "<path"), outline)
+	}
+}
+
+func TestSVGBlendMode(t *testing.T) {
+	buf := &bytes.Buffer{}
+	svg := New(buf, 100.0, 100.0, nil)
+	style := canvas.DefaultStyle
+	style.CompositeMode = canvas.CompositeMultiply
+	svg.RenderPath(canvas.Rectangle(10.0, 10.0), style, canvas.Identity)
+	style.CompositeMode = canvas.CompositeXor // unsupported
+	svg.RenderPath(canvas.Rectangle(10.0, 10.0), style, canvas.Identity)
+	test.Error(t, svg.Close())
+	test.T(t, strings.Count(buf.String(), `style="mix-blend-mode:multiply"`), 1)
+	test.T(t, strings.Count(buf.String(), `mix-blend-mode`), 1)
+}
+
+func TestSVGShadow(t *testing.T) {
+	buf := &bytes.Buffer{}
+	svg := New(buf, 100.0, 100.0, nil)
+	style := canvas.DefaultStyle
+	style.Shadow = &canvas.Shadow{Offset: canvas.Point{X: 1.0, Y: -2.0}, Blur: 0.5, Color: color.RGBA{0, 0, 0, 128}}
+	svg.RenderPath(canvas.Rectangle(10.0, 10.0), style, canvas.Identity)
+	svg.RenderPath(canvas.Rectangle(10.0, 10.0), style, canvas.Identity)
+	test.Error(t, svg.Close())
+	test.T(t, strings.Count(buf.String(), `<feDropShadow dx="1" dy="2" stdDeviation=".5" flood-color="#000" flood-opacity="`), 1)
+	test.T(t, strings.Count(buf.String(), `<g filter="url(#f1)"><path`), 2)
+}
+
+func TestSVGFilter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	svg := New(buf, 100.0, 100.0, nil)
+	filter := canvas.NewBlurFilter(0.5)
+	svg.BeginFilter(filter)
+	svg.RenderPath(canvas.Rectangle(10.0, 10.0), canvas.DefaultStyle, canvas.Identity)
+	svg.EndFilter()
+	svg.BeginFilter(filter)
+	svg.EndFilter()
+	svg.BeginFilter(canvas.NewConvolveFilter(2, []float64{1.0, 0.0, 0.0, -1.0}))
+	svg.EndFilter()
+	svg.BeginFilter(canvas.NewDilateFilter(1.0))
+	svg.EndFilter()
+	test.Error(t, svg.Close())
+	test.T(t, strings.Count(buf.String(), `<feGaussianBlur stdDeviation=".5"/>`), 1)
+	test.T(t, strings.Count(buf.String(), `<g filter="url(#f1)">`), 2)
+	test.T(t, strings.Count(buf.String(), `<feConvolveMatrix order="2" kernelMatrix="1 0 0 -1" edgeMode="none"/>`), 1)
+	test.T(t, strings.Count(buf.String(), `<feMorphology operator="dilate" radius="1"/>`), 1)
+}
@@ -13,6 +13,7 @@ import (
 // TeX is a TeX/PGF renderer. Be aware that TeX/PGF does not support transparency of colors.
 type TeX struct {
 	w             io.Writer
+	ew            *canvas.ErrorWriter
 	width, height float64
 
 	style      canvas.Style
@@ -22,11 +23,14 @@ type TeX struct {
 
 // New returns a TeX/PGF renderer.
 func New(w io.Writer, width, height float64) *TeX {
+	ew := &canvas.ErrorWriter{Writer: w}
+	w = ew
 	fmt.Fprintf(w, "\\begin{pgfpicture}")
 	style := canvas.DefaultStyle
 	style.StrokeWidth = 0.0
 	return &TeX{
 		w:          w,
+		ew:         ew,
 		width:      width,
 		height:     height,
 		style:      style,
@@ -37,8 +41,20 @@ func New(w io.Writer, width, height float64) *TeX {
 
 // Close finished and closes the TeX file.
 func (r *TeX) Close() error {
-	_, err := fmt.Fprintf(r.w, "\n\\end{pgfpicture}")
-	return err
+	fmt.Fprintf(r.w, "\n\\end{pgfpicture}")
+	return r.ew.Err
+}
+
+// Err returns the first error that occurred while writing.
+func (r *TeX) Err() error {
+	return r.ew.Err
+}
+
+// Capabilities returns the constructs that PGF/TikZ supports natively, which is only transparency. Composite modes are drawn over and text is drawn as paths, while gradients and shadows are converted to images that are not written yet.
+func (r *TeX) Capabilities() canvas.Capabilities {
+	return canvas.Capabilities{
+		Transparency: true,
+	}
 }
 
 // Size returns the size of the canvas in millimeters.
@@ -201,6 +217,12 @@ func (r *TeX) RenderPath(path *canvas.Path, style canvas.Style, m canvas.Matrix)
 	} else {
 		strokeUnsupported = true
 	}
+	// TeX doesn't support different start and end caps or custom cappers
+	if start, end, ok := canvas.NativeCapper(style.StrokeCapper); !ok || start != end {
+		strokeUnsupported = true
+	} else {
+		style.StrokeCapper = start
+	}
 
 	if style.HasFill() || style.HasStroke() && !strokeUnsupported {
 		r.writePath(path.Transform(m))
@@ -0,0 +1,310 @@
+package typst
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/tdewolff/canvas"
+	canvasFont "github.com/tdewolff/font"
+)
+
+// Typst is a Typst renderer that writes the canvas as Typst markup, so that it can be included in a Typst document as native content using #include. Paths are written as curves, text is written as text using the fonts installed for Typst, and images are embedded as PNG or JPEG. Be aware that Typst does not support gradients nor elliptical arcs, and that text is positioned per span and may thus be laid out slightly differently by Typst.
+type Typst struct {
+	w             io.Writer
+	ew            *canvas.ErrorWriter
+	width, height float64
+}
+
+// New returns a Typst renderer.
+func New(w io.Writer, width, height float64) *Typst {
+	ew := &canvas.ErrorWriter{Writer: w}
+	w = ew
+	fmt.Fprintf(w, "#box(width: %v, height: %v, {", mm(width), mm(height))
+	return &Typst{
+		w:      w,
+		ew:     ew,
+		width:  width,
+		height: height,
+	}
+}
+
+// Close finished and closes the Typst file.
+func (r *Typst) Close() error {
+	fmt.Fprintf(r.w, "\n})\n")
+	return r.ew.Err
+}
+
+// Err returns the first error that occurred while writing.
+func (r *Typst) Err() error {
+	return r.ew.Err
+}
+
+// Capabilities returns the constructs that Typst supports natively, which are text and transparency. Gradients, patterns, composite modes, and shadows are converted to paths and images.
+func (r *Typst) Capabilities() canvas.Capabilities {
+	return canvas.Capabilities{
+		NativeText:   true,
+		Transparency: true,
+	}
+}
+
+// Size returns the size of the canvas in millimeters.
+func (r *Typst) Size() (float64, float64) {
+	return r.width, r.height
+}
+
+func (r *Typst) point(p canvas.Point) string {
+	return fmt.Sprintf("(%v, %v)", mm(p.X), mm(r.height-p.Y))
+}
+
+func (r *Typst) writePath(path *canvas.Path) {
+	path = path.ReplaceArcs()
+	for scanner := path.Scanner(); scanner.Scan(); {
+		end := scanner.End()
+		switch scanner.Cmd() {
+		case canvas.MoveToCmd:
+			fmt.Fprintf(r.w, ", curve.move(%v)", r.point(end))
+		case canvas.LineToCmd:
+			fmt.Fprintf(r.w, ", curve.line(%v)", r.point(end))
+		case canvas.QuadToCmd:
+			fmt.Fprintf(r.w, ", curve.quad(%v, %v)", r.point(scanner.CP1()), r.point(end))
+		case canvas.CubeToCmd:
+			fmt.Fprintf(r.w, ", curve.cubic(%v, %v, %v)", r.point(scanner.CP1()), r.point(scanner.CP2()), r.point(end))
+		case canvas.CloseCmd:
+			fmt.Fprintf(r.w, `, curve.close(mode: "straight")`)
+		}
+	}
+}
+
+func (r *Typst) writeStroke(style canvas.Style) {
+	fmt.Fprintf(r.w, "stroke: (paint: %v, thickness: %v", rgba(style.Stroke.Color), mm(style.StrokeWidth))
+	switch style.StrokeCapper.(type) {
+	case canvas.RoundCapper:
+		fmt.Fprintf(r.w, `, cap: "round"`)
+	case canvas.SquareCapper:
+		fmt.Fprintf(r.w, `, cap: "square"`)
+	default:
+		fmt.Fprintf(r.w, `, cap: "butt"`)
+	}
+	if _, ok := style.StrokeJoiner.(canvas.BevelJoiner); ok {
+		fmt.Fprintf(r.w, `, join: "bevel"`)
+	} else if _, ok := style.StrokeJoiner.(canvas.RoundJoiner); ok {
+		fmt.Fprintf(r.w, `, join: "round"`)
+	} else if miter, ok := style.StrokeJoiner.(canvas.MiterJoiner); ok {
+		fmt.Fprintf(r.w, `, join: "miter", miter-limit: %v`, dec(miter.Limit))
+	}
+	if style.IsDashed() {
+		fmt.Fprintf(r.w, ", dash: (array: (")
+		for i, dash := range style.Dashes {
+			if i != 0 {
+				fmt.Fprintf(r.w, ", ")
+			}
+			fmt.Fprintf(r.w, "%v", mm(dash))
+		}
+		if len(style.Dashes) == 1 {
+			fmt.Fprintf(r.w, ",")
+		}
+		fmt.Fprintf(r.w, "), phase: %v)", mm(style.DashOffset))
+	}
+	fmt.Fprintf(r.w, ")")
+}
+
+// RenderPath renders a path to the canvas using a style and a transformation matrix.
+func (r *Typst) RenderPath(path *canvas.Path, style canvas.Style, m canvas.Matrix) {
+	if path.Empty() {
+		return
+	}
+
+	strokeUnsupported := false
+	if m.IsSimilarity() {
+		scale := math.Sqrt(math.Abs(m.Det()))
+		style.StrokeWidth *= scale
+		style.DashOffset *= scale
+		dashes := make([]float64, len(style.Dashes))
+		for i := range style.Dashes {
+			dashes[i] = style.Dashes[i] * scale
+		}
+		style.Dashes = dashes
+	} else {
+		strokeUnsupported = true
+	}
+	// Typst doesn't support different start and end caps, custom cappers, or joiners other than bevel, round, and miter with a bevel fallback
+	if start, end, ok := canvas.NativeCapper(style.StrokeCapper); !ok || start != end {
+		strokeUnsupported = true
+	} else {
+		style.StrokeCapper = start
+	}
+	switch joiner := style.StrokeJoiner.(type) {
+	case canvas.BevelJoiner, canvas.RoundJoiner:
+	case canvas.MiterJoiner:
+		if math.IsNaN(joiner.Limit) || joiner.GapJoiner != canvas.BevelJoin {
+			strokeUnsupported = true
+		}
+	default:
+		strokeUnsupported = true
+	}
+
+	if style.HasFill() || style.HasStroke() && !strokeUnsupported {
+		fmt.Fprintf(r.w, "\nplace(curve(")
+		if style.HasFill() {
+			fmt.Fprintf(r.w, "fill: %v", rgba(style.Fill.Color))
+			if style.FillRule == canvas.EvenOdd {
+				fmt.Fprintf(r.w, `, fill-rule: "even-odd"`)
+			}
+		} else {
+			fmt.Fprintf(r.w, "fill: none")
+		}
+		if style.HasStroke() && !strokeUnsupported {
+			fmt.Fprintf(r.w, ", ")
+			r.writeStroke(style)
+		} else {
+			fmt.Fprintf(r.w, ", stroke: none")
+		}
+		r.writePath(path.Transform(m))
+		fmt.Fprintf(r.w, "))")
+	}
+
+	if style.HasStroke() && strokeUnsupported {
+		// stroke settings unsupported by Typst, draw stroke explicitly
+		if style.IsDashed() {
+			path = path.Dash(style.DashOffset, style.Dashes...)
+		}
+		path = path.Stroke(style.StrokeWidth, style.StrokeCapper, style.StrokeJoiner, canvas.Tolerance)
+		fmt.Fprintf(r.w, "\nplace(curve(fill: %v, stroke: none", rgba(style.Stroke.Color))
+		r.writePath(path.Transform(m))
+		fmt.Fprintf(r.w, "))")
+	}
+}
+
+// beginTransform starts content that is transformed by m, in coordinates relative to the origin of m with the Y axis pointing down, which must be ended with endTransform.
+func (r *Typst) beginTransform(m canvas.Matrix) {
+	tx, ty, phi, sx, sy, theta := m.Decompose()
+	fmt.Fprintf(r.w, "\nplace(dx: %v, dy: %v", mm(tx), mm(r.height-ty))
+	if phi != 0.0 {
+		fmt.Fprintf(r.w, ", rotate(%vdeg, origin: top + left, reflow: false", dec(-phi))
+	}
+	if sx != 1.0 || sy != 1.0 {
+		fmt.Fprintf(r.w, ", scale(x: %v%%, y: %v%%, origin: top + left, reflow: false", dec(100.0*sx), dec(100.0*sy))
+	}
+	if theta != 0.0 {
+		fmt.Fprintf(r.w, ", rotate(%vdeg, origin: top + left, reflow: false", dec(-theta))
+	}
+	fmt.Fprintf(r.w, ", box(width: 0pt, height: 0pt, {")
+}
+
+func (r *Typst) endTransform(m canvas.Matrix) {
+	_, _, phi, sx, sy, theta := m.Decompose()
+	fmt.Fprintf(r.w, "\n}))")
+	if phi != 0.0 {
+		fmt.Fprintf(r.w, ")")
+	}
+	if sx != 1.0 || sy != 1.0 {
+		fmt.Fprintf(r.w, ")")
+	}
+	if theta != 0.0 {
+		fmt.Fprintf(r.w, ")")
+	}
+}
+
+func fontFamily(font *canvas.Font) string {
+	for _, id := range []canvasFont.NameID{canvasFont.NamePreferredFamily, canvasFont.NameFontFamily} {
+		if records := font.SFNT.Name.Get(id); 0 < len(records) {
+			return records[0].String()
+		}
+	}
+	return font.Name()
+}
+
+// RenderText renders a text object to the canvas using a transformation matrix.
+func (r *Typst) RenderText(text *canvas.Text, m canvas.Matrix) {
+	if text.Empty() {
+		return
+	}
+
+	native := text.WritingMode == canvas.HorizontalTB
+	text.WalkSpans(func(x, y float64, span canvas.TextSpan) {
+		if span.IsText() && !span.Face.Fill.IsColor() {
+			native = false
+		}
+	})
+	if !native {
+		text.RenderAsPath(r, m, 0.0)
+		return
+	}
+
+	text.WalkDecorations(func(paint canvas.Paint, p *canvas.Path) {
+		style := canvas.DefaultStyle
+		style.Fill = paint
+		r.RenderPath(p, style, m)
+	})
+
+	text.WalkSpans(func(x, y float64, span canvas.TextSpan) {
+		if !span.IsText() {
+			for _, obj := range span.Objects {
+				obj.Canvas.RenderViewTo(r, m.Mul(obj.View(x, y, span.Face)))
+			}
+		}
+	})
+
+	r.beginTransform(m)
+	text.WalkSpans(func(x, y float64, span canvas.TextSpan) {
+		if !span.IsText() {
+			return
+		}
+		face := span.Face
+		fmt.Fprintf(r.w, "\nplace(dx: %v, dy: %v, text(font: %v, size: %v", mm(x), mm(-y), typstString(fontFamily(face.Font)), mm(face.Size))
+		if boldness := face.Style.CSS(); boldness != 400 {
+			fmt.Fprintf(r.w, ", weight: %d", boldness)
+		}
+		if face.Style&canvas.FontItalic != 0 {
+			fmt.Fprintf(r.w, `, style: "italic"`)
+		}
+		if face.Variant == canvas.FontSmallcaps {
+			fmt.Fprintf(r.w, ", smallcaps: true")
+		}
+		fmt.Fprintf(r.w, `, fill: %v, top-edge: "baseline", bottom-edge: "baseline", %v))`, rgba(face.Fill.Color), typstString(span.Text))
+	})
+	r.endTransform(m)
+}
+
+// RenderImage renders an image to the canvas using a transformation matrix.
+func (r *Typst) RenderImage(img image.Image, m canvas.Matrix) {
+	size := img.Bounds().Size()
+	format, data := "png", []byte(nil)
+	if cimg, ok := img.(canvas.Image); ok && 0 < len(cimg.Bytes) && (cimg.Mimetype == "image/jpeg" || cimg.Mimetype == "image/png") {
+		data = cimg.Bytes
+		if cimg.Mimetype == "image/jpeg" {
+			format = "jpg"
+		}
+	} else {
+		buf := &bytes.Buffer{}
+		if err := png.Encode(buf, img); err != nil {
+			if r.ew.Err == nil {
+				r.ew.Err = err
+			}
+			return
+		}
+		data = buf.Bytes()
+	}
+
+	r.beginTransform(m)
+	fmt.Fprintf(r.w, "\nplace(dy: %v, image(bytes((", mm(-size.Y))
+	for i, b := range data {
+		if i != 0 {
+			fmt.Fprintf(r.w, ",")
+		}
+		fmt.Fprintf(r.w, "%d", b)
+	}
+	if len(data) == 1 {
+		fmt.Fprintf(r.w, ",")
+	}
+	fmt.Fprintf(r.w, `)), format: "%s", width: %v, height: %v, fit: "stretch"`, format, mm(size.X), mm(size.Y))
+	if filter := canvas.ImageFilterOf(img); filter == canvas.NearestNeighbor {
+		fmt.Fprintf(r.w, `, scaling: "pixelated"`)
+	}
+	fmt.Fprintf(r.w, "))")
+	r.endTransform(m)
+}
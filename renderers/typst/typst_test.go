@@ -0,0 +1,51 @@
+package typst
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/test"
+)
+
+func TestTypstPath(t *testing.T) {
+	buf := &bytes.Buffer{}
+	typst := New(buf, 100.0, 50.0)
+	style := canvas.DefaultStyle
+	style.Fill = canvas.Paint{Color: canvas.Red}
+	style.Stroke = canvas.Paint{Color: canvas.Blue}
+	typst.RenderPath(canvas.Rectangle(10.0, 5.0), style, canvas.Identity.Translate(10.0, 20.0))
+
+	style = canvas.DefaultStyle
+	style.Fill = canvas.Paint{}
+	style.Stroke = canvas.Paint{Color: canvas.Black}
+	style.StrokeWidth = 2.0
+	style.StrokeCapper = canvas.RoundCap
+	style.Dashes = []float64{2.0, 1.0}
+	typst.RenderPath(canvas.Line(10.0, 0.0), style, canvas.Identity)
+	test.Error(t, typst.Close())
+	test.String(t, buf.String(), `#box(width: 100mm, height: 50mm, {
+place(curve(fill: rgb("#ff0000"), stroke: (paint: rgb("#0000ff"), thickness: 1mm, cap: "butt", join: "miter", miter-limit: 4), curve.move((10mm, 30mm)), curve.line((20mm, 30mm)), curve.line((20mm, 25mm)), curve.line((10mm, 25mm)), curve.close(mode: "straight")))
+place(curve(fill: none, stroke: (paint: rgb("#000000"), thickness: 2mm, cap: "round", join: "miter", miter-limit: 4, dash: (array: (2mm, 1mm), phase: 0mm)), curve.move((0mm, 50mm)), curve.line((10mm, 50mm))))
+})
+`)
+}
+
+func TestTypstImage(t *testing.T) {
+	buf := &bytes.Buffer{}
+	typst := New(buf, 100.0, 50.0)
+	img := canvas.Image{
+		Image:    image.NewRGBA(image.Rect(0, 0, 2, 1)),
+		Mimetype: "image/png",
+		Bytes:    []byte{1, 2, 3},
+	}
+	typst.RenderImage(img, canvas.Identity.Translate(5.0, 5.0))
+	test.Error(t, typst.Close())
+	test.String(t, buf.String(), `#box(width: 100mm, height: 50mm, {
+place(dx: 5mm, dy: 45mm, box(width: 0pt, height: 0pt, {
+place(dy: -1mm, image(bytes((1,2,3)), format: "png", width: 2mm, height: 1mm, fit: "stretch"))
+}))
+})
+`)
+}
@@ -0,0 +1,82 @@
+package typst
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/minify/v2"
+)
+
+func float64sEqual(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i, f := range a {
+		if f != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+type dec float64
+
+func (f dec) String() string {
+	s := fmt.Sprintf("%.*f", canvas.Precision, f)
+	s = string(minify.Decimal([]byte(s), canvas.Precision))
+	if dec(math.MaxInt32) < f || f < dec(math.MinInt32) {
+		if i := strings.IndexByte(s, '.'); i == -1 {
+			s += ".0"
+		}
+	}
+	return s
+}
+
+// mm is a length in millimeters.
+type mm float64
+
+func (f mm) String() string {
+	return dec(f).String() + "mm"
+}
+
+// rgba is a premultiplied color that is written as a Typst color.
+type rgba color.RGBA
+
+func (col rgba) String() string {
+	if col.A == 0 {
+		return `rgb(0,0,0,0)`
+	} else if col.A == 255 {
+		return fmt.Sprintf(`rgb("#%02x%02x%02x")`, col.R, col.G, col.B)
+	}
+	A := float64(col.A) / 255.0
+	R := uint8(float64(col.R)/A + 0.5)
+	G := uint8(float64(col.G)/A + 0.5)
+	B := uint8(float64(col.B)/A + 0.5)
+	return fmt.Sprintf(`rgb("#%02x%02x%02x%02x")`, R, G, B, col.A)
+}
+
+// typstString returns s as a Typst string literal.
+func typstString(s string) string {
+	sb := strings.Builder{}
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
@@ -0,0 +1,388 @@
+//go:build js
+
+package webgpu
+
+import (
+	"errors"
+	"syscall/js"
+
+	"github.com/tdewolff/canvas"
+	"github.com/tdewolff/canvas/renderers/internal/gpu"
+)
+
+// ErrUnsupported is returned when the browser does not support WebGPU.
+var ErrUnsupported = errors.New("WebGPU not supported")
+
+// WebGPU is a renderer for WebAssembly that draws to a GPU texture using WebGPU. Paths filled or stroked with a color are tessellated into triangles that are drawn by the GPU with 4x multisampling, while gradients, patterns, text, and images are rasterized into images that are uploaded as textures, see gpu.Scene. The result can be drawn to an HTML canvas element with Draw, or be used by other WebGPU pipelines of the application with Texture.
+type WebGPU struct {
+	*gpu.Scene
+
+	device   js.Value
+	texture  js.Value
+	msaa     js.Value // multisampled texture that is resolved into texture
+	uniforms js.Value
+	sampler  js.Value
+
+	// pipelines to draw the layers of the scene into texture
+	meshPipeline   js.Value
+	meshBindGroup  js.Value
+	imagePipeline  js.Value
+	imageTextures  []js.Value
+	imageBindGroup []js.Value
+
+	// pipeline to draw the texture to an HTML canvas element
+	context   js.Value
+	format    js.Value
+	pipeline  js.Value
+	bindGroup js.Value
+}
+
+// RequestDevice requests a GPU device from the browser, which may be shared by several renderers.
+func RequestDevice() (js.Value, error) {
+	gpu := js.Global().Get("navigator").Get("gpu")
+	if gpu.IsUndefined() {
+		return js.Value{}, ErrUnsupported
+	}
+	adapter, ok := jsAwait(gpu.Call("requestAdapter"))
+	if !ok || adapter.IsNull() {
+		return js.Value{}, ErrUnsupported
+	}
+	device, ok := jsAwait(adapter.Call("requestDevice"))
+	if !ok {
+		return js.Value{}, errors.New("WebGPU: " + device.Call("toString").String())
+	}
+	return device, nil
+}
+
+// New returns a WebGPU renderer of the given size in millimeters that uses the GPU device, see RequestDevice.
+func New(device js.Value, width, height float64, resolution canvas.Resolution) *WebGPU {
+	scene := gpu.NewScene(width, height, resolution)
+	size := []interface{}{scene.Bounds().Dx(), scene.Bounds().Dy()}
+	textureUsage := js.Global().Get("GPUTextureUsage")
+	bufferUsage := js.Global().Get("GPUBufferUsage")
+	r := &WebGPU{
+		Scene:  scene,
+		device: device,
+		texture: device.Call("createTexture", map[string]interface{}{
+			"size":   size,
+			"format": "rgba8unorm",
+			"usage":  textureUsage.Get("TEXTURE_BINDING").Int() | textureUsage.Get("RENDER_ATTACHMENT").Int(),
+		}),
+		msaa: device.Call("createTexture", map[string]interface{}{
+			"size":        size,
+			"format":      "rgba8unorm",
+			"sampleCount": sampleCount,
+			"usage":       textureUsage.Get("RENDER_ATTACHMENT").Int(),
+		}),
+		uniforms: device.Call("createBuffer", map[string]interface{}{
+			"size":  gpu.UniformSize,
+			"usage": bufferUsage.Get("UNIFORM").Int() | bufferUsage.Get("COPY_DST").Int(),
+		}),
+		sampler: device.Call("createSampler", map[string]interface{}{
+			"magFilter": "linear",
+			"minFilter": "linear",
+		}),
+	}
+	device.Get("queue").Call("writeBuffer", r.uniforms, 0, jsBytes(r.Uniforms()))
+
+	// premultiplied colors are drawn over
+	blend := map[string]interface{}{
+		"color": map[string]interface{}{"srcFactor": "one", "dstFactor": "one-minus-src-alpha", "operation": "add"},
+		"alpha": map[string]interface{}{"srcFactor": "one", "dstFactor": "one-minus-src-alpha", "operation": "add"},
+	}
+	targets := []interface{}{map[string]interface{}{"format": "rgba8unorm", "blend": blend}}
+
+	meshModule := device.Call("createShaderModule", map[string]interface{}{
+		"code": meshShaderSource,
+	})
+	r.meshPipeline = device.Call("createRenderPipeline", map[string]interface{}{
+		"layout": "auto",
+		"vertex": map[string]interface{}{
+			"module":     meshModule,
+			"entryPoint": "vs",
+			"buffers": []interface{}{
+				map[string]interface{}{
+					"arrayStride": 4 * gpu.VertexSize,
+					"attributes": []interface{}{
+						map[string]interface{}{"shaderLocation": 0, "offset": 0, "format": "float32x2"},
+						map[string]interface{}{"shaderLocation": 1, "offset": 8, "format": "float32x4"},
+					},
+				},
+			},
+		},
+		"fragment": map[string]interface{}{
+			"module":     meshModule,
+			"entryPoint": "fs",
+			"targets":    targets,
+		},
+		"primitive": map[string]interface{}{
+			"topology": "triangle-list",
+		},
+		"multisample": map[string]interface{}{
+			"count": sampleCount,
+		},
+	})
+	r.meshBindGroup = device.Call("createBindGroup", map[string]interface{}{
+		"layout": r.meshPipeline.Call("getBindGroupLayout", 0),
+		"entries": []interface{}{
+			map[string]interface{}{"binding": 0, "resource": map[string]interface{}{"buffer": r.uniforms}},
+		},
+	})
+
+	module := device.Call("createShaderModule", map[string]interface{}{
+		"code": shaderSource,
+	})
+	r.imagePipeline = device.Call("createRenderPipeline", map[string]interface{}{
+		"layout": "auto",
+		"vertex": map[string]interface{}{
+			"module":     module,
+			"entryPoint": "vs",
+		},
+		"fragment": map[string]interface{}{
+			"module":     module,
+			"entryPoint": "fs",
+			"targets":    targets,
+		},
+		"primitive": map[string]interface{}{
+			"topology": "triangle-list",
+		},
+		"multisample": map[string]interface{}{
+			"count": sampleCount,
+		},
+	})
+	return r
+}
+
+// sampleCount is the number of samples per pixel for anti-aliasing the triangles.
+const sampleCount = 4
+
+// Texture draws the layers of the scene into a texture and returns the GPUTexture, which is in the rgba8unorm format with premultiplied alpha. The texture remains owned by the renderer.
+func (r *WebGPU) Texture() js.Value {
+	queue := r.device.Get("queue")
+	encoder := r.device.Call("createCommandEncoder")
+	pass := encoder.Call("beginRenderPass", map[string]interface{}{
+		"colorAttachments": []interface{}{
+			map[string]interface{}{
+				"view":          r.msaa.Call("createView"),
+				"resolveTarget": r.texture.Call("createView"),
+				"clearValue":    map[string]interface{}{"r": 0.0, "g": 0.0, "b": 0.0, "a": 0.0},
+				"loadOp":        "clear",
+				"storeOp":       "discard",
+			},
+		},
+	})
+
+	images := 0
+	buffers := []js.Value{}
+	for _, layer := range r.Layers {
+		if layer.Image != nil {
+			texture, bindGroup := r.imageTexture(images)
+			w, h := layer.Image.Rect.Dx(), layer.Image.Rect.Dy()
+			queue.Call("writeTexture",
+				map[string]interface{}{"texture": texture},
+				jsBytes(layer.Image.Pix),
+				map[string]interface{}{"bytesPerRow": 4 * w, "rowsPerImage": h},
+				[]interface{}{w, h},
+			)
+			pass.Call("setPipeline", r.imagePipeline)
+			pass.Call("setBindGroup", 0, bindGroup)
+			pass.Call("draw", 6)
+			images++
+		} else if 0 < len(layer.Vertices) {
+			buffer := r.device.Call("createBuffer", map[string]interface{}{
+				"size":  4 * len(layer.Vertices),
+				"usage": js.Global().Get("GPUBufferUsage").Get("VERTEX").Int() | js.Global().Get("GPUBufferUsage").Get("COPY_DST").Int(),
+			})
+			queue.Call("writeBuffer", buffer, 0, jsBytes(gpu.Float32Bytes(layer.Vertices)))
+			pass.Call("setPipeline", r.meshPipeline)
+			pass.Call("setBindGroup", 0, r.meshBindGroup)
+			pass.Call("setVertexBuffer", 0, buffer)
+			pass.Call("draw", len(layer.Vertices)/gpu.VertexSize)
+			buffers = append(buffers, buffer)
+		}
+	}
+	pass.Call("end")
+	queue.Call("submit", []interface{}{encoder.Call("finish")})
+	for _, buffer := range buffers {
+		buffer.Call("destroy") // destroyed after the submitted work completes
+	}
+	return r.texture
+}
+
+// imageTexture returns the texture and bind group for the i-th raster layer, which are created on first use.
+func (r *WebGPU) imageTexture(i int) (js.Value, js.Value) {
+	if i < len(r.imageTextures) {
+		return r.imageTextures[i], r.imageBindGroup[i]
+	}
+	usage := js.Global().Get("GPUTextureUsage")
+	texture := r.device.Call("createTexture", map[string]interface{}{
+		"size":   []interface{}{r.Bounds().Dx(), r.Bounds().Dy()},
+		"format": "rgba8unorm",
+		"usage":  usage.Get("TEXTURE_BINDING").Int() | usage.Get("COPY_DST").Int(),
+	})
+	bindGroup := r.device.Call("createBindGroup", map[string]interface{}{
+		"layout": r.imagePipeline.Call("getBindGroupLayout", 0),
+		"entries": []interface{}{
+			map[string]interface{}{"binding": 0, "resource": r.sampler},
+			map[string]interface{}{"binding": 1, "resource": texture.Call("createView")},
+		},
+	})
+	r.imageTextures = append(r.imageTextures, texture)
+	r.imageBindGroup = append(r.imageBindGroup, bindGroup)
+	return texture, bindGroup
+}
+
+// Draw draws the layers of the scene into the texture and draws it to an HTML canvas element, which is configured for WebGPU on the first call.
+func (r *WebGPU) Draw(c js.Value) {
+	r.Texture()
+	if r.context.IsUndefined() {
+		r.compile(c)
+	}
+
+	encoder := r.device.Call("createCommandEncoder")
+	pass := encoder.Call("beginRenderPass", map[string]interface{}{
+		"colorAttachments": []interface{}{
+			map[string]interface{}{
+				"view":       r.context.Call("getCurrentTexture").Call("createView"),
+				"clearValue": map[string]interface{}{"r": 0.0, "g": 0.0, "b": 0.0, "a": 0.0},
+				"loadOp":     "clear",
+				"storeOp":    "store",
+			},
+		},
+	})
+	pass.Call("setPipeline", r.pipeline)
+	pass.Call("setBindGroup", 0, r.bindGroup)
+	pass.Call("draw", 6)
+	pass.Call("end")
+	r.device.Get("queue").Call("submit", []interface{}{encoder.Call("finish")})
+}
+
+func (r *WebGPU) compile(c js.Value) {
+	c.Set("width", r.Bounds().Dx())
+	c.Set("height", r.Bounds().Dy())
+	r.format = js.Global().Get("navigator").Get("gpu").Call("getPreferredCanvasFormat")
+	r.context = c.Call("getContext", "webgpu")
+	r.context.Call("configure", map[string]interface{}{
+		"device":    r.device,
+		"format":    r.format,
+		"alphaMode": "premultiplied",
+	})
+
+	module := r.device.Call("createShaderModule", map[string]interface{}{
+		"code": shaderSource,
+	})
+	r.pipeline = r.device.Call("createRenderPipeline", map[string]interface{}{
+		"layout": "auto",
+		"vertex": map[string]interface{}{
+			"module":     module,
+			"entryPoint": "vs",
+		},
+		"fragment": map[string]interface{}{
+			"module":     module,
+			"entryPoint": "fs",
+			"targets":    []interface{}{map[string]interface{}{"format": r.format}},
+		},
+		"primitive": map[string]interface{}{
+			"topology": "triangle-list",
+		},
+	})
+	r.bindGroup = r.device.Call("createBindGroup", map[string]interface{}{
+		"layout": r.pipeline.Call("getBindGroupLayout", 0),
+		"entries": []interface{}{
+			map[string]interface{}{"binding": 0, "resource": r.sampler},
+			map[string]interface{}{"binding": 1, "resource": r.texture.Call("createView")},
+		},
+	})
+}
+
+// jsBytes copies the bytes into a Uint8Array.
+func jsBytes(b []byte) js.Value {
+	data := js.Global().Get("Uint8Array").New(len(b))
+	js.CopyBytesToJS(data, b)
+	return data
+}
+
+func jsAwait(v js.Value) (result js.Value, ok bool) {
+	// COPIED FROM https://go-review.googlesource.com/c/go/+/150917/
+	if v.Type() != js.TypeObject || v.Get("then").Type() != js.TypeFunction {
+		return v, true
+	}
+
+	done := make(chan struct{})
+
+	onResolve := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		result = args[0]
+		ok = true
+		close(done)
+		return nil
+	})
+	defer onResolve.Release()
+
+	onReject := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		result = args[0]
+		ok = false
+		close(done)
+		return nil
+	})
+	defer onReject.Release()
+
+	v.Call("then", onResolve, onReject)
+	<-done
+	return
+}
+
+// meshShaderSource draws triangles with per-vertex colors, where the uniforms transform positions in millimeters to clip space, see gpu.Scene.Uniforms
+var meshShaderSource = `
+	struct Uniforms {
+		scale: vec2f,
+		offset: vec2f,
+	};
+
+	struct VertexOutput {
+		@builtin(position) position: vec4f,
+		@location(0) color: vec4f,
+	};
+
+	@group(0) @binding(0) var<uniform> uniforms: Uniforms;
+
+	@vertex
+	fn vs(@location(0) position: vec2f, @location(1) color: vec4f) -> VertexOutput {
+		var out: VertexOutput;
+		out.position = vec4f(position*uniforms.scale + uniforms.offset, 0.0, 1.0);
+		out.color = color;
+		return out;
+	}
+
+	@fragment
+	fn fs(in: VertexOutput) -> @location(0) vec4f {
+		return in.color;
+	}
+`
+
+// shaderSource draws a full-screen quad with the texture, where the first row of the image is at the top
+var shaderSource = `
+	struct VertexOutput {
+		@builtin(position) position: vec4f,
+		@location(0) uv: vec2f,
+	};
+
+	@vertex
+	fn vs(@builtin(vertex_index) i: u32) -> VertexOutput {
+		var pos = array<vec2f, 6>(
+			vec2f(-1.0, -1.0), vec2f(1.0, -1.0), vec2f(-1.0, 1.0),
+			vec2f(1.0, -1.0), vec2f(1.0, 1.0), vec2f(-1.0, 1.0),
+		);
+		var out: VertexOutput;
+		out.position = vec4f(pos[i], 0.0, 1.0);
+		out.uv = vec2f(0.5*pos[i].x + 0.5, 0.5 - 0.5*pos[i].y);
+		return out;
+	}
+
+	@group(0) @binding(0) var samp: sampler;
+	@group(0) @binding(1) var tex: texture_2d<f32>;
+
+	@fragment
+	fn fs(in: VertexOutput) -> @location(0) vec4f {
+		return textureSample(tex, samp, in.uv);
+	}
+`
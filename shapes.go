@@ -70,6 +70,40 @@ func RoundedRectangle(w, h, r float64) *Path {
 	return p
 }
 
+// RoundedRectangleCorners returns a rectangle of width w and height h with rounded corners of radius r0 to r3 for the corners at (0,0), (w,0), (w,h), and (0,h) respectively. A zero radius results in a sharp corner. When the radii of adjacent corners exceed the side length, all radii are scaled down proportionally.
+func RoundedRectangleCorners(w, h, r0, r1, r2, r3 float64) *Path {
+	if Equal(w, 0.0) || Equal(h, 0.0) {
+		return &Path{}
+	}
+
+	r0, r1, r2, r3 = math.Abs(r0), math.Abs(r1), math.Abs(r2), math.Abs(r3)
+	w, h = math.Abs(w), math.Abs(h)
+	f := math.Min(math.Min(w/(r0+r1), h/(r1+r2)), math.Min(w/(r2+r3), h/(r3+r0)))
+	if f < 1.0 {
+		r0, r1, r2, r3 = f*r0, f*r1, f*r2, f*r3
+	}
+
+	p := &Path{}
+	p.MoveTo(0.0, r0)
+	if 0.0 < r0 {
+		p.ArcTo(r0, r0, 0.0, false, true, r0, 0.0)
+	}
+	p.LineTo(w-r1, 0.0)
+	if 0.0 < r1 {
+		p.ArcTo(r1, r1, 0.0, false, true, w, r1)
+	}
+	p.LineTo(w, h-r2)
+	if 0.0 < r2 {
+		p.ArcTo(r2, r2, 0.0, false, true, w-r2, h)
+	}
+	p.LineTo(r3, h)
+	if 0.0 < r3 {
+		p.ArcTo(r3, r3, 0.0, false, true, 0.0, h-r3)
+	}
+	p.Close()
+	return p
+}
+
 // BeveledRectangle returns a rectangle of width w and height h with beveled corners at distance r from the corner.
 func BeveledRectangle(w, h, r float64) *Path {
 	if Equal(w, 0.0) || Equal(h, 0.0) {
@@ -114,6 +148,98 @@ func Ellipse(rx, ry float64) *Path {
 	return p
 }
 
+// Superellipse returns a superellipse of radii rx and ry with exponent n, i.e. |x/rx|^n + |y/ry|^n = 1, approximated by one cubic Bézier per quadrant. An exponent of 2 gives an ellipse, 1 gives a rhombus, and larger exponents approach a rectangle. n must be 1 or more.
+func Superellipse(rx, ry, n float64) *Path {
+	if Equal(rx, 0.0) || Equal(ry, 0.0) || n < 1.0 {
+		return &Path{}
+	}
+
+	// choose the control points so that the curve passes through the superellipse at 45 degrees
+	k := (8.0*math.Pow(2.0, -1.0/n) - 4.0) / 3.0
+	p := &Path{}
+	p.MoveTo(rx, 0.0)
+	p.CubeTo(rx, k*ry, k*rx, ry, 0.0, ry)
+	p.CubeTo(-k*rx, ry, -rx, k*ry, -rx, 0.0)
+	p.CubeTo(-rx, -k*ry, -k*rx, -ry, 0.0, -ry)
+	p.CubeTo(k*rx, -ry, rx, -k*ry, rx, 0.0)
+	p.Close()
+	return p
+}
+
+// Squircle returns a squircle of radius r, which is a superellipse with exponent 4.
+func Squircle(r float64) *Path {
+	return Superellipse(r, r, 4.0)
+}
+
+// Wedge returns a pie wedge of radius r between the angles theta0 and theta1 in degrees, with its apex at (0,0). If theta0 < theta1, the arc will run in a CCW direction.
+func Wedge(r, theta0, theta1 float64) *Path {
+	if Equal(r, 0.0) || Equal(theta0, theta1) {
+		return &Path{}
+	} else if 360.0 <= math.Abs(theta1-theta0) {
+		return Circle(r)
+	}
+
+	p := &Path{}
+	p.LineTo(r*math.Cos(theta0*math.Pi/180.0), r*math.Sin(theta0*math.Pi/180.0))
+	p.Arc(r, r, 0.0, theta0, theta1)
+	p.Close()
+	return p
+}
+
+// AnnulusSector returns a sector of an annulus (ring) with inner radius r0 and outer radius r1 between the angles theta0 and theta1 in degrees, centered at (0,0). If the angles span 360 degrees or more, a full annulus is returned.
+func AnnulusSector(r0, r1, theta0, theta1 float64) *Path {
+	if r1 < r0 {
+		r0, r1 = r1, r0
+	}
+	if Equal(r1, 0.0) || Equal(theta0, theta1) {
+		return &Path{}
+	} else if Equal(r0, 0.0) {
+		return Wedge(r1, theta0, theta1)
+	} else if 360.0 <= math.Abs(theta1-theta0) {
+		return Circle(r1).Append(Circle(r0).Reverse())
+	}
+
+	sin0, cos0 := math.Sincos(theta0 * math.Pi / 180.0)
+	sin1, cos1 := math.Sincos(theta1 * math.Pi / 180.0)
+	p := &Path{}
+	p.MoveTo(r1*cos0, r1*sin0)
+	p.Arc(r1, r1, 0.0, theta0, theta1)
+	p.LineTo(r0*cos1, r0*sin1)
+	p.Arc(r0, r0, 0.0, theta1, theta0)
+	p.Close()
+	return p
+}
+
+// Arrow returns an arrow of length l pointing from (0,0) in the positive x direction, with a shaft of width w and a triangular head of length hl and width hw. The head length is limited to the arrow length.
+func Arrow(l, w, hl, hw float64) *Path {
+	if Equal(l, 0.0) || Equal(hw, 0.0) {
+		return &Path{}
+	}
+
+	hl = math.Min(math.Abs(hl), math.Abs(l))
+	if l < 0.0 {
+		hl = -hl
+	}
+	w, hw = math.Abs(w)/2.0, math.Abs(hw)/2.0
+
+	p := &Path{}
+	if Equal(w, 0.0) || Equal(hl, l) {
+		p.MoveTo(l-hl, -hw)
+	} else {
+		p.MoveTo(0.0, -w)
+		p.LineTo(l-hl, -w)
+		p.LineTo(l-hl, -hw)
+	}
+	p.LineTo(l, 0.0)
+	p.LineTo(l-hl, hw)
+	if !Equal(w, 0.0) && !Equal(hl, l) {
+		p.LineTo(l-hl, w)
+		p.LineTo(0.0, w)
+	}
+	p.Close()
+	return p
+}
+
 // Triangle returns a triangle of radius r pointing upwards.
 func Triangle(r float64) *Path {
 	return RegularPolygon(3, r, true)
@@ -28,4 +28,14 @@ func TestShapes(t *testing.T) {
 	test.T(t, StarPolygon(2, 4.0, 2.0, true), &Path{})
 	test.T(t, StarPolygon(4, 4.0, 2.0, true), MustParseSVGPath("M0 4L-1.414214 1.414214L-4 0L-1.414214 -1.414214L0 -4L1.414214 -1.414214L4 0L1.414214 1.414214z"))
 	test.T(t, StarPolygon(3, 4.0, 2.0, false), MustParseSVGPath("M-3.464102 2L0 -4L3.464102 2z"))
+	test.T(t, RoundedRectangleCorners(10.0, 4.0, 1.0, 0.0, 3.0, 2.0), MustParseSVGPath("M0 1A1 1 0 0 1 1 0L10 0L10 1A3 3 0 0 1 7 4L2 4A2 2 0 0 1 0 2z"))
+	test.T(t, RoundedRectangleCorners(4.0, 4.0, 4.0, 4.0, 0.0, 0.0), MustParseSVGPath("M0 2A2 2 0 0 1 2 0A2 2 0 0 1 4 2L4 4L0 4z"))
+	test.T(t, Superellipse(2.0, 2.0, 0.5), &Path{})
+	test.T(t, Superellipse(2.0, 1.0, 1.0), MustParseSVGPath("M2 0C2 0 0 1 0 1C0 1 -2 0 -2 0C-2 0 0 -1 0 -1C0 -1 2 0 2 0z"))
+	test.T(t, Squircle(2.0).Bounds(), Rect{-2.0, -2.0, 4.0, 4.0})
+	test.T(t, Wedge(2.0, 0.0, 90.0), MustParseSVGPath("M0 0L2 0A2 2 0 0 1 0 2z"))
+	test.T(t, AnnulusSector(1.0, 2.0, 0.0, 90.0), MustParseSVGPath("M2 0A2 2 0 0 1 0 2L0 1A1 1 0 0 0 1 0z"))
+	test.T(t, AnnulusSector(1.0, 2.0, 0.0, 360.0), MustParseSVGPath("M2 0A2 2 0 0 1 -2 0A2 2 0 0 1 2 0zM1 0A1 1 0 0 0 -1 0A1 1 0 0 0 1 0z"))
+	test.T(t, Arrow(10.0, 2.0, 3.0, 4.0), MustParseSVGPath("M0 -1L7 -1L7 -2L10 0L7 2L7 1L0 1z"))
+	test.T(t, Arrow(3.0, 2.0, 5.0, 4.0), MustParseSVGPath("M0 -2L3 0L0 2z"))
 }
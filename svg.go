@@ -31,6 +31,7 @@ type svgState struct {
 	textAnchor       string
 	fontFamily       string
 	fontSize         float64
+	group            bool // element has group opacity
 }
 
 var svgDefaultState = svgState{
@@ -111,6 +112,7 @@ func (svg *svgParser) init(width, height float64, viewbox [4]float64) {
 func (svg *svgParser) push(tag string, attrs map[string]string) {
 	svg.ctx.Push()
 	svg.stateStack = append(svg.stateStack, svg.state)
+	svg.state.group = false
 	svg.elemStack = append(svg.elemStack, svgElem{tag, attrs["id"], attrs})
 }
 
@@ -120,6 +122,9 @@ func (svg *svgParser) pop() {
 		return
 	}
 	svg.elemStack = svg.elemStack[:len(svg.elemStack)-1]
+	if svg.state.group {
+		svg.ctx.EndGroup()
+	}
 	svg.state = svg.stateStack[len(svg.stateStack)-1]
 	svg.stateStack = svg.stateStack[:len(svg.stateStack)-1]
 	svg.ctx.Pop()
@@ -444,6 +449,13 @@ func (svg *svgParser) parseDefs(l *xml.Lexer) {
 			x2 := svg.parseDimension(tag.attrs["x2"], 1.0)
 			y1 := svg.parseDimension(tag.attrs["y1"], 1.0)
 			y2 := svg.parseDimension(tag.attrs["y2"], 1.0)
+			spread := PadSpread
+			switch tag.attrs["spreadMethod"] {
+			case "reflect":
+				spread = ReflectSpread
+			case "repeat":
+				spread = RepeatSpread
+			}
 
 			stops := Stops{}
 			for _, tag := range tag.content {
@@ -469,7 +481,7 @@ func (svg *svgParser) parseDefs(l *xml.Lexer) {
 				}
 				layer := &layers[len(layers)-1]
 
-				rect := layer.path.FastBounds()
+				rect := layer.path.Bounds()
 				x1t, y1t, x2t, y2t := x1, y1, x2, y2
 				if x1p {
 					x1t = (rect.X + rect.W*x1t) * 25.4 / 96.0
@@ -485,6 +497,7 @@ func (svg *svgParser) parseDefs(l *xml.Lexer) {
 				}
 				linearGradient := NewLinearGradient(Point{x1t, y1t}, Point{x2t, y2t})
 				linearGradient.Stops = stops
+				linearGradient.Spread = spread
 
 				if attr == "fill" {
 					layer.style.Fill = Paint{Gradient: linearGradient}
@@ -744,7 +757,7 @@ func (svg *svgParser) setAttribute(key, val string) {
 		}
 	case "stroke-linejoin":
 		if val == "arcs" {
-			svg.ctx.SetStrokeJoiner(ArcsJoin)
+			svg.ctx.SetStrokeJoiner(ArcsJoiner{nil, svg.state.strokeMiterLimit})
 		} else if val == "bevel" {
 			svg.ctx.SetStrokeJoiner(BevelJoin)
 		} else if val == "miter" {
@@ -756,9 +769,14 @@ func (svg *svgParser) setAttribute(key, val string) {
 		}
 	case "stroke-miterlimit":
 		svg.state.strokeMiterLimit = svg.parseDimension(val, svg.diagonal)
-		if miter, ok := svg.ctx.StrokeJoiner.(MiterJoiner); ok {
-			miter.Limit = svg.state.strokeMiterLimit
+		svg.ctx.SetStrokeMiterLimit(svg.state.strokeMiterLimit)
+	case "opacity":
+		if opacity := svg.parseNumber(val); opacity < 1.0 && !svg.state.group {
+			svg.ctx.BeginGroup(math.Max(0.0, opacity))
+			svg.state.group = true
 		}
+	case "vector-effect":
+		svg.ctx.SetNonScalingStroke(val == "non-scaling-stroke")
 	case "transform":
 		m := svg.parseTransform(val)
 		svg.ctx.ComposeView(m)
@@ -844,24 +862,41 @@ func (svg *svgParser) drawShape(tag string, attrs map[string]string) {
 		y := svg.parseDimension(attrs["y"], svg.height)
 		width := svg.parseDimension(attrs["width"], svg.width)
 		height := svg.parseDimension(attrs["height"], svg.height)
-		if attrs["rx"] == "" && attrs["ry"] == "" {
-			svg.ctx.DrawPath(x, y, Rectangle(width, height))
-		} else {
-			// TODO: handle both rx and ry
-			var r float64
-			if attrs["ry"] == "" {
-				r = svg.parseDimension(attrs["rx"], svg.width)
-			} else {
-				r = svg.parseDimension(attrs["ry"], svg.height)
-			}
-			svg.ctx.DrawPath(x, y, RoundedRectangle(width, height, r))
+		rx := svg.parseDimension(attrs["rx"], svg.width)
+		ry := svg.parseDimension(attrs["ry"], svg.height)
+		if attrs["rx"] == "" {
+			rx = ry
+		} else if attrs["ry"] == "" {
+			ry = rx
 		}
+		svg.ctx.DrawPath(x, y, svgRectangle(width, height, rx, ry))
 	case "text":
 		svg.state.textX = svg.parseDimension(attrs["x"], svg.width)
 		svg.state.textY = svg.parseDimension(attrs["y"], svg.height)
 	}
 }
 
+// svgRectangle returns a rectangle of width w and height h with elliptical corners of radii rx and ry, which are limited to half the width and height respectively.
+func svgRectangle(w, h, rx, ry float64) *Path {
+	rx, ry = math.Min(math.Abs(rx), w/2.0), math.Min(math.Abs(ry), h/2.0)
+	if Equal(rx, 0.0) || Equal(ry, 0.0) {
+		return Rectangle(w, h)
+	}
+
+	p := &Path{}
+	p.MoveTo(0.0, ry)
+	p.ArcTo(rx, ry, 0.0, false, true, rx, 0.0)
+	p.LineTo(w-rx, 0.0)
+	p.ArcTo(rx, ry, 0.0, false, true, w, ry)
+	p.LineTo(w, h-ry)
+	p.ArcTo(rx, ry, 0.0, false, true, w-rx, h)
+	p.LineTo(rx, h)
+	p.ArcTo(rx, ry, 0.0, false, true, 0.0, h-ry)
+	p.Close()
+	return p
+}
+
+// ParseSVG parses an SVG file and returns it as a canvas, so that it can be rendered to any of the renderers such as PDF or PNG. It supports the basic shapes, paths, transformations, groups with opacity, fills and strokes including dashes and markers, linear gradients, CSS styling, and basic text. The size of the canvas is given by the width and height of the SVG tag, or by its view box.
 func ParseSVG(r io.Reader) (*Canvas, error) {
 	z := parse.NewInput(r)
 	defer z.Restore()
@@ -964,6 +999,46 @@ func ParseSVG(r io.Reader) (*Canvas, error) {
 	}
 }
 
+// ParseSVGPaths parses an SVG file like ParseSVG and returns the outlines of its shapes and text as filled paths in millimeters, where strokes are converted to their outline. This is useful to use SVG files as clipping geometry or in path boolean operations. Images are ignored.
+func ParseSVGPaths(r io.Reader) ([]*Path, error) {
+	c, err := ParseSVG(r)
+	if err != nil {
+		return nil, err
+	}
+	return c.outlines(), nil
+}
+
+// outlines returns the filled paths of the drawing operations of the canvas, see ParseSVGPaths.
+func (c *Canvas) outlines() []*Path {
+	paths := []*Path{}
+	for _, zindex := range c.ZIndices() {
+		for _, l := range c.layers[zindex] {
+			if l.text != nil {
+				text := New(c.W, c.H)
+				l.text.RenderAsPath(text, l.m, 0.0)
+				paths = append(paths, text.outlines()...)
+			} else if l.path != nil {
+				if l.style.HasFill() {
+					fill := l.path
+					if l.style.FillRule != NonZero {
+						fill = fill.Settle(l.style.FillRule)
+					}
+					paths = append(paths, fill.Transform(l.m))
+				}
+				if l.style.HasStroke() {
+					stroke := l.path
+					if l.style.IsDashed() {
+						stroke = stroke.Dash(l.style.DashOffset, l.style.Dashes...)
+					}
+					stroke = stroke.Stroke(l.style.StrokeWidth, l.style.StrokeCapper, l.style.StrokeJoiner, Tolerance)
+					paths = append(paths, stroke.Transform(l.m))
+				}
+			}
+		}
+	}
+	return paths
+}
+
 type cssAttrSelector struct {
 	op   byte // empty, =, ~, |
 	attr string
@@ -0,0 +1,30 @@
+package canvas
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestParseSVGPaths(t *testing.T) {
+	paths, err := ParseSVGPaths(strings.NewReader(`<svg viewBox="0 0 96 96"><rect x="0" y="0" width="96" height="48" rx="24" ry="12"/><line x1="0" y1="72" x2="96" y2="72" fill="none" stroke="black" stroke-width="9.6"/></svg>`))
+	test.Error(t, err)
+	test.T(t, len(paths), 2)
+
+	// rectangle with elliptical corners in the top half
+	bounds := paths[0].Bounds()
+	test.Float(t, bounds.X, 0.0)
+	test.Float(t, bounds.Y, 12.7)
+	test.Float(t, bounds.W, 25.4)
+	test.Float(t, bounds.H, 12.7)
+	test.That(t, !paths[0].Fills(0.1, 25.3, NonZero), "corner must be rounded")
+	test.That(t, paths[0].Fills(6.4, 25.3, NonZero), "edge must be straight")
+
+	// stroked line
+	bounds = paths[1].Bounds()
+	test.Float(t, bounds.X, 0.0)
+	test.Float(t, bounds.Y, 5.08)
+	test.Float(t, bounds.W, 25.4)
+	test.Float(t, bounds.H, 2.54)
+}
@@ -9,6 +9,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"unicode"
 	"unicode/utf8"
 
 	"github.com/tdewolff/canvas/text"
@@ -115,6 +116,31 @@ func (orient TextOrientation) String() string {
 	return "Invalid(" + strconv.Itoa(int(orient)) + ")"
 }
 
+// TextOverflow specifies how text that doesn't fit in a text box is handled.
+type TextOverflow int
+
+// see TextOverflow
+const (
+	TextClip     TextOverflow = iota // lines that don't fit are not drawn
+	TextEllipsis                     // the last line that fits is ended with an ellipsis
+	TextShrink                       // the font size is reduced until all text fits
+	TextPaginate                     // lines that don't fit are not drawn and are continued in the next box
+)
+
+func (overflow TextOverflow) String() string {
+	switch overflow {
+	case TextClip:
+		return "Clip"
+	case TextEllipsis:
+		return "Ellipsis"
+	case TextShrink:
+		return "Shrink"
+	case TextPaginate:
+		return "Paginate"
+	}
+	return "Invalid(" + strconv.Itoa(int(overflow)) + ")"
+}
+
 // Text holds the representation of a text object.
 type Text struct {
 	lines []line
@@ -335,7 +361,7 @@ func NewTextLine(face *FontFace, s string, halign TextAlign) *Text {
 				line := line{y: y, spans: []TextSpan{}}
 				for _, item := range itemizeString(s[i:j]) {
 					direction, _ := scriptDirection(HorizontalTB, Natural, item.Script, item.Level, face.Direction)
-					glyphs := face.Font.shaper.Shape(item.Text, ppem, direction, face.Script, face.Language, face.Font.features, face.Font.variations)
+					glyphs := face.Font.shape(item.Text, ppem, direction, face.Script, face.Language)
 					width := face.textWidth(glyphs)
 					line.spans = append(line.spans, TextSpan{
 						X:         x,
@@ -485,6 +511,35 @@ func (rt *RichText) WriteFace(face *FontFace, text string) {
 	rt.SetFace(origFace)
 }
 
+// WriteSpan writes a string with the current font face changed by the arguments, so that the size, color, or decoration of a span can differ from the rest of the paragraph without creating a font face. Arguments that can be passed: *FontFace to replace the current face, float64 for the font size in points, Paint/Pattern/Gradient/*Swatch/color.Color for the fill, multiple FontDecorator to add decorations, and []FontDecorator to replace the decorations. Line breaking and baseline alignment apply across spans as for WriteFace.
+func (rt *RichText) WriteSpan(text string, args ...interface{}) {
+	face := *rt.faces[len(rt.faces)-1]
+	for _, iarg := range args {
+		switch arg := iarg.(type) {
+		case *FontFace:
+			face = *arg
+		case float64:
+			face.Size = arg * mmPerPt
+			face.MmPerEm = face.Size / float64(face.Font.Head.UnitsPerEm)
+		case Paint:
+			face.Fill = arg
+		case Pattern:
+			face.Fill = Paint{Pattern: arg}
+		case Gradient:
+			face.Fill = Paint{Gradient: arg}
+		case *Swatch:
+			face.Fill = swatchPaint(arg)
+		case color.Color:
+			face.Fill = Paint{Color: rgbaColor(arg)}
+		case FontDecorator:
+			face.Deco = append(face.Deco[:len(face.Deco):len(face.Deco)], arg) // don't modify the decorations of other faces
+		case []FontDecorator:
+			face.Deco = arg
+		}
+	}
+	rt.WriteFace(&face, text)
+}
+
 // WriteCanvas writes an inline canvas object.
 func (rt *RichText) WriteCanvas(c *Canvas, valign VerticalAlign) {
 	width, height := c.Size()
@@ -600,9 +655,13 @@ func (rt *RichText) ToText(width, height float64, halign, valign TextAlign, inde
 	clusterOffset := uint32(0)
 	glyphIndices := indexer{} // indexes glyphs into runs
 	glyphs := make([]text.Glyph, 0, len(logRunes))
-	for _, run := range runs {
-		ppem := run.Face.PPEM(DefaultResolution)
-		glyphRun := run.Face.Font.shaper.Shape(run.Text, ppem, run.Direction, run.Script, run.Face.Language, run.Face.Font.features, run.Face.Font.variations)
+	glyphRuns := make([][]text.Glyph, len(runs))
+	parallelFor(len(runs), func(k int) {
+		run := runs[k]
+		glyphRuns[k] = run.Face.Font.shape(run.Text, run.Face.PPEM(DefaultResolution), run.Direction, run.Script, run.Face.Language)
+	})
+	for k, run := range runs {
+		glyphRun := glyphRuns[k]
 		for i, glyph := range glyphRun {
 			glyphRun[i].SFNT = run.Face.Font.SFNT
 			glyphRun[i].Size = run.Face.Size
@@ -942,6 +1001,110 @@ func (rt *RichText) ToText(width, height float64, halign, valign TextAlign, inde
 	return t
 }
 
+// Slice returns a new rich text of the text between start and end measured in bytes, keeping the font faces and inline objects. This can be used to continue text in a new text box, see ToTextBox.
+func (rt *RichText) Slice(start, end int) *RichText {
+	log := rt.String()
+	start = max(0, min(start, len(log)))
+	end = max(start, min(end, len(log)))
+
+	i := utf8.RuneCountInString(log[:start]) // index into runes
+	objectOffset := strings.Count(log[:start], "\uFFFC")
+	rt2 := NewRichText(rt.faces[rt.locs.index(i)])
+	rt2.mode = rt.mode
+	rt2.orient = rt.orient
+	rt2.defaultFace = rt.defaultFace
+	for _, r := range log[start:end] {
+		rt2.SetFace(rt.faces[rt.locs.index(i)])
+		rt2.WriteRune(r)
+		if r == '\uFFFC' {
+			rt2.objects = append(rt2.objects, rt.objects[objectOffset])
+			objectOffset++
+		}
+		i++
+	}
+	return rt2
+}
+
+// withScale returns a copy of the rich text with all font sizes scaled by f.
+func (rt *RichText) withScale(f float64) *RichText {
+	rt2 := rt.Slice(0, rt.Len())
+	scaled := map[*FontFace]*FontFace{}
+	for i, face := range rt2.faces {
+		if _, ok := scaled[face]; !ok {
+			face2 := *face
+			face2.Size *= f
+			face2.MmPerEm *= f
+			scaled[face] = &face2
+		}
+		rt2.faces[i] = scaled[face]
+	}
+	return rt2
+}
+
+// ToTextBox is like ToText, but handles text that doesn't fit the box according to the overflow mode. It returns the text and the number of bytes of the rich text that fitted in the box, so that callers can continue the remainder in another box using Slice. The shrink mode always fits all text unless the font size would need to be reduced below 1% of the original.
+func (rt *RichText) ToTextBox(width, height float64, halign, valign TextAlign, indent, lineStretch float64, overflow TextOverflow) (*Text, int) {
+	t := rt.ToText(width, height, halign, valign, indent, lineStretch)
+	n := len(t.Text)
+	if n == rt.Len() && !t.Overflows {
+		return t, n
+	}
+
+	switch overflow {
+	case TextEllipsis:
+		// find the longest prefix that fits with an ellipsis appended
+		log := rt.String()
+		lo, hi := 0, n
+		for lo < hi {
+			mid := (lo + hi + 1) / 2
+			for 0 < mid && mid < len(log) && !utf8.RuneStart(log[mid]) {
+				mid--
+			}
+			if mid <= lo {
+				break
+			}
+			if rt.ellipsize(mid).fits(width, height, halign, valign, indent, lineStretch) {
+				lo = mid
+			} else {
+				hi = mid - 1
+			}
+		}
+		n = len(strings.TrimRightFunc(log[:lo], unicode.IsSpace))
+		t = rt.ellipsize(n).ToText(width, height, halign, valign, indent, lineStretch)
+	case TextShrink:
+		lo, hi := 0.01, 1.0
+		if !rt.withScale(lo).fits(width, height, halign, valign, indent, lineStretch) {
+			break
+		}
+		for i := 0; i < 12; i++ {
+			mid := (lo + hi) / 2.0
+			if rt.withScale(mid).fits(width, height, halign, valign, indent, lineStretch) {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		t = rt.withScale(lo).ToText(width, height, halign, valign, indent, lineStretch)
+		n = len(t.Text)
+	case TextPaginate:
+		// skip whitespace at the break so that the next box starts with text
+		log := rt.String()
+		n = len(log) - len(strings.TrimLeftFunc(log[n:], unicode.IsSpace))
+	}
+	return t, n
+}
+
+// ellipsize returns the rich text of the first n bytes with trailing whitespace removed and an ellipsis appended.
+func (rt *RichText) ellipsize(n int) *RichText {
+	rt2 := rt.Slice(0, len(strings.TrimRightFunc(rt.String()[:n], unicode.IsSpace)))
+	rt2.WriteString("…")
+	return rt2
+}
+
+func (rt *RichText) fits(width, height float64, halign, valign TextAlign, indent, lineStretch float64) bool {
+	t := rt.ToText(width, height, halign, valign, indent, lineStretch)
+	return len(t.Text) == rt.Len() && !t.Overflows
+}
+
 // String returns the content of the text box.
 func (t *Text) String() string {
 	return t.Text
@@ -1005,7 +1168,7 @@ func (t *Text) OutlineBounds() Rect {
 			// TODO: vertical text
 			p, _, err := span.Face.toPath(span.Glyphs, span.Face.PPEM(DefaultResolution))
 			if err != nil {
-				panic(err)
+				continue
 			}
 			spanBounds := p.Bounds()
 			spanBounds = spanBounds.Move(Point{span.X, -line.y})
@@ -1195,7 +1358,21 @@ func (t *Text) WalkSpans(callback func(float64, float64, TextSpan)) {
 	}
 }
 
-// RenderAsPath renders the text and its decorations converted to paths, calling r.RenderPath on the renderer. Note that text lines are drawn downwards starting at the origin, that is along negative Y. The origin is thus the top-left corner of the text box.
+// outlineErr returns the first error that occurs when reading the glyph outlines of the text, such as for fonts with malformed glyph data.
+func (t *Text) outlineErr() error {
+	for _, line := range t.lines {
+		for _, span := range line.spans {
+			if span.IsText() {
+				if _, _, err := span.Face.toPath(span.Glyphs, span.Face.PPEM(DefaultResolution)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// RenderAsPath renders the text and its decorations converted to paths, calling r.RenderPath on the renderer. Spans whose glyph outlines cannot be read are skipped. Note that text lines are drawn downwards starting at the origin, that is along negative Y. The origin is thus the top-left corner of the text box.
 func (t *Text) RenderAsPath(r Renderer, m Matrix, resolution Resolution) {
 	t.WalkDecorations(func(paint Paint, p *Path) {
 		style := DefaultStyle
@@ -1203,6 +1380,25 @@ func (t *Text) RenderAsPath(r Renderer, m Matrix, resolution Resolution) {
 		r.RenderPath(p, style, m)
 	})
 
+	// convert glyphs to paths concurrently
+	type spanPath struct {
+		p   *Path
+		err error
+	}
+	spans := []*TextSpan{}
+	for i := range t.lines {
+		for j := range t.lines[i].spans {
+			if span := &t.lines[i].spans[j]; span.IsText() {
+				spans = append(spans, span)
+			}
+		}
+	}
+	paths := make([]spanPath, len(spans))
+	parallelFor(len(spans), func(k int) {
+		paths[k].p, _, paths[k].err = spans[k].Face.toPath(spans[k].Glyphs, spans[k].Face.PPEM(resolution))
+	})
+
+	k := 0
 	for _, line := range t.lines {
 		for _, span := range line.spans {
 			x, y := span.X, -line.y
@@ -1213,9 +1409,10 @@ func (t *Text) RenderAsPath(r Renderer, m Matrix, resolution Resolution) {
 			if span.IsText() {
 				style := DefaultStyle
 				style.Fill = span.Face.Fill
-				p, _, err := span.Face.toPath(span.Glyphs, span.Face.PPEM(resolution))
+				p, err := paths[k].p, paths[k].err
+				k++
 				if err != nil {
-					panic(err)
+					continue // malformed glyph data, see Context.Err
 				}
 				if span.Rotation != 0.0 {
 					p = p.Transform(Identity.Rotate(float64(span.Rotation)))
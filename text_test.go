@@ -142,6 +142,40 @@ func TestRichText2(t *testing.T) {
 	rt.ToText(100.0, 100.0, Left, Top, 0.0, 0.0)
 }
 
+func TestRichTextSpan(t *testing.T) {
+	family := NewFontFamily("dejavu-serif")
+	if err := family.LoadFontFile("resources/DejaVuSerif.ttf", FontRegular); err != nil {
+		test.Error(t, err)
+	}
+	pt := ptPerMm * float64(family.fonts[FontRegular].Head.UnitsPerEm)
+	face := family.Face(pt, Black, FontRegular, FontNormal)
+
+	rt := NewRichText(face)
+	rt.WriteString("a")
+	rt.WriteSpan("b", 1.5*pt, Red, FontUnderline)
+	rt.WriteString("c")
+	test.T(t, len(face.Deco), 0)
+	test.T(t, rt.faces[len(rt.faces)-1], face)
+
+	span := rt.faces[1]
+	test.Float(t, span.Size, 1.5*face.Size)
+	test.Float(t, span.MmPerEm, 1.5)
+	test.T(t, span.Fill, Paint{Color: Red})
+	test.T(t, span.Deco, []FontDecorator{FontUnderline})
+
+	rt.WriteSpan("d", span, []FontDecorator{})
+	test.T(t, rt.faces[3].Fill, Paint{Color: Red})
+	test.That(t, !rt.faces[3].HasDecoration())
+	test.T(t, span.Deco, []FontDecorator{FontUnderline})
+
+	// the baseline is aligned to the largest span
+	text := rt.ToText(4096.0, 4096.0, Left, Top, 0.0, 0.0)
+	test.T(t, len(text.lines), 1)
+	top, ascent, _, _ := text.lines[0].Heights(0.0)
+	test.Float(t, top, 1901*1.5)
+	test.Float(t, ascent, 1901*1.5)
+}
+
 func TestTextBounds(t *testing.T) {
 	family := NewFontFamily("dejavu-serif")
 	if err := family.LoadFontFile("resources/DejaVuSerif.ttf", FontRegular); err != nil {
@@ -190,3 +224,38 @@ func TestTextBox(t *testing.T) {
 	ctx.DrawText(0, 0, NewTextBox(face, "\ntext", 100, 100, Left, Top, 0, 0))
 	ctx.DrawText(0, 0, NewTextBox(face, "text\n\ntext2", 100, 100, Left, Top, 0, 0))
 }
+
+func TestTextBoxOverflow(t *testing.T) {
+	family := NewFontFamily("dejavu-serif")
+	if err := family.LoadFontFile("resources/DejaVuSerif.ttf", FontRegular); err != nil {
+		test.Error(t, err)
+	}
+	face := family.Face(12.0, Black, FontRegular, FontNormal)
+	rt := NewRichText(face)
+	rt.WriteString("The quick brown fox jumps over the lazy dog")
+	width, height := 20.0, 10.0
+
+	text, n := rt.ToTextBox(width, height, Left, Top, 0.0, 0.0, TextClip)
+	test.T(t, text.Lines(), 2)
+	test.T(t, n, 15)
+	test.String(t, text.Text, "The quick brown")
+
+	text, n = rt.ToTextBox(width, height, Left, Top, 0.0, 0.0, TextPaginate)
+	test.T(t, n, 16)
+	test.String(t, rt.Slice(n, rt.Len()).String(), "fox jumps over the lazy dog")
+
+	text, n = rt.ToTextBox(width, height, Left, Top, 0.0, 0.0, TextEllipsis)
+	test.T(t, n, 15)
+	test.String(t, text.Text, "The quick brown…")
+
+	text, n = rt.ToTextBox(width, height, Left, Top, 0.0, 0.0, TextShrink)
+	test.T(t, n, rt.Len())
+	test.That(t, !text.Overflows)
+	test.That(t, text.MostCommonFontFace().Size < face.Size)
+
+	c := New(100, 100)
+	ctx := NewContext(c)
+	n = ctx.DrawTextBox(Rect{10.0, 10.0, width, height}, rt, Left, Top, TextPaginate)
+	test.T(t, n, 16)
+	test.T(t, len(c.layers[0]), 1)
+}
@@ -0,0 +1,58 @@
+package canvas
+
+import (
+	"context"
+	"expvar"
+	"runtime/trace"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	tracing      atomic.Bool
+	traceOnce    sync.Once
+	traceMetrics *expvar.Map
+)
+
+// SetTracing enables or disables tracing of the major phases of path processing: flattening, finding intersections, settling, boolean operations, stroking and rasterizing. When enabled, each phase is recorded as a runtime/trace region (visible with `go tool trace` when a trace is being collected) and counted in the expvar map "canvas", which holds the number of calls and the total duration in nanoseconds for each phase, e.g. "flatten.calls" and "flatten.ns". If "canvas" is already published as a map it is reused, and if it is published as another type the metrics are not published.
+func SetTracing(enabled bool) {
+	if enabled {
+		traceOnce.Do(func() {
+			traceMetrics = publishedMap("canvas")
+		})
+	}
+	tracing.Store(enabled)
+}
+
+// publishedMap returns the expvar map published under the given name, publishing a new map if the name is not yet in use. Unlike expvar.NewMap it does not panic when the name is taken, in which case it returns the existing map or an unpublished map if the existing variable is not a map.
+func publishedMap(name string) *expvar.Map {
+	v := expvar.Get(name)
+	if v == nil {
+		return expvar.NewMap(name)
+	} else if m, ok := v.(*expvar.Map); ok {
+		return m
+	}
+	return new(expvar.Map).Init()
+}
+
+// Tracing returns whether tracing is enabled, see SetTracing.
+func Tracing() bool {
+	return tracing.Load()
+}
+
+func noopTraceEnd() {}
+
+// traceRegion starts a trace region for the given phase when tracing is enabled and returns the function that ends it. It is to be used as `defer traceRegion("phase")()`.
+func traceRegion(phase string) func() {
+	if !tracing.Load() {
+		return noopTraceEnd
+	}
+	start := time.Now()
+	region := trace.StartRegion(context.Background(), "canvas."+phase)
+	return func() {
+		region.End()
+		traceMetrics.Add(phase+".calls", 1)
+		traceMetrics.Add(phase+".ns", int64(time.Since(start)))
+	}
+}
@@ -0,0 +1,125 @@
+package canvas
+
+import (
+	"math"
+)
+
+// TurtleState is the state of a turtle, which is its position, its heading in degrees counter clockwise from the X axis, and whether the pen is down.
+type TurtleState struct {
+	Position Point
+	Heading  float64
+	Pen      bool // pen is down
+}
+
+// Turtle is a turtle graphics front-end for building paths, which is a turtle that moves and turns relative to its own position and heading and draws its trail while its pen is down. It is useful for generative art, such as L-systems, and for teaching.
+type Turtle struct {
+	TurtleState
+	stack []TurtleState
+	p     *Path
+}
+
+// NewTurtle returns a new turtle at the origin heading along the X axis with its pen down.
+func NewTurtle() *Turtle {
+	return &Turtle{
+		TurtleState: TurtleState{Pen: true},
+		p:           &Path{},
+	}
+}
+
+// Path returns the path drawn by the turtle.
+func (t *Turtle) Path() *Path {
+	return t.p
+}
+
+// moveTo starts a new subpath at the turtle's position unless the path already ends there.
+func (t *Turtle) moveTo() {
+	if t.p.Empty() || t.p.d[len(t.p.d)-1] == CloseCmd || !t.p.Pos().Equals(t.Position) {
+		t.p.MoveTo(t.Position.X, t.Position.Y)
+	}
+}
+
+// Forward moves the turtle forward by distance d along its heading, drawing a line if the pen is down. A negative distance moves the turtle backward.
+func (t *Turtle) Forward(d float64) {
+	t.Goto(t.Position.Add(PolarPoint(t.Heading*math.Pi/180.0, d)))
+}
+
+// Backward moves the turtle backward by distance d, drawing a line if the pen is down. The heading is unchanged.
+func (t *Turtle) Backward(d float64) {
+	t.Forward(-d)
+}
+
+// Goto moves the turtle to pos without changing its heading, drawing a line if the pen is down.
+func (t *Turtle) Goto(pos Point) {
+	if t.Pen {
+		t.moveTo()
+		t.p.LineTo(pos.X, pos.Y)
+	}
+	t.Position = pos
+}
+
+// Left turns the turtle counter clockwise by angle in degrees.
+func (t *Turtle) Left(angle float64) {
+	t.Heading = math.Mod(t.Heading+angle, 360.0)
+	if t.Heading < 0.0 {
+		t.Heading += 360.0
+	}
+}
+
+// Right turns the turtle clockwise by angle in degrees.
+func (t *Turtle) Right(angle float64) {
+	t.Left(-angle)
+}
+
+// Arc moves the turtle along a circular arc with the given radius while turning by angle in degrees, drawing the arc if the pen is down. A positive angle turns counter clockwise (to the left) and a negative angle turns clockwise (to the right).
+func (t *Turtle) Arc(radius, angle float64) {
+	if radius == 0.0 || angle == 0.0 {
+		t.Left(angle)
+		return
+	}
+	radius = math.Abs(radius)
+	theta0 := t.Heading - 90.0
+	if angle < 0.0 {
+		theta0 = t.Heading + 90.0
+	}
+	theta1 := theta0 + angle
+	center := t.Position.Sub(PolarPoint(theta0*math.Pi/180.0, radius))
+	if t.Pen {
+		t.moveTo()
+		t.p.Arc(radius, radius, 0.0, theta0, theta1)
+	}
+	t.Position = center.Add(PolarPoint(theta1*math.Pi/180.0, radius))
+	t.Left(angle)
+}
+
+// PenUp lifts the pen so that the turtle moves without drawing.
+func (t *Turtle) PenUp() {
+	t.Pen = false
+}
+
+// PenDown lowers the pen so that the turtle draws when it moves.
+func (t *Turtle) PenDown() {
+	t.Pen = true
+}
+
+// Close closes the current subpath with a line back to where the pen was put down, and moves the turtle there.
+func (t *Turtle) Close() {
+	if !t.Pen || t.p.Empty() {
+		return
+	}
+	t.p.Close()
+	t.Position = t.p.Pos()
+}
+
+// Push saves the current turtle state to the stack. It must be followed by a Pop to restore the state.
+func (t *Turtle) Push() {
+	t.stack = append(t.stack, t.TurtleState)
+}
+
+// Pop restores the last pushed turtle state, which moves the turtle back without drawing. If there are no states on the stack, this will do nothing.
+func (t *Turtle) Pop() {
+	if len(t.stack) == 0 {
+		return
+	}
+	t.TurtleState = t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+}
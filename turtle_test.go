@@ -0,0 +1,52 @@
+package canvas
+
+import (
+	"testing"
+
+	"github.com/tdewolff/test"
+)
+
+func TestTurtle(t *testing.T) {
+	turtle := NewTurtle()
+	for i := 0; i < 3; i++ {
+		turtle.Forward(2.0)
+		turtle.Left(90.0)
+	}
+	turtle.Close()
+	test.T(t, turtle.Path(), MustParseSVGPath("M0 0L2 0L2 2L0 2z"))
+	test.T(t, turtle.Position, Point{0.0, 0.0})
+	test.Float(t, turtle.Heading, 270.0)
+
+	turtle = NewTurtle()
+	turtle.Right(90.0)
+	turtle.Backward(1.0)
+	turtle.PenUp()
+	turtle.Forward(2.0)
+	turtle.PenDown()
+	turtle.Right(90.0)
+	turtle.Forward(1.0)
+	test.T(t, turtle.Path(), MustParseSVGPath("M0 0L0 1M0 -1L-1 -1"))
+	test.Float(t, turtle.Heading, 180.0)
+
+	// branches
+	turtle = NewTurtle()
+	turtle.Forward(1.0)
+	turtle.Push()
+	turtle.Left(90.0)
+	turtle.Forward(1.0)
+	turtle.Pop()
+	turtle.Forward(1.0)
+	turtle.Pop()
+	test.T(t, turtle.Path(), MustParseSVGPath("M0 0L1 0L1 1M1 0L2 0"))
+	test.T(t, turtle.TurtleState, TurtleState{Point{2.0, 0.0}, 0.0, true})
+
+	// arcs
+	turtle = NewTurtle()
+	turtle.Arc(1.0, 180.0)
+	test.T(t, turtle.Position, Point{0.0, 2.0})
+	test.Float(t, turtle.Heading, 180.0)
+	turtle.Arc(1.0, -90.0)
+	test.T(t, turtle.Position, Point{-1.0, 3.0})
+	test.Float(t, turtle.Heading, 90.0)
+	test.T(t, turtle.Path(), MustParseSVGPath("M0 0A1 1 0 0 1 0 2A1 1 0 0 0 -1 3"))
+}
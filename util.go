@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"image/color"
 	"math"
+	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/tdewolff/minify/v2"
 	"golang.org/x/image/math/fixed"
@@ -13,6 +16,33 @@ import (
 
 const minNormalFloat64 = 0x1p-1022
 
+// minParallelIterations is the number of iterations below which parallelFor runs inline, since starting goroutines costs more than it gains for short loops such as the runs of a single text label.
+const minParallelIterations = 8
+
+// parallelFor calls f for every i in [0,n), distributing the calls over at most GOMAXPROCS goroutines. Loops of fewer than minParallelIterations iterations run on the calling goroutine.
+func parallelFor(n int, f func(int)) {
+	workers := min(n, runtime.GOMAXPROCS(0))
+	if n < minParallelIterations || workers <= 1 {
+		for i := 0; i < n; i++ {
+			f(i)
+		}
+		return
+	}
+
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := int(next.Add(1)) - 1; i < n; i = int(next.Add(1)) - 1 {
+				f(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
 // Epsilon is the smallest number below which we assume the value to be zero. This is to avoid numerical floating point issues.
 var Epsilon = 1e-10
 
@@ -666,6 +696,85 @@ func (m Matrix) ToSVG(h float64) string {
 
 ////////////////////////////////////////////////////////////////
 
+// Homography is used for projective transformations, which unlike affine transformations can map a rectangle onto an arbitrary quadrilateral, as is the case for perspective. Points are transformed as homogeneous coordinates (x,y,1) and divided by the resulting third coordinate.
+type Homography [3][3]float64
+
+// SquareToQuad returns the projective transformation that maps the unit square's corners (0,0), (1,0), (1,1), and (0,1) to the quadrilateral's corners q[0], q[1], q[2], and q[3] respectively. The quadrilateral must not be degenerate, see IsDegenerateQuad, otherwise the transformation has NaN or infinite values.
+func SquareToQuad(q [4]Point) Homography {
+	sx := q[0].X - q[1].X + q[2].X - q[3].X
+	sy := q[0].Y - q[1].Y + q[2].Y - q[3].Y
+	if Equal(sx, 0.0) && Equal(sy, 0.0) {
+		// parallelogram
+		return Homography{
+			{q[1].X - q[0].X, q[3].X - q[0].X, q[0].X},
+			{q[1].Y - q[0].Y, q[3].Y - q[0].Y, q[0].Y},
+			{0.0, 0.0, 1.0},
+		}
+	}
+
+	dx1, dy1 := q[1].X-q[2].X, q[1].Y-q[2].Y
+	dx2, dy2 := q[3].X-q[2].X, q[3].Y-q[2].Y
+	det := dx1*dy2 - dx2*dy1
+	g := (sx*dy2 - dx2*sy) / det
+	h := (dx1*sy - sx*dy1) / det
+	return Homography{
+		{q[1].X - q[0].X + g*q[1].X, q[3].X - q[0].X + h*q[3].X, q[0].X},
+		{q[1].Y - q[0].Y + g*q[1].Y, q[3].Y - q[0].Y + h*q[3].Y, q[0].Y},
+		{g, h, 1.0},
+	}
+}
+
+// IsDegenerateQuad returns true if three consecutive corners of the quadrilateral are collinear, such as when corners coincide or the quadrilateral has zero area. No projective transformation maps the unit square onto such a quadrilateral.
+func IsDegenerateQuad(q [4]Point) bool {
+	for i := range q {
+		p0, p1, p2 := q[(i+3)%4], q[i], q[(i+1)%4]
+		if Equal(p1.Sub(p0).PerpDot(p2.Sub(p1)), 0.0) {
+			return true
+		}
+	}
+	return false
+}
+
+// Dot returns the dot product between the matrix and the given vector, i.e. applying the transformation.
+func (m Homography) Dot(p Point) Point {
+	w := m[2][0]*p.X + m[2][1]*p.Y + m[2][2]
+	return Point{
+		(m[0][0]*p.X + m[0][1]*p.Y + m[0][2]) / w,
+		(m[1][0]*p.X + m[1][1]*p.Y + m[1][2]) / w,
+	}
+}
+
+// IsAffine returns true if the transformation is affine, i.e. it maps rectangles to parallelograms.
+func (m Homography) IsAffine() bool {
+	return Equal(m[2][0], 0.0) && Equal(m[2][1], 0.0)
+}
+
+// Inv returns the matrix inverse. It panics if the matrix is singular, such as for the transformation of a degenerate quadrilateral.
+func (m Homography) Inv() Homography {
+	c00 := m[1][1]*m[2][2] - m[1][2]*m[2][1]
+	c01 := m[1][2]*m[2][0] - m[1][0]*m[2][2]
+	c02 := m[1][0]*m[2][1] - m[1][1]*m[2][0]
+	det := m[0][0]*c00 + m[0][1]*c01 + m[0][2]*c02
+	if Equal(det, 0.0) {
+		panic("determinant of projective transformation matrix is zero")
+	}
+	return Homography{{
+		c00 / det,
+		(m[0][2]*m[2][1] - m[0][1]*m[2][2]) / det,
+		(m[0][1]*m[1][2] - m[0][2]*m[1][1]) / det,
+	}, {
+		c01 / det,
+		(m[0][0]*m[2][2] - m[0][2]*m[2][0]) / det,
+		(m[0][2]*m[1][0] - m[0][0]*m[1][2]) / det,
+	}, {
+		c02 / det,
+		(m[0][1]*m[2][0] - m[0][0]*m[2][1]) / det,
+		(m[0][0]*m[1][1] - m[0][1]*m[1][0]) / det,
+	}}
+}
+
+////////////////////////////////////////////////////////////////
+
 // Numerically stable quadratic formula, lowest root is returned first, see https://math.stackexchange.com/a/2007723
 func solveQuadraticFormula(a, b, c float64) (float64, float64) {
 	if Equal(a, 0.0) {
@@ -833,6 +942,34 @@ func gaussLegendre7(f func(float64) float64, a, b float64) float64 {
 	return c * (0.129485*(Qd1+Qd7) + 0.279705*(Qd2+Qd6) + 0.381830*(Qd3+Qd5) + 0.417959*Qd4)
 }
 
+// Gauss-Legendre quadrature integration from a to b with n=7 using nodes and weights at full precision, so that the error goes to zero when subdividing
+func gaussLegendre7Full(f func(float64) float64, a, b float64) float64 {
+	c := (b - a) / 2.0
+	d := (a + b) / 2.0
+	Qd1 := f(-0.9491079123427585*c + d)
+	Qd2 := f(-0.7415311855993945*c + d)
+	Qd3 := f(-0.4058451513773972*c + d)
+	Qd4 := f(d)
+	Qd5 := f(0.4058451513773972*c + d)
+	Qd6 := f(0.7415311855993945*c + d)
+	Qd7 := f(0.9491079123427585*c + d)
+	return c * (0.1294849661688697*(Qd1+Qd7) + 0.2797053914892766*(Qd2+Qd6) + 0.3818300505051189*(Qd3+Qd5) + 0.4179591836734694*Qd4)
+}
+
+// Gauss-Legendre quadrature integration from a to b with n=7, recursively halving the interval until the difference with the integration of both halves is below tolerance
+func gaussLegendreAdaptive(f func(float64) float64, a, b, tolerance float64) float64 {
+	return gaussLegendreAdaptiveStep(f, a, b, gaussLegendre7Full(f, a, b), tolerance, 20)
+}
+
+func gaussLegendreAdaptiveStep(f func(float64) float64, a, b, whole, tolerance float64, depth int) float64 {
+	m := (a + b) / 2.0
+	left, right := gaussLegendre7Full(f, a, m), gaussLegendre7Full(f, m, b)
+	if depth == 0 || math.Abs(left+right-whole) <= tolerance {
+		return left + right
+	}
+	return gaussLegendreAdaptiveStep(f, a, m, left, tolerance/2.0, depth-1) + gaussLegendreAdaptiveStep(f, m, b, right, tolerance/2.0, depth-1)
+}
+
 //func lookupMin(f func(float64) float64, xmin, xmax float64) float64 {
 //	const MaxIterations = 1000
 //	min := math.Inf(1)
@@ -9,6 +9,18 @@ import (
 	"github.com/tdewolff/test"
 )
 
+func TestParallelFor(t *testing.T) {
+	for _, n := range []int{0, 1, minParallelIterations - 1, 100} {
+		visited := make([]int, n)
+		parallelFor(n, func(i int) {
+			visited[i]++
+		})
+		for i := range visited {
+			test.T(t, visited[i], 1, fmt.Sprint(n, i))
+		}
+	}
+}
+
 func TestAngleNorm(t *testing.T) {
 	var tests = []struct {
 		theta float64
@@ -127,6 +139,49 @@ func TestCSSColor(t *testing.T) {
 	test.String(t, CSSColor(color.RGBA{85, 85, 17, 85}).String(), "rgba(255,255,51,.33333333)")
 }
 
+func TestColorModels(t *testing.T) {
+	test.T(t, HSL(0.0, 1.0, 0.5), Red)
+	test.T(t, HSL(120.0, 1.0, 0.25), Green)
+	test.T(t, HSLA(240.0, 1.0, 0.5, 0.5), color.RGBA{0, 0, 128, 128})
+	test.T(t, Lab(100.0, 0.0, 0.0), White)
+	test.T(t, OKLCH(0.0, 0.0, 0.0), Black)
+
+	for _, col := range []color.RGBA{Red, Orange, Teal, Purple, Gray} {
+		test.T(t, HSL(ToHSL(col)), col)
+		test.T(t, Lab(ToLab(col)), col)
+		test.T(t, OKLCH(ToOKLCH(col)), col)
+	}
+
+	l, _, _ := ToLab(Gray)
+	test.FloatDiff(t, l, 53.585, 1e-3)
+}
+
+func TestInterpolateColor(t *testing.T) {
+	test.T(t, InterpolateColor(Red, Blue, 0.5, InterpolateSRGB), color.RGBA{127, 0, 127, 255})
+	test.T(t, InterpolateColor(Red, Blue, 0.5, InterpolateLinearRGB), color.RGBA{188, 0, 188, 255})
+	test.T(t, InterpolateColor(Red, Lime, 0.5, InterpolateHSL), Yellow)
+	test.T(t, InterpolateColor(Red, Blue, 0.0, InterpolateOKLCH), Red)
+	test.T(t, InterpolateColor(Red, Blue, 1.0, InterpolateOKLab), Blue)
+	test.T(t, InterpolateColor(Red, Transparent, 0.5, InterpolateOKLab), color.RGBA{128, 0, 0, 128})
+	test.T(t, InterpolateColor(Red, Lime, 0.5, InterpolateHSL|LongerHue), Blue)
+	test.T(t, InterpolateColor(Red, Lime, 0.5, InterpolateHSL|IncreasingHue), Yellow)
+	test.T(t, InterpolateColor(Lime, Red, 0.5, InterpolateHSL|IncreasingHue), Blue)
+	test.T(t, InterpolateColor(Red, Lime, 0.5, InterpolateHSL|DecreasingHue), Blue)
+	test.String(t, (InterpolateOKLCH | LongerHue).String(), "OKLCH|LongerHue")
+
+	// perceptual interpolation doesn't darken the midpoint
+	l0, _, _ := ToOKLCH(InterpolateColor(Red, Lime, 0.5, InterpolateSRGB))
+	l1, _, _ := ToOKLCH(InterpolateColor(Red, Lime, 0.5, InterpolateOKLCH))
+	test.That(t, l0 < l1)
+
+	stops := Stops{}
+	stops.Add(0.0, Red)
+	stops.Add(1.0, Blue)
+	test.T(t, len(stops.Resample(InterpolateSRGB)), 2)
+	test.T(t, len(stops.Resample(InterpolateOKLab)), 9)
+	test.T(t, stops.Resample(InterpolateOKLab).At(0.5), stops.Interpolate(0.5, InterpolateOKLab))
+}
+
 func TestToFromFixed(t *testing.T) {
 	test.T(t, fromP26_6(toP26_6(Point{3.0, 5.0})), Point{3.0, 5.0})
 	test.Float(t, fromI26_6(toI26_6(7.0)), 7.0)
@@ -269,6 +324,26 @@ func TestMatrix(t *testing.T) {
 	test.String(t, Identity.Rotate(45).Scale(2.0, 0.0).Rotate(-45).ToSVG(10.0), "matrix(1,-1,-1,1,0,10)")
 }
 
+func TestHomography(t *testing.T) {
+	q := [4]Point{{0.0, 0.0}, {4.0, 1.0}, {3.0, 3.0}, {1.0, 2.0}}
+	m := SquareToQuad(q)
+	test.That(t, !m.IsAffine())
+	test.T(t, m.Dot(Point{0.0, 0.0}), q[0])
+	test.T(t, m.Dot(Point{1.0, 0.0}), q[1])
+	test.T(t, m.Dot(Point{1.0, 1.0}), q[2])
+	test.T(t, m.Dot(Point{0.0, 1.0}), q[3])
+	test.T(t, m.Inv().Dot(q[2]), Point{1.0, 1.0})
+	test.T(t, m.Inv().Dot(m.Dot(Point{0.3, 0.6})), Point{0.3, 0.6})
+
+	m = SquareToQuad([4]Point{{1.0, 1.0}, {3.0, 1.0}, {4.0, 2.0}, {2.0, 2.0}})
+	test.That(t, m.IsAffine())
+	test.T(t, m.Dot(Point{0.5, 0.5}), Point{2.5, 1.5})
+
+	test.That(t, !IsDegenerateQuad(q))
+	test.That(t, IsDegenerateQuad([4]Point{{0.0, 0.0}, {0.0, 0.0}, {3.0, 3.0}, {1.0, 2.0}}))
+	test.That(t, IsDegenerateQuad([4]Point{{0.0, 0.0}, {1.0, 0.0}, {2.0, 0.0}, {1.0, 2.0}}))
+}
+
 func TestSolveQuadraticFormula(t *testing.T) {
 	x1, x2 := solveQuadraticFormula(0.0, 0.0, 0.0)
 	test.Float(t, x1, 0.0)
@@ -334,6 +409,9 @@ func TestGaussLegendre(t *testing.T) {
 	test.Float(t, gaussLegendre3(math.Log, 0.0, 1.0), -0.9476723836)
 	test.Float(t, gaussLegendre5(math.Log, 0.0, 1.0), -0.9790015666)
 	test.Float(t, gaussLegendre7(math.Log, 0.0, 1.0), -0.9887384497)
+
+	runge := func(x float64) float64 { return 1.0 / (1.0 + x*x) }
+	test.Float(t, gaussLegendreAdaptive(runge, 0.0, 10.0, 1e-9), math.Atan(10.0))
 }
 
 func TestPolynomialChebyshevApprox(t *testing.T) {